@@ -65,6 +65,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/metadata/inventoryhost"
 	"github.com/DataDog/datadog-agent/comp/metadata/packagesigning"
 	"github.com/DataDog/datadog-agent/comp/metadata/runner"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
 	netflowServer "github.com/DataDog/datadog-agent/comp/netflow/server"
 	otelcollector "github.com/DataDog/datadog-agent/comp/otelcol/collector"
 	processAgent "github.com/DataDog/datadog-agent/comp/process/agent"
@@ -122,6 +123,7 @@ func StartAgentWithDefaults(ctxChan <-chan context.Context) (<-chan error, error
 			_ expvarserver.Component,
 			metadatascheduler metadatascheduler.Component,
 			jmxlogger jmxlogger.Component,
+			interfaceStore interfacestore.Component,
 		) error {
 
 			defer StopAgentWithDefaults(agentAPI)
@@ -149,6 +151,7 @@ func StartAgentWithDefaults(ctxChan <-chan context.Context) (<-chan error, error
 				collector,
 				metadatascheduler,
 				jmxlogger,
+				interfaceStore,
 			)
 			if err != nil {
 				return err
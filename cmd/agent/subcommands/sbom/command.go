@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package sbom implements 'agent sbom'.
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/sbom/export"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// exportParams are the command-line arguments of the export subcommand
+type exportParams struct {
+	*command.GlobalParams
+
+	// kind is the kind of the entity whose SBOM is exported
+	kind string
+
+	// id identifies the image or the container
+	id string
+
+	// format is the format of the exported SBOM
+	format string
+
+	// output is the file the SBOM is written to, the standard output is used when empty
+	output string
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	sbomCmd := &cobra.Command{
+		Use:   "sbom",
+		Short: "SBOM utility commands",
+		Long:  ``,
+	}
+
+	sbomCmd.AddCommand(exportCommand(globalParams))
+	if scanCmd := scanCommand(globalParams); scanCmd != nil {
+		sbomCmd.AddCommand(scanCmd)
+	}
+
+	return []*cobra.Command{sbomCmd}
+}
+
+// exportCommand returns the 'agent sbom export' command
+func exportCommand(globalParams *command.GlobalParams) *cobra.Command {
+	params := &exportParams{
+		GlobalParams: globalParams,
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export <host|image|container> [id]",
+		Short: "Export the latest SBOM of the host, an image or a container collected by a running agent",
+		Long: `Export the latest SBOM of the host, an image or a container collected by a running agent,
+in the CycloneDX 1.5 or SPDX 2.3 JSON format. The vulnerabilities matched against the OSV
+advisories bundle are added as VEX annotations when the vulnerability matching is enabled.
+An image is identified by its ID, one of its repo tags or one of its repo digests.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params.kind = args[0]
+			if len(args) > 1 {
+				params.id = args[1]
+			}
+			if params.kind != export.KindHost && params.id == "" {
+				return fmt.Errorf("the %s to export must be given", params.kind)
+			}
+
+			return fxutil.OneShot(exportSBOM,
+				fx.Supply(params),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	exportCmd.Flags().StringVarP(&params.format, "format", "f", export.FormatCycloneDX, fmt.Sprintf("format of the SBOM, %s or %s", export.FormatCycloneDX, export.FormatSPDX))
+	exportCmd.Flags().StringVarP(&params.output, "output", "o", "", "file the SBOM is written to, defaults to the standard output")
+
+	return exportCmd
+}
+
+func exportSBOM(_ log.Component, config config.Component, params *exportParams) error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	if err := util.SetAuthToken(config); err != nil {
+		return err
+	}
+
+	url, err := exportURL(params)
+	if err != nil {
+		return err
+	}
+
+	r, err := util.DoGet(c, url, util.LeaveConnectionOpen)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			return fmt.Errorf("the agent ran into an error while exporting the SBOM: %s", string(r))
+		}
+		return fmt.Errorf("failed to query the agent (running?): %w", err)
+	}
+
+	if params.output == "" {
+		_, err = os.Stdout.Write(r)
+		return err
+	}
+
+	if err := os.WriteFile(params.output, r, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "SBOM written to %s\n", params.output)
+	return nil
+}
+
+func exportURL(params *exportParams) (string, error) {
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("format", params.format)
+	if params.id != "" {
+		query.Set("id", params.id)
+	}
+
+	return fmt.Sprintf("https://%v:%v/agent/sbom/%s?%s", ipcAddress, pkgconfig.Datadog.GetInt("cmd_port"), url.PathEscape(params.kind), query.Encode()), nil
+}
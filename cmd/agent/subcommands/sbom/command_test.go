@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestExportCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"sbom", "export", "image", "datadog/agent:7", "--format", "spdx", "--output", "/tmp/agent.spdx.json"},
+		exportSBOM,
+		func(params *exportParams, coreParams core.BundleParams) {
+			require.Equal(t, "image", params.kind)
+			require.Equal(t, "datadog/agent:7", params.id)
+			require.Equal(t, "spdx", params.format)
+			require.Equal(t, "/tmp/agent.spdx.json", params.output)
+		})
+}
+
+func TestExportURL(t *testing.T) {
+	url, err := exportURL(&exportParams{kind: "image", id: "datadog/agent:7", format: "cyclonedx"})
+	require.NoError(t, err)
+	require.Equal(t, "https://localhost:5001/agent/sbom/image?format=cyclonedx&id=datadog%2Fagent%3A7", url)
+}
@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build trivy
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/trivy"
+)
+
+// scanParams are the command-line arguments of the scan subcommand
+type scanParams struct {
+	*command.GlobalParams
+
+	// target is the docker image or the path to scan
+	target string
+
+	// advisoriesPath is the path of the OSV advisories bundle, the configured one is used when empty
+	advisoriesPath string
+
+	// jsonOutput prints the vulnerabilities as JSON
+	jsonOutput bool
+
+	// timeout is the maximum duration of the scan
+	timeout time.Duration
+}
+
+// scanCommand returns the 'agent sbom scan' command
+func scanCommand(globalParams *command.GlobalParams) *cobra.Command {
+	params := &scanParams{
+		GlobalParams: globalParams,
+	}
+
+	scanCmd := &cobra.Command{
+		Use:   "scan <image|path>",
+		Short: "Scan a docker image or a path and print its vulnerabilities",
+		Long: `Generate the SBOM of a docker image or of a path of the filesystem and match its
+packages against the OSV advisories bundle, without any network access.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params.target = args[0]
+			return fxutil.OneShot(scan,
+				fx.Supply(params),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams(globalParams.ConfFilePath),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "off", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+	scanCmd.Flags().StringVar(&params.advisoriesPath, "advisories", "", "path of the OSV advisories bundle, defaults to sbom.vulnerabilities.advisories_path")
+	scanCmd.Flags().BoolVarP(&params.jsonOutput, "json", "j", false, "print the vulnerabilities as JSON")
+	scanCmd.Flags().DurationVar(&params.timeout, "timeout", 10*time.Minute, "maximum duration of the scan")
+
+	return scanCmd
+}
+
+func scan(_ log.Component, cfg config.Component, params *scanParams) error {
+	advisoriesPath := params.advisoriesPath
+	if advisoriesPath == "" {
+		advisoriesPath = cfg.GetString("sbom.vulnerabilities.advisories_path")
+	}
+	if advisoriesPath == "" {
+		return errors.New("no OSV advisories bundle, set sbom.vulnerabilities.advisories_path or use --advisories")
+	}
+
+	matcher, err := osv.NewMatcher(advisoriesPath)
+	if err != nil {
+		return err
+	}
+
+	// The scan uses its own cache to not contend with the cache of a running agent
+	cacheDir, err := os.MkdirTemp("", "sbom-scan-")
+	if err != nil {
+		return fmt.Errorf("unable to create the cache directory: %w", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	collector, err := trivy.NewCollectorWithCacheDir(pkgconfig.Datadog, cacheDir)
+	if err != nil {
+		return err
+	}
+	defer collector.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), params.timeout)
+	defer cancel()
+
+	scanOptions := sbom.ScanOptions{
+		Analyzers: []string{trivy.OSAnalyzers, trivy.LanguagesAnalyzers},
+		Timeout:   params.timeout,
+	}
+
+	var report sbom.Report
+	if _, err := os.Stat(params.target); err == nil {
+		scanOptions.NoCache = true
+		report, err = collector.ScanFilesystem(ctx, params.target, scanOptions)
+		if err != nil {
+			return fmt.Errorf("unable to scan %s: %w", params.target, err)
+		}
+	} else {
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return fmt.Errorf("unable to connect to docker: %w", err)
+		}
+		defer cli.Close()
+
+		imgMeta := &workloadmeta.ContainerImageMetadata{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainerImageMetadata,
+				ID:   params.target,
+			},
+			EntityMeta: workloadmeta.EntityMeta{
+				Name: params.target,
+			},
+		}
+		report, err = collector.ScanDockerImage(ctx, imgMeta, cli, scanOptions)
+		if err != nil {
+			return fmt.Errorf("unable to scan the image %s: %w", params.target, err)
+		}
+	}
+
+	bom, err := report.ToCycloneDX()
+	if err != nil {
+		return fmt.Errorf("unable to convert the report of %s to CycloneDX: %w", params.target, err)
+	}
+
+	findings := matcher.Match(bom)
+	if params.jsonOutput {
+		return printJSON(os.Stdout, findings)
+	}
+	return printTable(os.Stdout, findings)
+}
+
+func printJSON(w io.Writer, findings []osv.Finding) error {
+	if findings == nil {
+		findings = []osv.Finding{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+func printTable(w io.Writer, findings []osv.Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No vulnerabilities found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tID\tPACKAGE\tVERSION\tFIXED VERSION")
+	for _, finding := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", finding.Severity, finding.VulnerabilityID, finding.Package, finding.Version, finding.FixedVersion)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !trivy
+
+package sbom
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+)
+
+// scanCommand returns nil when compiling without the trivy build flag
+func scanCommand(*command.GlobalParams) *cobra.Command {
+	return nil
+}
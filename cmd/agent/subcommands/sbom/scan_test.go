@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build trivy
+
+package sbom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestScanCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"sbom", "scan", "alpine:3.18", "--advisories", "/tmp/osv", "--json"},
+		scan,
+		func(params *scanParams, coreParams core.BundleParams) {
+			require.Equal(t, "alpine:3.18", params.target)
+			require.Equal(t, "/tmp/osv", params.advisoriesPath)
+			require.True(t, params.jsonOutput)
+		})
+}
+
+func TestPrintTable(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, printTable(&b, nil))
+	assert.Equal(t, "No vulnerabilities found\n", b.String())
+
+	b.Reset()
+	require.NoError(t, printTable(&b, []osv.Finding{
+		{VulnerabilityID: "DSA-5587-1", Severity: osv.SeverityHigh, Package: "curl", Version: "7.88.1-10+deb12u4", FixedVersion: "7.88.1-10+deb12u5"},
+	}))
+	assert.Equal(t, "SEVERITY  ID          PACKAGE  VERSION            FIXED VERSION\n"+
+		"high      DSA-5587-1  curl     7.88.1-10+deb12u4  7.88.1-10+deb12u5\n", b.String())
+
+	b.Reset()
+	require.NoError(t, printJSON(&b, nil))
+	assert.Equal(t, "[]\n", b.String())
+}
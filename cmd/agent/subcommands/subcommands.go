@@ -26,6 +26,7 @@ import (
 	cmdlaunchgui "github.com/DataDog/datadog-agent/cmd/agent/subcommands/launchgui"
 	cmdremoteconfig "github.com/DataDog/datadog-agent/cmd/agent/subcommands/remoteconfig"
 	cmdrun "github.com/DataDog/datadog-agent/cmd/agent/subcommands/run"
+	cmdsbom "github.com/DataDog/datadog-agent/cmd/agent/subcommands/sbom"
 	cmdsecret "github.com/DataDog/datadog-agent/cmd/agent/subcommands/secret"
 	cmdsecrethelper "github.com/DataDog/datadog-agent/cmd/agent/subcommands/secrethelper"
 	cmdsnmp "github.com/DataDog/datadog-agent/cmd/agent/subcommands/snmp"
@@ -57,6 +58,7 @@ func AgentSubcommands() []command.SubcommandFactory {
 		cmdlaunchgui.Commands,
 		cmdremoteconfig.Commands,
 		cmdrun.Commands,
+		cmdsbom.Commands,
 		cmdsecret.Commands,
 		cmdsnmp.Commands,
 		cmdstatus.Commands,
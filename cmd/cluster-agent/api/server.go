@@ -23,6 +23,7 @@ import (
 	"time"
 
 	languagedetection "github.com/DataDog/datadog-agent/cmd/cluster-agent/api/v1/languagedetection"
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/v1/servicemap"
 
 	"github.com/cihub/seelog"
 	"github.com/gorilla/mux"
@@ -68,6 +69,9 @@ func StartServer(w workloadmeta.Component, taggerComp tagger.Component, ac autod
 	// API V1 Language Detection APIs
 	languagedetection.InstallLanguageDetectionEndpoints(apiRouter, w)
 
+	// API V1 Service Map APIs
+	servicemap.InstallServiceMapEndpoints(apiRouter)
+
 	// Validate token for every request
 	router.Use(validateToken)
 
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+// Package servicemap implements the service map API handlers of the cluster
+// agent: the node agents post the summaries of their connections, which are
+// paired into the service-to-service dependency map of the cluster.
+package servicemap
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/api"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/servicemap"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	connectionsHandlerName = "service-map-connections-handler"
+	serviceMapHandlerName  = "service-map-handler"
+)
+
+var (
+	// statusSuccess is the value for the "status" tag that represents a successful operation
+	statusSuccess = "success"
+	// statusError is the value for the "status" tag that represents an error
+	statusError = "error"
+)
+
+// InstallServiceMapEndpoints installs the service map endpoints
+func InstallServiceMapEndpoints(r *mux.Router) {
+	connectionsHandler := api.WithLeaderProxyHandler(connectionsHandlerName, preHandler, connectionsLeaderHandler)
+	r.HandleFunc("/servicemap/connections", api.WithTelemetryWrapper(connectionsHandlerName, connectionsHandler)).Methods("POST")
+
+	serviceMapHandler := api.WithLeaderProxyHandler(serviceMapHandlerName, preHandler, serviceMapLeaderHandler)
+	r.HandleFunc("/servicemap", api.WithTelemetryWrapper(serviceMapHandlerName, serviceMapHandler)).Methods("GET")
+}
+
+var (
+	storeMu  sync.Mutex
+	store    *servicemap.Store
+	resolver servicemap.MetadataResolver
+)
+
+// loadStore creates the store on the first request handled by the leader
+func loadStore() *servicemap.Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if store == nil {
+		store = servicemap.NewStore(config.Datadog.GetDuration("cluster_agent.service_map.connection_ttl"))
+	}
+	return store
+}
+
+// loadResolver creates the resolver once the API server client is available,
+// it's retried on the next requests until then
+func loadResolver() servicemap.MetadataResolver {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if resolver == nil {
+		apiCl, err := apiserver.GetAPIClient()
+		if err != nil {
+			log.Warnf("Unable to resolve the pods and services of the service map: %v", err)
+			return nil
+		}
+		resolver = servicemap.NewKubeResolver(apiCl.InformerFactory)
+		apiCl.InformerFactory.Start(wait.NeverStop)
+	}
+	return resolver
+}
+
+// preHandler is called by both leader and followers and returns true if the request should be forwarded or handled by the leader
+func preHandler(w http.ResponseWriter, _ *http.Request) bool {
+	if !config.Datadog.GetBool("cluster_agent.service_map.enabled") {
+		ProcessedRequests.Inc(statusError)
+		http.Error(w, "Service map feature is disabled on the cluster agent", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// connectionsLeaderHandler is called only by the leader and stores the connections of a node
+func connectionsLeaderHandler(w http.ResponseWriter, r *http.Request) {
+	summaries := &servicemap.ConnectionSummaries{}
+	if err := json.NewDecoder(r.Body).Decode(summaries); err != nil {
+		http.Error(w, "Failed to unmarshal request body", http.StatusBadRequest)
+		ProcessedRequests.Inc(statusError)
+		return
+	}
+	if summaries.Hostname == "" {
+		http.Error(w, "Missing hostname", http.StatusBadRequest)
+		ProcessedRequests.Inc(statusError)
+		return
+	}
+
+	loadStore().Add(summaries)
+
+	ProcessedRequests.Inc(statusSuccess)
+	w.WriteHeader(http.StatusOK)
+}
+
+// serviceMapLeaderHandler is called only by the leader and returns the dependency map
+func serviceMapLeaderHandler(w http.ResponseWriter, _ *http.Request) {
+	body, err := json.Marshal(loadStore().Build(loadResolver()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ProcessedRequests.Inc(statusError)
+		return
+	}
+
+	ProcessedRequests.Inc(statusSuccess)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body) //nolint:errcheck
+}
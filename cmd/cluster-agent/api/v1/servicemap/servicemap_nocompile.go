@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build !kubeapiserver
+
+// Package servicemap implements the service map API handlers of the cluster
+// agent.
+package servicemap
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// InstallServiceMapEndpoints installs the service map endpoints
+func InstallServiceMapEndpoints(_ *mux.Router) {}
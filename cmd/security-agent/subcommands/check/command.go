@@ -137,7 +137,10 @@ func RunCheck(log log.Component, config config.Component, _ secrets.Component, s
 
 	var resolver compliance.Resolver
 	if checkArgs.overrideRegoInput != "" {
-		resolver = newFakeResolver(checkArgs.overrideRegoInput)
+		resolver, err = compliance.NewFixturesResolver(checkArgs.overrideRegoInput)
+		if err != nil {
+			return err
+		}
 	} else if flavor.GetFlavor() == flavor.ClusterAgent {
 		resolver = compliance.NewResolver(context.Background(), compliance.ResolverOptions{
 			Hostname:           hname,
@@ -268,43 +271,3 @@ func complianceKubernetesProvider(_ctx context.Context) (dynamic.Interface, disc
 	}
 	return apiCl.DynamicCl, apiCl.Cl.Discovery(), nil
 }
-
-type fakeResolver struct {
-	regoInputPath string
-}
-
-func newFakeResolver(regoInputPath string) compliance.Resolver {
-	return &fakeResolver{regoInputPath}
-}
-
-func (r *fakeResolver) ResolveInputs(_ context.Context, rule *compliance.Rule) (compliance.ResolvedInputs, error) {
-	var fixtures map[string]map[string]interface{}
-	data, err := os.ReadFile(r.regoInputPath)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(data, &fixtures); err != nil {
-		return nil, fmt.Errorf("could not unmarshal faked rego input: %w", err)
-	}
-	fixture, ok := fixtures[rule.ID]
-	if !ok {
-		return nil, fmt.Errorf("could not find fixtures for rule %q", rule.ID)
-	}
-	var resolvingContext compliance.ResolvingContext
-	if err := jsonRountrip(fixture["context"], &resolvingContext); err != nil {
-		return nil, err
-	}
-	delete(fixture, "context")
-	return compliance.NewResolvedInputs(resolvingContext, fixture)
-}
-
-func jsonRountrip(i interface{}, v interface{}) error {
-	b, err := json.Marshal(i)
-	if err != nil {
-		return err
-	}
-	return json.Unmarshal(b, &v)
-}
-
-func (r *fakeResolver) Close() {
-}
@@ -45,6 +45,8 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	complianceCmd.AddCommand(check.SecurityAgentCommands(globalParams)...)
 	complianceCmd.AddCommand(complianceEventCommand(globalParams))
 	complianceCmd.AddCommand(complianceLoadCommand(globalParams))
+	complianceCmd.AddCommand(complianceResolveInputsCommand(globalParams))
+	complianceCmd.AddCommand(complianceEvalCommand(globalParams))
 
 	return []*cobra.Command{complianceCmd}
 }
@@ -107,7 +107,7 @@ func TestCommand_kubeapiserver(t *testing.T) {
 		for _, subcommand := range rootCommand.Commands() {
 			subcommandNames = append(subcommandNames, subcommand.Use)
 		}
-		require.Equal(t, []string{"check", "event", "load <conf-type>"}, subcommandNames, "subcommand missing")
+		require.Equal(t, []string{"check", "eval [rule-id...]", "event", "load <conf-type>", "resolve-inputs [rule-id...]"}, subcommandNames, "subcommand missing")
 
 		fxutil.TestOneShotSubcommand(t,
 			Commands(&command.GlobalParams{}),
@@ -45,7 +45,7 @@ func TestEventCommands(t *testing.T) {
 			subcommandNames = append(subcommandNames, subcommand.Use)
 		}
 
-		require.Equal(t, []string{"event", "load <conf-type>"}, subcommandNames, "subcommand missing")
+		require.Equal(t, []string{"eval [rule-id...]", "event", "load <conf-type>", "resolve-inputs [rule-id...]"}, subcommandNames, "subcommand missing")
 
 		fxutil.TestOneShotSubcommand(t,
 			Commands(&command.GlobalParams{}),
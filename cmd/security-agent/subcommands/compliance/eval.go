@@ -0,0 +1,264 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+)
+
+type benchmarksCliParams struct {
+	*command.GlobalParams
+
+	ruleIDs   []string
+	framework string
+	file      string
+}
+
+type resolveInputsCliParams struct {
+	benchmarksCliParams
+
+	output string
+}
+
+type evalCliParams struct {
+	benchmarksCliParams
+
+	inputs string
+}
+
+func (params *benchmarksCliParams) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&params.framework, "framework", "", "", "Framework to load the rules from")
+	cmd.Flags().StringVarP(&params.file, "file", "f", "", "Compliance suite file to read rules from")
+}
+
+func complianceResolveInputsCommand(globalParams *command.GlobalParams) *cobra.Command {
+	resolveArgs := &resolveInputsCliParams{
+		benchmarksCliParams: benchmarksCliParams{GlobalParams: globalParams},
+	}
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve-inputs [rule-id...]",
+		Short: "Resolve the inputs of compliance rego rules and dump them to a JSON fixtures file",
+		Long: `Resolve the inputs of the rego rules on the current host and dump them to a JSON fixtures file, indexed by rule ID.
+The fixtures file can then be given to the "eval" command to evaluate the rules offline.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resolveArgs.ruleIDs = args
+			return fxutil.OneShot(resolveInputsRun,
+				fx.Supply(resolveArgs),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					SecretParams: secrets.NewEnabledParams(),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "info", true),
+				}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	resolveArgs.addFlags(resolveCmd)
+	resolveCmd.Flags().StringVarP(&resolveArgs.output, "output", "o", "", "Path of the fixtures file, printed on stdout when empty")
+	return resolveCmd
+}
+
+func complianceEvalCommand(globalParams *command.GlobalParams) *cobra.Command {
+	evalArgs := &evalCliParams{
+		benchmarksCliParams: benchmarksCliParams{GlobalParams: globalParams},
+	}
+
+	evalCmd := &cobra.Command{
+		Use:   "eval [rule-id...]",
+		Short: "Evaluate compliance rego rules against the inputs of a JSON fixtures file",
+		Long: `Evaluate the rego rules against the inputs of a JSON fixtures file, as written by the "resolve-inputs" command.
+The findings are printed in the format of the events reported by the compliance agent, one per line.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			evalArgs.ruleIDs = args
+			return fxutil.OneShot(evalRun,
+				fx.Supply(evalArgs),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					SecretParams: secrets.NewEnabledParams(),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "info", true),
+				}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	evalArgs.addFlags(evalCmd)
+	evalCmd.Flags().StringVarP(&evalArgs.inputs, "inputs", "", "", "Path to the JSON fixtures file holding the resolved inputs of the rules")
+	_ = evalCmd.MarkFlagRequired("inputs")
+	return evalCmd
+}
+
+// loadRegoBenchmarks loads the benchmarks selected by the command line
+// parameters, keeping only their rego rules.
+func loadRegoBenchmarks(log log.Component, config config.Component, params *benchmarksCliParams) ([]*compliance.Benchmark, error) {
+	configDir := config.GetString("compliance_config.dir")
+	var benchDir, benchGlob string
+	var ruleFilter compliance.RuleFilter
+	if params.file != "" {
+		benchDir, benchGlob = filepath.Dir(params.file), filepath.Base(params.file)
+	} else if params.framework != "" {
+		benchDir, benchGlob = configDir, fmt.Sprintf("%s.yaml", params.framework)
+	} else {
+		ruleFilter = compliance.DefaultRuleFilter
+		benchDir, benchGlob = configDir, "*.yaml"
+	}
+
+	ruleIDs := make(map[string]bool, len(params.ruleIDs))
+	for _, ruleID := range params.ruleIDs {
+		ruleIDs[ruleID] = true
+	}
+
+	log.Infof("Loading compliance rules from %s", benchDir)
+	benchmarks, err := compliance.LoadBenchmarks(benchDir, benchGlob, func(r *compliance.Rule) bool {
+		if !r.IsRego() {
+			return false
+		}
+		if ruleFilter != nil && !ruleFilter(r) {
+			return false
+		}
+		return len(ruleIDs) == 0 || ruleIDs[r.ID]
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load benchmark files %q: %w", filepath.Join(benchDir, benchGlob), err)
+	}
+
+	var rulesCount int
+	for _, benchmark := range benchmarks {
+		rulesCount += len(benchmark.Rules)
+	}
+	if rulesCount == 0 {
+		return nil, fmt.Errorf("could not find any rego rule in %q", filepath.Join(benchDir, benchGlob))
+	}
+	return benchmarks, nil
+}
+
+func resolveInputsRun(log log.Component, config config.Component, resolveArgs *resolveInputsCliParams) error {
+	benchmarks, err := loadRegoBenchmarks(log, config, &resolveArgs.benchmarksCliParams)
+	if err != nil {
+		return err
+	}
+
+	hname, err := hostname.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	resolver := compliance.NewResolver(context.Background(), compliance.ResolverOptions{
+		Hostname:           hname,
+		HostRoot:           os.Getenv("HOST_ROOT"),
+		DockerProvider:     compliance.DefaultDockerProvider,
+		LinuxAuditProvider: compliance.DefaultLinuxAuditProvider,
+	})
+	defer resolver.Close()
+
+	fixtures := make(compliance.RegoInputsFixtures)
+	for _, benchmark := range benchmarks {
+		for _, rule := range benchmark.Rules {
+			log.Infof("Resolving inputs of rule: %s: %s [version=%s]", rule.ID, rule.Description, benchmark.Version)
+			inputs, err := resolver.ResolveInputs(context.Background(), rule)
+			if errors.Is(err, compliance.ErrIncompatibleEnvironment) {
+				log.Infof("Skipping rule %s: %v", rule.ID, err)
+				continue
+			}
+			if err != nil {
+				log.Errorf("Could not resolve the inputs of rule %s: %v", rule.ID, err)
+				continue
+			}
+			fixtures[rule.ID] = inputs
+		}
+	}
+	if len(fixtures) == 0 {
+		return fmt.Errorf("could not resolve the inputs of any rule")
+	}
+
+	var w io.Writer = os.Stdout
+	if resolveArgs.output != "" {
+		f, err := os.Create(resolveArgs.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := fixtures.WriteTo(w); err != nil {
+		return err
+	}
+	if resolveArgs.output != "" {
+		fmt.Fprintf(os.Stderr, "Inputs of %d rules written to %s\n", len(fixtures), resolveArgs.output)
+	}
+	return nil
+}
+
+func evalRun(log log.Component, config config.Component, evalArgs *evalCliParams) error {
+	benchmarks, err := loadRegoBenchmarks(log, config, &evalArgs.benchmarksCliParams)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := compliance.NewFixturesResolver(evalArgs.inputs)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	return evalRegoRules(context.Background(), os.Stdout, resolver, benchmarks)
+}
+
+// evalRegoRules evaluates the rules of the benchmarks and writes the
+// resulting events in the JSON format used by the compliance reporter, one
+// per line, followed by a summary of the results on stderr.
+func evalRegoRules(ctx context.Context, w io.Writer, resolver compliance.Resolver, benchmarks []*compliance.Benchmark) error {
+	results := make(map[compliance.CheckResult]int)
+	for _, benchmark := range benchmarks {
+		for _, rule := range benchmark.Rules {
+			var events []*compliance.CheckEvent
+			inputs, err := resolver.ResolveInputs(ctx, rule)
+			if err != nil {
+				events = append(events, compliance.CheckEventFromError(compliance.RegoEvaluator, rule, benchmark, err))
+			} else {
+				events = compliance.EvaluateRegoRule(ctx, inputs, benchmark, rule)
+			}
+			for _, event := range events {
+				b, err := json.Marshal(event)
+				if err != nil {
+					return fmt.Errorf("failed to serialize compliance event: %w", err)
+				}
+				if _, err := fmt.Fprintln(w, string(b)); err != nil {
+					return err
+				}
+				results[event.Result]++
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d passed, %d failed, %d errors, %d skipped\n",
+		results[compliance.CheckPassed],
+		results[compliance.CheckFailed],
+		results[compliance.CheckError],
+		results[compliance.CheckSkipped])
+	return nil
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const evalTestSuite = `schema:
+  version: 1.0.0
+name: eval-test
+framework: eval-test
+version: 1.0.0
+rules:
+  - id: motd_permissions
+    scope:
+      - none
+    input:
+      - file:
+          path: /etc/motd
+        tag: motd
+  - id: missing_fixture
+    scope:
+      - none
+    input:
+      - file:
+          path: /etc/issue
+`
+
+const evalTestRego = `package datadog
+
+import data.datadog as dd
+
+findings[f] {
+	input.motd.permissions == 420
+	f := dd.passed_finding("file", input.motd.path, {"permissions": input.motd.permissions})
+}
+
+findings[f] {
+	input.motd.permissions != 420
+	f := dd.failing_finding("file", input.motd.path, {"permissions": input.motd.permissions})
+}
+`
+
+func TestResolveInputsSubcommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"compliance", "resolve-inputs", "motd_permissions", "--framework", "cis-docker", "--output", "fixtures.json"},
+		resolveInputsRun,
+		func(cliParams *resolveInputsCliParams, params core.BundleParams) {
+			require.Equal(t, command.LoggerName, params.LoggerName(), "logger name not matching")
+			require.Equal(t, []string{"motd_permissions"}, cliParams.ruleIDs)
+			require.Equal(t, "cis-docker", cliParams.framework)
+			require.Equal(t, "fixtures.json", cliParams.output)
+		},
+	)
+}
+
+func TestEvalSubcommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"compliance", "eval", "--file", "suite.yaml", "--inputs", "fixtures.json"},
+		evalRun,
+		func(cliParams *evalCliParams, params core.BundleParams) {
+			require.Equal(t, command.LoggerName, params.LoggerName(), "logger name not matching")
+			require.Empty(t, cliParams.ruleIDs)
+			require.Equal(t, "suite.yaml", cliParams.file)
+			require.Equal(t, "fixtures.json", cliParams.inputs)
+		},
+	)
+}
+
+func TestEvalRegoRules(t *testing.T) {
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "eval-test.yaml"), []byte(evalTestSuite), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "motd_permissions.rego"), []byte(evalTestRego), 0644))
+
+	benchmarks, err := compliance.LoadBenchmarks(rootDir, "eval-test.yaml", nil)
+	require.NoError(t, err)
+	require.Len(t, benchmarks, 1)
+
+	// fixtures are written as resolve-inputs would
+	inputs, err := compliance.NewResolvedInputs(compliance.ResolvingContext{
+		RuleID:   "motd_permissions",
+		Hostname: "test-host",
+	}, map[string]interface{}{
+		"motd": map[string]interface{}{
+			"path":        "/etc/motd",
+			"permissions": 0644,
+		},
+	})
+	require.NoError(t, err)
+
+	var fixtures bytes.Buffer
+	_, err = compliance.RegoInputsFixtures{"motd_permissions": inputs}.WriteTo(&fixtures)
+	require.NoError(t, err)
+	fixturesPath := filepath.Join(rootDir, "fixtures.json")
+	require.NoError(t, os.WriteFile(fixturesPath, fixtures.Bytes(), 0644))
+
+	resolver, err := compliance.NewFixturesResolver(fixturesPath)
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	resolved, err := resolver.ResolveInputs(context.Background(), benchmarks[0].Rules[0])
+	require.NoError(t, err)
+	assert.Equal(t, "test-host", resolved.GetContext().Hostname)
+
+	var out bytes.Buffer
+	require.NoError(t, evalRegoRules(context.Background(), &out, resolver, benchmarks))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var events []compliance.CheckEvent
+	for _, line := range lines {
+		var event compliance.CheckEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+
+	assert.Equal(t, "motd_permissions", events[0].RuleID)
+	assert.Equal(t, compliance.CheckPassed, events[0].Result)
+	assert.Equal(t, "/etc/motd", events[0].ResourceID)
+	assert.Equal(t, "file", events[0].ResourceType)
+	assert.Equal(t, "eval-test", events[0].FrameworkID)
+
+	assert.Equal(t, "missing_fixture", events[1].RuleID)
+	assert.Equal(t, compliance.CheckError, events[1].Result)
+	assert.Contains(t, events[1].Data["error"], `could not find fixtures for rule "missing_fixture"`)
+}
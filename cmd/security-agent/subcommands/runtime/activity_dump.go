@@ -130,6 +130,7 @@ func generateCommands(globalParams *command.GlobalParams) []*cobra.Command {
 
 	activityDumpGenerateCmd.AddCommand(generateDumpCommands(globalParams)...)
 	activityDumpGenerateCmd.AddCommand(generateEncodingCommands(globalParams)...)
+	activityDumpGenerateCmd.AddCommand(generateHardeningCommands(globalParams)...)
 
 	return []*cobra.Command{activityDumpGenerateCmd}
 }
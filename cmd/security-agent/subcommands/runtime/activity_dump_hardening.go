@@ -0,0 +1,242 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package runtime holds runtime related files
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/security/security_profile/dump"
+	"github.com/DataDog/datadog-agent/pkg/security/security_profile/hardening"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+type hardeningCliParams struct {
+	*command.GlobalParams
+
+	input         string
+	output        string
+	diff          string
+	name          string
+	namespace     string
+	podSelector   map[string]string
+	resolveDNS    bool
+	extraSyscalls []string
+}
+
+func generateHardeningCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	return []*cobra.Command{
+		hardeningCommand(globalParams, "seccomp", "generate a seccomp profile from the syscalls of an activity dump or a security profile", generateSeccompProfile, func(cmd *cobra.Command, cliParams *hardeningCliParams) {
+			cmd.Flags().StringSliceVar(
+				&cliParams.extraSyscalls,
+				"extra-syscall",
+				[]string{},
+				"syscalls allowed in addition to the recorded ones",
+			)
+		}),
+		hardeningCommand(globalParams, "apparmor", "generate an AppArmor profile from the files and network accesses of an activity dump or a security profile", generateAppArmorProfile, func(cmd *cobra.Command, cliParams *hardeningCliParams) {
+			cmd.Flags().StringVar(
+				&cliParams.name,
+				"name",
+				"",
+				"name of the AppArmor profile",
+			)
+			_ = cmd.MarkFlagRequired("name")
+		}),
+		hardeningCommand(globalParams, "network-policy", "generate a Kubernetes network policy from the network activity of an activity dump or a security profile", generateNetworkPolicy, func(cmd *cobra.Command, cliParams *hardeningCliParams) {
+			cmd.Flags().StringVar(
+				&cliParams.name,
+				"name",
+				"",
+				"name of the network policy",
+			)
+			_ = cmd.MarkFlagRequired("name")
+			cmd.Flags().StringVar(
+				&cliParams.namespace,
+				"namespace",
+				"",
+				"namespace of the network policy",
+			)
+			cmd.Flags().StringToStringVar(
+				&cliParams.podSelector,
+				"pod-selector",
+				map[string]string{},
+				"labels selecting the pods of the workload, e.g. app=nginx",
+			)
+			cmd.Flags().BoolVar(
+				&cliParams.resolveDNS,
+				"resolve-dns",
+				false,
+				"resolve the queried domain names to allow the egress traffic to their current addresses",
+			)
+		}),
+	}
+}
+
+func hardeningCommand(globalParams *command.GlobalParams, use string, short string, generate interface{}, addFlags func(cmd *cobra.Command, cliParams *hardeningCliParams)) *cobra.Command {
+	cliParams := &hardeningCliParams{
+		GlobalParams: globalParams,
+	}
+
+	hardeningCmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(generate,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					SecretParams: secrets.NewEnabledParams(),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	hardeningCmd.Flags().StringVar(
+		&cliParams.input,
+		"input",
+		"",
+		"path to the activity dump or security profile file",
+	)
+	_ = hardeningCmd.MarkFlagRequired("input")
+	hardeningCmd.Flags().StringVar(
+		&cliParams.output,
+		"output",
+		"",
+		"path of the generated profile, printed on stdout when empty",
+	)
+	hardeningCmd.Flags().StringVar(
+		&cliParams.diff,
+		"diff",
+		"",
+		"path to a prior profile, the differences with the generated profile are printed instead of the profile",
+	)
+	addFlags(hardeningCmd, cliParams)
+
+	return hardeningCmd
+}
+
+func decodeHardeningInput(cliParams *hardeningCliParams) (*dump.ActivityDump, error) {
+	ad := dump.NewEmptyActivityDump(nil)
+	if err := ad.Decode(cliParams.input); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", cliParams.input, err)
+	}
+	return ad, nil
+}
+
+// writeHardeningOutput writes the generated profile, or its differences with the prior profile
+func writeHardeningOutput(cliParams *hardeningCliParams, profile []byte, diff func(prior []byte) (*hardening.Diff, error)) error {
+	if cliParams.diff != "" {
+		prior, err := os.ReadFile(cliParams.diff)
+		if err != nil {
+			return err
+		}
+		d, err := diff(prior)
+		if err != nil {
+			return err
+		}
+		if d.IsEmpty() {
+			fmt.Println("the generated profile is equivalent to the prior profile")
+			return nil
+		}
+		fmt.Print(d.String())
+		return nil
+	}
+
+	if cliParams.output == "" {
+		_, err := os.Stdout.Write(profile)
+		return err
+	}
+	if err := os.WriteFile(cliParams.output, profile, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("profile written to %s\n", cliParams.output)
+	return nil
+}
+
+func generateSeccompProfile(_ log.Component, _ config.Component, _ secrets.Component, cliParams *hardeningCliParams) error {
+	ad, err := decodeHardeningInput(cliParams)
+	if err != nil {
+		return err
+	}
+
+	profile, err := hardening.GenerateSeccompProfile(ad.ActivityTree, hardening.SeccompOptions{
+		Arch:          ad.Metadata.Arch,
+		ExtraSyscalls: cliParams.extraSyscalls,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := hardening.EncodeSeccompProfile(profile)
+	if err != nil {
+		return err
+	}
+	return writeHardeningOutput(cliParams, append(data, '\n'), func(prior []byte) (*hardening.Diff, error) {
+		return hardening.DiffSeccompProfile(prior, profile)
+	})
+}
+
+func generateAppArmorProfile(_ log.Component, _ config.Component, _ secrets.Component, cliParams *hardeningCliParams) error {
+	ad, err := decodeHardeningInput(cliParams)
+	if err != nil {
+		return err
+	}
+
+	profile, err := hardening.GenerateAppArmorProfile(ad.ActivityTree, hardening.AppArmorOptions{
+		Name: cliParams.name,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeHardeningOutput(cliParams, profile.Encode(), func(prior []byte) (*hardening.Diff, error) {
+		return hardening.DiffAppArmorProfile(prior, profile)
+	})
+}
+
+func generateNetworkPolicy(_ log.Component, _ config.Component, _ secrets.Component, cliParams *hardeningCliParams) error {
+	ad, err := decodeHardeningInput(cliParams)
+	if err != nil {
+		return err
+	}
+
+	opts := hardening.NetworkPolicyOptions{
+		Name:        cliParams.name,
+		Namespace:   cliParams.namespace,
+		PodSelector: cliParams.podSelector,
+	}
+	if cliParams.resolveDNS {
+		opts.Resolver = net.LookupIP
+	}
+
+	policy, err := hardening.GenerateNetworkPolicy(ad.ActivityTree, opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := hardening.EncodeNetworkPolicy(policy)
+	if err != nil {
+		return err
+	}
+	return writeHardeningOutput(cliParams, data, func(prior []byte) (*hardening.Diff, error) {
+		return hardening.DiffNetworkPolicy(prior, policy)
+	})
+}
@@ -46,6 +46,30 @@ func TestGenerateEncodingFromActivityDumpCommand(t *testing.T) {
 		func() {})
 }
 
+func TestGenerateSeccompProfileCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "activity-dump", "generate", "seccomp", "--input", "file"},
+		generateSeccompProfile,
+		func() {})
+}
+
+func TestGenerateAppArmorProfileCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "activity-dump", "generate", "apparmor", "--input", "file", "--name", "nginx"},
+		generateAppArmorProfile,
+		func() {})
+}
+
+func TestGenerateNetworkPolicyCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "activity-dump", "generate", "network-policy", "--input", "file", "--name", "nginx", "--pod-selector", "app=nginx"},
+		generateNetworkPolicy,
+		func() {})
+}
+
 func TestDiffActivityDumpCommand(t *testing.T) {
 	fxutil.TestOneShotSubcommand(t,
 		Commands(&command.GlobalParams{}),
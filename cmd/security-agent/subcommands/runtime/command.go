@@ -18,6 +18,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 
@@ -38,6 +39,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/probe/kfilters"
 	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
 	"github.com/DataDog/datadog-agent/pkg/security/reporter"
+	secrules "github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/rules/policytest"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
@@ -63,6 +66,7 @@ func commonPolicyCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	}
 
 	commonPolicyCmd.AddCommand(evalCommands(globalParams)...)
+	commonPolicyCmd.AddCommand(testPoliciesCommands(globalParams)...)
 	commonPolicyCmd.AddCommand(commonCheckPoliciesCommands(globalParams)...)
 	commonPolicyCmd.AddCommand(commonReloadPoliciesCommands(globalParams)...)
 	commonPolicyCmd.AddCommand(downloadPolicyCommands(globalParams)...)
@@ -109,6 +113,45 @@ func evalCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	return []*cobra.Command{evalCmd}
 }
 
+type testPoliciesCliParams struct {
+	*command.GlobalParams
+
+	dir          string
+	testsDir     string
+	junitOutput  string
+	agentVersion string
+	origin       string
+}
+
+func testPoliciesCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	testArgs := &testPoliciesCliParams{
+		GlobalParams: globalParams,
+	}
+
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run the test suites of the policies",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(testPolicies,
+				fx.Supply(testArgs),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					SecretParams: secrets.NewEnabledParams(),
+					LogParams:    logimpl.ForOneShot(command.LoggerName, "off", false)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	testCmd.Flags().StringVar(&testArgs.dir, "policies-dir", pkgconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+	testCmd.Flags().StringVar(&testArgs.testsDir, "tests-dir", "", "Path to the directory of the test suites, defaults to the policies directory")
+	testCmd.Flags().StringVar(&testArgs.junitOutput, "junit-output", "", "Path of the JUnit XML report")
+	testCmd.Flags().StringVar(&testArgs.agentVersion, "agent-version", "", "Agent version used to filter the rules, defaults to the version of this agent")
+	testCmd.Flags().StringVar(&testArgs.origin, "origin", defaultRuleFilterOrigin, "Event origin used to filter the rules")
+
+	return []*cobra.Command{testCmd}
+}
+
 func commonCheckPoliciesCommands(globalParams *command.GlobalParams) []*cobra.Command {
 	cliParams := &checkPoliciesCliParams{
 		GlobalParams: globalParams,
@@ -589,6 +632,83 @@ func evalRule(_ log.Component, _ config.Component, _ secrets.Component, evalArgs
 	return nil
 }
 
+func testPolicies(_ log.Component, _ config.Component, _ secrets.Component, testArgs *testPoliciesCliParams) error {
+	var (
+		agentVersion *semver.Version
+		err          error
+	)
+	if testArgs.agentVersion != "" {
+		agentVersion, err = semver.NewVersion(testArgs.agentVersion)
+	} else {
+		agentVersion, err = utils.GetAgentSemverVersion()
+	}
+	if err != nil {
+		return fmt.Errorf("invalid agent version: %w", err)
+	}
+
+	ruleFilterModel, err := secrules.NewRuleFilterModel(testArgs.origin)
+	if err != nil {
+		return fmt.Errorf("failed to create rule filter: %w", err)
+	}
+
+	testsDir := testArgs.testsDir
+	if testsDir == "" {
+		testsDir = testArgs.dir
+	}
+
+	suites, err := policytest.LoadSuites(testsDir)
+	if err != nil {
+		return err
+	}
+	if len(suites) == 0 {
+		return fmt.Errorf("no test suite (*%s) found in %s", policytest.SuiteExtension, testsDir)
+	}
+
+	result, err := policytest.Run(policytest.Opts{
+		PoliciesDir:     testArgs.dir,
+		AgentVersion:    agentVersion,
+		RuleFilterModel: ruleFilterModel,
+	}, suites)
+	if err != nil {
+		return err
+	}
+
+	var tests, failures int
+	for _, suite := range result.Suites {
+		for _, c := range suite.Cases {
+			tests++
+			if !c.Failed() {
+				fmt.Printf("PASS %s/%s\n", suite.Name, c.Name)
+				continue
+			}
+			failures++
+			fmt.Printf("FAIL %s/%s\n", suite.Name, c.Name)
+			for _, failure := range c.Failures {
+				fmt.Printf("    %s\n", failure)
+			}
+		}
+	}
+	fmt.Printf("%d tests, %d failures\n", tests, failures)
+
+	if testArgs.junitOutput != "" {
+		f, err := os.Create(testArgs.junitOutput)
+		if err != nil {
+			return fmt.Errorf("unable to create the JUnit report: %w", err)
+		}
+		defer f.Close()
+
+		if err := policytest.WriteJUnit(f, result); err != nil {
+			return fmt.Errorf("unable to write the JUnit report: %w", err)
+		}
+	}
+
+	if result.Failed() {
+		return fmt.Errorf("%d policy tests failed", failures)
+	}
+
+	return nil
+}
+
 // nolint: deadcode, unused
 func runRuntimeSelfTest(_ log.Component, _ config.Component, _ secrets.Component) error {
 	client, err := secagent.NewRuntimeSecurityClient()
@@ -17,6 +17,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
 )
 
+// defaultRuleFilterOrigin is the origin of the events of the eBPF probe
+const defaultRuleFilterOrigin = "ebpf"
+
 // Commands returns the config commands
 func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	runtimeCmd := &cobra.Command{
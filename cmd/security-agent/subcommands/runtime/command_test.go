@@ -201,6 +201,14 @@ func TestEvalCommand(t *testing.T) {
 		func() {})
 }
 
+func TestTestPoliciesCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "policy", "test", "--junit-output=report.xml"},
+		testPolicies,
+		func() {})
+}
+
 func TestCheckPoliciesCommand(t *testing.T) {
 	fxutil.TestOneShotSubcommand(t,
 		Commands(&command.GlobalParams{}),
@@ -13,6 +13,9 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
 )
 
+// defaultRuleFilterOrigin is the origin of the events of the Windows probe
+const defaultRuleFilterOrigin = ""
+
 // Commands exports commands
 func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	runtimeCmd := &cobra.Command{
@@ -113,12 +113,32 @@ func parseParams(req *http.Request) (tracerouteutil.Config, error) {
 	if err != nil {
 		return tracerouteutil.Config{}, fmt.Errorf("invalid timeout: %s", err)
 	}
+	protocol, err := tracerouteutil.ParseProtocol(req.URL.Query().Get("protocol"))
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid protocol: %s", err)
+	}
+	numPaths, err := parseUint(req, "num_paths", 16)
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid num_paths: %s", err)
+	}
+	probesPerHop, err := parseUint(req, "probes_per_hop", 8)
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid probes_per_hop: %s", err)
+	}
+	flowID, err := parseUint(req, "flow_id", 16)
+	if err != nil {
+		return tracerouteutil.Config{}, fmt.Errorf("invalid flow_id: %s", err)
+	}
 
 	return tracerouteutil.Config{
 		DestHostname: host,
 		DestPort:     uint16(port),
 		MaxTTL:       uint8(maxTTL),
 		TimeoutMs:    uint(timeout),
+		Protocol:     protocol,
+		NumPaths:     uint16(numPaths),
+		ProbesPerHop: uint8(probesPerHop),
+		FlowID:       uint16(flowID),
 	}, nil
 }
 
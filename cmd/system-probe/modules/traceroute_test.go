@@ -32,23 +32,41 @@ func TestParseParams(t *testing.T) {
 			params: map[string]string{},
 			expectedConfig: tracerouteutil.Config{
 				DestHostname: "1.2.3.4",
+				Protocol:     tracerouteutil.ProtocolUDP,
 			},
 		},
 		{
 			name: "all config",
 			host: "1.2.3.4",
 			params: map[string]string{
-				"port":    "42",
-				"max_ttl": "35",
-				"timeout": "1000",
+				"port":           "42",
+				"max_ttl":        "35",
+				"timeout":        "1000",
+				"protocol":       "tcp",
+				"num_paths":      "4",
+				"probes_per_hop": "5",
+				"flow_id":        "40000",
 			},
 			expectedConfig: tracerouteutil.Config{
 				DestHostname: "1.2.3.4",
 				DestPort:     42,
 				MaxTTL:       35,
 				TimeoutMs:    1000,
+				Protocol:     tracerouteutil.ProtocolTCP,
+				NumPaths:     4,
+				ProbesPerHop: 5,
+				FlowID:       40000,
 			},
 		},
+		{
+			name: "invalid protocol",
+			host: "1.2.3.4",
+			params: map[string]string{
+				"protocol": "sctp",
+			},
+			expectedConfig: tracerouteutil.Config{},
+			expectedError:  `invalid protocol: unsupported protocol "sctp", expected one of udp, tcp or icmp`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t1 *testing.T) {
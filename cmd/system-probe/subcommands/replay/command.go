@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package replay is the replay system-probe subcommand
+package replay
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/comp/core/sysprobeconfig/sysprobeconfigimpl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const (
+	formatJSON     = "json"
+	formatProtobuf = "protobuf"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	// capture is the path of the pcap or pcapng file to replay
+	capture string
+	// format is the encoding of the connections, json or protobuf
+	format string
+	// output is the file the connections are written to, or stdout if empty
+	output string
+	// logLevel is the level of the logs of the replayed components
+	logLevel string
+}
+
+// Commands returns a slice of subcommands for the 'system-probe' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+	replayCommand := &cobra.Command{
+		Use:   "replay <capture>",
+		Short: "Replay a packet capture through the network tracer and print the encoded connections",
+		Long: `Feed the packets of a pcap or pcapng file to the userspace parts of the network tracer:
+the connection state, the DNS snooper and the USM decoders and stat keepers. The
+connections are printed as they would be sent to the process-agent. This doesn't
+require a running system-probe nor root privileges.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliParams.capture = args[0]
+			if cliParams.format != formatJSON && cliParams.format != formatProtobuf {
+				return fmt.Errorf("unsupported format %q, expected %s or %s", cliParams.format, formatJSON, formatProtobuf)
+			}
+			return fxutil.OneShot(replayCapture,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams:         config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SysprobeConfigParams: sysprobeconfigimpl.NewParams(sysprobeconfigimpl.WithSysProbeConfFilePath(globalParams.ConfFilePath)),
+					LogParams:            logimpl.ForOneShot("SYS-PROBE", cliParams.logLevel, false),
+				}),
+				// no need to provide sysprobe logger since ForOneShot ignores config values
+				core.Bundle(),
+			)
+		},
+	}
+	replayCommand.Flags().StringVarP(&cliParams.format, "format", "f", formatJSON, "Encoding of the connections: json or protobuf")
+	replayCommand.Flags().StringVarP(&cliParams.output, "output", "o", "", "Write the connections to the given file rather than stdout")
+	replayCommand.Flags().StringVarP(&cliParams.logLevel, "log-level", "l", "off", "Log level of the replayed components")
+
+	return []*cobra.Command{replayCommand}
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package replay
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DataDog/datadog-agent/comp/core/sysprobeconfig"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding/marshal"
+	"github.com/DataDog/datadog-agent/pkg/network/replay"
+)
+
+// replayCapture replays the capture and writes the encoded connections. The
+// system-probe configuration is loaded by the sysprobeconfig component, so
+// that the limits and features of the network tracer apply to the replay.
+func replayCapture(_ sysprobeconfig.Component, cliParams *cliParams) error {
+	replayer := replay.New(networkconfig.New())
+	defer replayer.Close()
+
+	if err := replayer.ReplayFile(cliParams.capture); err != nil {
+		return fmt.Errorf("could not replay %s: %w", cliParams.capture, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if cliParams.output != "" {
+		f, err := os.Create(cliParams.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	contentType := marshal.ContentTypeJSON
+	if cliParams.format == formatProtobuf {
+		contentType = marshal.ContentTypeProtobuf
+	}
+	return writeConnections(replayer, marshal.GetMarshaler(contentType), w)
+}
+
+func writeConnections(replayer *replay.Replayer, marshaler marshal.Marshaler, w io.Writer) error {
+	conns := replayer.Connections()
+	connectionsModeler := marshal.NewConnectionsModeler(conns)
+	defer connectionsModeler.Close()
+
+	if err := marshaler.Marshal(conns, w, connectionsModeler); err != nil {
+		return fmt.Errorf("unable to marshal connections with type %s: %w", marshaler.ContentType(), err)
+	}
+	return nil
+}
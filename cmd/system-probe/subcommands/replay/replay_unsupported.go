@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux_bpf
+
+package replay
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/comp/core/sysprobeconfig"
+)
+
+func replayCapture(_ sysprobeconfig.Component, _ *cliParams) error {
+	return errors.New("replay is only supported on linux with eBPF support")
+}
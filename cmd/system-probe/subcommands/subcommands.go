@@ -11,6 +11,7 @@ import (
 	cmdconfig "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/config"
 	cmddebug "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/debug"
 	cmdmodrestart "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/modrestart"
+	cmdreplay "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/replay"
 	cmdrun "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/run"
 	cmdversion "github.com/DataDog/datadog-agent/cmd/system-probe/subcommands/version"
 )
@@ -24,5 +25,6 @@ func SysprobeSubcommands() []command.SubcommandFactory {
 		cmdmodrestart.Commands,
 		cmddebug.Commands,
 		cmdconfig.Commands,
+		cmdreplay.Commands,
 	}
 }
@@ -10,8 +10,10 @@ package agent
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -54,6 +56,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 	"github.com/DataDog/datadog-agent/pkg/gohai"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	sbomexport "github.com/DataDog/datadog-agent/pkg/sbom/export"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/grpc"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
@@ -111,6 +115,9 @@ func SetupHandlers(
 	r.HandleFunc("/workload-list", func(w http.ResponseWriter, r *http.Request) {
 		getWorkloadList(w, r, wmeta)
 	}).Methods("GET")
+	r.HandleFunc("/sbom/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		getSBOM(w, r, wmeta)
+	}).Methods("GET")
 	r.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) { secretInfo(w, r, secretResolver) }).Methods("GET")
 	r.HandleFunc("/secret/refresh", func(w http.ResponseWriter, r *http.Request) { secretRefresh(w, r, secretResolver) }).Methods("GET")
 	r.HandleFunc("/metadata/gohai", metadataPayloadGohai).Methods("GET")
@@ -458,6 +465,39 @@ func getWorkloadList(w http.ResponseWriter, r *http.Request, wmeta workloadmeta.
 	w.Write(jsonDump)
 }
 
+func getSBOM(w http.ResponseWriter, r *http.Request, wmeta workloadmeta.Component) {
+	kind := mux.Vars(r)["kind"]
+	params := r.URL.Query()
+
+	doc, err := sbomexport.Get(wmeta, kind, params.Get("id"))
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, sbomexport.ErrNotFound) {
+			code = http.StatusNotFound
+		}
+		setJSONError(w, err, code)
+		return
+	}
+
+	// The bundle is refreshed by the sbom check, it isn't reloaded on each request
+	matcher, err := osv.GetGlobalMatcher(config.Datadog)
+	if err != nil {
+		log.Warnf("Unable to load the vulnerability advisories, the SBOM is exported without them: %v", err)
+	}
+	if matcher != nil {
+		doc.Findings = matcher.Match(doc.BOM)
+	}
+
+	var buf bytes.Buffer
+	if err := sbomexport.Write(&buf, doc, params.Get("format")); err != nil {
+		setJSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
 func secretInfo(w http.ResponseWriter, _ *http.Request, secretResolver secrets.Component) {
 	secretResolver.GetDebugInfo(w)
 }
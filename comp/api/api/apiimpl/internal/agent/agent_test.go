@@ -12,6 +12,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
 
 	// component dependencies
 	"github.com/DataDog/datadog-agent/comp/aggregator/demultiplexer"
@@ -50,6 +53,7 @@ import (
 
 	// package dependencies
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	sbomexport "github.com/DataDog/datadog-agent/pkg/sbom/export"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -166,3 +170,43 @@ func TestSetupHandlers(t *testing.T) {
 
 	assert.Equal(t, string(wantjson), string(body))
 }
+
+func TestGetSBOM(t *testing.T) {
+	router := setupRoutes(t)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	get := func(path string) (int, map[string]interface{}) {
+		res, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body := make(map[string]interface{})
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+
+	code, _ := get("/sbom/host")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = get("/sbom/pod?id=foo")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	sbomexport.SetHostSBOM("my-host", &cyclonedxgo.BOM{
+		Components: &[]cyclonedxgo.Component{
+			{BOMRef: "pkg:deb/debian/curl@7.88.1", Name: "curl", Version: "7.88.1", PackageURL: "pkg:deb/debian/curl@7.88.1"},
+		},
+	}, time.Now())
+
+	code, body := get("/sbom/host")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "CycloneDX", body["bomFormat"])
+	assert.Equal(t, "1.5", body["specVersion"])
+
+	code, body = get("/sbom/host?format=spdx")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "SPDX-2.3", body["spdxVersion"])
+
+	code, _ = get("/sbom/host?format=xml")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
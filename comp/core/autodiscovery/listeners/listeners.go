@@ -20,6 +20,7 @@ const (
 	kubeletListenerName         = "kubelet"
 	snmpListenerName            = "snmp"
 	staticConfigListenerName    = "static config"
+	systemdListenerName         = "systemd"
 	dbmAuroraListenerName       = "database-monitoring-aurora"
 )
 
@@ -34,5 +35,6 @@ func RegisterListeners(serviceListenerFactories map[string]ServiceListenerFactor
 	Register(kubeletListenerName, func(config Config) (ServiceListener, error) { return NewKubeletListener(config, wmeta) }, serviceListenerFactories)
 	Register(snmpListenerName, NewSNMPListener, serviceListenerFactories)
 	Register(staticConfigListenerName, NewStaticConfigListener, serviceListenerFactories)
+	Register(systemdListenerName, func(config Config) (ServiceListener, error) { return NewSystemdListener(config, wmeta) }, serviceListenerFactories)
 	Register(dbmAuroraListenerName, NewDBMAuroraListener, serviceListenerFactories)
 }
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !serverless
+
+package listeners
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/util/optional"
+)
+
+// SystemdListener listens to the systemd units running on the host through a
+// subscription to the workloadmeta store.
+type SystemdListener struct {
+	workloadmetaListener
+}
+
+// NewSystemdListener returns a new SystemdListener.
+func NewSystemdListener(_ Config, wmeta optional.Option[workloadmeta.Component]) (ServiceListener, error) {
+	const name = "ad-systemdlistener"
+	l := &SystemdListener{}
+	filterParams := workloadmeta.FilterParams{
+		Kinds:     []workloadmeta.Kind{workloadmeta.KindSystemdUnit},
+		Source:    workloadmeta.SourceSystemd,
+		EventType: workloadmeta.EventTypeAll,
+	}
+	f := workloadmeta.NewFilter(&filterParams)
+
+	wmetaInstance, ok := wmeta.Get()
+	if !ok {
+		return nil, errors.New("workloadmeta store is not initialized")
+	}
+	var err error
+	l.workloadmetaListener, err = newWorkloadmetaListener(name, f, l.createSystemdService, wmetaInstance)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *SystemdListener) createSystemdService(entity workloadmeta.Entity) {
+	unit := entity.(*workloadmeta.SystemdUnit)
+
+	// checks are only scheduled on units that are running, so that units
+	// that are starting, reloading or failed don't report spurious errors
+	svc := &service{
+		entity:        unit,
+		adIdentifiers: []string{unit.Name},
+		hosts:         map[string]string{"host": "127.0.0.1"},
+		pid:           int(unit.MainPID),
+		ready:         unit.ActiveState == "active",
+	}
+
+	l.AddService(buildSvcID(unit.GetID()), svc, "")
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !serverless
+
+package listeners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+)
+
+func TestCreateSystemdService(t *testing.T) {
+	unitEntityID := workloadmeta.EntityID{
+		Kind: workloadmeta.KindSystemdUnit,
+		ID:   "nginx.service",
+	}
+
+	tests := []struct {
+		name string
+		unit *workloadmeta.SystemdUnit
+	}{
+		{
+			name: "active unit",
+			unit: &workloadmeta.SystemdUnit{
+				EntityID:    unitEntityID,
+				EntityMeta:  workloadmeta.EntityMeta{Name: "nginx.service"},
+				ActiveState: "active",
+				MainPID:     1234,
+			},
+		},
+		{
+			name: "failed unit is not ready",
+			unit: &workloadmeta.SystemdUnit{
+				EntityID:    unitEntityID,
+				EntityMeta:  workloadmeta.EntityMeta{Name: "nginx.service"},
+				ActiveState: "failed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wlm := newTestWorkloadmetaListener(t)
+			listener := &SystemdListener{workloadmetaListener: wlm}
+
+			listener.createSystemdService(tt.unit)
+
+			wlm.assertServices(map[string]wlmListenerSvc{
+				"systemd_unit://nginx.service": {
+					service: &service{
+						entity:        tt.unit,
+						adIdentifiers: []string{"nginx.service"},
+						hosts:         map[string]string{"host": "127.0.0.1"},
+						pid:           int(tt.unit.MainPID),
+						ready:         tt.unit.ActiveState == "active",
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestSystemdServiceEntities(t *testing.T) {
+	svc := &service{
+		entity: &workloadmeta.SystemdUnit{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindSystemdUnit,
+				ID:   "nginx.service",
+			},
+		},
+	}
+
+	assert.Equal(t, "systemd_unit://nginx.service", svc.GetServiceID())
+	assert.Equal(t, "systemd_unit://nginx.service", svc.GetTaggerEntity())
+}
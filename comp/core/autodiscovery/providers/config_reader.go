@@ -345,23 +345,36 @@ func collectDir(parentPath string, folder os.DirEntry, integrationErrors map[str
 
 // GetIntegrationConfigFromFile returns an instance of integration.Config if `fpath` points to a valid config file
 func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error) {
-	cf := configFormat{}
-	conf := integration.Config{Name: name}
-
 	// Read file contents
 	// FIXME: ReadFile reads the entire file, possible security implications
 	yamlFile, err := os.ReadFile(fpath)
 	if err != nil {
-		return conf, err
+		return integration.Config{Name: name}, err
 	}
 
+	conf, err := getIntegrationConfigFromYAML(name, fpath, yamlFile)
+	if err == nil {
+		conf.Source = "file:" + fpath
+	}
+
+	return conf, err
+}
+
+// getIntegrationConfigFromYAML returns an instance of integration.Config
+// parsed from the content of a config file. `origin` is only used to give
+// context to log messages, the caller is responsible for setting the Source
+// of the returned config.
+func getIntegrationConfigFromYAML(name, origin string, yamlFile []byte) (integration.Config, error) {
+	cf := configFormat{}
+	conf := integration.Config{Name: name}
+
 	// Parse configuration
 	// Try UnmarshalStrict first, so we can warn about duplicated keys
 	if strictErr := yaml.UnmarshalStrict(yamlFile, &cf); strictErr != nil {
 		if err := yaml.Unmarshal(yamlFile, &cf); err != nil {
 			return conf, err
 		}
-		log.Warnf("reading config file %v: %v\n", fpath, strictErr)
+		log.Warnf("reading config file %v: %v\n", origin, strictErr)
 	}
 
 	// If no valid instances were found & this is neither a metrics file, nor a logs file
@@ -387,7 +400,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 			tags := configUtils.GetConfiguredTags(config.Datadog, false)
 			err := dataConf.MergeAdditionalTags(tags)
 			if err != nil {
-				log.Debugf("Could not add agent-level tags to instance of %v: %v", fpath, err)
+				log.Debugf("Could not add agent-level tags to instance of %v: %v", origin, err)
 			}
 		}
 		conf.Instances = append(conf.Instances, dataConf)
@@ -430,9 +443,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		}
 	}
 
-	conf.Source = "file:" + fpath
-
-	return conf, err
+	return conf, nil
 }
 
 func containsString(slice []string, str string) bool {
@@ -0,0 +1,328 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxHTTPTemplateDocumentSize bounds the size of the document returned by
+	// the config service, to protect the agent from a misbehaving endpoint.
+	maxHTTPTemplateDocumentSize = 10 * 1024 * 1024
+	httpTemplateRequestTimeout  = 30 * time.Second
+)
+
+// HTTPConfigProvider implements the ConfigProvider interface. It polls an
+// HTTP(S) endpoint serving integration templates and streams the changes to
+// autodiscovery.
+//
+// The endpoint is expected to return a YAML (or JSON) document mapping check
+// names to the content of their configuration file, for instance:
+//
+//	nginx:
+//	  ad_identifiers:
+//	    - nginx
+//	  init_config:
+//	  instances:
+//	    - nginx_status_url: http://%%host%%/nginx_status
+//
+// The ETag returned by the endpoint is sent back in an If-None-Match header so
+// that unchanged documents are not downloaded and parsed again.
+type HTTPConfigProvider struct {
+	url          string
+	token        string
+	username     string
+	password     string
+	client       *http.Client
+	pollInterval time.Duration
+
+	etag         string
+	cache        *templateCache
+	configErrors map[string]ErrorMsgSet // map[check name]ErrorMsgSet
+	mu           sync.RWMutex
+}
+
+// NewHTTPConfigProvider creates a new HTTPConfigProvider
+func NewHTTPConfigProvider(providerConfig *config.ConfigurationProviders) (ConfigProvider, error) {
+	if providerConfig == nil {
+		providerConfig = &config.ConfigurationProviders{}
+	}
+
+	templateURL, err := url.Parse(providerConfig.TemplateURL)
+	if err != nil {
+		return nil, err
+	}
+	if templateURL.Scheme != "http" && templateURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid template_url %q for the %s config provider: scheme must be http or https", providerConfig.TemplateURL, names.HTTP)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if templateURL.Scheme == "https" {
+		tlsConfig, err := buildHTTPProviderTLSConfig(providerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build the TLS configuration of the %s config provider: %s", names.HTTP, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &HTTPConfigProvider{
+		url:          templateURL.String(),
+		token:        providerConfig.Token,
+		username:     providerConfig.Username,
+		password:     providerConfig.Password,
+		client:       &http.Client{Transport: transport, Timeout: httpTemplateRequestTimeout},
+		pollInterval: GetPollInterval(*providerConfig),
+		cache:        newTemplateCache(),
+		configErrors: make(map[string]ErrorMsgSet),
+	}, nil
+}
+
+func buildHTTPProviderTLSConfig(providerConfig *config.ConfigurationProviders) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if providerConfig.CAFile != "" {
+		caCert, err := os.ReadFile(providerConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate found in %s", providerConfig.CAFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if providerConfig.CertFile != "" || providerConfig.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(providerConfig.CertFile, providerConfig.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// String returns a string representation of the HTTPConfigProvider
+func (p *HTTPConfigProvider) String() string {
+	return names.HTTP
+}
+
+// Stream polls the config endpoint until the context is cancelled and sends
+// the resulting config changes on the returned channel.
+func (p *HTTPConfigProvider) Stream(ctx context.Context) <-chan integration.ConfigChanges {
+	outCh := make(chan integration.ConfigChanges)
+
+	go func() {
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+
+		// the first changes are always sent, even when empty, as the
+		// config poller waits for them before completing its start
+		changes := p.poll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case outCh <- changes:
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				changes = p.poll(ctx)
+				if !changes.IsEmpty() {
+					break
+				}
+			}
+		}
+	}()
+
+	return outCh
+}
+
+// poll fetches the templates document and returns the changes compared to
+// the previously fetched one.
+func (p *HTTPConfigProvider) poll(ctx context.Context) integration.ConfigChanges {
+	body, etag, modified, err := p.fetch(ctx)
+	if err != nil {
+		log.Warnf("Unable to fetch autodiscovery templates from %s: %s", p.url, err)
+		p.setDocumentError(err)
+		return integration.ConfigChanges{}
+	}
+	if !modified {
+		return integration.ConfigChanges{}
+	}
+
+	templates, err := parseHTTPTemplateDocument(body)
+	if err != nil {
+		log.Warnf("Unable to parse autodiscovery templates from %s: %s", p.url, err)
+		p.setDocumentError(err)
+		return integration.ConfigChanges{}
+	}
+
+	// the ETag is only remembered once the document has been parsed
+	// successfully, so that an invalid document is downloaded again
+	p.etag = etag
+
+	return p.processTemplates(templates)
+}
+
+// fetch downloads the templates document along with its ETag. It returns
+// false if the document hasn't been modified since the last parsed one.
+func (p *HTTPConfigProvider) fetch(ctx context.Context) ([]byte, string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	req.Header.Set("Accept", "application/yaml, application/json")
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	} else if p.username != "" && p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, "", false, nil
+	case http.StatusOK:
+	default:
+		return nil, "", false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPTemplateDocumentSize+1))
+	if err != nil {
+		return nil, "", false, err
+	}
+	if len(body) > maxHTTPTemplateDocumentSize {
+		return nil, "", false, fmt.Errorf("document is larger than %d bytes", maxHTTPTemplateDocumentSize)
+	}
+
+	return body, resp.Header.Get("ETag"), true, nil
+}
+
+// parseHTTPTemplateDocument splits the templates document into the raw
+// configuration of each check, indexed by check name.
+func parseHTTPTemplateDocument(body []byte) (map[string][]byte, error) {
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string][]byte, len(document))
+	for name, content := range document {
+		if name == "" {
+			return nil, errors.New("found a template without check name")
+		}
+		// at this point the Yaml was already parsed, no need to check the error
+		rawTemplate, _ := yaml.Marshal(content)
+		templates[name] = rawTemplate
+	}
+
+	return templates, nil
+}
+
+func (p *HTTPConfigProvider) processTemplates(templates map[string][]byte) integration.ConfigChanges {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := integration.ConfigChanges{}
+	configErrors := make(map[string]ErrorMsgSet)
+
+	// iterate in a stable order so that changes are sent deterministically
+	checkNames := make([]string, 0, len(templates))
+	for name := range templates {
+		checkNames = append(checkNames, name)
+	}
+	sort.Strings(checkNames)
+
+	for _, name := range checkNames {
+		source := names.HTTP + ":" + name
+		conf, err := getIntegrationConfigFromYAML(name, p.url, templates[name])
+		if err != nil {
+			// keep the configs previously generated from this template
+			// scheduled, an invalid update shouldn't stop a running check
+			log.Warnf("Invalid autodiscovery template %q from %s: %s", name, p.url, err)
+			configErrors[name] = ErrorMsgSet{err.Error(): struct{}{}}
+			continue
+		}
+		conf.Source = source
+
+		changes.Merge(p.cache.set(source, []integration.Config{conf}))
+	}
+
+	for _, source := range p.cache.sources() {
+		name := source[len(names.HTTP)+1:]
+		if _, found := templates[name]; !found {
+			changes.Merge(p.cache.unset(source))
+		}
+	}
+
+	p.configErrors = configErrors
+	telemetry.Errors.Set(float64(len(p.configErrors)), names.HTTP)
+
+	return changes
+}
+
+func (p *HTTPConfigProvider) setDocumentError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.configErrors = map[string]ErrorMsgSet{
+		p.url: {err.Error(): struct{}{}},
+	}
+	telemetry.Errors.Set(float64(len(p.configErrors)), names.HTTP)
+}
+
+// GetConfigErrors returns a map of errors that occurred on the last poll
+func (p *HTTPConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	configErrors := make(map[string]ErrorMsgSet, len(p.configErrors))
+
+	for entity, errset := range p.configErrors {
+		configErrors[entity] = errset
+	}
+
+	return configErrors
+}
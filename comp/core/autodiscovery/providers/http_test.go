@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type templateServer struct {
+	sync.Mutex
+	document string
+	etag     string
+	notMod   int
+}
+
+func (s *templateServer) set(document, etag string) {
+	s.Lock()
+	defer s.Unlock()
+	s.document = document
+	s.etag = etag
+}
+
+func (s *templateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	_, _ = w.Write([]byte(s.document))
+}
+
+func newTestHTTPProvider(t *testing.T, srv *httptest.Server) *HTTPConfigProvider {
+	p, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: srv.URL})
+	require.NoError(t, err)
+	return p.(*HTTPConfigProvider)
+}
+
+func configNames(configs []integration.Config) []string {
+	names := make([]string, 0, len(configs))
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestHTTPConfigProviderPoll(t *testing.T) {
+	ts := &templateServer{}
+	ts.set(`
+nginx:
+  ad_identifiers: [nginx]
+  init_config:
+  instances:
+    - nginx_status_url: http://%%host%%/nginx_status
+redisdb:
+  ad_identifiers: [redis]
+  instances:
+    - host: "%%host%%"
+`, `"v1"`)
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+	ctx := context.Background()
+
+	// first poll schedules everything
+	changes := p.poll(ctx)
+	assert.ElementsMatch(t, []string{"nginx", "redisdb"}, configNames(changes.Schedule))
+	assert.Empty(t, changes.Unschedule)
+	for _, c := range changes.Schedule {
+		assert.Equal(t, "http:"+c.Name, c.Source)
+		assert.Len(t, c.Instances, 1)
+	}
+
+	// same ETag: nothing is downloaded nor changed
+	changes = p.poll(ctx)
+	assert.True(t, changes.IsEmpty())
+	ts.Lock()
+	assert.Equal(t, 1, ts.notMod)
+	ts.Unlock()
+
+	// one template is updated, the other one is removed
+	ts.set(`
+nginx:
+  ad_identifiers: [nginx]
+  instances:
+    - nginx_status_url: http://%%host%%:8080/nginx_status
+`, `"v2"`)
+	changes = p.poll(ctx)
+	assert.Equal(t, []string{"nginx"}, configNames(changes.Schedule))
+	assert.ElementsMatch(t, []string{"nginx", "redisdb"}, configNames(changes.Unschedule))
+	assert.Empty(t, p.GetConfigErrors())
+
+	// an invalid template is reported and the previous config is kept
+	ts.set(`
+nginx:
+  ad_identifiers: [nginx]
+`, `"v3"`)
+	changes = p.poll(ctx)
+	assert.True(t, changes.IsEmpty())
+	assert.Contains(t, p.GetConfigErrors(), "nginx")
+}
+
+func TestHTTPConfigProviderInvalidDocument(t *testing.T) {
+	ts := &templateServer{}
+	ts.set("nginx: [unterminated", `"v1"`)
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+	ctx := context.Background()
+
+	changes := p.poll(ctx)
+	assert.True(t, changes.IsEmpty())
+	assert.Contains(t, p.GetConfigErrors(), srv.URL)
+
+	// the ETag of the invalid document isn't sent back, so the document is
+	// downloaded and parsed again
+	changes = p.poll(ctx)
+	assert.True(t, changes.IsEmpty())
+	ts.Lock()
+	assert.Equal(t, 0, ts.notMod)
+	ts.Unlock()
+
+	ts.set(`
+nginx:
+  ad_identifiers: [nginx]
+  instances:
+    - {}
+`, `"v1"`)
+	changes = p.poll(ctx)
+	assert.Equal(t, []string{"nginx"}, configNames(changes.Schedule))
+}
+
+func TestHTTPConfigProviderServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+
+	changes := p.poll(context.Background())
+	assert.True(t, changes.IsEmpty())
+	assert.Contains(t, p.GetConfigErrors(), srv.URL)
+}
+
+func TestHTTPConfigProviderStream(t *testing.T) {
+	ts := &templateServer{}
+	ts.set(`
+nginx:
+  ad_identifiers: [nginx]
+  instances:
+    - {}
+`, `"v1"`)
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := <-p.Stream(ctx)
+	assert.Equal(t, []string{"nginx"}, configNames(changes.Schedule))
+}
+
+func TestNewHTTPConfigProviderInvalidScheme(t *testing.T) {
+	_, err := NewHTTPConfigProvider(&config.ConfigurationProviders{TemplateURL: "ftp://127.0.0.1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+)
+
+// templateCache keeps track of the configs generated from each template
+// source (a file, a key of an HTTP document, etc.) so that streaming
+// providers can send incremental changes instead of full reloads.
+type templateCache struct {
+	configs map[string]map[string]integration.Config // map[template source]map[config digest]integration.Config
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		configs: make(map[string]map[string]integration.Config),
+	}
+}
+
+// set replaces the configs known for a template source and returns the
+// changes needed to go from the previous state to the new one.
+func (c *templateCache) set(source string, configs []integration.Config) integration.ConfigChanges {
+	changes := integration.ConfigChanges{}
+
+	configCache, ok := c.configs[source]
+	if !ok {
+		configCache = make(map[string]integration.Config)
+		c.configs[source] = configCache
+	}
+
+	configsToUnschedule := make(map[string]integration.Config)
+	for digest, config := range configCache {
+		configsToUnschedule[digest] = config
+	}
+
+	for _, config := range configs {
+		digest := config.Digest()
+		if _, ok := configCache[digest]; ok {
+			delete(configsToUnschedule, digest)
+		} else {
+			configCache[digest] = config
+			changes.ScheduleConfig(config)
+		}
+	}
+
+	for oldDigest, oldConfig := range configsToUnschedule {
+		delete(configCache, oldDigest)
+		changes.UnscheduleConfig(oldConfig)
+	}
+
+	return changes
+}
+
+// unset forgets about a template source and returns the changes needed to
+// unschedule all of its configs.
+func (c *templateCache) unset(source string) integration.ConfigChanges {
+	changes := integration.ConfigChanges{}
+
+	for _, oldConfig := range c.configs[source] {
+		changes.UnscheduleConfig(oldConfig)
+	}
+	delete(c.configs, source)
+
+	return changes
+}
+
+// sources returns the list of template sources currently known
+func (c *templateCache) sources() []string {
+	sources := make([]string, 0, len(c.configs))
+	for source := range c.configs {
+		sources = append(sources, source)
+	}
+	return sources
+}
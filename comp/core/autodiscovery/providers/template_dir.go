@@ -0,0 +1,339 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// DirConfigProvider implements the ConfigProvider interface. It watches a
+// local directory of integration templates with inotify and streams the
+// changes to autodiscovery as files are created, modified or removed.
+//
+// Templates use the same format as the files of the `conf.d` directory and can
+// be laid out either as `<check name>.yaml` or `<check name>.d/<any>.yaml`.
+// The whole directory is also rescanned every poll interval, to recover from
+// missed filesystem events.
+type DirConfigProvider struct {
+	dir          string
+	pollInterval time.Duration
+
+	watcher      *fsnotify.Watcher
+	cache        *templateCache
+	configErrors map[string]ErrorMsgSet // map[file path]ErrorMsgSet
+	mu           sync.RWMutex
+}
+
+// NewDirConfigProvider creates a new DirConfigProvider
+func NewDirConfigProvider(providerConfig *config.ConfigurationProviders) (ConfigProvider, error) {
+	if providerConfig == nil || providerConfig.TemplateDir == "" {
+		return nil, errors.New("template_dir is required by the directory config provider")
+	}
+
+	return &DirConfigProvider{
+		dir:          filepath.Clean(providerConfig.TemplateDir),
+		pollInterval: GetPollInterval(*providerConfig),
+		cache:        newTemplateCache(),
+		configErrors: make(map[string]ErrorMsgSet),
+	}, nil
+}
+
+// String returns a string representation of the DirConfigProvider
+func (p *DirConfigProvider) String() string {
+	return names.Directory
+}
+
+// Stream watches the template directory until the context is cancelled and
+// sends the resulting config changes on the returned channel.
+func (p *DirConfigProvider) Stream(ctx context.Context) <-chan integration.ConfigChanges {
+	outCh := make(chan integration.ConfigChanges)
+
+	var err error
+	if p.watcher, err = fsnotify.NewWatcher(); err != nil {
+		log.Warnf("Unable to watch %s, falling back to polling every %s: %s", p.dir, p.pollInterval, err)
+		p.watcher = nil
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+
+		var events <-chan fsnotify.Event
+		var watchErrors <-chan error
+		if p.watcher != nil {
+			defer p.watcher.Close()
+			events = p.watcher.Events
+			watchErrors = p.watcher.Errors
+		}
+
+		// the first changes are always sent, even when empty, as the
+		// config poller waits for them before completing its start
+		changes := p.rescan()
+		first := true
+		for {
+			if first || !changes.IsEmpty() {
+				select {
+				case <-ctx.Done():
+					return
+				case outCh <- changes:
+				}
+				first = false
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changes = p.rescan()
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					changes = integration.ConfigChanges{}
+					continue
+				}
+				changes = p.processEvent(event)
+			case err, ok := <-watchErrors:
+				if !ok {
+					watchErrors = nil
+				} else {
+					log.Debugf("Error watching %s: %s", p.dir, err)
+				}
+				changes = integration.ConfigChanges{}
+			}
+		}
+	}()
+
+	return outCh
+}
+
+// rescan reads every template of the directory and returns the changes
+// compared to the known state.
+func (p *DirConfigProvider) rescan() integration.ConfigChanges {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := integration.ConfigChanges{}
+
+	files, err := p.listTemplateFiles()
+	if err != nil {
+		log.Warnf("Unable to list autodiscovery templates in %s: %s", p.dir, err)
+		p.configErrors[p.dir] = ErrorMsgSet{err.Error(): struct{}{}}
+		telemetry.Errors.Set(float64(len(p.configErrors)), names.Directory)
+		return changes
+	}
+	delete(p.configErrors, p.dir)
+
+	found := make(map[string]struct{}, len(files))
+	for _, path := range files {
+		found[p.source(path)] = struct{}{}
+		changes.Merge(p.loadFile(path))
+	}
+
+	for _, source := range p.cache.sources() {
+		if _, ok := found[source]; !ok {
+			changes.Merge(p.cache.unset(source))
+		}
+	}
+	for path := range p.configErrors {
+		if _, ok := found[p.source(path)]; !ok && path != p.dir {
+			delete(p.configErrors, path)
+		}
+	}
+
+	telemetry.Errors.Set(float64(len(p.configErrors)), names.Directory)
+
+	return changes
+}
+
+// listTemplateFiles returns the template files of the directory, sorted, and
+// makes sure that the directory and its `.d` sub-directories are watched.
+func (p *DirConfigProvider) listTemplateFiles() ([]string, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	p.watch(p.dir)
+
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(p.dir, entry.Name())
+
+		if !entry.IsDir() {
+			if isTemplateFile(path) {
+				files = append(files, path)
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".d") {
+			continue
+		}
+		p.watch(path)
+
+		subEntries, err := os.ReadDir(path)
+		if err != nil {
+			log.Debugf("Unable to list autodiscovery templates in %s: %s", path, err)
+			continue
+		}
+		for _, subEntry := range subEntries {
+			subPath := filepath.Join(path, subEntry.Name())
+			if !subEntry.IsDir() && isTemplateFile(subPath) {
+				files = append(files, subPath)
+			}
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// processEvent returns the config changes resulting from a filesystem event
+func (p *DirConfigProvider) processEvent(event fsnotify.Event) integration.ConfigChanges {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := integration.ConfigChanges{}
+	path := filepath.Clean(event.Name)
+
+	switch {
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) > 0:
+		// a removed `.d` directory doesn't generate events for its files
+		prefix := p.source(path) + string(filepath.Separator)
+		for _, source := range p.cache.sources() {
+			if source == p.source(path) || strings.HasPrefix(source, prefix) {
+				changes.Merge(p.cache.unset(source))
+			}
+		}
+		for errPath := range p.configErrors {
+			if errPath == path || strings.HasPrefix(errPath, path+string(filepath.Separator)) {
+				delete(p.configErrors, errPath)
+			}
+		}
+
+	case event.Op&(fsnotify.Create|fsnotify.Write) > 0:
+		if filepath.Dir(path) == p.dir && strings.HasSuffix(path, ".d") {
+			info, err := os.Stat(path)
+			if err != nil || !info.IsDir() {
+				break
+			}
+			p.watch(path)
+
+			// files may have been created before the watch was added
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				break
+			}
+			for _, entry := range entries {
+				subPath := filepath.Join(path, entry.Name())
+				if !entry.IsDir() && isTemplateFile(subPath) {
+					changes.Merge(p.loadFile(subPath))
+				}
+			}
+			break
+		}
+
+		if p.isTemplatePath(path) {
+			changes.Merge(p.loadFile(path))
+		}
+	}
+
+	telemetry.Errors.Set(float64(len(p.configErrors)), names.Directory)
+
+	return changes
+}
+
+// loadFile parses a template file and returns the changes compared to the
+// configs previously generated from it.
+func (p *DirConfigProvider) loadFile(path string) integration.ConfigChanges {
+	conf, err := GetIntegrationConfigFromFile(p.templateName(path), path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			delete(p.configErrors, path)
+			return p.cache.unset(p.source(path))
+		}
+
+		// keep the configs previously generated from this file scheduled,
+		// the file might be in the middle of being written
+		log.Warnf("Invalid autodiscovery template %s: %s", path, err)
+		p.configErrors[path] = ErrorMsgSet{err.Error(): struct{}{}}
+		return integration.ConfigChanges{}
+	}
+	delete(p.configErrors, path)
+
+	conf.Source = p.source(path)
+
+	return p.cache.set(conf.Source, []integration.Config{conf})
+}
+
+func (p *DirConfigProvider) watch(path string) {
+	if p.watcher == nil {
+		return
+	}
+	if err := p.watcher.Add(path); err != nil {
+		log.Debugf("Unable to watch %s: %s", path, err)
+	}
+}
+
+func (p *DirConfigProvider) source(path string) string {
+	return names.Directory + ":" + path
+}
+
+// isTemplatePath returns whether path is located where templates are expected
+func (p *DirConfigProvider) isTemplatePath(path string) bool {
+	if !isTemplateFile(path) {
+		return false
+	}
+
+	parent := filepath.Dir(path)
+
+	return parent == p.dir || (filepath.Dir(parent) == p.dir && strings.HasSuffix(parent, ".d"))
+}
+
+// templateName returns the name of the check configured by a template file
+func (p *DirConfigProvider) templateName(path string) string {
+	parent := filepath.Dir(path)
+	if parent != p.dir {
+		return strings.TrimSuffix(filepath.Base(parent), ".d")
+	}
+
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func isTemplateFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// GetConfigErrors returns a map of errors that occurred while loading templates
+func (p *DirConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	configErrors := make(map[string]ErrorMsgSet, len(p.configErrors))
+
+	for entity, errset := range p.configErrors {
+		configErrors[entity] = errset
+	}
+
+	return configErrors
+}
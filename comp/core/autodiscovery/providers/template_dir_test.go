@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const testTemplate = `
+ad_identifiers: [nginx]
+instances:
+  - nginx_status_url: http://%%host%%/nginx_status
+`
+
+func newTestDirProvider(t *testing.T, dir string) *DirConfigProvider {
+	p, err := NewDirConfigProvider(&config.ConfigurationProviders{TemplateDir: dir})
+	require.NoError(t, err)
+	return p.(*DirConfigProvider)
+}
+
+func TestDirConfigProviderRescan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx.yaml"), []byte(testTemplate), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "redisdb.d"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "redisdb.d", "conf.yaml"), []byte("instances: [{}]"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a template"), 0o644))
+
+	p := newTestDirProvider(t, dir)
+
+	changes := p.rescan()
+	assert.ElementsMatch(t, []string{"nginx", "redisdb"}, configNames(changes.Schedule))
+	assert.Empty(t, changes.Unschedule)
+
+	// nothing changed
+	changes = p.rescan()
+	assert.True(t, changes.IsEmpty())
+
+	// invalid files are reported and don't unschedule anything
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx.yaml"), []byte("ad_identifiers: [nginx]"), 0o644))
+	changes = p.rescan()
+	assert.True(t, changes.IsEmpty())
+	assert.Contains(t, p.GetConfigErrors(), filepath.Join(dir, "nginx.yaml"))
+
+	// removed files are unscheduled
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "redisdb.d")))
+	changes = p.rescan()
+	assert.Empty(t, changes.Schedule)
+	assert.Equal(t, []string{"redisdb"}, configNames(changes.Unschedule))
+}
+
+func TestDirConfigProviderProcessEvent(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestDirProvider(t, dir)
+
+	path := filepath.Join(dir, "nginx.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testTemplate), 0o644))
+
+	changes := p.processEvent(fsnotify.Event{Name: path, Op: fsnotify.Create})
+	assert.Equal(t, []string{"nginx"}, configNames(changes.Schedule))
+	assert.Equal(t, "directory:"+path, changes.Schedule[0].Source)
+
+	changes = p.processEvent(fsnotify.Event{Name: path, Op: fsnotify.Chmod})
+	assert.True(t, changes.IsEmpty())
+
+	require.NoError(t, os.Remove(path))
+	changes = p.processEvent(fsnotify.Event{Name: path, Op: fsnotify.Remove})
+	assert.Equal(t, []string{"nginx"}, configNames(changes.Unschedule))
+}
+
+func TestDirConfigProviderStream(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestDirProvider(t, dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := p.Stream(ctx)
+
+	changes := <-ch
+	assert.True(t, changes.IsEmpty())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx.yaml"), []byte(testTemplate), 0o644))
+
+	select {
+	case changes = <-ch:
+		assert.Equal(t, []string{"nginx"}, configNames(changes.Schedule))
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the template to be scheduled")
+	}
+}
@@ -60,25 +60,34 @@ func printTaggerEntities(w io.Writer, tr *TaggerListResponse) {
 		for _, source := range sources {
 			fmt.Fprintf(w, "== Source %s =\n=", source)
 
-			fmt.Fprint(w, "Tags: [")
-
-			// sort tags for easy comparison
-			tags := tagItem.Tags[source]
-			sort.Slice(tags, func(i, j int) bool {
-				return tags[i] < tags[j]
-			})
-
-			for i, tag := range tags {
-				tagInfo := strings.Split(tag, ":")
-				fmt.Fprintf(w, "%s:%s", color.BlueString(tagInfo[0]), color.CyanString(strings.Join(tagInfo[1:], ":")))
-				if i != len(tags)-1 {
-					fmt.Fprintf(w, " ")
-				}
+			if rawTags, ok := tagItem.RawTags[source]; ok {
+				printTags(w, "Tags before rules", rawTags)
+				fmt.Fprint(w, "=")
 			}
 
-			fmt.Fprintln(w, "]")
+			printTags(w, "Tags", tagItem.Tags[source])
 		}
 
 		fmt.Fprintln(w, "===")
 	}
 }
+
+// printTags prints a sorted list of tags on a single line
+func printTags(w io.Writer, label string, tags []string) {
+	fmt.Fprintf(w, "%s: [", label)
+
+	// sort tags for easy comparison
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i] < tags[j]
+	})
+
+	for i, tag := range tags {
+		tagInfo := strings.Split(tag, ":")
+		fmt.Fprintf(w, "%s:%s", color.BlueString(tagInfo[0]), color.CyanString(strings.Join(tagInfo[1:], ":")))
+		if i != len(tags)-1 {
+			fmt.Fprintf(w, " ")
+		}
+	}
+
+	fmt.Fprintln(w, "]")
+}
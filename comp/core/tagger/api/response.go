@@ -13,4 +13,7 @@ type TaggerListResponse struct {
 // TaggerListEntity holds the tagging info about an entity
 type TaggerListEntity struct {
 	Tags map[string][]string `json:"tags"`
+	// RawTags holds, for the sources whose tags were modified by the
+	// tagger rules, the tags as reported by the source.
+	RawTags map[string][]string `json:"raw_tags,omitempty"`
 }
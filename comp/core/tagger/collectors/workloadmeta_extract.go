@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/DataDog/datadog-agent/comp/core/tagger/utils"
@@ -18,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/systemd"
 )
 
 const (
@@ -145,6 +147,8 @@ func (c *WorkloadMetaCollector) processEvents(evBundle workloadmeta.EventBundle)
 				// tagInfos = append(tagInfos, c.handleProcess(ev)...) No tags for now
 			case workloadmeta.KindKubernetesDeployment:
 				// tagInfos = append(tagInfos, c.handleDeployment(ev)...) No tags for now
+			case workloadmeta.KindSystemdUnit:
+				tagInfos = append(tagInfos, c.handleSystemdUnit(ev)...)
 			default:
 				log.Errorf("cannot handle event for entity %q with kind %q", entityID.ID, entityID.Kind)
 			}
@@ -296,6 +300,58 @@ func (c *WorkloadMetaCollector) handleHostTags(ev workloadmeta.Event) []*TagInfo
 	}
 }
 
+func (c *WorkloadMetaCollector) handleSystemdUnit(ev workloadmeta.Event) []*TagInfo {
+	unit := ev.Entity.(*workloadmeta.SystemdUnit)
+
+	tags := utils.NewTagList()
+	tags.AddLow("systemd_unit", unit.Name)
+
+	for label, value := range unit.Labels {
+		tags.AddLow("systemd_"+label, value)
+	}
+
+	// standard tags from environment
+	c.extractFromMapWithFn(unit.EnvVars, standardEnvKeys, tags.AddStandard)
+
+	// extract env as tags
+	for envName, envValue := range unit.EnvVars {
+		utils.AddMetadataAsTags(envName, envValue, c.containerEnvAsTags, c.globContainerEnvLabels, tags)
+	}
+
+	low, orch, high, standard := tags.Compute()
+	tagInfos := []*TagInfo{
+		{
+			Source:               systemdUnitSource,
+			Entity:               buildTaggerEntityID(unit.EntityID),
+			HighCardTags:         high,
+			OrchestratorCardTags: orch,
+			LowCardTags:          low,
+			StandardTags:         standard,
+		},
+	}
+
+	// the main process of the unit gets the same tags, so that data
+	// identified by PID, like process metrics, can be tagged
+	if unit.MainPID > 0 {
+		processID := workloadmeta.EntityID{
+			Kind: workloadmeta.KindProcess,
+			ID:   strconv.Itoa(int(unit.MainPID)),
+		}
+		c.registerChild(unit.EntityID, processID)
+
+		tagInfos = append(tagInfos, &TagInfo{
+			Source:               systemdUnitSource,
+			Entity:               buildTaggerEntityID(processID),
+			HighCardTags:         high,
+			OrchestratorCardTags: orch,
+			LowCardTags:          low,
+			StandardTags:         standard,
+		})
+	}
+
+	return tagInfos
+}
+
 func (c *WorkloadMetaCollector) labelsToTags(labels map[string]string, tags *utils.TagList) {
 	// standard tags from labels
 	c.extractFromMapWithFn(labels, standardDockerLabels, tags.AddStandard)
@@ -725,6 +781,8 @@ func buildTaggerEntityID(entityID workloadmeta.EntityID) string {
 		return fmt.Sprintf("deployment://%s", entityID.ID)
 	case workloadmeta.KindHost:
 		return fmt.Sprintf("host://%s", entityID.ID)
+	case workloadmeta.KindSystemdUnit:
+		return systemd.BuildTaggerEntityName(entityID.ID)
 	default:
 		log.Errorf("can't recognize entity %q with kind %q; trying %s://%s as tagger entity",
 			entityID.ID, entityID.Kind, entityID.ID, entityID.Kind)
@@ -32,6 +32,7 @@ const (
 	containerImageSource = workloadmetaCollectorName + "-" + string(workloadmeta.KindContainerImageMetadata)
 	processSource        = workloadmetaCollectorName + "-" + string(workloadmeta.KindProcess)
 	hostSource           = workloadmetaCollectorName + "-" + string(workloadmeta.KindHost)
+	systemdUnitSource    = workloadmetaCollectorName + "-" + string(workloadmeta.KindSystemdUnit)
 
 	clusterTagNamePrefix = "kube_cluster_name"
 )
@@ -207,4 +208,5 @@ func init() {
 	CollectorPriorities[taskSource] = NodeOrchestrator
 	CollectorPriorities[containerSource] = NodeRuntime
 	CollectorPriorities[containerImageSource] = NodeRuntime
+	CollectorPriorities[systemdUnitSource] = NodeRuntime
 }
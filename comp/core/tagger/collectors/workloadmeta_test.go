@@ -1286,6 +1286,74 @@ func TestHandleContainerImage(t *testing.T) {
 	}
 }
 
+func TestHandleSystemdUnit(t *testing.T) {
+	entityID := workloadmeta.EntityID{
+		Kind: workloadmeta.KindSystemdUnit,
+		ID:   "nginx.service",
+	}
+
+	unit := &workloadmeta.SystemdUnit{
+		EntityID: entityID,
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: entityID.ID,
+			Labels: map[string]string{
+				"slice": "system.slice",
+				"user":  "www-data",
+			},
+		},
+		MainPID: 1234,
+		EnvVars: map[string]string{
+			"DD_ENV":     "production",
+			"DD_SERVICE": "web",
+			"TEAM":       "frontend",
+		},
+	}
+
+	lowCardTags := []string{
+		"env:production",
+		"service:web",
+		"systemd_slice:system.slice",
+		"systemd_unit:nginx.service",
+		"systemd_user:www-data",
+		"team:frontend",
+	}
+	standardTags := []string{
+		"env:production",
+		"service:web",
+	}
+
+	collector := &WorkloadMetaCollector{
+		children: make(map[string]map[string]struct{}),
+	}
+	collector.initContainerMetaAsTags(nil, map[string]string{"team": "team"})
+
+	actual := collector.handleSystemdUnit(workloadmeta.Event{
+		Type:   workloadmeta.EventTypeSet,
+		Entity: unit,
+	})
+
+	assertTagInfoListEqual(t, []*TagInfo{
+		{
+			Source:               systemdUnitSource,
+			Entity:               "systemd_unit://nginx.service",
+			HighCardTags:         []string{},
+			OrchestratorCardTags: []string{},
+			LowCardTags:          lowCardTags,
+			StandardTags:         standardTags,
+		},
+		{
+			Source:               systemdUnitSource,
+			Entity:               "process://1234",
+			HighCardTags:         []string{},
+			OrchestratorCardTags: []string{},
+			LowCardTags:          lowCardTags,
+			StandardTags:         standardTags,
+		},
+	}, actual)
+
+	assert.Contains(t, collector.children["systemd_unit://nginx.service"], "process://1234")
+}
+
 func TestHandleDelete(t *testing.T) {
 	const (
 		podName       = "datadog-agent-foobar"
@@ -13,11 +13,14 @@ import (
 
 	tagger_api "github.com/DataDog/datadog-agent/comp/core/tagger/api"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/rules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/tagstore"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/telemetry"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Tagger is the entry class for entity tagging. It hold the tagger collector,
@@ -38,8 +41,14 @@ type Tagger struct {
 // NewTagger returns an allocated tagger. You are probably looking for
 // tagger.Tag() using the global instance instead of creating your own.
 func NewTagger(workloadStore workloadmeta.Component) *Tagger {
+	ruleSet, err := rules.NewFromConfig(config.Datadog)
+	if err != nil {
+		log.Errorf("Tagger rules are ignored: %s", err)
+		ruleSet = nil
+	}
+
 	return &Tagger{
-		tagStore:      tagstore.NewTagStore(),
+		tagStore:      tagstore.NewTagStoreWithRules(ruleSet),
 		workloadStore: workloadStore,
 	}
 }
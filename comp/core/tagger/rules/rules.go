@@ -0,0 +1,259 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package rules implements the tag transformation rules that the tagger
+// applies to the tags extracted by its collectors, before they are stored and
+// served to DogStatsD, logs, traces, etc.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// configKey is the configuration key holding the list of rules
+const configKey = "tagger_rules"
+
+// Action is the transformation applied to the tags matching a rule
+type Action string
+
+const (
+	// ActionRename replaces a matching tag by the expansion of the target
+	ActionRename Action = "rename"
+	// ActionDerive adds the expansion of the target next to a matching tag
+	ActionDerive Action = "derive"
+	// ActionDrop removes a matching tag
+	ActionDrop Action = "drop"
+	// ActionSetCardinality moves a matching tag to another cardinality
+	ActionSetCardinality Action = "set_cardinality"
+)
+
+// Config is the user-facing configuration of a rule.
+//
+// Match is a regular expression matched against the whole `name:value` tag.
+// Target is expanded with the capture groups of Match, using the `$1` or
+// `${name}` syntax, and is required by the rename and derive actions.
+// Cardinality is required by the set_cardinality action and optional for the
+// derive action, in which case derived tags inherit the cardinality of the
+// tag they are derived from.
+type Config struct {
+	Match       string `mapstructure:"match" yaml:"match" json:"match"`
+	Action      string `mapstructure:"action" yaml:"action" json:"action"`
+	Target      string `mapstructure:"target" yaml:"target" json:"target"`
+	Cardinality string `mapstructure:"cardinality" yaml:"cardinality" json:"cardinality"`
+}
+
+type rule struct {
+	action         Action
+	match          *regexp.Regexp
+	target         string
+	cardinality    collectors.TagCardinality
+	hasCardinality bool
+}
+
+// RuleSet is an ordered list of rules. Each rule is applied to the output of
+// the previous one.
+type RuleSet struct {
+	rules []rule
+}
+
+// NewFromConfig returns the RuleSet configured in `tagger_rules`. It returns
+// nil if no rule is configured.
+func NewFromConfig(cfg model.Reader) (*RuleSet, error) {
+	if !cfg.IsSet(configKey) {
+		return nil, nil
+	}
+
+	var configs []Config
+	if err := cfg.UnmarshalKey(configKey, &configs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", configKey, err)
+	}
+
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	return New(configs)
+}
+
+// New compiles a list of rule configurations into a RuleSet
+func New(configs []Config) (*RuleSet, error) {
+	rs := &RuleSet{
+		rules: make([]rule, 0, len(configs)),
+	}
+
+	for i, c := range configs {
+		r, err := newRule(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tagger rule #%d: %w", i, err)
+		}
+		rs.rules = append(rs.rules, r)
+	}
+
+	return rs, nil
+}
+
+func newRule(c Config) (rule, error) {
+	r := rule{
+		action: Action(c.Action),
+		target: c.Target,
+	}
+
+	if c.Match == "" {
+		return r, fmt.Errorf("match is required")
+	}
+
+	var err error
+	if r.match, err = regexp.Compile("^(?:" + c.Match + ")$"); err != nil {
+		return r, fmt.Errorf("invalid match %q: %w", c.Match, err)
+	}
+
+	if c.Cardinality != "" {
+		if r.cardinality, err = collectors.StringToTagCardinality(c.Cardinality); err != nil {
+			return r, err
+		}
+		r.hasCardinality = true
+	}
+
+	switch r.action {
+	case ActionRename, ActionDerive:
+		if r.target == "" {
+			return r, fmt.Errorf("target is required by the %s action", r.action)
+		}
+	case ActionDrop:
+	case ActionSetCardinality:
+		if !r.hasCardinality {
+			return r, fmt.Errorf("cardinality is required by the %s action", r.action)
+		}
+	default:
+		return r, fmt.Errorf("unknown action %q", c.Action)
+	}
+
+	return r, nil
+}
+
+type cardTag struct {
+	tag         string
+	cardinality collectors.TagCardinality
+}
+
+// Apply transforms the tags of an entity and returns them grouped by
+// cardinality, along with the standard tags that survived the rules. The
+// last return value is false if no rule matched, in which case the input
+// slices are returned untouched.
+func (rs *RuleSet) Apply(low, orchestrator, high, standard []string) ([]string, []string, []string, []string, bool) {
+	if rs == nil || len(rs.rules) == 0 {
+		return low, orchestrator, high, standard, false
+	}
+
+	tags := make([]cardTag, 0, len(low)+len(orchestrator)+len(high))
+	for _, t := range low {
+		tags = append(tags, cardTag{tag: t, cardinality: collectors.LowCardinality})
+	}
+	for _, t := range orchestrator {
+		tags = append(tags, cardTag{tag: t, cardinality: collectors.OrchestratorCardinality})
+	}
+	for _, t := range high {
+		tags = append(tags, cardTag{tag: t, cardinality: collectors.HighCardinality})
+	}
+
+	changed := false
+	for _, r := range rs.rules {
+		var matched bool
+		tags, matched = r.apply(tags)
+		changed = changed || matched
+	}
+
+	if !changed {
+		return low, orchestrator, high, standard, false
+	}
+
+	// a tag reported at several cardinalities is only kept at the lowest one
+	seen := make(map[string]collectors.TagCardinality, len(tags))
+	for _, t := range tags {
+		if card, found := seen[t.tag]; !found || t.cardinality < card {
+			seen[t.tag] = t.cardinality
+		}
+	}
+
+	var newLow, newOrchestrator, newHigh []string
+	for _, t := range tags {
+		card, found := seen[t.tag]
+		if !found || card != t.cardinality {
+			continue
+		}
+		delete(seen, t.tag)
+
+		switch t.cardinality {
+		case collectors.LowCardinality:
+			newLow = append(newLow, t.tag)
+		case collectors.OrchestratorCardinality:
+			newOrchestrator = append(newOrchestrator, t.tag)
+		default:
+			newHigh = append(newHigh, t.tag)
+		}
+	}
+
+	// standard tags are also reported as low cardinality tags, only keep
+	// the ones that weren't renamed, dropped or moved
+	kept := make(map[string]struct{}, len(newLow))
+	for _, t := range newLow {
+		kept[t] = struct{}{}
+	}
+	var newStandard []string
+	for _, t := range standard {
+		if _, ok := kept[t]; ok {
+			newStandard = append(newStandard, t)
+		}
+	}
+
+	return newLow, newOrchestrator, newHigh, newStandard, true
+}
+
+// apply runs the rule on every tag. Tags derived by the rule are not matched
+// against it again.
+func (r *rule) apply(tags []cardTag) ([]cardTag, bool) {
+	matched := false
+	out := make([]cardTag, 0, len(tags))
+
+	for _, t := range tags {
+		submatches := r.match.FindStringSubmatchIndex(t.tag)
+		if submatches == nil {
+			out = append(out, t)
+			continue
+		}
+		matched = true
+
+		switch r.action {
+		case ActionRename:
+			if renamed := r.expand(t.tag, submatches); renamed != "" {
+				t.tag = renamed
+				out = append(out, t)
+			}
+		case ActionDerive:
+			out = append(out, t)
+			if derived := r.expand(t.tag, submatches); derived != "" {
+				card := t.cardinality
+				if r.hasCardinality {
+					card = r.cardinality
+				}
+				out = append(out, cardTag{tag: derived, cardinality: card})
+			}
+		case ActionDrop:
+		case ActionSetCardinality:
+			t.cardinality = r.cardinality
+			out = append(out, t)
+		}
+	}
+
+	return out, matched
+}
+
+func (r *rule) expand(tag string, submatches []int) string {
+	return string(r.match.ExpandString(nil, r.target, tag, submatches))
+}
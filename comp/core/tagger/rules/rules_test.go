@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInvalidRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "missing match", config: Config{Action: "drop"}},
+		{name: "invalid match", config: Config{Match: "(", Action: "drop"}},
+		{name: "unknown action", config: Config{Match: "foo:.*", Action: "explode"}},
+		{name: "rename without target", config: Config{Match: "foo:.*", Action: "rename"}},
+		{name: "derive without target", config: Config{Match: "foo:.*", Action: "derive"}},
+		{name: "set_cardinality without cardinality", config: Config{Match: "foo:.*", Action: "set_cardinality"}},
+		{name: "invalid cardinality", config: Config{Match: "foo:.*", Action: "set_cardinality", Cardinality: "medium"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New([]Config{test.config})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name             string
+		rules            []Config
+		low, orch, high  []string
+		standard         []string
+		expectedLow      []string
+		expectedOrch     []string
+		expectedHigh     []string
+		expectedStandard []string
+		expectedChanged  bool
+	}{
+		{
+			name:             "no match",
+			rules:            []Config{{Match: "foo:.*", Action: "drop"}},
+			low:              []string{"env:prod"},
+			standard:         []string{"env:prod"},
+			expectedLow:      []string{"env:prod"},
+			expectedStandard: []string{"env:prod"},
+		},
+		{
+			name:            "rename",
+			rules:           []Config{{Match: "kube_app_name:(.*)", Action: "rename", Target: "app:$1"}},
+			low:             []string{"kube_app_name:web", "env:prod"},
+			expectedLow:     []string{"app:web", "env:prod"},
+			expectedChanged: true,
+		},
+		{
+			name:            "derive with named capture",
+			rules:           []Config{{Match: "kube_namespace:(?P<team>[a-z]+)-.*", Action: "derive", Target: "team:${team}"}},
+			orch:            []string{"kube_namespace:payments-prod"},
+			expectedOrch:    []string{"kube_namespace:payments-prod", "team:payments"},
+			expectedChanged: true,
+		},
+		{
+			name:            "derive with cardinality",
+			rules:           []Config{{Match: "pod_name:(.*)-[a-z0-9]+", Action: "derive", Target: "pod_group:$1", Cardinality: "low"}},
+			orch:            []string{"pod_name:web-abc12"},
+			expectedLow:     []string{"pod_group:web"},
+			expectedOrch:    []string{"pod_name:web-abc12"},
+			expectedChanged: true,
+		},
+		{
+			name:             "drop standard tag",
+			rules:            []Config{{Match: "version:.*", Action: "drop"}},
+			low:              []string{"version:1.2", "env:prod"},
+			standard:         []string{"version:1.2", "env:prod"},
+			expectedLow:      []string{"env:prod"},
+			expectedStandard: []string{"env:prod"},
+			expectedChanged:  true,
+		},
+		{
+			name:            "set cardinality",
+			rules:           []Config{{Match: "container_id:.*", Action: "set_cardinality", Cardinality: "orchestrator"}},
+			high:            []string{"container_id:abc", "display_container_name:web"},
+			expectedOrch:    []string{"container_id:abc"},
+			expectedHigh:    []string{"display_container_name:web"},
+			expectedChanged: true,
+		},
+		{
+			name: "rules are chained and duplicates are kept at the lowest cardinality",
+			rules: []Config{
+				{Match: "kube_deployment:(.*)", Action: "derive", Target: "service:$1"},
+				{Match: "short_image:(.*)", Action: "rename", Target: "service:$1"},
+			},
+			low:             []string{"kube_deployment:web"},
+			high:            []string{"short_image:web"},
+			expectedLow:     []string{"kube_deployment:web", "service:web"},
+			expectedChanged: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs, err := New(test.rules)
+			require.NoError(t, err)
+
+			low, orch, high, standard, changed := rs.Apply(test.low, test.orch, test.high, test.standard)
+			assert.ElementsMatch(t, test.expectedLow, low)
+			assert.ElementsMatch(t, test.expectedOrch, orch)
+			assert.ElementsMatch(t, test.expectedHigh, high)
+			assert.ElementsMatch(t, test.expectedStandard, standard)
+			assert.Equal(t, test.expectedChanged, changed)
+		})
+	}
+}
+
+func TestApplyNilRuleSet(t *testing.T) {
+	var rs *RuleSet
+
+	low, _, _, _, changed := rs.Apply([]string{"env:prod"}, nil, nil, nil)
+	assert.Equal(t, []string{"env:prod"}, low)
+	assert.False(t, changed)
+}
@@ -15,6 +15,19 @@ type sourceTags struct {
 	highCardTags         []string
 	standardTags         []string
 	expiryDate           time.Time
+
+	// rawTags are the tags reported by the source before the tagger rules
+	// were applied. It is nil if no rule modified them.
+	rawTags []string
+}
+
+// allTags returns the tags of all cardinalities in a new slice
+func (st *sourceTags) allTags() []string {
+	tags := make([]string, 0, len(st.lowCardTags)+len(st.orchestratorCardTags)+len(st.highCardTags))
+	tags = append(tags, st.lowCardTags...)
+	tags = append(tags, st.orchestratorCardTags...)
+	tags = append(tags, st.highCardTags...)
+	return tags
 }
 
 func (st *sourceTags) isEmpty() bool {
@@ -16,6 +16,7 @@ import (
 
 	tagger_api "github.com/DataDog/datadog-agent/comp/core/tagger/api"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/rules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/subscriber"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/telemetry"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
@@ -44,6 +45,10 @@ type TagStore struct {
 
 	subscriber *subscriber.Subscriber
 
+	// rules transform the tags reported by collectors before they are
+	// stored. It can be nil.
+	rules *rules.RuleSet
+
 	clock clock.Clock
 }
 
@@ -52,6 +57,14 @@ func NewTagStore() *TagStore {
 	return newTagStoreWithClock(clock.New())
 }
 
+// NewTagStoreWithRules creates a new TagStore applying the given tag
+// transformation rules to the tags reported by collectors.
+func NewTagStoreWithRules(rs *rules.RuleSet) *TagStore {
+	s := newTagStoreWithClock(clock.New())
+	s.rules = rs
+	return s
+}
+
 func newTagStoreWithClock(clock clock.Clock) *TagStore {
 	return &TagStore{
 		telemetry:  make(map[string]map[string]float64),
@@ -129,6 +142,16 @@ func (s *TagStore) ProcessTagInfo(tagInfos []*collectors.TagInfo) {
 			expiryDate:           info.ExpiryDate,
 		}
 
+		if low, orch, high, standard, changed := s.rules.Apply(info.LowCardTags, info.OrchestratorCardTags, info.HighCardTags, info.StandardTags); changed {
+			// keep the tags reported by the collector to show them in
+			// `agent tagger-list`
+			newSt.rawTags = newSt.allTags()
+			newSt.lowCardTags = low
+			newSt.orchestratorCardTags = orch
+			newSt.highCardTags = high
+			newSt.standardTags = standard
+		}
+
 		eventType := types.EventTypeModified
 		if exist {
 			st, ok := storedTags.sourceTags[info.Source]
@@ -322,10 +345,14 @@ func (s *TagStore) List() tagger_api.TaggerListResponse {
 		}
 
 		for source, sourceTags := range et.sourceTags {
-			tags := append([]string(nil), sourceTags.lowCardTags...)
-			tags = append(tags, sourceTags.orchestratorCardTags...)
-			tags = append(tags, sourceTags.highCardTags...)
-			entity.Tags[source] = tags
+			entity.Tags[source] = sourceTags.allTags()
+
+			if sourceTags.rawTags != nil {
+				if entity.RawTags == nil {
+					entity.RawTags = make(map[string][]string)
+				}
+				entity.RawTags[source] = append([]string(nil), sourceTags.rawTags...)
+			}
 		}
 
 		r.Entities[entityID] = entity
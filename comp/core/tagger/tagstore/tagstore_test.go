@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/rules"
 	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
 )
 
@@ -247,6 +248,36 @@ func TestStoreSuite(t *testing.T) {
 	suite.Run(t, &StoreTestSuite{})
 }
 
+func TestProcessTagInfoWithRules(t *testing.T) {
+	rs, err := rules.New([]rules.Config{
+		{Match: "kube_namespace:(?P<team>[a-z]+)-.*", Action: "derive", Target: "team:${team}"},
+		{Match: "pod_phase:.*", Action: "drop"},
+	})
+	assert.NoError(t, err)
+
+	store := NewTagStoreWithRules(rs)
+	store.ProcessTagInfo([]*collectors.TagInfo{
+		{
+			Source:      "source1",
+			Entity:      "test",
+			LowCardTags: []string{"kube_namespace:payments-prod", "pod_phase:running"},
+		},
+		{
+			Source:      "source2",
+			Entity:      "test",
+			LowCardTags: []string{"image_name:redis"},
+		},
+	})
+
+	assert.ElementsMatch(t, []string{"kube_namespace:payments-prod", "team:payments", "image_name:redis"}, store.Lookup("test", collectors.LowCardinality))
+
+	list := store.List()
+	entity := list.Entities["test"]
+	assert.ElementsMatch(t, []string{"kube_namespace:payments-prod", "team:payments"}, entity.Tags["source1"])
+	assert.ElementsMatch(t, []string{"kube_namespace:payments-prod", "pod_phase:running"}, entity.RawTags["source1"])
+	assert.NotContains(t, entity.RawTags, "source2")
+}
+
 func TestGetEntityTags(t *testing.T) {
 	etags := newEntityTags("deadbeef")
 
@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/podman"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/processcollector"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/remote/workloadmeta"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/collectors/internal/systemd"
 )
 
 // GetCatalog returns the set of FX options to populate the catalog
@@ -42,6 +43,7 @@ func GetCatalog() fx.Option {
 		workloadmeta.GetFxOptions(),
 		processcollector.GetFxOptions(),
 		host.GetFxOptions(),
+		systemd.GetFxOptions(),
 	}
 
 	// remove nil options
@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+
+// Package systemd implements the systemd Workloadmeta collector.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	dderrors "github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	systemdutil "github.com/DataDog/datadog-agent/pkg/util/systemd"
+)
+
+const (
+	collectorID   = "systemd"
+	componentName = "workloadmeta-systemd"
+
+	defaultPrivateSocket = "/run/systemd/private"
+	typeService          = "Service"
+)
+
+// serviceLabels maps the properties of service units reported as labels to
+// their label name.
+var serviceLabels = map[string]string{
+	"Slice": "slice",
+	"User":  "user",
+	"Group": "group",
+}
+
+// dbusClient is the subset of the systemd D-Bus API used by the collector
+type dbusClient interface {
+	ListUnitsByPatternsContext(ctx context.Context, states []string, patterns []string) ([]dbus.UnitStatus, error)
+	GetUnitTypePropertiesContext(ctx context.Context, unit string, unitType string) (map[string]interface{}, error)
+}
+
+type dependencies struct {
+	fx.In
+
+	Config config.Component
+}
+
+type collector struct {
+	store    workloadmeta.Component
+	catalog  workloadmeta.AgentType
+	config   config.Component
+	client   dbusClient
+	patterns []string
+	seen     map[workloadmeta.EntityID]struct{}
+}
+
+// NewCollector returns a new systemd collector provider and an error
+func NewCollector(deps dependencies) (workloadmeta.CollectorProvider, error) {
+	return workloadmeta.CollectorProvider{
+		Collector: &collector{
+			catalog: workloadmeta.NodeAgent | workloadmeta.ProcessAgent,
+			config:  deps.Config,
+			seen:    make(map[workloadmeta.EntityID]struct{}),
+		},
+	}, nil
+}
+
+// GetFxOptions returns the FX framework options for the collector
+func GetFxOptions() fx.Option {
+	return fx.Provide(NewCollector)
+}
+
+// Start the collector for the provided workloadmeta component
+func (c *collector) Start(ctx context.Context, store workloadmeta.Component) error {
+	if !c.config.GetBool("workloadmeta.systemd_collector.enabled") {
+		return dderrors.NewDisabled(componentName, "systemd collector is disabled")
+	}
+
+	client, err := c.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to connect to systemd: %w", err)
+	}
+
+	c.client = client
+	c.store = store
+	c.patterns = c.config.GetStringSlice("workloadmeta.systemd_collector.units")
+
+	return nil
+}
+
+// connect returns a D-Bus connection to systemd, using its private socket
+// when running in a container or when the system bus isn't available.
+func (c *collector) connect(ctx context.Context) (*dbus.Conn, error) {
+	privateSocket := c.config.GetString("workloadmeta.systemd_collector.private_socket")
+	if privateSocket != "" {
+		return systemdutil.NewSystemdConnection(privateSocket)
+	}
+
+	if pkgconfig.IsContainerized() {
+		return systemdutil.NewSystemdConnection("/host" + defaultPrivateSocket)
+	}
+
+	conn, err := dbus.NewSystemConnectionContext(ctx)
+	if err != nil {
+		log.Debugf("Unable to connect to the system bus, falling back to the systemd private socket: %s", err)
+		return systemdutil.NewSystemdConnection(defaultPrivateSocket)
+	}
+
+	return conn, nil
+}
+
+func (c *collector) Pull(ctx context.Context) error {
+	units, err := c.client.ListUnitsByPatternsContext(ctx, nil, c.patterns)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[workloadmeta.EntityID]struct{}, len(units))
+	events := make([]workloadmeta.CollectorEvent, 0, len(units))
+
+	for _, unit := range units {
+		if unit.LoadState != "loaded" || unit.ActiveState == "inactive" {
+			continue
+		}
+
+		var properties map[string]interface{}
+		if strings.HasSuffix(unit.Name, ".service") {
+			properties, err = c.client.GetUnitTypePropertiesContext(ctx, unit.Name, typeService)
+			if err != nil {
+				log.Debugf("Unable to get the properties of unit %s: %s", unit.Name, err)
+				continue
+			}
+		}
+
+		entity := buildUnit(unit, properties, containers.EnvVarFilterFromConfig())
+		seen[entity.EntityID] = struct{}{}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceSystemd,
+			Entity: entity,
+		})
+	}
+
+	for seenID := range c.seen {
+		if _, ok := seen[seenID]; ok {
+			continue
+		}
+
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceSystemd,
+			Entity: &workloadmeta.SystemdUnit{
+				EntityID: seenID,
+			},
+		})
+	}
+
+	c.seen = seen
+
+	c.store.Notify(events)
+
+	return nil
+}
+
+func (c *collector) GetID() string {
+	return collectorID
+}
+
+func (c *collector) GetTargetCatalog() workloadmeta.AgentType {
+	return c.catalog
+}
+
+// buildUnit converts a unit and the properties of its type into a
+// workloadmeta entity.
+func buildUnit(unit dbus.UnitStatus, properties map[string]interface{}, envFilter containers.EnvFilter) *workloadmeta.SystemdUnit {
+	entity := &workloadmeta.SystemdUnit{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindSystemdUnit,
+			ID:   unit.Name,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:   unit.Name,
+			Labels: make(map[string]string),
+		},
+		Description: unit.Description,
+		ActiveState: unit.ActiveState,
+		SubState:    unit.SubState,
+		EnvVars:     make(map[string]string),
+	}
+
+	if pid, ok := properties["MainPID"].(uint32); ok {
+		entity.MainPID = int32(pid)
+	}
+
+	if cgroup, ok := properties["ControlGroup"].(string); ok {
+		entity.ControlGroup = cgroup
+	}
+
+	for property, label := range serviceLabels {
+		if value, ok := properties[property].(string); ok && value != "" {
+			entity.Labels[label] = value
+		}
+	}
+
+	if environment, ok := properties["Environment"].([]string); ok {
+		for _, env := range environment {
+			name, value, found := strings.Cut(env, "=")
+			if !found || !envFilter.IsIncluded(name) {
+				continue
+			}
+			entity.EnvVars[name] = value
+		}
+	}
+
+	return entity
+}
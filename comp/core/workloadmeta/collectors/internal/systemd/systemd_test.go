@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd && test
+
+package systemd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+type fakeDBusClient struct {
+	units      []dbus.UnitStatus
+	properties map[string]map[string]interface{}
+}
+
+func (c *fakeDBusClient) ListUnitsByPatternsContext(_ context.Context, _ []string, _ []string) ([]dbus.UnitStatus, error) {
+	return c.units, nil
+}
+
+func (c *fakeDBusClient) GetUnitTypePropertiesContext(_ context.Context, unit string, _ string) (map[string]interface{}, error) {
+	return c.properties[unit], nil
+}
+
+func TestBuildUnit(t *testing.T) {
+	unit := dbus.UnitStatus{
+		Name:        "nginx.service",
+		Description: "A high performance web server",
+		LoadState:   "loaded",
+		ActiveState: "active",
+		SubState:    "running",
+	}
+	properties := map[string]interface{}{
+		"MainPID":      uint32(1234),
+		"ControlGroup": "/system.slice/nginx.service",
+		"Slice":        "system.slice",
+		"User":         "www-data",
+		"Group":        "",
+		"Environment":  []string{"DD_SERVICE=web", "DD_ENV=prod", "SECRET=hunter2", "INVALID"},
+	}
+
+	entity := buildUnit(unit, properties, containers.EnvVarFilterFromConfig())
+
+	assert.Equal(t, &workloadmeta.SystemdUnit{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindSystemdUnit,
+			ID:   "nginx.service",
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: "nginx.service",
+			Labels: map[string]string{
+				"slice": "system.slice",
+				"user":  "www-data",
+			},
+		},
+		Description:  "A high performance web server",
+		ActiveState:  "active",
+		SubState:     "running",
+		MainPID:      1234,
+		ControlGroup: "/system.slice/nginx.service",
+		EnvVars: map[string]string{
+			"DD_SERVICE": "web",
+			"DD_ENV":     "prod",
+		},
+	}, entity)
+}
+
+type testDeps struct {
+	fx.In
+
+	Config config.Component
+	Wml    workloadmeta.Mock
+}
+
+func TestPull(t *testing.T) {
+	deps := fxutil.Test[testDeps](t, fx.Options(
+		core.MockBundle(),
+		fx.Supply(workloadmeta.NewParams()),
+		fx.Supply(context.Background()),
+		workloadmeta.MockModule(),
+	))
+
+	client := &fakeDBusClient{
+		units: []dbus.UnitStatus{
+			{Name: "nginx.service", LoadState: "loaded", ActiveState: "active"},
+			{Name: "redis.service", LoadState: "loaded", ActiveState: "active"},
+			{Name: "stopped.service", LoadState: "loaded", ActiveState: "inactive"},
+			{Name: "missing.service", LoadState: "not-found", ActiveState: "active"},
+		},
+		properties: map[string]map[string]interface{}{
+			"nginx.service": {"MainPID": uint32(10)},
+			"redis.service": {"MainPID": uint32(20)},
+		},
+	}
+
+	c := &collector{
+		store:  deps.Wml,
+		config: deps.Config,
+		client: client,
+		seen:   make(map[workloadmeta.EntityID]struct{}),
+	}
+
+	ctx := context.Background()
+	require.NoError(t, c.Pull(ctx))
+
+	events := deps.Wml.GetNotifiedEvents()
+	require.Len(t, events, 2)
+	for _, event := range events {
+		assert.Equal(t, workloadmeta.EventTypeSet, event.Type)
+		assert.Equal(t, workloadmeta.SourceSystemd, event.Source)
+	}
+
+	// redis is stopped and gets unset
+	client.units = client.units[:1]
+	require.NoError(t, c.Pull(ctx))
+
+	events = deps.Wml.GetNotifiedEvents()[2:]
+	require.Len(t, events, 2)
+	assert.Equal(t, workloadmeta.EventTypeSet, events[0].Type)
+	assert.Equal(t, "nginx.service", events[0].Entity.GetID().ID)
+	assert.Equal(t, workloadmeta.EventTypeUnset, events[1].Type)
+	assert.Equal(t, "redis.service", events[1].Entity.GetID().ID)
+}
@@ -285,6 +285,28 @@ func (w *workloadmeta) ListProcessesWithFilter(filter ProcessFilterFunc) []*Proc
 	return processes
 }
 
+// GetSystemdUnit implements Store#GetSystemdUnit.
+func (w *workloadmeta) GetSystemdUnit(name string) (*SystemdUnit, error) {
+	entity, err := w.getEntityByKind(KindSystemdUnit, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*SystemdUnit), nil
+}
+
+// ListSystemdUnits implements Store#ListSystemdUnits.
+func (w *workloadmeta) ListSystemdUnits() []*SystemdUnit {
+	entities := w.listEntitiesByKind(KindSystemdUnit)
+
+	units := make([]*SystemdUnit, 0, len(entities))
+	for i := range entities {
+		units = append(units, entities[i].(*SystemdUnit))
+	}
+
+	return units
+}
+
 // GetKubernetesPodForContainer implements Store#GetKubernetesPodForContainer
 func (w *workloadmeta) GetKubernetesPodForContainer(containerID string) (*KubernetesPod, error) {
 	w.storeMut.RLock()
@@ -46,6 +46,7 @@ const (
 	KindContainerImageMetadata Kind = "container_image_metadata"
 	KindProcess                Kind = "process"
 	KindHost                   Kind = "host"
+	KindSystemdUnit            Kind = "systemd_unit"
 )
 
 // Source is the source name of an entity.
@@ -86,6 +87,10 @@ const (
 
 	// SourceHost represents entities detected by the host such as host tags.
 	SourceHost Source = "host"
+
+	// SourceSystemd represents entities detected by querying systemd, such
+	// as the units running on a host.
+	SourceSystemd Source = "systemd"
 )
 
 // ContainerRuntime is the container runtime used by a container.
@@ -1215,6 +1220,69 @@ func (p HostTags) String(verbose bool) string {
 	return sb.String()
 }
 
+// SystemdUnit is an Entity that represents a unit managed by systemd, such
+// as a service running directly on a host.
+type SystemdUnit struct {
+	EntityID // EntityID.ID is the unit name, e.g. nginx.service
+	EntityMeta
+
+	Description  string
+	ActiveState  string
+	SubState     string
+	MainPID      int32
+	ControlGroup string
+	// EnvVars are the `Environment=` variables of the unit, limited to
+	// variables included in pkg/util/containers/env_vars_filter.go
+	EnvVars map[string]string
+}
+
+var _ Entity = &SystemdUnit{}
+
+// GetID implements Entity#GetID.
+func (u SystemdUnit) GetID() EntityID {
+	return u.EntityID
+}
+
+// DeepCopy implements Entity#DeepCopy.
+func (u SystemdUnit) DeepCopy() Entity {
+	cp := deepcopy.Copy(u).(SystemdUnit)
+	return &cp
+}
+
+// Merge implements Entity#Merge.
+func (u *SystemdUnit) Merge(e Entity) error {
+	otherUnit, ok := e.(*SystemdUnit)
+	if !ok {
+		return fmt.Errorf("cannot merge SystemdUnit with different kind %T", e)
+	}
+
+	return merge(u, otherUnit)
+}
+
+// String implements Entity#String.
+func (u SystemdUnit) String(verbose bool) string {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity ID -----------")
+	_, _ = fmt.Fprint(&sb, u.EntityID.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Entity Meta -----------")
+	_, _ = fmt.Fprint(&sb, u.EntityMeta.String(verbose))
+
+	_, _ = fmt.Fprintln(&sb, "----------- Unit Info -----------")
+	_, _ = fmt.Fprintln(&sb, "Description:", u.Description)
+	_, _ = fmt.Fprintln(&sb, "Active State:", u.ActiveState)
+	_, _ = fmt.Fprintln(&sb, "Sub State:", u.SubState)
+	_, _ = fmt.Fprintln(&sb, "Main PID:", u.MainPID)
+
+	if verbose {
+		_, _ = fmt.Fprintln(&sb, "Control Group:", u.ControlGroup)
+		_, _ = fmt.Fprintln(&sb, "Allowed env variables:", filterAndFormatEnvVars(u.EnvVars))
+	}
+
+	return sb.String()
+}
+
 // CollectorEvent is an event generated by a metadata collector, to be handled
 // by the metadata store.
 type CollectorEvent struct {
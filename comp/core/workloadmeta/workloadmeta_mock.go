@@ -119,6 +119,28 @@ func (w *workloadMetaMock) ListProcessesWithFilter(filter ProcessFilterFunc) []*
 	return res
 }
 
+// GetSystemdUnit implements workloadMetaMock#GetSystemdUnit.
+func (w *workloadMetaMock) GetSystemdUnit(name string) (*SystemdUnit, error) {
+	entity, err := w.getEntityByKind(KindSystemdUnit, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.(*SystemdUnit), nil
+}
+
+// ListSystemdUnits implements workloadMetaMock#ListSystemdUnits.
+func (w *workloadMetaMock) ListSystemdUnits() []*SystemdUnit {
+	entities := w.listEntitiesByKind(KindSystemdUnit)
+
+	units := make([]*SystemdUnit, 0, len(entities))
+	for i := range entities {
+		units = append(units, entities[i].(*SystemdUnit))
+	}
+
+	return units
+}
+
 // GetKubernetesPod returns metadata about a Kubernetes pod.
 func (w *workloadMetaMock) GetKubernetesPod(id string) (*KubernetesPod, error) {
 	entity, err := w.getEntityByKind(KindKubernetesPod, id)
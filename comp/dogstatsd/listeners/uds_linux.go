@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
+	"github.com/DataDog/datadog-agent/pkg/util/systemd"
 )
 
 const (
@@ -122,8 +123,9 @@ func getEntityForPID(pid int32, capture bool, wmeta optional.Option[workloadmeta
 	}
 }
 
-// entityForPID returns the entity ID for a given PID. It can return
-// errNoContainerMatch if no match is found for the PID.
+// entityForPID returns the entity ID for a given PID, either the container or
+// the systemd unit it belongs to. It can return errNoContainerMatch if no
+// match is found for the PID.
 func entityForPID(pid int32, capture bool, wmeta optional.Option[workloadmeta.Component], state pidmap.Component) (string, error) {
 	if capture {
 		return state.ContainerIDForPID(pid)
@@ -134,8 +136,30 @@ func entityForPID(pid int32, capture bool, wmeta optional.Option[workloadmeta.Co
 		return "", err
 	}
 	if cID == "" {
-		return "", errNoContainerMatch
+		return entityForSystemdUnit(pid, wmeta)
 	}
 
 	return containers.BuildTaggerEntityName(cID), nil
 }
+
+// entityForSystemdUnit returns the entity ID of the systemd unit a process
+// outside of any container belongs to. It returns errNoContainerMatch if the
+// unit isn't known by workloadmeta, e.g. when the systemd collector is
+// disabled.
+func entityForSystemdUnit(pid int32, wmeta optional.Option[workloadmeta.Component]) (string, error) {
+	store, ok := wmeta.Get()
+	if !ok {
+		return "", errNoContainerMatch
+	}
+
+	unit, err := systemd.UnitForPID(int(pid))
+	if err != nil || unit == "" {
+		return "", errNoContainerMatch
+	}
+
+	if _, err := store.GetSystemdUnit(unit); err != nil {
+		return "", errNoContainerMatch
+	}
+
+	return systemd.BuildTaggerEntityName(unit), nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
 	clientComp "github.com/DataDog/datadog-agent/comp/languagedetection/client"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/servicemap"
 	"github.com/DataDog/datadog-agent/pkg/languagedetection/languagemodels"
 	pbgo "github.com/DataDog/datadog-agent/pkg/proto/pbgo/process"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
@@ -42,6 +43,10 @@ func (m *MockDCAClient) PostLanguageMetadata(_ context.Context, request *pbgo.Pa
 	return nil
 }
 
+func (m *MockDCAClient) PostConnectionSummaries(_ context.Context, _ *servicemap.ConnectionSummaries) error {
+	return nil
+}
+
 func newTestClient(t *testing.T) (*client, chan *pbgo.ParentLanguageAnnotationRequest) {
 	respCh := make(chan *pbgo.ParentLanguageAnnotationRequest)
 	mockDCAClient := &MockDCAClient{respCh: respCh}
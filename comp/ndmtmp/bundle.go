@@ -4,12 +4,14 @@
 // Copyright 2023-present Datadog, Inc.
 
 // Package ndmtmp implements the "ndmtmp" bundle, which exposes the default
-// sender.Sender and the event platform forwarder. This is a temporary module
-// intended for ndm internal use until these pieces are properly componentized.
+// sender.Sender, the event platform forwarder and the store of the interfaces
+// reported by the SNMP check. This is a temporary module intended for ndm
+// internal use until these pieces are properly componentized.
 package ndmtmp
 
 import (
 	"github.com/DataDog/datadog-agent/comp/ndmtmp/forwarder/forwarderimpl"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore/interfacestoreimpl"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -20,5 +22,6 @@ import (
 // Bundle defines the fx options for this bundle.
 func Bundle() fxutil.BundleOptions {
 	return fxutil.Bundle(
-		forwarderimpl.Module())
+		forwarderimpl.Module(),
+		interfacestoreimpl.Module())
 }
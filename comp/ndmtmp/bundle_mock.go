@@ -4,8 +4,9 @@
 // Copyright 2023-present Datadog, Inc.
 
 // Package ndmtmp implements the "ndmtmp" bundle, which exposes the default
-// sender.Sender and the event platform forwarder. This is a temporary module
-// intended for ndm internal use until these pieces are properly componentized.
+// sender.Sender, the event platform forwarder and the store of the interfaces
+// reported by the SNMP check. This is a temporary module intended for ndm
+// internal use until these pieces are properly componentized.
 
 //go:build test
 
@@ -13,6 +14,7 @@ package ndmtmp
 
 import (
 	"github.com/DataDog/datadog-agent/comp/ndmtmp/forwarder/forwarderimpl"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore/interfacestoreimpl"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -21,5 +23,6 @@ import (
 // MockBundle defines the fx options for mock versions of everything in this bundle.
 func MockBundle() fxutil.BundleOptions {
 	return fxutil.Bundle(
-		forwarderimpl.MockModule())
+		forwarderimpl.MockModule(),
+		interfacestoreimpl.Module())
 }
@@ -0,0 +1,22 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package interfacestore exposes the metadata of the interfaces reported by
+// the SNMP check to the other components, such as netflow.
+package interfacestore
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
+)
+
+// team: network-device-monitoring
+
+// Component is the component type.
+type Component interface {
+	// SetDeviceInterfaces replaces the interfaces of a device, by interface index
+	SetDeviceInterfaces(deviceID string, interfaces map[uint32]interfacestore.Interface)
+	// GetInterface returns the interface of a device with the given index
+	GetInterface(deviceID string, index uint32) (interfacestore.Interface, bool)
+}
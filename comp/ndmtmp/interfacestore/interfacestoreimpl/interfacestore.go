@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package interfacestoreimpl provides the store of the interfaces reported by
+// the SNMP check.
+package interfacestoreimpl
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
+	store "github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// Module defines the fx options for this component.
+func Module() fxutil.Module {
+	return fxutil.Component(
+		fx.Provide(newInterfaceStore))
+}
+
+func newInterfaceStore() interfacestore.Component {
+	return store.NewStore(store.DefaultTTL)
+}
@@ -21,6 +21,9 @@ const (
 	// DefaultAggregatorRollupTrackerRefreshInterval is the default aggregator rollup tracker refresh interval
 	DefaultAggregatorRollupTrackerRefreshInterval = 300 // 5min
 
+	// DefaultTopTalkersCount is the default number of top talkers, conversations and ports submitted per exporter
+	DefaultTopTalkersCount = 10
+
 	// DefaultBindHost is the default bind host used for flow listeners
 	DefaultBindHost = "0.0.0.0"
 
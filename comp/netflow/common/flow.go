@@ -70,6 +70,28 @@ type Flow struct {
 
 	// Configured fields
 	AdditionalFields AdditionalFields
+
+	// Enrichment, derived from the fields above before aggregation
+	SrcGeo              *GeoLocation
+	DstGeo              *GeoLocation
+	InputInterfaceInfo  *InterfaceInfo
+	OutputInterfaceInfo *InterfaceInfo
+}
+
+// GeoLocation contains the location and autonomous system of an IP address
+type GeoLocation struct {
+	ContinentCode  string
+	CountryISOCode string
+	City           string
+	ASNumber       uint32
+	ASOrganization string
+}
+
+// InterfaceInfo contains the metadata of an interface of the exporter
+type InterfaceInfo struct {
+	Name  string
+	Alias string
+	Speed uint64 // in bits per second
 }
 
 // AdditionalFields holds additional fields collected
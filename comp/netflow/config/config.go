@@ -8,6 +8,8 @@ package config
 
 import (
 	"fmt"
+	"net"
+
 	"github.com/DataDog/datadog-agent/comp/core/log"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
@@ -33,6 +35,42 @@ type NetflowConfig struct {
 
 	PrometheusListenerAddress string `mapstructure:"prometheus_listener_address"` // Example `localhost:9090`
 	PrometheusListenerEnabled bool   `mapstructure:"prometheus_listener_enabled"`
+
+	Enrichment            EnrichmentConfig            `mapstructure:"enrichment"`
+	SamplingNormalization SamplingNormalizationConfig `mapstructure:"sampling_normalization"`
+	TopTalkers            TopTalkersConfig            `mapstructure:"top_talkers"`
+}
+
+// EnrichmentConfig contains configuration for the enrichment of the flows
+type EnrichmentConfig struct {
+	// GeoIPDatabasePath is the path of a GeoIP2 or GeoLite2 City or Country MMDB database
+	GeoIPDatabasePath string `mapstructure:"geoip_database_path"`
+	// ASNDatabasePath is the path of a GeoIP2 ISP or GeoLite2 ASN MMDB database
+	ASNDatabasePath string `mapstructure:"asn_database_path"`
+	// InterfaceMetadataDisabled disables the lookup of the interfaces reported by the SNMP check
+	InterfaceMetadataDisabled bool `mapstructure:"interface_metadata_disabled"`
+}
+
+// SamplingNormalizationConfig contains configuration for the scaling of the
+// bytes and packets of sampled flows
+type SamplingNormalizationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Overrides take precedence over the sampling rate reported by the exporters
+	Overrides []SamplingRateOverride `mapstructure:"overrides"`
+}
+
+// SamplingRateOverride contains the sampling rate of an exporter
+type SamplingRateOverride struct {
+	ExporterIP   string `mapstructure:"exporter_ip"`
+	Namespace    string `mapstructure:"namespace"`
+	SamplingRate uint64 `mapstructure:"sampling_rate"`
+}
+
+// TopTalkersConfig contains configuration for the top talkers, conversations
+// and ports metrics submitted for each exporter on flush
+type TopTalkersConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Count   int  `mapstructure:"count"`
 }
 
 // ListenerConfig contains configuration for a single flow listener
@@ -47,12 +85,26 @@ type ListenerConfig struct {
 
 // Mapping contains configuration for a Netflow/IPFIX field mapping
 type Mapping struct {
+	// Enterprise is the IPFIX private enterprise number of the field, zero
+	// for the fields defined by IANA
+	Enterprise  uint32            `mapstructure:"enterprise"`
 	Field       uint16            `mapstructure:"field"`
 	Destination string            `mapstructure:"destination"`
 	Endian      common.EndianType `mapstructure:"endianness"`
 	Type        common.FieldType  `mapstructure:"type"`
 }
 
+// MappingKey identifies the Netflow/IPFIX field of a mapping
+type MappingKey struct {
+	Enterprise uint32
+	Field      uint16
+}
+
+// Key returns the key of the field of the mapping
+func (m Mapping) Key() MappingKey {
+	return MappingKey{Enterprise: m.Enterprise, Field: m.Field}
+}
+
 // ReadConfig builds and returns configuration from Agent configuration.
 func ReadConfig(conf config.Component, logger log.Component) (*NetflowConfig, error) {
 	var mainConfig NetflowConfig
@@ -110,6 +162,30 @@ func (mainConfig *NetflowConfig) SetDefaults(namespace string, logger log.Compon
 		}
 	}
 
+	for i := range mainConfig.SamplingNormalization.Overrides {
+		override := &mainConfig.SamplingNormalization.Overrides[i]
+
+		exporterIP := net.ParseIP(override.ExporterIP)
+		if exporterIP == nil {
+			return fmt.Errorf("invalid exporter_ip `%s` for sampling rate override", override.ExporterIP)
+		}
+		override.ExporterIP = exporterIP.String()
+		if override.SamplingRate == 0 {
+			return fmt.Errorf("the sampling rate override for exporter `%s` must be greater than 0", override.ExporterIP)
+		}
+		if override.Namespace == "" {
+			override.Namespace = namespace
+		}
+		normalizedNamespace, err := utils.NormalizeNamespace(override.Namespace)
+		if err != nil {
+			return fmt.Errorf("invalid namespace `%s` error: %s", override.Namespace, err)
+		}
+		override.Namespace = normalizedNamespace
+	}
+	if mainConfig.TopTalkers.Enabled && mainConfig.TopTalkers.Count == 0 {
+		mainConfig.TopTalkers.Count = common.DefaultTopTalkersCount
+	}
+
 	if mainConfig.StopTimeout == 0 {
 		mainConfig.StopTimeout = common.DefaultStopTimeout
 	}
@@ -211,6 +211,110 @@ network_devices:
 				},
 			},
 		},
+		{
+			name: "enterprise field mapping and enrichment",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    listeners:
+      - flow_type: ipfix
+        mapping:
+          - enterprise: 9
+            field: 12235
+            destination: application_name
+            type: string
+    enrichment:
+      geoip_database_path: /opt/geoip/GeoLite2-City.mmdb
+      asn_database_path: /opt/geoip/GeoLite2-ASN.mmdb
+      interface_metadata_disabled: true
+`,
+			expectedConfig: NetflowConfig{
+				Enabled:                                true,
+				StopTimeout:                            5,
+				AggregatorBufferSize:                   10000,
+				AggregatorFlushInterval:                300,
+				AggregatorFlowContextTTL:               300,
+				AggregatorPortRollupThreshold:          10,
+				AggregatorRollupTrackerRefreshInterval: 300,
+				PrometheusListenerAddress:              "localhost:9090",
+				Listeners: []ListenerConfig{
+					{
+						FlowType:  common.TypeIPFIX,
+						BindHost:  "0.0.0.0",
+						Port:      uint16(4739),
+						Workers:   1,
+						Namespace: "default",
+						Mapping: []Mapping{
+							{
+								Enterprise:  9,
+								Field:       12235,
+								Destination: "application_name",
+								Type:        "string",
+							},
+						},
+					},
+				},
+				Enrichment: EnrichmentConfig{
+					GeoIPDatabasePath:         "/opt/geoip/GeoLite2-City.mmdb",
+					ASNDatabasePath:           "/opt/geoip/GeoLite2-ASN.mmdb",
+					InterfaceMetadataDisabled: true,
+				},
+			},
+		},
+		{
+			name: "sampling normalization and top talkers",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    sampling_normalization:
+      enabled: true
+      overrides:
+        - exporter_ip: 10.0.0.1
+          sampling_rate: 1000
+        - exporter_ip: 2001:db8::1
+          namespace: my-ns
+          sampling_rate: 512
+    top_talkers:
+      enabled: true
+`,
+			expectedConfig: NetflowConfig{
+				Enabled:                                true,
+				StopTimeout:                            5,
+				AggregatorBufferSize:                   10000,
+				AggregatorFlushInterval:                300,
+				AggregatorFlowContextTTL:               300,
+				AggregatorPortRollupThreshold:          10,
+				AggregatorRollupTrackerRefreshInterval: 300,
+				PrometheusListenerAddress:              "localhost:9090",
+				SamplingNormalization: SamplingNormalizationConfig{
+					Enabled: true,
+					Overrides: []SamplingRateOverride{
+						{ExporterIP: "10.0.0.1", Namespace: "default", SamplingRate: 1000},
+						{ExporterIP: "2001:db8::1", Namespace: "my-ns", SamplingRate: 512},
+					},
+				},
+				TopTalkers: TopTalkersConfig{
+					Enabled: true,
+					Count:   10,
+				},
+			},
+		},
+		{
+			name: "invalid sampling rate override",
+			configYaml: `
+network_devices:
+  netflow:
+    enabled: true
+    sampling_normalization:
+      enabled: true
+      overrides:
+        - exporter_ip: not-an-ip
+          sampling_rate: 1000
+`,
+			expectedError: "invalid exporter_ip `not-an-ip` for sampling rate override",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package enrichment adds to the flows the data which isn't exported by the
+// devices, before the flows are aggregated.
+package enrichment
+
+import (
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/format"
+)
+
+// Enricher adds the GeoIP location and autonomous system of the endpoints,
+// and the metadata of the interfaces reported by the SNMP check, to the flows
+type Enricher struct {
+	geoIP      *geoIPDatabases
+	interfaces interfacestore.Component
+}
+
+// NewEnricher returns an enricher for the given config, reading the interfaces
+// from the given store. The enrichments which can't be set up are logged and
+// skipped, they don't prevent collecting flows.
+func NewEnricher(conf config.EnrichmentConfig, interfaces interfacestore.Component, logger log.Component) *Enricher {
+	e := &Enricher{}
+	if conf.GeoIPDatabasePath != "" || conf.ASNDatabasePath != "" {
+		geoIP, err := openGeoIPDatabases(conf.GeoIPDatabasePath, conf.ASNDatabasePath)
+		if err != nil {
+			logger.Errorf("Flows won't be enriched with GeoIP data: %s", err)
+		} else {
+			e.geoIP = geoIP
+		}
+	}
+	if !conf.InterfaceMetadataDisabled {
+		e.interfaces = interfaces
+	}
+	return e
+}
+
+// Enrich sets the enrichment fields of a flow
+func (e *Enricher) Enrich(flow *common.Flow) {
+	if e == nil {
+		return
+	}
+	if e.geoIP != nil {
+		flow.SrcGeo = e.geoIP.lookup(flow.SrcAddr)
+		flow.DstGeo = e.geoIP.lookup(flow.DstAddr)
+	}
+	if e.interfaces != nil {
+		// the SNMP check identifies the devices by namespace and IP
+		deviceID := flow.Namespace + ":" + format.IPAddr(flow.ExporterAddr)
+		flow.InputInterfaceInfo = e.interfaceInfo(deviceID, flow.InputInterface)
+		flow.OutputInterfaceInfo = e.interfaceInfo(deviceID, flow.OutputInterface)
+	}
+}
+
+func (e *Enricher) interfaceInfo(deviceID string, index uint32) *common.InterfaceInfo {
+	iface, ok := e.interfaces.GetInterface(deviceID, index)
+	if !ok {
+		return nil
+	}
+	return &common.InterfaceInfo{
+		Name:  iface.Name,
+		Alias: iface.Alias,
+		Speed: iface.Speed,
+	}
+}
+
+// Close releases the databases of the enricher
+func (e *Enricher) Close() {
+	if e != nil && e.geoIP != nil {
+		e.geoIP.close()
+	}
+}
@@ -0,0 +1,264 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package enrichment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+)
+
+// writeMMDB writes an IPv4 MMDB database with 24 bits records containing the
+// given records by network
+func writeMMDB(t *testing.T, dbType string, records map[string]map[string]any) string {
+	// nodes of the search tree, a child is 0 when empty, the index of a
+	// node when positive, and -(index of the data+1) when negative
+	nodes := [][2]int{{0, 0}}
+	var data []map[string]any
+	networks := make([]string, 0, len(records))
+	for network := range records {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		require.NoError(t, err)
+		ones, _ := ipNet.Mask.Size()
+		ip := ipNet.IP.To4()
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == ones-1 {
+				data = append(data, records[network])
+				nodes[node][bit] = -len(data)
+				break
+			}
+			if nodes[node][bit] <= 0 {
+				nodes = append(nodes, [2]int{0, 0})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	var dataSection bytes.Buffer
+	offsets := make([]int, len(data))
+	for i, record := range data {
+		offsets[i] = dataSection.Len()
+		encodeMMDBValue(&dataSection, record)
+	}
+
+	var db bytes.Buffer
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		for _, child := range node {
+			record := nodeCount
+			if child > 0 {
+				record = child
+			} else if child < 0 {
+				record = nodeCount + 16 + offsets[-child-1]
+			}
+			db.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	db.Write(make([]byte, 16))
+	db.Write(dataSection.Bytes())
+	db.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeMMDBValue(&db, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               dbType,
+		"description":                 map[string]any{"en": "test database"},
+		"ip_version":                  uint16(4),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	})
+
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	require.NoError(t, os.WriteFile(path, db.Bytes(), 0o600))
+	return path
+}
+
+// encodeMMDBControl writes the control byte of a value, the sizes being
+// limited to 284 bytes
+func encodeMMDBControl(buf *bytes.Buffer, typ int, size int) {
+	sizeBits := size
+	if size >= 29 {
+		sizeBits = 29
+	}
+	if typ <= 7 {
+		buf.WriteByte(byte(typ<<5 | sizeBits))
+	} else {
+		// extended type
+		buf.WriteByte(byte(sizeBits))
+		buf.WriteByte(byte(typ - 7))
+	}
+	if size >= 29 {
+		buf.WriteByte(byte(size - 29))
+	}
+}
+
+func encodeMMDBUint(buf *bytes.Buffer, typ int, value uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], value)
+	trimmed := bytes.TrimLeft(b[:], "\x00")
+	encodeMMDBControl(buf, typ, len(trimmed))
+	buf.Write(trimmed)
+}
+
+func encodeMMDBValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case string:
+		encodeMMDBControl(buf, 2, len(v))
+		buf.WriteString(v)
+	case uint16:
+		encodeMMDBUint(buf, 5, uint64(v))
+	case uint32:
+		encodeMMDBUint(buf, 6, uint64(v))
+	case uint64:
+		encodeMMDBUint(buf, 9, v)
+	case []any:
+		encodeMMDBControl(buf, 11, len(v))
+		for _, item := range v {
+			encodeMMDBValue(buf, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		encodeMMDBControl(buf, 7, len(v))
+		for _, key := range keys {
+			encodeMMDBValue(buf, key)
+			encodeMMDBValue(buf, v[key])
+		}
+	default:
+		panic("unsupported MMDB value")
+	}
+}
+
+func testEnrichmentConfig(t *testing.T) config.EnrichmentConfig {
+	return config.EnrichmentConfig{
+		GeoIPDatabasePath: writeMMDB(t, "GeoLite2-City", map[string]map[string]any{
+			"203.0.113.0/24": {
+				"continent": map[string]any{"code": "EU"},
+				"country":   map[string]any{"iso_code": "FR"},
+				"city":      map[string]any{"names": map[string]any{"en": "Paris", "fr": "Paris"}},
+			},
+			"198.51.100.0/25": {
+				"continent": map[string]any{"code": "NA"},
+				"country":   map[string]any{"iso_code": "US"},
+			},
+		}),
+		ASNDatabasePath: writeMMDB(t, "GeoLite2-ASN", map[string]map[string]any{
+			"203.0.113.0/24": {
+				"autonomous_system_number":       uint32(64500),
+				"autonomous_system_organization": "Example Transit",
+			},
+		}),
+	}
+}
+
+func TestEnrichGeoIP(t *testing.T) {
+	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
+	enricher := NewEnricher(testEnrichmentConfig(t), interfacestore.NewStore(interfacestore.DefaultTTL), logger)
+	defer enricher.Close()
+	require.NotNil(t, enricher.geoIP)
+
+	flow := &common.Flow{
+		SrcAddr: net.ParseIP("203.0.113.7").To4(),
+		DstAddr: net.ParseIP("198.51.100.10"),
+	}
+	enricher.Enrich(flow)
+	assert.Equal(t, &common.GeoLocation{
+		ContinentCode:  "EU",
+		CountryISOCode: "FR",
+		City:           "Paris",
+		ASNumber:       64500,
+		ASOrganization: "Example Transit",
+	}, flow.SrcGeo)
+	assert.Equal(t, &common.GeoLocation{
+		ContinentCode:  "NA",
+		CountryISOCode: "US",
+	}, flow.DstGeo)
+
+	// addresses which aren't in the databases
+	flow = &common.Flow{
+		SrcAddr: net.ParseIP("10.0.0.1").To4(),
+		DstAddr: net.ParseIP("198.51.100.200").To4(),
+	}
+	enricher.Enrich(flow)
+	assert.Nil(t, flow.SrcGeo)
+	assert.Nil(t, flow.DstGeo)
+}
+
+func TestEnrichInvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+
+	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
+	enricher := NewEnricher(config.EnrichmentConfig{GeoIPDatabasePath: path}, interfacestore.NewStore(interfacestore.DefaultTTL), logger)
+	assert.Nil(t, enricher.geoIP)
+
+	// the flows are still enriched with the interfaces
+	assert.NotNil(t, enricher.interfaces)
+}
+
+func TestEnrichInterfaces(t *testing.T) {
+	interfaces := interfacestore.NewStore(interfacestore.DefaultTTL)
+	interfaces.SetDeviceInterfaces("my-ns:192.0.2.1", map[uint32]interfacestore.Interface{
+		1: {Name: "ge-0/0/1", Alias: "uplink", Speed: 1e10},
+		2: {Name: "ge-0/0/2"},
+	})
+
+	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
+	enricher := NewEnricher(config.EnrichmentConfig{}, interfaces, logger)
+	flow := &common.Flow{
+		Namespace:       "my-ns",
+		ExporterAddr:    net.ParseIP("192.0.2.1").To4(),
+		InputInterface:  1,
+		OutputInterface: 3,
+	}
+	enricher.Enrich(flow)
+	assert.Equal(t, &common.InterfaceInfo{Name: "ge-0/0/1", Alias: "uplink", Speed: 1e10}, flow.InputInterfaceInfo)
+	assert.Nil(t, flow.OutputInterfaceInfo)
+	assert.Nil(t, flow.SrcGeo)
+
+	// the devices are identified by namespace
+	flow.Namespace = "other-ns"
+	flow.InputInterfaceInfo = nil
+	enricher.Enrich(flow)
+	assert.Nil(t, flow.InputInterfaceInfo)
+
+	enricher = NewEnricher(config.EnrichmentConfig{InterfaceMetadataDisabled: true}, interfaces, logger)
+	flow.Namespace = "my-ns"
+	enricher.Enrich(flow)
+	assert.Nil(t, flow.InputInterfaceInfo)
+
+	// a nil enricher doesn't enrich the flows
+	var nilEnricher *Enricher
+	nilEnricher.Enrich(flow)
+	nilEnricher.Close()
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package enrichment
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+)
+
+// locationRecord is the subset of the records of the GeoIP2 and GeoLite2
+// City and Country databases used by the enrichment
+type locationRecord struct {
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// asnRecord is the record of the GeoIP2 ISP and GeoLite2 ASN databases
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// geoIPDatabases looks up the location and the autonomous system of the
+// addresses in local MMDB databases, any of them being optional
+type geoIPDatabases struct {
+	location *maxminddb.Reader
+	asn      *maxminddb.Reader
+}
+
+func openGeoIPDatabases(locationPath string, asnPath string) (*geoIPDatabases, error) {
+	dbs := &geoIPDatabases{}
+	var err error
+	if locationPath != "" {
+		if dbs.location, err = maxminddb.Open(locationPath); err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP database `%s`: %w", locationPath, err)
+		}
+	}
+	if asnPath != "" {
+		if dbs.asn, err = maxminddb.Open(asnPath); err != nil {
+			dbs.close()
+			return nil, fmt.Errorf("failed to open ASN database `%s`: %w", asnPath, err)
+		}
+	}
+	return dbs, nil
+}
+
+// lookup returns the location and autonomous system of an address, or nil if
+// it isn't in any of the databases
+func (d *geoIPDatabases) lookup(addr []byte) *common.GeoLocation {
+	ip := net.IP(addr)
+	if len(addr) != net.IPv4len && len(addr) != net.IPv6len {
+		return nil
+	}
+
+	var geo common.GeoLocation
+	found := false
+	if d.location != nil {
+		var record locationRecord
+		if err := d.location.Lookup(ip, &record); err == nil {
+			geo.ContinentCode = record.Continent.Code
+			geo.CountryISOCode = record.Country.ISOCode
+			geo.City = record.City.Names["en"]
+			found = geo.ContinentCode != "" || geo.CountryISOCode != "" || geo.City != ""
+		}
+	}
+	if d.asn != nil {
+		var record asnRecord
+		if err := d.asn.Lookup(ip, &record); err == nil && record.Number != 0 {
+			geo.ASNumber = record.Number
+			geo.ASOrganization = record.Organization
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &geo
+}
+
+func (d *geoIPDatabases) close() {
+	if d.location != nil {
+		d.location.Close()
+	}
+	if d.asn != nil {
+		d.asn.Close()
+	}
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
 	"github.com/DataDog/datadog-agent/comp/netflow/format"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/netflow/common"
 	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/enrichment"
 	"github.com/DataDog/datadog-agent/comp/netflow/goflowlib"
 )
 
@@ -50,6 +52,9 @@ type FlowAggregator struct {
 	goflowPrometheusGatherer     prometheus.Gatherer
 	TimeNowFunction              func() time.Time // Allows to mock time in tests
 
+	// topTalkersCount is the number of top talkers submitted per exporter, zero if disabled
+	topTalkersCount int
+
 	lastSequencePerExporter   map[sequenceDeltaKey]uint32
 	lastSequencePerExporterMu sync.Mutex
 
@@ -77,13 +82,13 @@ var maxNegativeSequenceDiffToReset = map[common.FlowType]int{
 }
 
 // NewFlowAggregator returns a new FlowAggregator
-func NewFlowAggregator(sender sender.Sender, epForwarder eventplatform.Forwarder, config *config.NetflowConfig, hostname string, logger log.Component) *FlowAggregator {
+func NewFlowAggregator(sender sender.Sender, epForwarder eventplatform.Forwarder, interfaces interfacestore.Component, config *config.NetflowConfig, hostname string, logger log.Component) *FlowAggregator {
 	flushInterval := time.Duration(config.AggregatorFlushInterval) * time.Second
 	flowContextTTL := time.Duration(config.AggregatorFlowContextTTL) * time.Second
 	rollupTrackerRefreshInterval := time.Duration(config.AggregatorRollupTrackerRefreshInterval) * time.Second
-	return &FlowAggregator{
+	agg := &FlowAggregator{
 		flowIn:                       make(chan *common.Flow, config.AggregatorBufferSize),
-		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled, logger),
+		flowAcc:                      newFlowAccumulator(flushInterval, flowContextTTL, config.AggregatorPortRollupThreshold, config.AggregatorPortRollupDisabled, enrichment.NewEnricher(config.Enrichment, interfaces, logger), newSamplingNormalizer(config.SamplingNormalization), logger),
 		FlushFlowsToSendInterval:     flushFlowsToSendInterval,
 		rollupTrackerRefreshInterval: rollupTrackerRefreshInterval,
 		sender:                       sender,
@@ -99,6 +104,10 @@ func NewFlowAggregator(sender sender.Sender, epForwarder eventplatform.Forwarder
 		lastSequencePerExporter:      make(map[sequenceDeltaKey]uint32),
 		logger:                       logger,
 	}
+	if config.TopTalkers.Enabled {
+		agg.topTalkersCount = config.TopTalkers.Count
+	}
+	return agg
 }
 
 // Start will start the FlowAggregator worker
@@ -113,6 +122,7 @@ func (agg *FlowAggregator) Stop() {
 	close(agg.stopChan)
 	<-agg.flushLoopDone
 	<-agg.runDone
+	agg.flowAcc.enricher.Close()
 }
 
 // GetFlowInChan returns flow input chan
@@ -268,6 +278,9 @@ func (agg *FlowAggregator) flush() int {
 	if len(flowsToFlush) > 0 {
 		agg.sendFlows(flowsToFlush, flushTime)
 	}
+	if agg.topTalkersCount > 0 {
+		agg.submitTopTalkers(flowsToFlush)
+	}
 	agg.sendExporterMetadata(flowsToFlush, flushTime)
 
 	flushCount := len(flowsToFlush)
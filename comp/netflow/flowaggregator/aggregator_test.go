@@ -166,7 +166,7 @@ func TestAggregator(t *testing.T) {
 	epForwarder.EXPECT().SendEventPlatformEventBlocking(message.NewMessage(compactMetadataEvent.Bytes(), nil, "", 0), "network-devices-metadata").Return(nil).Times(1)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 	aggregator.FlushFlowsToSendInterval = 1 * time.Second
 	aggregator.TimeNowFunction = func() time.Time {
 		return flushTime
@@ -267,7 +267,7 @@ func TestAggregator_withMockPayload(t *testing.T) {
 	epForwarder.EXPECT().SendEventPlatformEventBlocking(message.NewMessage(compactMetadataEvent.Bytes(), nil, "", 0), "network-devices-metadata").Return(nil).Times(1)
 
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 	aggregator.FlushFlowsToSendInterval = 1 * time.Second
 	aggregator.TimeNowFunction = func() time.Time {
 		return flushTime
@@ -359,7 +359,7 @@ func TestFlowAggregator_flush_submitCollectorMetrics_error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 	aggregator.goflowPrometheusGatherer = prometheus.GathererFunc(func() ([]*promClient.MetricFamily, error) {
 		return nil, fmt.Errorf("some prometheus gatherer error")
 	})
@@ -399,7 +399,7 @@ func TestFlowAggregator_submitCollectorMetrics(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 	aggregator.goflowPrometheusGatherer = prometheus.GathererFunc(func() ([]*promClient.MetricFamily, error) {
 		return []*promClient.MetricFamily{
 			{
@@ -475,7 +475,7 @@ func TestFlowAggregator_submitCollectorMetrics_error(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 	aggregator.goflowPrometheusGatherer = prometheus.GathererFunc(func() ([]*promClient.MetricFamily, error) {
 		return nil, fmt.Errorf("some prometheus gatherer error")
 	})
@@ -509,7 +509,7 @@ func TestFlowAggregator_sendExporterMetadata_multiplePayloads(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 
 	var flows []*common.Flow
 	for i := 1; i <= 250; i++ {
@@ -593,7 +593,7 @@ func TestFlowAggregator_sendExporterMetadata_noPayloads(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 
 	var flows []*common.Flow
 	now := time.Unix(1681295467, 0)
@@ -625,7 +625,7 @@ func TestFlowAggregator_sendExporterMetadata_invalidIPIgnored(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 
 	now := time.Unix(1681295467, 0)
 	flows := []*common.Flow{
@@ -709,7 +709,7 @@ func TestFlowAggregator_sendExporterMetadata_multipleNamespaces(t *testing.T) {
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 
 	now := time.Unix(1681295467, 0)
 	flows := []*common.Flow{
@@ -812,7 +812,7 @@ func TestFlowAggregator_sendExporterMetadata_singleExporterIpWithMultipleFlowTyp
 	epForwarder := eventplatformimpl.NewMockEventPlatformForwarder(ctrl)
 	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
 
-	aggregator := NewFlowAggregator(sender, epForwarder, &conf, "my-hostname", logger)
+	aggregator := NewFlowAggregator(sender, epForwarder, nil, &conf, "my-hostname", logger)
 
 	now := time.Unix(1681295467, 0)
 	flows := []*common.Flow{
@@ -1163,7 +1163,7 @@ func TestFlowAggregator_getSequenceDelta(t *testing.T) {
 				AggregatorPortRollupThreshold:          10,
 				AggregatorRollupTrackerRefreshInterval: 3600,
 			}
-			agg := NewFlowAggregator(sender, nil, &conf, "my-hostname", logger)
+			agg := NewFlowAggregator(sender, nil, nil, &conf, "my-hostname", logger)
 			for roundNum, testRound := range tt.rounds {
 				assert.Equal(t, testRound.expectedSequenceDelta, agg.getSequenceDelta(testRound.flowsToFlush), fmt.Sprintf("Test Round %d", roundNum))
 			}
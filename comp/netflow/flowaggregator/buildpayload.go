@@ -14,6 +14,8 @@ import (
 )
 
 func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time) payload.FlowPayload {
+	srcGeo, srcAS := buildGeo(aggFlow.SrcGeo)
+	dstGeo, dstAS := buildGeo(aggFlow.DstGeo)
 	return payload.FlowPayload{
 		// TODO: Implement Tos
 		FlushTimestamp: flushTime.UnixMilli(),
@@ -37,22 +39,22 @@ func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time) pa
 			Port: format.Port(aggFlow.SrcPort),
 			Mac:  format.MacAddress(aggFlow.SrcMac),
 			Mask: format.CIDR(aggFlow.SrcAddr, aggFlow.SrcMask),
+			Geo:  srcGeo,
+			AS:   srcAS,
 		},
 		Destination: payload.Endpoint{
 			IP:   format.IPAddr(aggFlow.DstAddr),
 			Port: format.Port(aggFlow.DstPort),
 			Mac:  format.MacAddress(aggFlow.DstMac),
 			Mask: format.CIDR(aggFlow.DstAddr, aggFlow.DstMask),
+			Geo:  dstGeo,
+			AS:   dstAS,
 		},
 		Ingress: payload.ObservationPoint{
-			Interface: payload.Interface{
-				Index: aggFlow.InputInterface,
-			},
+			Interface: buildInterface(aggFlow.InputInterface, aggFlow.InputInterfaceInfo),
 		},
 		Egress: payload.ObservationPoint{
-			Interface: payload.Interface{
-				Index: aggFlow.OutputInterface,
-			},
+			Interface: buildInterface(aggFlow.OutputInterface, aggFlow.OutputInterfaceInfo),
 		},
 		Host:     hostname,
 		TCPFlags: format.TCPFlags(aggFlow.TCPFlags),
@@ -62,3 +64,35 @@ func buildPayload(aggFlow *common.Flow, hostname string, flushTime time.Time) pa
 		AdditionalFields: aggFlow.AdditionalFields,
 	}
 }
+
+func buildGeo(geo *common.GeoLocation) (*payload.Geo, *payload.AutonomousSystem) {
+	if geo == nil {
+		return nil, nil
+	}
+	var location *payload.Geo
+	if geo.ContinentCode != "" || geo.CountryISOCode != "" || geo.City != "" {
+		location = &payload.Geo{
+			ContinentCode:  geo.ContinentCode,
+			CountryISOCode: geo.CountryISOCode,
+			City:           geo.City,
+		}
+	}
+	var as *payload.AutonomousSystem
+	if geo.ASNumber != 0 {
+		as = &payload.AutonomousSystem{
+			Number:       geo.ASNumber,
+			Organization: geo.ASOrganization,
+		}
+	}
+	return location, as
+}
+
+func buildInterface(index uint32, info *common.InterfaceInfo) payload.Interface {
+	iface := payload.Interface{Index: index}
+	if info != nil {
+		iface.Name = info.Name
+		iface.Alias = info.Alias
+		iface.Speed = info.Speed
+	}
+	return iface
+}
@@ -223,6 +223,62 @@ func Test_buildPayload(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "enriched flow",
+			flow: common.Flow{
+				Namespace:       "my-namespace",
+				FlowType:        common.TypeIPFIX,
+				ExporterAddr:    []byte{127, 0, 0, 1},
+				SrcAddr:         []byte{203, 0, 113, 7},
+				DstAddr:         []byte{198, 51, 100, 10},
+				IPProtocol:      uint32(17),
+				SrcPort:         53,
+				DstPort:         -1,
+				InputInterface:  10,
+				OutputInterface: 20,
+				SrcGeo: &common.GeoLocation{
+					ContinentCode:  "EU",
+					CountryISOCode: "FR",
+					City:           "Paris",
+					ASNumber:       64500,
+					ASOrganization: "Example Transit",
+				},
+				// only the autonomous system is known
+				DstGeo:              &common.GeoLocation{ASNumber: 64501},
+				InputInterfaceInfo:  &common.InterfaceInfo{Name: "ge-0/0/1", Alias: "uplink", Speed: 1e10},
+				OutputInterfaceInfo: &common.InterfaceInfo{Name: "ge-0/0/2"},
+			},
+			expectedPayload: payload.FlowPayload{
+				FlushTimestamp: curTime.UnixMilli(),
+				FlowType:       "ipfix",
+				Direction:      "ingress",
+				IPProtocol:     "UDP",
+				Device: payload.Device{
+					Namespace: "my-namespace",
+				},
+				Exporter: payload.Exporter{
+					IP: "127.0.0.1",
+				},
+				Source: payload.Endpoint{
+					IP:   "203.0.113.7",
+					Port: "53",
+					Mac:  "00:00:00:00:00:00",
+					Mask: "0.0.0.0/0",
+					Geo:  &payload.Geo{ContinentCode: "EU", CountryISOCode: "FR", City: "Paris"},
+					AS:   &payload.AutonomousSystem{Number: 64500, Organization: "Example Transit"},
+				},
+				Destination: payload.Endpoint{
+					IP:   "198.51.100.10",
+					Port: "*",
+					Mac:  "00:00:00:00:00:00",
+					Mask: "0.0.0.0/0",
+					AS:   &payload.AutonomousSystem{Number: 64501},
+				},
+				Ingress: payload.ObservationPoint{Interface: payload.Interface{Index: 10, Name: "ge-0/0/1", Alias: "uplink", Speed: 1e10}},
+				Egress:  payload.ObservationPoint{Interface: payload.Interface{Index: 20, Name: "ge-0/0/2"}},
+				Host:    "my-hostname",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
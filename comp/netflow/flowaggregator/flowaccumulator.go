@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/enrichment"
 	"github.com/DataDog/datadog-agent/comp/netflow/portrollup"
 	"go.uber.org/atomic"
 )
@@ -40,6 +41,11 @@ type flowAccumulator struct {
 
 	hashCollisionFlowCount *atomic.Uint64
 
+	// enricher may be nil, in which case the flows aren't enriched
+	enricher *enrichment.Enricher
+	// samplingNormalizer may be nil, in which case the flows aren't scaled
+	samplingNormalizer *samplingNormalizer
+
 	logger log.Component
 }
 
@@ -51,7 +57,7 @@ func newFlowContext(flow *common.Flow) flowContext {
 	}
 }
 
-func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowContextTTL time.Duration, portRollupThreshold int, portRollupDisabled bool, logger log.Component) *flowAccumulator {
+func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowContextTTL time.Duration, portRollupThreshold int, portRollupDisabled bool, enricher *enrichment.Enricher, samplingNormalizer *samplingNormalizer, logger log.Component) *flowAccumulator {
 	return &flowAccumulator{
 		flows:                  make(map[uint64]flowContext),
 		flowFlushInterval:      aggregatorFlushInterval,
@@ -60,6 +66,8 @@ func newFlowAccumulator(aggregatorFlushInterval time.Duration, aggregatorFlowCon
 		portRollupThreshold:    portRollupThreshold,
 		portRollupDisabled:     portRollupDisabled,
 		hashCollisionFlowCount: atomic.NewUint64(0),
+		enricher:               enricher,
+		samplingNormalizer:     samplingNormalizer,
 		logger:                 logger,
 	}
 }
@@ -104,6 +112,11 @@ func (f *flowAccumulator) flush() []*common.Flow {
 func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 	f.logger.Tracef("Add new flow: %+v", flowToAdd)
 
+	// the enrichment only depends on the fields of the flow, it's done
+	// before the aggregation to be done once per flow
+	f.enricher.Enrich(flowToAdd)
+	f.samplingNormalizer.normalize(flowToAdd)
+
 	if !f.portRollupDisabled {
 		// Handle port rollup
 		f.portRollup.Add(flowToAdd.SrcAddr, flowToAdd.DstAddr, uint16(flowToAdd.SrcPort), uint16(flowToAdd.DstPort))
@@ -139,6 +152,11 @@ func (f *flowAccumulator) add(flowToAdd *common.Flow) {
 		aggFlow.flow.SequenceNum = common.Max(aggFlow.flow.SequenceNum, flowToAdd.SequenceNum)
 		aggFlow.flow.TCPFlags |= flowToAdd.TCPFlags
 
+		// the egress interface isn't part of the aggregation key
+		if aggFlow.flow.OutputInterfaceInfo == nil {
+			aggFlow.flow.OutputInterfaceInfo = flowToAdd.OutputInterfaceInfo
+		}
+
 		// keep first non-null value for custom fields
 		if flowToAdd.AdditionalFields != nil {
 			if aggFlow.flow.AdditionalFields == nil {
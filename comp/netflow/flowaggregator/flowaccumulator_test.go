@@ -12,7 +12,10 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
 	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/enrichment"
 	"github.com/DataDog/datadog-agent/comp/netflow/portrollup"
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 
 	"github.com/stretchr/testify/assert"
@@ -90,7 +93,7 @@ func Test_flowAccumulator_add(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false, logger)
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false, nil, nil, logger)
 	acc.add(flowA1)
 	acc.add(flowA2)
 	acc.add(flowB1)
@@ -163,7 +166,7 @@ func Test_flowAccumulator_portRollUp(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, 3, false, logger)
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, 3, false, nil, nil, logger)
 	acc.add(flowA1)
 	acc.add(flowA2)
 
@@ -239,7 +242,7 @@ func Test_flowAccumulator_flush(t *testing.T) {
 	}
 
 	// When
-	acc := newFlowAccumulator(flushInterval, flowContextTTL, common.DefaultAggregatorPortRollupThreshold, false, logger)
+	acc := newFlowAccumulator(flushInterval, flowContextTTL, common.DefaultAggregatorPortRollupThreshold, false, nil, nil, logger)
 	acc.add(flow)
 
 	// Then
@@ -302,3 +305,39 @@ func Test_flowAccumulator_flush(t *testing.T) {
 	_, ok = acc.flows[flow.AggregationHash()]
 	assert.False(t, ok)
 }
+
+func Test_flowAccumulator_enrich(t *testing.T) {
+	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
+	interfaces := interfacestore.NewStore(interfacestore.DefaultTTL)
+	interfaces.SetDeviceInterfaces("my-ns:127.0.0.1", map[uint32]interfacestore.Interface{
+		1: {Name: "eth0", Speed: 1e9},
+		3: {Name: "eth2"},
+	})
+	enricher := enrichment.NewEnricher(config.EnrichmentConfig{}, interfaces, logger)
+	acc := newFlowAccumulator(common.DefaultAggregatorFlushInterval, common.DefaultAggregatorFlushInterval, common.DefaultAggregatorPortRollupThreshold, false, enricher, nil, logger)
+
+	newFlow := func(outputInterface uint32) *common.Flow {
+		return &common.Flow{
+			Namespace:       "my-ns",
+			FlowType:        common.TypeNetFlow9,
+			ExporterAddr:    []byte{127, 0, 0, 1},
+			Bytes:           10,
+			SrcAddr:         []byte{10, 10, 10, 10},
+			DstAddr:         []byte{10, 10, 10, 20},
+			IPProtocol:      uint32(6),
+			SrcPort:         2000,
+			DstPort:         80,
+			InputInterface:  1,
+			OutputInterface: outputInterface,
+		}
+	}
+	// the second flow egresses through an interface unknown to the store
+	acc.add(newFlow(2))
+	acc.add(newFlow(3))
+
+	flows := acc.flush()
+	assert.Len(t, flows, 1)
+	assert.Equal(t, uint64(20), flows[0].Bytes)
+	assert.Equal(t, &common.InterfaceInfo{Name: "eth0", Speed: 1e9}, flows[0].InputInterfaceInfo)
+	assert.Equal(t, &common.InterfaceInfo{Name: "eth2"}, flows[0].OutputInterfaceInfo)
+}
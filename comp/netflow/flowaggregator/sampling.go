@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+	"github.com/DataDog/datadog-agent/comp/netflow/format"
+)
+
+type exporterKey struct {
+	Namespace  string
+	ExporterIP string
+}
+
+func newExporterKey(flow *common.Flow) exporterKey {
+	return exporterKey{
+		Namespace:  flow.Namespace,
+		ExporterIP: format.IPAddr(flow.ExporterAddr),
+	}
+}
+
+// samplingNormalizer scales the bytes and packets of the sampled flows by the
+// sampling rate of their exporter, to estimate the actual traffic
+type samplingNormalizer struct {
+	overrides map[exporterKey]uint64
+	// lastSamplingRates are the last sampling rates reported by the
+	// exporters. The NetFlow v9 and IPFIX exporters report it in options
+	// data, that may be received after the first flows of a template.
+	lastSamplingRates map[exporterKey]uint64
+}
+
+func newSamplingNormalizer(conf config.SamplingNormalizationConfig) *samplingNormalizer {
+	if !conf.Enabled {
+		return nil
+	}
+	overrides := make(map[exporterKey]uint64, len(conf.Overrides))
+	for _, override := range conf.Overrides {
+		overrides[exporterKey{Namespace: override.Namespace, ExporterIP: override.ExporterIP}] = override.SamplingRate
+	}
+	return &samplingNormalizer{
+		overrides:         overrides,
+		lastSamplingRates: make(map[exporterKey]uint64),
+	}
+}
+
+// normalize scales the flow, the sampling rate of a scaled flow is set to 1
+// so that its bytes and packets aren't scaled again downstream. It's a noop
+// if the normalizer is nil.
+func (n *samplingNormalizer) normalize(flow *common.Flow) {
+	if n == nil {
+		return
+	}
+
+	key := newExporterKey(flow)
+	samplingRate, ok := n.overrides[key]
+	if !ok {
+		if flow.SamplingRate > 0 {
+			n.lastSamplingRates[key] = flow.SamplingRate
+		}
+		samplingRate = n.lastSamplingRates[key]
+	}
+	if samplingRate <= 1 {
+		return
+	}
+
+	flow.Bytes *= samplingRate
+	flow.Packets *= samplingRate
+	flow.SamplingRate = 1
+}
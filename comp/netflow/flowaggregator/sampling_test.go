@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+)
+
+func Test_samplingNormalizer_normalize(t *testing.T) {
+	normalizer := newSamplingNormalizer(config.SamplingNormalizationConfig{
+		Enabled: true,
+		Overrides: []config.SamplingRateOverride{
+			{ExporterIP: "127.0.0.2", Namespace: "my-ns", SamplingRate: 100},
+		},
+	})
+	newFlow := func(exporterAddr []byte, samplingRate uint64) *common.Flow {
+		return &common.Flow{
+			Namespace:    "my-ns",
+			ExporterAddr: exporterAddr,
+			SamplingRate: samplingRate,
+			Bytes:        10,
+			Packets:      2,
+		}
+	}
+
+	// the flows received before the sampling rate of the exporter is known
+	// aren't scaled
+	flow := newFlow([]byte{127, 0, 0, 1}, 0)
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(10), flow.Bytes)
+	assert.Equal(t, uint64(2), flow.Packets)
+
+	flow = newFlow([]byte{127, 0, 0, 1}, 512)
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(5120), flow.Bytes)
+	assert.Equal(t, uint64(1024), flow.Packets)
+	// the scaled flows are reported as unsampled
+	assert.Equal(t, uint64(1), flow.SamplingRate)
+
+	// the last sampling rate of the exporter is used for the flows without one
+	flow = newFlow([]byte{127, 0, 0, 1}, 0)
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(5120), flow.Bytes)
+	assert.Equal(t, uint64(1), flow.SamplingRate)
+
+	// the override takes precedence over the reported sampling rate
+	flow = newFlow([]byte{127, 0, 0, 2}, 512)
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(1000), flow.Bytes)
+	assert.Equal(t, uint64(200), flow.Packets)
+	assert.Equal(t, uint64(1), flow.SamplingRate)
+
+	// the override is specific to the namespace of the exporter
+	flow = newFlow([]byte{127, 0, 0, 2}, 0)
+	flow.Namespace = "other-ns"
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(10), flow.Bytes)
+	assert.Equal(t, uint64(0), flow.SamplingRate)
+}
+
+func Test_samplingNormalizer_disabled(t *testing.T) {
+	normalizer := newSamplingNormalizer(config.SamplingNormalizationConfig{})
+	assert.Nil(t, normalizer)
+
+	flow := &common.Flow{SamplingRate: 512, Bytes: 10, Packets: 2}
+	normalizer.normalize(flow)
+	assert.Equal(t, uint64(10), flow.Bytes)
+	assert.Equal(t, uint64(2), flow.Packets)
+}
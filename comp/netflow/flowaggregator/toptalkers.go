@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package flowaggregator
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/format"
+)
+
+// trafficVolume is the traffic of a talker, conversation or port
+type trafficVolume struct {
+	tags    []string
+	bytes   uint64
+	packets uint64
+}
+
+// topVolumes accumulates the traffic by key and returns the top ones
+type topVolumes map[string]*trafficVolume
+
+func (t topVolumes) add(key string, flow *common.Flow, tags func() []string) {
+	volume, ok := t[key]
+	if !ok {
+		volume = &trafficVolume{tags: tags()}
+		t[key] = volume
+	}
+	volume.bytes += flow.Bytes
+	volume.packets += flow.Packets
+}
+
+// top returns the n volumes with the most bytes
+func (t topVolumes) top(n int) []*trafficVolume {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		bi, bj := t[keys[i]].bytes, t[keys[j]].bytes
+		if bi != bj {
+			return bi > bj
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	volumes := make([]*trafficVolume, 0, len(keys))
+	for _, key := range keys {
+		volumes = append(volumes, t[key])
+	}
+	return volumes
+}
+
+// exporterTopTalkers contains the traffic of the flows of an exporter
+type exporterTopTalkers struct {
+	talkers       topVolumes
+	conversations topVolumes
+	ports         topVolumes
+}
+
+// submitTopTalkers submits the top talkers, conversations and destination
+// ports of each exporter for the flushed flows
+func (agg *FlowAggregator) submitTopTalkers(flows []*common.Flow) {
+	perExporter := make(map[exporterKey]*exporterTopTalkers)
+	for _, flow := range flows {
+		key := newExporterKey(flow)
+		exporter, ok := perExporter[key]
+		if !ok {
+			exporter = &exporterTopTalkers{
+				talkers:       make(topVolumes),
+				conversations: make(topVolumes),
+				ports:         make(topVolumes),
+			}
+			perExporter[key] = exporter
+		}
+
+		srcIP, dstIP := format.IPAddr(flow.SrcAddr), format.IPAddr(flow.DstAddr)
+		exporter.talkers.add(srcIP, flow, func() []string {
+			return []string{"src_ip:" + srcIP}
+		})
+		exporter.conversations.add(srcIP+"|"+dstIP, flow, func() []string {
+			return []string{"src_ip:" + srcIP, "dst_ip:" + dstIP}
+		})
+		// the rolled up ephemeral ports aren't services
+		if flow.DstPort >= 0 {
+			port, protocol := format.Port(flow.DstPort), format.IPProtocol(flow.IPProtocol)
+			exporter.ports.add(port+"|"+protocol, flow, func() []string {
+				return []string{"dst_port:" + port, "ip_protocol:" + protocol}
+			})
+		}
+	}
+
+	for key, exporter := range perExporter {
+		exporterTags := []string{"device_namespace:" + key.Namespace, "exporter_ip:" + key.ExporterIP}
+		agg.submitTopVolumes("netflow.top_talkers", exporterTags, exporter.talkers)
+		agg.submitTopVolumes("netflow.top_conversations", exporterTags, exporter.conversations)
+		agg.submitTopVolumes("netflow.top_ports", exporterTags, exporter.ports)
+	}
+}
+
+func (agg *FlowAggregator) submitTopVolumes(metricName string, exporterTags []string, volumes topVolumes) {
+	for _, volume := range volumes.top(agg.topTalkersCount) {
+		tags := append(append([]string{}, exporterTags...), volume.tags...)
+		agg.sender.Count(metricName+".bytes", float64(volume.bytes), "", tags)
+		agg.sender.Count(metricName+".packets", float64(volume.packets), "", tags)
+	}
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+//go:build test
+
+package flowaggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+
+	"github.com/DataDog/datadog-agent/comp/netflow/common"
+	"github.com/DataDog/datadog-agent/comp/netflow/config"
+)
+
+func TestAggregator_submitTopTalkers(t *testing.T) {
+	logger := fxutil.Test[log.Component](t, logimpl.MockModule())
+	sender := mocksender.NewMockSender("")
+	sender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	conf := config.NetflowConfig{
+		AggregatorFlushInterval: 1,
+		TopTalkers:              config.TopTalkersConfig{Enabled: true, Count: 2},
+	}
+	agg := NewFlowAggregator(sender, nil, nil, &conf, "my-hostname", logger)
+
+	newFlow := func(src byte, dst byte, dstPort int32, bytes uint64) *common.Flow {
+		return &common.Flow{
+			Namespace:    "my-ns",
+			ExporterAddr: []byte{127, 0, 0, 1},
+			SrcAddr:      []byte{10, 0, 0, src},
+			DstAddr:      []byte{10, 0, 0, dst},
+			IPProtocol:   6,
+			SrcPort:      -1,
+			DstPort:      dstPort,
+			Bytes:        bytes,
+			Packets:      1,
+		}
+	}
+	otherExporterFlow := newFlow(1, 2, 443, 1)
+	otherExporterFlow.ExporterAddr = []byte{127, 0, 0, 2}
+
+	agg.submitTopTalkers([]*common.Flow{
+		newFlow(1, 2, 443, 100),
+		newFlow(1, 3, 443, 50),
+		newFlow(4, 2, 80, 120),
+		newFlow(5, 2, 22, 10),
+		newFlow(5, 3, -1, 500),
+		otherExporterFlow,
+	})
+
+	exporterTags := []string{"device_namespace:my-ns", "exporter_ip:127.0.0.1"}
+	withTags := func(tags ...string) []string {
+		return append(append([]string{}, exporterTags...), tags...)
+	}
+
+	sender.AssertMetric(t, "Count", "netflow.top_talkers.bytes", 510, "", withTags("src_ip:10.0.0.5"))
+	sender.AssertMetric(t, "Count", "netflow.top_talkers.packets", 2, "", withTags("src_ip:10.0.0.5"))
+	sender.AssertMetric(t, "Count", "netflow.top_talkers.bytes", 150, "", withTags("src_ip:10.0.0.1"))
+	sender.AssertNotCalled(t, "Count", "netflow.top_talkers.bytes", float64(120), "", withTags("src_ip:10.0.0.4"))
+
+	sender.AssertMetric(t, "Count", "netflow.top_conversations.bytes", 500, "", withTags("src_ip:10.0.0.5", "dst_ip:10.0.0.3"))
+	sender.AssertMetric(t, "Count", "netflow.top_conversations.bytes", 120, "", withTags("src_ip:10.0.0.4", "dst_ip:10.0.0.2"))
+	sender.AssertNotCalled(t, "Count", "netflow.top_conversations.bytes", float64(100), "", withTags("src_ip:10.0.0.1", "dst_ip:10.0.0.2"))
+
+	// the rolled up ephemeral ports are ignored
+	sender.AssertMetric(t, "Count", "netflow.top_ports.bytes", 150, "", withTags("dst_port:443", "ip_protocol:TCP"))
+	sender.AssertMetric(t, "Count", "netflow.top_ports.packets", 2, "", withTags("dst_port:443", "ip_protocol:TCP"))
+	sender.AssertMetric(t, "Count", "netflow.top_ports.bytes", 120, "", withTags("dst_port:80", "ip_protocol:TCP"))
+	sender.AssertNotCalled(t, "Count", "netflow.top_ports.bytes", float64(10), "", withTags("dst_port:22", "ip_protocol:TCP"))
+
+	sender.AssertMetric(t, "Count", "netflow.top_talkers.bytes", 1, "", []string{"device_namespace:my-ns", "exporter_ip:127.0.0.2", "src_ip:10.0.0.1"})
+}
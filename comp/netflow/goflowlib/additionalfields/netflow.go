@@ -39,7 +39,7 @@ func mapAdditionalField(additionalFields common.AdditionalFields, v []byte, cfg
 	}
 }
 
-func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]config.Mapping) common.AdditionalFields {
+func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[config.MappingKey]config.Mapping) common.AdditionalFields {
 	additionalFields := make(common.AdditionalFields)
 
 	for i := range record {
@@ -50,7 +50,11 @@ func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]c
 			continue
 		}
 
-		mappingConfig, ok := fieldsConfig[df.Type]
+		key := config.MappingKey{Field: df.Type}
+		if df.PenProvided {
+			key.Enterprise = df.Pen
+		}
+		mappingConfig, ok := fieldsConfig[key]
 		if !ok {
 			continue
 		}
@@ -61,7 +65,7 @@ func convertNetFlowDataSet(record []netflow.DataField, fieldsConfig map[uint16]c
 	return additionalFields
 }
 
-func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig map[uint16]config.Mapping) []common.AdditionalFields {
+func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig map[config.MappingKey]config.Mapping) []common.AdditionalFields {
 	var setsAdditionalFields []common.AdditionalFields
 	for _, record := range dataRecords {
 		additionalFields := convertNetFlowDataSet(record.Values, fieldsConfig)
@@ -72,7 +76,7 @@ func searchNetFlowDataSetsRecords(dataRecords []netflow.DataRecord, fieldsConfig
 	return setsAdditionalFields
 }
 
-func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[uint16]config.Mapping) []common.AdditionalFields {
+func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[config.MappingKey]config.Mapping) []common.AdditionalFields {
 	var flowsAdditonalFields []common.AdditionalFields
 	for _, dataFlowSetItem := range dataFlowSet {
 		setsAdditionalFields := searchNetFlowDataSetsRecords(dataFlowSetItem.Records, fieldsConfig)
@@ -84,7 +88,7 @@ func searchNetFlowDataSets(dataFlowSet []netflow.DataFlowSet, fieldsConfig map[u
 }
 
 // ProcessMessageNetFlowAdditionalFields collects additional fields from netflow packet using the given config
-func ProcessMessageNetFlowAdditionalFields(msgDec interface{}, fieldsConfig map[uint16]config.Mapping) ([]common.AdditionalFields, error) {
+func ProcessMessageNetFlowAdditionalFields(msgDec interface{}, fieldsConfig map[config.MappingKey]config.Mapping) ([]common.AdditionalFields, error) {
 	if len(fieldsConfig) == 0 {
 		return nil, nil
 	}
@@ -42,7 +42,7 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 	tests := []struct {
 		name                    string
 		fields                  []netflow.DataField
-		config                  map[uint16]config.Mapping
+		config                  map[config.MappingKey]config.Mapping
 		expectedCollectedFields []common.AdditionalFields
 	}{
 		{
@@ -51,8 +51,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte{45},
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[config.MappingKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
@@ -68,8 +68,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte("test"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[config.MappingKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.String,
@@ -85,8 +85,8 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  123,
 				Value: []byte{45, 12},
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[config.MappingKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 				},
@@ -104,13 +104,13 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  124,
 				Value: []byte("hello"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[config.MappingKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
 				},
-				124: {
+				{Field: 124}: {
 					Field:       124,
 					Destination: "second_field",
 					Type:        common.String,
@@ -130,13 +130,13 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				Type:  124,
 				Value: []byte("hello"),
 			}},
-			config: map[uint16]config.Mapping{
-				123: {
+			config: map[config.MappingKey]config.Mapping{
+				{Field: 123}: {
 					Field:       123,
 					Destination: "test_field",
 					Type:        common.Integer,
 				},
-				126: {
+				{Field: 126}: {
 					Field:       126,
 					Destination: "missing_field",
 					Type:        common.Integer,
@@ -146,13 +146,47 @@ func Test_ProcessMessageNetFlowAdditionalFields(t *testing.T) {
 				"test_field": uint64(45),
 			}},
 		},
+		{
+			name: "Custom field enterprise",
+			fields: []netflow.DataField{{
+				Type:  123,
+				Value: []byte{45},
+			}, {
+				PenProvided: true,
+				Pen:         9,
+				Type:        123,
+				Value:       []byte{46},
+			}, {
+				PenProvided: true,
+				Pen:         29305,
+				Type:        123,
+				Value:       []byte{47},
+			}},
+			config: map[config.MappingKey]config.Mapping{
+				{Enterprise: 9, Field: 123}: {
+					Enterprise:  9,
+					Field:       123,
+					Destination: "cisco_field",
+					Type:        common.Integer,
+				},
+				{Field: 123}: {
+					Field:       123,
+					Destination: "iana_field",
+					Type:        common.Integer,
+				},
+			},
+			expectedCollectedFields: []common.AdditionalFields{{
+				"iana_field":  uint64(45),
+				"cisco_field": uint64(46),
+			}},
+		},
 		{
 			name: "Custom field empty configuration",
 			fields: []netflow.DataField{{
 				Type:  123,
 				Value: []byte{45, 12},
 			}},
-			config:                  map[uint16]config.Mapping{},
+			config:                  map[config.MappingKey]config.Mapping{},
 			expectedCollectedFields: nil,
 		},
 	}
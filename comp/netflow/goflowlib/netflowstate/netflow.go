@@ -44,7 +44,7 @@ type StateNetFlow struct {
 
 	ctx context.Context
 
-	mappedFieldsConfig map[uint16]config.Mapping
+	mappedFieldsConfig map[config.MappingKey]config.Mapping
 }
 
 // NewStateNetFlow initializes a new Netflow/IPFIX producer, with the goflow default producer and the additional fields producer
@@ -158,10 +158,10 @@ func (s *StateNetFlow) initConfig() {
 	s.configMapped = producer.NewProducerConfigMapped(s.Config)
 }
 
-func mapFieldsConfig(mappingConfs []config.Mapping) map[uint16]config.Mapping {
-	mappedFieldsConfig := make(map[uint16]config.Mapping)
+func mapFieldsConfig(mappingConfs []config.Mapping) map[config.MappingKey]config.Mapping {
+	mappedFieldsConfig := make(map[config.MappingKey]config.Mapping)
 	for _, conf := range mappingConfs {
-		mappedFieldsConfig[conf.Field] = conf
+		mappedFieldsConfig[conf.Key()] = conf
 	}
 	return mappedFieldsConfig
 }
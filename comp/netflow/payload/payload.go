@@ -20,10 +20,25 @@ type Exporter struct {
 
 // Endpoint contains source or destination endpoint details
 type Endpoint struct {
-	IP   string `json:"ip"`
-	Port string `json:"port"` // Port number can be zero/positive or `*` (ephemeral port)
-	Mac  string `json:"mac"`
-	Mask string `json:"mask"`
+	IP   string            `json:"ip"`
+	Port string            `json:"port"` // Port number can be zero/positive or `*` (ephemeral port)
+	Mac  string            `json:"mac"`
+	Mask string            `json:"mask"`
+	Geo  *Geo              `json:"geo,omitempty"`
+	AS   *AutonomousSystem `json:"as,omitempty"`
+}
+
+// Geo contains the location of an endpoint
+type Geo struct {
+	ContinentCode  string `json:"continent_code,omitempty"`
+	CountryISOCode string `json:"country_iso_code,omitempty"`
+	City           string `json:"city,omitempty"`
+}
+
+// AutonomousSystem contains the autonomous system of an endpoint
+type AutonomousSystem struct {
+	Number       uint32 `json:"number"`
+	Organization string `json:"organization,omitempty"`
 }
 
 // NextHop contains next hop details
@@ -34,6 +49,9 @@ type NextHop struct {
 // Interface contains interface details
 type Interface struct {
 	Index uint32 `json:"index"`
+	Name  string `json:"name,omitempty"`
+	Alias string `json:"alias,omitempty"`
+	Speed uint64 `json:"speed,omitempty"` // in bits per second
 }
 
 // ObservationPoint contains ingress or egress observation point
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/core/status"
 	"github.com/DataDog/datadog-agent/comp/ndmtmp/forwarder"
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
 	nfconfig "github.com/DataDog/datadog-agent/comp/netflow/config"
 	"github.com/DataDog/datadog-agent/comp/netflow/flowaggregator"
 )
@@ -29,6 +30,7 @@ type dependencies struct {
 	Logger        log.Component
 	Demultiplexer demultiplexer.Component
 	Forwarder     forwarder.Component
+	Interfaces    interfacestore.Component
 	Hostname      hostname.Component
 }
 
@@ -46,7 +48,7 @@ func newServer(lc fx.Lifecycle, deps dependencies) (provides, error) {
 	if err != nil {
 		return provides{}, err
 	}
-	flowAgg := flowaggregator.NewFlowAggregator(sender, deps.Forwarder, conf, deps.Hostname.GetSafe(context.Background()), deps.Logger)
+	flowAgg := flowaggregator.NewFlowAggregator(sender, deps.Forwarder, deps.Interfaces, conf, deps.Hostname.GetSafe(context.Background()), deps.Logger)
 
 	server := &Server{
 		config:  conf,
@@ -143,6 +143,8 @@ var (
 		[]string{"shard", "metric_type"}, "Count the number of dogstatsd contexts in the aggregator, by metric type")
 	tlmDogstatsdContextsBytesByMtype = telemetry.NewGauge("aggregator", "dogstatsd_contexts_bytes_by_mtype",
 		[]string{"shard", "metric_type", util.BytesKindTelemetryKey}, "Estimated count of bytes taken by contexts in the aggregator, by metric type")
+	tlmDogstatsdRollupContextsSaved = telemetry.NewGauge("aggregator", "dogstatsd_rollup_contexts_saved",
+		[]string{"shard", "rule"}, "Number of dogstatsd contexts merged into another one by each roll-up rule during the last flush interval")
 	tlmChecksContexts = telemetry.NewGauge("aggregator", "checks_contexts",
 		[]string{"shard"}, "Count the number of checks contexts in the check aggregator")
 	tlmChecksContextsByMtype = telemetry.NewGauge("aggregator", "checks_contexts_by_mtype",
@@ -227,6 +229,7 @@ type BufferedAggregator struct {
 
 	tagsStore              *tags.Store
 	checkSamplers          map[checkid.ID]*CheckSampler
+	metricOverrides        *metricOverrides
 	serviceChecks          servicecheck.ServiceChecks
 	events                 event.Events
 	manifests              []*senderOrchestratorManifest
@@ -283,6 +286,11 @@ func NewBufferedAggregator(s serializer.MetricSerializer, eventPlatformForwarder
 
 	tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), "aggregator")
 
+	metricOverrides, err := newMetricOverridesFromConfig(config.Datadog)
+	if err != nil {
+		log.Errorf("Metric overrides won't be applied to checks: %s", err)
+	}
+
 	aggregator := &BufferedAggregator{
 		bufferedServiceCheckIn: make(chan []*servicecheck.ServiceCheck, bufferSize),
 		bufferedEventIn:        make(chan []*event.Event, bufferSize),
@@ -298,6 +306,7 @@ func NewBufferedAggregator(s serializer.MetricSerializer, eventPlatformForwarder
 
 		tagsStore:                   tagsStore,
 		checkSamplers:               make(map[checkid.ID]*CheckSampler),
+		metricOverrides:             metricOverrides,
 		flushInterval:               flushInterval,
 		serializer:                  s,
 		eventPlatformForwarder:      eventPlatformForwarder,
@@ -928,6 +937,7 @@ func (agg *BufferedAggregator) handleRegisterSampler(id checkid.ID) {
 		config.Datadog.GetBool("check_sampler_expire_metrics"),
 		config.Datadog.GetBool("check_sampler_context_metrics"),
 		config.Datadog.GetDuration("check_sampler_stateful_metric_expiration_time"),
+		agg.metricOverrides,
 		agg.tagsStore,
 		id,
 	)
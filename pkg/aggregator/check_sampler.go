@@ -33,12 +33,12 @@ type CheckSampler struct {
 }
 
 // newCheckSampler returns a newly initialized CheckSampler
-func newCheckSampler(expirationCount int, expireMetrics bool, contextResolverMetrics bool, statefulTimeout time.Duration, cache *tags.Store, id checkid.ID) *CheckSampler {
+func newCheckSampler(expirationCount int, expireMetrics bool, contextResolverMetrics bool, statefulTimeout time.Duration, overrides *metricOverrides, cache *tags.Store, id checkid.ID) *CheckSampler {
 	return &CheckSampler{
 		id:                     id,
 		series:                 make([]*metrics.Serie, 0),
 		sketches:               make(metrics.SketchSeriesList, 0),
-		contextResolver:        newCountBasedContextResolver(expirationCount, cache, string(id)),
+		contextResolver:        newCountBasedContextResolver(expirationCount, cache, string(id), overrides),
 		metrics:                metrics.NewCheckMetrics(expireMetrics, statefulTimeout),
 		sketchMap:              make(sketchMap),
 		lastBucketValue:        make(map[ckey.ContextKey]int64),
@@ -54,7 +54,10 @@ func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
 		return
 	}
 
-	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1, config.Datadog); err != nil {
+	// only the histogram configuration of the overrides applies to checks, their samples
+	// are flushed each time the check runs
+	histogramConfig := cs.contextResolver.override(contextKey).histogramConfig()
+	if err := cs.metrics.AddSampleWithHistogramConfig(contextKey, metricSample, metricSample.Timestamp, 1, config.Datadog, histogramConfig); err != nil {
 		log.Debugf("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
 	}
 }
@@ -71,6 +71,11 @@ type contextResolver struct {
 	keyGenerator     *ckey.KeyGenerator
 	taggerBuffer     *tagset.HashingTagsAccumulator
 	metricBuffer     *tagset.HashingTagsAccumulator
+	rollup           *rollupRules
+	rollupTracker    *rollupTracker
+	overrides        *metricOverrides
+	// overrideByKey only holds the contexts matching an override
+	overrideByKey map[ckey.ContextKey]*metricOverride
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -93,16 +98,38 @@ func newContextResolver(cache *tags.Store, id string) *contextResolver {
 	}
 }
 
+// withRollup enables the roll-up rules on the resolver
+func (cr *contextResolver) withRollup(rollup *rollupRules) *contextResolver {
+	if rollup != nil {
+		cr.rollup = rollup
+		cr.rollupTracker = newRollupTracker()
+	}
+	return cr
+}
+
+// withOverrides makes the resolver match the contexts it tracks against the metric overrides
+func (cr *contextResolver) withOverrides(overrides *metricOverrides) *contextResolver {
+	if overrides != nil {
+		cr.overrides = overrides
+		cr.overrideByKey = make(map[ckey.ContextKey]*metricOverride)
+	}
+	return cr
+}
+
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
 	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer, tagger.EnrichTags) // tags here are not sorted and can contain duplicates
 	defer cr.taggerBuffer.Reset()
 	defer cr.metricBuffer.Reset()
 
+	mtype := metricSampleContext.GetMetricType()
+
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
+	if cr.rollupTracker != nil {
+		contextKey, taggerKey, metricKey = cr.rollUp(metricSampleContext, mtype, contextKey, taggerKey, metricKey)
+	}
 
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		mtype := metricSampleContext.GetMetricType()
 		context := &Context{
 			Name:       metricSampleContext.GetName(),
 			taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
@@ -117,16 +144,52 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 		cr.countsByMtype[mtype]++
 		cr.bytesByMtype[mtype] += uint64(context.SizeInBytes())
 		cr.dataBytesByMtype[mtype] += uint64(context.DataSizeInBytes())
+
+		if override := cr.overrides.match(context.Name); override != nil {
+			cr.overrideByKey[contextKey] = override
+		}
 	}
 
 	return contextKey
 }
 
+// rollUp returns the keys of the context a sample is aggregated in once the
+// roll-up rules are applied. Roll-up rules drop tags, so that the samples only
+// differing by these tags are aggregated in the same context. The roll-up of
+// each source context is cached, so that the rules are only matched once.
+func (cr *contextResolver) rollUp(metricSampleContext metrics.MetricSampleContext, mtype metrics.MetricType, contextKey ckey.ContextKey, taggerKey, metricKey ckey.TagsKey) (ckey.ContextKey, ckey.TagsKey, ckey.TagsKey) {
+	source, found := cr.rollupTracker.get(contextKey)
+	if !found {
+		source = &rollupSource{contextKey: contextKey, taggerKey: taggerKey, metricKey: metricKey}
+		if rule := cr.rollup.match(metricSampleContext.GetName(), mtype); rule != nil && rule.apply(cr.taggerBuffer, cr.metricBuffer) {
+			source.rule = rule
+			source.contextKey, source.taggerKey, source.metricKey = cr.generateContextKey(metricSampleContext)
+		}
+		cr.rollupTracker.add(contextKey, source)
+	} else if source.rule != nil {
+		if _, ok := cr.contextsByKey[source.contextKey]; !ok {
+			// the tags of the buffers are those of the context to create
+			source.rule.apply(cr.taggerBuffer, cr.metricBuffer)
+		}
+	}
+
+	cr.rollupTracker.track(source)
+	return source.contextKey, source.taggerKey, source.metricKey
+}
+
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
 	ctx, found := cr.contextsByKey[key]
 	return ctx, found
 }
 
+// override returns the metric override of the context, or nil if there is none
+func (cr *contextResolver) override(key ckey.ContextKey) *metricOverride {
+	if cr.overrideByKey == nil {
+		return nil
+	}
+	return cr.overrideByKey[key]
+}
+
 func (cr *contextResolver) length() int {
 	return len(cr.contextsByKey)
 }
@@ -134,6 +197,12 @@ func (cr *contextResolver) length() int {
 func (cr *contextResolver) remove(expiredContextKey ckey.ContextKey) {
 	context := cr.contextsByKey[expiredContextKey]
 	delete(cr.contextsByKey, expiredContextKey)
+	if cr.overrideByKey != nil {
+		delete(cr.overrideByKey, expiredContextKey)
+	}
+	if cr.rollupTracker != nil {
+		cr.rollupTracker.forget(expiredContextKey)
+	}
 
 	if context != nil {
 		cr.countsByMtype[context.mtype]--
@@ -160,6 +229,12 @@ func (cr *contextResolver) updateMetrics(countsByMTypeGauge telemetry.Gauge, byt
 	}
 }
 
+func (cr *contextResolver) updateRollupMetrics(contextsSavedGauge telemetry.Gauge) {
+	if cr.rollupTracker != nil {
+		cr.rollupTracker.flush(cr.rollup, cr.id, contextsSavedGauge)
+	}
+}
+
 func (cr *contextResolver) release() {
 	for _, c := range cr.contextsByKey {
 		c.release()
@@ -203,9 +278,9 @@ type timestampContextResolver struct {
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store, id string) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, id string, rollup *rollupRules, overrides *metricOverrides) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache, id),
+		resolver:      newContextResolver(cache, id).withRollup(rollup).withOverrides(overrides),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -240,6 +315,10 @@ func (cr *timestampContextResolver) get(key ckey.ContextKey) (*Context, bool) {
 	return cr.resolver.get(key)
 }
 
+func (cr *timestampContextResolver) override(key ckey.ContextKey) *metricOverride {
+	return cr.resolver.override(key)
+}
+
 // expireContexts cleans up the contexts that haven't been tracked since the given timestamp
 // and returns the associated contextKeys.
 // keep can be used to retain contexts longer than their natural expiration time based on some condition.
@@ -264,6 +343,10 @@ func (cr *timestampContextResolver) updateMetrics(countsByMTypeGauge telemetry.G
 	cr.resolver.updateMetrics(countsByMTypeGauge, bytesByMTypeGauge)
 }
 
+func (cr *timestampContextResolver) updateRollupMetrics(contextsSavedGauge telemetry.Gauge) {
+	cr.resolver.updateRollupMetrics(contextsSavedGauge)
+}
+
 // countBasedContextResolver allows tracking and expiring contexts based on the number
 // of calls of `expireContexts`.
 type countBasedContextResolver struct {
@@ -273,9 +356,9 @@ type countBasedContextResolver struct {
 	expireCountInterval int64
 }
 
-func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, id string) *countBasedContextResolver {
+func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, id string, overrides *metricOverrides) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache, id),
+		resolver:            newContextResolver(cache, id).withOverrides(overrides),
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -302,6 +385,10 @@ func (cr *countBasedContextResolver) get(key ckey.ContextKey) (*Context, bool) {
 	return cr.resolver.get(key)
 }
 
+func (cr *countBasedContextResolver) override(key ckey.ContextKey) *metricOverride {
+	return cr.resolver.override(key)
+}
+
 // expireContexts cleans up the contexts that haven't been tracked since `expirationCount`
 // call to `expireContexts` and returns the associated contextKeys
 func (cr *countBasedContextResolver) expireContexts() []ckey.ContextKey {
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+
+	"github.com/gobwas/glob"
+
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const metricOverridesConfigKey = "aggregator_metric_overrides"
+
+// metricOverrideConfig is the user-facing configuration of a metric override
+type metricOverrideConfig struct {
+	Metric               string   `mapstructure:"metric"`
+	Interval             int64    `mapstructure:"interval"`
+	HistogramAggregates  []string `mapstructure:"histogram_aggregates"`
+	HistogramPercentiles []string `mapstructure:"histogram_percentiles"`
+}
+
+// metricOverride changes how the metrics whose name matches a glob pattern
+// are aggregated.
+type metricOverride struct {
+	pattern string
+	metric  glob.Glob
+	// interval is the bucket interval of the metric in seconds, 0 to keep
+	// the interval of the sampler
+	interval int64
+	// histogram is nil when the histograms keep the global configuration
+	histogram *metrics.HistogramConfig
+}
+
+// metricOverrides is an ordered list of overrides. Only the first override
+// matching a metric is applied.
+type metricOverrides struct {
+	overrides []*metricOverride
+}
+
+// newMetricOverridesFromConfig returns the overrides configured in
+// `aggregator_metric_overrides`, or nil if none is configured.
+func newMetricOverridesFromConfig(cfg model.Reader) (*metricOverrides, error) {
+	if !cfg.IsSet(metricOverridesConfigKey) {
+		return nil, nil
+	}
+
+	var configs []metricOverrideConfig
+	if err := cfg.UnmarshalKey(metricOverridesConfigKey, &configs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", metricOverridesConfigKey, err)
+	}
+
+	return newMetricOverrides(configs)
+}
+
+func newMetricOverrides(configs []metricOverrideConfig) (*metricOverrides, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	mo := &metricOverrides{
+		overrides: make([]*metricOverride, 0, len(configs)),
+	}
+
+	for i, c := range configs {
+		if c.Metric == "" {
+			return nil, fmt.Errorf("invalid metric override #%d: metric is required", i)
+		}
+		if c.Interval < 0 {
+			return nil, fmt.Errorf("invalid metric override #%d: negative interval %d", i, c.Interval)
+		}
+
+		g, err := glob.Compile(c.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric override #%d: invalid metric pattern %q: %w", i, c.Metric, err)
+		}
+
+		override := &metricOverride{
+			pattern:  c.Metric,
+			metric:   g,
+			interval: c.Interval,
+		}
+		if c.HistogramAggregates != nil || c.HistogramPercentiles != nil {
+			override.histogram = metrics.NewHistogramConfig(c.HistogramAggregates, c.HistogramPercentiles)
+		}
+
+		mo.overrides = append(mo.overrides, override)
+	}
+
+	return mo, nil
+}
+
+// match returns the override to apply to a metric, or nil if none matches
+func (mo *metricOverrides) match(name string) *metricOverride {
+	if mo == nil {
+		return nil
+	}
+
+	for _, override := range mo.overrides {
+		if override.metric.Match(name) {
+			return override
+		}
+	}
+
+	return nil
+}
+
+// withInterval returns overrides whose intervals are valid for a sampler
+// flushing buckets of samplerInterval seconds. The interval of an override
+// must divide the interval of the sampler, so that its buckets are closed at
+// the same time as the sampler bucket they belong to. Other intervals are
+// ignored.
+func (mo *metricOverrides) withInterval(samplerInterval int64) (*metricOverrides, []error) {
+	if mo == nil {
+		return nil, nil
+	}
+
+	var errs []error
+	res := &metricOverrides{
+		overrides: make([]*metricOverride, 0, len(mo.overrides)),
+	}
+	for _, override := range mo.overrides {
+		if override.interval != 0 && samplerInterval%override.interval != 0 {
+			errs = append(errs, fmt.Errorf("ignoring the interval of the metric override %q: %ds doesn't divide the flush interval of %ds", override.pattern, override.interval, samplerInterval))
+			o := *override
+			o.interval = 0
+			override = &o
+		}
+		res.overrides = append(res.overrides, override)
+	}
+	return res, errs
+}
+
+// intervalOr returns the interval of the override, or defaultInterval if the
+// override is nil or doesn't set one
+func (o *metricOverride) intervalOr(defaultInterval int64) int64 {
+	if o == nil || o.interval == 0 {
+		return defaultInterval
+	}
+	return o.interval
+}
+
+// histogramConfig returns the histogram configuration of the override, or
+// nil if it doesn't set one
+func (o *metricOverride) histogramConfig() *metrics.HistogramConfig {
+	if o == nil {
+		return nil
+	}
+	return o.histogram
+}
@@ -0,0 +1,292 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func testOverridesSampler(t *testing.T, store *tags.Store, configs []metricOverrideConfig) *TimeSampler {
+	overrides, err := newMetricOverrides(configs)
+	require.NoError(t, err)
+	overrides, errs := overrides.withInterval(10)
+	require.Empty(t, errs)
+
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, store, "host")
+	sampler.contextResolver = newTimestampContextResolver(store, "0", nil, overrides)
+	return sampler
+}
+
+func TestNewMetricOverrides(t *testing.T) {
+	overrides, err := newMetricOverrides(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, overrides)
+
+	_, err = newMetricOverrides([]metricOverrideConfig{{Interval: 1}})
+	assert.Error(t, err)
+
+	_, err = newMetricOverrides([]metricOverrideConfig{{Metric: "http.*", Interval: -1}})
+	assert.Error(t, err)
+
+	_, err = newMetricOverrides([]metricOverrideConfig{{Metric: "http.[", Interval: 1}})
+	assert.Error(t, err)
+
+	overrides, err = newMetricOverrides([]metricOverrideConfig{
+		{Metric: "http.latency", Interval: 1},
+		{Metric: "http.*", HistogramPercentiles: []string{"0.99", "0.5"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, overrides.overrides, 2)
+
+	latency := overrides.match("http.latency")
+	assert.Equal(t, int64(1), latency.intervalOr(10))
+	assert.Nil(t, latency.histogramConfig())
+
+	requests := overrides.match("http.requests")
+	assert.Equal(t, int64(10), requests.intervalOr(10))
+	require.NotNil(t, requests.histogramConfig())
+	assert.Nil(t, requests.histogramConfig().Aggregates)
+	assert.Equal(t, []int{50, 99}, requests.histogramConfig().Percentiles)
+
+	none := overrides.match("db.queries")
+	assert.Nil(t, none)
+	assert.Equal(t, int64(10), none.intervalOr(10))
+	assert.Nil(t, none.histogramConfig())
+}
+
+func TestNewMetricOverridesFromConfig(t *testing.T) {
+	cfg := config.Mock(t)
+
+	overrides, err := newMetricOverridesFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, overrides)
+
+	cfg.SetWithoutSource(metricOverridesConfigKey, []map[string]interface{}{
+		{"metric": "http.*", "interval": 1, "histogram_aggregates": []string{"max"}, "histogram_percentiles": []float64{0.99}},
+	})
+	overrides, err = newMetricOverridesFromConfig(cfg)
+	require.NoError(t, err)
+	require.Len(t, overrides.overrides, 1)
+	assert.Equal(t, int64(1), overrides.overrides[0].interval)
+	assert.Equal(t, []string{"max"}, overrides.overrides[0].histogram.Aggregates)
+	assert.Equal(t, []int{99}, overrides.overrides[0].histogram.Percentiles)
+}
+
+func TestMetricOverridesWithInterval(t *testing.T) {
+	overrides, err := newMetricOverrides([]metricOverrideConfig{
+		{Metric: "a.*", Interval: 5},
+		{Metric: "b.*", Interval: 3},
+		{Metric: "c.*", Interval: 60},
+	})
+	require.NoError(t, err)
+
+	valid, errs := overrides.withInterval(10)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, int64(5), valid.match("a.metric").intervalOr(10))
+	assert.Equal(t, int64(10), valid.match("b.metric").intervalOr(10))
+	assert.Equal(t, int64(10), valid.match("c.metric").intervalOr(10))
+
+	// the original overrides are left untouched
+	assert.Equal(t, int64(3), overrides.match("b.metric").interval)
+}
+
+func testOverrideInterval(t *testing.T, store *tags.Store) {
+	sampler := testOverridesSampler(t, store, []metricOverrideConfig{{Metric: "fast.*", Interval: 1}})
+
+	for _, ts := range []float64{12341.0, 12341.5, 12343.0, 12349.9, 12350.0} {
+		for _, name := range []string{"fast.requests", "slow.requests"} {
+			sampler.sample(&metrics.MetricSample{
+				Name:       name,
+				Value:      1,
+				Mtype:      metrics.CountType,
+				Tags:       []string{"foo"},
+				SampleRate: 1,
+			}, ts)
+		}
+	}
+
+	series, _ := flushSerie(sampler, 12350.0)
+	require.Len(t, series, 2)
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+
+	fast := series[0]
+	assert.Equal(t, "fast.requests", fast.Name)
+	assert.Equal(t, int64(1), fast.Interval)
+	sort.Slice(fast.Points, func(i, j int) bool { return fast.Points[i].Ts < fast.Points[j].Ts })
+	assert.Equal(t, []metrics.Point{
+		{Ts: 12341.0, Value: 2},
+		{Ts: 12343.0, Value: 1},
+		{Ts: 12349.0, Value: 1},
+	}, fast.Points)
+
+	slow := series[1]
+	assert.Equal(t, "slow.requests", slow.Name)
+	assert.Equal(t, int64(10), slow.Interval)
+	assert.Equal(t, []metrics.Point{{Ts: 12340.0, Value: 4}}, slow.Points)
+
+	// the sample of the next sampler bucket is flushed with it
+	series, _ = flushSerie(sampler, 12360.0)
+	require.Len(t, series, 2)
+	for _, serie := range series {
+		require.Len(t, serie.Points, 1)
+		assert.Equal(t, float64(12350.0), serie.Points[0].Ts)
+	}
+}
+
+func TestOverrideInterval(t *testing.T) {
+	testWithTagsStore(t, testOverrideInterval)
+}
+
+func testOverrideIntervalCounter(t *testing.T, store *tags.Store) {
+	sampler := testOverridesSampler(t, store, []metricOverrideConfig{{Metric: "fast.*", Interval: 1}})
+
+	for _, ts := range []float64{12341.0, 12343.0} {
+		for _, name := range []string{"fast.hits", "slow.hits"} {
+			sampler.sample(&metrics.MetricSample{
+				Name:       name,
+				Value:      2,
+				Mtype:      metrics.CounterType,
+				SampleRate: 1,
+			}, ts)
+		}
+	}
+
+	series, _ := flushSerie(sampler, 12350.0)
+	require.Len(t, series, 2)
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+
+	// the counters are only zero-filled in the buckets of their own interval
+	fast := series[0]
+	assert.Equal(t, "fast.hits", fast.Name)
+	sort.Slice(fast.Points, func(i, j int) bool { return fast.Points[i].Ts < fast.Points[j].Ts })
+	assert.Equal(t, []metrics.Point{
+		{Ts: 12340.0, Value: 0},
+		{Ts: 12341.0, Value: 2},
+		{Ts: 12343.0, Value: 2},
+	}, fast.Points)
+
+	slow := series[1]
+	assert.Equal(t, "slow.hits", slow.Name)
+	assert.Equal(t, []metrics.Point{{Ts: 12340.0, Value: 0.4}}, slow.Points)
+
+	// without samples, the counters get a zero in the last sampler bucket
+	series, _ = flushSerie(sampler, 12360.0)
+	require.Len(t, series, 2)
+	for _, serie := range series {
+		assert.Equal(t, []metrics.Point{{Ts: 12350.0, Value: 0}}, serie.Points)
+	}
+}
+
+func TestOverrideIntervalCounter(t *testing.T) {
+	testWithTagsStore(t, testOverrideIntervalCounter)
+}
+
+func testOverrideIntervalDistribution(t *testing.T, store *tags.Store) {
+	sampler := testOverridesSampler(t, store, []metricOverrideConfig{{Metric: "fast.*", Interval: 2}})
+
+	for _, ts := range []float64{12341.0, 12342.0, 12343.0} {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "fast.latency",
+			Value:      ts - 12340,
+			Mtype:      metrics.DistributionType,
+			SampleRate: 1,
+		}, ts)
+	}
+
+	_, sketches := flushSerie(sampler, 12350.0)
+	require.Len(t, sketches, 1)
+	assert.Equal(t, int64(2), sketches[0].Interval)
+
+	points := sketches[0].Points
+	sort.Slice(points, func(i, j int) bool { return points[i].Ts < points[j].Ts })
+	require.Len(t, points, 2)
+	assert.Equal(t, int64(12340), points[0].Ts)
+	assert.Equal(t, int64(1), points[0].Sketch.Basic.Cnt)
+	assert.Equal(t, int64(12342), points[1].Ts)
+	assert.Equal(t, int64(2), points[1].Sketch.Basic.Cnt)
+}
+
+func TestOverrideIntervalDistribution(t *testing.T) {
+	testWithTagsStore(t, testOverrideIntervalDistribution)
+}
+
+func testOverrideHistogram(t *testing.T, store *tags.Store) {
+	sampler := testOverridesSampler(t, store, []metricOverrideConfig{
+		{Metric: "custom.*", HistogramAggregates: []string{"min", "max"}, HistogramPercentiles: []string{"0.5", "0.99"}},
+	})
+
+	for _, name := range []string{"custom.latency", "default.latency"} {
+		for _, v := range []float64{1, 2, 3} {
+			sampler.sample(&metrics.MetricSample{
+				Name:       name,
+				Value:      v,
+				Mtype:      metrics.HistogramType,
+				SampleRate: 1,
+			}, 12345.0)
+		}
+	}
+
+	series, _ := flushSerie(sampler, 12350.0)
+	var names []string
+	for _, serie := range series {
+		names = append(names, serie.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"custom.latency.min",
+		"custom.latency.max",
+		"custom.latency.50percentile",
+		"custom.latency.99percentile",
+		"default.latency.max",
+		"default.latency.median",
+		"default.latency.avg",
+		"default.latency.count",
+		"default.latency.95percentile",
+	}, names)
+}
+
+func TestOverrideHistogram(t *testing.T) {
+	testWithTagsStore(t, testOverrideHistogram)
+}
+
+func testCheckOverrideHistogram(t *testing.T, store *tags.Store) {
+	overrides, err := newMetricOverrides([]metricOverrideConfig{
+		{Metric: "custom.*", Interval: 1, HistogramAggregates: []string{"sum"}, HistogramPercentiles: []string{}},
+	})
+	require.NoError(t, err)
+	checkSampler := newCheckSampler(1, true, true, 1*time.Second, overrides, store, checkid.ID("hello:world:1234"))
+
+	for _, v := range []float64{1, 2, 3} {
+		checkSampler.addSample(&metrics.MetricSample{
+			Name:       "custom.latency",
+			Value:      v,
+			Mtype:      metrics.HistogramType,
+			SampleRate: 1,
+			Timestamp:  12345.0,
+		})
+	}
+
+	checkSampler.commit(12349.0)
+	series, _ := checkSampler.flush()
+	require.Len(t, series, 1)
+	assert.Equal(t, "custom.latency.sum", series[0].Name)
+	assert.Equal(t, []metrics.Point{{Ts: 12349.0, Value: 6}}, series[0].Points)
+}
+
+func TestCheckOverrideHistogram(t *testing.T) {
+	testWithTagsStore(t, testCheckOverrideHistogram)
+}
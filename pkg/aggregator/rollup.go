@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const rollupRulesConfigKey = "aggregator_rollup_rules"
+
+// rollupMetricTypes are the metric types whose samples can be merged across
+// contexts: counters are summed, and histogram, distribution and set samples
+// are added to the same aggregate. Gauges are never rolled up, as keeping the
+// last value of one of the merged contexts would be misleading.
+var rollupMetricTypes = map[metrics.MetricType]struct{}{
+	metrics.CountType:        {},
+	metrics.CounterType:      {},
+	metrics.HistogramType:    {},
+	metrics.SetType:          {},
+	metrics.DistributionType: {},
+}
+
+// rollupRuleConfig is the user-facing configuration of a roll-up rule
+type rollupRuleConfig struct {
+	Metric   string   `mapstructure:"metric"`
+	DropTags []string `mapstructure:"drop_tags"`
+}
+
+// rollupRule aggregates away some tags of the metrics whose name matches a
+// glob pattern.
+type rollupRule struct {
+	pattern  string
+	metric   glob.Glob
+	dropTags map[string]struct{}
+}
+
+// rollupRules is an ordered list of roll-up rules. Only the first rule
+// matching a metric is applied.
+type rollupRules struct {
+	rules []*rollupRule
+}
+
+// newRollupRulesFromConfig returns the rules configured in
+// `aggregator_rollup_rules`, or nil if none is configured.
+func newRollupRulesFromConfig(cfg model.Reader) (*rollupRules, error) {
+	if !cfg.IsSet(rollupRulesConfigKey) {
+		return nil, nil
+	}
+
+	var configs []rollupRuleConfig
+	if err := cfg.UnmarshalKey(rollupRulesConfigKey, &configs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", rollupRulesConfigKey, err)
+	}
+
+	return newRollupRules(configs)
+}
+
+func newRollupRules(configs []rollupRuleConfig) (*rollupRules, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	rr := &rollupRules{
+		rules: make([]*rollupRule, 0, len(configs)),
+	}
+
+	for i, c := range configs {
+		if c.Metric == "" {
+			return nil, fmt.Errorf("invalid roll-up rule #%d: metric is required", i)
+		}
+		if len(c.DropTags) == 0 {
+			return nil, fmt.Errorf("invalid roll-up rule #%d: drop_tags is required", i)
+		}
+
+		g, err := glob.Compile(c.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid roll-up rule #%d: invalid metric pattern %q: %w", i, c.Metric, err)
+		}
+
+		rule := &rollupRule{
+			pattern:  c.Metric,
+			metric:   g,
+			dropTags: make(map[string]struct{}, len(c.DropTags)),
+		}
+		for _, tag := range c.DropTags {
+			if tag == "" {
+				return nil, fmt.Errorf("invalid roll-up rule #%d: empty tag name", i)
+			}
+			rule.dropTags[tag] = struct{}{}
+		}
+
+		rr.rules = append(rr.rules, rule)
+	}
+
+	return rr, nil
+}
+
+// match returns the rule to apply to a metric, or nil if none matches
+func (rr *rollupRules) match(name string, mtype metrics.MetricType) *rollupRule {
+	if rr == nil {
+		return nil
+	}
+
+	if _, ok := rollupMetricTypes[mtype]; !ok {
+		return nil
+	}
+
+	for _, rule := range rr.rules {
+		if rule.metric.Match(name) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// apply removes the tags dropped by the rule from the buffers and returns
+// whether any tag was removed.
+func (r *rollupRule) apply(buffers ...*tagset.HashingTagsAccumulator) bool {
+	removed := 0
+	for _, tb := range buffers {
+		removed += tb.RetainFunc(r.keep)
+	}
+	return removed > 0
+}
+
+func (r *rollupRule) keep(tag string) bool {
+	name, _, _ := strings.Cut(tag, ":")
+	_, drop := r.dropTags[name]
+	return !drop
+}
+
+// maxRollupSources bounds the number of source contexts whose roll-up is
+// cached by a resolver. The cache is reset once it's full.
+const maxRollupSources = 100000
+
+// rollupSource is the cached roll-up of a source context, the context a
+// sample would have without roll-up.
+type rollupSource struct {
+	// rule is the rule applied to the source context, nil if none applies
+	rule *rollupRule
+	// contextKey, taggerKey and metricKey are the keys of the rolled-up
+	// context, the keys of the source context if no rule applies
+	contextKey ckey.ContextKey
+	taggerKey  ckey.TagsKey
+	metricKey  ckey.TagsKey
+	// flushID is the flush interval the source was last counted in
+	flushID uint64
+}
+
+// rollupTracker caches the roll-up of the source contexts, so that the rules
+// are only matched and the rolled-up keys only generated once per source
+// context. It also counts, for each rolled-up context, the source contexts
+// merged into it since the last flush.
+type rollupTracker struct {
+	sources map[ckey.ContextKey]*rollupSource
+	counts  map[ckey.ContextKey]int
+	rules   map[ckey.ContextKey]*rollupRule
+	flushID uint64
+}
+
+func newRollupTracker() *rollupTracker {
+	return &rollupTracker{
+		sources: make(map[ckey.ContextKey]*rollupSource),
+		counts:  make(map[ckey.ContextKey]int),
+		rules:   make(map[ckey.ContextKey]*rollupRule),
+		flushID: 1,
+	}
+}
+
+func (t *rollupTracker) get(sourceKey ckey.ContextKey) (*rollupSource, bool) {
+	source, found := t.sources[sourceKey]
+	return source, found
+}
+
+func (t *rollupTracker) add(sourceKey ckey.ContextKey, source *rollupSource) {
+	if len(t.sources) >= maxRollupSources {
+		t.sources = make(map[ckey.ContextKey]*rollupSource)
+	}
+	t.sources[sourceKey] = source
+}
+
+// forget removes a source context from the cache
+func (t *rollupTracker) forget(sourceKey ckey.ContextKey) {
+	delete(t.sources, sourceKey)
+}
+
+// track counts the source as merged into its rolled-up context, once per
+// flush interval
+func (t *rollupTracker) track(source *rollupSource) {
+	if source.rule == nil || source.flushID == t.flushID {
+		return
+	}
+	source.flushID = t.flushID
+	t.counts[source.contextKey]++
+	t.rules[source.contextKey] = source.rule
+}
+
+// flush reports the number of contexts saved by each rule and resets the
+// counts.
+func (t *rollupTracker) flush(rr *rollupRules, id string, gauge telemetry.Gauge) {
+	if rr == nil {
+		return
+	}
+
+	saved := make(map[*rollupRule]int, len(rr.rules))
+	for contextKey, count := range t.counts {
+		saved[t.rules[contextKey]] += count - 1
+	}
+
+	for _, rule := range rr.rules {
+		gauge.Set(float64(saved[rule]), id, rule.pattern)
+	}
+
+	t.counts = make(map[ckey.ContextKey]int)
+	t.rules = make(map[ckey.ContextKey]*rollupRule)
+	t.flushID++
+}
@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func testRollupSampler(t *testing.T, store *tags.Store, configs []rollupRuleConfig) *TimeSampler {
+	rules, err := newRollupRules(configs)
+	require.NoError(t, err)
+
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, store, "host")
+	sampler.contextResolver = newTimestampContextResolver(store, "0", rules, nil)
+	return sampler
+}
+
+func TestNewRollupRules(t *testing.T) {
+	rules, err := newRollupRules(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+
+	_, err = newRollupRules([]rollupRuleConfig{{DropTags: []string{"pod_name"}}})
+	assert.Error(t, err)
+
+	_, err = newRollupRules([]rollupRuleConfig{{Metric: "http.*"}})
+	assert.Error(t, err)
+
+	_, err = newRollupRules([]rollupRuleConfig{{Metric: "http.[", DropTags: []string{"pod_name"}}})
+	assert.Error(t, err)
+
+	_, err = newRollupRules([]rollupRuleConfig{{Metric: "http.*", DropTags: []string{""}}})
+	assert.Error(t, err)
+
+	rules, err = newRollupRules([]rollupRuleConfig{
+		{Metric: "http.requests", DropTags: []string{"container_id"}},
+		{Metric: "http.*", DropTags: []string{"pod_name"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules.rules, 2)
+
+	assert.Equal(t, "http.requests", rules.match("http.requests", metrics.CounterType).pattern)
+	assert.Equal(t, "http.*", rules.match("http.latency", metrics.DistributionType).pattern)
+	assert.Nil(t, rules.match("http.latency", metrics.GaugeType))
+	assert.Nil(t, rules.match("db.queries", metrics.CounterType))
+}
+
+func TestNewRollupRulesFromConfig(t *testing.T) {
+	cfg := config.Mock(t)
+
+	rules, err := newRollupRulesFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+
+	cfg.SetWithoutSource(rollupRulesConfigKey, []map[string]interface{}{
+		{"metric": "http.*", "drop_tags": []string{"pod_name", "container_id"}},
+	})
+	rules, err = newRollupRulesFromConfig(cfg)
+	require.NoError(t, err)
+	require.Len(t, rules.rules, 1)
+	assert.Equal(t, "http.*", rules.rules[0].pattern)
+	assert.Len(t, rules.rules[0].dropTags, 2)
+}
+
+func TestRollupRuleApply(t *testing.T) {
+	rule := &rollupRule{dropTags: map[string]struct{}{"pod_name": {}, "flag": {}}}
+
+	taggerTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"pod_name:a", "env:prod"})
+	metricTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"flag", "pod_name_suffix:b", "endpoint:/"})
+	assert.True(t, rule.apply(taggerTags, metricTags))
+	assert.Equal(t, []string{"env:prod"}, taggerTags.Get())
+	assert.Equal(t, []string{"pod_name_suffix:b", "endpoint:/"}, metricTags.Get())
+
+	assert.False(t, rule.apply(taggerTags, metricTags))
+}
+
+func testRollupCounters(t *testing.T, store *tags.Store) {
+	sampler := testRollupSampler(t, store, []rollupRuleConfig{{Metric: "http.*", DropTags: []string{"pod_name"}}})
+
+	for _, pod := range []string{"a", "b", "c"} {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "http.requests",
+			Value:      2,
+			Mtype:      metrics.CounterType,
+			Tags:       []string{"env:prod", "pod_name:" + pod},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+	// gauges are never rolled up
+	for _, pod := range []string{"a", "b"} {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "http.inflight",
+			Value:      1,
+			Mtype:      metrics.GaugeType,
+			Tags:       []string{"env:prod", "pod_name:" + pod},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+
+	series, _ := flushSerie(sampler, 12360.0)
+	require.Len(t, series, 3)
+	assert.Equal(t, float64(2), tlmDogstatsdRollupContextsSaved.WithValues("0", "http.*").Get())
+
+	var counters []*metrics.Serie
+	for _, serie := range series {
+		if serie.Name == "http.requests" {
+			counters = append(counters, serie)
+		}
+	}
+	require.Len(t, counters, 1)
+	metrics.AssertSerieEqual(t, &metrics.Serie{
+		Name:     "http.requests",
+		Tags:     tagset.CompositeTagsFromSlice([]string{"env:prod"}),
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 0.6}},
+		MType:    metrics.APIRateType,
+		Interval: 10,
+	}, counters[0])
+
+	// the tracked contexts are reset on each flush
+	flushSerie(sampler, 12370.0)
+	assert.Equal(t, float64(0), tlmDogstatsdRollupContextsSaved.WithValues("0", "http.*").Get())
+}
+
+func TestRollupCounters(t *testing.T) {
+	testWithTagsStore(t, testRollupCounters)
+}
+
+func testRollupCache(t *testing.T, store *tags.Store) {
+	sampler := testRollupSampler(t, store, []rollupRuleConfig{{Metric: "http.*", DropTags: []string{"pod_name"}}})
+	sample := func(pod string) {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "http.requests",
+			Value:      1,
+			Mtype:      metrics.CounterType,
+			Tags:       []string{"env:prod", "pod_name:" + pod},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+
+	// each source context is resolved once and counted once per interval
+	for i := 0; i < 3; i++ {
+		sample("a")
+		sample("b")
+	}
+	tracker := sampler.contextResolver.resolver.rollupTracker
+	assert.Len(t, tracker.sources, 2)
+	assert.Len(t, tracker.counts, 1)
+
+	flushSerie(sampler, 12360.0)
+	assert.Equal(t, float64(1), tlmDogstatsdRollupContextsSaved.WithValues("0", "http.*").Get())
+	assert.Empty(t, tracker.counts)
+
+	// a source seen again is counted again in the next interval
+	sample("b")
+	flushSerie(sampler, 12370.0)
+	assert.Equal(t, float64(0), tlmDogstatsdRollupContextsSaved.WithValues("0", "http.*").Get())
+	assert.Len(t, tracker.sources, 2)
+}
+
+func TestRollupCache(t *testing.T) {
+	testWithTagsStore(t, testRollupCache)
+}
+
+func TestRollupTrackerBound(t *testing.T) {
+	tracker := newRollupTracker()
+	for i := 0; i < maxRollupSources; i++ {
+		tracker.add(ckey.ContextKey(i), &rollupSource{})
+	}
+	assert.Len(t, tracker.sources, maxRollupSources)
+
+	tracker.add(ckey.ContextKey(maxRollupSources), &rollupSource{})
+	assert.Len(t, tracker.sources, 1)
+}
+
+func testRollupDistributions(t *testing.T, store *tags.Store) {
+	sampler := testRollupSampler(t, store, []rollupRuleConfig{{Metric: "http.latency", DropTags: []string{"pod_name"}}})
+
+	values := []float64{1, 2, 3, 4}
+	for i, v := range values {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "http.latency",
+			Value:      v,
+			Mtype:      metrics.DistributionType,
+			Tags:       []string{"env:prod", "pod_name:" + string(rune('a'+i%2))},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+
+	_, sketches := flushSerie(sampler, 12360.0)
+	require.Len(t, sketches, 1)
+	assert.Equal(t, []string{"env:prod"}, sketches[0].Tags.UnsafeToReadOnlySliceString())
+	require.Len(t, sketches[0].Points, 1)
+
+	sketch := sketches[0].Points[0].Sketch
+	assert.Equal(t, int64(len(values)), sketch.Basic.Cnt)
+	assert.Equal(t, float64(10), sketch.Basic.Sum)
+	assert.Equal(t, float64(1), sketch.Basic.Min)
+	assert.Equal(t, float64(4), sketch.Basic.Max)
+}
+
+func TestRollupDistributions(t *testing.T) {
+	testWithTagsStore(t, testRollupDistributions)
+}
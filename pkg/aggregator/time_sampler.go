@@ -53,9 +53,23 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, hostnam
 	idString := strconv.Itoa(int(id))
 	log.Infof("Creating TimeSampler #%s", idString)
 
+	rollup, err := newRollupRulesFromConfig(config.Datadog)
+	if err != nil {
+		log.Errorf("TimeSampler #%s: contexts won't be rolled up: %s", idString, err)
+	}
+
+	overrides, err := newMetricOverridesFromConfig(config.Datadog)
+	if err != nil {
+		log.Errorf("TimeSampler #%s: metric overrides won't be applied: %s", idString, err)
+	}
+	overrides, errs := overrides.withInterval(interval)
+	for _, err := range errs {
+		log.Warnf("TimeSampler #%s: %s", idString, err)
+	}
+
 	s := &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache, idString),
+		contextResolver:             newTimestampContextResolver(cache, idString, rollup, overrides),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
@@ -68,11 +82,18 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, hostnam
 }
 
 func (s *TimeSampler) calculateBucketStart(timestamp float64) int64 {
-	return int64(timestamp) - int64(timestamp)%s.interval
+	return bucketStart(timestamp, s.interval)
+}
+
+func bucketStart(timestamp float64, interval int64) int64 {
+	return int64(timestamp) - int64(timestamp)%interval
 }
 
+// isBucketStillOpen returns whether the bucket starting at bucketStartTimestamp is still open
+// at timestamp. The shorter buckets of metrics overriding the interval are closed at the same
+// time as the sampler bucket they belong to.
 func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) bool {
-	return bucketStartTimestamp+s.interval > timestamp
+	return s.calculateBucketStart(float64(bucketStartTimestamp))+s.interval > timestamp
 }
 
 func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float64) {
@@ -83,7 +104,9 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 
 	// Keep track of the context
 	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
-	bucketStart := s.calculateBucketStart(timestamp)
+	override := s.contextResolver.override(contextKey)
+	interval := override.intervalOr(s.interval)
+	bucketStart := bucketStart(timestamp, interval)
 
 	switch metricSample.Mtype {
 	case metrics.DistributionType:
@@ -101,7 +124,7 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 		}
 
 		// Add sample to bucket
-		if err := bucketMetrics.AddSample(contextKey, metricSample, timestamp, s.interval, nil, config.Datadog); err != nil {
+		if err := bucketMetrics.AddSampleWithHistogramConfig(contextKey, metricSample, timestamp, interval, nil, config.Datadog, override.histogramConfig()); err != nil {
 			log.Debugf("TimeSampler #%d Ignoring sample '%s' on host '%s' and tags '%s': %s", s.id, metricSample.Name, metricSample.Host, metricSample.Tags, err)
 		}
 	}
@@ -117,7 +140,7 @@ func (s *TimeSampler) newSketchSeries(ck ckey.ContextKey, points []metrics.Sketc
 		Name:       ctx.Name,
 		Tags:       ctx.Tags(),
 		Host:       ctx.Host,
-		Interval:   s.interval,
+		Interval:   s.contextResolver.override(ck).intervalOr(s.interval),
 		Points:     points,
 		ContextKey: ck,
 		Source:     ctx.source,
@@ -133,6 +156,16 @@ func (s *TimeSampler) flushSeries(cutoffTime int64, series metrics.SerieSink) {
 	contextMetricsFlusher := metrics.NewContextMetricsFlusher()
 
 	if len(s.metricsByTimestamp) > 0 {
+		// The counters are zero-filled in the buckets of their own interval, make sure
+		// the sampler bucket of the shorter buckets of the overrides exists so that the
+		// other counters get their zero too
+		for bucketTimestamp := range s.metricsByTimestamp {
+			samplerBucket := s.calculateBucketStart(float64(bucketTimestamp))
+			if _, found := s.metricsByTimestamp[samplerBucket]; !found && !s.isBucketStillOpen(bucketTimestamp, cutoffTime) {
+				s.metricsByTimestamp[samplerBucket] = metrics.MakeContextMetrics()
+			}
+		}
+
 		for bucketTimestamp, contextMetrics := range s.metricsByTimestamp {
 			// disregard when the timestamp is too recent
 			if s.isBucketStillOpen(bucketTimestamp, cutoffTime) {
@@ -196,7 +229,7 @@ func (s *TimeSampler) dedupSerieBySerieSignature(
 			serie.Tags = context.Tags()
 			serie.Host = context.Host
 			serie.NoIndex = context.noIndex
-			serie.Interval = s.interval
+			serie.Interval = s.contextResolver.override(serie.ContextKey).intervalOr(s.interval)
 			serie.Source = context.source
 
 			serieBySignature[serieSignature] = serie
@@ -262,6 +295,7 @@ func (s *TimeSampler) updateMetrics() {
 		aggregatorDogstatsdContextsByMtype[i].Set(int64(count))
 	}
 	s.contextResolver.updateMetrics(tlmDogstatsdContextsByMtype, tlmDogstatsdContextsBytesByMtype)
+	s.contextResolver.updateRollupMetrics(tlmDogstatsdRollupContextsSaved)
 }
 
 // flushContextMetrics flushes the contextMetrics inside contextMetricsFlusher, handles its errors,
@@ -281,6 +315,13 @@ func (s *TimeSampler) flushContextMetrics(contextMetricsFlusher *metrics.Context
 func (s *TimeSampler) countersSampleZeroValue(timestamp int64, contextMetrics metrics.ContextMetrics, counterContextsToDelete map[ckey.ContextKey]struct{}) {
 	expirySeconds := config.Datadog.GetFloat64("dogstatsd_expiry_seconds")
 	for counterContext, lastSampled := range s.counterLastSampledByContext {
+		// Only add zeros to the buckets of the interval of the counter, the shorter
+		// buckets of the overrides don't line up with the ones of the other counters
+		interval := s.contextResolver.override(counterContext).intervalOr(s.interval)
+		if bucketStart(float64(timestamp), interval) != timestamp {
+			continue
+		}
+
 		if expirySeconds+lastSampled > float64(timestamp) {
 			sample := &metrics.MetricSample{
 				Name:       "",
@@ -294,7 +335,7 @@ func (s *TimeSampler) countersSampleZeroValue(timestamp int64, contextMetrics me
 			}
 			// Add a zero value sample to the counter
 			// It is ok to add a 0 sample to a counter that was already sampled in the bucket, it won't change its value
-			contextMetrics.AddSample(counterContext, sample, float64(timestamp), s.interval, nil, config.Datadog) //nolint:errcheck
+			contextMetrics.AddSample(counterContext, sample, float64(timestamp), interval, nil, config.Datadog) //nolint:errcheck
 
 			// Update the tracked context so that the contextResolver doesn't expire counter contexts too early
 			// i.e. while we are still sending zeros for them
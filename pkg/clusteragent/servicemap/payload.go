@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package servicemap builds the service-to-service dependency map of the
+// cluster from the connections observed by the node agents.
+//
+// A connection between two hosts of the cluster is reported by the agents of
+// both hosts, usually with different addresses when it goes through a
+// Kubernetes service: the client sees the cluster IP of the service while the
+// server sees the pod IP of the client, or the IP of its node if it's
+// masqueraded. The node agents report the NAT translations resolved from
+// conntrack, which are used by the cluster agent to pair both ends of the
+// connection and count it once.
+package servicemap
+
+import (
+	"strings"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+)
+
+// ConnectionSummaries is the payload sent by the node agents
+type ConnectionSummaries struct {
+	Hostname    string              `json:"hostname"`
+	Connections []ConnectionSummary `json:"connections"`
+}
+
+// Endpoint is an end of a connection
+type Endpoint struct {
+	IP   string `json:"ip"`
+	Port int32  `json:"port"`
+}
+
+// ConnectionSummary is a connection observed by a node agent
+type ConnectionSummary struct {
+	// Protocol is tcp or udp
+	Protocol string `json:"protocol"`
+	// Outgoing is true if the connection was initiated by the local end
+	Outgoing bool `json:"outgoing"`
+	// Local and Remote are the ends of the connection as seen by the socket
+	Local  Endpoint `json:"local"`
+	Remote Endpoint `json:"remote"`
+	// TranslatedLocal and TranslatedRemote are the ends of the connection
+	// after the NAT translation, as seen by the remote end
+	TranslatedLocal  *Endpoint `json:"translated_local,omitempty"`
+	TranslatedRemote *Endpoint `json:"translated_remote,omitempty"`
+	BytesSent        uint64    `json:"bytes_sent"`
+	BytesReceived    uint64    `json:"bytes_received"`
+}
+
+// NewConnectionSummaries returns the summaries of the connections collected
+// from system-probe. The local connections and the connections without
+// traffic since the previous collection are skipped.
+func NewConnectionSummaries(hostname string, conns []*model.Connection) *ConnectionSummaries {
+	summaries := &ConnectionSummaries{Hostname: hostname}
+	for _, conn := range conns {
+		if conn.Laddr == nil || conn.Raddr == nil || conn.Direction == model.ConnectionDirection_local {
+			continue
+		}
+		if conn.LastBytesSent == 0 && conn.LastBytesReceived == 0 {
+			continue
+		}
+
+		summary := ConnectionSummary{
+			Protocol:      strings.ToLower(conn.Type.String()),
+			Outgoing:      conn.Direction != model.ConnectionDirection_incoming,
+			Local:         Endpoint{IP: conn.Laddr.Ip, Port: conn.Laddr.Port},
+			Remote:        Endpoint{IP: conn.Raddr.Ip, Port: conn.Raddr.Port},
+			BytesSent:     conn.LastBytesSent,
+			BytesReceived: conn.LastBytesReceived,
+		}
+		// the translation is the tuple of the replies: their source is the
+		// remote end after the DNAT, their destination the local end after
+		// the SNAT
+		if t := conn.IpTranslation; t != nil {
+			summary.TranslatedLocal = &Endpoint{IP: t.ReplDstIP, Port: t.ReplDstPort}
+			summary.TranslatedRemote = &Endpoint{IP: t.ReplSrcIP, Port: t.ReplSrcPort}
+		}
+		summaries.Connections = append(summaries.Connections, summary)
+	}
+	return summaries
+}
+
+// wireLocal returns the local end as seen by the remote end
+func (c *ConnectionSummary) wireLocal() Endpoint {
+	if c.TranslatedLocal != nil {
+		return *c.TranslatedLocal
+	}
+	return c.Local
+}
+
+// wireRemote returns the remote end after the translation
+func (c *ConnectionSummary) wireRemote() Endpoint {
+	if c.TranslatedRemote != nil {
+		return *c.TranslatedRemote
+	}
+	return c.Remote
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+package servicemap
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// indexRefreshInterval is the maximum age of the index of the IPs
+const indexRefreshInterval = 30 * time.Second
+
+// kubeResolver resolves the IPs with the pods and services of the cluster
+type kubeResolver struct {
+	pods     corelisters.PodLister
+	services corelisters.ServiceLister
+
+	mu        sync.Mutex
+	byIP      map[string]*Workload
+	indexedAt time.Time
+}
+
+// NewKubeResolver returns a resolver using the pod and service informers of
+// the factory, which must be started by the caller
+func NewKubeResolver(factory informers.SharedInformerFactory) MetadataResolver {
+	return &kubeResolver{
+		pods:     factory.Core().V1().Pods().Lister(),
+		services: factory.Core().V1().Services().Lister(),
+	}
+}
+
+// Resolve implements MetadataResolver#Resolve
+func (r *kubeResolver) Resolve(ip string) *Workload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.byIP == nil || time.Since(r.indexedAt) > indexRefreshInterval {
+		r.index()
+	}
+	return r.byIP[ip]
+}
+
+func (r *kubeResolver) index() {
+	r.byIP = make(map[string]*Workload)
+	r.indexedAt = time.Now()
+
+	services, err := r.services.List(labels.Everything())
+	if err != nil {
+		log.Warnf("Unable to list the services for the service map: %v", err)
+	}
+	servicesByNamespace := make(map[string][]*v1.Service)
+	for _, service := range services {
+		servicesByNamespace[service.Namespace] = append(servicesByNamespace[service.Namespace], service)
+		for _, clusterIP := range service.Spec.ClusterIPs {
+			if clusterIP == "" || clusterIP == v1.ClusterIPNone {
+				continue
+			}
+			r.byIP[clusterIP] = &Workload{Namespace: service.Namespace, Services: []string{service.Name}}
+		}
+	}
+
+	pods, err := r.pods.List(labels.Everything())
+	if err != nil {
+		log.Warnf("Unable to list the pods for the service map: %v", err)
+	}
+	for _, pod := range pods {
+		// the pods of the host network share the IP of their node
+		if pod.Spec.HostNetwork || len(pod.Status.PodIPs) == 0 {
+			continue
+		}
+		workload := &Workload{Namespace: pod.Namespace, Pod: pod.Name}
+		for _, service := range servicesByNamespace[pod.Namespace] {
+			if len(service.Spec.Selector) == 0 {
+				continue
+			}
+			if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+				workload.Services = append(workload.Services, service.Name)
+			}
+		}
+		sort.Strings(workload.Services)
+		for _, podIP := range pod.Status.PodIPs {
+			r.byIP[podIP.IP] = workload
+		}
+	}
+}
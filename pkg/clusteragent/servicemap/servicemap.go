@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package servicemap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Workload is an end of the dependencies, identified by its Kubernetes
+// services, or its pod or IP when it isn't part of a service
+type Workload struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Services  []string `json:"services,omitempty"`
+	Pod       string   `json:"pod,omitempty"`
+	IP        string   `json:"ip,omitempty"`
+}
+
+// MetadataResolver resolves the IPs of the connections to Kubernetes workloads
+type MetadataResolver interface {
+	// Resolve returns the pod or service with the IP, nil if it's unknown
+	Resolve(ip string) *Workload
+}
+
+// Dependency is the traffic from a client workload to a server workload
+type Dependency struct {
+	Client   Workload `json:"client"`
+	Server   Workload `json:"server"`
+	Protocol string   `json:"protocol"`
+	Port     int32    `json:"port"`
+	// Connections is the number of connections, each connection is counted
+	// once even if it's reported by the hosts of both ends
+	Connections int `json:"connections"`
+	// Paired is the number of connections reported by both ends
+	Paired int    `json:"paired"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+// ServiceMap is the deduplicated dependency map of the cluster
+type ServiceMap struct {
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+type nodeReport struct {
+	connections []ConnectionSummary
+	receivedAt  time.Time
+}
+
+// Store keeps the last connection summaries of each node
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	reports map[string]nodeReport
+	timeNow func() time.Time
+}
+
+// NewStore returns a store forgetting the nodes without report since ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		reports: make(map[string]nodeReport),
+		timeNow: time.Now,
+	}
+}
+
+// Add replaces the connections of the node of the summaries
+func (s *Store) Add(summaries *ConnectionSummaries) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports[summaries.Hostname] = nodeReport{
+		connections: summaries.Connections,
+		receivedAt:  s.timeNow(),
+	}
+}
+
+// Build returns the dependency map of the connections of the nodes
+func (s *Store) Build(resolver MetadataResolver) *ServiceMap {
+	s.mu.Lock()
+	now := s.timeNow()
+	var conns []*ConnectionSummary
+	for hostname, report := range s.reports {
+		if now.Sub(report.receivedAt) > s.ttl {
+			delete(s.reports, hostname)
+			continue
+		}
+		for i := range report.connections {
+			conns = append(conns, &report.connections[i])
+		}
+	}
+	s.mu.Unlock()
+
+	return buildServiceMap(conns, resolver)
+}
+
+// tupleKey identifies a connection on the wire, from the client to the server
+func tupleKey(protocol string, client, server Endpoint) string {
+	return fmt.Sprintf("%s|%s:%d|%s:%d", protocol, client.IP, client.Port, server.IP, server.Port)
+}
+
+// connection is a connection with both ends resolved
+type connection struct {
+	protocol string
+	clientIP string
+	serverIP string
+	// serviceIP is the address dialed by the client, which may be the
+	// cluster IP of a service
+	serviceIP  string
+	serverPort int32
+	bytes      uint64
+	paired     bool
+}
+
+// pairConnections deduplicates the connections reported by both ends
+func pairConnections(conns []*ConnectionSummary) []connection {
+	// the incoming connections are indexed with the tuples before and after
+	// their translation, as the NAT may happen on the node of the client or
+	// the node of the server
+	servers := make(map[string][]*ConnectionSummary)
+	for _, conn := range conns {
+		if conn.Outgoing {
+			continue
+		}
+		key := tupleKey(conn.Protocol, conn.Remote, conn.Local)
+		servers[key] = append(servers[key], conn)
+		if conn.TranslatedLocal != nil || conn.TranslatedRemote != nil {
+			translatedKey := tupleKey(conn.Protocol, conn.wireRemote(), conn.wireLocal())
+			if translatedKey != key {
+				servers[translatedKey] = append(servers[translatedKey], conn)
+			}
+		}
+	}
+
+	paired := make(map[*ConnectionSummary]bool)
+	var result []connection
+	for _, conn := range conns {
+		if !conn.Outgoing {
+			continue
+		}
+		remote := conn.wireRemote()
+		c := connection{
+			protocol:   conn.Protocol,
+			clientIP:   conn.Local.IP,
+			serverIP:   remote.IP,
+			serviceIP:  conn.Remote.IP,
+			serverPort: remote.Port,
+			bytes:      conn.BytesSent + conn.BytesReceived,
+		}
+		for _, server := range servers[tupleKey(conn.Protocol, conn.wireLocal(), remote)] {
+			if paired[server] {
+				continue
+			}
+			paired[server] = true
+			c.paired = true
+			c.serverIP, c.serverPort = server.Local.IP, server.Local.Port
+			break
+		}
+		result = append(result, c)
+	}
+
+	for _, conn := range conns {
+		if conn.Outgoing || paired[conn] {
+			continue
+		}
+		result = append(result, connection{
+			protocol:   conn.Protocol,
+			clientIP:   conn.Remote.IP,
+			serverIP:   conn.Local.IP,
+			serviceIP:  conn.wireLocal().IP,
+			serverPort: conn.Local.Port,
+			bytes:      conn.BytesSent + conn.BytesReceived,
+		})
+	}
+	return result
+}
+
+// workloadResolver resolves and caches the workloads of the IPs
+type workloadResolver struct {
+	resolver MetadataResolver
+	cache    map[string]*Workload
+}
+
+func (r *workloadResolver) resolve(ip string) *Workload {
+	if workload, ok := r.cache[ip]; ok {
+		return workload
+	}
+	var workload *Workload
+	if r.resolver != nil {
+		workload = r.resolver.Resolve(ip)
+	}
+	r.cache[ip] = workload
+	return workload
+}
+
+// workload returns the workload of the IP, reduced to its services when
+// it's part of services
+func (r *workloadResolver) workload(ip string) Workload {
+	resolved := r.resolve(ip)
+	switch {
+	case resolved == nil:
+		return Workload{IP: ip}
+	case len(resolved.Services) > 0:
+		return Workload{Namespace: resolved.Namespace, Services: resolved.Services}
+	default:
+		return Workload{Namespace: resolved.Namespace, Pod: resolved.Pod}
+	}
+}
+
+// server returns the workload of the server, the service dialed by the
+// client takes precedence over the services of the pod
+func (r *workloadResolver) server(c connection) Workload {
+	if c.serviceIP != "" && c.serviceIP != c.serverIP {
+		if service := r.resolve(c.serviceIP); service != nil && len(service.Services) > 0 {
+			return r.workload(c.serviceIP)
+		}
+	}
+	return r.workload(c.serverIP)
+}
+
+func (w Workload) key() string {
+	switch {
+	case len(w.Services) > 0:
+		return w.Namespace + "/" + strings.Join(w.Services, ",")
+	case w.Pod != "":
+		return w.Namespace + "/pod/" + w.Pod
+	default:
+		return w.IP
+	}
+}
+
+func buildServiceMap(conns []*ConnectionSummary, resolver MetadataResolver) *ServiceMap {
+	workloads := &workloadResolver{
+		resolver: resolver,
+		cache:    make(map[string]*Workload),
+	}
+
+	dependencies := make(map[string]*Dependency)
+	for _, c := range pairConnections(conns) {
+		client, server := workloads.workload(c.clientIP), workloads.server(c)
+		key := fmt.Sprintf("%s|%s|%s|%d", client.key(), server.key(), c.protocol, c.serverPort)
+		dependency, ok := dependencies[key]
+		if !ok {
+			dependency = &Dependency{
+				Client:   client,
+				Server:   server,
+				Protocol: c.protocol,
+				Port:     c.serverPort,
+			}
+			dependencies[key] = dependency
+		}
+		dependency.Connections++
+		dependency.Bytes += c.bytes
+		if c.paired {
+			dependency.Paired++
+		}
+	}
+
+	keys := make([]string, 0, len(dependencies))
+	for key := range dependencies {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		bi, bj := dependencies[keys[i]].Bytes, dependencies[keys[j]].Bytes
+		if bi != bj {
+			return bi > bj
+		}
+		return keys[i] < keys[j]
+	})
+
+	serviceMap := &ServiceMap{Dependencies: make([]*Dependency, 0, len(keys))}
+	for _, key := range keys {
+		serviceMap.Dependencies = append(serviceMap.Dependencies, dependencies[key])
+	}
+	return serviceMap
+}
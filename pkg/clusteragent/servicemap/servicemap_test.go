@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package servicemap
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver map[string]*Workload
+
+func (f fakeResolver) Resolve(ip string) *Workload {
+	return f[ip]
+}
+
+var testResolver = fakeResolver{
+	"10.1.0.5":   {Namespace: "shop", Pod: "frontend-1", Services: []string{"frontend"}},
+	"10.1.0.6":   {Namespace: "shop", Pod: "frontend-2", Services: []string{"frontend"}},
+	"10.1.0.9":   {Namespace: "jobs", Pod: "cron-1"},
+	"10.2.0.7":   {Namespace: "shop", Pod: "backend-1", Services: []string{"backend"}},
+	"10.2.0.8":   {Namespace: "shop", Pod: "backend-2", Services: []string{"backend"}},
+	"10.96.0.10": {Namespace: "shop", Services: []string{"backend"}},
+}
+
+func endpoint(ip string, port int32) Endpoint {
+	return Endpoint{IP: ip, Port: port}
+}
+
+func TestBuildServiceMap(t *testing.T) {
+	store := NewStore(5 * time.Minute)
+	store.Add(&ConnectionSummaries{
+		Hostname: "node-1",
+		Connections: []ConnectionSummary{
+			// through the cluster IP of the backend, translated on the node
+			// of the client
+			{
+				Protocol:         "tcp",
+				Outgoing:         true,
+				Local:            endpoint("10.1.0.5", 40000),
+				Remote:           endpoint("10.96.0.10", 80),
+				TranslatedLocal:  &Endpoint{IP: "10.1.0.5", Port: 40000},
+				TranslatedRemote: &Endpoint{IP: "10.2.0.7", Port: 8080},
+				BytesSent:        100,
+				BytesReceived:    1000,
+			},
+			// through a node port, translated on the node of the server
+			{
+				Protocol:      "tcp",
+				Outgoing:      true,
+				Local:         endpoint("10.1.0.6", 40001),
+				Remote:        endpoint("192.168.0.2", 30080),
+				BytesSent:     50,
+				BytesReceived: 500,
+			},
+			// to a destination outside of the cluster
+			{
+				Protocol:  "tcp",
+				Outgoing:  true,
+				Local:     endpoint("10.1.0.9", 40002),
+				Remote:    endpoint("93.184.216.34", 443),
+				BytesSent: 10,
+			},
+		},
+	})
+	store.Add(&ConnectionSummaries{
+		Hostname: "node-2",
+		Connections: []ConnectionSummary{
+			{
+				Protocol:      "tcp",
+				Local:         endpoint("10.2.0.7", 8080),
+				Remote:        endpoint("10.1.0.5", 40000),
+				BytesSent:     1000,
+				BytesReceived: 100,
+			},
+			{
+				Protocol:         "tcp",
+				Local:            endpoint("10.2.0.8", 8080),
+				Remote:           endpoint("10.1.0.6", 40001),
+				TranslatedLocal:  &Endpoint{IP: "192.168.0.2", Port: 30080},
+				TranslatedRemote: &Endpoint{IP: "10.1.0.6", Port: 40001},
+				BytesSent:        500,
+				BytesReceived:    50,
+			},
+			// from a client outside of the cluster
+			{
+				Protocol:      "tcp",
+				Local:         endpoint("10.2.0.7", 8080),
+				Remote:        endpoint("203.0.113.9", 50000),
+				BytesReceived: 5,
+			},
+		},
+	})
+
+	serviceMap := store.Build(testResolver)
+	require.Len(t, serviceMap.Dependencies, 3)
+
+	// both connections from the frontend to the backend are counted once
+	assert.Equal(t, &Dependency{
+		Client:      Workload{Namespace: "shop", Services: []string{"frontend"}},
+		Server:      Workload{Namespace: "shop", Services: []string{"backend"}},
+		Protocol:    "tcp",
+		Port:        8080,
+		Connections: 2,
+		Paired:      2,
+		Bytes:       1650,
+	}, serviceMap.Dependencies[0])
+	assert.Equal(t, &Dependency{
+		Client:      Workload{Namespace: "jobs", Pod: "cron-1"},
+		Server:      Workload{IP: "93.184.216.34"},
+		Protocol:    "tcp",
+		Port:        443,
+		Connections: 1,
+		Bytes:       10,
+	}, serviceMap.Dependencies[1])
+	assert.Equal(t, &Dependency{
+		Client:      Workload{IP: "203.0.113.9"},
+		Server:      Workload{Namespace: "shop", Services: []string{"backend"}},
+		Protocol:    "tcp",
+		Port:        8080,
+		Connections: 1,
+		Bytes:       5,
+	}, serviceMap.Dependencies[2])
+}
+
+func TestBuildServiceMapUnpairedServer(t *testing.T) {
+	store := NewStore(5 * time.Minute)
+	// the node of the client doesn't report its connections
+	store.Add(&ConnectionSummaries{
+		Hostname: "node-2",
+		Connections: []ConnectionSummary{
+			{Protocol: "udp", Local: endpoint("10.2.0.7", 53), Remote: endpoint("10.1.0.5", 40000), BytesSent: 20},
+		},
+	})
+
+	serviceMap := store.Build(testResolver)
+	require.Len(t, serviceMap.Dependencies, 1)
+	assert.Equal(t, Workload{Namespace: "shop", Services: []string{"frontend"}}, serviceMap.Dependencies[0].Client)
+	assert.Equal(t, Workload{Namespace: "shop", Services: []string{"backend"}}, serviceMap.Dependencies[0].Server)
+	assert.Equal(t, 0, serviceMap.Dependencies[0].Paired)
+}
+
+func TestStoreExpiration(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Minute)
+	store.timeNow = func() time.Time { return now }
+	store.Add(&ConnectionSummaries{
+		Hostname: "node-1",
+		Connections: []ConnectionSummary{
+			{Protocol: "tcp", Outgoing: true, Local: endpoint("10.1.0.5", 40000), Remote: endpoint("10.2.0.7", 8080), BytesSent: 1},
+		},
+	})
+	assert.Len(t, store.Build(nil).Dependencies, 1)
+
+	now = now.Add(2 * time.Minute)
+	assert.Empty(t, store.Build(nil).Dependencies)
+	assert.Empty(t, store.reports)
+}
+
+func TestNewConnectionSummaries(t *testing.T) {
+	summaries := NewConnectionSummaries("node-1", []*model.Connection{
+		{
+			Laddr:         &model.Addr{Ip: "10.1.0.5", Port: 40000},
+			Raddr:         &model.Addr{Ip: "10.96.0.10", Port: 80},
+			Type:          model.ConnectionType_tcp,
+			Direction:     model.ConnectionDirection_outgoing,
+			LastBytesSent: 100,
+			IpTranslation: &model.IPTranslation{
+				ReplSrcIP:   "10.2.0.7",
+				ReplSrcPort: 8080,
+				ReplDstIP:   "10.1.0.5",
+				ReplDstPort: 40000,
+			},
+		},
+		// without traffic since the previous check run
+		{
+			Laddr:     &model.Addr{Ip: "10.1.0.5", Port: 40001},
+			Raddr:     &model.Addr{Ip: "10.96.0.10", Port: 80},
+			Direction: model.ConnectionDirection_outgoing,
+		},
+		{
+			Laddr:             &model.Addr{Ip: "127.0.0.1", Port: 6379},
+			Raddr:             &model.Addr{Ip: "127.0.0.1", Port: 50000},
+			Direction:         model.ConnectionDirection_local,
+			LastBytesReceived: 10,
+		},
+		{
+			Laddr:             &model.Addr{Ip: "10.1.0.5", Port: 53},
+			Raddr:             &model.Addr{Ip: "10.1.0.6", Port: 50000},
+			Type:              model.ConnectionType_udp,
+			Direction:         model.ConnectionDirection_incoming,
+			LastBytesReceived: 10,
+		},
+	})
+
+	assert.Equal(t, &ConnectionSummaries{
+		Hostname: "node-1",
+		Connections: []ConnectionSummary{
+			{
+				Protocol:         "tcp",
+				Outgoing:         true,
+				Local:            endpoint("10.1.0.5", 40000),
+				Remote:           endpoint("10.96.0.10", 80),
+				TranslatedLocal:  &Endpoint{IP: "10.1.0.5", Port: 40000},
+				TranslatedRemote: &Endpoint{IP: "10.2.0.7", Port: 8080},
+				BytesSent:        100,
+			},
+			{
+				Protocol:      "udp",
+				Local:         endpoint("10.1.0.5", 53),
+				Remote:        endpoint("10.1.0.6", 50000),
+				BytesReceived: 10,
+			},
+		},
+	}, summaries)
+}
@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package networkpath
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"time"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/net"
+)
+
+// autoTargetsClientID is the system-probe client of the check, the
+// connections returned to a client are the ones active since its last call
+const autoTargetsClientID = "network-path-auto-targets"
+
+// RankBy is the criteria used to rank the auto targets
+type RankBy string
+
+const (
+	// RankByBytes ranks the targets by the bytes sent and received
+	RankByBytes RankBy = "bytes"
+	// RankByConnections ranks the targets by number of connections
+	RankByConnections RankBy = "connections"
+)
+
+// DedupeBy is how the connections are grouped into auto targets
+type DedupeBy string
+
+const (
+	// DedupeByHost traces each remote address once
+	DedupeByHost DedupeBy = "host"
+	// DedupeByPort traces each remote address and port once
+	DedupeByPort DedupeBy = "port"
+	// DedupeByService traces each resolved domain once, whatever the
+	// number of addresses it resolves to
+	DedupeByService DedupeBy = "service"
+)
+
+const (
+	defaultMaxAutoTargets       = 10
+	defaultMaxTraceroutesPerRun = 5
+	defaultTargetInterval       = 5 * time.Minute
+)
+
+// AutoTargetsConfig is the configuration of the targets derived from the
+// connections observed by system-probe
+type AutoTargetsConfig struct {
+	MaxTargets           int
+	RankBy               RankBy
+	DedupeBy             DedupeBy
+	ExcludeCIDRs         []netip.Prefix
+	TargetInterval       time.Duration
+	MaxTraceroutesPerRun int
+}
+
+// autoTarget is a destination selected from the connections
+type autoTarget struct {
+	// key identifies the target according to the dedupe mode
+	key      string
+	ip       string
+	port     uint16
+	domain   string
+	bytes    uint64
+	conns    int
+	topBytes uint64
+}
+
+func (t *autoTarget) score(rankBy RankBy) uint64 {
+	if rankBy == RankByConnections {
+		return uint64(t.conns)
+	}
+	return t.bytes
+}
+
+// excluded returns true if the address can't or shouldn't be traced
+func (c *AutoTargetsConfig) excluded(addr netip.Addr) bool {
+	if !addr.Is4() || addr.IsLoopback() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, prefix := range c.ExcludeCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectTargets returns the top targets of the outgoing connections
+func (c *AutoTargetsConfig) selectTargets(conns *model.Connections) []*autoTarget {
+	if conns == nil {
+		return nil
+	}
+
+	targets := make(map[string]*autoTarget)
+	for _, conn := range conns.Conns {
+		if conn.Direction == model.ConnectionDirection_incoming || conn.Direction == model.ConnectionDirection_local || conn.IntraHost || conn.Raddr == nil {
+			continue
+		}
+		addr, err := netip.ParseAddr(conn.Raddr.Ip)
+		if err != nil || c.excluded(addr) {
+			continue
+		}
+
+		ip, port := addr.String(), uint16(conn.Raddr.Port)
+		domain := ""
+		if entry, ok := conns.Dns[ip]; ok && len(entry.Names) > 0 {
+			domain = entry.Names[0]
+		}
+
+		var key string
+		switch c.DedupeBy {
+		case DedupeByPort:
+			key = ip + ":" + strconv.Itoa(int(port))
+		case DedupeByService:
+			key = ip
+			if domain != "" {
+				key = domain
+			}
+		default:
+			key = ip
+		}
+
+		bytes := conn.LastBytesSent + conn.LastBytesReceived
+		target, ok := targets[key]
+		if !ok {
+			target = &autoTarget{key: key, domain: domain}
+			targets[key] = target
+		}
+		target.bytes += bytes
+		target.conns++
+		// the group is traced with the address and port of its busiest
+		// connection
+		if target.ip == "" || bytes > target.topBytes {
+			target.ip, target.port, target.topBytes = ip, port, bytes
+		}
+	}
+
+	selected := make([]*autoTarget, 0, len(targets))
+	for _, target := range targets {
+		selected = append(selected, target)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		si, sj := selected[i].score(c.RankBy), selected[j].score(c.RankBy)
+		if si != sj {
+			return si > sj
+		}
+		return selected[i].key < selected[j].key
+	})
+	if len(selected) > c.MaxTargets {
+		selected = selected[:c.MaxTargets]
+	}
+	return selected
+}
+
+// autoTargetScheduler picks the targets to trace in a check run, each target
+// being traced at most once per target interval
+type autoTargetScheduler struct {
+	cfg        *AutoTargetsConfig
+	lastTraced map[string]time.Time
+}
+
+func newAutoTargetScheduler(cfg *AutoTargetsConfig) *autoTargetScheduler {
+	return &autoTargetScheduler{
+		cfg:        cfg,
+		lastTraced: make(map[string]time.Time),
+	}
+}
+
+// due returns the targets to trace now, by rank, and records them as traced
+func (s *autoTargetScheduler) due(targets []*autoTarget, now time.Time) []*autoTarget {
+	var due []*autoTarget
+	for _, target := range targets {
+		if len(due) >= s.cfg.MaxTraceroutesPerRun {
+			break
+		}
+		if last, ok := s.lastTraced[target.key]; ok && now.Sub(last) < s.cfg.TargetInterval {
+			continue
+		}
+		s.lastTraced[target.key] = now
+		due = append(due, target)
+	}
+
+	// forget the targets which weren't traced for a while so that the map
+	// doesn't grow with the churn of the connections
+	for key, last := range s.lastTraced {
+		if now.Sub(last) >= 2*s.cfg.TargetInterval {
+			delete(s.lastTraced, key)
+		}
+	}
+	return due
+}
+
+// fetchConnections returns the connections observed by system-probe since
+// the previous call
+func fetchConnections() (*model.Connections, error) {
+	tu, err := net.GetRemoteSystemProbeUtil(
+		dd_config.SystemProbe.GetString("system_probe_config.sysprobe_socket"))
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize system-probe connection: %w", err)
+	}
+	return tu.GetConnections(autoTargetsClientID)
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package networkpath
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outgoing(ip string, port int32, bytes uint64) *model.Connection {
+	return &model.Connection{
+		Laddr:         &model.Addr{Ip: "10.0.0.1", Port: 40000},
+		Raddr:         &model.Addr{Ip: ip, Port: port},
+		Direction:     model.ConnectionDirection_outgoing,
+		LastBytesSent: bytes,
+	}
+}
+
+func testConnections() *model.Connections {
+	incoming := outgoing("192.0.2.50", 5000, 1e9)
+	incoming.Direction = model.ConnectionDirection_incoming
+	intraHost := outgoing("10.0.0.1", 8080, 1e9)
+	intraHost.IntraHost = true
+
+	return &model.Connections{
+		Conns: []*model.Connection{
+			outgoing("192.0.2.1", 443, 100),
+			outgoing("192.0.2.1", 443, 100),
+			outgoing("192.0.2.1", 80, 50),
+			outgoing("192.0.2.2", 443, 1000),
+			outgoing("198.51.100.1", 443, 400),
+			outgoing("198.51.100.2", 443, 300),
+			outgoing("127.0.0.1", 6379, 1e9),
+			outgoing("172.16.0.1", 5432, 1e9),
+			outgoing("2001:db8::1", 443, 1e9),
+			incoming,
+			intraHost,
+		},
+		Dns: map[string]*model.DNSEntry{
+			"198.51.100.1": {Names: []string{"api.example.com"}},
+			"198.51.100.2": {Names: []string{"api.example.com"}},
+		},
+	}
+}
+
+func targetKeys(targets []*autoTarget) []string {
+	var keys []string
+	for _, target := range targets {
+		keys = append(keys, target.key)
+	}
+	return keys
+}
+
+func TestSelectTargets(t *testing.T) {
+	excluded := []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12")}
+
+	tests := []struct {
+		name         string
+		cfg          AutoTargetsConfig
+		expectedKeys []string
+	}{
+		{
+			name:         "by bytes, deduped by host",
+			cfg:          AutoTargetsConfig{MaxTargets: 10, RankBy: RankByBytes, DedupeBy: DedupeByHost, ExcludeCIDRs: excluded},
+			expectedKeys: []string{"192.0.2.2", "198.51.100.1", "198.51.100.2", "192.0.2.1"},
+		},
+		{
+			name:         "by connections, deduped by port",
+			cfg:          AutoTargetsConfig{MaxTargets: 10, RankBy: RankByConnections, DedupeBy: DedupeByPort, ExcludeCIDRs: excluded},
+			expectedKeys: []string{"192.0.2.1:443", "192.0.2.1:80", "192.0.2.2:443", "198.51.100.1:443", "198.51.100.2:443"},
+		},
+		{
+			name:         "by bytes, deduped by service",
+			cfg:          AutoTargetsConfig{MaxTargets: 10, RankBy: RankByBytes, DedupeBy: DedupeByService, ExcludeCIDRs: excluded},
+			expectedKeys: []string{"192.0.2.2", "api.example.com", "192.0.2.1"},
+		},
+		{
+			name:         "top targets",
+			cfg:          AutoTargetsConfig{MaxTargets: 2, RankBy: RankByBytes, DedupeBy: DedupeByHost, ExcludeCIDRs: excluded},
+			expectedKeys: []string{"192.0.2.2", "198.51.100.1"},
+		},
+		{
+			name:         "no exclusions",
+			cfg:          AutoTargetsConfig{MaxTargets: 1, RankBy: RankByBytes, DedupeBy: DedupeByHost},
+			expectedKeys: []string{"172.16.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedKeys, targetKeys(tt.cfg.selectTargets(testConnections())))
+		})
+	}
+}
+
+func TestSelectTargetsBusiestConnection(t *testing.T) {
+	cfg := AutoTargetsConfig{MaxTargets: 10, RankBy: RankByBytes, DedupeBy: DedupeByService}
+	targets := cfg.selectTargets(&model.Connections{
+		Conns: []*model.Connection{
+			outgoing("198.51.100.1", 443, 10),
+			outgoing("198.51.100.2", 8443, 20),
+		},
+		Dns: map[string]*model.DNSEntry{
+			"198.51.100.1": {Names: []string{"api.example.com"}},
+			"198.51.100.2": {Names: []string{"api.example.com"}},
+		},
+	})
+	require.Len(t, targets, 1)
+	assert.Equal(t, "198.51.100.2", targets[0].ip)
+	assert.Equal(t, uint16(8443), targets[0].port)
+	assert.Equal(t, "api.example.com", targets[0].domain)
+	assert.Equal(t, uint64(30), targets[0].bytes)
+	assert.Equal(t, 2, targets[0].conns)
+}
+
+func TestAutoTargetSchedulerDue(t *testing.T) {
+	scheduler := newAutoTargetScheduler(&AutoTargetsConfig{
+		TargetInterval:       5 * time.Minute,
+		MaxTraceroutesPerRun: 2,
+	})
+	targets := []*autoTarget{{key: "a"}, {key: "b"}, {key: "c"}}
+
+	now := time.Now()
+	assert.Equal(t, []string{"a", "b"}, targetKeys(scheduler.due(targets, now)))
+	// the rate limit defers c to the next run
+	assert.Equal(t, []string{"c"}, targetKeys(scheduler.due(targets, now.Add(time.Minute))))
+	assert.Empty(t, scheduler.due(targets, now.Add(2*time.Minute)))
+	assert.Equal(t, []string{"a", "b"}, targetKeys(scheduler.due(targets, now.Add(5*time.Minute))))
+
+	// the targets not seen for a while are forgotten
+	scheduler.due(nil, now.Add(time.Hour))
+	assert.Empty(t, scheduler.lastTraced)
+}
@@ -7,9 +7,12 @@ package networkpath
 
 import (
 	"fmt"
+	"net/netip"
+	"time"
+
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/traceroute"
 	"gopkg.in/yaml.v2"
-	"time"
 )
 
 const defaultCheckInterval time.Duration = 1 * time.Minute
@@ -29,9 +32,37 @@ type InstanceConfig struct {
 
 	TimeoutMs uint `yaml:"timeout"` // millisecond
 
+	Protocol string `yaml:"protocol"`
+
+	NumPaths uint16 `yaml:"num_paths"`
+
+	ProbesPerHop uint8 `yaml:"probes_per_hop"`
+
+	FlowID uint16 `yaml:"flow_id"`
+
 	MinCollectionInterval int `yaml:"min_collection_interval"`
 
 	Tags []string `yaml:"tags"`
+
+	AutoTargets AutoTargetsInstanceConfig `yaml:"auto_targets"`
+}
+
+// AutoTargetsInstanceConfig is used to deserialize the auto targets config of
+// an instance
+type AutoTargetsInstanceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	MaxTargets int `yaml:"max_targets"`
+
+	RankBy string `yaml:"rank_by"`
+
+	DedupeBy string `yaml:"dedupe_by"`
+
+	ExcludeCIDRs []string `yaml:"exclude_cidrs"`
+
+	TargetInterval int `yaml:"target_interval"` // second
+
+	MaxTraceroutesPerRun int `yaml:"max_traceroutes_per_run"`
 }
 
 // CheckConfig defines the configuration of the
@@ -41,8 +72,15 @@ type CheckConfig struct {
 	DestPort              uint16
 	MaxTTL                uint8
 	TimeoutMs             uint
+	Protocol              traceroute.Protocol
+	NumPaths              uint16
+	ProbesPerHop          uint8
+	FlowID                uint16
 	MinCollectionInterval time.Duration
 	Tags                  []string
+	// AutoTargets is set when the destinations are derived from the
+	// connections of the host instead of DestHostname
+	AutoTargets *AutoTargetsConfig
 }
 
 // NewCheckConfig builds a new check config
@@ -66,6 +104,20 @@ func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data
 	c.DestPort = instance.DestPort
 	c.MaxTTL = instance.MaxTTL
 	c.TimeoutMs = instance.TimeoutMs
+	c.NumPaths = instance.NumPaths
+	c.ProbesPerHop = instance.ProbesPerHop
+	c.FlowID = instance.FlowID
+
+	c.Protocol, err = traceroute.ParseProtocol(instance.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	if c.NumPaths > traceroute.MaxNumPaths {
+		return nil, fmt.Errorf("num_paths must be <= %d", traceroute.MaxNumPaths)
+	}
+	if c.ProbesPerHop > traceroute.MaxProbesPerHop {
+		return nil, fmt.Errorf("probes_per_hop must be <= %d", traceroute.MaxProbesPerHop)
+	}
 
 	c.MinCollectionInterval = firstNonZero(
 		time.Duration(instance.MinCollectionInterval)*time.Second,
@@ -78,6 +130,65 @@ func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data
 
 	c.Tags = instance.Tags
 
+	if instance.AutoTargets.Enabled {
+		if c.DestHostname != "" {
+			return nil, fmt.Errorf("hostname and auto_targets are mutually exclusive")
+		}
+		c.AutoTargets, err = newAutoTargetsConfig(instance.AutoTargets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func newAutoTargetsConfig(instance AutoTargetsInstanceConfig) (*AutoTargetsConfig, error) {
+	c := &AutoTargetsConfig{
+		MaxTargets:           instance.MaxTargets,
+		RankBy:               RankBy(instance.RankBy),
+		DedupeBy:             DedupeBy(instance.DedupeBy),
+		TargetInterval:       time.Duration(instance.TargetInterval) * time.Second,
+		MaxTraceroutesPerRun: instance.MaxTraceroutesPerRun,
+	}
+
+	if c.MaxTargets == 0 {
+		c.MaxTargets = defaultMaxAutoTargets
+	}
+	if c.MaxTraceroutesPerRun == 0 {
+		c.MaxTraceroutesPerRun = defaultMaxTraceroutesPerRun
+	}
+	if c.TargetInterval == 0 {
+		c.TargetInterval = defaultTargetInterval
+	}
+	if c.MaxTargets < 0 || c.MaxTraceroutesPerRun < 0 || c.TargetInterval < 0 {
+		return nil, fmt.Errorf("auto_targets max_targets, max_traceroutes_per_run and target_interval must be > 0")
+	}
+
+	switch c.RankBy {
+	case "":
+		c.RankBy = RankByBytes
+	case RankByBytes, RankByConnections:
+	default:
+		return nil, fmt.Errorf("invalid auto_targets rank_by %q, expected one of bytes or connections", instance.RankBy)
+	}
+
+	switch c.DedupeBy {
+	case "":
+		c.DedupeBy = DedupeByHost
+	case DedupeByHost, DedupeByPort, DedupeByService:
+	default:
+		return nil, fmt.Errorf("invalid auto_targets dedupe_by %q, expected one of host, port or service", instance.DedupeBy)
+	}
+
+	for _, cidr := range instance.ExcludeCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auto_targets exclude_cidrs: %w", err)
+		}
+		c.ExcludeCIDRs = append(c.ExcludeCIDRs, prefix.Masked())
+	}
+
 	return c, nil
 }
 
@@ -6,7 +6,10 @@
 package networkpath
 
 import (
+	"net/netip"
+
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/traceroute"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -50,6 +53,7 @@ min_collection_interval: 10
 `),
 			expectedConfig: &CheckConfig{
 				DestHostname:          "1.2.3.4",
+				Protocol:              traceroute.ProtocolUDP,
 				MinCollectionInterval: time.Duration(42) * time.Second,
 			},
 		},
@@ -63,6 +67,7 @@ min_collection_interval: 10
 `),
 			expectedConfig: &CheckConfig{
 				DestHostname:          "1.2.3.4",
+				Protocol:              traceroute.ProtocolUDP,
 				MinCollectionInterval: time.Duration(10) * time.Second,
 			},
 		},
@@ -73,9 +78,122 @@ hostname: 1.2.3.4
 `),
 			expectedConfig: &CheckConfig{
 				DestHostname:          "1.2.3.4",
+				Protocol:              traceroute.ProtocolUDP,
+				MinCollectionInterval: time.Duration(1) * time.Minute,
+			},
+		},
+		{
+			name: "probes config",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+port: 443
+protocol: TCP
+num_paths: 4
+probes_per_hop: 5
+flow_id: 40000
+`),
+			expectedConfig: &CheckConfig{
+				DestHostname:          "1.2.3.4",
+				DestPort:              443,
+				Protocol:              traceroute.ProtocolTCP,
+				NumPaths:              4,
+				ProbesPerHop:          5,
+				FlowID:                40000,
 				MinCollectionInterval: time.Duration(1) * time.Minute,
 			},
 		},
+		{
+			name: "invalid protocol",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+protocol: sctp
+`),
+			expectedError: "unsupported protocol",
+		},
+		{
+			name: "too many paths",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+num_paths: 100
+`),
+			expectedError: "num_paths must be <= 16",
+		},
+		{
+			name: "auto targets defaults",
+			rawInstance: []byte(`
+protocol: tcp
+auto_targets:
+  enabled: true
+`),
+			expectedConfig: &CheckConfig{
+				Protocol:              traceroute.ProtocolTCP,
+				MinCollectionInterval: time.Duration(1) * time.Minute,
+				AutoTargets: &AutoTargetsConfig{
+					MaxTargets:           10,
+					RankBy:               RankByBytes,
+					DedupeBy:             DedupeByHost,
+					TargetInterval:       time.Duration(5) * time.Minute,
+					MaxTraceroutesPerRun: 5,
+				},
+			},
+		},
+		{
+			name: "auto targets",
+			rawInstance: []byte(`
+auto_targets:
+  enabled: true
+  max_targets: 20
+  rank_by: connections
+  dedupe_by: service
+  exclude_cidrs:
+    - 10.0.0.0/8
+    - 192.168.1.1/16
+  target_interval: 600
+  max_traceroutes_per_run: 3
+`),
+			expectedConfig: &CheckConfig{
+				Protocol:              traceroute.ProtocolUDP,
+				MinCollectionInterval: time.Duration(1) * time.Minute,
+				AutoTargets: &AutoTargetsConfig{
+					MaxTargets: 20,
+					RankBy:     RankByConnections,
+					DedupeBy:   DedupeByService,
+					ExcludeCIDRs: []netip.Prefix{
+						netip.MustParsePrefix("10.0.0.0/8"),
+						netip.MustParsePrefix("192.168.0.0/16"),
+					},
+					TargetInterval:       time.Duration(10) * time.Minute,
+					MaxTraceroutesPerRun: 3,
+				},
+			},
+		},
+		{
+			name: "auto targets with hostname",
+			rawInstance: []byte(`
+hostname: 1.2.3.4
+auto_targets:
+  enabled: true
+`),
+			expectedError: "hostname and auto_targets are mutually exclusive",
+		},
+		{
+			name: "auto targets invalid rank_by",
+			rawInstance: []byte(`
+auto_targets:
+  enabled: true
+  rank_by: packets
+`),
+			expectedError: "invalid auto_targets rank_by",
+		},
+		{
+			name: "auto targets invalid exclude_cidrs",
+			rawInstance: []byte(`
+auto_targets:
+  enabled: true
+  exclude_cidrs: [10.0.0.0]
+`),
+			expectedError: "invalid auto_targets exclude_cidrs",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
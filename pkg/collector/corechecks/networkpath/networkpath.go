@@ -14,6 +14,8 @@ import (
 	"strconv"
 	"time"
 
+	model "github.com/DataDog/agent-payload/v5/process"
+
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
@@ -34,33 +36,109 @@ const CheckName = "network_path"
 // Check doesn't need additional fields
 type Check struct {
 	core.CheckBase
-	config        *CheckConfig
-	lastCheckTime time.Time
+	config *CheckConfig
+	// lastCheckTimes holds the time of the last check of each destination
+	lastCheckTimes map[string]time.Time
+	scheduler      *autoTargetScheduler
+
+	fetchConnections func() (*model.Connections, error)
+	runTraceroute    func(cfg traceroute.Config) (traceroute.NetworkPath, error)
 }
 
 // Run executes the check
 func (c *Check) Run() error {
-	startTime := time.Now()
 	senderInstance, err := c.GetSender()
 	if err != nil {
 		return err
 	}
 
+	if c.config.AutoTargets != nil {
+		err = c.runAutoTargets(senderInstance)
+	} else {
+		err = c.traceDestination(senderInstance, c.config.DestHostname, c.config.DestPort, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	senderInstance.Commit()
+	return nil
+}
+
+// runAutoTargets traces the top destinations of the connections of the host
+// which are due
+func (c *Check) runAutoTargets(senderInstance sender.Sender) error {
+	conns, err := c.fetchConnections()
+	if err != nil {
+		return fmt.Errorf("failed to get connections: %w", err)
+	}
+
+	targets := c.config.AutoTargets.selectTargets(conns)
+	due := c.scheduler.due(targets, time.Now())
+	c.pruneLastCheckTimes(targets)
+	senderInstance.Gauge("datadog.network_path.auto_targets.selected", float64(len(targets)), "", c.config.Tags)
+
+	var lastErr error
+	traced := 0
+	for _, target := range due {
+		tags := []string{"auto_target:true"}
+		if target.domain != "" {
+			tags = append(tags, "destination_domain:"+target.domain)
+		}
+		if err := c.traceDestination(senderInstance, target.ip, target.port, tags); err != nil {
+			// a destination which can't be traced shouldn't prevent
+			// tracing the next ones
+			log.Warnf("failed to trace auto target %s: %s", target.key, err)
+			lastErr = err
+			continue
+		}
+		traced++
+	}
+	senderInstance.Gauge("datadog.network_path.auto_targets.traced", float64(traced), "", c.config.Tags)
+
+	if traced == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// pruneLastCheckTimes forgets the destinations which are no longer auto
+// targets, so that the map doesn't grow with the churn of the connections
+func (c *Check) pruneLastCheckTimes(targets []*autoTarget) {
+	current := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		current[target.ip+":"+strconv.Itoa(int(target.port))] = struct{}{}
+	}
+	for destination := range c.lastCheckTimes {
+		if _, ok := current[destination]; !ok {
+			delete(c.lastCheckTimes, destination)
+		}
+	}
+}
+
+// traceDestination traces the path to a destination and sends it along with
+// the telemetry metrics
+func (c *Check) traceDestination(senderInstance sender.Sender, hostname string, port uint16, extraTags []string) error {
+	startTime := time.Now()
+
 	cfg := traceroute.Config{
-		DestHostname: c.config.DestHostname,
-		DestPort:     c.config.DestPort,
+		DestHostname: hostname,
+		DestPort:     port,
 		MaxTTL:       c.config.MaxTTL,
 		TimeoutMs:    c.config.TimeoutMs,
+		Protocol:     c.config.Protocol,
+		NumPaths:     c.config.NumPaths,
+		ProbesPerHop: c.config.ProbesPerHop,
+		FlowID:       c.config.FlowID,
 	}
 
-	tr := traceroute.New(cfg)
-	path, err := tr.Run()
+	path, err := c.runTraceroute(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to trace path: %w", err)
 	}
 
 	// Add tags to path
-	commonTags := c.getCommonTags()
+	commonTags := append(c.getCommonTags(), extraTags...)
 	path.Tags = commonTags
 
 	// send to EP
@@ -69,11 +147,9 @@ func (c *Check) Run() error {
 		return fmt.Errorf("failed to send network path metadata: %w", err)
 	}
 
-	metricTags := c.getCommonTagsForMetrics()
+	metricTags := c.getCommonTagsForMetrics(hostname, port)
 	metricTags = append(metricTags, commonTags...)
-	c.submitTelemetryMetrics(senderInstance, path, startTime, metricTags)
-
-	senderInstance.Commit()
+	c.submitTelemetryMetrics(senderInstance, path, hostname+":"+strconv.Itoa(int(port)), startTime, metricTags)
 	return nil
 }
 
@@ -92,14 +168,14 @@ func (c *Check) getCommonTags() []string {
 	return tags
 }
 
-func (c *Check) getCommonTagsForMetrics() []string {
+func (c *Check) getCommonTagsForMetrics(hostname string, port uint16) []string {
 	destPortTag := "unspecified"
-	if c.config.DestPort > 0 {
-		destPortTag = strconv.Itoa(int(c.config.DestPort))
+	if port > 0 {
+		destPortTag = strconv.Itoa(int(port))
 	}
 	tags := []string{
-		"protocol:udp", // TODO: Update to protocol from config when we support tcp/icmp
-		"destination_hostname:" + c.config.DestHostname,
+		"protocol:" + string(c.config.Protocol),
+		"destination_hostname:" + hostname,
 		"destination_port:" + destPortTag,
 	}
 	return tags
@@ -116,17 +192,17 @@ func (c *Check) SendNetPathMDToEP(sender sender.Sender, path traceroute.NetworkP
 	return nil
 }
 
-func (c *Check) submitTelemetryMetrics(senderInstance sender.Sender, path traceroute.NetworkPath, startTime time.Time, tags []string) {
+func (c *Check) submitTelemetryMetrics(senderInstance sender.Sender, path traceroute.NetworkPath, destination string, startTime time.Time, tags []string) {
 	newTags := utils.CopyStrings(tags)
 
 	checkDuration := time.Since(startTime)
 	senderInstance.Gauge("datadog.network_path.check_duration", checkDuration.Seconds(), "", newTags)
 
-	if !c.lastCheckTime.IsZero() {
-		checkInterval := startTime.Sub(c.lastCheckTime)
+	if lastCheckTime, ok := c.lastCheckTimes[destination]; ok {
+		checkInterval := startTime.Sub(lastCheckTime)
 		senderInstance.Gauge("datadog.network_path.check_interval", checkInterval.Seconds(), "", newTags)
 	}
-	c.lastCheckTime = startTime
+	c.lastCheckTimes[destination] = startTime
 
 	senderInstance.Gauge("datadog.network_path.path.monitored", float64(1), "", newTags)
 	if len(path.Hops) > 0 {
@@ -137,6 +213,9 @@ func (c *Check) submitTelemetryMetrics(senderInstance sender.Sender, path tracer
 		senderInstance.Gauge("datadog.network_path.path.reachable", float64(utils.BoolToFloat64(lastHop.Success)), "", newTags)
 		senderInstance.Gauge("datadog.network_path.path.unreachable", float64(utils.BoolToFloat64(!lastHop.Success)), "", newTags)
 	}
+	if path.Graph != nil {
+		senderInstance.Gauge("datadog.network_path.path.paths", float64(path.Graph.NumPaths), "", newTags)
+	}
 }
 
 // Interval returns the scheduling time for the check
@@ -159,6 +238,9 @@ func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigD
 		return err
 	}
 	c.config = config
+	if config.AutoTargets != nil {
+		c.scheduler = newAutoTargetScheduler(config.AutoTargets)
+	}
 	return nil
 }
 
@@ -169,6 +251,11 @@ func Factory() optional.Option[func() check.Check] {
 
 func newCheck() check.Check {
 	return &Check{
-		CheckBase: core.NewCheckBase(CheckName),
+		CheckBase:        core.NewCheckBase(CheckName),
+		lastCheckTimes:   make(map[string]time.Time),
+		fetchConnections: fetchConnections,
+		runTraceroute: func(cfg traceroute.Config) (traceroute.NetworkPath, error) {
+			return traceroute.New(cfg).Run()
+		},
 	}
 }
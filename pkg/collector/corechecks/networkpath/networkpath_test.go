@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package networkpath
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/networkpath/traceroute"
+)
+
+func TestRunAutoTargets(t *testing.T) {
+	chk := newCheck().(*Check)
+	chk.config = &CheckConfig{
+		Protocol: traceroute.ProtocolTCP,
+		AutoTargets: &AutoTargetsConfig{
+			MaxTargets:           10,
+			RankBy:               RankByBytes,
+			DedupeBy:             DedupeByService,
+			ExcludeCIDRs:         []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12")},
+			TargetInterval:       time.Minute,
+			MaxTraceroutesPerRun: 2,
+		},
+	}
+	chk.scheduler = newAutoTargetScheduler(chk.config.AutoTargets)
+	chk.fetchConnections = func() (*model.Connections, error) {
+		return testConnections(), nil
+	}
+	var traced []traceroute.Config
+	chk.runTraceroute = func(cfg traceroute.Config) (traceroute.NetworkPath, error) {
+		traced = append(traced, cfg)
+		if cfg.DestHostname == "192.0.2.2" {
+			return traceroute.NetworkPath{}, errors.New("timeout")
+		}
+		return traceroute.NetworkPath{Hops: []traceroute.NetworkPathHop{{TTL: 1, Success: true}}}, nil
+	}
+
+	sender := mocksender.NewMockSender(chk.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, chk.runAutoTargets(sender))
+
+	require.Len(t, traced, 2)
+	assert.Equal(t, "192.0.2.2", traced[0].DestHostname)
+	assert.Equal(t, "198.51.100.1", traced[1].DestHostname)
+	assert.Equal(t, uint16(443), traced[1].DestPort)
+	assert.Equal(t, traceroute.ProtocolTCP, traced[1].Protocol)
+
+	sender.AssertMetric(t, "Gauge", "datadog.network_path.auto_targets.selected", 3, "", nil)
+	sender.AssertMetric(t, "Gauge", "datadog.network_path.auto_targets.traced", 1, "", nil)
+	sender.AssertMetricTaggedWith(t, "Gauge", "datadog.network_path.path.monitored", []string{
+		"auto_target:true",
+		"destination_domain:api.example.com",
+		"destination_hostname:198.51.100.1",
+		"destination_port:443",
+	})
+	sender.AssertNumberOfCalls(t, "EventPlatformEvent", 1)
+	assert.Contains(t, chk.lastCheckTimes, "198.51.100.1:443")
+
+	// the destinations which are no longer selected are forgotten
+	chk.lastCheckTimes["192.0.2.99:80"] = time.Now()
+	require.NoError(t, chk.runAutoTargets(sender))
+	assert.NotContains(t, chk.lastCheckTimes, "192.0.2.99:80")
+	assert.Contains(t, chk.lastCheckTimes, "198.51.100.1:443")
+
+	chk.fetchConnections = func() (*model.Connections, error) {
+		return nil, errors.New("system-probe is not running")
+	}
+	assert.ErrorContains(t, chk.runAutoTargets(sender), "failed to get connections")
+}
+
+func TestRunAutoTargetsAllFailed(t *testing.T) {
+	chk := newCheck().(*Check)
+	chk.config = &CheckConfig{
+		AutoTargets: &AutoTargetsConfig{
+			MaxTargets:           10,
+			RankBy:               RankByBytes,
+			DedupeBy:             DedupeByHost,
+			TargetInterval:       time.Minute,
+			MaxTraceroutesPerRun: 2,
+		},
+	}
+	chk.scheduler = newAutoTargetScheduler(chk.config.AutoTargets)
+	chk.fetchConnections = func() (*model.Connections, error) {
+		return testConnections(), nil
+	}
+	chk.runTraceroute = func(traceroute.Config) (traceroute.NetworkPath, error) {
+		return traceroute.NetworkPath{}, errors.New("timeout")
+	}
+
+	sender := mocksender.NewMockSender(chk.ID())
+	sender.SetupAcceptAll()
+	assert.ErrorContains(t, chk.runAutoTargets(sender), "timeout")
+	sender.AssertNotCalled(t, "EventPlatformEvent", mock.Anything, mock.Anything)
+}
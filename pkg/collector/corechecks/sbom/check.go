@@ -21,6 +21,7 @@ import (
 	ddConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/sbom"
 	"github.com/DataDog/datadog-agent/pkg/sbom/collectors"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
 )
@@ -29,6 +30,8 @@ const (
 	// CheckName is the name of the check
 	CheckName    = "sbom"
 	metricPeriod = 15 * time.Minute
+
+	defaultProcessRescanInterval = time.Hour
 )
 
 // Config holds the container_image check configuration
@@ -38,6 +41,7 @@ type Config struct {
 	ContainerPeriodicRefreshSeconds int `yaml:"periodic_refresh_seconds"`
 	HostPeriodicRefreshSeconds      int `yaml:"host_periodic_refresh_seconds"`
 	HostHeartbeatValiditySeconds    int `yaml:"host_heartbeat_validity_seconds"`
+	ProcessHeartbeatValiditySeconds int `yaml:"process_heartbeat_validity_seconds"`
 }
 
 type configValueRange struct {
@@ -76,6 +80,12 @@ var /* const */ (
 		max:          604800,    // 1 week
 		defaultValue: 3600 * 24, // 1 day
 	}
+
+	processHeartbeatValiditySeconds = &configValueRange{
+		min:          60,        // 1 min
+		max:          604800,    // 1 week
+		defaultValue: 3600 * 24, // 1 day
+	}
 )
 
 func validateValue(val *int, valueRange *configValueRange) {
@@ -99,6 +109,7 @@ func (c *Config) Parse(data []byte) error {
 	validateValue(&c.ContainerPeriodicRefreshSeconds, containerPeriodicRefreshSecondsValueRange)
 	validateValue(&c.HostPeriodicRefreshSeconds, hostPeriodicRefreshSecondsValueRange)
 	validateValue(&c.HostHeartbeatValiditySeconds, hostHeartbeatValiditySeconds)
+	validateValue(&c.ProcessHeartbeatValiditySeconds, processHeartbeatValiditySeconds)
 
 	return nil
 }
@@ -147,13 +158,29 @@ func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigD
 	c.sender = sender
 	sender.SetNoIndex(true)
 
+	processRescanInterval := ddConfig.Datadog.GetDuration("sbom.process.rescan_interval")
+	if processRescanInterval <= 0 {
+		processRescanInterval = defaultProcessRescanInterval
+	}
+
+	// The matcher is kept when the bundle can't be loaded yet, it is
+	// refreshed with the container images.
+	vulnMatcher, err := osv.GetGlobalMatcher(ddConfig.Datadog)
+	if err != nil {
+		log.Warnf("Unable to load the vulnerability advisories: %v", err)
+	}
+
 	if c.processor, err = newProcessor(
 		c.workloadmetaStore,
 		sender,
 		c.instance.ChunkSize,
 		time.Duration(c.instance.NewSBOMMaxLatencySeconds)*time.Second,
 		ddConfig.Datadog.GetBool("sbom.host.enabled"),
-		time.Duration(c.instance.HostHeartbeatValiditySeconds)*time.Second); err != nil {
+		time.Duration(c.instance.HostHeartbeatValiditySeconds)*time.Second,
+		ddConfig.Datadog.GetBool("sbom.process.enabled"),
+		processRescanInterval,
+		time.Duration(c.instance.ProcessHeartbeatValiditySeconds)*time.Second,
+		vulnMatcher); err != nil {
 		return err
 	}
 
@@ -173,6 +200,9 @@ func (c *Check) Run() error {
 		Source:    workloadmeta.SourceAll,
 		EventType: workloadmeta.EventTypeAll,
 	}
+	if c.processor.processSBOM {
+		filterParams.Kinds = append(filterParams.Kinds, workloadmeta.KindProcess)
+	}
 
 	imgEventsCh := c.workloadmetaStore.Subscribe(
 		CheckName,
@@ -190,6 +220,13 @@ func (c *Check) Run() error {
 	}
 	c.processor.triggerHostScan()
 
+	// The results of the scans of the filesystems of the containers, triggered
+	// by the process events and refreshed periodically.
+	processSbomChan := make(chan sbom.ScanResult) // default value to listen to nothing
+	if collectors.GetProcessScanner() != nil && collectors.GetProcessScanner().Channel() != nil {
+		processSbomChan = collectors.GetProcessScanner().Channel()
+	}
+
 	c.sendUsageMetrics()
 
 	containerPeriodicRefreshTicker := time.NewTicker(time.Duration(c.instance.ContainerPeriodicRefreshSeconds) * time.Second)
@@ -198,6 +235,9 @@ func (c *Check) Run() error {
 	hostPeriodicRefreshTicker := time.NewTicker(time.Duration(c.instance.HostPeriodicRefreshSeconds) * time.Second)
 	defer hostPeriodicRefreshTicker.Stop()
 
+	processPeriodicRefreshTicker := time.NewTicker(c.processor.processRescanInterval)
+	defer processPeriodicRefreshTicker.Stop()
+
 	metricTicker := time.NewTicker(metricPeriod)
 	defer metricTicker.Stop()
 
@@ -214,10 +254,17 @@ func (c *Check) Run() error {
 				return nil
 			}
 			c.processor.processHostScanResult(scanResult)
+		case scanResult, ok := <-processSbomChan:
+			if !ok {
+				return nil
+			}
+			c.processor.processProcessScanResult(scanResult)
 		case <-containerPeriodicRefreshTicker.C:
 			c.processor.processContainerImagesRefresh(c.workloadmetaStore.ListImages())
 		case <-hostPeriodicRefreshTicker.C:
 			c.processor.triggerHostScan()
+		case <-processPeriodicRefreshTicker.C:
+			c.processor.processProcessesRefresh(c.workloadmetaStore.ListProcesses())
 		case <-metricTicker.C:
 			c.sendUsageMetrics()
 		case <-c.stopCh:
@@ -233,6 +280,10 @@ func (c *Check) sendUsageMetrics() {
 		c.sender.Count("datadog.agent.sbom.hosts.running", 1.0, "", nil)
 	}
 
+	if ddConfig.Datadog.GetBool("sbom.process.enabled") {
+		c.sender.Count("datadog.agent.sbom.processes.running", 1.0, "", nil)
+	}
+
 	c.sender.Commit()
 }
 
@@ -10,7 +10,9 @@ package sbom
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -19,12 +21,17 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
 
 	ddConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/sbom"
 	"github.com/DataDog/datadog-agent/pkg/sbom/collectors/host"
+	"github.com/DataDog/datadog-agent/pkg/sbom/collectors/process"
+	"github.com/DataDog/datadog-agent/pkg/sbom/export"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
 	sbomscanner "github.com/DataDog/datadog-agent/pkg/sbom/scanner"
 	queue "github.com/DataDog/datadog-agent/pkg/util/aggregatingqueue"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -39,20 +46,38 @@ var /* const */ (
 	sourceAgent = "agent"
 )
 
+// maxEventFindings is the number of vulnerabilities listed in the text of the
+// vulnerabilities events, the most severe ones are listed first
+const maxEventFindings = 20
+
 type processor struct {
-	queue                 chan *model.SBOMEntity
-	workloadmetaStore     workloadmeta.Component
-	imageRepoDigests      map[string]string              // Map where keys are image repo digest and values are image ID
-	imageUsers            map[string]map[string]struct{} // Map where keys are image repo digest and values are set of container IDs
-	sbomScanner           *sbomscanner.Scanner
-	hostSBOM              bool
-	hostname              string
-	hostCache             string
-	hostLastFullSBOM      time.Time
-	hostHeartbeatValidity time.Duration
+	queue                    chan *model.SBOMEntity
+	workloadmetaStore        workloadmeta.Component
+	imageRepoDigests         map[string]string              // Map where keys are image repo digest and values are image ID
+	imageUsers               map[string]map[string]struct{} // Map where keys are image repo digest and values are set of container IDs
+	sbomScanner              *sbomscanner.Scanner
+	hostSBOM                 bool
+	hostname                 string
+	hostCache                string
+	hostLastFullSBOM         time.Time
+	hostHeartbeatValidity    time.Duration
+	processSBOM              bool
+	processRescanInterval    time.Duration
+	processHeartbeatValidity time.Duration
+	processContainers        map[string]time.Time        // Map where keys are the scanned container IDs and values are the time of their last scan
+	processCache             map[string]processSBOMCache // Map where keys are the container IDs of the process scans
+	sender                   sender.Sender
+	vulnMatcher              *osv.Matcher
+	imageFindings            map[string]string // Map where keys are image IDs and values are the keys of their vulnerabilities
+}
+
+// processSBOMCache holds the last SBOM sent for the filesystem of a container
+type processSBOMCache struct {
+	hash     string
+	lastFull time.Time
 }
 
-func newProcessor(workloadmetaStore workloadmeta.Component, sender sender.Sender, maxNbItem int, maxRetentionTime time.Duration, hostSBOM bool, hostHeartbeatValidity time.Duration) (*processor, error) {
+func newProcessor(workloadmetaStore workloadmeta.Component, sender sender.Sender, maxNbItem int, maxRetentionTime time.Duration, hostSBOM bool, hostHeartbeatValidity time.Duration, processSBOM bool, processRescanInterval time.Duration, processHeartbeatValidity time.Duration, vulnMatcher *osv.Matcher) (*processor, error) {
 	sbomScanner := sbomscanner.GetGlobalScanner()
 	if sbomScanner == nil {
 		return nil, errors.New("failed to get global SBOM scanner")
@@ -78,13 +103,21 @@ func newProcessor(workloadmetaStore workloadmeta.Component, sender sender.Sender
 
 			sender.EventPlatformEvent(encoded, eventplatform.EventTypeContainerSBOM)
 		}),
-		workloadmetaStore:     workloadmetaStore,
-		imageRepoDigests:      make(map[string]string),
-		imageUsers:            make(map[string]map[string]struct{}),
-		sbomScanner:           sbomScanner,
-		hostSBOM:              hostSBOM,
-		hostname:              hname,
-		hostHeartbeatValidity: hostHeartbeatValidity,
+		workloadmetaStore:        workloadmetaStore,
+		imageRepoDigests:         make(map[string]string),
+		imageUsers:               make(map[string]map[string]struct{}),
+		sbomScanner:              sbomScanner,
+		hostSBOM:                 hostSBOM,
+		hostname:                 hname,
+		hostHeartbeatValidity:    hostHeartbeatValidity,
+		processSBOM:              processSBOM,
+		processRescanInterval:    processRescanInterval,
+		processHeartbeatValidity: processHeartbeatValidity,
+		processContainers:        make(map[string]time.Time),
+		processCache:             make(map[string]processSBOMCache),
+		sender:                   sender,
+		vulnMatcher:              vulnMatcher,
+		imageFindings:            make(map[string]string),
 	}, nil
 }
 
@@ -111,6 +144,10 @@ func (p *processor) processContainerImagesEvents(evBundle workloadmeta.EventBund
 			case workloadmeta.EventTypeUnset:
 				p.unregisterContainer(event.Entity.(*workloadmeta.Container))
 			}
+		case workloadmeta.KindProcess:
+			if event.Type == workloadmeta.EventTypeSet {
+				p.triggerProcessScan(event.Entity.(*workloadmeta.Process))
+			}
 		}
 	}
 }
@@ -128,6 +165,7 @@ func (p *processor) unregisterImage(img *workloadmeta.ContainerImageMetadata) {
 			delete(p.imageRepoDigests, repoDigest)
 		}
 	}
+	delete(p.imageFindings, img.ID)
 }
 
 func (p *processor) registerContainer(ctr *workloadmeta.Container) {
@@ -165,9 +203,19 @@ func (p *processor) unregisterContainer(ctr *workloadmeta.Container) {
 	if len(p.imageUsers[imgID]) == 0 {
 		delete(p.imageUsers, imgID)
 	}
+
+	delete(p.processContainers, ctrID)
+	delete(p.processCache, ctrID)
+	export.DeleteContainerSBOM(ctrID)
 }
 
 func (p *processor) processContainerImagesRefresh(allImages []*workloadmeta.ContainerImageMetadata) {
+	if p.vulnMatcher != nil {
+		if err := p.vulnMatcher.Refresh(); err != nil {
+			log.Warnf("Unable to refresh the vulnerability advisories: %v", err)
+		}
+	}
+
 	// So far, the check is refreshing all the images every 5 minutes all together.
 	for _, img := range allImages {
 		p.processImageSBOM(img)
@@ -208,6 +256,7 @@ func (p *processor) processHostScanResult(result sbom.ScanResult) {
 				sbom.Sbom = &model.SBOMEntity_Cyclonedx{
 					Cyclonedx: convertBOM(report),
 				}
+				export.SetHostSBOM(p.hostname, report, result.CreatedAt)
 			}
 
 			sbom.Hash = result.Report.ID()
@@ -236,6 +285,132 @@ func (p *processor) triggerHostScan() {
 	}
 }
 
+func (p *processor) processProcessesRefresh(allProcesses []*workloadmeta.Process) {
+	// Forget the containers scanned long ago so that they are scanned again
+	now := time.Now()
+	for ctrID, lastScan := range p.processContainers {
+		if now.Sub(lastScan) >= p.processRescanInterval {
+			delete(p.processContainers, ctrID)
+		}
+	}
+
+	for _, proc := range allProcesses {
+		p.triggerProcessScan(proc)
+	}
+}
+
+// triggerProcessScan triggers the scan of the filesystem of the container of a
+// process, unless it was already scanned for another of its processes during the
+// rescan interval. The mounts of the container and the working directories of
+// all its processes are resolved by the scanner.
+func (p *processor) triggerProcessScan(proc *workloadmeta.Process) {
+	if !p.processSBOM || proc.ContainerID == "" {
+		return
+	}
+
+	if _, found := p.processContainers[proc.ContainerID]; found {
+		return
+	}
+
+	request, err := process.NewScanRequest(proc)
+	if err != nil {
+		log.Debugf("Failed to create the scan request of process %s: %v", proc.ID, err)
+		return
+	}
+
+	log.Debugf("Triggering SBOM generation for the filesystem of container %s", request.ContainerID)
+	if err := p.sbomScanner.Scan(request); err != nil {
+		log.Errorf("Failed to trigger SBOM generation for container %s: %s", request.ContainerID, err)
+		return
+	}
+	p.processContainers[request.ContainerID] = time.Now()
+}
+
+// processProcessScanResult sends the packages found in the filesystem of a container
+// that are not part of the SBOM of its image.
+func (p *processor) processProcessScanResult(result sbom.ScanResult) {
+	request, ok := result.Request.(process.ScanRequest)
+	if !ok {
+		log.Errorf("invalid scan request type '%T' for a process scan result", result.Request)
+		return
+	}
+	log.Debugf("processing process scan result of %s", request.ID())
+
+	ctr, err := p.workloadmetaStore.GetContainer(request.ContainerID)
+	if err != nil {
+		log.Debugf("Couldn’t find container %s, dropping its SBOM: %v", request.ContainerID, err)
+		return
+	}
+
+	ddTags, err := tagger.Tag(containers.BuildTaggerEntityName(ctr.ID), collectors.HighCardinality)
+	if err != nil {
+		log.Errorf("Could not retrieve tags for container %s: %v", ctr.ID, err)
+	}
+
+	sbom := &model.SBOMEntity{
+		Status:             model.SBOMStatus_SUCCESS,
+		Type:               model.SBOMSourceType_CONTAINER_FILE_SYSTEM,
+		Id:                 ctr.ID,
+		DdTags:             ddTags,
+		InUse:              true,
+		GeneratedAt:        timestamppb.New(result.CreatedAt),
+		GenerationDuration: convertDuration(result.Duration),
+	}
+
+	if result.Error != nil {
+		log.Errorf("Scan error for container %s: %v", ctr.ID, result.Error)
+		sbom.Sbom = &model.SBOMEntity_Error{
+			Error: result.Error.Error(),
+		}
+		sbom.Status = model.SBOMStatus_FAILED
+		p.queue <- sbom
+		return
+	}
+
+	imgID := ctr.Image.ID
+	if realImgID, found := p.imageRepoDigests[imgID]; found {
+		imgID = realImgID
+	}
+	img, err := p.workloadmetaStore.GetImage(imgID)
+	if err != nil || img.SBOM == nil || img.SBOM.Status != workloadmeta.Success || img.SBOM.CycloneDXBOM == nil {
+		// The packages can't be told apart from the ones of the image yet, let
+		// the container be scanned again at the next refresh.
+		log.Debugf("SBOM of image %s of container %s is not available yet, dropping the SBOM of its filesystem", imgID, ctr.ID)
+		delete(p.processContainers, ctr.ID)
+		return
+	}
+
+	log.Infof("Successfully generated SBOM for the filesystem of container %s: %v, %v", ctr.ID, result.CreatedAt, result.Duration)
+
+	cache, found := p.processCache[ctr.ID]
+	if found && cache.hash == result.Report.ID() && result.CreatedAt.Sub(cache.lastFull) < p.processHeartbeatValidity {
+		sbom.Heartbeat = true
+	} else {
+		report, err := result.Report.ToCycloneDX()
+		if err != nil {
+			log.Errorf("Failed to extract SBOM from report: %s", err)
+			sbom.Sbom = &model.SBOMEntity_Error{
+				Error: err.Error(),
+			}
+			sbom.Status = model.SBOMStatus_FAILED
+		} else {
+			delta := process.DiffBOM(report, img.SBOM.CycloneDXBOM)
+			sbom.Sbom = &model.SBOMEntity_Cyclonedx{
+				Cyclonedx: convertBOM(delta),
+			}
+			export.SetContainerSBOM(ctr.ID, delta, result.CreatedAt)
+		}
+
+		sbom.Hash = result.Report.ID()
+		p.processCache[ctr.ID] = processSBOMCache{
+			hash:     result.Report.ID(),
+			lastFull: result.CreatedAt,
+		}
+	}
+
+	p.queue <- sbom
+}
+
 func (p *processor) processImageSBOM(img *workloadmeta.ContainerImageMetadata) {
 	if img.SBOM == nil {
 		return
@@ -344,6 +519,94 @@ func (p *processor) processImageSBOM(img *workloadmeta.ContainerImageMetadata) {
 		}
 		p.queue <- sbom
 	}
+
+	if img.SBOM.Status == workloadmeta.Success {
+		p.processImageVulnerabilities(img, ddTags)
+	}
+}
+
+// processImageVulnerabilities matches the SBOM of an image against the OSV
+// advisories. It sends the number of vulnerabilities by severity, and an event
+// when the vulnerabilities affecting the image have changed.
+func (p *processor) processImageVulnerabilities(img *workloadmeta.ContainerImageMetadata, ddTags []string) {
+	if p.vulnMatcher == nil {
+		return
+	}
+	bundle := p.vulnMatcher.Bundle()
+	if bundle == nil {
+		return
+	}
+
+	findings := p.vulnMatcher.Match(img.SBOM.CycloneDXBOM)
+
+	bySeverity := make(map[string]int)
+	keys := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		bySeverity[finding.Severity]++
+		keys = append(keys, finding.VulnerabilityID+"/"+finding.PURL)
+	}
+	sort.Strings(keys)
+
+	for _, severity := range osv.Severities {
+		tags := make([]string, 0, len(ddTags)+1)
+		tags = append(tags, ddTags...)
+		tags = append(tags, "severity:"+severity)
+		p.sender.Gauge("sbom.vulnerabilities", float64(bySeverity[severity]), "", tags)
+	}
+	p.sender.Gauge("sbom.advisories.age", time.Since(bundle.LastModified).Seconds(), "", nil)
+	p.sender.Commit()
+
+	key := strings.Join(keys, ",")
+	previous, found := p.imageFindings[img.ID]
+	p.imageFindings[img.ID] = key
+	if previous == key || (!found && len(findings) == 0) {
+		return
+	}
+
+	alertType := event.EventAlertTypeWarning
+	switch {
+	case len(findings) == 0:
+		alertType = event.EventAlertTypeSuccess
+	case bySeverity[osv.SeverityCritical] > 0:
+		alertType = event.EventAlertTypeError
+	}
+
+	var text strings.Builder
+	for i, finding := range findings {
+		if i == maxEventFindings {
+			fmt.Fprintf(&text, "- and %d more\n", len(findings)-maxEventFindings)
+			break
+		}
+		fmt.Fprintf(&text, "- %s (%s): %s %s", finding.VulnerabilityID, finding.Severity, finding.Package, finding.Version)
+		if finding.FixedVersion != "" {
+			fmt.Fprintf(&text, ", fixed in %s", finding.FixedVersion)
+		}
+		text.WriteString("\n")
+	}
+
+	p.sender.Event(event.Event{
+		Title:          fmt.Sprintf("%d vulnerabilities found in image %s", len(findings), imageName(img)),
+		Text:           text.String(),
+		Ts:             time.Now().Unix(),
+		Priority:       event.EventPriorityNormal,
+		Tags:           ddTags,
+		AlertType:      alertType,
+		AggregationKey: img.ID,
+		SourceTypeName: "sbom",
+		EventType:      "sbom",
+	})
+	p.sender.Commit()
+}
+
+// imageName returns a human readable name of an image
+func imageName(img *workloadmeta.ContainerImageMetadata) string {
+	if len(img.RepoTags) > 0 {
+		return img.RepoTags[0]
+	}
+	if img.Name != "" {
+		return img.Name
+	}
+	return img.ID
 }
 
 func (p *processor) stop() {
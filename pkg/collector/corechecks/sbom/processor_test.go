@@ -9,7 +9,10 @@ package sbom
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +34,11 @@ import (
 	"github.com/DataDog/datadog-agent/comp/forwarder/eventplatform"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/sbom/collectors/process"
+	"github.com/DataDog/datadog-agent/pkg/sbom/export"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
 	sbomscanner "github.com/DataDog/datadog-agent/pkg/sbom/scanner"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
@@ -624,7 +632,7 @@ func TestProcessEvents(t *testing.T) {
 
 			// Define a max size of 1 for the queue. With a size > 1, it's difficult to
 			// control the number of events sent on each call.
-			p, err := newProcessor(workloadmetaStore, sender, 1, 50*time.Millisecond, false, time.Second)
+			p, err := newProcessor(workloadmetaStore, sender, 1, 50*time.Millisecond, false, time.Second, false, time.Hour, time.Second, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -665,3 +673,212 @@ func TestProcessEvents(t *testing.T) {
 		})
 	}
 }
+
+type fakeReport struct {
+	bom *cyclonedx.BOM
+	id  string
+}
+
+func (r *fakeReport) ToCycloneDX() (*cyclonedx.BOM, error) {
+	return r.bom, nil
+}
+
+func (r *fakeReport) ID() string {
+	return r.id
+}
+
+func TestProcessProcessScanResult(t *testing.T) {
+	workloadmetaStore := fxutil.Test[workloadmeta.Mock](t, fx.Options(
+		logimpl.MockModule(),
+		configcomp.MockModule(),
+		fx.Supply(context.Background()),
+		fx.Supply(workloadmeta.NewParams()),
+		workloadmeta.MockModuleV2(),
+	))
+
+	imageSBOM := &cyclonedx.BOM{
+		Components: &[]cyclonedx.Component{
+			{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+		},
+	}
+	workloadmetaStore.Set(&workloadmeta.ContainerImageMetadata{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainerImageMetadata,
+			ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+		},
+		SBOM: &workloadmeta.SBOM{
+			CycloneDXBOM: imageSBOM,
+			Status:       workloadmeta.Success,
+		},
+	})
+	workloadmetaStore.Set(&workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainer,
+			ID:   "e19e1ca0f3a7e9ee0e4e1c3a1bd8b4a10c5c3f1dc1e2b1e5a7b0a7e9f4a1c2d3",
+		},
+		Image: workloadmeta.ContainerImage{
+			ID: "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+		},
+		State: workloadmeta.ContainerState{
+			Running: true,
+		},
+	})
+
+	p := &processor{
+		queue:                    make(chan *model.SBOMEntity, 10),
+		workloadmetaStore:        workloadmetaStore,
+		imageRepoDigests:         make(map[string]string),
+		imageUsers:               make(map[string]map[string]struct{}),
+		processSBOM:              true,
+		processRescanInterval:    time.Hour,
+		processHeartbeatValidity: time.Hour,
+		processContainers:        make(map[string]time.Time),
+		processCache:             make(map[string]processSBOMCache),
+	}
+
+	request := process.ScanRequest{
+		PID:         42,
+		ContainerID: "e19e1ca0f3a7e9ee0e4e1c3a1bd8b4a10c5c3f1dc1e2b1e5a7b0a7e9f4a1c2d3",
+	}
+	result := sbom.ScanResult{
+		Request: request,
+		Report: &fakeReport{
+			id: "sha256:1",
+			bom: &cyclonedx.BOM{
+				Components: &[]cyclonedx.Component{
+					{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+					{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+				},
+			},
+		},
+		CreatedAt: time.Now(),
+	}
+
+	p.processProcessScanResult(result)
+	entity := <-p.queue
+	assert.Equal(t, model.SBOMSourceType_CONTAINER_FILE_SYSTEM, entity.Type)
+	assert.Equal(t, "e19e1ca0f3a7e9ee0e4e1c3a1bd8b4a10c5c3f1dc1e2b1e5a7b0a7e9f4a1c2d3", entity.Id)
+	assert.Equal(t, "sha256:1", entity.Hash)
+	assert.False(t, entity.Heartbeat)
+	components := entity.GetCyclonedx().GetComponents()
+	if assert.Len(t, components, 1) {
+		assert.Equal(t, "requests", components[0].Name)
+	}
+
+	// the packages of the filesystem are exported along with the ones of the image
+	doc, err := export.Get(workloadmetaStore, export.KindContainer, request.ContainerID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *doc.BOM.Components, 2)
+	}
+
+	// the same report is only sent as a heartbeat
+	p.processProcessScanResult(result)
+	entity = <-p.queue
+	assert.True(t, entity.Heartbeat)
+	assert.Nil(t, entity.Sbom)
+
+	// the container is forgotten once it's gone
+	p.unregisterContainer(&workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: request.ContainerID},
+	})
+	assert.Empty(t, p.processCache)
+	doc, err = export.Get(workloadmetaStore, export.KindContainer, request.ContainerID)
+	if assert.NoError(t, err) {
+		assert.Len(t, *doc.BOM.Components, 1)
+	}
+}
+
+func TestProcessImageVulnerabilities(t *testing.T) {
+	bundle := t.TempDir()
+	advisory := `{
+		"id": "GHSA-j8r2-6x86-q33q",
+		"modified": "2024-03-01T00:00:00Z",
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "requests"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.3.0"}, {"fixed": "2.31.0"}]}],
+			"database_specific": {"severity": "MODERATE"}
+		}]
+	}`
+	assert.NoError(t, os.WriteFile(filepath.Join(bundle, "GHSA-j8r2-6x86-q33q.json"), []byte(advisory), 0644))
+	matcher, err := osv.NewMatcher(bundle)
+	assert.NoError(t, err)
+
+	sender := mocksender.NewMockSender("")
+	sender.SetupAcceptAll()
+
+	p := &processor{
+		sender:        sender,
+		vulnMatcher:   matcher,
+		imageFindings: make(map[string]string),
+	}
+
+	img := &workloadmeta.ContainerImageMetadata{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainerImageMetadata,
+			ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+		},
+		RepoTags: []string{"datadog/app:1.0"},
+		SBOM: &workloadmeta.SBOM{
+			CycloneDXBOM: &cyclonedx.BOM{
+				Components: &[]cyclonedx.Component{
+					{Name: "requests", Version: "2.28.1", PackageURL: "pkg:pypi/requests@2.28.1"},
+				},
+			},
+			Status: workloadmeta.Success,
+		},
+	}
+	tags := []string{"image_name:datadog/app"}
+
+	p.processImageVulnerabilities(img, tags)
+	sender.AssertMetric(t, "Gauge", "sbom.vulnerabilities", 1, "", []string{"image_name:datadog/app", "severity:medium"})
+	sender.AssertMetric(t, "Gauge", "sbom.vulnerabilities", 0, "", []string{"image_name:datadog/app", "severity:critical"})
+	sender.AssertEvent(t, event.Event{
+		Title:          "1 vulnerabilities found in image datadog/app:1.0",
+		Text:           "- GHSA-j8r2-6x86-q33q (medium): requests 2.28.1, fixed in 2.31.0\n",
+		Ts:             time.Now().Unix(),
+		Priority:       event.EventPriorityNormal,
+		Tags:           tags,
+		AlertType:      event.EventAlertTypeWarning,
+		AggregationKey: img.ID,
+		SourceTypeName: "sbom",
+		EventType:      "sbom",
+	}, time.Minute)
+
+	// the event is only sent when the vulnerabilities change
+	p.processImageVulnerabilities(img, tags)
+	sender.AssertNumberOfCalls(t, "Event", 1)
+
+	img.SBOM.CycloneDXBOM.Components = &[]cyclonedx.Component{
+		{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+	}
+	p.processImageVulnerabilities(img, tags)
+	sender.AssertNumberOfCalls(t, "Event", 2)
+	sender.AssertEvent(t, event.Event{
+		Title:          "0 vulnerabilities found in image datadog/app:1.0",
+		Ts:             time.Now().Unix(),
+		Priority:       event.EventPriorityNormal,
+		Tags:           tags,
+		AlertType:      event.EventAlertTypeSuccess,
+		AggregationKey: img.ID,
+		SourceTypeName: "sbom",
+		EventType:      "sbom",
+	}, time.Minute)
+
+	// only the most severe vulnerabilities are listed in the event
+	components := make([]cyclonedx.Component, 0, maxEventFindings+2)
+	for i := 0; i < maxEventFindings+2; i++ {
+		version := fmt.Sprintf("2.28.%d", i)
+		components = append(components, cyclonedx.Component{Name: "requests", Version: version, PackageURL: "pkg:pypi/requests@" + version})
+	}
+	img.SBOM.CycloneDXBOM.Components = &components
+	p.processImageVulnerabilities(img, tags)
+	sender.AssertNumberOfCalls(t, "Event", 3)
+	var text string
+	for _, call := range sender.Mock.Calls {
+		if call.Method == "Event" {
+			text = call.Arguments.Get(0).(event.Event).Text
+		}
+	}
+	assert.Equal(t, maxEventFindings+1, strings.Count(text, "\n"))
+	assert.True(t, strings.HasSuffix(text, "- and 2 more\n"))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
 	devicemetadata "github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/utils"
@@ -59,6 +60,7 @@ func (ms *MetricSender) ReportNetworkDeviceMetadata(config *checkconfig.CheckCon
 	devices := []devicemetadata.DeviceMetadata{buildNetworkDeviceMetadata(config.DeviceID, config.DeviceIDTags, config, metadataStore, tags, deviceStatus, pingStatus)}
 
 	interfaces := buildNetworkInterfacesMetadata(config.DeviceID, metadataStore)
+	ms.storeInterfaces(config.DeviceID, interfaces, store)
 	ipAddresses := buildNetworkIPAddressesMetadata(config.DeviceID, metadataStore)
 	topologyLinks := buildNetworkTopologyMetadata(config.DeviceID, metadataStore, interfaces)
 
@@ -299,6 +301,28 @@ func buildNetworkInterfacesMetadata(deviceID string, store *metadata.Store) []de
 	return interfaces
 }
 
+// storeInterfaces shares the interfaces of the device with the other
+// components of the agent, such as the NetFlow collector
+func (ms *MetricSender) storeInterfaces(deviceID string, interfaces []devicemetadata.InterfaceMetadata, store *valuestore.ResultValueStore) {
+	if ms.interfaceStore == nil || len(interfaces) == 0 {
+		return
+	}
+	stored := make(map[uint32]interfacestore.Interface, len(interfaces))
+	for _, iface := range interfaces {
+		var speed uint64
+		if store != nil {
+			// the speed is unknown when ifHighSpeed isn't collected
+			speed, _ = ms.getIfHighSpeed(strconv.Itoa(int(iface.Index)), store)
+		}
+		stored[uint32(iface.Index)] = interfacestore.Interface{
+			Name:  iface.Name,
+			Alias: iface.Alias,
+			Speed: speed,
+		}
+	}
+	ms.interfaceStore.SetDeviceInterfaces(deviceID, stored)
+}
+
 func buildNetworkIPAddressesMetadata(deviceID string, store *metadata.Store) []devicemetadata.IPAddressMetadata {
 	if store == nil {
 		// it's expected that the value store is nil if we can't reach the device
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/networkdevice/interfacestore"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/metadata"
 	"github.com/DataDog/datadog-agent/pkg/networkdevice/profile/profiledefinition"
 	"github.com/DataDog/datadog-agent/pkg/snmp/snmpintegration"
@@ -266,13 +267,19 @@ func Test_metricSender_reportNetworkDeviceMetadata_withDeviceInterfacesAndDiagno
 				"1": valuestore.ResultValue{Value: "ifAlias1"},
 				"2": valuestore.ResultValue{Value: ""},
 			},
+			// ifHighSpeed
+			"1.3.6.1.2.1.31.1.1.1.15": {
+				"1": valuestore.ResultValue{Value: float64(1000)},
+			},
 		},
 	}
 	sender := mocksender.NewMockSender("testID") // required to initiate aggregator
 	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return()
 	sender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	interfaceStore := interfacestore.NewStore(interfacestore.DefaultTTL)
 	ms := &MetricSender{
-		sender: sender,
+		sender:         sender,
+		interfaceStore: interfaceStore,
 		interfaceConfigs: []snmpintegration.InterfaceConfig{
 			{
 				MatchField: "index",
@@ -422,6 +429,13 @@ func Test_metricSender_reportNetworkDeviceMetadata_withDeviceInterfacesAndDiagno
 	assert.NoError(t, err)
 
 	sender.AssertEventPlatformEvent(t, compactEvent.Bytes(), "network-devices-metadata")
+
+	iface, ok := interfaceStore.GetInterface("1234", 1)
+	assert.True(t, ok)
+	assert.Equal(t, interfacestore.Interface{Name: "21", Alias: "ifAlias1", Speed: 1e9}, iface)
+	iface, ok = interfaceStore.GetInterface("1234", 2)
+	assert.True(t, ok)
+	assert.Equal(t, interfacestore.Interface{Name: "22"}, iface)
 }
 
 func Test_metricSender_reportNetworkDeviceMetadata_fallbackOnFieldValue(t *testing.T) {
@@ -8,6 +8,7 @@ package report
 import (
 	"fmt"
 
+	"github.com/DataDog/datadog-agent/comp/ndmtmp/interfacestore"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -27,6 +28,9 @@ type MetricSender struct {
 	submittedMetrics        int
 	interfaceConfigs        []snmpintegration.InterfaceConfig
 	interfaceBandwidthState InterfaceBandwidthState
+	// interfaceStore shares the reported interfaces with the other
+	// components, it's nil when they aren't shared
+	interfaceStore interfacestore.Component
 }
 
 // MetricSample is a collected metric sample with its metadata, ready to be submitted through the metric sender
@@ -39,12 +43,13 @@ type MetricSample struct {
 }
 
 // NewMetricSender create a new MetricSender
-func NewMetricSender(sender sender.Sender, hostname string, interfaceConfigs []snmpintegration.InterfaceConfig, interfaceBandwidthState InterfaceBandwidthState) *MetricSender {
+func NewMetricSender(sender sender.Sender, hostname string, interfaceConfigs []snmpintegration.InterfaceConfig, interfaceBandwidthState InterfaceBandwidthState, interfaceStore interfacestore.Component) *MetricSender {
 	return &MetricSender{
 		sender:                  sender,
 		hostname:                hostname,
 		interfaceConfigs:        interfaceConfigs,
 		interfaceBandwidthState: interfaceBandwidthState,
+		interfaceStore:          interfaceStore,
 	}
 }
 
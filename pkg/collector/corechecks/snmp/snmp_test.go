@@ -980,10 +980,10 @@ community_string: public
 
 func TestCheckID(t *testing.T) {
 	profile.SetConfdPathAndCleanProfiles()
-	check1 := newCheck()
-	check2 := newCheck()
-	check3 := newCheck()
-	checkSubnet := newCheck()
+	check1 := newCheck(nil)
+	check2 := newCheck(nil)
+	check3 := newCheck(nil)
+	checkSubnet := newCheck(nil)
 	// language=yaml
 	rawInstanceConfig1 := []byte(`
 ip_address: 1.1.1.1
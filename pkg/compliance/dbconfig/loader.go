@@ -33,6 +33,10 @@ const (
 
 	mongoDBResourceType = "db_mongodb"
 	mongoDBConfigPath   = "/etc/mongod.conf"
+
+	mySQLResourceType = "db_mysql"
+
+	redisResourceType = "db_redis"
 )
 
 func relPath(hostroot, configPath string) string {
@@ -69,6 +73,10 @@ func GetProcResourceType(proc *process.Process) (string, bool) {
 		return postgresqlResourceType, true
 	case "mongod":
 		return mongoDBResourceType, true
+	case "mysqld", "mariadbd":
+		return mySQLResourceType, true
+	case "redis-server":
+		return redisResourceType, true
 	case "java":
 		cmdline, _ := proc.CmdlineSlice()
 		if len(cmdline) > 0 && cmdline[len(cmdline)-1] == "org.apache.cassandra.service.CassandraDaemon" {
@@ -85,21 +93,34 @@ func LoadConfiguration(ctx context.Context, rootPath string, proc *process.Proce
 	if !ok {
 		return "", nil, false
 	}
+	conf, ok := loadDBConfig(ctx, resourceType, rootPath, proc)
+	if !ok {
+		return "", nil, false
+	}
+	return resourceType, conf, true
+}
+
+func loadDBConfig(ctx context.Context, resourceType string, hostroot string, proc *process.Process) (*DBConfig, bool) {
 	var conf *DBConfig
+	var ok bool
 	switch resourceType {
 	case postgresqlResourceType:
-		conf, ok = LoadPostgreSQLConfig(ctx, rootPath, proc)
+		conf, ok = LoadPostgreSQLConfig(ctx, hostroot, proc)
 	case mongoDBResourceType:
-		conf, ok = LoadMongoDBConfig(ctx, rootPath, proc)
+		conf, ok = LoadMongoDBConfig(ctx, hostroot, proc)
 	case cassandraResourceType:
-		conf, ok = LoadCassandraConfig(ctx, rootPath, proc)
+		conf, ok = LoadCassandraConfig(ctx, hostroot, proc)
+	case mySQLResourceType:
+		conf, ok = LoadMySQLConfig(ctx, hostroot, proc)
+	case redisResourceType:
+		conf, ok = LoadRedisConfig(ctx, hostroot, proc)
 	default:
 		ok = false
 	}
 	if !ok || conf == nil {
-		return "", nil, false
+		return nil, false
 	}
-	return resourceType, conf, true
+	return conf, true
 }
 
 // LoadDBResourceFromPID loads and returns an optional DBResource associated
@@ -121,18 +142,8 @@ func LoadDBResourceFromPID(ctx context.Context, pid int32) (*DBResource, bool) {
 		return nil, false
 	}
 
-	var conf *DBConfig
-	switch resourceType {
-	case postgresqlResourceType:
-		conf, ok = LoadPostgreSQLConfig(ctx, hostroot, proc)
-	case mongoDBResourceType:
-		conf, ok = LoadMongoDBConfig(ctx, hostroot, proc)
-	case cassandraResourceType:
-		conf, ok = LoadCassandraConfig(ctx, hostroot, proc)
-	default:
-		ok = false
-	}
-	if !ok || conf == nil {
+	conf, ok := loadDBConfig(ctx, resourceType, hostroot, proc)
+	if !ok {
 		return nil, false
 	}
 	return &DBResource{
@@ -309,6 +309,127 @@ func TestMongoDBConfParsing(t *testing.T) {
 	assert.Equal(t, "/var/log/mongodb/mongod.log", *configData.SystemLog.Path)
 }
 
+func TestMySQLConfParsing(t *testing.T) {
+	hostroot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(hostroot, "/etc/mysql/conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostroot, "/etc/mysql/my.cnf"), []byte(mySQLConfigSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostroot, "/etc/mysql/conf.d/security.cnf"), []byte(mySQLConfigIncludedSample), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		proc, stop := launchFakeProcess(context.Background(), t, "mysqld", "--port=3307", "--loose-ssl-ca=/etc/ssl/ca.pem")
+		defer stop()
+
+		resourceType, ok := GetProcResourceType(proc)
+		assert.True(t, ok)
+		assert.Equal(t, mySQLResourceType, resourceType)
+
+		c, ok := LoadMySQLConfig(context.Background(), hostroot, proc)
+		assert.True(t, ok)
+		assert.Equal(t, "/etc/mysql/my.cnf", c.ConfigFilePath)
+		assert.Equal(t, uint32(0644), c.ConfigFileMode)
+		assert.NotEmpty(t, c.ConfigFileUser)
+		if assert.Len(t, c.IncludedFiles, 1) {
+			assert.Equal(t, "/etc/mysql/conf.d/security.cnf", c.IncludedFiles[0].Path)
+			assert.Equal(t, uint32(0640), c.IncludedFiles[0].Mode)
+			assert.NotEmpty(t, c.IncludedFiles[0].User)
+			assert.NotEmpty(t, c.IncludedFiles[0].Group)
+		}
+
+		configData := c.ConfigData.(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"user":              "mysql",
+			"local_infile":      "OFF",
+			"skip_name_resolve": "ON",
+			"log_error":         "/var/log/mysql/error.log",
+			"symbolic_links":    "0",
+			"port":              "3307",
+			"ssl_ca":            "/etc/ssl/ca.pem",
+		}, configData)
+	}
+
+	{
+		proc, stop := launchFakeProcess(context.Background(), t, "mysqld", "--defaults-file=/etc/mysql/conf.d/security.cnf")
+		defer stop()
+
+		c, ok := LoadMySQLConfig(context.Background(), hostroot, proc)
+		assert.True(t, ok)
+		assert.Equal(t, "/etc/mysql/conf.d/security.cnf", c.ConfigFilePath)
+		assert.Empty(t, c.IncludedFiles)
+		assert.Equal(t, map[string]interface{}{
+			"local_infile":   "OFF",
+			"symbolic_links": "0",
+		}, c.ConfigData)
+	}
+
+	{
+		proc, stop := launchFakeProcess(context.Background(), t, "mysqld", "--no-defaults")
+		defer stop()
+
+		c, ok := LoadMySQLConfig(context.Background(), hostroot, proc)
+		assert.True(t, ok)
+		assert.Empty(t, c.ConfigFilePath)
+		assert.Equal(t, "<none>", c.ConfigFileUser)
+		assert.Empty(t, c.ConfigData)
+	}
+}
+
+func TestRedisConfParsing(t *testing.T) {
+	hostroot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(hostroot, "/etc/redis/conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostroot, "/etc/redis/redis.conf"), []byte(redisConfigSample), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostroot, "/etc/redis/conf.d/port.conf"), []byte("port 6380\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	proc, stop := launchFakeProcess(context.Background(), t, "redis-server", "/etc/redis/redis.conf", "--protected-mode", "no", "--bind", "0.0.0.0", "::")
+	defer stop()
+
+	resourceType, ok := GetProcResourceType(proc)
+	assert.True(t, ok)
+	assert.Equal(t, redisResourceType, resourceType)
+
+	resourceType, c, ok := LoadConfiguration(context.Background(), hostroot, proc)
+	assert.True(t, ok)
+	assert.Equal(t, redisResourceType, resourceType)
+	assert.Equal(t, "/etc/redis/redis.conf", c.ConfigFilePath)
+	assert.Equal(t, uint32(0640), c.ConfigFileMode)
+	assert.NotEmpty(t, c.ConfigFileUser)
+	if assert.Len(t, c.IncludedFiles, 1) {
+		assert.Equal(t, "/etc/redis/conf.d/port.conf", c.IncludedFiles[0].Path)
+		assert.Equal(t, uint32(0600), c.IncludedFiles[0].Mode)
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"requirepass":    "<redacted>",
+		"save":           []string{"900 1", "300 10"},
+		"rename-command": []string{"FLUSHALL ", "CONFIG b840fc02d524045429941cc15f59e41cb7be6c52"},
+		"user":           []string{"alice on ><redacted> ~* +@all", "bob off #<redacted>"},
+		"protected-mode": "no",
+		"bind":           "0.0.0.0 ::",
+		"port":           "6380",
+		"loglevel":       "notice",
+	}, c.ConfigData)
+
+	{
+		args, ok := splitRedisArgs(`logfile "/var/log/redis \"main\".log" 'it\'s'`)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"logfile", `/var/log/redis "main".log`, "it's"}, args)
+
+		_, ok = splitRedisArgs(`logfile "/var/log/redis.log`)
+		assert.False(t, ok)
+	}
+}
+
 const pgConfigCommon = `
 # -----------------------------
 # PostgreSQL configuration file
@@ -1586,3 +1707,43 @@ net:
 
 #auditLog:
 `
+
+const mySQLConfigSample = `
+[client]
+password = secret
+port = 3306
+
+[mysqld]
+user = mysql
+local-infile = 1
+skip-name-resolve
+log_error = "/var/log/mysql/error.log" # error log
+
+!includedir /etc/mysql/conf.d/
+`
+
+const mySQLConfigIncludedSample = `
+# hardening
+[mysqld]
+local_infile=OFF
+symbolic-links = 0
+
+[mysqldump]
+quick
+`
+
+const redisConfigSample = `
+# Redis configuration
+requirepass "foo bar"
+save 900 1
+save 300 10
+rename-command FLUSHALL ""
+rename-command CONFIG b840fc02d524045429941cc15f59e41cb7be6c52
+user alice on >secret ~* +@all
+user bob off #e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+bind 127.0.0.1 -::1
+protected-mode yes
+LogLevel notice
+
+include /etc/redis/conf.d/*.conf
+`
@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dbconfig
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/utils"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// mySQLDefaultConfigPaths are the global option files read by mysqld, in order.
+//
+// reference: https://dev.mysql.com/doc/refman/8.0/en/option-files.html
+var mySQLDefaultConfigPaths = []string{
+	"/etc/my.cnf",
+	"/etc/mysql/my.cnf",
+	"/usr/etc/my.cnf",
+}
+
+// mySQLServerGroups are the option groups read by the MySQL and MariaDB
+// servers. Version specific groups like [mysqld-8.0] are ignored since the
+// version of the server is not known.
+var mySQLServerGroups = map[string]bool{
+	"mysqld":        true,
+	"server":        true,
+	"mariadb":       true,
+	"mariadbd":      true,
+	"client-server": true,
+	"galera":        true,
+}
+
+// LoadMySQLConfig loads and extracts the MySQL or MariaDB server
+// configuration data found on the system. The options of the server groups
+// of all the option files are merged in the order they are read by mysqld,
+// and overridden by the options given on the command line.
+func LoadMySQLConfig(ctx context.Context, hostroot string, proc *process.Process) (*DBConfig, bool) {
+	var result DBConfig
+	result.ProcessUser, _ = proc.UsernameWithContext(ctx)
+	result.ProcessName, _ = proc.NameWithContext(ctx)
+
+	var (
+		noDefaults       bool
+		defaultsFile     string
+		defaultsExtra    string
+		cmdlineOverrides []string
+	)
+	cmdline, _ := proc.CmdlineSlice()
+	for i := 1; i < len(cmdline); i++ {
+		arg := cmdline[i]
+		switch {
+		case arg == "--no-defaults":
+			noDefaults = true
+		case strings.HasPrefix(arg, "--defaults-file="):
+			defaultsFile = filepath.Clean(strings.TrimPrefix(arg, "--defaults-file="))
+		case strings.HasPrefix(arg, "--defaults-extra-file="):
+			defaultsExtra = filepath.Clean(strings.TrimPrefix(arg, "--defaults-extra-file="))
+		case strings.HasPrefix(arg, "--defaults-group-suffix="):
+			// the suffixed groups are not supported
+		case strings.HasPrefix(arg, "--"):
+			cmdlineOverrides = append(cmdlineOverrides, strings.TrimPrefix(arg, "--"))
+		}
+	}
+
+	var configPaths []string
+	switch {
+	case noDefaults:
+	case defaultsFile != "":
+		configPaths = []string{defaultsFile}
+	default:
+		configPaths = append(configPaths, mySQLDefaultConfigPaths...)
+		if defaultsExtra != "" {
+			configPaths = append(configPaths, defaultsExtra)
+		}
+	}
+
+	configData := make(map[string]interface{})
+	var files []DBConfigFile
+	for _, configPath := range configPaths {
+		parseMySQLConfig(hostroot, configPath, 0, configData, &files)
+	}
+
+	for _, override := range cmdlineOverrides {
+		key, val, _ := parseMySQLOption(override)
+		if key != "" {
+			configData[key] = val
+		}
+	}
+
+	if len(files) == 0 {
+		// mysqld can run without any option file.
+		result.ConfigFileUser = "<none>"
+		result.ConfigFileGroup = "<none>"
+		result.ConfigData = configData
+		return &result, true
+	}
+
+	result.ConfigFilePath = files[0].Path
+	result.ConfigFileUser = files[0].User
+	result.ConfigFileGroup = files[0].Group
+	result.ConfigFileMode = files[0].Mode
+	result.IncludedFiles = files[1:]
+	result.ConfigData = configData
+	return &result, true
+}
+
+// parseMySQLConfig reads the given option file and merges the options of the
+// server groups into config. The metadata of the files read are appended to
+// files.
+//
+// reference: https://dev.mysql.com/doc/refman/8.0/en/option-files.html#option-file-syntax
+func parseMySQLConfig(hostroot, configPath string, includeDepth int, config map[string]interface{}, files *[]DBConfigFile) bool {
+	// Let's protect ourselves from circular "includes" by limiting the depth
+	// of the include directives.
+	if includeDepth > 10 {
+		return false
+	}
+
+	fullPath := filepath.Join(hostroot, configPath)
+	fi, err := os.Stat(fullPath)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	b, err := readFileLimit(fullPath)
+	if err != nil {
+		return false
+	}
+	*files = append(*files, newDBConfigFile(configPath, fi))
+
+	var inServerGroup bool
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Split(bufio.ScanLines)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case strings.HasPrefix(line, "!includedir"):
+			includedDir := strings.TrimSpace(strings.TrimPrefix(line, "!includedir"))
+			if !filepath.IsAbs(includedDir) {
+				includedDir = filepath.Join(filepath.Dir(configPath), includedDir)
+			}
+			matches, _ := filepath.Glob(filepath.Join(hostroot, includedDir, "*.cnf"))
+			sort.Strings(matches)
+			for _, match := range matches {
+				parseMySQLConfig(hostroot, relPath(hostroot, match), includeDepth+1, config, files)
+			}
+		case strings.HasPrefix(line, "!include"):
+			includedPath := strings.TrimSpace(strings.TrimPrefix(line, "!include"))
+			if !filepath.IsAbs(includedPath) {
+				includedPath = filepath.Join(filepath.Dir(configPath), includedPath)
+			}
+			parseMySQLConfig(hostroot, includedPath, includeDepth+1, config, files)
+		case line[0] == '[':
+			group := strings.TrimSpace(strings.Trim(line, "[]"))
+			inServerGroup = mySQLServerGroups[strings.ToLower(group)]
+		case inServerGroup:
+			if key, val, ok := parseMySQLOption(line); ok {
+				config[key] = val
+			}
+		}
+	}
+	return true
+}
+
+// parseMySQLOption parses an option line of the form "name=value" or "name".
+// Dashes and underscores being interchangeable in option names, the names
+// are normalized with underscores. As for PostgreSQL, boolean values are
+// normalized to the ON / OFF nomenclature and options given without value
+// are considered enabled.
+func parseMySQLOption(line string) (string, string, bool) {
+	key, val, hasValue := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(key, "loose-")
+	key = strings.TrimPrefix(key, "loose_")
+	key = strings.ReplaceAll(key, "-", "_")
+	if key == "" {
+		return "", "", false
+	}
+	if !hasValue {
+		return key, "ON", true
+	}
+
+	val = unquoteMySQLValue(strings.TrimSpace(val))
+	switch strings.ToLower(val) {
+	case "on", "true", "yes":
+		val = "ON"
+	case "off", "false", "no":
+		val = "OFF"
+	}
+	return key, val, true
+}
+
+// unquoteMySQLValue removes the quotes, the trailing comment and interprets
+// the escape sequences of an option value.
+func unquoteMySQLValue(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') {
+		if end := strings.IndexByte(val[1:], val[0]); end != -1 {
+			return unescapeMySQLValue(val[1 : end+1])
+		}
+	}
+	if comment := strings.IndexByte(val, '#'); comment != -1 {
+		val = strings.TrimSpace(val[:comment])
+	}
+	return unescapeMySQLValue(val)
+}
+
+func unescapeMySQLValue(val string) string {
+	if !strings.Contains(val, "\\") {
+		return val
+	}
+	var out strings.Builder
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c != '\\' || i+1 == len(val) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		switch val[i] {
+		case 'b':
+			out.WriteByte('\b')
+		case 't':
+			out.WriteByte('\t')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 's':
+			out.WriteByte(' ')
+		case '\\':
+			out.WriteByte('\\')
+		default:
+			// unknown escape sequences are kept as is, for instance in
+			// Windows paths
+			out.WriteByte('\\')
+			out.WriteByte(val[i])
+		}
+	}
+	return out.String()
+}
+
+func newDBConfigFile(configPath string, fi os.FileInfo) DBConfigFile {
+	return DBConfigFile{
+		Path:  configPath,
+		User:  utils.GetFileUser(fi),
+		Group: utils.GetFileGroup(fi),
+		Mode:  uint32(fi.Mode()),
+	}
+}
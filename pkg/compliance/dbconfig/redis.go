@@ -0,0 +1,290 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dbconfig
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const redisRedactedValue = "<redacted>"
+
+// redisDefaultConfigPaths are the usual locations of the redis configuration
+// file. redis-server has no default configuration path and the path given on
+// its command line may be hidden by the rewriting of its process title.
+var redisDefaultConfigPaths = []string{
+	"/etc/redis/redis.conf",
+	"/etc/redis.conf",
+	"/usr/local/etc/redis/redis.conf",
+	"/usr/local/etc/redis.conf",
+}
+
+// redisMultiValueDirectives are the directives that can be repeated, their
+// values are accumulated in a list instead of being overridden.
+var redisMultiValueDirectives = map[string]bool{
+	"save":                       true,
+	"rename-command":             true,
+	"user":                       true,
+	"loadmodule":                 true,
+	"client-output-buffer-limit": true,
+}
+
+// redisSecretDirectives are the directives holding credentials, their values
+// are redacted.
+var redisSecretDirectives = map[string]bool{
+	"requirepass":              true,
+	"masterauth":               true,
+	"tls-key-file-pass":        true,
+	"tls-client-key-file-pass": true,
+}
+
+// LoadRedisConfig loads and extracts the Redis configuration data found on
+// the system. The directives of the configuration file are overridden by the
+// ones given on the command line. Passwords are redacted but their presence
+// is kept so that they can be checked.
+func LoadRedisConfig(ctx context.Context, hostroot string, proc *process.Process) (*DBConfig, bool) {
+	var result DBConfig
+	result.ProcessUser, _ = proc.UsernameWithContext(ctx)
+	result.ProcessName, _ = proc.NameWithContext(ctx)
+
+	var (
+		configLocalPath string
+		overrides       [][]string
+	)
+	cmdline, _ := proc.CmdlineSlice()
+	// the arguments follow the redis-server executable, which may itself be
+	// preceded by an interpreter or a wrapper.
+	first := 1
+	for i, arg := range cmdline {
+		if strings.HasPrefix(filepath.Base(arg), "redis-server") {
+			first = i + 1
+			break
+		}
+	}
+	for i := first; i < len(cmdline); i++ {
+		arg := cmdline[i]
+		switch {
+		case i == first && arg != "" && !strings.HasPrefix(arg, "-"):
+			configLocalPath = filepath.Clean(arg)
+		case strings.HasPrefix(arg, "--") && len(arg) > 2:
+			overrides = append(overrides, []string{strings.TrimPrefix(arg, "--")})
+		case len(overrides) > 0 && arg != "":
+			overrides[len(overrides)-1] = append(overrides[len(overrides)-1], arg)
+		}
+	}
+
+	configPaths := redisDefaultConfigPaths
+	if configLocalPath != "" {
+		configPaths = []string{configLocalPath}
+	}
+
+	configData := make(map[string]interface{})
+	var files []DBConfigFile
+	for _, configPath := range configPaths {
+		if parseRedisConfig(hostroot, configPath, 0, configData, &files) {
+			break
+		}
+	}
+
+	for _, override := range overrides {
+		setRedisDirective(configData, override)
+	}
+
+	if len(files) == 0 {
+		result.ConfigFileUser = "<none>"
+		result.ConfigFileGroup = "<none>"
+		result.ConfigData = configData
+		return &result, true
+	}
+
+	result.ConfigFilePath = files[0].Path
+	result.ConfigFileUser = files[0].User
+	result.ConfigFileGroup = files[0].Group
+	result.ConfigFileMode = files[0].Mode
+	result.IncludedFiles = files[1:]
+	result.ConfigData = configData
+	return &result, true
+}
+
+// parseRedisConfig reads the given configuration file and merges its
+// directives into config. The metadata of the files read are appended to
+// files.
+//
+// reference: https://redis.io/docs/management/config-file/
+func parseRedisConfig(hostroot, configPath string, includeDepth int, config map[string]interface{}, files *[]DBConfigFile) bool {
+	// Let's protect ourselves from circular "includes" by limiting the depth
+	// of the include directives.
+	if includeDepth > 10 {
+		return false
+	}
+
+	fullPath := filepath.Join(hostroot, configPath)
+	fi, err := os.Stat(fullPath)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	b, err := readFileLimit(fullPath)
+	if err != nil {
+		return false
+	}
+	*files = append(*files, newDBConfigFile(configPath, fi))
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Split(bufio.ScanLines)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		args, ok := splitRedisArgs(line)
+		if !ok || len(args) == 0 {
+			continue
+		}
+		if strings.ToLower(args[0]) == "include" {
+			for _, includedPath := range args[1:] {
+				if !filepath.IsAbs(includedPath) {
+					includedPath = filepath.Join(filepath.Dir(configPath), includedPath)
+				}
+				// since redis 7.0, included paths can be glob patterns
+				matches, _ := filepath.Glob(filepath.Join(hostroot, includedPath))
+				sort.Strings(matches)
+				for _, match := range matches {
+					parseRedisConfig(hostroot, relPath(hostroot, match), includeDepth+1, config, files)
+				}
+			}
+			continue
+		}
+		setRedisDirective(config, args)
+	}
+	return true
+}
+
+func setRedisDirective(config map[string]interface{}, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	key := strings.ToLower(args[0])
+	values := args[1:]
+
+	if redisSecretDirectives[key] {
+		values = []string{redisRedactedValue}
+	} else if key == "user" {
+		values = redactRedisUserRules(values)
+	}
+	val := strings.Join(values, " ")
+
+	if !redisMultiValueDirectives[key] {
+		config[key] = val
+		return
+	}
+	// an empty save directive disables the snapshotting
+	if key == "save" && val == "" {
+		config[key] = []string{}
+		return
+	}
+	list, _ := config[key].([]string)
+	config[key] = append(list, val)
+}
+
+// redactRedisUserRules redacts the passwords and password hashes of the ACL
+// rules of a user directive.
+func redactRedisUserRules(rules []string) []string {
+	redacted := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if strings.HasPrefix(rule, ">") || strings.HasPrefix(rule, "<") || strings.HasPrefix(rule, "#") || strings.HasPrefix(rule, "!") {
+			rule = rule[:1] + redisRedactedValue
+		}
+		redacted = append(redacted, rule)
+	}
+	return redacted
+}
+
+// splitRedisArgs splits a configuration line into arguments, handling
+// quoted strings and escape sequences.
+//
+// reference: https://github.com/redis/redis/blob/7.2.4/src/sds.c#L1028-L1143
+func splitRedisArgs(line string) ([]string, bool) {
+	var args []string
+	for i := 0; i < len(line); {
+		for i < len(line) && isWhiteSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+
+		var arg strings.Builder
+		switch line[i] {
+		case '"':
+			i++
+			for {
+				if i >= len(line) {
+					// unterminated quotes
+					return nil, false
+				}
+				c := line[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						arg.WriteByte('\n')
+					case 'r':
+						arg.WriteByte('\r')
+					case 't':
+						arg.WriteByte('\t')
+					case 'b':
+						arg.WriteByte('\b')
+					case 'a':
+						arg.WriteByte('\a')
+					default:
+						arg.WriteByte(line[i])
+					}
+					i++
+					continue
+				}
+				arg.WriteByte(c)
+				i++
+			}
+		case '\'':
+			i++
+			for {
+				if i >= len(line) {
+					// unterminated quotes
+					return nil, false
+				}
+				c := line[i]
+				if c == '\'' {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					c = '\''
+				}
+				arg.WriteByte(c)
+				i++
+			}
+		default:
+			for i < len(line) && !isWhiteSpace(line[i]) {
+				arg.WriteByte(line[i])
+				i++
+			}
+		}
+		args = append(args, arg.String())
+	}
+	return args, true
+}
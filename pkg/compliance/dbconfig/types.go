@@ -23,6 +23,20 @@ type DBConfig struct {
 	ConfigFileGroup string      `json:"config_file_group"`
 	ConfigFileMode  uint32      `json:"config_file_mode"`
 	ConfigData      interface{} `json:"config_data"`
+
+	// IncludedFiles holds the metadata of the additional configuration files
+	// read along the main configuration file, through include directives or
+	// default lookup paths.
+	IncludedFiles []DBConfigFile `json:"included_files,omitempty"`
+}
+
+// DBConfigFile represents the ownership and permissions of a configuration
+// file.
+type DBConfigFile struct {
+	Path  string `json:"path"`
+	User  string `json:"user"`
+	Group string `json:"group"`
+	Mode  uint32 `json:"mode"`
 }
 
 type mongoDBConfig struct {
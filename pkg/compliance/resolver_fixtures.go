@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RegoInputsFixtures holds the resolved inputs of a set of rules, indexed by
+// rule ID. Once serialized in JSON, they can be used as a replacement of the
+// resolution of the inputs on a live host to evaluate rego rules offline.
+type RegoInputsFixtures map[string]ResolvedInputs
+
+// WriteTo serializes the fixtures in JSON to the given writer.
+func (f RegoInputsFixtures) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("could not marshal rego inputs fixtures: %w", err)
+	}
+	b = append(b, '\n')
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+type fixturesResolver struct {
+	fixtures map[string]map[string]interface{}
+}
+
+// NewFixturesResolver returns a Resolver resolving the inputs of the rules
+// from the JSON fixtures file at the given path, as written by
+// RegoInputsFixtures.WriteTo. The inputs of a rule hold the "context" key
+// with the resolving context that was used to resolve them.
+func NewFixturesResolver(fixturesPath string) (Resolver, error) {
+	data, err := os.ReadFile(fixturesPath)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("could not unmarshal rego inputs fixtures: %w", err)
+	}
+	return &fixturesResolver{fixtures: fixtures}, nil
+}
+
+func (r *fixturesResolver) ResolveInputs(_ context.Context, rule *Rule) (ResolvedInputs, error) {
+	fixture, ok := r.fixtures[rule.ID]
+	if !ok {
+		return nil, fmt.Errorf("could not find fixtures for rule %q", rule.ID)
+	}
+
+	var resolvingContext ResolvingContext
+	b, err := json.Marshal(fixture["context"])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &resolvingContext); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the resolving context of rule %q: %w", rule.ID, err)
+	}
+
+	resolved := make(map[string]interface{}, len(fixture))
+	for k, v := range fixture {
+		if k != "context" {
+			resolved[k] = v
+		}
+	}
+	return NewResolvedInputs(resolvingContext, resolved)
+}
+
+func (r *fixturesResolver) Close() {
+}
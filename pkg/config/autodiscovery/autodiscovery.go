@@ -37,6 +37,11 @@ func DiscoverComponentsFromConfig() ([]config.ConfigurationProviders, []config.L
 		detectedListeners = append(detectedListeners, config.Listeners{Name: "database-monitoring-aurora"})
 		log.Info("Database monitoring aurora discovery is enabled: Adding the aurora listener")
 	}
+	// Add systemd listener if the workloadmeta systemd collector is enabled
+	if config.Datadog.GetBool("workloadmeta.systemd_collector.enabled") {
+		detectedListeners = append(detectedListeners, config.Listeners{Name: "systemd"})
+		log.Info("The systemd collector is enabled: Adding the systemd listener")
+	}
 
 	// Auto-add file-based kube service and endpoints config providers based on check config files.
 	if flavor.GetFlavor() == flavor.ClusterAgent {
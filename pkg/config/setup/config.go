@@ -433,6 +433,22 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_chan_size", 200)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_buffer_size", 4000)
+	config.BindEnv("aggregator_rollup_rules")
+	config.SetEnvKeyTransformer("aggregator_rollup_rules", func(in string) interface{} {
+		var rules []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"aggregator_rollup_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+	config.BindEnv("aggregator_metric_overrides")
+	config.SetEnvKeyTransformer("aggregator_metric_overrides", func(in string) interface{} {
+		var overrides []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &overrides); err != nil {
+			log.Errorf(`"aggregator_metric_overrides" can not be parsed: %v`, err)
+		}
+		return overrides
+	})
 
 	// Serializer
 	config.BindEnvAndSetDefault("enable_stream_payload_serialization", true)
@@ -706,6 +722,13 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.SetKnown("network_devices.netflow.aggregator_flow_context_ttl")
 	config.SetKnown("network_devices.netflow.aggregator_port_rollup_threshold")
 	config.SetKnown("network_devices.netflow.aggregator_rollup_tracker_refresh_interval")
+	config.SetKnown("network_devices.netflow.enrichment.geoip_database_path")
+	config.SetKnown("network_devices.netflow.enrichment.asn_database_path")
+	config.SetKnown("network_devices.netflow.enrichment.interface_metadata_disabled")
+	config.SetKnown("network_devices.netflow.sampling_normalization.enabled")
+	config.SetKnown("network_devices.netflow.sampling_normalization.overrides")
+	config.SetKnown("network_devices.netflow.top_talkers.enabled")
+	config.SetKnown("network_devices.netflow.top_talkers.count")
 	config.BindEnvAndSetDefault("network_devices.netflow.enabled", "false")
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.netflow.forwarder.")
 
@@ -753,6 +776,10 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("cluster_agent.language_detection.cleanup.language_ttl", "30m")
 	// language annotation cleanup period
 	config.BindEnvAndSetDefault("cluster_agent.language_detection.cleanup.period", "10m")
+	// enables the reporting of the connections of the nodes and the service map of the cluster agent
+	config.BindEnvAndSetDefault("cluster_agent.service_map.enabled", false)
+	// the connections of the nodes which didn't report them since the TTL are removed from the service map
+	config.BindEnvAndSetDefault("cluster_agent.service_map.connection_ttl", "5m")
 
 	// Metadata endpoints
 
@@ -1136,6 +1163,7 @@ func InitConfig(config pkgconfigmodel.Config) {
 	// Mostly, keys we use IsSet() on, because IsSet always returns true if a key has a default.
 	config.SetKnown("metadata_providers")
 	config.SetKnown("config_providers")
+	config.SetKnown("tagger_rules")
 	config.SetKnown("cluster_name")
 	config.SetKnown("listeners")
 	config.SetKnown("proxy.http")
@@ -1171,6 +1199,11 @@ func InitConfig(config pkgconfigmodel.Config) {
 	// Remote process collector
 	config.BindEnvAndSetDefault("workloadmeta.local_process_collector.collection_interval", DefaultLocalProcessCollectorInterval)
 
+	// systemd collector
+	config.BindEnvAndSetDefault("workloadmeta.systemd_collector.enabled", false)
+	config.BindEnvAndSetDefault("workloadmeta.systemd_collector.units", []string{"*.service"})
+	config.BindEnvAndSetDefault("workloadmeta.systemd_collector.private_socket", "")
+
 	// SBOM configuration
 	config.BindEnvAndSetDefault("sbom.enabled", false)
 	bindEnvAndSetLogsConfigKeys(config, "sbom.")
@@ -1195,6 +1228,18 @@ func InitConfig(config pkgconfigmodel.Config) {
 	config.BindEnvAndSetDefault("sbom.host.enabled", false)
 	config.BindEnvAndSetDefault("sbom.host.analyzers", []string{"os"})
 
+	// Process SBOM configuration
+	config.BindEnvAndSetDefault("sbom.process.enabled", false)
+	config.BindEnvAndSetDefault("sbom.process.analyzers", []string{"languages"})
+	config.BindEnvAndSetDefault("sbom.process.scan_interval", 10)   // Integer seconds
+	config.BindEnvAndSetDefault("sbom.process.scan_timeout", 10*60) // Integer seconds
+	config.BindEnvAndSetDefault("sbom.process.rescan_interval", "1h")
+
+	// SBOM vulnerability matching configuration
+	config.BindEnvAndSetDefault("sbom.vulnerabilities.enabled", false)
+	config.BindEnvAndSetDefault("sbom.vulnerabilities.advisories_path", "") // OSV advisories bundle: a JSON file, a zip archive or a directory
+	config.BindEnvAndSetDefault("sbom.vulnerabilities.max_bundle_age", "168h")
+
 	// Orchestrator Explorer - process agent
 	// DEPRECATED in favor of `orchestrator_explorer.orchestrator_dd_url` setting. If both are set `orchestrator_explorer.orchestrator_dd_url` will take precedence.
 	config.BindEnv("process_config.orchestrator_dd_url", "DD_PROCESS_CONFIG_ORCHESTRATOR_DD_URL", "DD_PROCESS_AGENT_ORCHESTRATOR_DD_URL")
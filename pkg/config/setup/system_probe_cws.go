@@ -6,6 +6,8 @@
 package setup
 
 import (
+	"path/filepath"
+
 	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
 	"github.com/DataDog/datadog-agent/pkg/config/setup/constants"
 )
@@ -115,4 +117,8 @@ func initCWSSystemProbeConfig(cfg pkgconfigmodel.Config) {
 
 	// CWS enforcement capabilities
 	cfg.BindEnvAndSetDefault("runtime_security_config.enforcement.enabled", true)
+	cfg.BindEnvAndSetDefault("runtime_security_config.enforcement.remediation.max_rate", 1)
+	cfg.BindEnvAndSetDefault("runtime_security_config.enforcement.remediation.max_burst", 10)
+	cfg.BindEnvAndSetDefault("runtime_security_config.enforcement.quarantine.directory", filepath.Join(defaultRunPath, "runtime-security", "quarantine"))
+	cfg.BindEnvAndSetDefault("runtime_security_config.enforcement.coredump.max_size", 100*1024*1024)
 }
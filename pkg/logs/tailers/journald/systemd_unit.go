@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+
+package journald
+
+import (
+	"github.com/coreos/go-systemd/sdjournal"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/systemd"
+)
+
+// getSystemdUnit returns the systemd unit the journal entry comes from.
+func (t *Tailer) getSystemdUnit(entry *sdjournal.JournalEntry) (string, bool) {
+	unit, exists := entry.Fields[sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT]
+	return unit, exists && unit != ""
+}
+
+// getSystemdUnitTags returns all the tags of a given systemd unit. Units are
+// only known by the tagger when the systemd workloadmeta collector is enabled.
+func (t *Tailer) getSystemdUnitTags(unit string) []string {
+	tags, err := tagger.Tag(systemd.BuildTaggerEntityName(unit), collectors.HighCardinality)
+	if err != nil {
+		log.Debugf("Unable to get the tags of unit %s: %s", unit, err)
+	}
+	return tags
+}
@@ -44,6 +44,12 @@ func (a *AddSampleTelemetry) Inc(isStateful bool) {
 
 // AddSample add a sample to the current ContextMetrics and initialize a new metrics if needed.
 func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, t *AddSampleTelemetry, config pkgconfigmodel.Config) error {
+	return m.AddSampleWithHistogramConfig(contextKey, sample, timestamp, interval, t, config, nil)
+}
+
+// AddSampleWithHistogramConfig is like AddSample, but new histograms and historates are
+// configured with histogramConfig, when it is not nil, instead of the default configuration.
+func (m ContextMetrics) AddSampleWithHistogramConfig(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, t *AddSampleTelemetry, config pkgconfigmodel.Config, histogramConfig *HistogramConfig) error {
 	if math.IsInf(sample.Value, 0) || math.IsNaN(sample.Value) {
 		return fmt.Errorf("sample with value '%v'", sample.Value)
 	}
@@ -58,9 +64,17 @@ func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSamp
 		case MonotonicCountType:
 			m[contextKey] = &MonotonicCount{}
 		case HistogramType:
-			m[contextKey] = NewHistogram(interval, config) // default histogram configuration (no call to `configure`) for now
+			h := NewHistogram(interval, config)
+			if histogramConfig != nil {
+				h.applyConfig(histogramConfig)
+			}
+			m[contextKey] = h
 		case HistorateType:
-			m[contextKey] = NewHistorate(interval, config) // internal histogram has the configuration for now
+			h := NewHistorate(interval, config)
+			if histogramConfig != nil {
+				h.histogram.applyConfig(histogramConfig)
+			}
+			m[contextKey] = h
 		case SetType:
 			m[contextKey] = NewSet()
 		case CounterType:
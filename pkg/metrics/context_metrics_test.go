@@ -257,6 +257,45 @@ func TestContextMetricsHistogramSampling(t *testing.T) {
 	}
 }
 
+func TestContextMetricsHistogramConfig(t *testing.T) {
+	metrics := MakeContextMetrics()
+	contextKey := ckey.ContextKey(0xffffffffffffffff)
+
+	c := setupConfig()
+	hc := NewHistogramConfig([]string{"min", "sum"}, []string{"0.5"})
+	metrics.AddSampleWithHistogramConfig(contextKey, &MetricSample{Mtype: HistogramType, Value: 1}, 12340, 10, nil, c, hc)
+	metrics.AddSampleWithHistogramConfig(contextKey, &MetricSample{Mtype: HistogramType, Value: 6}, 12342, 10, nil, c, hc)
+	series, err := metrics.Flush(12351)
+
+	assert.Len(t, err, 0)
+	expectedSeries := []*Serie{
+		{
+			ContextKey: contextKey,
+			Points:     []Point{{12351.0, 1.}},
+			MType:      APIGaugeType,
+			NameSuffix: ".min",
+		},
+		{
+			ContextKey: contextKey,
+			Points:     []Point{{12351.0, 7.}},
+			MType:      APIGaugeType,
+			NameSuffix: ".sum",
+		},
+		{
+			ContextKey: contextKey,
+			Points:     []Point{{12351.0, 1.}},
+			MType:      APIGaugeType,
+			NameSuffix: ".50percentile",
+		},
+	}
+
+	if assert.Len(t, series, len(expectedSeries)) {
+		for i := range expectedSeries {
+			AssertSerieEqual(t, expectedSeries[i], series[i])
+		}
+	}
+}
+
 func TestContextMetricsHistorateSampling(t *testing.T) {
 	metrics := MakeContextMetrics()
 	contextKey := ckey.ContextKey(0xffffffffffffffff)
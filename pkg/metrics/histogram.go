@@ -55,8 +55,12 @@ type histogramPercentilesConfig struct {
 }
 
 func (h *histogramPercentilesConfig) percentiles() []int {
+	return parsePercentiles(h.Percentiles)
+}
+
+func parsePercentiles(percentiles []string) []int {
 	res := []int{}
-	for _, p := range h.Percentiles {
+	for _, p := range percentiles {
 		i, err := strconv.ParseFloat(p, 64)
 		if err != nil {
 			log.Errorf("Could not parse '%s' from 'histogram_percentiles' (skipping): %s", p, err)
@@ -74,6 +78,28 @@ func (h *histogramPercentilesConfig) percentiles() []int {
 	return res
 }
 
+// HistogramConfig overrides the aggregates and percentiles computed by a
+// histogram. A nil field keeps the value configured globally with
+// `histogram_aggregates` or `histogram_percentiles`.
+type HistogramConfig struct {
+	Aggregates  []string
+	Percentiles []int
+}
+
+// NewHistogramConfig returns a HistogramConfig from the aggregates and the
+// percentiles, formatted as in `histogram_percentiles`. Invalid percentiles
+// are skipped.
+func NewHistogramConfig(aggregates []string, percentiles []string) *HistogramConfig {
+	c := &HistogramConfig{
+		Aggregates: aggregates,
+	}
+	if percentiles != nil {
+		c.Percentiles = parsePercentiles(percentiles)
+		sort.Ints(c.Percentiles)
+	}
+	return c
+}
+
 // NewHistogram returns a newly initialized histogram
 func NewHistogram(interval int64, config pkgconfigmodel.Config) *Histogram {
 	// we initialize default value on the first histogram creation
@@ -104,6 +130,17 @@ func (h *Histogram) configure(aggregates []string, percentiles []int) {
 	h.percentiles = percentiles
 }
 
+// applyConfig overrides the default configuration of the histogram with the
+// non-nil fields of c
+func (h *Histogram) applyConfig(c *HistogramConfig) {
+	if c.Aggregates != nil {
+		h.aggregates = c.Aggregates
+	}
+	if c.Percentiles != nil {
+		h.percentiles = c.Percentiles
+	}
+}
+
 //nolint:revive // TODO(AML) Fix revive linter
 func (h *Histogram) addSample(sample *MetricSample, timestamp float64) {
 	rate := sample.SampleRate
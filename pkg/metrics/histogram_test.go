@@ -26,6 +26,29 @@ func TestHistogramConfError(t *testing.T) {
 	assert.Equal(t, []int{95, 22}, h.percentiles())
 }
 
+func TestNewHistogramConfig(t *testing.T) {
+	c := NewHistogramConfig([]string{"max", "sum"}, []string{"0.99", "test", "0.5"})
+	assert.Equal(t, []string{"max", "sum"}, c.Aggregates)
+	assert.Equal(t, []int{50, 99}, c.Percentiles)
+
+	c = NewHistogramConfig(nil, nil)
+	assert.Nil(t, c.Aggregates)
+	assert.Nil(t, c.Percentiles)
+}
+
+func TestApplyConfig(t *testing.T) {
+	cfg := setupConfig()
+	hist := NewHistogram(10, cfg)
+
+	hist.applyConfig(NewHistogramConfig(nil, []string{"0.99"}))
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, hist.aggregates)
+	assert.Equal(t, []int{99}, hist.percentiles)
+
+	hist.applyConfig(NewHistogramConfig([]string{"sum"}, []string{}))
+	assert.Equal(t, []string{"sum"}, hist.aggregates)
+	assert.Empty(t, hist.percentiles)
+}
+
 func TestConfigureDefault(t *testing.T) {
 	cfg := setupConfig()
 	hist := NewHistogram(10, cfg)
@@ -74,6 +74,10 @@ type Config struct {
 	// These stats objects get flushed on every client request (default 30s check interval)
 	MaxDNSStats int
 
+	// DNSNXDomainStormThreshold is the number of NXDOMAIN responses for a domain within a check interval
+	// above which the domain is flagged as an NXDOMAIN storm. 0 disables the detection.
+	DNSNXDomainStormThreshold int
+
 	// EnableHTTPMonitoring specifies whether the tracer should monitor HTTP traffic
 	EnableHTTPMonitoring bool
 
@@ -83,6 +87,15 @@ type Config struct {
 	// EnableKafkaMonitoring specifies whether the tracer should monitor Kafka traffic
 	EnableKafkaMonitoring bool
 
+	// EnablePostgresMonitoring specifies whether the tracer should monitor Postgres traffic
+	EnablePostgresMonitoring bool
+
+	// EnableMySQLMonitoring specifies whether the tracer should monitor MySQL traffic
+	EnableMySQLMonitoring bool
+
+	// EnableRedisMonitoring specifies whether the tracer should monitor Redis traffic
+	EnableRedisMonitoring bool
+
 	// EnableNativeTLSMonitoring specifies whether the USM should monitor HTTPS traffic via native libraries.
 	// Supported libraries: OpenSSL, GnuTLS, LibCrypto.
 	EnableNativeTLSMonitoring bool
@@ -179,6 +192,26 @@ type Config struct {
 	// get flushed on every client request (default 30s check interval)
 	MaxKafkaStatsBuffered int
 
+	// MaxPostgresStatsBuffered represents the maximum number of Postgres stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxPostgresStatsBuffered int
+
+	// MaxMySQLStatsBuffered represents the maximum number of MySQL stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMySQLStatsBuffered int
+
+	// MaxRedisStatsBuffered represents the maximum number of Redis stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxRedisStatsBuffered int
+
+	// RedisKeyPrefixDepth is the number of colon-separated segments of the Redis keys used to group the commands.
+	// Keys are not reported when set to 0.
+	RedisKeyPrefixDepth int
+
+	// MaxGRPCStatsBuffered represents the maximum number of gRPC stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxGRPCStatsBuffered int
+
 	// MaxConnectionsStateBuffered represents the maximum number of state objects that we'll store in memory. These state objects store
 	// the stats for a connection so we can accurately determine traffic change between client requests.
 	MaxConnectionsStateBuffered int
@@ -327,6 +360,8 @@ func New() *Config {
 		MaxDNSStatsBuffered: 75000,
 		DNSTimeout:          time.Duration(cfg.GetInt(join(spNS, "dns_timeout_in_s"))) * time.Second,
 
+		DNSNXDomainStormThreshold: cfg.GetInt(join(spNS, "dns_nxdomain_storm_threshold")),
+
 		ProtocolClassificationEnabled: cfg.GetBool(join(netNS, "enable_protocol_classification")),
 
 		NPMRingbuffersEnabled: cfg.GetBool(join(netNS, "enable_ringbuffers")),
@@ -334,12 +369,20 @@ func New() *Config {
 		EnableHTTPMonitoring:      cfg.GetBool(join(smNS, "enable_http_monitoring")),
 		EnableHTTP2Monitoring:     cfg.GetBool(join(smNS, "enable_http2_monitoring")),
 		EnableKafkaMonitoring:     cfg.GetBool(join(smNS, "enable_kafka_monitoring")),
+		EnablePostgresMonitoring:  cfg.GetBool(join(smNS, "enable_postgres_monitoring")),
+		EnableMySQLMonitoring:     cfg.GetBool(join(smNS, "enable_mysql_monitoring")),
+		EnableRedisMonitoring:     cfg.GetBool(join(smNS, "enable_redis_monitoring")),
 		EnableNativeTLSMonitoring: cfg.GetBool(join(smNS, "tls", "native", "enabled")),
 		EnableIstioMonitoring:     cfg.GetBool(join(smNS, "tls", "istio", "enabled")),
 		EnableNodeJSMonitoring:    cfg.GetBool(join(smNS, "tls", "nodejs", "enabled")),
 		MaxUSMConcurrentRequests:  uint32(cfg.GetInt(join(smNS, "max_concurrent_requests"))),
 		MaxHTTPStatsBuffered:      cfg.GetInt(join(smNS, "max_http_stats_buffered")),
 		MaxKafkaStatsBuffered:     cfg.GetInt(join(smNS, "max_kafka_stats_buffered")),
+		MaxPostgresStatsBuffered:  cfg.GetInt(join(smNS, "max_postgres_stats_buffered")),
+		MaxMySQLStatsBuffered:     cfg.GetInt(join(smNS, "max_mysql_stats_buffered")),
+		MaxRedisStatsBuffered:     cfg.GetInt(join(smNS, "max_redis_stats_buffered")),
+		RedisKeyPrefixDepth:       cfg.GetInt(join(smNS, "redis_key_prefix_depth")),
+		MaxGRPCStatsBuffered:      cfg.GetInt(join(smNS, "max_grpc_stats_buffered")),
 
 		MaxTrackedHTTPConnections: cfg.GetInt64(join(smNS, "max_tracked_http_connections")),
 		HTTPNotificationThreshold: cfg.GetInt64(join(smNS, "http_notification_threshold")),
@@ -256,6 +256,72 @@ service_monitoring_config:
 	})
 }
 
+func TestEnablePostgresMonitoring(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  enable_postgres_monitoring: true
+`)
+
+		assert.True(t, cfg.EnablePostgresMonitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_POSTGRES_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnablePostgresMonitoring)
+	})
+}
+
+func TestEnableMySQLMonitoring(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  enable_mysql_monitoring: true
+`)
+
+		assert.True(t, cfg.EnableMySQLMonitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_MYSQL_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableMySQLMonitoring)
+	})
+}
+
+func TestEnableRedisMonitoring(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  enable_redis_monitoring: true
+`)
+
+		assert.True(t, cfg.EnableRedisMonitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_REDIS_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableRedisMonitoring)
+	})
+}
+
 func TestDefaultDisabledJavaTLSSupport(t *testing.T) {
 	aconfig.ResetSystemProbeConfig(t)
 
@@ -421,6 +487,35 @@ system_probe_config:
 	})
 }
 
+func TestDNSNXDomainStormThreshold(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+system_probe_config:
+  dns_nxdomain_storm_threshold: 20
+`)
+
+		assert.Equal(t, 20, cfg.DNSNXDomainStormThreshold)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 100, cfg.DNSNXDomainStormThreshold) // default value
+
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_CONFIG_DNS_NXDOMAIN_STORM_THRESHOLD", "0")
+		_, err = sysconfig.New("")
+		require.NoError(t, err)
+		cfg = New()
+
+		assert.Equal(t, 0, cfg.DNSNXDomainStormThreshold)
+	})
+}
+
 func TestHTTPReplaceRules(t *testing.T) {
 	expected := []*ReplaceRule{
 		{
@@ -1217,6 +1312,97 @@ service_monitoring_config:
 	})
 }
 
+func TestMaxPostgresStatsBuffered(t *testing.T) {
+	t.Run("default value", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+
+		cfg := New()
+		assert.Equal(t, 100000, cfg.MaxPostgresStatsBuffered)
+		assert.Equal(t, 100000, cfg.MaxMySQLStatsBuffered)
+	})
+
+	t.Run("value set through env var", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_POSTGRES_STATS_BUFFERED", "50000")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_MYSQL_STATS_BUFFERED", "40000")
+
+		cfg := New()
+		assert.Equal(t, 50000, cfg.MaxPostgresStatsBuffered)
+		assert.Equal(t, 40000, cfg.MaxMySQLStatsBuffered)
+	})
+
+	t.Run("value set through yaml", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  max_postgres_stats_buffered: 30000
+  max_mysql_stats_buffered: 20000
+`)
+
+		assert.Equal(t, 30000, cfg.MaxPostgresStatsBuffered)
+		assert.Equal(t, 20000, cfg.MaxMySQLStatsBuffered)
+	})
+}
+
+func TestRedisStatsConfig(t *testing.T) {
+	t.Run("default value", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+
+		cfg := New()
+		assert.Equal(t, 100000, cfg.MaxRedisStatsBuffered)
+		assert.Equal(t, 1, cfg.RedisKeyPrefixDepth)
+	})
+
+	t.Run("value set through env var", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_REDIS_STATS_BUFFERED", "50000")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_REDIS_KEY_PREFIX_DEPTH", "2")
+
+		cfg := New()
+		assert.Equal(t, 50000, cfg.MaxRedisStatsBuffered)
+		assert.Equal(t, 2, cfg.RedisKeyPrefixDepth)
+	})
+
+	t.Run("value set through yaml", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  max_redis_stats_buffered: 30000
+  redis_key_prefix_depth: 0
+`)
+
+		assert.Equal(t, 30000, cfg.MaxRedisStatsBuffered)
+		assert.Equal(t, 0, cfg.RedisKeyPrefixDepth)
+	})
+}
+
+func TestMaxGRPCStatsBuffered(t *testing.T) {
+	t.Run("default value", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+
+		cfg := New()
+		assert.Equal(t, 100000, cfg.MaxGRPCStatsBuffered)
+	})
+
+	t.Run("value set through env var", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_GRPC_STATS_BUFFERED", "50000")
+
+		cfg := New()
+		assert.Equal(t, 50000, cfg.MaxGRPCStatsBuffered)
+	})
+
+	t.Run("value set through yaml", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+service_monitoring_config:
+  max_grpc_stats_buffered: 30000
+`)
+
+		assert.Equal(t, 30000, cfg.MaxGRPCStatsBuffered)
+	})
+}
+
 func TestNetworkConfigEnabled(t *testing.T) {
 	ys := true
 
@@ -9,6 +9,7 @@ package dns
 
 import (
 	"bytes"
+	"encoding/binary"
 	"syscall"
 	"time"
 
@@ -21,7 +22,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-const maxIPBufferSize = 200
+const (
+	maxIPBufferSize = 200
+
+	// dnsOptionCodeExtendedError is the EDNS option holding an extended DNS
+	// error, see RFC 8914
+	dnsOptionCodeExtendedError layers.DNSOptionCode = 15
+)
 
 var (
 	errTruncated      = errors.New("the packet is truncated")
@@ -59,13 +66,17 @@ var (
 )
 
 type dnsParser struct {
-	decoder            *gopacket.DecodingLayerParser
-	layers             []gopacket.LayerType
-	ipv4Payload        *layers.IPv4
-	ipv6Payload        *layers.IPv6
-	udpPayload         *layers.UDP
-	tcpPayload         *tcpWithDNSSupport
-	dnsPayload         *layers.DNS
+	decoder     *gopacket.DecodingLayerParser
+	layers      []gopacket.LayerType
+	ipv4Payload *layers.IPv4
+	ipv6Payload *layers.IPv6
+	udpPayload  *layers.UDP
+	tcpPayload  *tcpWithDNSSupport
+	dnsPayload  *layers.DNS
+	tcpStreams  *tcpDNSStreams
+	// pendingMessages holds the DNS messages completed by the last TCP
+	// segment which are yet to be parsed by ParseNextInto
+	pendingMessages    [][]byte
 	collectDNSStats    bool
 	collectDNSDomains  bool
 	recordedQueryTypes map[layers.DNSType]struct{}
@@ -102,13 +113,18 @@ func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
 		udpPayload:         udpPayload,
 		tcpPayload:         tcpPayload,
 		dnsPayload:         dnsPayload,
+		tcpStreams:         newTCPDNSStreams(),
 		collectDNSStats:    cfg.CollectDNSStats,
 		collectDNSDomains:  cfg.CollectDNSDomains,
 		recordedQueryTypes: queryTypes,
 	}
 }
 
+// ParseInto parses the DNS message of a packet. When the packet is a DNS over
+// TCP segment completing several messages, the first one is parsed and the
+// others are parsed by the following calls to ParseNextInto.
 func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInfo) error {
+	p.pendingMessages = p.pendingMessages[:0]
 	err := p.decoder.DecodeLayers(data, &p.layers)
 
 	if p.decoder.Truncated {
@@ -119,11 +135,58 @@ func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInf
 		return err
 	}
 
-	// If there is a DNS layer then it would be the last layer
-	if p.layers[len(p.layers)-1] != layers.LayerTypeDNS {
+	// If there is a DNS layer then it would be the last layer, unless the
+	// message is carried over TCP
+	switch p.layers[len(p.layers)-1] {
+	case layers.LayerTypeDNS:
+	case layers.LayerTypeTCP:
+		tcp := p.tcpPayload
+		messages := p.tcpStreams.feed(p.tcpStreamKey(), tcp.Payload, tcp.FIN || tcp.RST)
+		if len(messages) == 0 {
+			return errSkippedPayload
+		}
+		p.pendingMessages = append(p.pendingMessages, messages[1:]...)
+		if err := p.dnsPayload.DecodeFromBytes(messages[0], gopacket.NilDecodeFeedback); err != nil {
+			return err
+		}
+	default:
 		return errSkippedPayload
 	}
 
+	return p.parseMessageInto(t, pktInfo)
+}
+
+// ParseNextInto parses the next DNS message completed by the last TCP segment
+// given to ParseInto. It returns false once all the messages were parsed.
+func (p *dnsParser) ParseNextInto(t *translation, pktInfo *dnsPacketInfo) (bool, error) {
+	if len(p.pendingMessages) == 0 {
+		return false, nil
+	}
+
+	message := p.pendingMessages[0]
+	p.pendingMessages = p.pendingMessages[1:]
+	if err := p.dnsPayload.DecodeFromBytes(message, gopacket.NilDecodeFeedback); err != nil {
+		return true, err
+	}
+	return true, p.parseMessageInto(t, pktInfo)
+}
+
+func (p *dnsParser) tcpStreamKey() tcpStreamKey {
+	key := tcpStreamKey{sport: uint16(p.tcpPayload.SrcPort), dport: uint16(p.tcpPayload.DstPort)}
+	for _, layer := range p.layers {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			key.src, key.dst = util.AddressFromNetIP(p.ipv4Payload.SrcIP), util.AddressFromNetIP(p.ipv4Payload.DstIP)
+		case layers.LayerTypeIPv6:
+			key.src, key.dst = util.AddressFromNetIP(p.ipv6Payload.SrcIP), util.AddressFromNetIP(p.ipv6Payload.DstIP)
+		}
+	}
+	return key
+}
+
+// parseMessageInto parses the decoded DNS message along with the layers it
+// was carried by
+func (p *dnsParser) parseMessageInto(t *translation, pktInfo *dnsPacketInfo) error {
 	if err := p.parseAnswerInto(p.dnsPayload, t, pktInfo); err != nil {
 		return err
 	}
@@ -179,6 +242,19 @@ func (p *dnsParser) parseAnswerInto(
 	t *translation,
 	pktInfo *dnsPacketInfo,
 ) error {
+	opt := findOPT(dns.Additionals)
+
+	// Error responses such as FORMERR or NOTIMP may not echo the question,
+	// they are matched to their query by transaction ID
+	if dns.QR && len(dns.Questions) == 0 {
+		pktInfo.rCode, pktInfo.extendedError, pktInfo.hasExtendedError = responseCode(dns, opt)
+		if pktInfo.rCode == 0 {
+			return errSkippedPayload
+		}
+		pktInfo.pktType = failedResponse
+		return nil
+	}
+
 	// Only consider singleton, A-record questions
 	if len(dns.Questions) != 1 {
 		return errSkippedPayload
@@ -193,6 +269,7 @@ func (p *dnsParser) parseAnswerInto(
 	if !dns.QR {
 		pktInfo.pktType = query
 		pktInfo.queryType = QueryType(question.Type)
+		pktInfo.clientSubnet = findOption(opt, layers.DNSOptionCodeEDNSClientSubnet) != nil
 		if p.collectDNSDomains {
 			pktInfo.question = ToHostname(string(question.Name))
 		} else {
@@ -201,8 +278,8 @@ func (p *dnsParser) parseAnswerInto(
 		return nil
 	}
 
-	pktInfo.rCode = uint8(dns.ResponseCode)
-	if dns.ResponseCode != 0 {
+	pktInfo.rCode, pktInfo.extendedError, pktInfo.hasExtendedError = responseCode(dns, opt)
+	if pktInfo.rCode != 0 {
 		pktInfo.pktType = failedResponse
 		return nil
 	}
@@ -244,6 +321,46 @@ func (*dnsParser) extractIPsInto(alias []byte, records []layers.DNSResourceRecor
 	}
 }
 
+// findOPT returns the EDNS OPT pseudo-record of a message, if any
+func findOPT(records []layers.DNSResourceRecord) *layers.DNSResourceRecord {
+	for i := range records {
+		if records[i].Type == layers.DNSTypeOPT {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+// findOption returns the EDNS option of the given code, if any
+func findOption(opt *layers.DNSResourceRecord, code layers.DNSOptionCode) *layers.DNSOPT {
+	if opt == nil {
+		return nil
+	}
+	for i := range opt.OPT {
+		if opt.OPT[i].Code == code {
+			return &opt.OPT[i]
+		}
+	}
+	return nil
+}
+
+// responseCode returns the 12 bits response code of a response, made of the
+// header response code and of the upper bits carried by the OPT record, see
+// RFC 6891, along with the extended DNS error info code of RFC 8914, if any
+func responseCode(dns *layers.DNS, opt *layers.DNSResourceRecord) (rCode uint16, extendedError uint16, hasExtendedError bool) {
+	rCode = uint16(dns.ResponseCode) & 0xF
+	if opt == nil {
+		return rCode, 0, false
+	}
+	rCode |= uint16(opt.TTL>>24) << 4
+
+	// the info code is followed by an optional UTF-8 explanation
+	if ede := findOption(opt, dnsOptionCodeExtendedError); ede != nil && len(ede.Data) >= 2 {
+		return rCode, binary.BigEndian.Uint16(ede.Data), true
+	}
+	return rCode, 0, false
+}
+
 func (p *dnsParser) isWantedQueryType(checktype layers.DNSType) bool {
 	_, ok := p.recordedQueryTypes[checktype]
 	return ok
@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	mdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	parserClientIP = net.ParseIP("10.0.0.1").To4()
+	parserServerIP = net.ParseIP("10.0.0.2").To4()
+)
+
+const parserClientPort = 40000
+
+func newTestParser(t *testing.T) *dnsParser {
+	cfg := testConfig()
+	cfg.CollectDNSStats = true
+	cfg.CollectDNSDomains = true
+	return newDNSParser(layers.LayerTypeIPv4, cfg)
+}
+
+func testQuery(id uint16, name string) *mdns.Msg {
+	msg := new(mdns.Msg)
+	msg.SetQuestion(mdns.Fqdn(name), mdns.TypeA)
+	msg.Id = id
+	return msg
+}
+
+func testResponse(query *mdns.Msg, rcode int, ip string) *mdns.Msg {
+	msg := new(mdns.Msg)
+	msg.SetRcode(query, rcode)
+	if ip != "" {
+		msg.Answer = append(msg.Answer, &mdns.A{
+			Hdr: mdns.RR_Header{Name: query.Question[0].Name, Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 60},
+			A:   net.ParseIP(ip),
+		})
+	}
+	return msg
+}
+
+// tcpMessages prefixes the DNS messages with their length, as sent over TCP
+func tcpMessages(t *testing.T, msgs ...*mdns.Msg) []byte {
+	var stream []byte
+	for _, msg := range msgs {
+		data, err := msg.Pack()
+		require.NoError(t, err)
+		stream = binary.BigEndian.AppendUint16(stream, uint16(len(data)))
+		stream = append(stream, data...)
+	}
+	return stream
+}
+
+func packDNS(t *testing.T, msg *mdns.Msg) []byte {
+	data, err := msg.Pack()
+	require.NoError(t, err)
+	return data
+}
+
+// testPacket returns an IPv4 packet carrying the payload, sent by the client
+// unless fromServer is set
+func testPacket(t *testing.T, protocol layers.IPProtocol, fromServer bool, payload []byte) []byte {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: protocol, SrcIP: parserClientIP, DstIP: parserServerIP}
+	sport, dport := uint16(parserClientPort), uint16(53)
+	if fromServer {
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+		sport, dport = dport, sport
+	}
+
+	var transport gopacket.SerializableLayer
+	if protocol == layers.IPProtocolTCP {
+		tcp := &layers.TCP{SrcPort: layers.TCPPort(sport), DstPort: layers.TCPPort(dport), ACK: true, PSH: true, Window: 1024}
+		require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+		transport = tcp
+	} else {
+		udp := &layers.UDP{SrcPort: layers.UDPPort(sport), DstPort: layers.UDPPort(dport)}
+		require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+		transport = udp
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, ip, transport, gopacket.Payload(payload)))
+	return buf.Bytes()
+}
+
+func TestParseTCPMultipleMessages(t *testing.T) {
+	p := newTestParser(t)
+	q1, q2 := testQuery(1, "foo.com"), testQuery(2, "bar.com")
+	pkt := testPacket(t, layers.IPProtocolTCP, false, tcpMessages(t, q1, q2))
+
+	var info dnsPacketInfo
+	require.NoError(t, p.ParseInto(pkt, new(translation), &info))
+	assert.Equal(t, query, info.pktType)
+	assert.Equal(t, uint16(1), info.transactionID)
+	assert.Equal(t, "foo.com", ToString(info.question))
+	assert.Equal(t, uint8(syscall.IPPROTO_TCP), info.key.Protocol)
+	assert.Equal(t, uint16(parserClientPort), info.key.ClientPort)
+
+	info = dnsPacketInfo{}
+	ok, err := p.ParseNextInto(new(translation), &info)
+	require.True(t, ok)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(2), info.transactionID)
+	assert.Equal(t, "bar.com", ToString(info.question))
+	assert.Equal(t, uint16(parserClientPort), info.key.ClientPort)
+
+	ok, _ = p.ParseNextInto(new(translation), &info)
+	assert.False(t, ok)
+}
+
+func TestParseTCPSplitMessage(t *testing.T) {
+	p := newTestParser(t)
+	q := testQuery(1, "foo.com")
+	stream := tcpMessages(t, testResponse(q, mdns.RcodeSuccess, "1.2.3.4"))
+
+	var info dnsPacketInfo
+	// the length field and the beginning of the header
+	err := p.ParseInto(testPacket(t, layers.IPProtocolTCP, true, stream[:5]), new(translation), &info)
+	assert.ErrorIs(t, err, errSkippedPayload)
+
+	tr := newTranslation("")
+	require.NoError(t, p.ParseInto(testPacket(t, layers.IPProtocolTCP, true, stream[5:]), tr, &info))
+	assert.Equal(t, successfulResponse, info.pktType)
+	assert.Equal(t, "foo.com", ToString(tr.dns))
+	assert.Len(t, tr.ips, 1)
+	assert.Empty(t, p.tcpStreams.streams)
+}
+
+func TestParseTCPStreamExpiry(t *testing.T) {
+	p := newTestParser(t)
+	now := time.Now()
+	p.tcpStreams.now = func() time.Time { return now }
+
+	q := testQuery(1, "foo.com")
+	stream := tcpMessages(t, testResponse(q, mdns.RcodeSuccess, "1.2.3.4"))
+
+	var info dnsPacketInfo
+	err := p.ParseInto(testPacket(t, layers.IPProtocolTCP, true, stream[:5]), new(translation), &info)
+	assert.ErrorIs(t, err, errSkippedPayload)
+	assert.Len(t, p.tcpStreams.streams, 1)
+
+	// the rest of the message arrives after the stream expired, so it can't
+	// be parsed anymore
+	now = now.Add(tcpStreamTimeout)
+	err = p.ParseInto(testPacket(t, layers.IPProtocolTCP, true, stream[5:]), new(translation), &info)
+	assert.Error(t, err)
+	assert.Empty(t, p.tcpStreams.streams)
+}
+
+func TestParseTCPInvalidStream(t *testing.T) {
+	p := newTestParser(t)
+
+	var info dnsPacketInfo
+	err := p.ParseInto(testPacket(t, layers.IPProtocolTCP, false, []byte{0, 1, 0xff}), new(translation), &info)
+	assert.ErrorIs(t, err, errSkippedPayload)
+	assert.Empty(t, p.tcpStreams.streams)
+}
+
+func TestParseEDNSClientSubnet(t *testing.T) {
+	p := newTestParser(t)
+	q := testQuery(1, "foo.com")
+	q.SetEdns0(4096, false)
+	opt := q.IsEdns0()
+	opt.Option = append(opt.Option, &mdns.EDNS0_SUBNET{
+		Code:          mdns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("192.0.2.0").To4(),
+	})
+
+	var info dnsPacketInfo
+	require.NoError(t, p.ParseInto(testPacket(t, layers.IPProtocolUDP, false, packDNS(t, q)), new(translation), &info))
+	assert.Equal(t, query, info.pktType)
+	assert.True(t, info.clientSubnet)
+}
+
+func TestParseExtendedResponseCode(t *testing.T) {
+	p := newTestParser(t)
+	q := testQuery(1, "foo.com")
+	r := testResponse(q, mdns.RcodeBadVers, "")
+	r.SetEdns0(4096, false)
+	opt := r.IsEdns0()
+	opt.SetExtendedRcode(mdns.RcodeBadVers)
+	opt.Option = append(opt.Option, &mdns.EDNS0_EDE{InfoCode: mdns.ExtendedErrorCodeStaleAnswer, ExtraText: "stale"})
+
+	var info dnsPacketInfo
+	require.NoError(t, p.ParseInto(testPacket(t, layers.IPProtocolUDP, true, packDNS(t, r)), new(translation), &info))
+	assert.Equal(t, failedResponse, info.pktType)
+	assert.Equal(t, uint16(mdns.RcodeBadVers), info.rCode)
+	assert.True(t, info.hasExtendedError)
+	assert.Equal(t, mdns.ExtendedErrorCodeStaleAnswer, info.extendedError)
+}
+
+func TestParseErrorResponseWithoutQuestion(t *testing.T) {
+	p := newTestParser(t)
+	r := testResponse(testQuery(7, "foo.com"), mdns.RcodeFormatError, "")
+	r.Question = nil
+
+	var info dnsPacketInfo
+	require.NoError(t, p.ParseInto(testPacket(t, layers.IPProtocolUDP, true, packDNS(t, r)), new(translation), &info))
+	assert.Equal(t, failedResponse, info.pktType)
+	assert.Equal(t, uint16(mdns.RcodeFormatError), info.rCode)
+	assert.Equal(t, uint16(7), info.transactionID)
+	assert.Equal(t, uint16(parserClientPort), info.key.ClientPort)
+
+	// a successful response needs a question to be matched to the IPs
+	r.Rcode = mdns.RcodeSuccess
+	err := p.ParseInto(testPacket(t, layers.IPProtocolUDP, true, packDNS(t, r)), new(translation), &info)
+	assert.ErrorIs(t, err, errSkippedPayload)
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package dns
+
+import (
+	"time"
+
+	"github.com/google/gopacket"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// PacketReplayer feeds previously captured packets to the DNS snooper logic.
+// Unlike the snooper, it doesn't read packets from a socket: the packets are
+// processed synchronously by ProcessPacket, in the order they were captured.
+type PacketReplayer struct {
+	snooper *socketFilterSnooper
+	parsers map[gopacket.LayerType]*dnsParser
+	cfg     *config.Config
+
+	// lastSeen is the timestamp of the latest packet, which is used instead
+	// of the wall clock to expire the queries that got no response
+	lastSeen time.Time
+}
+
+// NewPacketReplayer returns a new PacketReplayer
+func NewPacketReplayer(cfg *config.Config) *PacketReplayer {
+	var statKeeper *dnsStatKeeper
+	if cfg.CollectDNSStats {
+		statKeeper = newDNSStatkeeper(cfg.DNSTimeout, int64(cfg.MaxDNSStats), uint32(cfg.DNSNXDomainStormThreshold))
+	}
+	return &PacketReplayer{
+		snooper: &socketFilterSnooper{
+			cache:           newReverseDNSCache(dnsCacheSize, dnsCacheExpirationPeriod),
+			statKeeper:      statKeeper,
+			translation:     new(translation),
+			collectLocalDNS: cfg.CollectLocalDNS,
+		},
+		parsers: make(map[gopacket.LayerType]*dnsParser),
+		cfg:     cfg,
+	}
+}
+
+// ProcessPacket processes a packet starting with a layer of the given type,
+// which is either an Ethernet, IPv4 or IPv6 layer
+func (r *PacketReplayer) ProcessPacket(layerType gopacket.LayerType, data []byte, ts time.Time) error {
+	parser, ok := r.parsers[layerType]
+	if !ok {
+		parser = newDNSParser(layerType, r.cfg)
+		r.parsers[layerType] = parser
+	}
+	if ts.After(r.lastSeen) {
+		r.lastSeen = ts
+	}
+
+	r.snooper.parser = parser
+	return r.snooper.processPacket(data, ts)
+}
+
+// Resolve IPs to DNS addresses
+func (r *PacketReplayer) Resolve(ips map[util.Address]struct{}) map[util.Address][]Hostname {
+	return r.snooper.Resolve(ips)
+}
+
+// GetDNSStats gets the stats of the replayed packets. The queries sent more
+// than the DNS timeout before the latest packet are counted as timeouts.
+func (r *PacketReplayer) GetDNSStats() StatsByKeyByNameByType {
+	if r.snooper.statKeeper == nil {
+		return nil
+	}
+	r.snooper.statKeeper.removeExpiredStates(r.lastSeen.Add(-r.cfg.DNSTimeout))
+	return r.snooper.GetDNSStats()
+}
+
+// Close releases the resources held by the replayer
+func (r *PacketReplayer) Close() {
+	r.snooper.cache.Close()
+	if r.snooper.statKeeper != nil {
+		r.snooper.statKeeper.Close()
+	}
+}
@@ -32,6 +32,10 @@ var snooperTelemetry = struct {
 	queries        *telemetry.StatCounterWrapper
 	successes      *telemetry.StatCounterWrapper
 	errors         *telemetry.StatCounterWrapper
+
+	tcpStreamErrors   *telemetry.StatCounterWrapper
+	tcpStreamsDropped *telemetry.StatCounterWrapper
+	tcpStreamsExpired *telemetry.StatCounterWrapper
 }{
 	telemetry.NewStatCounterWrapper(dnsModuleName, "decoding_errors", []string{}, "Counter measuring the number of decoding errors while processing packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "truncated_pkts", []string{}, "Counter measuring the number of truncated packets while processing"),
@@ -39,6 +43,10 @@ var snooperTelemetry = struct {
 	telemetry.NewStatCounterWrapper(dnsModuleName, "queries", []string{}, "Counter measuring the number of packets that are DNS queries in processed packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "successes", []string{}, "Counter measuring the number of successful DNS responses in processed packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "errors", []string{}, "Counter measuring the number of failed DNS responses in processed packets"),
+
+	telemetry.NewStatCounterWrapper(dnsModuleName, "tcp_stream_errors", []string{}, "Counter measuring the number of DNS over TCP streams dropped because of an invalid message length"),
+	telemetry.NewStatCounterWrapper(dnsModuleName, "tcp_streams_dropped", []string{}, "Counter measuring the number of partial DNS over TCP messages dropped because too many streams were buffered"),
+	telemetry.NewStatCounterWrapper(dnsModuleName, "tcp_streams_expired", []string{}, "Counter measuring the number of partial DNS over TCP messages dropped because their stream timed out"),
 }
 
 var _ ReverseDNS = &socketFilterSnooper{}
@@ -83,7 +91,7 @@ func newSocketFilterSnooper(cfg *config.Config, source packetSource) (*socketFil
 	cache := newReverseDNSCache(dnsCacheSize, dnsCacheExpirationPeriod)
 	var statKeeper *dnsStatKeeper
 	if cfg.CollectDNSStats {
-		statKeeper = newDNSStatkeeper(cfg.DNSTimeout, int64(cfg.MaxDNSStats))
+		statKeeper = newDNSStatkeeper(cfg.DNSTimeout, int64(cfg.MaxDNSStats), uint32(cfg.DNSNXDomainStormThreshold))
 		log.Infof("DNS Stats Collection has been enabled. Maximum number of stats objects: %d", cfg.MaxDNSStats)
 		if cfg.CollectDNSDomains {
 			log.Infof("DNS domain collection has been enabled")
@@ -157,8 +165,25 @@ func (s *socketFilterSnooper) Close() {
 func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 	t := s.getCachedTranslation()
 	pktInfo := dnsPacketInfo{}
+	s.processMessage(s.parser.ParseInto(data, t, &pktInfo), t, pktInfo, ts)
+
+	// a DNS over TCP segment may complete several messages
+	for {
+		t = s.getCachedTranslation()
+		pktInfo = dnsPacketInfo{}
+		ok, err := s.parser.ParseNextInto(t, &pktInfo)
+		if !ok {
+			break
+		}
+		s.processMessage(err, t, pktInfo, ts)
+	}
 
-	if err := s.parser.ParseInto(data, t, &pktInfo); err != nil {
+	return nil
+}
+
+// processMessage records a parsed DNS message, err being the parsing error
+func (s *socketFilterSnooper) processMessage(err error, t *translation, pktInfo dnsPacketInfo, ts time.Time) {
+	if err != nil {
 		switch err {
 		case errSkippedPayload: // no need to count or log cases where the packet is valid but has no relevant content
 		case errTruncated:
@@ -166,7 +191,7 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 		default:
 			snooperTelemetry.decodingErrors.Inc()
 		}
-		return nil
+		return
 	}
 
 	if s.statKeeper != nil && (s.collectLocalDNS || !pktInfo.key.ServerIP.IsLoopback()) {
@@ -181,8 +206,6 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 	} else {
 		snooperTelemetry.queries.Inc()
 	}
-
-	return nil
 }
 
 func (s *socketFilterSnooper) pollPackets() {
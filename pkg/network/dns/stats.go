@@ -32,6 +32,10 @@ const (
 	// for 10000 entries.
 	maxStateMapSize = 10000
 
+	// rcodeNXDomain is the response code of the queries for domains which
+	// don't exist
+	rcodeNXDomain = 3
+
 	// See WaitForDomain
 	waitForDomainTimeout = 5 * time.Second
 )
@@ -48,9 +52,14 @@ type dnsPacketInfo struct {
 	transactionID uint16
 	key           Key
 	pktType       packetType
-	rCode         uint8    // responseCode
+	rCode         uint16   // responseCode, including the EDNS extended bits
 	question      Hostname // only relevant for query packets
 	queryType     QueryType
+	// clientSubnet is set when a query carries an EDNS client subnet option
+	clientSubnet bool
+	// extendedError is the extended DNS error info code of a response
+	extendedError    uint16
+	hasExtendedError bool
 }
 
 type stateKey struct {
@@ -59,9 +68,10 @@ type stateKey struct {
 }
 
 type stateValue struct {
-	ts       uint64
-	question Hostname
-	qtype    QueryType
+	ts           uint64
+	question     Hostname
+	qtype        QueryType
+	clientSubnet bool
 }
 
 type dnsStatKeeper struct {
@@ -76,16 +86,21 @@ type dnsStatKeeper struct {
 	processedStats   int64
 	droppedStats     int64
 	maxStats         int64
+	// nxDomainStormThreshold is the number of NXDOMAIN responses for a
+	// domain within an interval which is reported as a storm, 0 disables
+	// the detection
+	nxDomainStormThreshold uint32
 }
 
-func newDNSStatkeeper(timeout time.Duration, maxStats int64) *dnsStatKeeper {
+func newDNSStatkeeper(timeout time.Duration, maxStats int64, nxDomainStormThreshold uint32) *dnsStatKeeper {
 	statsKeeper := &dnsStatKeeper{
-		stats:            make(StatsByKeyByNameByType),
-		state:            make(map[stateKey]stateValue),
-		expirationPeriod: timeout,
-		exit:             make(chan struct{}),
-		maxSize:          maxStateMapSize,
-		maxStats:         maxStats,
+		stats:                  make(StatsByKeyByNameByType),
+		state:                  make(map[stateKey]stateValue),
+		expirationPeriod:       timeout,
+		exit:                   make(chan struct{}),
+		maxSize:                maxStateMapSize,
+		maxStats:               maxStats,
+		nxDomainStormThreshold: nxDomainStormThreshold,
 	}
 
 	ticker := time.NewTicker(statsKeeper.expirationPeriod)
@@ -118,7 +133,7 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 		}
 
 		if _, ok := d.state[sk]; !ok {
-			d.state[sk] = stateValue{question: info.question, ts: microSecs(ts), qtype: info.queryType}
+			d.state[sk] = stateValue{question: info.question, ts: microSecs(ts), qtype: info.queryType, clientSubnet: info.clientSubnet}
 		}
 		return
 	}
@@ -169,6 +184,18 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 		} else if info.pktType == failedResponse {
 			byqtype.FailureLatencySum += latency
 		}
+		if start.clientSubnet {
+			byqtype.ClientSubnetQueries++
+		}
+		if info.hasExtendedError {
+			if byqtype.CountByExtendedError == nil {
+				byqtype.CountByExtendedError = make(map[uint16]uint32)
+			}
+			byqtype.CountByExtendedError[info.extendedError]++
+		}
+		if d.nxDomainStormThreshold > 0 && byqtype.CountByRcode[rcodeNXDomain] >= d.nxDomainStormThreshold {
+			byqtype.NXDomainStorm = true
+		}
 	}
 	stats[start.qtype] = byqtype
 	allStats[start.question] = stats
@@ -234,6 +261,13 @@ func (d *dnsStatKeeper) Snapshot() StatsByKeyByNameByType {
 					rcodeCopy[rcode] = count
 				}
 				statsCopy.CountByRcode = rcodeCopy
+				if statsCopy.CountByExtendedError != nil {
+					extendedErrorCopy := make(map[uint16]uint32, len(statsCopy.CountByExtendedError))
+					for code, count := range statsCopy.CountByExtendedError {
+						extendedErrorCopy[code] = count
+					}
+					statsCopy.CountByExtendedError = extendedErrorCopy
+				}
 				snapshot[key][domain][qtype] = statsCopy
 			}
 		}
@@ -42,7 +42,7 @@ func testLatency(
 	expectedTimeouts uint32,
 ) {
 	var d = ToHostname("abc.com")
-	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000)
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000, 0)
 	key := getSampleDNSKey()
 	qPkt := dnsPacketInfo{transactionID: 1, pktType: query, key: key, question: d, queryType: TypeA}
 	then := time.Now()
@@ -79,7 +79,7 @@ func TestTimeout(t *testing.T) {
 }
 
 func TestExpiredStateRemoval(t *testing.T) {
-	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000)
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000, 0)
 	key := getSampleDNSKey()
 	var d = ToHostname("abc.com")
 	qPkt1 := dnsPacketInfo{transactionID: 1, pktType: query, key: key, question: d, queryType: TypeA}
@@ -123,7 +123,7 @@ func BenchmarkStats(b *testing.B) {
 			b.ResetTimer()
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				sk := newDNSStatkeeper(1000*time.Second, 10000)
+				sk := newDNSStatkeeper(1000*time.Second, 10000, 0)
 				for j := 0; j < numPackets; j++ {
 					sk.ProcessPacketInfo(packets[j], ts)
 				}
@@ -131,3 +131,37 @@ func BenchmarkStats(b *testing.B) {
 		})
 	}
 }
+
+func TestNXDomainStorm(t *testing.T) {
+	d := ToHostname("abc.com")
+	sk := newDNSStatkeeper(1000*time.Second, 10000, 3)
+	key := getSampleDNSKey()
+	now := time.Now()
+
+	for i := uint16(0); i < 3; i++ {
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: i, pktType: query, key: key, question: d, queryType: TypeA}, now)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: i, pktType: failedResponse, key: key, rCode: rcodeNXDomain}, now)
+		assert.Equal(t, i == 2, sk.Snapshot()[key][d][TypeA].NXDomainStorm)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Equal(t, uint32(3), stats[key][d][TypeA].CountByRcode[rcodeNXDomain])
+}
+
+func TestEDNSStats(t *testing.T) {
+	d := ToHostname("abc.com")
+	sk := newDNSStatkeeper(1000*time.Second, 10000, 0)
+	key := getSampleDNSKey()
+	now := time.Now()
+
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, pktType: query, key: key, question: d, queryType: TypeA, clientSubnet: true}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, pktType: failedResponse, key: key, rCode: 2, extendedError: 22, hasExtendedError: true}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 2, pktType: query, key: key, question: d, queryType: TypeA}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 2, pktType: successfulResponse, key: key}, now)
+
+	stats := sk.GetAndResetAllStats()[key][d][TypeA]
+	assert.Equal(t, uint32(1), stats.ClientSubnetQueries)
+	assert.Equal(t, map[uint16]uint32{22: 1}, stats.CountByExtendedError)
+	assert.Equal(t, map[uint32]uint32{0: 1, 2: 1}, stats.CountByRcode)
+	assert.False(t, stats.NXDomainStorm)
+}
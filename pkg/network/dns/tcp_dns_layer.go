@@ -3,55 +3,144 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2016-present Datadog, Inc.
 
-//go:build windows || linux_bpf
+//go:build (windows && npm) || linux_bpf
 
 package dns
 
 import (
 	"encoding/binary"
+	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// dnsHeaderSize is the size of the header of a DNS message, messages
+	// announcing a smaller length are not DNS
+	dnsHeaderSize = 12
+	// maxTCPStreams limits the number of DNS over TCP streams holding a
+	// partial message
+	maxTCPStreams = 1024
+	// tcpStreamTimeout is how long a partial message is buffered without
+	// receiving the next segment of its stream
+	tcpStreamTimeout = 30 * time.Second
 )
 
 var _ gopacket.DecodingLayer = &tcpWithDNSSupport{}
 
-// source: https://github.com/weaveworks/scope/blob/master/probe/endpoint/dns_snooper.go
+// tcpWithDNSSupport stops the decoding at the TCP layer: a DNS message may
+// span several segments and a segment may hold several messages, so the DNS
+// messages are extracted from the payload by tcpDNSStreams.
 // Gopacket doesn't provide direct support for DNS over TCP, see https://github.com/google/gopacket/issues/236
 type tcpWithDNSSupport struct {
 	layers.TCP
 }
 
-// A DNS payload in a TCP segment is preceded by extra two bytes that
-// contain the size of the DNS payload. So if the size of the TCP payload
-// minus first two bytes equals the DNS payload size, we can safely say
-// that the whole of the DNS payload is contained in that single TCP segment.
-func (m *tcpWithDNSSupport) hasSelfContainedDNSPayload() bool {
-	payload := m.TCP.LayerPayload()
-	if len(payload) < 2 {
-		return false
-	}
+func (m *tcpWithDNSSupport) NextLayerType() gopacket.LayerType {
+	return gopacket.LayerTypeZero
+}
+
+func (m *tcpWithDNSSupport) LayerPayload() []byte {
+	return nil
+}
 
-	// Assume it's a self-contained DNS message if the Length field
-	// matches the length of the TCP segment
-	dnsLengthField := binary.BigEndian.Uint16(payload)
-	return int(dnsLengthField) == len(payload)-2
+type tcpStreamKey struct {
+	src, dst     util.Address
+	sport, dport uint16
 }
 
-func (m *tcpWithDNSSupport) NextLayerType() gopacket.LayerType {
-	// TODO: deal with TCP fragmentation and out-of-order segments
-	if m.hasSelfContainedDNSPayload() {
-		return layers.LayerTypeDNS
+type tcpStream struct {
+	buf      []byte
+	lastSeen time.Time
+}
+
+// tcpDNSStreams splits the payload of DNS over TCP streams in DNS messages,
+// which are preceded by a two bytes length field. The messages which aren't
+// complete are buffered until the next segment of the stream, or until the
+// stream expires.
+type tcpDNSStreams struct {
+	streams map[tcpStreamKey]*tcpStream
+	// messages is reused between calls to feed
+	messages [][]byte
+
+	now        func() time.Time
+	lastExpiry time.Time
+}
+
+func newTCPDNSStreams() *tcpDNSStreams {
+	return &tcpDNSStreams{
+		streams: make(map[tcpStreamKey]*tcpStream),
+		now:     time.Now,
 	}
-	return m.TCP.NextLayerType()
 }
 
-func (m *tcpWithDNSSupport) LayerPayload() []byte {
-	payload := m.TCP.LayerPayload()
-	if len(payload) > 1 {
-		// Omit the DNS length field, only included
-		// in TCP, in order to reuse the DNS UDP parser
-		payload = payload[2:]
+// feed appends the payload of a segment to its stream and returns the DNS
+// messages it completes. The messages are only valid until the next call.
+func (s *tcpDNSStreams) feed(key tcpStreamKey, payload []byte, closed bool) [][]byte {
+	s.messages = s.messages[:0]
+	now := s.now()
+	s.expire(now)
+
+	var buf []byte
+	stream, buffered := s.streams[key]
+	if buffered {
+		buf = append(stream.buf, payload...)
+	} else {
+		buf = payload
+	}
+
+	for len(buf) >= 2 {
+		size := int(binary.BigEndian.Uint16(buf))
+		if size < dnsHeaderSize {
+			// either not DNS, or the beginning of the stream was missed
+			snooperTelemetry.tcpStreamErrors.Inc()
+			buf = nil
+			break
+		}
+		if len(buf) < 2+size {
+			break
+		}
+		s.messages = append(s.messages, buf[2:2+size])
+		buf = buf[2+size:]
+	}
+
+	if closed || len(buf) == 0 {
+		delete(s.streams, key)
+		return s.messages
+	}
+
+	if !buffered {
+		if len(s.streams) >= maxTCPStreams {
+			snooperTelemetry.tcpStreamsDropped.Inc()
+			return s.messages
+		}
+		// the payload is reused for the next packet, so the rest of the
+		// stream is copied unless it was already buffered
+		stream = &tcpStream{buf: append([]byte(nil), buf...)}
+		s.streams[key] = stream
+	} else {
+		stream.buf = buf
+	}
+	stream.lastSeen = now
+	return s.messages
+}
+
+// expire drops the partial messages of the streams which didn't receive a
+// segment for tcpStreamTimeout. The streams are scanned at most once per
+// timeout period.
+func (s *tcpDNSStreams) expire(now time.Time) {
+	if now.Sub(s.lastExpiry) < tcpStreamTimeout {
+		return
+	}
+	s.lastExpiry = now
+
+	for key, stream := range s.streams {
+		if now.Sub(stream.lastSeen) >= tcpStreamTimeout {
+			delete(s.streams, key)
+			snooperTelemetry.tcpStreamsExpired.Inc()
+		}
 	}
-	return payload
 }
@@ -92,4 +92,13 @@ type Stats struct {
 	SuccessLatencySum uint64
 	FailureLatencySum uint64
 	CountByRcode      map[uint32]uint32
+	// CountByExtendedError counts the responses by extended DNS error info
+	// code, see RFC 8914
+	CountByExtendedError map[uint16]uint32
+	// ClientSubnetQueries is the number of answered queries which carried an
+	// EDNS client subnet option
+	ClientSubnetQueries uint32
+	// NXDomainStorm is set when the domain got more NXDOMAIN responses than
+	// the configured threshold within the interval
+	NXDomainStorm bool
 }
@@ -128,6 +128,9 @@ typedef enum {
 
 typedef enum {
     DISPATCHER_KAFKA_PROG = 0,
+    DISPATCHER_POSTGRES_PROG,
+    DISPATCHER_MYSQL_PROG,
+    DISPATCHER_REDIS_PROG,
     // Add before this value.
     DISPATCHER_PROG_MAX,
 } dispatcher_prog_t;
@@ -142,6 +145,9 @@ typedef enum {
     PROG_HTTP2_EOS_PARSER,
     PROG_KAFKA,
     PROG_GRPC,
+    PROG_POSTGRES,
+    PROG_MYSQL,
+    PROG_REDIS,
     // Add before this value.
     PROG_MAX,
 } protocol_prog_t;
@@ -15,6 +15,12 @@
 #include "protocols/http2/usm-events.h"
 #include "protocols/kafka/kafka-classification.h"
 #include "protocols/kafka/usm-events.h"
+#include "protocols/mysql/helpers.h"
+#include "protocols/mysql/usm-events.h"
+#include "protocols/postgres/helpers.h"
+#include "protocols/postgres/usm-events.h"
+#include "protocols/redis/helpers.h"
+#include "protocols/redis/usm-events.h"
 
 __maybe_unused static __always_inline protocol_prog_t protocol_to_program(protocol_t proto) {
     switch(proto) {
@@ -24,6 +30,12 @@ __maybe_unused static __always_inline protocol_prog_t protocol_to_program(protoc
         return PROG_HTTP2_HANDLE_FIRST_FRAME;
     case PROTOCOL_KAFKA:
         return PROG_KAFKA;
+    case PROTOCOL_POSTGRES:
+        return PROG_POSTGRES;
+    case PROTOCOL_MYSQL:
+        return PROG_MYSQL;
+    case PROTOCOL_REDIS:
+        return PROG_REDIS;
     default:
         if (proto != PROTOCOL_UNKNOWN) {
             log_debug("protocol doesn't have a matching program: %d", proto);
@@ -60,6 +72,21 @@ static __always_inline bool has_sequence_seen_before(conn_tuple_t *tup, skb_info
     return false;
 }
 
+// Tail calls the classification programs following the given one, in the order of the dispatcher_prog_t enum. The
+// programs of the protocols whose monitoring is disabled aren't registered, and a tail call to an empty slot falls
+// through to the next one.
+static __always_inline void dispatch_next_classification(struct __sk_buff *skb, dispatcher_prog_t prog) {
+    if (prog < DISPATCHER_POSTGRES_PROG) {
+        bpf_tail_call_compat(skb, &dispatcher_classification_progs, DISPATCHER_POSTGRES_PROG);
+    }
+    if (prog < DISPATCHER_MYSQL_PROG) {
+        bpf_tail_call_compat(skb, &dispatcher_classification_progs, DISPATCHER_MYSQL_PROG);
+    }
+    if (prog < DISPATCHER_REDIS_PROG) {
+        bpf_tail_call_compat(skb, &dispatcher_classification_progs, DISPATCHER_REDIS_PROG);
+    }
+}
+
 // Determines the protocols of the given buffer. If we already classified the payload (a.k.a protocol out param
 // has a known protocol), then we do nothing.
 static __always_inline void classify_protocol_for_dispatcher(protocol_t *protocol, conn_tuple_t *tup, const char *buf, __u32 size) {
@@ -142,6 +169,9 @@ static __always_inline void protocol_dispatcher_entrypoint(struct __sk_buff *skb
         if (is_kafka_monitoring_enabled() && cur_fragment_protocol == PROTOCOL_UNKNOWN) {
             bpf_tail_call_compat(skb, &dispatcher_classification_progs, DISPATCHER_KAFKA_PROG);
         }
+        if (cur_fragment_protocol == PROTOCOL_UNKNOWN) {
+            dispatch_next_classification(skb, DISPATCHER_KAFKA_PROG);
+        }
         log_debug("[protocol_dispatcher_entrypoint]: %p Classifying protocol as: %d", skb, cur_fragment_protocol);
         // If there has been a change in the classification, save the new protocol.
         if (cur_fragment_protocol != PROTOCOL_UNKNOWN) {
@@ -200,10 +230,68 @@ static __always_inline void dispatch_kafka(struct __sk_buff *skb) {
         // dispatch if possible
         log_debug("dispatching to protocol number: %d", cur_fragment_protocol);
         bpf_tail_call_compat(skb, &protocols_progs, protocol_to_program(cur_fragment_protocol));
+    } else {
+        dispatch_next_classification(skb, DISPATCHER_KAFKA_PROG);
     }
     return;
 }
 
+// Classifies the database protocols decoded in userspace. Each protocol has its own classification program, given
+// by `prog`, so that the programs of the protocols whose monitoring is disabled are left out.
+static __always_inline void dispatch_database(struct __sk_buff *skb, dispatcher_prog_t prog) {
+    skb_info_t skb_info = {0};
+    conn_tuple_t skb_tup = {0};
+    // Exporting the conn tuple from the skb, alongside couple of relevant fields from the skb.
+    if (!read_conn_tuple_skb(skb, &skb_info, &skb_tup)) {
+        return;
+    }
+
+    char request_fragment[CLASSIFICATION_MAX_BUFFER];
+    bpf_memset(request_fragment, 0, sizeof(request_fragment));
+    read_into_buffer_for_classification((char *)request_fragment, skb, skb_info.data_off);
+    const size_t payload_length = skb_info.data_end - skb_info.data_off;
+    const size_t final_fragment_size = payload_length < CLASSIFICATION_MAX_BUFFER ? payload_length : CLASSIFICATION_MAX_BUFFER;
+    protocol_t cur_fragment_protocol = PROTOCOL_UNKNOWN;
+    switch (prog) {
+    case DISPATCHER_POSTGRES_PROG:
+        if (is_postgres(request_fragment, final_fragment_size)) {
+            cur_fragment_protocol = PROTOCOL_POSTGRES;
+        }
+        break;
+    case DISPATCHER_MYSQL_PROG:
+        if (is_mysql(&skb_tup, request_fragment, final_fragment_size)) {
+            cur_fragment_protocol = PROTOCOL_MYSQL;
+        }
+        break;
+    case DISPATCHER_REDIS_PROG:
+        if (is_redis(request_fragment, final_fragment_size)) {
+            cur_fragment_protocol = PROTOCOL_REDIS;
+        }
+        break;
+    default:
+        break;
+    }
+
+    if (cur_fragment_protocol == PROTOCOL_UNKNOWN) {
+        dispatch_next_classification(skb, prog);
+        return;
+    }
+    update_protocol_stack(&skb_tup, cur_fragment_protocol);
+
+    const u32 zero = 0;
+    dispatcher_arguments_t *args = bpf_map_lookup_elem(&dispatcher_arguments, &zero);
+    if (args == NULL) {
+        log_debug("dispatcher failed to save arguments for tail call");
+        return;
+    }
+    bpf_memset(args, 0, sizeof(dispatcher_arguments_t));
+    bpf_memcpy(&args->tup, &skb_tup, sizeof(conn_tuple_t));
+    bpf_memcpy(&args->skb_info, &skb_info, sizeof(skb_info_t));
+
+    log_debug("dispatching to protocol number: %d", cur_fragment_protocol);
+    bpf_tail_call_compat(skb, &protocols_progs, protocol_to_program(cur_fragment_protocol));
+}
+
 static __always_inline bool fetch_dispatching_arguments(conn_tuple_t *tup, skb_info_t *skb_info) {
     const __u32 zero = 0;
     dispatcher_arguments_t *args = bpf_map_lookup_elem(&dispatcher_arguments, &zero);
@@ -0,0 +1,9 @@
+#ifndef __MYSQL_USM_EVENTS_H
+#define __MYSQL_USM_EVENTS_H
+
+#include "protocols/events.h"
+#include "protocols/segments/types.h"
+
+USM_EVENTS_INIT(mysql, segment_t, SEGMENT_BATCH_SIZE);
+
+#endif
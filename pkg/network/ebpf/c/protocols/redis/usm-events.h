@@ -0,0 +1,9 @@
+#ifndef __REDIS_USM_EVENTS_H
+#define __REDIS_USM_EVENTS_H
+
+#include "protocols/events.h"
+#include "protocols/segments/types.h"
+
+USM_EVENTS_INIT(redis, segment_t, SEGMENT_BATCH_SIZE);
+
+#endif
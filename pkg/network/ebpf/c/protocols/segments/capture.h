@@ -0,0 +1,62 @@
+#ifndef __SEGMENTS_CAPTURE_H
+#define __SEGMENTS_CAPTURE_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "port_range.h"
+
+#include "protocols/classification/dispatcher-helpers.h"
+#include "protocols/read_into_buffer.h"
+#include "protocols/segments/types.h"
+
+READ_INTO_BUFFER(segment_head, SEGMENT_HEAD_SIZE, BLK_SIZE)
+READ_INTO_BUFFER(segment_tail, SEGMENT_TAIL_SIZE, BLK_SIZE)
+
+// Fills the segment with the payload of the packet dispatched to the current
+// program. Returns false if the dispatching arguments can't be fetched.
+static __always_inline bool read_segment(struct __sk_buff *skb, segment_t *segment) {
+    skb_info_t skb_info = {0};
+    if (!fetch_dispatching_arguments(&segment->tup, &skb_info)) {
+        return false;
+    }
+
+    // normalize_tuple flips the tuples of the packets sent by the server
+    segment->flags = normalize_tuple(&segment->tup) ? 0 : SEGMENT_FROM_CLIENT;
+    if (is_tcp_termination(&skb_info)) {
+        segment->flags |= SEGMENT_CLOSE;
+    }
+    segment->timestamp = bpf_ktime_get_ns();
+
+    const u32 len = skb_info.data_end > skb_info.data_off ? skb_info.data_end - skb_info.data_off : 0;
+    segment->len = len;
+    segment->head_len = len < SEGMENT_HEAD_SIZE ? len : SEGMENT_HEAD_SIZE;
+    segment->tail_len = 0;
+    read_into_buffer_segment_head(segment->head, skb, skb_info.data_off);
+    if (len > SEGMENT_HEAD_SIZE) {
+        const u32 tail_len = len - SEGMENT_HEAD_SIZE < SEGMENT_TAIL_SIZE ? len - SEGMENT_HEAD_SIZE : SEGMENT_TAIL_SIZE;
+        segment->tail_len = tail_len;
+        read_into_buffer_segment_tail(segment->tail, skb, skb_info.data_end - tail_len);
+    }
+    return true;
+}
+
+// SEGMENTS_CAPTURE_INIT defines the socket__<name>_filter program, which sends
+// the segments of the connections classified as the protocol to userspace
+// through the <name> USM events.
+#define SEGMENTS_CAPTURE_INIT(name)                                                         \
+    BPF_PERCPU_ARRAY_MAP(name##_segment_heap, segment_t, 1)                                 \
+                                                                                            \
+    SEC("socket/" #name "_filter")                                                          \
+    int socket__##name##_filter(struct __sk_buff *skb) {                                    \
+        const u32 zero = 0;                                                                 \
+        segment_t *segment = bpf_map_lookup_elem(&name##_segment_heap, &zero);              \
+        if (segment == NULL) {                                                              \
+            return 0;                                                                       \
+        }                                                                                   \
+        if (read_segment(skb, segment)) {                                                   \
+            name##_batch_enqueue(segment);                                                  \
+        }                                                                                   \
+        return 0;                                                                           \
+    }
+
+#endif
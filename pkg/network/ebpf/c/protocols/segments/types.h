@@ -0,0 +1,36 @@
+#ifndef __SEGMENTS_TYPES_H
+#define __SEGMENTS_TYPES_H
+
+#include "conn_tuple.h"
+
+// The number of bytes captured at the start and at the end of a segment. The
+// bytes in between are skipped, which is enough for the userspace decoders to
+// follow the messages of a stream as long as the messages they read are
+// smaller than the head.
+#define SEGMENT_HEAD_SIZE 320
+#define SEGMENT_TAIL_SIZE 128
+
+// The number of segments fitting in a batch of USM events.
+#define SEGMENT_BATCH_SIZE 8
+
+// The segment was sent by the client of the connection.
+#define SEGMENT_FROM_CLIENT (1 << 0)
+// The segment closes the connection.
+#define SEGMENT_CLOSE (1 << 1)
+
+// segment_t is the payload of a TCP segment of a connection whose protocol is
+// decoded in userspace. The tuple is normalized, `len` is the length of the
+// payload, of which `head_len` bytes are in `head` and the last `tail_len`
+// bytes are in `tail`.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 timestamp;
+    __u32 len;
+    __u16 head_len;
+    __u8 tail_len;
+    __u8 flags;
+    char head[SEGMENT_HEAD_SIZE];
+    char tail[SEGMENT_TAIL_SIZE];
+} segment_t;
+
+#endif
@@ -18,6 +18,7 @@
 #include "protocols/http2/decoding.h"
 #include "protocols/http2/decoding-tls.h"
 #include "protocols/kafka/kafka-parsing.h"
+#include "protocols/segments/capture.h"
 #include "protocols/sockfd-probes.h"
 #include "protocols/tls/java/erpc_dispatcher.h"
 #include "protocols/tls/java/erpc_handlers.h"
@@ -44,6 +45,29 @@ int socket__protocol_dispatcher_kafka(struct __sk_buff *skb) {
     return 0;
 }
 
+SEC("socket/protocol_dispatcher_postgres")
+int socket__protocol_dispatcher_postgres(struct __sk_buff *skb) {
+    dispatch_database(skb, DISPATCHER_POSTGRES_PROG);
+    return 0;
+}
+
+SEC("socket/protocol_dispatcher_mysql")
+int socket__protocol_dispatcher_mysql(struct __sk_buff *skb) {
+    dispatch_database(skb, DISPATCHER_MYSQL_PROG);
+    return 0;
+}
+
+SEC("socket/protocol_dispatcher_redis")
+int socket__protocol_dispatcher_redis(struct __sk_buff *skb) {
+    dispatch_database(skb, DISPATCHER_REDIS_PROG);
+    return 0;
+}
+
+// The Postgres, MySQL and Redis segments are decoded in userspace
+SEGMENTS_CAPTURE_INIT(postgres)
+SEGMENTS_CAPTURE_INIT(mysql)
+SEGMENTS_CAPTURE_INIT(redis)
+
 SEC("kprobe/tcp_sendmsg")
 int BPF_KPROBE(kprobe__tcp_sendmsg, struct sock *sk) {
     log_debug("kprobe/tcp_sendmsg: sk=%llx", sk);
@@ -62,6 +86,9 @@ int tracepoint__net__netif_receive_skb(struct pt_regs* ctx) {
     http2_batch_flush(ctx);
     terminated_http2_batch_flush(ctx);
     kafka_batch_flush(ctx);
+    postgres_batch_flush(ctx);
+    mysql_batch_flush(ctx);
+    redis_batch_flush(ctx);
     return 0;
 }
 
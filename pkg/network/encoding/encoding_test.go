@@ -346,6 +346,11 @@ func testSerialization(t *testing.T, aggregateByStatusCode bool) {
 			result.Tags = nil
 		}
 		result.PrebuiltEBPFAssets = nil
+		result.ResolvConfs = nil
+		// fixup: json marshaler encode nil map as empty
+		for _, c := range result.Conns {
+			c.TcpFailuresByErrCode = nil
+		}
 		assertConnsEqual(t, out, result)
 	})
 
@@ -370,6 +375,11 @@ func testSerialization(t *testing.T, aggregateByStatusCode bool) {
 			result.Tags = nil
 		}
 		result.PrebuiltEBPFAssets = nil
+		result.ResolvConfs = nil
+		// fixup: json marshaler encode nil map as empty
+		for _, c := range result.Conns {
+			c.TcpFailuresByErrCode = nil
+		}
 		assertConnsEqual(t, out, result)
 	})
 
@@ -401,6 +411,11 @@ func testSerialization(t *testing.T, aggregateByStatusCode bool) {
 			result.Tags = nil
 		}
 		result.PrebuiltEBPFAssets = nil
+		result.ResolvConfs = nil
+		// fixup: json marshaler encode nil map as empty
+		for _, c := range result.Conns {
+			c.TcpFailuresByErrCode = nil
+		}
 		assertConnsEqual(t, out, result)
 	})
 
@@ -433,6 +448,11 @@ func testSerialization(t *testing.T, aggregateByStatusCode bool) {
 			result.Tags = nil
 		}
 		result.PrebuiltEBPFAssets = nil
+		result.ResolvConfs = nil
+		// fixup: json marshaler encode nil map as empty
+		for _, c := range result.Conns {
+			c.TcpFailuresByErrCode = nil
+		}
 		assertConnsEqual(t, out, result)
 	})
 
@@ -11,12 +11,19 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 )
 
+// dnsOverTLSPort is the port of DNS over TLS, see RFC 7858
+const dnsOverTLSPort = 853
+
 type dnsFormatter struct {
 	conns     *network.Connections
 	ipc       ipCache
 	domainSet map[string]int
+	// telemetry sums up the DNS details which don't fit the DNS stats of
+	// the connections
+	telemetry map[network.ConnTelemetryType]int64
 
 	// Configuration flags
 	queryTypeEnabled  bool
@@ -28,11 +35,13 @@ func newDNSFormatter(conns *network.Connections, ipc ipCache) *dnsFormatter {
 		conns:             conns,
 		ipc:               ipc,
 		domainSet:         make(map[string]int),
+		telemetry:         make(map[network.ConnTelemetryType]int64),
 		queryTypeEnabled:  config.SystemProbe.GetBool("network_config.enable_dns_by_querytype"),
 		dnsDomainsEnabled: config.SystemProbe.GetBool("system_probe_config.collect_dns_domains"),
 	}
 }
 
+// FormatConnectionDNS writes the DNS stats of a connection
 func (f *dnsFormatter) FormatConnectionDNS(nc network.ConnectionStats, builder *model.ConnectionBuilder) {
 	var (
 		dnsCountByRcode        map[uint32]uint32
@@ -80,6 +89,48 @@ func (f *dnsFormatter) FormatConnectionDNS(nc network.ConnectionStats, builder *
 		//// downconvert to simply by domain
 		formatDNSStatsByDomain(builder, nc.DNSStats, f.domainSet)
 	}
+
+	f.addTelemetry(nc)
+}
+
+// addTelemetry sums up the EDNS options and NXDOMAIN storms seen in the DNS
+// stats of a connection
+func (f *dnsFormatter) addTelemetry(nc network.ConnectionStats) {
+	for _, byType := range nc.DNSStats {
+		for _, typeStats := range byType {
+			if typeStats.NXDomainStorm {
+				f.telemetry[network.DNSNXDomainStorms]++
+			}
+			f.telemetry[network.DNSClientSubnetQueries] += int64(typeStats.ClientSubnetQueries)
+			for _, count := range typeStats.CountByExtendedError {
+				f.telemetry[network.DNSExtendedErrors] += int64(count)
+			}
+		}
+	}
+}
+
+// FormatTelemetry writes the DNS telemetry summed up over the formatted
+// connections in the connection telemetry of the payload
+func (f *dnsFormatter) FormatTelemetry(builder *model.ConnectionsBuilder) {
+	for k, v := range f.telemetry {
+		if v == 0 {
+			continue
+		}
+		builder.AddConnTelemetryMap(func(w *model.Connections_ConnTelemetryMapEntryBuilder) {
+			w.SetKey(string(k))
+			w.SetValue(v)
+		})
+	}
+}
+
+// GetProtocolStack returns the protocol stack of a connection, flagging the
+// DNS over TLS connections as encrypted
+func (f *dnsFormatter) GetProtocolStack(nc network.ConnectionStats) protocols.Stack {
+	stack := nc.ProtocolStack
+	if stack.Encryption == protocols.Unknown && nc.Type == network.TCP && (nc.DPort == dnsOverTLSPort || nc.SPort == dnsOverTLSPort) {
+		stack.Encryption = protocols.TLS
+	}
+	return stack
 }
 
 // FormatDNS writes the DNS field in the Connections object
@@ -161,7 +212,12 @@ func formatDNSStatsByDomain(builder *model.ConnectionBuilder, stats map[dns.Host
 
 			} else {
 				var ms model.DNSStats
-				ms.DnsCountByRcode = stat.CountByRcode
+				// the counts are copied as they are summed up across the
+				// query types
+				ms.DnsCountByRcode = make(map[uint32]uint32, len(stat.CountByRcode))
+				for rcode, count := range stat.CountByRcode {
+					ms.DnsCountByRcode[rcode] = count
+				}
 				ms.DnsFailureLatencySum = stat.FailureLatencySum
 				ms.DnsSuccessLatencySum = stat.SuccessLatencySum
 				ms.DnsTimeouts = stat.Timeouts
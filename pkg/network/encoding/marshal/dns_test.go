@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 
 	model "github.com/DataDog/agent-payload/v5/process"
@@ -112,6 +113,55 @@ func TestFormatConnectionDNS(t *testing.T) {
 	})
 }
 
+func TestFormatConnectionDNSTelemetry(t *testing.T) {
+	config.SystemProbe.SetWithoutSource("system_probe_config.collect_dns_domains", true)
+	config.SystemProbe.SetWithoutSource("network_config.enable_dns_by_querytype", false)
+
+	conn := network.ConnectionStats{
+		Source: util.AddressFromString("10.1.1.1"),
+		Dest:   util.AddressFromString("8.8.8.8"),
+		SPort:  1000,
+		DPort:  853,
+		Type:   network.TCP,
+		Family: network.AFINET,
+		DNSStats: map[dns.Hostname]map[dns.QueryType]dns.Stats{
+			dns.ToHostname("foo.com"): {
+				dns.TypeA: {
+					CountByRcode:         map[uint32]uint32{3: 100},
+					CountByExtendedError: map[uint16]uint32{22: 1, 23: 2},
+					NXDomainStorm:        true,
+				},
+				dns.TypeAAAA: {
+					CountByRcode:        map[uint32]uint32{0: 1},
+					ClientSubnetQueries: 1,
+				},
+			},
+		},
+	}
+
+	formatter := newDNSFormatter(&network.Connections{}, make(ipCache))
+	builder := model.NewConnectionBuilder(NewProtoTestStreamer[*model.Connection]())
+	formatter.FormatConnectionDNS(conn, builder)
+	assert.Equal(t, protocols.TLS, formatter.GetProtocolStack(conn).Encryption)
+
+	// the counts by rcode of the stats are left untouched by the
+	// aggregation by domain
+	assert.Equal(t, map[uint32]uint32{3: 100}, conn.DNSStats[dns.ToHostname("foo.com")][dns.TypeA].CountByRcode)
+
+	conn.DPort = 53
+	conn.DNSStats = nil
+	formatter.FormatConnectionDNS(conn, builder)
+	assert.Equal(t, protocols.Unknown, formatter.GetProtocolStack(conn).Encryption)
+
+	streamer := NewProtoTestStreamer[*model.Connections]()
+	formatter.FormatTelemetry(model.NewConnectionsBuilder(streamer))
+	assert.Equal(t, map[string]int64{
+		string(network.DNSNXDomainStorms):      1,
+		string(network.DNSClientSubnetQueries): 1,
+		string(network.DNSExtendedErrors):      3,
+	}, streamer.Unwrap(t, &model.Connections{}).ConnTelemetryMap)
+}
+
 var _ io.Writer = &ProtoTestStreamer[*model.Connection]{}
 
 type ProtoTestStreamer[T proto.Message] struct {
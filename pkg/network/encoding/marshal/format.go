@@ -52,7 +52,7 @@ func mergeDynamicTags(dynamicTags ...map[string]struct{}) (out map[string]struct
 }
 
 // FormatConnection converts a ConnectionStats into an model.Connection
-func FormatConnection(builder *model.ConnectionBuilder, conn network.ConnectionStats, routes map[string]RouteIdx, httpEncoder *httpEncoder, http2Encoder *http2Encoder, kafkaEncoder *kafkaEncoder, dnsFormatter *dnsFormatter, ipc ipCache, tagsSet *network.TagsSet) {
+func FormatConnection(builder *model.ConnectionBuilder, conn network.ConnectionStats, routes map[string]RouteIdx, httpEncoder *httpEncoder, http2Encoder *http2Encoder, kafkaEncoder *kafkaEncoder, postgresEncoder *postgresEncoder, mysqlEncoder *mysqlEncoder, redisEncoder *redisEncoder, grpcEncoder *grpcEncoder, dnsFormatter *dnsFormatter, ipc ipCache, tagsSet *network.TagsSet) {
 
 	builder.SetPid(int32(conn.Pid))
 
@@ -99,6 +99,9 @@ func FormatConnection(builder *model.ConnectionBuilder, conn network.ConnectionS
 	builder.SetIntraHost(conn.IntraHost)
 	builder.SetLastTcpEstablished(conn.Last.TCPEstablished)
 	builder.SetLastTcpClosed(conn.Last.TCPClosed)
+	conn.ProtocolStack = mysqlEncoder.GetProtocolStack(conn)
+	conn.ProtocolStack = grpcEncoder.GetProtocolStack(conn)
+	conn.ProtocolStack = dnsFormatter.GetProtocolStack(conn)
 	builder.SetProtocol(func(w *model.ProtocolStackBuilder) {
 		ps := FormatProtocolStack(conn.ProtocolStack, conn.StaticTags)
 		for _, p := range ps.Stack {
@@ -116,6 +119,9 @@ func FormatConnection(builder *model.ConnectionBuilder, conn network.ConnectionS
 	dynamicTags := mergeDynamicTags(httpDynamicTags, http2DynamicTags)
 
 	kafkaEncoder.WriteKafkaAggregations(conn, builder)
+	postgresEncoder.WritePostgresAggregations(conn, builder)
+	mysqlEncoder.WriteMySQLAggregations(conn, builder)
+	redisEncoder.WriteRedisAggregations(conn, builder)
 
 	conn.StaticTags |= staticTags
 	tags, tagChecksum := formatTags(conn, tagsSet, dynamicTags)
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// grpcEncoder reports the gRPC calls decoded by USM. The process payload has
+// no message for gRPC stats, so the connections carrying gRPC calls are
+// reported through their protocol stack.
+type grpcEncoder struct {
+	byConnection *USMConnectionIndex[grpc.Key, *grpc.RequestStats]
+}
+
+func newGRPCEncoder(grpcPayloads map[grpc.Key]*grpc.RequestStats) *grpcEncoder {
+	if len(grpcPayloads) == 0 {
+		return nil
+	}
+
+	return &grpcEncoder{
+		byConnection: GroupByConnection("grpc", grpcPayloads, func(key grpc.Key) types.ConnectionKey {
+			return key.ConnectionKey
+		}),
+	}
+}
+
+// GetProtocolStack returns the protocol stack of the connection, with gRPC
+// over HTTP/2 if calls were decoded on a connection the classifier didn't
+// recognize as such.
+func (e *grpcEncoder) GetProtocolStack(c network.ConnectionStats) protocols.Stack {
+	stack := c.ProtocolStack
+	if e == nil || stack.API != protocols.Unknown {
+		return stack
+	}
+	if stack.Application != protocols.Unknown && stack.Application != protocols.HTTP2 {
+		return stack
+	}
+
+	connectionData := e.byConnection.Find(c)
+	if connectionData == nil || len(connectionData.Data) == 0 || connectionData.IsPIDCollision(c) {
+		return stack
+	}
+
+	stack.Application = protocols.HTTP2
+	stack.API = protocols.GRPC
+	return stack
+}
+
+func (e *grpcEncoder) Close() {
+	if e == nil {
+		return
+	}
+
+	e.byConnection.Close()
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func TestGRPCProtocolStack(t *testing.T) {
+	connections := []network.ConnectionStats{
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 1},
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 2},
+		{Source: localhost, SPort: clientPort + 1, Dest: localhost, DPort: serverPort, Pid: 1},
+	}
+
+	stats := grpc.NewRequestStats()
+	stats.AddRequest(grpc.OK, 1000)
+	encoder := newGRPCEncoder(map[grpc.Key]*grpc.RequestStats{
+		grpc.NewKey(types.NewConnectionKey(localhost, localhost, clientPort, serverPort), "helloworld.Greeter", "SayHello"): stats,
+	})
+	t.Cleanup(encoder.Close)
+
+	grpcStack := protocols.Stack{Application: protocols.HTTP2, API: protocols.GRPC}
+	assert.Equal(t, grpcStack, encoder.GetProtocolStack(connections[0]))
+	// the other connection sharing the same addresses but a different PID
+	// doesn't get the stats
+	assert.Equal(t, protocols.Stack{}, encoder.GetProtocolStack(connections[1]))
+	assert.Equal(t, protocols.Stack{}, encoder.GetProtocolStack(connections[2]))
+
+	// the protocol found by the classifier is kept
+	classified := connections[0]
+	classified.ProtocolStack = protocols.Stack{Application: protocols.HTTP}
+	assert.Equal(t, classified.ProtocolStack, encoder.GetProtocolStack(classified))
+
+	var nilEncoder *grpcEncoder
+	assert.Equal(t, protocols.Stack{}, nilEncoder.GetProtocolStack(connections[0]))
+}
@@ -21,13 +21,17 @@ var (
 
 // ConnectionsModeler contains all the necessary structs for modeling a connection.
 type ConnectionsModeler struct {
-	httpEncoder  *httpEncoder
-	http2Encoder *http2Encoder
-	kafkaEncoder *kafkaEncoder
-	dnsFormatter *dnsFormatter
-	ipc          ipCache
-	routeIndex   map[string]RouteIdx
-	tagsSet      *network.TagsSet
+	httpEncoder     *httpEncoder
+	http2Encoder    *http2Encoder
+	kafkaEncoder    *kafkaEncoder
+	postgresEncoder *postgresEncoder
+	mysqlEncoder    *mysqlEncoder
+	redisEncoder    *redisEncoder
+	grpcEncoder     *grpcEncoder
+	dnsFormatter    *dnsFormatter
+	ipc             ipCache
+	routeIndex      map[string]RouteIdx
+	tagsSet         *network.TagsSet
 }
 
 // NewConnectionsModeler initializes the connection modeler with encoders, dns formatter for
@@ -38,13 +42,17 @@ type ConnectionsModeler struct {
 func NewConnectionsModeler(conns *network.Connections) *ConnectionsModeler {
 	ipc := make(ipCache, len(conns.Conns)/2)
 	return &ConnectionsModeler{
-		httpEncoder:  newHTTPEncoder(conns.HTTP),
-		http2Encoder: newHTTP2Encoder(conns.HTTP2),
-		kafkaEncoder: newKafkaEncoder(conns.Kafka),
-		ipc:          ipc,
-		dnsFormatter: newDNSFormatter(conns, ipc),
-		routeIndex:   make(map[string]RouteIdx),
-		tagsSet:      network.NewTagsSet(),
+		httpEncoder:     newHTTPEncoder(conns.HTTP),
+		http2Encoder:    newHTTP2Encoder(conns.HTTP2),
+		kafkaEncoder:    newKafkaEncoder(conns.Kafka),
+		postgresEncoder: newPostgresEncoder(conns.Postgres),
+		mysqlEncoder:    newMySQLEncoder(conns.MySQL),
+		redisEncoder:    newRedisEncoder(conns.Redis),
+		grpcEncoder:     newGRPCEncoder(conns.GRPC),
+		ipc:             ipc,
+		dnsFormatter:    newDNSFormatter(conns, ipc),
+		routeIndex:      make(map[string]RouteIdx),
+		tagsSet:         network.NewTagsSet(),
 	}
 }
 
@@ -53,6 +61,10 @@ func (c *ConnectionsModeler) Close() {
 	c.httpEncoder.Close()
 	c.http2Encoder.Close()
 	c.kafkaEncoder.Close()
+	c.postgresEncoder.Close()
+	c.mysqlEncoder.Close()
+	c.redisEncoder.Close()
+	c.grpcEncoder.Close()
 }
 
 func (c *ConnectionsModeler) modelConnections(builder *model.ConnectionsBuilder, conns *network.Connections) {
@@ -65,7 +77,7 @@ func (c *ConnectionsModeler) modelConnections(builder *model.ConnectionsBuilder,
 
 	for _, conn := range conns.Conns {
 		builder.AddConns(func(builder *model.ConnectionBuilder) {
-			FormatConnection(builder, conn, c.routeIndex, c.httpEncoder, c.http2Encoder, c.kafkaEncoder, c.dnsFormatter, c.ipc, c.tagsSet)
+			FormatConnection(builder, conn, c.routeIndex, c.httpEncoder, c.http2Encoder, c.kafkaEncoder, c.postgresEncoder, c.mysqlEncoder, c.redisEncoder, c.grpcEncoder, c.dnsFormatter, c.ipc, c.tagsSet)
 		})
 	}
 
@@ -98,6 +110,7 @@ func (c *ConnectionsModeler) modelConnections(builder *model.ConnectionsBuilder,
 	}
 
 	FormatConnectionTelemetry(builder, conns.ConnTelemetry)
+	c.dnsFormatter.FormatTelemetry(builder)
 	FormatCompilationTelemetry(builder, conns.CompilationTelemetryByAsset)
 	FormatCORETelemetry(builder, conns.CORETelemetryByAsset)
 	builder.SetKernelHeaderFetchResult(uint64(conns.KernelHeaderFetchResult))
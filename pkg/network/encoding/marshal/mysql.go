@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// The process payload has no message for the MySQL stats yet, so they are
+// written in the next member of the DatabaseStats oneof, after postgres and
+// redis, with the fields of PostgresStats followed by the error count:
+//
+//	message MySQLStats {
+//		string tableName = 1;
+//		uint64 operation = 2;
+//		bytes latencies = 3;
+//		double firstLatencySample = 4;
+//		uint32 count = 5;
+//		uint32 errorCount = 6;
+//	}
+const (
+	databaseAggregationsAggregationsField protowire.Number = 1
+	databaseStatsMySQLField               protowire.Number = 3
+
+	mysqlStatsTableNameField          protowire.Number = 1
+	mysqlStatsOperationField          protowire.Number = 2
+	mysqlStatsLatenciesField          protowire.Number = 3
+	mysqlStatsFirstLatencySampleField protowire.Number = 4
+	mysqlStatsCountField              protowire.Number = 5
+	mysqlStatsErrorCountField         protowire.Number = 6
+)
+
+// mysqlAggregationKey identifies the stats sent in the payload, which doesn't
+// carry the query signatures
+type mysqlAggregationKey struct {
+	operation mysql.Operation
+	tableName string
+}
+
+// mysqlEncoder reports the MySQL traffic decoded by USM, as database stats and
+// through the protocol stack of the connections
+type mysqlEncoder struct {
+	byConnection *USMConnectionIndex[mysql.Key, *mysql.RequestStat]
+	// the buffers of the messages being encoded
+	stats         []byte
+	databaseStats []byte
+	aggregation   []byte
+}
+
+func newMySQLEncoder(mysqlPayloads map[mysql.Key]*mysql.RequestStat) *mysqlEncoder {
+	if len(mysqlPayloads) == 0 {
+		return nil
+	}
+
+	return &mysqlEncoder{
+		byConnection: GroupByConnection("mysql", mysqlPayloads, func(key mysql.Key) types.ConnectionKey {
+			return key.ConnectionKey
+		}),
+	}
+}
+
+// GetProtocolStack returns the protocol stack of the connection, with MySQL as
+// application protocol if queries were decoded on a connection the classifier
+// didn't recognize.
+func (e *mysqlEncoder) GetProtocolStack(c network.ConnectionStats) protocols.Stack {
+	stack := c.ProtocolStack
+	if e == nil || stack.Application != protocols.Unknown {
+		return stack
+	}
+
+	connectionData := e.byConnection.Find(c)
+	if connectionData == nil || len(connectionData.Data) == 0 || connectionData.IsPIDCollision(c) {
+		return stack
+	}
+
+	stack.Application = protocols.MySQL
+	return stack
+}
+
+func (e *mysqlEncoder) WriteMySQLAggregations(c network.ConnectionStats, builder *model.ConnectionBuilder) {
+	if e == nil {
+		return
+	}
+
+	connectionData := e.byConnection.Find(c)
+	if connectionData == nil || len(connectionData.Data) == 0 || connectionData.IsPIDCollision(c) {
+		return
+	}
+
+	builder.SetDatabaseAggregations(func(b *bytes.Buffer) {
+		e.encodeData(connectionData, b)
+	})
+}
+
+func (e *mysqlEncoder) encodeData(connectionData *USMConnectionData[mysql.Key, *mysql.RequestStat], w io.Writer) {
+	// the stats of the queries sharing an operation and a table are merged
+	aggregations := make(map[mysqlAggregationKey]*mysql.RequestStat, len(connectionData.Data))
+	keys := make([]mysqlAggregationKey, 0, len(connectionData.Data))
+	for _, kv := range connectionData.Data {
+		key := mysqlAggregationKey{operation: kv.Key.Operation, tableName: kv.Key.TableName}
+		stats, ok := aggregations[key]
+		if !ok {
+			stats = new(mysql.RequestStat)
+			aggregations[key] = stats
+			keys = append(keys, key)
+		}
+		stats.CombineWith(kv.Value)
+	}
+
+	for _, key := range keys {
+		stats := aggregations[key]
+
+		e.stats = e.stats[:0]
+		if key.tableName != "" {
+			e.stats = protowire.AppendTag(e.stats, mysqlStatsTableNameField, protowire.BytesType)
+			e.stats = protowire.AppendString(e.stats, key.tableName)
+		}
+		e.stats = protowire.AppendTag(e.stats, mysqlStatsOperationField, protowire.VarintType)
+		e.stats = protowire.AppendVarint(e.stats, uint64(key.operation))
+		if latencies := stats.Latencies; latencies != nil {
+			blob, _ := proto.Marshal(latencies.ToProto())
+			e.stats = protowire.AppendTag(e.stats, mysqlStatsLatenciesField, protowire.BytesType)
+			e.stats = protowire.AppendBytes(e.stats, blob)
+		} else {
+			e.stats = protowire.AppendTag(e.stats, mysqlStatsFirstLatencySampleField, protowire.Fixed64Type)
+			e.stats = protowire.AppendFixed64(e.stats, math.Float64bits(stats.FirstLatencySample))
+		}
+		e.stats = protowire.AppendTag(e.stats, mysqlStatsCountField, protowire.VarintType)
+		e.stats = protowire.AppendVarint(e.stats, uint64(uint32(stats.Count)))
+		if stats.ErrorCount > 0 {
+			e.stats = protowire.AppendTag(e.stats, mysqlStatsErrorCountField, protowire.VarintType)
+			e.stats = protowire.AppendVarint(e.stats, uint64(uint32(stats.ErrorCount)))
+		}
+
+		e.databaseStats = protowire.AppendTag(e.databaseStats[:0], databaseStatsMySQLField, protowire.BytesType)
+		e.databaseStats = protowire.AppendBytes(e.databaseStats, e.stats)
+
+		e.aggregation = protowire.AppendTag(e.aggregation[:0], databaseAggregationsAggregationsField, protowire.BytesType)
+		e.aggregation = protowire.AppendBytes(e.aggregation, e.databaseStats)
+		_, _ = w.Write(e.aggregation)
+	}
+}
+
+func (e *mysqlEncoder) Close() {
+	if e == nil {
+		return
+	}
+
+	e.byConnection.Close()
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func TestMySQLProtocolStack(t *testing.T) {
+	connections := []network.ConnectionStats{
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 1},
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 2},
+		{Source: localhost, SPort: clientPort + 1, Dest: localhost, DPort: serverPort, Pid: 1},
+	}
+
+	stats := new(mysql.RequestStat)
+	stats.Add(1000, false)
+	encoder := newMySQLEncoder(map[mysql.Key]*mysql.RequestStat{
+		mysql.NewKey(types.NewConnectionKey(localhost, localhost, clientPort, serverPort), mysql.SelectOP, "dummy", "SELECT * FROM dummy"): stats,
+	})
+	t.Cleanup(encoder.Close)
+
+	assert.Equal(t, protocols.Stack{Application: protocols.MySQL}, encoder.GetProtocolStack(connections[0]))
+	// the other connection sharing the same addresses but a different PID
+	// doesn't get the stats
+	assert.Equal(t, protocols.Stack{}, encoder.GetProtocolStack(connections[1]))
+	assert.Equal(t, protocols.Stack{}, encoder.GetProtocolStack(connections[2]))
+
+	// the protocol found by the classifier is kept
+	classified := connections[0]
+	classified.ProtocolStack = protocols.Stack{Application: protocols.Postgres}
+	assert.Equal(t, classified.ProtocolStack, encoder.GetProtocolStack(classified))
+
+	var nilEncoder *mysqlEncoder
+	assert.Equal(t, protocols.Stack{}, nilEncoder.GetProtocolStack(connections[0]))
+}
+
+// mysqlTestStats are the decoded fields of an encoded MySQL stats message
+type mysqlTestStats struct {
+	tableName          string
+	operation          mysql.Operation
+	latencies          []byte
+	firstLatencySample float64
+	count              uint32
+	errorCount         uint32
+}
+
+func TestFormatMySQLStats(t *testing.T) {
+	connKey := types.NewConnectionKey(localhost, localhost, clientPort, serverPort)
+
+	selectStats := new(mysql.RequestStat)
+	selectStats.Add(1000, false)
+	selectStats.Add(2000, false)
+	otherSelectStats := new(mysql.RequestStat)
+	otherSelectStats.Add(3000, true)
+	insertStats := new(mysql.RequestStat)
+	insertStats.Add(500, false)
+
+	encoder := newMySQLEncoder(map[mysql.Key]*mysql.RequestStat{
+		mysql.NewKey(connKey, mysql.SelectOP, "dummy", "SELECT * FROM dummy WHERE id = ?"): selectStats,
+		mysql.NewKey(connKey, mysql.SelectOP, "dummy", "SELECT id FROM dummy"):             otherSelectStats,
+		mysql.NewKey(connKey, mysql.InsertOP, "dummy", "INSERT INTO dummy VALUES ( ? )"):   insertStats,
+	})
+	t.Cleanup(encoder.Close)
+
+	stats := getMySQLAggregations(t, encoder, defaultConnection)
+	require.Len(t, stats, 2)
+
+	byOperation := make(map[mysql.Operation]mysqlTestStats)
+	for _, s := range stats {
+		assert.Equal(t, "dummy", s.tableName)
+		byOperation[s.operation] = s
+	}
+
+	// the queries sharing an operation and a table are merged
+	selects, ok := byOperation[mysql.SelectOP]
+	require.True(t, ok)
+	assert.Equal(t, uint32(3), selects.count)
+	assert.Equal(t, uint32(1), selects.errorCount)
+	assert.Equal(t, float64(3), unmarshalSketch(t, selects.latencies).GetCount())
+
+	inserts, ok := byOperation[mysql.InsertOP]
+	require.True(t, ok)
+	assert.Equal(t, uint32(1), inserts.count)
+	assert.Zero(t, inserts.errorCount)
+	assert.Empty(t, inserts.latencies)
+	assert.Equal(t, float64(500), inserts.firstLatencySample)
+
+	// the other connection sharing the same addresses but a different PID
+	// doesn't get the stats
+	other := defaultConnection
+	other.Pid++
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WriteMySQLAggregations(other, model.NewConnectionBuilder(streamer))
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+	assert.Empty(t, conn.DatabaseAggregations)
+}
+
+func getMySQLAggregations(t *testing.T, encoder *mysqlEncoder, c network.ConnectionStats) []mysqlTestStats {
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WriteMySQLAggregations(c, model.NewConnectionBuilder(streamer))
+
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+
+	// the readers which don't know the MySQL stats still decode the
+	// aggregations
+	var aggregations model.DatabaseAggregations
+	require.NoError(t, proto.Unmarshal(conn.DatabaseAggregations, &aggregations))
+
+	var stats []mysqlTestStats
+	for _, aggregation := range consumeMySQLTestFields(t, conn.DatabaseAggregations) {
+		require.Equal(t, databaseAggregationsAggregationsField, aggregation.number)
+		databaseStats := consumeMySQLTestFields(t, aggregation.bytes)
+		require.Len(t, databaseStats, 1)
+		require.Equal(t, databaseStatsMySQLField, databaseStats[0].number)
+
+		var s mysqlTestStats
+		for _, field := range consumeMySQLTestFields(t, databaseStats[0].bytes) {
+			switch field.number {
+			case mysqlStatsTableNameField:
+				s.tableName = string(field.bytes)
+			case mysqlStatsOperationField:
+				s.operation = mysql.Operation(field.value)
+			case mysqlStatsLatenciesField:
+				s.latencies = field.bytes
+			case mysqlStatsFirstLatencySampleField:
+				s.firstLatencySample = math.Float64frombits(field.value)
+			case mysqlStatsCountField:
+				s.count = uint32(field.value)
+			case mysqlStatsErrorCountField:
+				s.errorCount = uint32(field.value)
+			default:
+				t.Fatalf("unexpected field %d", field.number)
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+type mysqlTestField struct {
+	number protowire.Number
+	value  uint64
+	bytes  []byte
+}
+
+func consumeMySQLTestFields(t *testing.T, b []byte) []mysqlTestField {
+	var fields []mysqlTestField
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		field := mysqlTestField{number: number}
+		switch typ {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"bytes"
+	"io"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// postgresAggregationKey identifies the stats sent in the payload, which
+// doesn't carry the query signatures
+type postgresAggregationKey struct {
+	operation postgres.Operation
+	tableName string
+}
+
+type postgresEncoder struct {
+	postgresAggregationsBuilder *model.DatabaseAggregationsBuilder
+	byConnection                *USMConnectionIndex[postgres.Key, *postgres.RequestStat]
+}
+
+func newPostgresEncoder(postgresPayloads map[postgres.Key]*postgres.RequestStat) *postgresEncoder {
+	if len(postgresPayloads) == 0 {
+		return nil
+	}
+
+	return &postgresEncoder{
+		postgresAggregationsBuilder: model.NewDatabaseAggregationsBuilder(nil),
+		byConnection: GroupByConnection("postgres", postgresPayloads, func(key postgres.Key) types.ConnectionKey {
+			return key.ConnectionKey
+		}),
+	}
+}
+
+func (e *postgresEncoder) WritePostgresAggregations(c network.ConnectionStats, builder *model.ConnectionBuilder) {
+	if e == nil {
+		return
+	}
+
+	connectionData := e.byConnection.Find(c)
+	if connectionData == nil || len(connectionData.Data) == 0 || connectionData.IsPIDCollision(c) {
+		return
+	}
+
+	builder.SetDatabaseAggregations(func(b *bytes.Buffer) {
+		e.encodeData(connectionData, b)
+	})
+}
+
+func (e *postgresEncoder) encodeData(connectionData *USMConnectionData[postgres.Key, *postgres.RequestStat], w io.Writer) {
+	e.postgresAggregationsBuilder.Reset(w)
+
+	// the stats of the queries sharing an operation and a table are merged
+	aggregations := make(map[postgresAggregationKey]*postgres.RequestStat, len(connectionData.Data))
+	keys := make([]postgresAggregationKey, 0, len(connectionData.Data))
+	for _, kv := range connectionData.Data {
+		key := postgresAggregationKey{operation: kv.Key.Operation, tableName: kv.Key.TableName}
+		stats, ok := aggregations[key]
+		if !ok {
+			stats = new(postgres.RequestStat)
+			aggregations[key] = stats
+			keys = append(keys, key)
+		}
+		stats.CombineWith(kv.Value)
+	}
+
+	for _, key := range keys {
+		stats := aggregations[key]
+		e.postgresAggregationsBuilder.AddAggregations(func(builder *model.DatabaseStatsBuilder) {
+			builder.SetPostgres(func(statsBuilder *model.PostgresStatsBuilder) {
+				statsBuilder.SetTableName(key.tableName)
+				statsBuilder.SetOperation(uint64(key.operation))
+				statsBuilder.SetCount(uint32(stats.Count))
+				if latencies := stats.Latencies; latencies != nil {
+					blob, _ := proto.Marshal(latencies.ToProto())
+					statsBuilder.SetLatencies(func(b *bytes.Buffer) {
+						b.Write(blob)
+					})
+				} else {
+					statsBuilder.SetFirstLatencySample(stats.FirstLatencySample)
+				}
+			})
+		})
+	}
+}
+
+func (e *postgresEncoder) Close() {
+	if e == nil {
+		return
+	}
+
+	e.byConnection.Close()
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func TestFormatPostgresStats(t *testing.T) {
+	connKey := types.NewConnectionKey(localhost, localhost, clientPort, serverPort)
+
+	selectStats := new(postgres.RequestStat)
+	selectStats.Add(1000, false)
+	selectStats.Add(2000, false)
+	otherSelectStats := new(postgres.RequestStat)
+	otherSelectStats.Add(3000, true)
+	insertStats := new(postgres.RequestStat)
+	insertStats.Add(500, false)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				defaultConnection,
+			},
+		},
+		Postgres: map[postgres.Key]*postgres.RequestStat{
+			postgres.NewKey(connKey, postgres.SelectOP, "dummy", "SELECT * FROM dummy WHERE id = ?"): selectStats,
+			postgres.NewKey(connKey, postgres.SelectOP, "dummy", "SELECT id FROM dummy"):             otherSelectStats,
+			postgres.NewKey(connKey, postgres.InsertOP, "dummy", "INSERT INTO dummy VALUES ( ? )"):   insertStats,
+		},
+	}
+
+	encoder := newPostgresEncoder(in.Postgres)
+	t.Cleanup(encoder.Close)
+
+	aggregations := getPostgresAggregations(t, encoder, in.Conns[0])
+	require.Len(t, aggregations.Aggregations, 2)
+
+	byOperation := make(map[model.PostgresOperation]*model.PostgresStats)
+	for _, aggregation := range aggregations.Aggregations {
+		stats := aggregation.GetPostgres()
+		require.NotNil(t, stats)
+		assert.Equal(t, "dummy", stats.TableName)
+		byOperation[stats.Operation] = stats
+	}
+
+	// the queries sharing an operation and a table are merged
+	selects := byOperation[model.PostgresOperation_PostgresSelectOp]
+	require.NotNil(t, selects)
+	assert.Equal(t, uint32(3), selects.Count)
+	assert.NotEmpty(t, selects.Latencies)
+
+	inserts := byOperation[model.PostgresOperation_PostgresInsertOp]
+	require.NotNil(t, inserts)
+	assert.Equal(t, uint32(1), inserts.Count)
+	assert.Empty(t, inserts.Latencies)
+	assert.Equal(t, float64(500), inserts.FirstLatencySample)
+}
+
+func TestPostgresIDCollision(t *testing.T) {
+	connections := []network.ConnectionStats{
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 1},
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 2},
+	}
+
+	stats := new(postgres.RequestStat)
+	stats.Add(1000, false)
+	encoder := newPostgresEncoder(map[postgres.Key]*postgres.RequestStat{
+		postgres.NewKey(types.NewConnectionKey(localhost, localhost, clientPort, serverPort), postgres.SelectOP, "dummy", "SELECT * FROM dummy"): stats,
+	})
+	t.Cleanup(encoder.Close)
+
+	aggregations := getPostgresAggregations(t, encoder, connections[0])
+	require.Len(t, aggregations.Aggregations, 1)
+
+	// the other connection sharing the same addresses but a different PID
+	// doesn't get the stats
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WritePostgresAggregations(connections[1], model.NewConnectionBuilder(streamer))
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+	assert.Empty(t, conn.DatabaseAggregations)
+}
+
+func getPostgresAggregations(t *testing.T, encoder *postgresEncoder, c network.ConnectionStats) *model.DatabaseAggregations {
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WritePostgresAggregations(c, model.NewConnectionBuilder(streamer))
+
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+
+	var aggregations model.DatabaseAggregations
+	err := proto.Unmarshal(conn.DatabaseAggregations, &aggregations)
+	require.NoError(t, err)
+
+	return &aggregations
+}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// redisAggregationKey identifies the stats sent in the payload. The commands
+// without a value in the payload are reported as unknown commands.
+type redisAggregationKey struct {
+	command   model.RedisCommand
+	keyPrefix string
+	truncated bool
+}
+
+type redisEncoder struct {
+	redisAggregationsBuilder *model.DatabaseAggregationsBuilder
+	byConnection             *USMConnectionIndex[redis.Key, *redis.RequestStats]
+}
+
+func newRedisEncoder(redisPayloads map[redis.Key]*redis.RequestStats) *redisEncoder {
+	if len(redisPayloads) == 0 {
+		return nil
+	}
+
+	return &redisEncoder{
+		redisAggregationsBuilder: model.NewDatabaseAggregationsBuilder(nil),
+		byConnection: GroupByConnection("redis", redisPayloads, func(key redis.Key) types.ConnectionKey {
+			return key.ConnectionKey
+		}),
+	}
+}
+
+func (e *redisEncoder) WriteRedisAggregations(c network.ConnectionStats, builder *model.ConnectionBuilder) {
+	if e == nil {
+		return
+	}
+
+	connectionData := e.byConnection.Find(c)
+	if connectionData == nil || len(connectionData.Data) == 0 || connectionData.IsPIDCollision(c) {
+		return
+	}
+
+	builder.SetDatabaseAggregations(func(b *bytes.Buffer) {
+		e.encodeData(connectionData, b)
+	})
+}
+
+func (e *redisEncoder) encodeData(connectionData *USMConnectionData[redis.Key, *redis.RequestStats], w io.Writer) {
+	e.redisAggregationsBuilder.Reset(w)
+
+	// the stats of the commands without a dedicated value in the payload are
+	// merged
+	aggregations := make(map[redisAggregationKey]*redis.RequestStats, len(connectionData.Data))
+	keys := make([]redisAggregationKey, 0, len(connectionData.Data))
+	for _, kv := range connectionData.Data {
+		key := redisAggregationKey{
+			command:   redisCommand(kv.Key.Command),
+			keyPrefix: kv.Key.KeyPrefix,
+			truncated: kv.Key.Truncated,
+		}
+		stats, ok := aggregations[key]
+		if !ok {
+			stats = redis.NewRequestStats()
+			aggregations[key] = stats
+			keys = append(keys, key)
+		}
+		stats.CombineWith(kv.Value)
+	}
+
+	for _, key := range keys {
+		stats := aggregations[key]
+		e.redisAggregationsBuilder.AddAggregations(func(builder *model.DatabaseStatsBuilder) {
+			builder.SetRedis(func(statsBuilder *model.RedisStatsBuilder) {
+				statsBuilder.SetCommand(uint64(key.command))
+				statsBuilder.SetKeyName(key.keyPrefix)
+				statsBuilder.SetTruncated(key.truncated)
+				for errType, errStats := range stats.ErrorToStats {
+					statsBuilder.AddErrorToStats(func(entryBuilder *model.RedisStats_ErrorToStatsEntryBuilder) {
+						entryBuilder.SetKey(int32(errType))
+						entryBuilder.SetValue(func(valueBuilder *model.RedisStatsEntryBuilder) {
+							valueBuilder.SetCount(uint32(errStats.Count))
+							if latencies := errStats.Latencies; latencies != nil {
+								blob, _ := proto.Marshal(latencies.ToProto())
+								valueBuilder.SetLatencies(func(b *bytes.Buffer) {
+									b.Write(blob)
+								})
+							} else {
+								valueBuilder.SetFirstLatencySample(errStats.FirstLatencySample)
+							}
+						})
+					})
+				}
+			})
+		})
+	}
+}
+
+func (e *redisEncoder) Close() {
+	if e == nil {
+		return
+	}
+
+	e.byConnection.Close()
+}
+
+// redisCommands maps the upper-case name of the commands to their payload
+// value, e.g. "HGET" to RedisHGetCommand, so that every command known to the
+// payload is reported
+var redisCommands = func() map[string]model.RedisCommand {
+	commands := make(map[string]model.RedisCommand, len(model.RedisCommand_value))
+	for name, value := range model.RedisCommand_value {
+		command := model.RedisCommand(value)
+		if command == model.RedisCommand_RedisUnknownCommand {
+			continue
+		}
+		commands[strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(name, "Redis"), "Command"))] = command
+	}
+	return commands
+}()
+
+// redisCommand returns the payload value of a command
+func redisCommand(command string) model.RedisCommand {
+	if value, ok := redisCommands[command]; ok {
+		return value
+	}
+	return model.RedisCommand_RedisUnknownCommand
+}
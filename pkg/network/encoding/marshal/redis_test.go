@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func TestFormatRedisStats(t *testing.T) {
+	connKey := types.NewConnectionKey(localhost, localhost, clientPort, serverPort)
+
+	getStats := redis.NewRequestStats()
+	getStats.AddRequest(redis.NoErr, 1000)
+	getStats.AddRequest(redis.NoErr, 2000)
+	getStats.AddRequest(redis.WrongTypeErr, 3000)
+	lpushStats := redis.NewRequestStats()
+	lpushStats.AddRequest(redis.NoErr, 500)
+	clientStats := redis.NewRequestStats()
+	clientStats.AddRequest(redis.NoErr, 700)
+	flushStats := redis.NewRequestStats()
+	flushStats.AddRequest(redis.NoErr, 900)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				defaultConnection,
+			},
+		},
+		Redis: map[redis.Key]*redis.RequestStats{
+			redis.NewKey(connKey, "GET", "user", false):      getStats,
+			redis.NewKey(connKey, "LPUSH", "user", false):    lpushStats,
+			redis.NewKey(connKey, "CLIENT", "user", false):   clientStats,
+			redis.NewKey(connKey, "FLUSHALL", "user", false): flushStats,
+		},
+	}
+
+	encoder := newRedisEncoder(in.Redis)
+	t.Cleanup(encoder.Close)
+
+	aggregations := getRedisAggregations(t, encoder, in.Conns[0])
+	require.Len(t, aggregations.Aggregations, 3)
+
+	byCommand := make(map[model.RedisCommand]*model.RedisStats)
+	for _, aggregation := range aggregations.Aggregations {
+		stats := aggregation.GetRedis()
+		require.NotNil(t, stats)
+		assert.Equal(t, "user", stats.KeyName)
+		assert.False(t, stats.Truncated)
+		byCommand[stats.Command] = stats
+	}
+
+	gets := byCommand[model.RedisCommand_RedisGetCommand]
+	require.NotNil(t, gets)
+	require.Len(t, gets.ErrorToStats, 2)
+	assert.Equal(t, uint32(2), gets.ErrorToStats[int32(model.RedisErrorType_RedisNoError)].Count)
+	assert.NotEmpty(t, gets.ErrorToStats[int32(model.RedisErrorType_RedisNoError)].Latencies)
+	assert.Equal(t, uint32(1), gets.ErrorToStats[int32(model.RedisErrorType_RedisErrWrongType)].Count)
+	assert.Equal(t, float64(3000), gets.ErrorToStats[int32(model.RedisErrorType_RedisErrWrongType)].FirstLatencySample)
+
+	lpushes := byCommand[model.RedisCommand_RedisLPushCommand]
+	require.NotNil(t, lpushes)
+	require.Len(t, lpushes.ErrorToStats, 1)
+	assert.Equal(t, float64(500), lpushes.ErrorToStats[int32(model.RedisErrorType_RedisNoError)].FirstLatencySample)
+
+	// the commands without a dedicated value in the payload are merged
+	others := byCommand[model.RedisCommand_RedisUnknownCommand]
+	require.NotNil(t, others)
+	require.Len(t, others.ErrorToStats, 1)
+	assert.Equal(t, uint32(2), others.ErrorToStats[int32(model.RedisErrorType_RedisNoError)].Count)
+}
+
+func TestRedisCommand(t *testing.T) {
+	// every command of the payload is mapped
+	assert.Len(t, redisCommands, len(model.RedisCommand_value)-1)
+
+	assert.Equal(t, model.RedisCommand_RedisGetCommand, redisCommand("GET"))
+	assert.Equal(t, model.RedisCommand_RedisSetCommand, redisCommand("SET"))
+	assert.Equal(t, model.RedisCommand_RedisPingCommand, redisCommand("PING"))
+	assert.Equal(t, model.RedisCommand_RedisDelCommand, redisCommand("DEL"))
+	assert.Equal(t, model.RedisCommand_RedisIncrCommand, redisCommand("INCR"))
+	assert.Equal(t, model.RedisCommand_RedisExpireCommand, redisCommand("EXPIRE"))
+	assert.Equal(t, model.RedisCommand_RedisExistsCommand, redisCommand("EXISTS"))
+	assert.Equal(t, model.RedisCommand_RedisHGetCommand, redisCommand("HGET"))
+	assert.Equal(t, model.RedisCommand_RedisHSetCommand, redisCommand("HSET"))
+	assert.Equal(t, model.RedisCommand_RedisLPushCommand, redisCommand("LPUSH"))
+	assert.Equal(t, model.RedisCommand_RedisUnknownCommand, redisCommand("UNKNOWN"))
+	assert.Equal(t, model.RedisCommand_RedisUnknownCommand, redisCommand("CLIENT"))
+}
+
+func TestRedisIDCollision(t *testing.T) {
+	connections := []network.ConnectionStats{
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 1},
+		{Source: localhost, SPort: clientPort, Dest: localhost, DPort: serverPort, Pid: 2},
+	}
+
+	stats := redis.NewRequestStats()
+	stats.AddRequest(redis.NoErr, 1000)
+	encoder := newRedisEncoder(map[redis.Key]*redis.RequestStats{
+		redis.NewKey(types.NewConnectionKey(localhost, localhost, clientPort, serverPort), "GET", "user", false): stats,
+	})
+	t.Cleanup(encoder.Close)
+
+	aggregations := getRedisAggregations(t, encoder, connections[0])
+	require.Len(t, aggregations.Aggregations, 1)
+
+	// the other connection sharing the same addresses but a different PID
+	// doesn't get the stats
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WriteRedisAggregations(connections[1], model.NewConnectionBuilder(streamer))
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+	assert.Empty(t, conn.DatabaseAggregations)
+}
+
+func getRedisAggregations(t *testing.T, encoder *redisEncoder, c network.ConnectionStats) *model.DatabaseAggregations {
+	streamer := NewProtoTestStreamer[*model.Connection]()
+	encoder.WriteRedisAggregations(c, model.NewConnectionBuilder(streamer))
+
+	var conn model.Connection
+	streamer.Unwrap(t, &conn)
+
+	var aggregations model.DatabaseAggregations
+	err := proto.Unmarshal(conn.DatabaseAggregations, &aggregations)
+	require.NoError(t, err)
+
+	return &aggregations
+}
@@ -16,8 +16,12 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -137,6 +141,10 @@ type Connections struct {
 	HTTP                        map[http.Key]*http.RequestStats
 	HTTP2                       map[http.Key]*http.RequestStats
 	Kafka                       map[kafka.Key]*kafka.RequestStat
+	Postgres                    map[postgres.Key]*postgres.RequestStat
+	MySQL                       map[mysql.Key]*mysql.RequestStat
+	Redis                       map[redis.Key]*redis.RequestStats
+	GRPC                        map[grpc.Key]*grpc.RequestStats
 }
 
 // NewConnections create a new Connections object
@@ -171,6 +179,11 @@ const (
 	ConntrackSamplingPercent        ConnTelemetryType = "conntrack_sampling_percent"
 	NPMDriverFlowsMissedMaxExceeded ConnTelemetryType = "driver_flows_missed_max_exceeded"
 
+	// DNS Payload Telemetry, summed up over the connections of the payload
+	DNSNXDomainStorms      ConnTelemetryType = "dns_nxdomain_storms"
+	DNSClientSubnetQueries ConnTelemetryType = "dns_client_subnet_queries"
+	DNSExtendedErrors      ConnTelemetryType = "dns_extended_errors"
+
 	// USM Payload Telemetry
 	USMHTTPHits ConnTelemetryType = "usm.http.total_hits"
 )
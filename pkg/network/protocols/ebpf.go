@@ -25,6 +25,12 @@ type DispatcherProgramType C.dispatcher_prog_t
 const (
 	// DispatcherKafkaProg is the Golang representation of the C.DISPATCHER_KAFKA_PROG enum.
 	DispatcherKafkaProg DispatcherProgramType = C.DISPATCHER_KAFKA_PROG
+	// DispatcherPostgresProg is the Golang representation of the C.DISPATCHER_POSTGRES_PROG enum.
+	DispatcherPostgresProg DispatcherProgramType = C.DISPATCHER_POSTGRES_PROG
+	// DispatcherMySQLProg is the Golang representation of the C.DISPATCHER_MYSQL_PROG enum.
+	DispatcherMySQLProg DispatcherProgramType = C.DISPATCHER_MYSQL_PROG
+	// DispatcherRedisProg is the Golang representation of the C.DISPATCHER_REDIS_PROG enum.
+	DispatcherRedisProg DispatcherProgramType = C.DISPATCHER_REDIS_PROG
 )
 
 // ProgramType is a C type to represent the eBPF programs used for tail calls.
@@ -45,6 +51,12 @@ const (
 	ProgramHTTP2EOSParser ProgramType = C.PROG_HTTP2_EOS_PARSER
 	// ProgramKafka is the Golang representation of the C.PROG_KAFKA enum
 	ProgramKafka ProgramType = C.PROG_KAFKA
+	// ProgramPostgres is the Golang representation of the C.PROG_POSTGRES enum
+	ProgramPostgres ProgramType = C.PROG_POSTGRES
+	// ProgramMySQL is the Golang representation of the C.PROG_MYSQL enum
+	ProgramMySQL ProgramType = C.PROG_MYSQL
+	// ProgramRedis is the Golang representation of the C.PROG_REDIS enum
+	ProgramRedis ProgramType = C.PROG_REDIS
 )
 
 // Application layer of the protocol stack.
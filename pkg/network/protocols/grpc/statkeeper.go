@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http2"
+)
+
+// StatKeeper is a struct to hold the stats for the gRPC protocol
+type StatKeeper struct {
+	stats      map[Key]*RequestStats
+	statsMutex sync.Mutex
+	maxEntries int
+	telemetry  *Telemetry
+
+	// quantizer bounds the cardinality of the paths which aren't made of a
+	// service and a method
+	quantizer *http.URLQuantizer
+}
+
+// NewStatkeeper creates a new StatKeeper
+func NewStatkeeper(c *config.Config, telemetry *Telemetry) *StatKeeper {
+	return &StatKeeper{
+		stats:      make(map[Key]*RequestStats),
+		maxEntries: c.MaxGRPCStatsBuffered,
+		telemetry:  telemetry,
+		quantizer:  http.NewURLQuantizer(),
+	}
+}
+
+// Process processes an HTTP/2 transaction, which is ignored if it isn't a
+// gRPC call
+func (statKeeper *StatKeeper) Process(tx *http2.Transaction) {
+	if !tx.IsGRPC() {
+		return
+	}
+
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+
+	statKeeper.telemetry.calls.Add(1)
+	status := statKeeper.status(tx)
+	if status != OK {
+		statKeeper.telemetry.errors.Add(1)
+	}
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		statKeeper.telemetry.negativeLatency.Add(1)
+		return
+	}
+
+	service, method, ok := SplitPath(tx.Path)
+	if !ok {
+		service = string(statKeeper.quantizer.Quantize([]byte(tx.Path)))
+	}
+
+	key := NewKey(tx.ConnTuple, service, method)
+	requestStats, ok := statKeeper.stats[key]
+	if !ok {
+		if len(statKeeper.stats) >= statKeeper.maxEntries {
+			statKeeper.telemetry.dropped.Add(1)
+			return
+		}
+		requestStats = NewRequestStats()
+		statKeeper.stats[key] = requestStats
+	}
+	requestStats.AddRequest(status, tx.RequestLatency())
+}
+
+// status returns the status code of a call, which is carried by the trailers
+// of the response, or by its headers when the server didn't send any message.
+func (statKeeper *StatKeeper) status(tx *http2.Transaction) StatusCode {
+	if tx.GRPCStatus >= 0 {
+		if tx.GRPCStatus > int(Unauthenticated) {
+			return Unknown
+		}
+		return StatusCode(tx.GRPCStatus)
+	}
+
+	statKeeper.telemetry.missingStatus.Add(1)
+	if tx.Reset {
+		return Canceled
+	}
+	if tx.StatusCode != 200 {
+		return StatusFromHTTP(tx.StatusCode)
+	}
+	return Unknown
+}
+
+// GetAndResetAllStats returns all the stats and resets the stats
+func (statKeeper *StatKeeper) GetAndResetAllStats() map[Key]*RequestStats {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+	ret := statKeeper.stats // No deep copy needed since `statKeeper.stats` gets reset
+	statKeeper.stats = make(map[Key]*RequestStats)
+	return ret
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http2"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func grpcTx(path string, grpcStatus int, latency uint64) *http2.Transaction {
+	return &http2.Transaction{
+		Method:           "POST",
+		Path:             path,
+		ContentType:      "application/grpc",
+		StatusCode:       200,
+		GRPCStatus:       grpcStatus,
+		RequestStarted:   1000,
+		ResponseLastSeen: 1000 + latency,
+	}
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	tel := NewTelemetry()
+	sk := NewStatkeeper(&config.Config{MaxGRPCStatsBuffered: 1000}, tel)
+
+	sk.Process(grpcTx("/helloworld.Greeter/SayHello", 0, 1000))
+	sk.Process(grpcTx("/helloworld.Greeter/SayHello", 0, 2000))
+	sk.Process(grpcTx("/helloworld.Greeter/SayHello", int(NotFound), 3000))
+	sk.Process(grpcTx("/helloworld.Greeter/SayGoodbye", 0, 500))
+
+	// a call canceled by the client
+	canceled := grpcTx("/helloworld.Greeter/SayGoodbye", -1, 700)
+	canceled.Reset = true
+	sk.Process(canceled)
+
+	// a call rejected by a proxy, without any grpc-status
+	rejected := grpcTx("/helloworld.Greeter/SayGoodbye", -1, 100)
+	rejected.StatusCode = 503
+	sk.Process(rejected)
+
+	// an HTTP/2 request which isn't a gRPC call is ignored
+	sk.Process(&http2.Transaction{Method: "GET", Path: "/index.html", StatusCode: 200, GRPCStatus: -1})
+
+	stats := sk.GetAndResetAllStats()
+	assert.Empty(t, sk.GetAndResetAllStats())
+	require.Len(t, stats, 2)
+
+	sayHello := stats[NewKey(types.ConnectionKey{}, "helloworld.Greeter", "SayHello")]
+	require.NotNil(t, sayHello)
+	require.Len(t, sayHello.Data, 2)
+	assert.Equal(t, 2, sayHello.Data[OK].Count)
+	require.NotNil(t, sayHello.Data[OK].Latencies)
+	assert.Equal(t, float64(2), sayHello.Data[OK].Latencies.GetCount())
+	assert.Equal(t, 1, sayHello.Data[NotFound].Count)
+	assert.Equal(t, float64(3000), sayHello.Data[NotFound].FirstLatencySample)
+
+	sayGoodbye := stats[NewKey(types.ConnectionKey{}, "helloworld.Greeter", "SayGoodbye")]
+	require.NotNil(t, sayGoodbye)
+	require.Len(t, sayGoodbye.Data, 3)
+	assert.Equal(t, 1, sayGoodbye.Data[OK].Count)
+	assert.Equal(t, 1, sayGoodbye.Data[Canceled].Count)
+	assert.Equal(t, 1, sayGoodbye.Data[Unavailable].Count)
+
+	assert.Equal(t, int64(6), tel.calls.Get())
+	assert.Equal(t, int64(3), tel.errors.Get())
+	assert.Equal(t, int64(2), tel.missingStatus.Get())
+}
+
+func TestStatKeeperQuantization(t *testing.T) {
+	sk := NewStatkeeper(&config.Config{MaxGRPCStatsBuffered: 1000}, NewTelemetry())
+
+	// paths which aren't made of a service and a method are quantized
+	sk.Process(grpcTx("/users/1234/profile", 0, 1000))
+	sk.Process(grpcTx("/users/5678/profile", 0, 1000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 2, stats[NewKey(types.ConnectionKey{}, "/users/*/profile", "")].Data[OK].Count)
+}
+
+func TestStatKeeperMaxEntries(t *testing.T) {
+	tel := NewTelemetry()
+	sk := NewStatkeeper(&config.Config{MaxGRPCStatsBuffered: 2}, tel)
+
+	for _, method := range []string{"A", "B", "C", "A"} {
+		sk.Process(grpcTx("/svc.Service/"+method, 0, 1000))
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 2, stats[NewKey(types.ConnectionKey{}, "svc.Service", "A")].Data[OK].Count)
+	assert.Equal(t, int64(1), tel.dropped.Get())
+}
+
+func TestSplitPath(t *testing.T) {
+	for _, tc := range []struct {
+		path            string
+		service, method string
+		ok              bool
+	}{
+		{path: "/helloworld.Greeter/SayHello", service: "helloworld.Greeter", method: "SayHello", ok: true},
+		{path: "/Greeter/SayHello", service: "Greeter", method: "SayHello", ok: true},
+		{path: "helloworld.Greeter/SayHello"},
+		{path: "/helloworld.Greeter"},
+		{path: "/helloworld.Greeter/"},
+		{path: "//SayHello"},
+		{path: "/a/b/c"},
+	} {
+		service, method, ok := SplitPath(tc.path)
+		assert.Equal(t, tc.ok, ok, tc.path)
+		assert.Equal(t, tc.service, service, tc.path)
+		assert.Equal(t, tc.method, method, tc.path)
+	}
+}
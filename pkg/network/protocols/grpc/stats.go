@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package grpc aggregates the gRPC calls decoded from HTTP/2 traffic by
+// service, method and status code. The calls come from the userspace HTTP/2
+// decoder, which only the pcap replay feeds.
+package grpc
+
+import (
+	"strings"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+const RelativeAccuracy = 0.01
+
+// StatusCode is a gRPC status code
+type StatusCode uint8
+
+// The gRPC status codes, as defined in
+// https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	OK StatusCode = iota
+	Canceled
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+// StatusFromHTTP returns the status code of a call which ended without a
+// grpc-status, from the HTTP status of the response, as defined in
+// https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func StatusFromHTTP(httpStatus uint16) StatusCode {
+	switch httpStatus {
+	case 400:
+		return Internal
+	case 401:
+		return Unauthenticated
+	case 403:
+		return PermissionDenied
+	case 404:
+		return Unimplemented
+	case 429, 502, 503, 504:
+		return Unavailable
+	}
+	return Unknown
+}
+
+// Key is an identifier for a group of gRPC calls
+type Key struct {
+	Service string
+	Method  string
+	types.ConnectionKey
+}
+
+// NewKey generates a new Key
+func NewKey(connKey types.ConnectionKey, service, method string) Key {
+	return Key{
+		Service:       service,
+		Method:        method,
+		ConnectionKey: connKey,
+	}
+}
+
+// SplitPath returns the service and the method of a gRPC call from its
+// path, which has the form /package.Service/Method
+func SplitPath(path string) (service, method string, ok bool) {
+	if !strings.HasPrefix(path, "/") {
+		return "", "", false
+	}
+	service, method, ok = strings.Cut(path[1:], "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", false
+	}
+	return service, method, true
+}
+
+// RequestStat stores stats for gRPC calls with the same status code
+type RequestStat struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	// Count is the number of calls. Latencies can discard values outside of
+	// the range it tracks, so it isn't used as the source of truth.
+	Count int
+
+	// FirstLatencySample holds the latency (in nanoseconds) of the first call,
+	// to avoid creating sketches with a single value.
+	FirstLatencySample float64
+}
+
+// Add records the latency of a call in the stats.
+func (r *RequestStat) Add(latency float64) {
+	r.Count++
+	if r.Count == 1 {
+		// We postpone the creation of sketches when we have only one latency sample
+		r.FirstLatencySample = latency
+		return
+	}
+
+	if r.Latencies == nil {
+		if err := r.initSketch(); err != nil {
+			return
+		}
+
+		// Add the deferred latency sample
+		if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+			log.Debugf("could not add grpc call latency to ddsketch: %v", err)
+		}
+	}
+
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add grpc call latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	if newStats.Count == 0 {
+		return
+	}
+
+	if newStats.Count == 1 {
+		// The other stat has a single latency sample, so we "manually" add it
+		r.Add(newStats.FirstLatencySample)
+		return
+	}
+
+	// The other stat has multiple samples and therefore a DDSketch object. We
+	// first ensure that the receiver has one.
+	if newStats.Latencies == nil {
+		log.Debugf("could not merge grpc calls: missing ddsketch")
+	} else if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in the receiver we now add it to the DDSketch
+		if r.Count == 1 {
+			if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+				log.Debugf("could not add grpc call latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging grpc calls: %v", err)
+	}
+	r.Count += newStats.Count
+}
+
+func (r *RequestStat) initSketch() (err error) {
+	r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+	if err != nil {
+		log.Debugf("error recording grpc call latency: could not create new ddsketch: %v", err)
+	}
+	return
+}
+
+// RequestStats stores the stats of the calls of a Key, by status code
+type RequestStats struct {
+	Data map[StatusCode]*RequestStat
+}
+
+// NewRequestStats creates a new RequestStats object.
+func NewRequestStats() *RequestStats {
+	return &RequestStats{
+		Data: make(map[StatusCode]*RequestStat),
+	}
+}
+
+// AddRequest records a call with the given status code and latency.
+func (r *RequestStats) AddRequest(status StatusCode, latency float64) {
+	stats, ok := r.Data[status]
+	if !ok {
+		stats = new(RequestStat)
+		r.Data[status] = stats
+	}
+	stats.Add(latency)
+}
+
+// CombineWith merges the data in 2 RequestStats objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStats) CombineWith(newStats *RequestStats) {
+	for status, newRequests := range newStats.Data {
+		stats, ok := r.Data[status]
+		if !ok {
+			stats = new(RequestStat)
+			r.Data[status] = stats
+		}
+		stats.CombineWith(newRequests)
+	}
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Telemetry is a struct to hold the telemetry for the gRPC protocol
+type Telemetry struct {
+	metricGroup *libtelemetry.MetricGroup
+
+	calls           *libtelemetry.Counter
+	errors          *libtelemetry.Counter // calls ended with a status other than OK
+	missingStatus   *libtelemetry.Counter // calls ended without a grpc-status
+	dropped         *libtelemetry.Counter // this happens when StatKeeper reaches capacity
+	negativeLatency *libtelemetry.Counter // this happens when the response was captured before the request
+}
+
+// NewTelemetry creates a new Telemetry
+func NewTelemetry() *Telemetry {
+	metricGroup := libtelemetry.NewMetricGroup("usm.grpc")
+
+	return &Telemetry{
+		metricGroup:     metricGroup,
+		calls:           metricGroup.NewCounter("total_hits", libtelemetry.OptStatsd),
+		errors:          metricGroup.NewCounter("errors", libtelemetry.OptStatsd),
+		missingStatus:   metricGroup.NewCounter("missing_status"),
+		dropped:         metricGroup.NewCounter("dropped", libtelemetry.OptStatsd),
+		negativeLatency: metricGroup.NewCounter("negative_latency"),
+	}
+}
+
+// Log logs the gRPC stats summary
+func (t *Telemetry) Log() {
+	log.Debugf("grpc stats summary: %s", t.metricGroup.Summary())
+}
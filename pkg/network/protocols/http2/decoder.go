@@ -0,0 +1,438 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http2
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// clientPreface is sent by the client at the start of every HTTP/2
+	// connection
+	clientPreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+	frameHeaderSize = 9
+	// maxHeaderBlockSize is the largest header block, including its
+	// CONTINUATION frames, accepted by the decoder
+	maxHeaderBlockSize = 64 << 10
+	// maxHeaderStringSize is the largest header name or value decoded
+	maxHeaderStringSize = 4096
+	// maxPendingStreams is the number of streams waiting for a response
+	// tracked by the decoder on a connection
+	maxPendingStreams = 1024
+	// defaultHeaderTableSize is the initial size of the HPACK dynamic tables
+	defaultHeaderTableSize = 4096
+)
+
+// Frame types
+const (
+	dataFrame         = 0x0
+	headersFrame      = 0x1
+	rstStreamFrame    = 0x3
+	settingsFrame     = 0x4
+	continuationFrame = 0x9
+)
+
+// Frame flags
+const (
+	flagEndStream  = 0x1
+	flagAck        = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+
+	settingHeaderTableSize = 0x1
+)
+
+const grpcContentType = "application/grpc"
+
+var errMalformedFrame = errors.New("malformed http2 frame")
+
+// Transaction is an HTTP/2 request and the response of the server
+type Transaction struct {
+	ConnTuple types.ConnectionKey
+	StreamID  uint32
+	Method    string
+	Path      string
+	// ContentType is the content-type of the request
+	ContentType string
+	// StatusCode is the :status of the response, or 0 if the stream was reset
+	// before the response headers were sent
+	StatusCode uint16
+	// GRPCStatus is the grpc-status sent by the server, or -1 if it wasn't sent
+	GRPCStatus int
+	// Reset is true if the stream was ended by a RST_STREAM frame
+	Reset bool
+	// RequestStarted is the time the request headers were sent, in nanoseconds
+	RequestStarted uint64
+	// ResponseLastSeen is the time the stream was closed by the server, in
+	// nanoseconds
+	ResponseLastSeen uint64
+}
+
+// RequestLatency returns the latency of the request in nanoseconds
+func (tx *Transaction) RequestLatency() float64 {
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		return 0
+	}
+	return float64(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+// IsGRPC returns true if the request is a gRPC call
+func (tx *Transaction) IsGRPC() bool {
+	// the content-type can carry the message encoding, as in
+	// application/grpc+proto, and parameters
+	contentType, _, _ := strings.Cut(tx.ContentType, ";")
+	return contentType == grpcContentType || strings.HasPrefix(contentType, grpcContentType+"+")
+}
+
+// frameHeader is the fixed size header of an HTTP/2 frame
+type frameHeader struct {
+	length   int
+	typ      byte
+	flags    byte
+	streamID uint32
+}
+
+// frameReader splits one direction of an HTTP/2 connection into frames.
+// Only the payloads of the frames requested by the caller are buffered, the
+// rest is discarded as it arrives.
+type frameReader struct {
+	buf     []byte
+	discard int
+}
+
+// read buffers data and calls handle for each complete frame. keep reports
+// whether the payload of a frame is passed to handle.
+func (r *frameReader) read(data []byte, keep func(frameHeader) bool, handle func(frameHeader, []byte) error) error {
+	if r.discard > 0 {
+		n := min(r.discard, len(data))
+		r.discard -= n
+		data = data[n:]
+	}
+	r.buf = append(r.buf, data...)
+
+	for len(r.buf) >= frameHeaderSize {
+		header := frameHeader{
+			length:   int(r.buf[0])<<16 | int(r.buf[1])<<8 | int(r.buf[2]),
+			typ:      r.buf[3],
+			flags:    r.buf[4],
+			streamID: binary.BigEndian.Uint32(r.buf[5:9]) & 0x7fffffff,
+		}
+
+		kept := 0
+		if keep(header) {
+			if header.length > maxHeaderBlockSize {
+				return errMalformedFrame
+			}
+			kept = header.length
+		}
+		if len(r.buf) < frameHeaderSize+kept {
+			return nil
+		}
+		if err := handle(header, r.buf[frameHeaderSize:frameHeaderSize+kept]); err != nil {
+			return err
+		}
+
+		total := frameHeaderSize + header.length
+		if total > len(r.buf) {
+			r.discard = total - len(r.buf)
+			r.buf = r.buf[:0]
+			return nil
+		}
+		r.buf = append(r.buf[:0], r.buf[total:]...)
+	}
+	return nil
+}
+
+// headerBlock accumulates a header block split across a HEADERS frame and
+// CONTINUATION frames
+type headerBlock struct {
+	streamID  uint32
+	endStream bool
+	fragments []byte
+}
+
+// direction holds the decoding state of one side of the connection
+type direction struct {
+	frames frameReader
+	hpack  *hpack.Decoder
+	// block is the header block being received, if any
+	block *headerBlock
+}
+
+func newDirection() *direction {
+	d := &direction{
+		hpack: hpack.NewDecoder(defaultHeaderTableSize, nil),
+	}
+	d.hpack.SetMaxStringLength(maxHeaderStringSize)
+	return d
+}
+
+// Decoder extracts the requests and their responses from the traffic of a
+// cleartext HTTP/2 connection. The header blocks of both directions are fed
+// to HPACK decoders to keep their dynamic tables in sync, while the DATA
+// payloads are skipped. It is fed by the pcap replay: the eBPF HTTP/2
+// programs capture neither the content-type header nor the trailers.
+type Decoder struct {
+	connKey types.ConnectionKey
+
+	client *direction
+	server *direction
+
+	// prefaceSeen is true once the client connection preface was read
+	prefaceSeen bool
+	preface     []byte
+	// stopped is true once the decoder can't make sense of the traffic anymore
+	stopped bool
+
+	streams map[uint32]*Transaction
+}
+
+// NewDecoder returns a Decoder for the connection identified by connKey
+func NewDecoder(connKey types.ConnectionKey) *Decoder {
+	return &Decoder{
+		connKey: connKey,
+		client:  newDirection(),
+		server:  newDirection(),
+		streams: make(map[uint32]*Transaction),
+	}
+}
+
+// Feed decodes a segment of the connection payload. fromClient is true when
+// the segment was sent by the client, ts is the capture time of the segment in
+// nanoseconds. onTx is called for each completed transaction.
+func (d *Decoder) Feed(fromClient bool, data []byte, ts uint64, onTx func(*Transaction)) {
+	if d.stopped || len(data) == 0 {
+		return
+	}
+
+	var err error
+	if fromClient {
+		err = d.feedClient(data, ts, onTx)
+	} else {
+		err = d.server.frames.read(data, keepFrame, func(header frameHeader, payload []byte) error {
+			return d.handleFrame(d.server, false, header, payload, ts, onTx)
+		})
+	}
+	if err != nil {
+		d.stop()
+	}
+}
+
+// Stopped returns true if the decoder gave up on the connection, either
+// because it isn't an HTTP/2 connection or because its traffic couldn't be
+// parsed
+func (d *Decoder) Stopped() bool {
+	return d.stopped
+}
+
+func (d *Decoder) stop() {
+	d.stopped = true
+	d.client = nil
+	d.server = nil
+	d.streams = nil
+	d.preface = nil
+}
+
+func (d *Decoder) feedClient(data []byte, ts uint64, onTx func(*Transaction)) error {
+	if !d.prefaceSeen {
+		n := min(len(clientPreface)-len(d.preface), len(data))
+		d.preface = append(d.preface, data[:n]...)
+		if !strings.HasPrefix(clientPreface, string(d.preface)) {
+			// the connection doesn't start with the preface, which is the
+			// case of TLS connections and of connections upgraded from
+			// HTTP/1.1
+			return errMalformedFrame
+		}
+		if len(d.preface) < len(clientPreface) {
+			return nil
+		}
+		d.prefaceSeen = true
+		d.preface = nil
+		data = data[n:]
+	}
+
+	return d.client.frames.read(data, keepFrame, func(header frameHeader, payload []byte) error {
+		return d.handleFrame(d.client, true, header, payload, ts, onTx)
+	})
+}
+
+func keepFrame(header frameHeader) bool {
+	switch header.typ {
+	case headersFrame, continuationFrame, settingsFrame:
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) handleFrame(dir *direction, fromClient bool, header frameHeader, payload []byte, ts uint64, onTx func(*Transaction)) error {
+	if dir.block != nil && header.typ != continuationFrame {
+		// a header block must be followed by its CONTINUATION frames
+		return errMalformedFrame
+	}
+
+	switch header.typ {
+	case headersFrame:
+		fragment, err := headersFragment(header, payload)
+		if err != nil {
+			return err
+		}
+		dir.block = &headerBlock{
+			streamID:  header.streamID,
+			endStream: header.flags&flagEndStream != 0,
+		}
+		return d.addFragment(dir, fromClient, header, fragment, ts, onTx)
+	case continuationFrame:
+		if dir.block == nil || dir.block.streamID != header.streamID {
+			return errMalformedFrame
+		}
+		return d.addFragment(dir, fromClient, header, payload, ts, onTx)
+	case settingsFrame:
+		if header.flags&flagAck == 0 {
+			d.handleSettings(fromClient, payload)
+		}
+	case dataFrame:
+		if !fromClient && header.flags&flagEndStream != 0 {
+			d.complete(header.streamID, ts, false, onTx)
+		}
+	case rstStreamFrame:
+		d.complete(header.streamID, ts, true, onTx)
+	}
+	return nil
+}
+
+// headersFragment returns the header block fragment of a HEADERS frame,
+// without its padding and priority fields
+func headersFragment(header frameHeader, payload []byte) ([]byte, error) {
+	if header.flags&flagPadded != 0 {
+		if len(payload) < 1 || int(payload[0]) >= len(payload) {
+			return nil, errMalformedFrame
+		}
+		padding := int(payload[0])
+		payload = payload[1 : len(payload)-padding]
+	}
+	if header.flags&flagPriority != 0 {
+		if len(payload) < 5 {
+			return nil, errMalformedFrame
+		}
+		payload = payload[5:]
+	}
+	return payload, nil
+}
+
+func (d *Decoder) addFragment(dir *direction, fromClient bool, header frameHeader, fragment []byte, ts uint64, onTx func(*Transaction)) error {
+	block := dir.block
+	if len(block.fragments)+len(fragment) > maxHeaderBlockSize {
+		return errMalformedFrame
+	}
+	block.fragments = append(block.fragments, fragment...)
+	if header.flags&flagEndHeaders == 0 {
+		return nil
+	}
+	dir.block = nil
+
+	// every header block is decoded, as they all update the dynamic table
+	fields, err := dir.hpack.DecodeFull(block.fragments)
+	if err != nil {
+		return err
+	}
+	if fromClient {
+		d.handleRequestHeaders(block, fields, ts)
+	} else {
+		d.handleResponseHeaders(block, fields, ts, onTx)
+	}
+	return nil
+}
+
+// handleSettings applies the header table size advertised by a peer to the
+// decoder of the header blocks the other peer sends
+func (d *Decoder) handleSettings(fromClient bool, payload []byte) {
+	dir := d.server
+	if !fromClient {
+		dir = d.client
+	}
+	for ; len(payload) >= 6; payload = payload[6:] {
+		if binary.BigEndian.Uint16(payload) == settingHeaderTableSize {
+			dir.hpack.SetAllowedMaxDynamicTableSize(binary.BigEndian.Uint32(payload[2:6]))
+		}
+	}
+}
+
+func (d *Decoder) handleRequestHeaders(block *headerBlock, fields []hpack.HeaderField, ts uint64) {
+	if _, ok := d.streams[block.streamID]; ok {
+		// request trailers
+		return
+	}
+	if len(d.streams) >= maxPendingStreams {
+		return
+	}
+
+	tx := &Transaction{
+		ConnTuple:      d.connKey,
+		StreamID:       block.streamID,
+		GRPCStatus:     -1,
+		RequestStarted: ts,
+	}
+	for _, field := range fields {
+		switch field.Name {
+		case ":method":
+			tx.Method = field.Value
+		case ":path":
+			tx.Path = field.Value
+		case "content-type":
+			tx.ContentType = field.Value
+		}
+	}
+	d.streams[block.streamID] = tx
+}
+
+func (d *Decoder) handleResponseHeaders(block *headerBlock, fields []hpack.HeaderField, ts uint64, onTx func(*Transaction)) {
+	tx, ok := d.streams[block.streamID]
+	if !ok {
+		return
+	}
+
+	for _, field := range fields {
+		switch field.Name {
+		case ":status":
+			if code, err := strconv.ParseUint(field.Value, 10, 16); err == nil {
+				// informational responses are followed by the final one
+				if code >= 100 && code < 200 {
+					return
+				}
+				tx.StatusCode = uint16(code)
+			}
+		case "grpc-status":
+			if code, err := strconv.Atoi(field.Value); err == nil && code >= 0 {
+				tx.GRPCStatus = code
+			}
+		}
+	}
+
+	if block.endStream {
+		d.complete(block.streamID, ts, false, onTx)
+	}
+}
+
+func (d *Decoder) complete(streamID uint32, ts uint64, reset bool, onTx func(*Transaction)) {
+	tx, ok := d.streams[streamID]
+	if !ok {
+		return
+	}
+	delete(d.streams, streamID)
+
+	tx.Reset = reset
+	tx.ResponseLastSeen = ts
+	onTx(tx)
+}
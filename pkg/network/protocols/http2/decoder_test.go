@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http2
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// peer writes the frames sent by one side of a connection
+type peer struct {
+	t       *testing.T
+	buf     bytes.Buffer
+	framer  *http2.Framer
+	hbuf    bytes.Buffer
+	encoder *hpack.Encoder
+}
+
+func newPeer(t *testing.T) *peer {
+	p := &peer{t: t}
+	p.framer = http2.NewFramer(&p.buf, nil)
+	p.encoder = hpack.NewEncoder(&p.hbuf)
+	return p
+}
+
+func (p *peer) headerBlock(fields ...string) []byte {
+	p.hbuf.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		require.NoError(p.t, p.encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	return append([]byte(nil), p.hbuf.Bytes()...)
+}
+
+func (p *peer) headers(streamID uint32, endStream bool, fields ...string) *peer {
+	require.NoError(p.t, p.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: p.headerBlock(fields...),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	}))
+	return p
+}
+
+func (p *peer) data(streamID uint32, endStream bool, payload []byte) *peer {
+	require.NoError(p.t, p.framer.WriteData(streamID, endStream, payload))
+	return p
+}
+
+// bytes returns the frames written since the last call
+func (p *peer) bytes() []byte {
+	b := append([]byte(nil), p.buf.Bytes()...)
+	p.buf.Reset()
+	return b
+}
+
+type decoderTest struct {
+	decoder *Decoder
+	client  *peer
+	server  *peer
+	txs     []*Transaction
+}
+
+func newDecoderTest(t *testing.T) *decoderTest {
+	test := &decoderTest{
+		decoder: NewDecoder(types.ConnectionKey{}),
+		client:  newPeer(t),
+		server:  newPeer(t),
+	}
+	test.send(true, []byte(clientPreface), 0)
+	require.NoError(t, test.client.framer.WriteSettings())
+	require.NoError(t, test.server.framer.WriteSettings())
+	test.flush(0)
+	return test
+}
+
+func (d *decoderTest) send(fromClient bool, data []byte, ts uint64) {
+	d.decoder.Feed(fromClient, data, ts, func(tx *Transaction) {
+		d.txs = append(d.txs, tx)
+	})
+}
+
+func (d *decoderTest) flush(ts uint64) {
+	d.send(true, d.client.bytes(), ts)
+	d.send(false, d.server.bytes(), ts)
+}
+
+func grpcRequest(path string) []string {
+	return []string{":method", "POST", ":scheme", "http", ":path", path, ":authority", "localhost", "content-type", "application/grpc", "te", "trailers"}
+}
+
+func TestDecoderGRPC(t *testing.T) {
+	test := newDecoderTest(t)
+
+	// a unary call answered with trailers
+	test.client.headers(1, false, grpcRequest("/helloworld.Greeter/SayHello")...).data(1, true, []byte{0, 0, 0, 0, 2, 10, 0})
+	test.flush(1000)
+	test.server.headers(1, false, ":status", "200", "content-type", "application/grpc").data(1, false, []byte{0, 0, 0, 0, 0})
+	test.server.headers(1, true, "grpc-status", "0")
+	test.flush(3000)
+
+	// a failed call answered with a trailers-only response, the request
+	// headers being compressed with the dynamic table
+	test.client.headers(3, false, grpcRequest("/helloworld.Greeter/SayHello")...).data(3, true, nil)
+	test.flush(4000)
+	test.server.headers(3, true, ":status", "200", "content-type", "application/grpc", "grpc-status", "5", "grpc-message", "not found")
+	test.flush(5000)
+
+	// a call canceled by the client
+	test.client.headers(5, false, grpcRequest("/helloworld.Greeter/SayHelloStream")...)
+	test.flush(6000)
+	require.NoError(t, test.client.framer.WriteRSTStream(5, http2.ErrCodeCancel))
+	test.flush(9000)
+
+	require.Len(t, test.txs, 3)
+
+	assert.Equal(t, uint32(1), test.txs[0].StreamID)
+	assert.Equal(t, "POST", test.txs[0].Method)
+	assert.Equal(t, "/helloworld.Greeter/SayHello", test.txs[0].Path)
+	assert.True(t, test.txs[0].IsGRPC())
+	assert.Equal(t, uint16(200), test.txs[0].StatusCode)
+	assert.Equal(t, 0, test.txs[0].GRPCStatus)
+	assert.Equal(t, float64(2000), test.txs[0].RequestLatency())
+
+	assert.Equal(t, "/helloworld.Greeter/SayHello", test.txs[1].Path)
+	assert.Equal(t, 5, test.txs[1].GRPCStatus)
+	assert.Equal(t, float64(1000), test.txs[1].RequestLatency())
+
+	assert.Equal(t, "/helloworld.Greeter/SayHelloStream", test.txs[2].Path)
+	assert.True(t, test.txs[2].Reset)
+	assert.Equal(t, uint16(0), test.txs[2].StatusCode)
+	assert.Equal(t, -1, test.txs[2].GRPCStatus)
+	assert.Equal(t, float64(3000), test.txs[2].RequestLatency())
+
+	assert.False(t, test.decoder.Stopped())
+}
+
+func TestDecoderHTTP(t *testing.T) {
+	test := newDecoderTest(t)
+
+	test.client.headers(1, true, ":method", "GET", ":scheme", "http", ":path", "/index.html", ":authority", "localhost")
+	test.flush(1000)
+	test.server.headers(1, false, ":status", "103")
+	test.server.headers(1, false, ":status", "404", "content-type", "text/html")
+	// the DATA payloads aren't buffered
+	test.server.data(1, false, []byte(strings.Repeat("a", 16000))).data(1, true, []byte("b"))
+	data := test.server.bytes()
+	for i := 0; i < len(data); i += 1000 {
+		test.send(false, data[i:min(i+1000, len(data))], 2000)
+	}
+
+	require.Len(t, test.txs, 1)
+	assert.Equal(t, "GET", test.txs[0].Method)
+	assert.Equal(t, "/index.html", test.txs[0].Path)
+	assert.False(t, test.txs[0].IsGRPC())
+	assert.Equal(t, uint16(404), test.txs[0].StatusCode)
+	assert.Equal(t, -1, test.txs[0].GRPCStatus)
+	assert.Empty(t, test.decoder.server.frames.buf)
+}
+
+func TestDecoderContinuation(t *testing.T) {
+	test := newDecoderTest(t)
+
+	block := test.client.headerBlock(grpcRequest("/helloworld.Greeter/SayHello")...)
+	require.NoError(t, test.client.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: block[:10],
+		EndStream:     true,
+	}))
+	require.NoError(t, test.client.framer.WriteContinuation(1, true, block[10:]))
+	test.flush(1000)
+	test.server.headers(1, true, ":status", "200", "content-type", "application/grpc+proto", "grpc-status", "0")
+	test.flush(2000)
+
+	require.Len(t, test.txs, 1)
+	assert.Equal(t, "/helloworld.Greeter/SayHello", test.txs[0].Path)
+	assert.Equal(t, 0, test.txs[0].GRPCStatus)
+}
+
+func TestDecoderHeaderTableSize(t *testing.T) {
+	test := newDecoderTest(t)
+
+	// the server allows the client to use a larger dynamic table
+	require.NoError(t, test.server.framer.WriteSettings(http2.Setting{ID: http2.SettingHeaderTableSize, Val: 8192}))
+	test.flush(0)
+	test.client.encoder.SetMaxDynamicTableSizeLimit(8192)
+	test.client.encoder.SetMaxDynamicTableSize(8192)
+
+	test.client.headers(1, true, grpcRequest("/helloworld.Greeter/SayHello")...)
+	test.flush(1000)
+	test.server.headers(1, true, ":status", "200", "grpc-status", "0")
+	test.flush(2000)
+
+	require.Len(t, test.txs, 1)
+	assert.False(t, test.decoder.Stopped())
+}
+
+func TestDecoderNotHTTP2(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+	decoder.Feed(true, []byte("GET / HTTP/1.1\r\n\r\n"), 1, nil)
+	assert.True(t, decoder.Stopped())
+}
@@ -28,20 +28,16 @@ type protocol struct {
 }
 
 const (
-	eventStreamName                          = "kafka"
-	filterTailCall                           = "socket__kafka_filter"
-	dispatcherTailCall                       = "socket__protocol_dispatcher_kafka"
-	protocolDispatcherClassificationPrograms = "dispatcher_classification_progs"
-	kafkaHeapMap                             = "kafka_heap"
+	eventStreamName    = "kafka"
+	filterTailCall     = "socket__kafka_filter"
+	dispatcherTailCall = "socket__protocol_dispatcher_kafka"
+	kafkaHeapMap       = "kafka_heap"
 )
 
 // Spec is the protocol spec for the kafka protocol.
 var Spec = &protocols.ProtocolSpec{
 	Factory: newKafkaProtocol,
 	Maps: []*manager.Map{
-		{
-			Name: protocolDispatcherClassificationPrograms,
-		},
 		{
 			Name: kafkaHeapMap,
 		},
@@ -55,7 +51,7 @@ var Spec = &protocols.ProtocolSpec{
 			},
 		},
 		{
-			ProgArrayName: protocolDispatcherClassificationPrograms,
+			ProgArrayName: protocols.ProtocolDispatcherClassificationProgramsMap,
 			Key:           uint32(protocols.DispatcherKafkaProg),
 			ProbeIdentificationPair: manager.ProbeIdentificationPair{
 				EBPFFuncName: dispatcherTailCall,
@@ -0,0 +1,625 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// maxQuerySize is the number of bytes of a query kept by the decoder.
+	// Longer queries are truncated.
+	maxQuerySize = 4096
+	// maxResponseHeaderSize is the number of bytes of the server packets kept
+	// by the decoder, which is enough to read the status flags of OK and EOF
+	// packets.
+	maxResponseHeaderSize = 32
+	// maxPacketSize is the payload length of a packet followed by a
+	// continuation packet
+	maxPacketSize = 0xffffff
+	// maxPreparedStatements is the number of prepared statements tracked by
+	// the decoder on a connection.
+	maxPreparedStatements = 1024
+	// packetHeaderSize is the size of the length and sequence id of a packet
+	packetHeaderSize = 4
+)
+
+// Commands
+const (
+	comQuit        = 0x01
+	comQuery       = 0x03
+	comStmtPrepare = 0x16
+	comStmtExecute = 0x17
+	comStmtSend    = 0x18
+	comStmtClose   = 0x19
+)
+
+// Response packet headers
+const (
+	okPacket           = 0x00
+	eofPacket          = 0xfe
+	errPacket          = 0xff
+	localInfilePacket  = 0xfb
+	handshakeV10Packet = 0x0a
+	// eofMaxLength is the length under which a packet starting with 0xfe is an
+	// EOF packet rather than a row
+	eofMaxLength = 9
+)
+
+// Capability flags
+const (
+	clientSSL             = 0x00000800
+	clientQueryAttributes = 0x08000000
+	clientDeprecateEOF    = 0x01000000
+	// sslRequestLength is the payload length of an SSLRequest packet, which
+	// is a truncated HandshakeResponse41
+	sslRequestLength = 32
+)
+
+// Status flags
+const (
+	serverMoreResultsExist = 0x0008
+)
+
+var errMalformedPacket = errors.New("malformed mysql packet")
+
+// Transaction is a MySQL query and the response of the server
+type Transaction struct {
+	ConnTuple types.ConnectionKey
+	Query     string
+	// RequestStarted is the time the query was sent, in nanoseconds
+	RequestStarted uint64
+	// ResponseLastSeen is the time the last packet of the response was seen,
+	// in nanoseconds
+	ResponseLastSeen uint64
+	// IsError is true if the server answered with an ERR packet
+	IsError bool
+}
+
+// RequestLatency returns the latency of the query in nanoseconds
+func (tx *Transaction) RequestLatency() float64 {
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		return 0
+	}
+	return float64(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+// packetReader splits a stream of bytes into MySQL packets. Only the bytes of
+// the payloads requested by the caller are buffered, the rest is discarded as
+// it arrives.
+type packetReader struct {
+	buf     []byte
+	discard int
+	// continued is true when the next packet continues the payload of the
+	// previous one
+	continued bool
+}
+
+// read buffers data and calls handle for each packet which isn't the
+// continuation of a previous one. keep returns the number of bytes of the
+// payload to pass to handle.
+func (r *packetReader) read(data []byte, keep func(length int) int, handle func(seq byte, length int, payload []byte) error) error {
+	if r.discard > 0 {
+		n := r.discard
+		if n > len(data) {
+			n = len(data)
+		}
+		r.discard -= n
+		data = data[n:]
+	}
+	r.buf = append(r.buf, data...)
+
+	for len(r.buf) >= packetHeaderSize {
+		length := int(r.buf[0]) | int(r.buf[1])<<8 | int(r.buf[2])<<16
+		seq := r.buf[3]
+
+		continuation := r.continued
+		r.continued = length == maxPacketSize
+
+		kept := 0
+		if !continuation {
+			kept = keep(length)
+		}
+		if len(r.buf) < packetHeaderSize+kept {
+			// restore the state of the packet until it can be handled
+			r.continued = continuation
+			return nil
+		}
+		if !continuation {
+			if err := handle(seq, length, r.buf[packetHeaderSize:packetHeaderSize+kept]); err != nil {
+				return err
+			}
+		}
+
+		total := packetHeaderSize + length
+		if total > len(r.buf) {
+			r.discard = total - len(r.buf)
+			r.buf = r.buf[:0]
+			return nil
+		}
+		r.buf = append(r.buf[:0], r.buf[total:]...)
+	}
+	return nil
+}
+
+// skip accounts for n bytes of the stream which weren't captured. It returns
+// false if they don't all belong to the packet being read, in which case the
+// boundaries of the packets are lost. The skipped bytes are read as zeros.
+func (r *packetReader) skip(n int) bool {
+	if r.discard > 0 {
+		if n > r.discard {
+			return false
+		}
+		r.discard -= n
+		return true
+	}
+
+	if len(r.buf) < packetHeaderSize {
+		return false
+	}
+	length := int(r.buf[0]) | int(r.buf[1])<<8 | int(r.buf[2])<<16
+	if len(r.buf)+n > packetHeaderSize+length {
+		return false
+	}
+	r.buf = append(r.buf, make([]byte, n)...)
+	return true
+}
+
+// responseState is the part of a response the decoder is waiting for
+type responseState uint8
+
+const (
+	// stateIdle means no command waiting for a response
+	stateIdle responseState = iota
+	// stateResponse means the first packet of the response is expected
+	stateResponse
+	// stateColumns means column definitions are expected
+	stateColumns
+	// stateColumnsEOF means the EOF packet ending column definitions is
+	// expected
+	stateColumnsEOF
+	// stateRows means rows are expected, until an EOF or ERR packet
+	stateRows
+	// statePrepare means the parameter and column definitions of a
+	// COM_STMT_PREPARE response are expected
+	statePrepare
+)
+
+// Decoder extracts the queries and their responses from the traffic of a
+// MySQL connection. Text queries (COM_QUERY) and prepared statements
+// (COM_STMT_EXECUTE) are supported. Encrypted connections are ignored.
+type Decoder struct {
+	connKey types.ConnectionKey
+
+	client packetReader
+	server packetReader
+
+	// started is true once the first packet of the connection was seen
+	started bool
+	// handshakeDone is true once the connection is in the command phase
+	handshakeDone bool
+	// stopped is true once the decoder can't make sense of the traffic anymore
+	stopped bool
+	// clientLost and serverLost are true when bytes spanning several packets
+	// weren't captured, until the decoder finds the start of a packet again
+	clientLost   bool
+	serverLost   bool
+	capabilities uint32
+
+	statements map[uint32]string
+
+	state   responseState
+	command byte
+	tx      *Transaction
+	// prepareQuery is the query of the pending COM_STMT_PREPARE command
+	prepareQuery string
+	// remaining is the number of column definitions left to read, including
+	// the EOF packets ending them for COM_STMT_PREPARE responses
+	remaining int
+}
+
+// NewDecoder returns a Decoder for the connection identified by connKey
+func NewDecoder(connKey types.ConnectionKey) *Decoder {
+	return &Decoder{
+		connKey:    connKey,
+		statements: make(map[uint32]string),
+	}
+}
+
+// Feed decodes a segment of the connection payload. fromClient is true when
+// the segment was sent by the client, ts is the capture time of the segment in
+// nanoseconds. onTx is called for each completed transaction.
+func (d *Decoder) Feed(fromClient bool, data []byte, ts uint64, onTx func(*Transaction)) {
+	if d.stopped || len(data) == 0 {
+		return
+	}
+
+	if fromClient && d.clientLost {
+		if !isCommandStart(data) {
+			return
+		}
+		d.clientLost = false
+	}
+	if !fromClient && d.serverLost {
+		i := lastResponseEnd(data)
+		if i < 0 {
+			return
+		}
+		d.serverLost = false
+		if d.state != stateIdle {
+			// the rows weren't fully captured, the response ends with the
+			// packet found at the end of the data
+			d.state = stateRows
+		}
+		data = data[i:]
+	}
+
+	var err error
+	if fromClient {
+		err = d.client.read(data,
+			func(length int) int { return min(length, maxQuerySize) },
+			func(seq byte, _ int, payload []byte) error {
+				return d.handleClient(seq, payload, ts)
+			},
+		)
+	} else {
+		err = d.server.read(data,
+			func(length int) int { return min(length, maxResponseHeaderSize) },
+			func(seq byte, length int, payload []byte) error {
+				return d.handleServer(seq, length, payload, ts, onTx)
+			},
+		)
+	}
+	if err != nil {
+		d.stop()
+	}
+}
+
+// Skip accounts for n bytes of the connection payload which weren't captured.
+// When they span several packets, the decoder waits for the next command sent
+// by the client, and for the EOF, OK or ERR packet ending a response sent by
+// the server.
+func (d *Decoder) Skip(fromClient bool, n int) {
+	if d.stopped || n <= 0 {
+		return
+	}
+
+	if !d.started {
+		d.started = true
+		d.handshakeDone = true
+	}
+	if fromClient {
+		if !d.client.skip(n) {
+			d.clientLost = true
+			d.client = packetReader{}
+		}
+		return
+	}
+	if !d.server.skip(n) {
+		d.serverLost = true
+		d.server = packetReader{}
+	}
+}
+
+// Stopped returns true if the decoder gave up on the connection, either
+// because it is encrypted or because its traffic couldn't be parsed
+func (d *Decoder) Stopped() bool {
+	return d.stopped
+}
+
+func (d *Decoder) stop() {
+	d.stopped = true
+	d.statements = nil
+	d.tx = nil
+	d.client = packetReader{}
+	d.server = packetReader{}
+}
+
+func (d *Decoder) handleClient(seq byte, payload []byte, ts uint64) error {
+	if !d.started {
+		// When the decoder is created in the middle of a connection the
+		// handshake is already over.
+		d.started = true
+		d.handshakeDone = true
+	}
+
+	if !d.handshakeDone {
+		if seq != 1 {
+			// authentication data
+			return nil
+		}
+		if len(payload) < 4 {
+			return errMalformedPacket
+		}
+		d.capabilities = binary.LittleEndian.Uint32(payload)
+		if d.capabilities&clientSSL != 0 && len(payload) == sslRequestLength {
+			// the rest of the connection is encrypted
+			return errMalformedPacket
+		}
+		return nil
+	}
+
+	if seq != 0 || len(payload) == 0 {
+		// not a command, for instance the data of a LOAD DATA LOCAL INFILE
+		return nil
+	}
+
+	d.command = payload[0]
+	d.tx = nil
+	d.state = stateResponse
+	switch d.command {
+	case comQuery:
+		d.tx = d.newTransaction(d.readQuery(payload[1:]), ts)
+	case comStmtPrepare:
+		d.prepareQuery = queryText(payload[1:])
+	case comStmtExecute:
+		if len(payload) < 5 {
+			return errMalformedPacket
+		}
+		d.tx = d.newTransaction(d.statements[binary.LittleEndian.Uint32(payload[1:])], ts)
+	case comStmtClose:
+		if len(payload) >= 5 {
+			delete(d.statements, binary.LittleEndian.Uint32(payload[1:]))
+		}
+		d.state = stateIdle
+	case comQuit, comStmtSend:
+		d.state = stateIdle
+	}
+	return nil
+}
+
+// readQuery returns the text of a COM_QUERY command. When query attributes
+// are enabled, the query is prefixed by its attributes, which are skipped.
+func (d *Decoder) readQuery(b []byte) string {
+	if d.capabilities&clientQueryAttributes == 0 {
+		return queryText(b)
+	}
+
+	paramCount, n := readLengthEncodedInt(b)
+	if n == 0 {
+		return ""
+	}
+	b = b[n:]
+	// parameter_set_count, which is always 1
+	_, n = readLengthEncodedInt(b)
+	if n == 0 || paramCount != 0 {
+		// the attributes values aren't parsed
+		return ""
+	}
+	return queryText(b[n:])
+}
+
+// queryText returns the text of a query, up to the bytes which weren't
+// captured and were read as zeros
+func queryText(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func (d *Decoder) newTransaction(query string, ts uint64) *Transaction {
+	return &Transaction{
+		ConnTuple:      d.connKey,
+		Query:          query,
+		RequestStarted: ts,
+	}
+}
+
+func (d *Decoder) handleServer(seq byte, length int, payload []byte, ts uint64, onTx func(*Transaction)) error {
+	if !d.started {
+		d.started = true
+		d.handshakeDone = !(seq == 0 && len(payload) > 0 && payload[0] == handshakeV10Packet)
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+
+	if !d.handshakeDone {
+		switch payload[0] {
+		case okPacket:
+			d.handshakeDone = true
+		case errPacket:
+			return errMalformedPacket
+		}
+		return nil
+	}
+
+	header := payload[0]
+	isEOF := header == eofPacket && length < eofMaxLength
+	switch d.state {
+	case stateIdle:
+	case stateResponse:
+		switch {
+		case header == errPacket:
+			d.complete(ts, true, onTx)
+		case header == okPacket && d.command == comStmtPrepare:
+			d.handlePrepareOK(payload)
+		case header == okPacket || isEOF:
+			d.completeOrContinue(ts, statusFlags(header, payload), onTx)
+		case header == localInfilePacket:
+			// the client sends the file and the server answers with OK or ERR
+		default:
+			columns, n := readLengthEncodedInt(payload)
+			if n == 0 || columns == 0 {
+				return errMalformedPacket
+			}
+			d.remaining = int(columns)
+			d.state = stateColumns
+		}
+	case stateColumns:
+		d.remaining--
+		if d.remaining > 0 {
+			break
+		}
+		if d.capabilities&clientDeprecateEOF != 0 {
+			d.state = stateRows
+		} else {
+			d.state = stateColumnsEOF
+		}
+	case stateColumnsEOF:
+		if header == errPacket {
+			d.complete(ts, true, onTx)
+			break
+		}
+		d.state = stateRows
+	case stateRows:
+		switch {
+		case header == errPacket:
+			d.complete(ts, true, onTx)
+		case isEOF || (header == eofPacket && length < maxPacketSize && d.capabilities&clientDeprecateEOF != 0):
+			d.completeOrContinue(ts, statusFlags(header, payload), onTx)
+		}
+	case statePrepare:
+		d.remaining--
+		if d.remaining <= 0 {
+			d.state = stateIdle
+		}
+	}
+	return nil
+}
+
+// handlePrepareOK records the statement of a COM_STMT_PREPARE_OK packet, and
+// counts the definitions which follow it
+func (d *Decoder) handlePrepareOK(payload []byte) {
+	d.state = stateIdle
+	if len(payload) < 9 {
+		return
+	}
+
+	id := binary.LittleEndian.Uint32(payload[1:])
+	columns := int(binary.LittleEndian.Uint16(payload[5:]))
+	params := int(binary.LittleEndian.Uint16(payload[7:]))
+	if _, ok := d.statements[id]; ok || len(d.statements) < maxPreparedStatements {
+		d.statements[id] = d.prepareQuery
+	}
+	d.prepareQuery = ""
+
+	d.remaining = columns + params
+	if d.capabilities&clientDeprecateEOF == 0 {
+		if columns > 0 {
+			d.remaining++
+		}
+		if params > 0 {
+			d.remaining++
+		}
+	}
+	if d.remaining > 0 {
+		d.state = statePrepare
+	}
+}
+
+// completeOrContinue completes the transaction, unless the server announced
+// more result sets in the status flags of its OK or EOF packet
+func (d *Decoder) completeOrContinue(ts uint64, status uint16, onTx func(*Transaction)) {
+	if status&serverMoreResultsExist != 0 {
+		d.state = stateResponse
+		return
+	}
+	d.complete(ts, false, onTx)
+}
+
+func (d *Decoder) complete(ts uint64, isError bool, onTx func(*Transaction)) {
+	d.state = stateIdle
+	tx := d.tx
+	d.tx = nil
+	if tx == nil {
+		return
+	}
+
+	tx.ResponseLastSeen = ts
+	tx.IsError = isError
+	onTx(tx)
+}
+
+// statusFlags returns the status flags of an OK or EOF packet, or 0 if they
+// can't be read
+func statusFlags(header byte, payload []byte) uint16 {
+	b := payload[1:]
+	if header == eofPacket && len(payload) < eofMaxLength {
+		// warnings, then status flags
+		if len(b) < 4 {
+			return 0
+		}
+		return binary.LittleEndian.Uint16(b[2:])
+	}
+
+	// affected rows and last insert id, then status flags
+	for i := 0; i < 2; i++ {
+		_, n := readLengthEncodedInt(b)
+		if n == 0 {
+			return 0
+		}
+		b = b[n:]
+	}
+	if len(b) < 2 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+// isCommandStart returns true if data starts with the packet of a command
+// tracked by the decoder
+func isCommandStart(data []byte) bool {
+	if len(data) < packetHeaderSize+1 || data[3] != 0 {
+		return false
+	}
+	switch data[packetHeaderSize] {
+	case comQuit, comQuery, comStmtPrepare, comStmtExecute, comStmtSend, comStmtClose:
+		return true
+	}
+	return false
+}
+
+// lastResponseEnd returns the offset of the EOF, OK or ERR packet ending data,
+// or -1 if data doesn't end with one
+func lastResponseEnd(data []byte) int {
+	for i := len(data) - packetHeaderSize - 1; i >= 0; i-- {
+		length := int(data[i]) | int(data[i+1])<<8 | int(data[i+2])<<16
+		if i+packetHeaderSize+length != len(data) {
+			continue
+		}
+		switch data[i+packetHeaderSize] {
+		case eofPacket, errPacket:
+			return i
+		}
+	}
+	return -1
+}
+
+// readLengthEncodedInt reads a length-encoded integer, and returns it along
+// with the number of bytes read, or 0 if it couldn't be read
+func readLengthEncodedInt(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+
+	switch b[0] {
+	case 0xfc:
+		if len(b) < 3 {
+			return 0, 0
+		}
+		return uint64(binary.LittleEndian.Uint16(b[1:])), 3
+	case 0xfd:
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return uint64(b[1]) | uint64(b[2])<<8 | uint64(b[3])<<16, 4
+	case 0xfe:
+		if len(b) < 9 {
+			return 0, 0
+		}
+		return binary.LittleEndian.Uint64(b[1:]), 9
+	case 0xfb, 0xff:
+		return 0, 0
+	default:
+		return uint64(b[0]), 1
+	}
+}
@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func decodePCAP(t *testing.T, path string) []*Transaction {
+	segments := testutil.ReadPCAP(t, path, 3306)
+	require.NotEmpty(t, segments)
+
+	var txs []*Transaction
+	decoder := NewDecoder(segments[0].ConnectionKey)
+	for _, segment := range segments {
+		decoder.Feed(segment.FromClient, segment.Payload, uint64(segment.Timestamp.UnixNano()), func(tx *Transaction) {
+			txs = append(txs, tx)
+		})
+	}
+	require.False(t, decoder.Stopped())
+	return txs
+}
+
+func TestDecoder(t *testing.T) {
+	txs := decodePCAP(t, "testdata/queries.pcap")
+
+	expected := []struct {
+		query   string
+		latency time.Duration
+		isError bool
+	}{
+		{query: "CREATE TABLE dummy (id int)", latency: 10 * time.Millisecond},
+		{query: "INSERT INTO dummy VALUES (1)", latency: 2 * time.Millisecond},
+		{query: "SELECT * FROM dummy WHERE id = 1", latency: 3 * time.Millisecond},
+		{query: "SELECT * FROM missing", latency: time.Millisecond, isError: true},
+		{query: "CALL two_results()", latency: 4 * time.Millisecond},
+		{query: "SELECT * FROM dummy WHERE id = ?", latency: 4 * time.Millisecond},
+		{query: "DROP TABLE dummy", latency: 5 * time.Millisecond},
+	}
+	require.Len(t, txs, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, e.query, txs[i].Query)
+		assert.Equal(t, e.isError, txs[i].IsError)
+		assert.InDelta(t, float64(e.latency), txs[i].RequestLatency(), float64(10*time.Microsecond), e.query)
+	}
+}
+
+func TestDecoderDeprecateEOF(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	decoder.started, decoder.handshakeDone = true, true
+	decoder.capabilities = clientDeprecateEOF
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	decoder.Feed(true, []byte{9, 0, 0, 0, comQuery, 'S', 'E', 'L', 'E', 'C', 'T', ' ', '1'}, 1, onTx)
+	// column count, column definition, row, and OK packet with a 0xfe header
+	decoder.Feed(false, []byte{1, 0, 0, 1, 1}, 2, onTx)
+	decoder.Feed(false, []byte{3, 0, 0, 2, 'd', 'e', 'f'}, 3, onTx)
+	decoder.Feed(false, []byte{2, 0, 0, 3, 1, '1'}, 4, onTx)
+	assert.Empty(t, txs)
+	decoder.Feed(false, []byte{7, 0, 0, 4, eofPacket, 0, 0, 2, 0, 0, 0}, 5, onTx)
+
+	require.Len(t, txs, 1)
+	assert.Equal(t, "SELECT 1", txs[0].Query)
+	assert.Equal(t, float64(4), txs[0].RequestLatency())
+}
+
+func TestDecoderEncrypted(t *testing.T) {
+	segments := testutil.ReadPCAP(t, "testdata/queries.pcap", 3306)
+
+	decoder := NewDecoder(segments[0].ConnectionKey)
+	decoder.Feed(false, segments[0].Payload, 1, nil)
+	// SSLRequest
+	request := make([]byte, 4+sslRequestLength)
+	request[0], request[3], request[5] = sslRequestLength, 1, clientSSL>>8
+	decoder.Feed(true, request, 2, nil)
+	assert.True(t, decoder.Stopped())
+}
+
+func TestDecoderLargePacket(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	decoder.started, decoder.handshakeDone = true, true
+
+	// a query split in two packets, the first one being of the maximum size
+	query := make([]byte, maxPacketSize+10)
+	query[0] = comQuery
+	for i := 1; i < len(query); i++ {
+		query[i] = 'a'
+	}
+	packets := append([]byte{0xff, 0xff, 0xff, 0}, query[:maxPacketSize]...)
+	packets = append(packets, 10, 0, 0, 1)
+	packets = append(packets, query[maxPacketSize:]...)
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	for i := 0; i < len(packets); i += 65536 {
+		decoder.Feed(true, packets[i:min(i+65536, len(packets))], 1, onTx)
+	}
+	decoder.Feed(false, []byte{7, 0, 0, 1, okPacket, 0, 0, 2, 0, 0, 0}, 2, onTx)
+
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Query, maxQuerySize-1)
+	assert.Empty(t, decoder.client.buf)
+	assert.False(t, decoder.client.continued)
+}
+
+func TestDecoderSkip(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	decoder.started, decoder.handshakeDone = true, true
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+
+	// the middle of a query wasn't captured, the query is truncated
+	query := make([]byte, 1000)
+	query[0] = comQuery
+	for i := 1; i < len(query); i++ {
+		query[i] = 'a'
+	}
+	packet := append([]byte{0xe8, 0x03, 0, 0}, query...)
+	decoder.Feed(true, packet[:320], 1, onTx)
+	decoder.Skip(true, len(packet)-320-128)
+	decoder.Feed(true, packet[len(packet)-128:], 1, onTx)
+	decoder.Feed(false, []byte{7, 0, 0, 1, okPacket, 0, 0, 2, 0, 0, 0}, 2, onTx)
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Query, 315)
+	assert.False(t, decoder.clientLost)
+
+	// the rows of the response weren't captured, the server stream is found
+	// again with the EOF packet ending the response
+	decoder.Feed(true, []byte{9, 0, 0, 0, comQuery, 'S', 'E', 'L', 'E', 'C', 'T', ' ', '1'}, 3, onTx)
+	decoder.Feed(false, []byte{1, 0, 0, 1, 1}, 4, onTx)
+	decoder.Feed(false, []byte{3, 0, 0, 2, 'd', 'e', 'f'}, 4, onTx)
+	decoder.Feed(false, []byte{3, 0, 0, 3, 2, '1'}, 4, onTx)
+	decoder.Skip(false, 10000)
+	assert.True(t, decoder.serverLost)
+	decoder.Feed(false, []byte{'1', '1', 2, 0, 0, 9, 1, '1'}, 5, onTx)
+	assert.Len(t, txs, 1)
+	decoder.Feed(false, []byte{2, 0, 0, 9, 1, '1', 5, 0, 0, 10, eofPacket, 0, 0, 2, 0}, 6, onTx)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "SELECT 1", txs[1].Query)
+	assert.Equal(t, float64(3), txs[1].RequestLatency())
+	assert.False(t, txs[1].IsError)
+
+	// the client stream is found again with the next command
+	decoder.Skip(true, 10000)
+	assert.True(t, decoder.clientLost)
+	decoder.Feed(true, []byte{'a', 'a', 'a', 'a', 'a'}, 7, onTx)
+	decoder.Feed(true, []byte{9, 0, 0, 0, comQuery, 'S', 'E', 'L', 'E', 'C', 'T', ' ', '2'}, 8, onTx)
+	decoder.Feed(false, []byte{7, 0, 0, 1, errPacket, 0, 0, 2, 0, 0, 0}, 9, onTx)
+	require.Len(t, txs, 3)
+	assert.Equal(t, "SELECT 2", txs[2].Query)
+	assert.True(t, txs[2].IsError)
+	assert.False(t, decoder.Stopped())
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Telemetry is a struct to hold the telemetry for the mysql protocol
+type Telemetry struct {
+	metricGroup *libtelemetry.MetricGroup
+
+	queries         *libtelemetry.Counter
+	errors          *libtelemetry.Counter // queries answered with an ERR packet
+	dropped         *libtelemetry.Counter // this happens when StatKeeper reaches capacity
+	invalidQueries  *libtelemetry.Counter // this happens when a query can't be normalized
+	negativeLatency *libtelemetry.Counter // this happens when the response was captured before the query
+}
+
+// NewTelemetry creates a new Telemetry
+func NewTelemetry() *Telemetry {
+	metricGroup := libtelemetry.NewMetricGroup("usm.mysql")
+
+	return &Telemetry{
+		metricGroup:     metricGroup,
+		queries:         metricGroup.NewCounter("total_hits", libtelemetry.OptStatsd),
+		errors:          metricGroup.NewCounter("errors", libtelemetry.OptStatsd),
+		dropped:         metricGroup.NewCounter("dropped", libtelemetry.OptStatsd),
+		invalidQueries:  metricGroup.NewCounter("invalid_queries", libtelemetry.OptStatsd),
+		negativeLatency: metricGroup.NewCounter("negative_latency"),
+	}
+}
+
+// Log logs the mysql stats summary
+func (t *Telemetry) Log() {
+	log.Debugf("mysql stats summary: %s", t.metricGroup.Summary())
+}
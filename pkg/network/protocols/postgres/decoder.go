@@ -0,0 +1,480 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// maxQuerySize is the number of bytes of a query kept by the decoder.
+	// Longer queries are truncated.
+	maxQuerySize = 4096
+	// maxMessageSize is the largest message length accepted by the decoder,
+	// which matches the largest message accepted by Postgres.
+	maxMessageSize = 1 << 30
+	// maxPendingTransactions is the number of queries waiting for a response
+	// tracked by the decoder on a connection.
+	maxPendingTransactions = 64
+	// maxPreparedStatements is the number of prepared statements and portals
+	// tracked by the decoder on a connection.
+	maxPreparedStatements = 1024
+
+	// startup codes of the untyped messages sent by the client at the start of
+	// a connection
+	protocolVersion3   = 196608
+	cancelRequestCode  = 80877102
+	sslRequestCode     = 80877103
+	gssencRequestCode  = 80877104
+	startupMessageSize = 8
+)
+
+// Client messages
+const (
+	queryMessage     = 'Q'
+	parseMessage     = 'P'
+	bindMessage      = 'B'
+	executeMessage   = 'E'
+	closeMessage     = 'C'
+	terminateMessage = 'X'
+)
+
+// Server messages
+const (
+	commandCompleteMessage = 'C'
+	emptyQueryMessage      = 'I'
+	portalSuspendedMessage = 's'
+	errorResponseMessage   = 'E'
+	readyForQueryMessage   = 'Z'
+)
+
+var errMalformedMessage = errors.New("malformed postgres message")
+
+// Transaction is a Postgres query and the response of the server
+type Transaction struct {
+	ConnTuple types.ConnectionKey
+	Query     string
+	// RequestStarted is the time the query was sent, in nanoseconds
+	RequestStarted uint64
+	// ResponseLastSeen is the time the query was completed, in nanoseconds
+	ResponseLastSeen uint64
+	// IsError is true if the server answered with an ErrorResponse
+	IsError bool
+}
+
+// RequestLatency returns the latency of the query in nanoseconds
+func (tx *Transaction) RequestLatency() float64 {
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		return 0
+	}
+	return float64(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+// messageReader splits a stream of bytes into Postgres messages. Only the
+// bytes of the message bodies requested by the caller are buffered, the rest
+// is discarded as it arrives.
+type messageReader struct {
+	buf     []byte
+	discard int
+}
+
+// read buffers data and calls handle for each complete message. untyped
+// reports whether the next message has no type byte, which is the case for
+// the messages sent by the client during the startup phase. keep returns the
+// number of bytes of the body of a message to pass to handle.
+func (r *messageReader) read(data []byte, untyped func() bool, keep func(typ byte, bodyLen int) int, handle func(typ byte, body []byte) error) error {
+	if r.discard > 0 {
+		n := r.discard
+		if n > len(data) {
+			n = len(data)
+		}
+		r.discard -= n
+		data = data[n:]
+	}
+	r.buf = append(r.buf, data...)
+
+	for {
+		typeLen := 1
+		if untyped() {
+			typeLen = 0
+		}
+		headerLen := typeLen + 4
+		if len(r.buf) < headerLen {
+			return nil
+		}
+
+		var typ byte
+		if typeLen == 1 {
+			typ = r.buf[0]
+		}
+		length := int(binary.BigEndian.Uint32(r.buf[typeLen:headerLen]))
+		if length < 4 || length > maxMessageSize {
+			return errMalformedMessage
+		}
+
+		bodyLen := length - 4
+		kept := keep(typ, bodyLen)
+		if len(r.buf) < headerLen+kept {
+			return nil
+		}
+		if err := handle(typ, r.buf[headerLen:headerLen+kept]); err != nil {
+			return err
+		}
+
+		total := typeLen + length
+		if total > len(r.buf) {
+			r.discard = total - len(r.buf)
+			r.buf = r.buf[:0]
+			return nil
+		}
+		r.buf = append(r.buf[:0], r.buf[total:]...)
+	}
+}
+
+// skip accounts for n bytes of the stream which weren't captured. It returns
+// false if they don't all belong to the message being read, in which case the
+// boundaries of the messages are lost. The skipped bytes are read as zeros.
+func (r *messageReader) skip(n int, typeLen int) bool {
+	if r.discard > 0 {
+		if n > r.discard {
+			return false
+		}
+		r.discard -= n
+		return true
+	}
+
+	headerLen := typeLen + 4
+	if len(r.buf) < headerLen {
+		return false
+	}
+	total := typeLen + int(binary.BigEndian.Uint32(r.buf[typeLen:headerLen]))
+	if len(r.buf)+n > total {
+		return false
+	}
+	r.buf = append(r.buf, make([]byte, n)...)
+	return true
+}
+
+// Decoder extracts the queries and their responses from the traffic of a
+// Postgres connection. Both the simple and the extended query protocols are
+// supported. Encrypted connections are ignored.
+type Decoder struct {
+	connKey types.ConnectionKey
+
+	client messageReader
+	server messageReader
+
+	// started is true once the first bytes sent by the client were seen
+	started bool
+	// startupDone is true once the client is done with the untyped startup
+	// messages
+	startupDone bool
+	// encryptionRequested is true when the server is about to answer an
+	// SSLRequest or a GSSENCRequest with a single byte
+	encryptionRequested bool
+	// stopped is true once the decoder can't make sense of the traffic anymore
+	stopped bool
+	// clientLost and serverLost are true when bytes spanning several messages
+	// weren't captured, until the decoder finds the start of a message again
+	clientLost bool
+	serverLost bool
+
+	statements map[string]string
+	portals    map[string]string
+	pending    []*Transaction
+}
+
+// NewDecoder returns a Decoder for the connection identified by connKey
+func NewDecoder(connKey types.ConnectionKey) *Decoder {
+	return &Decoder{
+		connKey:    connKey,
+		statements: make(map[string]string),
+		portals:    make(map[string]string),
+	}
+}
+
+// Feed decodes a segment of the connection payload. fromClient is true when
+// the segment was sent by the client, ts is the capture time of the segment in
+// nanoseconds. onTx is called for each completed transaction.
+func (d *Decoder) Feed(fromClient bool, data []byte, ts uint64, onTx func(*Transaction)) {
+	if d.stopped || len(data) == 0 {
+		return
+	}
+
+	var err error
+	if fromClient {
+		err = d.feedClient(data, ts)
+	} else {
+		err = d.feedServer(data, ts, onTx)
+	}
+	if err != nil {
+		d.stop()
+	}
+}
+
+// Skip accounts for n bytes of the connection payload which weren't captured.
+// When they span several messages, the decoder waits for the next query sent
+// by the client, and for the next ReadyForQuery message sent by the server.
+func (d *Decoder) Skip(fromClient bool, n int) {
+	if d.stopped || n <= 0 {
+		return
+	}
+
+	if fromClient {
+		typeLen := 1
+		if d.started && !d.startupDone {
+			typeLen = 0
+		}
+		if !d.started || !d.client.skip(n, typeLen) {
+			// the connection can't be in its startup phase anymore
+			d.started = true
+			d.startupDone = true
+			d.clientLost = true
+			d.client = messageReader{}
+		}
+		return
+	}
+
+	if d.encryptionRequested {
+		d.stop()
+		return
+	}
+	if !d.server.skip(n, 1) {
+		d.serverLost = true
+		d.server = messageReader{}
+	}
+}
+
+// Stopped returns true if the decoder gave up on the connection, either
+// because it is encrypted or because its traffic couldn't be parsed
+func (d *Decoder) Stopped() bool {
+	return d.stopped
+}
+
+func (d *Decoder) stop() {
+	d.stopped = true
+	d.pending = nil
+	d.statements = nil
+	d.portals = nil
+	d.client = messageReader{}
+	d.server = messageReader{}
+}
+
+func (d *Decoder) feedClient(data []byte, ts uint64) error {
+	if d.clientLost {
+		if !isQueryStart(data) {
+			return nil
+		}
+		d.clientLost = false
+	}
+
+	if !d.started {
+		if len(d.client.buf)+len(data) < startupMessageSize {
+			d.client.buf = append(d.client.buf, data...)
+			return nil
+		}
+		d.started = true
+		// When the decoder is created in the middle of a connection the
+		// startup phase is already over.
+		data = append(d.client.buf, data...)
+		d.client.buf = nil
+		d.startupDone = !isStartupCode(binary.BigEndian.Uint32(data[4:8]))
+	}
+
+	return d.client.read(data,
+		func() bool { return !d.startupDone },
+		func(typ byte, bodyLen int) int {
+			if !d.startupDone {
+				return min(bodyLen, 4)
+			}
+			switch typ {
+			case queryMessage, parseMessage, bindMessage, executeMessage, closeMessage:
+				return min(bodyLen, maxQuerySize)
+			}
+			return 0
+		},
+		func(typ byte, body []byte) error {
+			if !d.startupDone {
+				return d.handleStartup(body)
+			}
+			d.handleClient(typ, body, ts)
+			return nil
+		},
+	)
+}
+
+// isQueryStart returns true if data starts with the header of a Query or a
+// Parse message
+func isQueryStart(data []byte) bool {
+	if len(data) < 5 || (data[0] != queryMessage && data[0] != parseMessage) {
+		return false
+	}
+	length := binary.BigEndian.Uint32(data[1:5])
+	return length >= 4 && length <= maxMessageSize
+}
+
+func isStartupCode(code uint32) bool {
+	switch code {
+	case protocolVersion3, cancelRequestCode, sslRequestCode, gssencRequestCode:
+		return true
+	}
+	return false
+}
+
+func (d *Decoder) handleStartup(body []byte) error {
+	if len(body) < 4 {
+		return errMalformedMessage
+	}
+
+	switch binary.BigEndian.Uint32(body) {
+	case sslRequestCode, gssencRequestCode:
+		d.encryptionRequested = true
+	case protocolVersion3:
+		d.startupDone = true
+	default:
+		// CancelRequest messages are sent on their own connection
+		return errMalformedMessage
+	}
+	return nil
+}
+
+func (d *Decoder) handleClient(typ byte, body []byte, ts uint64) {
+	switch typ {
+	case queryMessage:
+		query, _ := readString(body)
+		d.addPending(query, ts)
+	case parseMessage:
+		name, rest := readString(body)
+		query, _ := readString(rest)
+		if _, ok := d.statements[name]; ok || len(d.statements) < maxPreparedStatements {
+			d.statements[name] = query
+		}
+	case bindMessage:
+		portal, rest := readString(body)
+		statement, _ := readString(rest)
+		if _, ok := d.portals[portal]; ok || len(d.portals) < maxPreparedStatements {
+			d.portals[portal] = d.statements[statement]
+		}
+	case executeMessage:
+		portal, _ := readString(body)
+		d.addPending(d.portals[portal], ts)
+	case closeMessage:
+		if len(body) == 0 {
+			return
+		}
+		name, _ := readString(body[1:])
+		switch body[0] {
+		case 'S':
+			delete(d.statements, name)
+		case 'P':
+			delete(d.portals, name)
+		}
+	case terminateMessage:
+		d.pending = nil
+	}
+}
+
+func (d *Decoder) addPending(query string, ts uint64) {
+	if len(d.pending) >= maxPendingTransactions {
+		return
+	}
+	d.pending = append(d.pending, &Transaction{
+		ConnTuple:      d.connKey,
+		Query:          query,
+		RequestStarted: ts,
+	})
+}
+
+func (d *Decoder) feedServer(data []byte, ts uint64, onTx func(*Transaction)) error {
+	if d.serverLost {
+		if !endsWithReadyForQuery(data) {
+			return nil
+		}
+		// the server is done with the pending queries. Their responses
+		// weren't fully captured, so they are reported as successful.
+		d.serverLost = false
+		for len(d.pending) > 0 {
+			d.complete(ts, false, onTx)
+		}
+		return nil
+	}
+
+	if d.encryptionRequested {
+		d.encryptionRequested = false
+		if data[0] != 'N' {
+			// the rest of the connection is encrypted
+			return errMalformedMessage
+		}
+		data = data[1:]
+	}
+
+	return d.server.read(data,
+		func() bool { return false },
+		func(byte, int) int { return 0 },
+		func(typ byte, _ []byte) error {
+			d.handleServer(typ, ts, onTx)
+			return nil
+		},
+	)
+}
+
+func (d *Decoder) handleServer(typ byte, ts uint64, onTx func(*Transaction)) {
+	switch typ {
+	case commandCompleteMessage, emptyQueryMessage, portalSuspendedMessage:
+		d.complete(ts, false, onTx)
+	case errorResponseMessage:
+		d.complete(ts, true, onTx)
+	case readyForQueryMessage:
+		// the server skips the remaining messages of an extended query batch
+		// after an error
+		d.pending = d.pending[:0]
+	}
+}
+
+func (d *Decoder) complete(ts uint64, isError bool, onTx func(*Transaction)) {
+	if len(d.pending) == 0 {
+		return
+	}
+
+	tx := d.pending[0]
+	d.pending = d.pending[1:]
+	tx.ResponseLastSeen = ts
+	tx.IsError = isError
+	onTx(tx)
+}
+
+// endsWithReadyForQuery returns true if data ends with a ReadyForQuery
+// message, which the server sends once it is done with the queries it got
+func endsWithReadyForQuery(data []byte) bool {
+	const readyForQuerySize = 6
+	if len(data) < readyForQuerySize {
+		return false
+	}
+
+	msg := data[len(data)-readyForQuerySize:]
+	if msg[0] != readyForQueryMessage || binary.BigEndian.Uint32(msg[1:5]) != 5 {
+		return false
+	}
+	switch msg[5] {
+	case 'I', 'T', 'E':
+		return true
+	}
+	return false
+}
+
+// readString reads a null-terminated string, and returns it along with the
+// remaining bytes. A truncated string is returned as is.
+func readString(b []byte) (string, []byte) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return string(b), nil
+	}
+	return string(b[:i]), b[i+1:]
+}
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func decodePCAP(t *testing.T, path string) []*Transaction {
+	segments := testutil.ReadPCAP(t, path, 5432)
+	require.NotEmpty(t, segments)
+
+	var txs []*Transaction
+	decoder := NewDecoder(segments[0].ConnectionKey)
+	for _, segment := range segments {
+		decoder.Feed(segment.FromClient, segment.Payload, uint64(segment.Timestamp.UnixNano()), func(tx *Transaction) {
+			txs = append(txs, tx)
+		})
+	}
+	require.False(t, decoder.Stopped())
+	return txs
+}
+
+func TestDecoder(t *testing.T) {
+	txs := decodePCAP(t, "testdata/queries.pcap")
+
+	expected := []struct {
+		query   string
+		latency time.Duration
+		isError bool
+	}{
+		{query: "CREATE TABLE dummy (id int)", latency: 10 * time.Millisecond},
+		{query: "INSERT INTO dummy VALUES (1)", latency: 2 * time.Millisecond},
+		{query: "SELECT * FROM dummy WHERE id = 1", latency: 4 * time.Millisecond},
+		{query: "SELECT * FROM missing", latency: time.Millisecond, isError: true},
+		{query: "SELECT * FROM dummy WHERE id = $1", latency: 4 * time.Millisecond},
+		{query: "SELECT * FROM dummy WHERE id = $1", latency: 2 * time.Millisecond},
+		{query: "DROP TABLE dummy", latency: 5 * time.Millisecond},
+	}
+	require.Len(t, txs, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, e.query, txs[i].Query)
+		assert.Equal(t, e.isError, txs[i].IsError)
+		assert.InDelta(t, float64(e.latency), txs[i].RequestLatency(), float64(10*time.Microsecond), e.query)
+	}
+}
+
+func TestDecoderMidStream(t *testing.T) {
+	segments := testutil.ReadPCAP(t, "testdata/queries.pcap", 5432)
+
+	// skip the startup and the first query
+	var txs []*Transaction
+	decoder := NewDecoder(segments[0].ConnectionKey)
+	for _, segment := range segments[3:] {
+		decoder.Feed(segment.FromClient, segment.Payload, uint64(segment.Timestamp.UnixNano()), func(tx *Transaction) {
+			txs = append(txs, tx)
+		})
+	}
+	require.Len(t, txs, 6)
+	assert.Equal(t, "INSERT INTO dummy VALUES (1)", txs[0].Query)
+}
+
+func TestDecoderEncrypted(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	decoder.Feed(true, []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}, 1, nil)
+	assert.False(t, decoder.Stopped())
+	decoder.Feed(false, []byte{'S'}, 2, nil)
+	assert.True(t, decoder.Stopped())
+}
+
+func TestDecoderLargeMessage(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	// skip the startup phase
+	decoder.startupDone, decoder.started = true, true
+
+	query := make([]byte, 3*maxQuerySize)
+	for i := range query {
+		query[i] = 'a'
+	}
+	message := append([]byte{'Q', 0, 0, 0x30, 5}, query...)
+	message = append(message, 0)
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	for i := 0; i < len(message); i += 1000 {
+		decoder.Feed(true, message[i:min(i+1000, len(message))], 1, onTx)
+	}
+	// the server sends a large row, followed by CommandComplete
+	row := append([]byte{'D', 0, 0, 0x30, 4}, query...)
+	decoder.Feed(false, row[:100], 2, onTx)
+	decoder.Feed(false, row[100:], 3, onTx)
+	decoder.Feed(false, []byte{'C', 0, 0, 0, 4}, 4, onTx)
+
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Query, maxQuerySize)
+	assert.Equal(t, float64(3), txs[0].RequestLatency())
+	assert.Empty(t, decoder.client.buf)
+	assert.Empty(t, decoder.server.buf)
+}
+
+func TestDecoderSkip(t *testing.T) {
+	decoder := NewDecoder(testutil.PCAPSegment{}.ConnectionKey)
+	decoder.startupDone, decoder.started = true, true
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+
+	// the middle of a query wasn't captured, the query is truncated
+	query := make([]byte, 1000)
+	for i := range query {
+		query[i] = 'a'
+	}
+	message := append([]byte{'Q', 0, 0, 0x03, 0xed}, query...)
+	message = append(message, 0)
+	decoder.Feed(true, message[:320], 1, onTx)
+	decoder.Skip(true, len(message)-320-128)
+	decoder.Feed(true, message[len(message)-128:], 1, onTx)
+	decoder.Feed(false, []byte{'C', 0, 0, 0, 4, 'Z', 0, 0, 0, 5, 'I'}, 2, onTx)
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Query, 315)
+	assert.False(t, decoder.clientLost)
+
+	// the rows of the response weren't captured, the server stream is found
+	// again with the ReadyForQuery message ending the response
+	decoder.Feed(true, []byte{'Q', 0, 0, 0, 13, 'S', 'E', 'L', 'E', 'C', 'T', ' ', '1', 0}, 3, onTx)
+	decoder.Feed(false, []byte{'D', 0, 0, 0, 10, 0, 1, 0, 0, 0, 2, 'a'}, 4, onTx)
+	decoder.Skip(false, 10000)
+	assert.True(t, decoder.serverLost)
+	decoder.Feed(false, []byte{'a', 'C', 0, 0, 0, 4}, 5, onTx)
+	assert.Len(t, txs, 1)
+	decoder.Feed(false, []byte{'C', 0, 0, 0, 4, 'Z', 0, 0, 0, 5, 'I'}, 6, onTx)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "SELECT 1", txs[1].Query)
+	assert.Equal(t, float64(3), txs[1].RequestLatency())
+	assert.False(t, txs[1].IsError)
+
+	// the client stream is found again with the next query
+	decoder.Skip(true, 10000)
+	assert.True(t, decoder.clientLost)
+	decoder.Feed(true, []byte{0, 0, 'Q'}, 7, onTx)
+	decoder.Feed(true, []byte{'Q', 0, 0, 0, 13, 'S', 'E', 'L', 'E', 'C', 'T', ' ', '2', 0}, 8, onTx)
+	decoder.Feed(false, []byte{'C', 0, 0, 0, 4, 'Z', 0, 0, 0, 5, 'I'}, 9, onTx)
+	require.Len(t, txs, 3)
+	assert.Equal(t, "SELECT 2", txs[2].Query)
+	assert.False(t, decoder.Stopped())
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// queryInfo is what is extracted from a query to aggregate its stats
+type queryInfo struct {
+	operation Operation
+	tableName string
+	signature string
+}
+
+// StatKeeper is a struct to hold the stats for the postgres protocol
+type StatKeeper struct {
+	stats      map[Key]*RequestStat
+	statsMutex sync.Mutex
+	maxEntries int
+	telemetry  *Telemetry
+	obfuscator *obfuscate.Obfuscator
+
+	// queries caches the normalization of all the queries currently stored in
+	// the `StatKeeper`
+	queries map[string]queryInfo
+}
+
+// NewStatkeeper creates a new StatKeeper
+func NewStatkeeper(c *config.Config, telemetry *Telemetry) *StatKeeper {
+	return &StatKeeper{
+		stats:      make(map[Key]*RequestStat),
+		maxEntries: c.MaxPostgresStatsBuffered,
+		telemetry:  telemetry,
+		obfuscator: obfuscate.NewObfuscator(obfuscate.Config{
+			SQL: obfuscate.SQLConfig{
+				DBMS:             obfuscate.DBMSPostgres,
+				TableNames:       true,
+				CollectCommands:  true,
+				DollarQuotedFunc: true,
+				ObfuscationMode:  obfuscate.ObfuscateAndNormalize,
+			},
+		}),
+		queries: make(map[string]queryInfo),
+	}
+}
+
+// Process processes the postgres transaction
+func (statKeeper *StatKeeper) Process(tx *Transaction) {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+
+	statKeeper.telemetry.queries.Add(1)
+	if tx.IsError {
+		statKeeper.telemetry.errors.Add(1)
+	}
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		statKeeper.telemetry.negativeLatency.Add(1)
+		return
+	}
+
+	info := statKeeper.normalize(tx.Query)
+	key := NewKey(tx.ConnTuple, info.operation, info.tableName, info.signature)
+	requestStats, ok := statKeeper.stats[key]
+	if !ok {
+		if len(statKeeper.stats) >= statKeeper.maxEntries {
+			statKeeper.telemetry.dropped.Add(1)
+			return
+		}
+		requestStats = new(RequestStat)
+		statKeeper.stats[key] = requestStats
+	}
+	requestStats.Add(tx.RequestLatency(), tx.IsError)
+}
+
+// GetAndResetAllStats returns all the stats and resets the stats
+func (statKeeper *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+	ret := statKeeper.stats // No deep copy needed since `statKeeper.stats` gets reset
+	statKeeper.stats = make(map[Key]*RequestStat)
+	statKeeper.queries = make(map[string]queryInfo)
+	return ret
+}
+
+// normalize extracts the operation, the first table and the signature of a
+// query
+func (statKeeper *StatKeeper) normalize(query string) queryInfo {
+	if info, ok := statKeeper.queries[query]; ok {
+		return info
+	}
+
+	var info queryInfo
+	oq, err := statKeeper.obfuscator.ObfuscateSQLString(query)
+	if err != nil {
+		log.Debugf("unable to normalize postgres query: %v", err)
+		statKeeper.telemetry.invalidQueries.Add(1)
+		if fields := strings.Fields(query); len(fields) > 0 {
+			info.operation = FromString(fields[0])
+		}
+	} else {
+		info.signature = oq.Query
+		if len(oq.Metadata.Commands) > 0 {
+			info.operation = FromString(oq.Metadata.Commands[0])
+		}
+		info.tableName, _, _ = strings.Cut(oq.Metadata.TablesCSV, ",")
+	}
+
+	if len(statKeeper.queries) < statKeeper.maxEntries {
+		statKeeper.queries[query] = info
+	}
+	return info
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := NewStatkeeper(&config.Config{MaxPostgresStatsBuffered: 1000}, NewTelemetry())
+
+	txs := decodePCAP(t, "testdata/queries.pcap")
+	for _, tx := range txs {
+		sk.Process(tx)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Empty(t, sk.GetAndResetAllStats())
+
+	connKey := txs[0].ConnTuple
+	expected := map[Key]struct {
+		count      int
+		errorCount int
+	}{
+		NewKey(connKey, CreateOP, "dummy", "CREATE TABLE dummy ( id int )"):  {count: 1},
+		NewKey(connKey, InsertOP, "dummy", "INSERT INTO dummy VALUES ( ? )"): {count: 1},
+		// the literal and the bound parameters share the same signature
+		NewKey(connKey, SelectOP, "dummy", "SELECT * FROM dummy WHERE id = ?"): {count: 3},
+		NewKey(connKey, SelectOP, "missing", "SELECT * FROM missing"):          {count: 1, errorCount: 1},
+		NewKey(connKey, DropOP, "dummy", "DROP TABLE dummy"):                   {count: 1},
+	}
+	require.Len(t, stats, len(expected))
+	for key, e := range expected {
+		stat, ok := stats[key]
+		require.True(t, ok, "missing stats for %+v", key)
+		assert.Equal(t, e.count, stat.Count)
+		assert.Equal(t, e.errorCount, stat.ErrorCount)
+	}
+
+	selectStats := stats[NewKey(connKey, SelectOP, "dummy", "SELECT * FROM dummy WHERE id = ?")]
+	require.NotNil(t, selectStats.Latencies)
+	assert.Equal(t, float64(3), selectStats.Latencies.GetCount())
+}
+
+func TestStatKeeperMaxEntries(t *testing.T) {
+	tel := NewTelemetry()
+	sk := NewStatkeeper(&config.Config{MaxPostgresStatsBuffered: 2}, tel)
+
+	for _, query := range []string{"SELECT * FROM a", "SELECT * FROM b", "SELECT * FROM c", "SELECT * FROM a"} {
+		sk.Process(&Transaction{Query: query, RequestStarted: 1, ResponseLastSeen: 2})
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 2, stats[NewKey(Transaction{}.ConnTuple, SelectOP, "a", "SELECT * FROM a")].Count)
+	assert.Equal(t, int64(1), tel.dropped.Get())
+}
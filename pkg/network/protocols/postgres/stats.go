@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"strings"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+const RelativeAccuracy = 0.01
+
+// Operation represents a Postgres query operation. The values match the
+// PostgresOperation enum of the payload.
+type Operation uint8
+
+const (
+	// UnknownOP represents an unknown operation.
+	UnknownOP Operation = iota
+	// SelectOP represents a SELECT operation.
+	SelectOP
+	// InsertOP represents an INSERT operation.
+	InsertOP
+	// UpdateOP represents an UPDATE operation.
+	UpdateOP
+	// DeleteOP represents a DELETE operation.
+	DeleteOP
+	// AlterOP represents an ALTER operation.
+	AlterOP
+	// CreateOP represents a CREATE operation.
+	CreateOP
+	// DropOP represents a DROP operation.
+	DropOP
+	// TruncateOP represents a TRUNCATE operation.
+	TruncateOP
+)
+
+// String returns the string representation of the operation.
+func (op Operation) String() string {
+	switch op {
+	case SelectOP:
+		return "SELECT"
+	case InsertOP:
+		return "INSERT"
+	case UpdateOP:
+		return "UPDATE"
+	case DeleteOP:
+		return "DELETE"
+	case AlterOP:
+		return "ALTER"
+	case CreateOP:
+		return "CREATE"
+	case DropOP:
+		return "DROP"
+	case TruncateOP:
+		return "TRUNCATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FromString returns the Operation matching a SQL command.
+func FromString(command string) Operation {
+	switch strings.ToUpper(command) {
+	case "SELECT":
+		return SelectOP
+	case "INSERT":
+		return InsertOP
+	case "UPDATE":
+		return UpdateOP
+	case "DELETE":
+		return DeleteOP
+	case "ALTER":
+		return AlterOP
+	case "CREATE":
+		return CreateOP
+	case "DROP":
+		return DropOP
+	case "TRUNCATE":
+		return TruncateOP
+	default:
+		return UnknownOP
+	}
+}
+
+// Key is an identifier for a group of Postgres transactions
+type Key struct {
+	Operation Operation
+	TableName string
+	// QuerySignature is the normalized and obfuscated query
+	QuerySignature string
+	types.ConnectionKey
+}
+
+// NewKey generates a new Key
+func NewKey(connKey types.ConnectionKey, operation Operation, tableName, querySignature string) Key {
+	return Key{
+		Operation:      operation,
+		TableName:      tableName,
+		QuerySignature: querySignature,
+		ConnectionKey:  connKey,
+	}
+}
+
+// RequestStat stores stats for Postgres queries to a particular key
+type RequestStat struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	// Count is the number of queries. Latencies can discard values outside of
+	// the range it tracks, so it isn't used as the source of truth.
+	Count int
+	// ErrorCount is the number of queries which ended with an ErrorResponse
+	ErrorCount int
+
+	// FirstLatencySample holds the latency (in nanoseconds) of the first query,
+	// to avoid creating sketches with a single value.
+	FirstLatencySample float64
+}
+
+// Add records the latency of a query in the stats.
+func (r *RequestStat) Add(latency float64, isError bool) {
+	if isError {
+		r.ErrorCount++
+	}
+
+	r.Count++
+	if r.Count == 1 {
+		// We postpone the creation of sketches when we have only one latency sample
+		r.FirstLatencySample = latency
+		return
+	}
+
+	if r.Latencies == nil {
+		if err := r.initSketch(); err != nil {
+			return
+		}
+
+		// Add the deferred latency sample
+		if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+			log.Debugf("could not add postgres query latency to ddsketch: %v", err)
+		}
+	}
+
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add postgres query latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	if newStats.Count == 0 {
+		return
+	}
+
+	if newStats.Count == 1 {
+		// The other stat has a single latency sample, so we "manually" add it
+		r.Add(newStats.FirstLatencySample, newStats.ErrorCount > 0)
+		return
+	}
+
+	// The other stat has multiple samples and therefore a DDSketch object. We
+	// first ensure that the receiver has one.
+	if newStats.Latencies == nil {
+		log.Debugf("could not merge postgres transactions: missing ddsketch")
+	} else if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in the receiver we now add it to the DDSketch
+		if r.Count == 1 {
+			if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+				log.Debugf("could not add postgres query latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging postgres transactions: %v", err)
+	}
+	r.Count += newStats.Count
+	r.ErrorCount += newStats.ErrorCount
+}
+
+func (r *RequestStat) initSketch() (err error) {
+	r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+	if err != nil {
+		log.Debugf("error recording postgres transaction latency: could not create new ddsketch: %v", err)
+	}
+	return
+}
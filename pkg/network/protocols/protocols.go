@@ -19,8 +19,9 @@ import (
 
 // Programs maps used for tail calls
 const (
-	ProtocolDispatcherProgramsMap = "protocols_progs"
-	TLSDispatcherProgramsMap      = "tls_process_progs"
+	ProtocolDispatcherProgramsMap               = "protocols_progs"
+	TLSDispatcherProgramsMap                    = "tls_process_progs"
+	ProtocolDispatcherClassificationProgramsMap = "dispatcher_classification_progs"
 )
 
 // Protocol is the interface that represents a protocol supported by USM.
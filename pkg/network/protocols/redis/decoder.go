@@ -0,0 +1,587 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// maxLineSize is the length of the longest line accepted by the decoder.
+	// Long values are sent as bulk strings, which aren't buffered.
+	maxLineSize = 64 * 1024
+	// maxCommandNameSize is the number of bytes of a command name kept by the
+	// decoder
+	maxCommandNameSize = 32
+	// maxKeySize is the number of bytes of a key kept by the decoder. Longer
+	// keys are truncated.
+	maxKeySize = 128
+	// maxErrorPrefixSize is the number of bytes of an error reply read to find
+	// its prefix
+	maxErrorPrefixSize = 32
+	// maxPendingTransactions is the number of pipelined commands waiting for a
+	// reply tracked by the decoder on a connection
+	maxPendingTransactions = 1024
+	// maxNestingDepth is the deepest nesting of aggregate replies accepted by
+	// the decoder
+	maxNestingDepth = 32
+	// scriptKeyIndex is the index of the first key of the scripting commands,
+	// which follows the script and the number of keys
+	scriptKeyIndex = 3
+	// scriptNumKeysIndex is the index of the number of keys of the scripting
+	// commands
+	scriptNumKeysIndex = 2
+)
+
+var errMalformedMessage = errors.New("malformed redis message")
+
+// noKeyCommands are the commands whose first argument isn't a key. Their
+// arguments aren't read, as some of them are credentials.
+var noKeyCommands = map[string]struct{}{
+	"ACL": {}, "AUTH": {}, "BGREWRITEAOF": {}, "BGSAVE": {}, "CLIENT": {}, "CLUSTER": {},
+	"COMMAND": {}, "CONFIG": {}, "DBSIZE": {}, "DEBUG": {}, "DISCARD": {}, "ECHO": {},
+	"EXEC": {}, "FLUSHALL": {}, "FLUSHDB": {}, "FUNCTION": {}, "HELLO": {}, "INFO": {},
+	"LASTSAVE": {}, "LATENCY": {}, "MEMORY": {}, "MIGRATE": {}, "MODULE": {}, "MULTI": {},
+	"OBJECT": {}, "PING": {}, "PUBLISH": {}, "QUIT": {}, "READONLY": {}, "READWRITE": {},
+	"REPLICAOF": {}, "RESET": {}, "SAVE": {}, "SCAN": {}, "SCRIPT": {}, "SELECT": {},
+	"SHUTDOWN": {}, "SLAVEOF": {}, "SLOWLOG": {}, "SPUBLISH": {}, "SWAPDB": {}, "TIME": {},
+	"UNWATCH": {}, "WAIT": {}, "XREAD": {}, "XREADGROUP": {},
+}
+
+// scriptCommands are the commands whose keys follow a script and the number of
+// keys
+var scriptCommands = map[string]struct{}{
+	"EVAL": {}, "EVALSHA": {}, "EVAL_RO": {}, "EVALSHA_RO": {}, "FCALL": {}, "FCALL_RO": {},
+}
+
+// streamingCommands are the commands after which the server doesn't reply to
+// each command anymore
+var streamingCommands = map[string]struct{}{
+	"MONITOR": {}, "PSUBSCRIBE": {}, "PSYNC": {}, "SSUBSCRIBE": {}, "SUBSCRIBE": {}, "SYNC": {},
+}
+
+// keyIndex returns the index of the first key in the arguments of a command,
+// or -1 if the command has no key
+func keyIndex(command string) int {
+	if _, ok := noKeyCommands[command]; ok {
+		return -1
+	}
+	if _, ok := scriptCommands[command]; ok {
+		return scriptKeyIndex
+	}
+	return 1
+}
+
+// Transaction is a Redis command and the reply of the server
+type Transaction struct {
+	ConnTuple types.ConnectionKey
+	// Command is the upper-case name of the command
+	Command string
+	// Key is the first key of the command, if any
+	Key string
+	// KeyTruncated is true if the key was too long to be read entirely
+	KeyTruncated bool
+	// RequestStarted is the time the command was sent, in nanoseconds
+	RequestStarted uint64
+	// ResponseLastSeen is the time the reply was completed, in nanoseconds
+	ResponseLastSeen uint64
+	// ErrorType is the type of the error reply, or NoErr
+	ErrorType ErrorType
+}
+
+// RequestLatency returns the latency of the command in nanoseconds
+func (tx *Transaction) RequestLatency() float64 {
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		return 0
+	}
+	return float64(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+// respScanner splits a stream of RESP data into lines and bulk strings. Only
+// the bytes of the bulk strings requested by the caller are buffered, the
+// rest is discarded as it arrives.
+type respScanner struct {
+	line []byte
+
+	// bulkRemaining is the number of bytes of the current bulk string left to
+	// read, including its CRLF, or 0 when reading lines
+	bulkRemaining int
+	bulkCapture   int
+	bulkTruncated bool
+	bulk          []byte
+}
+
+// expectBulk announces a bulk string of the given length, of which only the
+// first capture bytes are passed to the caller
+func (s *respScanner) expectBulk(length, capture int) {
+	s.bulkRemaining = length + 2
+	s.bulkCapture = min(length, capture)
+	s.bulkTruncated = length > capture
+	s.bulk = s.bulk[:0]
+}
+
+// skip accounts for n bytes which weren't captured. It returns false when they
+// don't fall within the current bulk string, as the lines they contain can't
+// be found anymore.
+func (s *respScanner) skip(n int) bool {
+	if n >= s.bulkRemaining {
+		return false
+	}
+	if len(s.bulk) < s.bulkCapture {
+		// the end of the captured bytes is lost
+		s.bulkCapture = len(s.bulk)
+		s.bulkTruncated = true
+	}
+	s.bulkRemaining -= n
+	return true
+}
+
+// idle returns true if the scanner isn't reading a line or a bulk string
+func (s *respScanner) idle() bool {
+	return len(s.line) == 0 && s.bulkRemaining == 0
+}
+
+// scan calls onLine for each line, without its CRLF, and onBulk for each bulk
+// string announced through expectBulk
+func (s *respScanner) scan(data []byte, onLine func(line []byte) error, onBulk func(captured []byte, truncated bool) error) error {
+	for len(data) > 0 {
+		if s.bulkRemaining > 0 {
+			n := min(s.bulkRemaining, len(data))
+			if missing := s.bulkCapture - len(s.bulk); missing > 0 {
+				s.bulk = append(s.bulk, data[:min(missing, n)]...)
+			}
+			s.bulkRemaining -= n
+			data = data[n:]
+			if s.bulkRemaining == 0 {
+				if err := onBulk(s.bulk, s.bulkTruncated); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if len(s.line)+len(data) > maxLineSize {
+				return errMalformedMessage
+			}
+			s.line = append(s.line, data...)
+			return nil
+		}
+
+		line := data[:i]
+		if len(s.line) > 0 {
+			s.line = append(s.line, line...)
+			line = s.line
+		}
+		data = data[i+1:]
+		if len(line) > maxLineSize {
+			return errMalformedMessage
+		}
+
+		err := onLine(bytes.TrimSuffix(line, []byte{'\r'}))
+		s.line = s.line[:0]
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregate is an aggregate reply being read
+type aggregate struct {
+	remaining int
+	// attribute is true for attribute maps, which aren't elements of their
+	// parent
+	attribute bool
+}
+
+// Decoder extracts the commands and their replies from the traffic of a Redis
+// connection. RESP2 and RESP3 are supported, as well as pipelining and inline
+// commands. The decoder stops when the connection starts streaming data, after
+// a command such as SUBSCRIBE or MONITOR.
+type Decoder struct {
+	connKey types.ConnectionKey
+
+	client respScanner
+	server respScanner
+
+	// stopped is true once the decoder can't make sense of the traffic anymore
+	stopped bool
+
+	// clientLost and serverLost are true when bytes spanning several lines
+	// weren't captured, until the client starts a command again
+	clientLost bool
+	serverLost bool
+	// serverLastSeen is the capture time of the last bytes sent by the server
+	serverLastSeen uint64
+
+	// the command being read
+	args     int
+	argIndex int
+	keyIndex int
+	command  Transaction
+
+	// the reply being read
+	stack     []aggregate
+	replyKind byte
+	errType   ErrorType
+
+	pending []*Transaction
+}
+
+// NewDecoder returns a Decoder for the connection identified by connKey
+func NewDecoder(connKey types.ConnectionKey) *Decoder {
+	return &Decoder{
+		connKey: connKey,
+	}
+}
+
+// Feed decodes a segment of the connection payload. fromClient is true when
+// the segment was sent by the client, ts is the capture time of the segment in
+// nanoseconds. onTx is called for each completed transaction.
+func (d *Decoder) Feed(fromClient bool, data []byte, ts uint64, onTx func(*Transaction)) {
+	if d.stopped || len(data) == 0 {
+		return
+	}
+
+	var err error
+	if fromClient {
+		if (d.clientLost || d.serverLost) && d.args == 0 && d.client.idle() && isCommandStart(data) {
+			d.resync(onTx)
+		}
+		if d.clientLost {
+			return
+		}
+		err = d.client.scan(data,
+			func(line []byte) error { return d.handleRequestLine(line, ts) },
+			func(captured []byte, truncated bool) error { return d.handleArgument(captured, truncated) },
+		)
+	} else {
+		d.serverLastSeen = ts
+		if d.serverLost {
+			return
+		}
+		err = d.server.scan(data,
+			func(line []byte) error { return d.handleReplyLine(line, ts, onTx) },
+			func(captured []byte, _ bool) error {
+				if len(d.stack) == 0 && d.replyKind == '!' {
+					d.errType = ErrorTypeFromReply(string(captured))
+				}
+				d.elementDone(ts, onTx)
+				return nil
+			},
+		)
+	}
+	if err != nil {
+		d.stop()
+	}
+}
+
+// Skip accounts for n bytes of the connection payload which weren't captured.
+// When they span several lines, the decoder waits for the next command sent by
+// the client, and the commands sent before it are considered replied to.
+func (d *Decoder) Skip(fromClient bool, n int) {
+	if d.stopped || n <= 0 {
+		return
+	}
+
+	if fromClient {
+		if d.client.skip(n) {
+			return
+		}
+		d.clientLost = true
+		d.client = respScanner{}
+		d.args = 0
+	} else if d.server.skip(n) {
+		return
+	}
+
+	// the replies can't be matched with the commands until the next command
+	d.serverLost = true
+	d.server = respScanner{}
+	d.stack = nil
+}
+
+// resync is called when the client starts a command after bytes spanning
+// several lines weren't captured. The commands sent before it are completed
+// with the last bytes sent by the server, and the replies are read again from
+// there.
+func (d *Decoder) resync(onTx func(*Transaction)) {
+	for _, tx := range d.pending {
+		if d.serverLastSeen < tx.RequestStarted {
+			// the server didn't send anything since the command
+			continue
+		}
+		tx.ResponseLastSeen = d.serverLastSeen
+		tx.ErrorType = NoErr
+		onTx(tx)
+	}
+	d.pending = nil
+	d.clientLost = false
+	d.serverLost = false
+	d.server = respScanner{}
+	d.stack = nil
+}
+
+// Stopped returns true if the decoder gave up on the connection, either
+// because it is streaming data or because its traffic couldn't be parsed
+func (d *Decoder) Stopped() bool {
+	return d.stopped
+}
+
+func (d *Decoder) stop() {
+	d.stopped = true
+	d.pending = nil
+	d.stack = nil
+	d.client = respScanner{}
+	d.server = respScanner{}
+}
+
+func (d *Decoder) handleRequestLine(line []byte, ts uint64) error {
+	if d.args == 0 {
+		if len(line) == 0 {
+			return nil
+		}
+		if line[0] != '*' {
+			return d.handleInlineCommand(line, ts)
+		}
+
+		n, ok := parseInt(line[1:])
+		if !ok {
+			return errMalformedMessage
+		}
+		if n <= 0 {
+			return nil
+		}
+		d.args = n
+		d.argIndex = 0
+		d.keyIndex = -1
+		d.command = Transaction{
+			ConnTuple:      d.connKey,
+			RequestStarted: ts,
+		}
+		return nil
+	}
+
+	if len(line) == 0 || line[0] != '$' {
+		return errMalformedMessage
+	}
+	n, ok := parseInt(line[1:])
+	if !ok {
+		return errMalformedMessage
+	}
+	if n < 0 {
+		return d.handleArgument(nil, false)
+	}
+
+	capture := 0
+	switch {
+	case d.argIndex == 0:
+		capture = maxCommandNameSize
+	case d.argIndex == d.keyIndex:
+		capture = maxKeySize
+	case d.argIndex == scriptNumKeysIndex && d.keyIndex == scriptKeyIndex:
+		capture = maxCommandNameSize
+	}
+	d.client.expectBulk(n, capture)
+	return nil
+}
+
+func (d *Decoder) handleArgument(arg []byte, truncated bool) error {
+	switch {
+	case d.argIndex == 0:
+		d.command.Command = strings.ToUpper(string(arg))
+		d.keyIndex = keyIndex(d.command.Command)
+	case d.argIndex == d.keyIndex:
+		d.command.Key = string(arg)
+		d.command.KeyTruncated = truncated
+	case d.argIndex == scriptNumKeysIndex && d.keyIndex == scriptKeyIndex:
+		if string(arg) == "0" {
+			d.keyIndex = -1
+		}
+	}
+
+	d.argIndex++
+	d.args--
+	if d.args > 0 {
+		return nil
+	}
+
+	tx := d.command
+	return d.addPending(&tx)
+}
+
+func (d *Decoder) handleInlineCommand(line []byte, ts uint64) error {
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	tx := &Transaction{
+		ConnTuple:      d.connKey,
+		Command:        strings.ToUpper(fields[0]),
+		RequestStarted: ts,
+	}
+	index := keyIndex(tx.Command)
+	if index == scriptKeyIndex && (len(fields) <= scriptNumKeysIndex || fields[scriptNumKeysIndex] == "0") {
+		index = -1
+	}
+	if index > 0 && index < len(fields) {
+		tx.Key = fields[index]
+		if len(tx.Key) > maxKeySize {
+			tx.Key = tx.Key[:maxKeySize]
+			tx.KeyTruncated = true
+		}
+	}
+	return d.addPending(tx)
+}
+
+func (d *Decoder) addPending(tx *Transaction) error {
+	if _, ok := streamingCommands[tx.Command]; ok {
+		return errMalformedMessage
+	}
+	if len(d.pending) >= maxPendingTransactions {
+		// the replies can't be matched with the commands anymore
+		return errMalformedMessage
+	}
+	d.pending = append(d.pending, tx)
+	return nil
+}
+
+func (d *Decoder) handleReplyLine(line []byte, ts uint64, onTx func(*Transaction)) error {
+	if len(line) == 0 {
+		return errMalformedMessage
+	}
+
+	kind := line[0]
+	if len(d.stack) == 0 {
+		d.replyKind = kind
+		d.errType = NoErr
+	}
+
+	switch kind {
+	case '+', ':', ',', '#', '(', '_':
+		d.elementDone(ts, onTx)
+	case '-':
+		if len(d.stack) == 0 {
+			d.errType = ErrorTypeFromReply(string(line[1:min(len(line), 1+maxErrorPrefixSize)]))
+		}
+		d.elementDone(ts, onTx)
+	case '$', '=', '!':
+		n, ok := parseInt(line[1:])
+		if !ok {
+			return errMalformedMessage
+		}
+		if n < 0 {
+			d.elementDone(ts, onTx)
+			return nil
+		}
+		capture := 0
+		if kind == '!' && len(d.stack) == 0 {
+			capture = maxErrorPrefixSize
+		}
+		d.server.expectBulk(n, capture)
+	case '*', '~', '>', '%', '|':
+		n, ok := parseInt(line[1:])
+		if !ok {
+			return errMalformedMessage
+		}
+		if kind == '%' || kind == '|' {
+			n *= 2
+		}
+		if n <= 0 {
+			d.elementDone(ts, onTx)
+			return nil
+		}
+		if len(d.stack) >= maxNestingDepth {
+			return errMalformedMessage
+		}
+		d.stack = append(d.stack, aggregate{remaining: n, attribute: kind == '|'})
+	default:
+		return errMalformedMessage
+	}
+	return nil
+}
+
+// elementDone is called when an element of a reply was read entirely
+func (d *Decoder) elementDone(ts uint64, onTx func(*Transaction)) {
+	for len(d.stack) > 0 {
+		top := &d.stack[len(d.stack)-1]
+		top.remaining--
+		if top.remaining > 0 {
+			return
+		}
+		d.stack = d.stack[:len(d.stack)-1]
+		if top.attribute {
+			// attributes precede the element they describe
+			return
+		}
+	}
+
+	if d.replyKind == '>' {
+		// push data isn't the reply to a command
+		return
+	}
+	d.complete(ts, onTx)
+}
+
+func (d *Decoder) complete(ts uint64, onTx func(*Transaction)) {
+	if len(d.pending) == 0 {
+		return
+	}
+
+	tx := d.pending[0]
+	d.pending = d.pending[1:]
+	tx.ResponseLastSeen = ts
+	tx.ErrorType = d.errType
+	onTx(tx)
+}
+
+// isCommandStart returns true if data starts with the header of a command sent
+// as an array of bulk strings
+func isCommandStart(data []byte) bool {
+	if len(data) < 2 || data[0] != '*' {
+		return false
+	}
+
+	i := 1
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	return i > 1 && bytes.HasPrefix(data[i:], []byte("\r\n$"))
+}
+
+// parseInt parses the decimal integer of a RESP header
+func parseInt(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+
+	negative := b[0] == '-'
+	if negative {
+		b = b[1:]
+		if len(b) == 0 {
+			return 0, false
+		}
+	}
+
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' || n > maxLineSize*maxLineSize {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	if negative {
+		return -n, true
+	}
+	return n, true
+}
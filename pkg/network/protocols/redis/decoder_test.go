@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func decodePCAP(t *testing.T, path string) ([]*Transaction, *Decoder) {
+	segments := testutil.ReadPCAP(t, path, 6379)
+	require.NotEmpty(t, segments)
+
+	var txs []*Transaction
+	decoder := NewDecoder(segments[0].ConnectionKey)
+	for _, segment := range segments {
+		decoder.Feed(segment.FromClient, segment.Payload, uint64(segment.Timestamp.UnixNano()), func(tx *Transaction) {
+			txs = append(txs, tx)
+		})
+	}
+	return txs, decoder
+}
+
+func TestDecoder(t *testing.T) {
+	txs, decoder := decodePCAP(t, "testdata/commands.pcap")
+	// the decoder stops on SUBSCRIBE
+	assert.True(t, decoder.Stopped())
+
+	expected := []struct {
+		command   string
+		key       string
+		latency   time.Duration
+		errorType ErrorType
+	}{
+		{command: "SET", key: "user:1:name", latency: 2 * time.Millisecond},
+		{command: "GET", key: "user:1:name", latency: time.Millisecond},
+		{command: "GET", key: "user:2:name", latency: time.Millisecond},
+		{command: "LPUSH", key: "user:1:name", latency: time.Millisecond, errorType: WrongTypeErr},
+		{command: "INCR", key: "counter", latency: 3 * time.Millisecond},
+		{command: "MGET", key: "user:1:name", latency: 4 * time.Millisecond},
+		{command: "EVALSHA", key: "session:abc", latency: 4 * time.Millisecond, errorType: NoScriptErr},
+		{command: "SET", key: "blob:1", latency: 2300 * time.Microsecond},
+		{command: "PING", latency: time.Millisecond},
+		{command: "HELLO", latency: time.Millisecond},
+		{command: "HGETALL", key: "user:1", latency: 2 * time.Millisecond},
+		{command: "EVAL", latency: time.Millisecond, errorType: ErrErr},
+	}
+	require.Len(t, txs, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, e.command, txs[i].Command)
+		assert.Equal(t, e.key, txs[i].Key, e.command)
+		assert.Equal(t, e.errorType, txs[i].ErrorType, e.command)
+		assert.InDelta(t, float64(e.latency), txs[i].RequestLatency(), float64(10*time.Microsecond), e.command)
+	}
+}
+
+func TestDecoderCredentials(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	decoder.Feed(true, []byte("*3\r\n$4\r\nAUTH\r\n$4\r\nuser\r\n$6\r\nsecret\r\nAUTH secret\r\n"), 1, onTx)
+	decoder.Feed(false, []byte("+OK\r\n-WRONGPASS invalid username-password pair\r\n"), 2, onTx)
+
+	require.Len(t, txs, 2)
+	for _, tx := range txs {
+		assert.Equal(t, "AUTH", tx.Command)
+		assert.Empty(t, tx.Key)
+	}
+	assert.Equal(t, NoErr, txs[0].ErrorType)
+	assert.Equal(t, WrongPassErr, txs[1].ErrorType)
+}
+
+func TestDecoderLongKey(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	key := strings.Repeat("k", 2*maxKeySize)
+	decoder.Feed(true, []byte("*2\r\n$3\r\nget\r\n$256\r\n"+key+"\r\n"), 1, onTx)
+	decoder.Feed(false, []byte("$-1\r\n"), 2, onTx)
+
+	require.Len(t, txs, 1)
+	assert.Equal(t, "GET", txs[0].Command)
+	assert.Equal(t, key[:maxKeySize], txs[0].Key)
+	assert.True(t, txs[0].KeyTruncated)
+}
+
+func TestDecoderNestedReplies(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+	decoder.Feed(true, []byte("*1\r\n$4\r\nEXEC\r\n*1\r\n$4\r\nPING\r\n"), 1, onTx)
+	// an array with a nested array preceded by an attribute, then a simple string
+	decoder.Feed(false, []byte("*2\r\n|1\r\n+key\r\n+value\r\n*2\r\n:1\r\n_\r\n~0\r\n"), 2, onTx)
+	require.Len(t, txs, 1)
+	decoder.Feed(false, []byte("+PONG\r\n"), 3, onTx)
+
+	require.Len(t, txs, 2)
+	assert.Equal(t, "EXEC", txs[0].Command)
+	assert.Equal(t, "PING", txs[1].Command)
+	assert.False(t, decoder.Stopped())
+}
+
+func TestDecoderMalformed(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+	decoder.Feed(true, []byte("*1\r\n+GET\r\n"), 1, nil)
+	assert.True(t, decoder.Stopped())
+}
+
+func TestDecoderSkip(t *testing.T) {
+	decoder := NewDecoder(types.ConnectionKey{})
+
+	var txs []*Transaction
+	onTx := func(tx *Transaction) { txs = append(txs, tx) }
+
+	// the middle of a value wasn't captured
+	decoder.Feed(true, []byte("*3\r\n$3\r\nSET\r\n$4\r\nblob\r\n$1000\r\n"+strings.Repeat("v", 100)), 1, onTx)
+	decoder.Skip(true, 800)
+	decoder.Feed(true, []byte(strings.Repeat("v", 100)+"\r\n"), 1, onTx)
+	decoder.Feed(false, []byte("+OK\r\n"), 2, onTx)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "SET", txs[0].Command)
+	assert.Equal(t, "blob", txs[0].Key)
+	assert.False(t, decoder.clientLost)
+
+	// the elements of a reply weren't captured, the replies are matched again
+	// from the next command
+	decoder.Feed(true, []byte("*2\r\n$7\r\nHGETALL\r\n$4\r\nuser\r\n"), 3, onTx)
+	decoder.Feed(false, []byte("*1000\r\n$4\r\nname\r\n"), 4, onTx)
+	decoder.Skip(false, 10000)
+	assert.True(t, decoder.serverLost)
+	decoder.Feed(false, []byte("\r\n$4\r\nlast\r\n"), 5, onTx)
+	assert.Len(t, txs, 1)
+	decoder.Feed(true, []byte("*2\r\n$3\r\nGET\r\n$4\r\nuser\r\n"), 6, onTx)
+	decoder.Feed(false, []byte("$-1\r\n"), 7, onTx)
+	require.Len(t, txs, 3)
+	assert.Equal(t, "HGETALL", txs[1].Command)
+	assert.Equal(t, float64(2), txs[1].RequestLatency())
+	assert.Equal(t, "GET", txs[2].Command)
+	assert.Equal(t, float64(1), txs[2].RequestLatency())
+
+	// the client stream is found again with the next command
+	decoder.Skip(true, 10000)
+	assert.True(t, decoder.clientLost)
+	decoder.Feed(true, []byte("\r\n$3\r\nkey\r\n"), 8, onTx)
+	decoder.Feed(true, []byte("*1\r\n$4\r\nPING\r\n"), 9, onTx)
+	decoder.Feed(false, []byte("+PONG\r\n"), 10, onTx)
+	require.Len(t, txs, 4)
+	assert.Equal(t, "PING", txs[3].Command)
+	assert.False(t, decoder.Stopped())
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package redis
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/segments"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const eventStreamName = "redis"
+
+// Spec is the protocol spec for the redis protocol.
+var Spec = segments.NewSpec(eventStreamName, protocols.ProgramRedis, protocols.DispatcherRedisProg, newRedisProtocol)
+
+func newRedisProtocol(cfg *config.Config) (protocols.Protocol, error) {
+	if !cfg.EnableRedisMonitoring {
+		return nil, nil
+	}
+
+	statkeeper := NewStatkeeper(cfg, NewTelemetry())
+	return segments.NewProtocol(cfg, segments.ProtocolConfig[Transaction]{
+		Name:        "Redis",
+		Type:        protocols.Redis,
+		EventStream: eventStreamName,
+		NewDecoder: func(connKey types.ConnectionKey) segments.Decoder[Transaction] {
+			return NewDecoder(connKey)
+		},
+		Process: statkeeper.Process,
+		GetAndResetAllStats: func() interface{} {
+			return statkeeper.GetAndResetAllStats()
+		},
+	}), nil
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+// keySeparator separates the segments of a key, such as `user:1234:profile`
+const keySeparator = ':'
+
+// StatKeeper is a struct to hold the stats for the redis protocol
+type StatKeeper struct {
+	stats      map[Key]*RequestStats
+	statsMutex sync.Mutex
+	maxEntries int
+	telemetry  *Telemetry
+
+	// keyPrefixDepth is the number of segments of the keys kept to group
+	// the commands
+	keyPrefixDepth int
+}
+
+// NewStatkeeper creates a new StatKeeper
+func NewStatkeeper(c *config.Config, telemetry *Telemetry) *StatKeeper {
+	return &StatKeeper{
+		stats:          make(map[Key]*RequestStats),
+		maxEntries:     c.MaxRedisStatsBuffered,
+		telemetry:      telemetry,
+		keyPrefixDepth: c.RedisKeyPrefixDepth,
+	}
+}
+
+// Process processes the redis transaction
+func (statKeeper *StatKeeper) Process(tx *Transaction) {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+
+	statKeeper.telemetry.commands.Add(1)
+	if tx.ErrorType != NoErr {
+		statKeeper.telemetry.errors.Add(1)
+	}
+	if tx.ResponseLastSeen < tx.RequestStarted {
+		statKeeper.telemetry.negativeLatency.Add(1)
+		return
+	}
+
+	prefix, truncated := keyPrefix(tx.Key, statKeeper.keyPrefixDepth), false
+	if prefix == tx.Key {
+		// the prefix is the whole key, which may have been truncated
+		truncated = tx.KeyTruncated
+	}
+
+	key := NewKey(tx.ConnTuple, tx.Command, prefix, truncated)
+	requestStats, ok := statKeeper.stats[key]
+	if !ok {
+		if len(statKeeper.stats) >= statKeeper.maxEntries {
+			statKeeper.telemetry.dropped.Add(1)
+			return
+		}
+		requestStats = NewRequestStats()
+		statKeeper.stats[key] = requestStats
+	}
+	requestStats.AddRequest(tx.ErrorType, tx.RequestLatency())
+}
+
+// GetAndResetAllStats returns all the stats and resets the stats
+func (statKeeper *StatKeeper) GetAndResetAllStats() map[Key]*RequestStats {
+	statKeeper.statsMutex.Lock()
+	defer statKeeper.statsMutex.Unlock()
+	ret := statKeeper.stats // No deep copy needed since `statKeeper.stats` gets reset
+	statKeeper.stats = make(map[Key]*RequestStats)
+	return ret
+}
+
+// keyPrefix returns the first depth segments of a key, or an empty string if
+// depth isn't positive
+func keyPrefix(key string, depth int) string {
+	if depth <= 0 {
+		return ""
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] != keySeparator {
+			continue
+		}
+		depth--
+		if depth == 0 {
+			return key[:i]
+		}
+	}
+	return key
+}
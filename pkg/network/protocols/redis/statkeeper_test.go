@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := NewStatkeeper(&config.Config{MaxRedisStatsBuffered: 1000, RedisKeyPrefixDepth: 1}, NewTelemetry())
+
+	txs, _ := decodePCAP(t, "testdata/commands.pcap")
+	for _, tx := range txs {
+		sk.Process(tx)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Empty(t, sk.GetAndResetAllStats())
+
+	connKey := txs[0].ConnTuple
+	expected := map[Key]map[ErrorType]int{
+		NewKey(connKey, "SET", "user", false):        {NoErr: 1},
+		NewKey(connKey, "GET", "user", false):        {NoErr: 2},
+		NewKey(connKey, "LPUSH", "user", false):      {WrongTypeErr: 1},
+		NewKey(connKey, "INCR", "counter", false):    {NoErr: 1},
+		NewKey(connKey, "MGET", "user", false):       {NoErr: 1},
+		NewKey(connKey, "EVALSHA", "session", false): {NoScriptErr: 1},
+		NewKey(connKey, "SET", "blob", false):        {NoErr: 1},
+		NewKey(connKey, "PING", "", false):           {NoErr: 1},
+		NewKey(connKey, "HELLO", "", false):          {NoErr: 1},
+		NewKey(connKey, "HGETALL", "user", false):    {NoErr: 1},
+		NewKey(connKey, "EVAL", "", false):           {ErrErr: 1},
+	}
+	require.Len(t, stats, len(expected))
+	for key, counts := range expected {
+		stat, ok := stats[key]
+		require.True(t, ok, "missing stats for %+v", key)
+		require.Len(t, stat.ErrorToStats, len(counts))
+		for errType, count := range counts {
+			require.Contains(t, stat.ErrorToStats, errType)
+			assert.Equal(t, count, stat.ErrorToStats[errType].Count)
+		}
+	}
+
+	getStats := stats[NewKey(connKey, "GET", "user", false)].ErrorToStats[NoErr]
+	require.NotNil(t, getStats.Latencies)
+	assert.Equal(t, float64(2), getStats.Latencies.GetCount())
+}
+
+func TestStatKeeperKeyPrefix(t *testing.T) {
+	sk := NewStatkeeper(&config.Config{MaxRedisStatsBuffered: 1000, RedisKeyPrefixDepth: 2}, NewTelemetry())
+
+	sk.Process(&Transaction{Command: "GET", Key: "user:1:name", RequestStarted: 1, ResponseLastSeen: 2})
+	sk.Process(&Transaction{Command: "GET", Key: "user:1:email", RequestStarted: 1, ResponseLastSeen: 2})
+	sk.Process(&Transaction{Command: "GET", Key: "user:1", KeyTruncated: true, RequestStarted: 1, ResponseLastSeen: 2})
+	sk.Process(&Transaction{Command: "GET", Key: "session", RequestStarted: 1, ResponseLastSeen: 2})
+
+	stats := sk.GetAndResetAllStats()
+	assert.Len(t, stats, 3)
+	assert.Equal(t, 2, stats[NewKey(types.ConnectionKey{}, "GET", "user:1", false)].ErrorToStats[NoErr].Count)
+	assert.Equal(t, 1, stats[NewKey(types.ConnectionKey{}, "GET", "user:1", true)].ErrorToStats[NoErr].Count)
+	assert.Equal(t, 1, stats[NewKey(types.ConnectionKey{}, "GET", "session", false)].ErrorToStats[NoErr].Count)
+}
+
+func TestKeyPrefix(t *testing.T) {
+	assert.Equal(t, "", keyPrefix("user:1:name", 0))
+	assert.Equal(t, "user", keyPrefix("user:1:name", 1))
+	assert.Equal(t, "user:1", keyPrefix("user:1:name", 2))
+	assert.Equal(t, "user:1:name", keyPrefix("user:1:name", 3))
+	assert.Equal(t, "user:1:name", keyPrefix("user:1:name", 10))
+	assert.Equal(t, "", keyPrefix(":name", 1))
+}
+
+func TestStatKeeperMaxEntries(t *testing.T) {
+	tel := NewTelemetry()
+	sk := NewStatkeeper(&config.Config{MaxRedisStatsBuffered: 2, RedisKeyPrefixDepth: 1}, tel)
+
+	for _, key := range []string{"a", "b", "c", "a"} {
+		sk.Process(&Transaction{Command: "GET", Key: key, RequestStarted: 1, ResponseLastSeen: 2})
+	}
+
+	stats := sk.GetAndResetAllStats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, 2, stats[NewKey(types.ConnectionKey{}, "GET", "a", false)].ErrorToStats[NoErr].Count)
+	assert.Equal(t, int64(1), tel.dropped.Get())
+}
@@ -0,0 +1,245 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"strings"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+const RelativeAccuracy = 0.01
+
+// ErrorType represents the type of an error reply. The values match the
+// RedisErrorType enum of the payload.
+type ErrorType uint8
+
+const (
+	// NoErr means the command succeeded.
+	NoErr ErrorType = iota
+	// UnknownErr represents an error reply without a known prefix.
+	UnknownErr
+	// ErrErr represents a generic ERR error reply.
+	ErrErr
+	// WrongTypeErr represents a WRONGTYPE error reply.
+	WrongTypeErr
+	// NoAuthErr represents a NOAUTH error reply.
+	NoAuthErr
+	// NoPermErr represents a NOPERM error reply.
+	NoPermErr
+	// BusyErr represents a BUSY error reply.
+	BusyErr
+	// NoScriptErr represents a NOSCRIPT error reply.
+	NoScriptErr
+	// LoadingErr represents a LOADING error reply.
+	LoadingErr
+	// ReadOnlyErr represents a READONLY error reply.
+	ReadOnlyErr
+	// ExecAbortErr represents an EXECABORT error reply.
+	ExecAbortErr
+	// MasterDownErr represents a MASTERDOWN error reply.
+	MasterDownErr
+	// MisconfErr represents a MISCONF error reply.
+	MisconfErr
+	// CrossSlotErr represents a CROSSSLOT error reply.
+	CrossSlotErr
+	// TryAgainErr represents a TRYAGAIN error reply.
+	TryAgainErr
+	// AskErr represents an ASK redirection.
+	AskErr
+	// MovedErr represents a MOVED redirection.
+	MovedErr
+	// ClusterDownErr represents a CLUSTERDOWN error reply.
+	ClusterDownErr
+	// NoReplicasErr represents a NOREPLICAS error reply.
+	NoReplicasErr
+	// OOMErr represents an OOM error reply.
+	OOMErr
+	// NoQuorumErr represents a NOQUORUM error reply.
+	NoQuorumErr
+	// BusyKeyErr represents a BUSYKEY error reply.
+	BusyKeyErr
+	// UnblockedErr represents an UNBLOCKED error reply.
+	UnblockedErr
+	// WrongPassErr represents a WRONGPASS error reply.
+	WrongPassErr
+	// InvalidObjErr represents an INVALIDOBJ error reply.
+	InvalidObjErr
+)
+
+var errorPrefixes = map[string]ErrorType{
+	"ERR":         ErrErr,
+	"WRONGTYPE":   WrongTypeErr,
+	"NOAUTH":      NoAuthErr,
+	"NOPERM":      NoPermErr,
+	"BUSY":        BusyErr,
+	"NOSCRIPT":    NoScriptErr,
+	"LOADING":     LoadingErr,
+	"READONLY":    ReadOnlyErr,
+	"EXECABORT":   ExecAbortErr,
+	"MASTERDOWN":  MasterDownErr,
+	"MISCONF":     MisconfErr,
+	"CROSSSLOT":   CrossSlotErr,
+	"TRYAGAIN":    TryAgainErr,
+	"ASK":         AskErr,
+	"MOVED":       MovedErr,
+	"CLUSTERDOWN": ClusterDownErr,
+	"NOREPLICAS":  NoReplicasErr,
+	"OOM":         OOMErr,
+	"NOQUORUM":    NoQuorumErr,
+	"BUSYKEY":     BusyKeyErr,
+	"UNBLOCKED":   UnblockedErr,
+	"WRONGPASS":   WrongPassErr,
+	"INVALIDOBJ":  InvalidObjErr,
+}
+
+// ErrorTypeFromReply returns the type of an error reply from its message,
+// whose first word is the error prefix.
+func ErrorTypeFromReply(message string) ErrorType {
+	prefix, _, _ := strings.Cut(message, " ")
+	if errType, ok := errorPrefixes[prefix]; ok {
+		return errType
+	}
+	return UnknownErr
+}
+
+// Key is an identifier for a group of Redis commands
+type Key struct {
+	// Command is the upper-case name of the command
+	Command string
+	// KeyPrefix is the prefix of the first key of the command
+	KeyPrefix string
+	// Truncated is true if the key was too long to be read entirely
+	Truncated bool
+	types.ConnectionKey
+}
+
+// NewKey generates a new Key
+func NewKey(connKey types.ConnectionKey, command, keyPrefix string, truncated bool) Key {
+	return Key{
+		Command:       command,
+		KeyPrefix:     keyPrefix,
+		Truncated:     truncated,
+		ConnectionKey: connKey,
+	}
+}
+
+// RequestStat stores stats for Redis commands with the same reply type
+type RequestStat struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	// Count is the number of commands. Latencies can discard values outside of
+	// the range it tracks, so it isn't used as the source of truth.
+	Count int
+
+	// FirstLatencySample holds the latency (in nanoseconds) of the first
+	// command, to avoid creating sketches with a single value.
+	FirstLatencySample float64
+}
+
+// Add records the latency of a command in the stats.
+func (r *RequestStat) Add(latency float64) {
+	r.Count++
+	if r.Count == 1 {
+		// We postpone the creation of sketches when we have only one latency sample
+		r.FirstLatencySample = latency
+		return
+	}
+
+	if r.Latencies == nil {
+		if err := r.initSketch(); err != nil {
+			return
+		}
+
+		// Add the deferred latency sample
+		if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+			log.Debugf("could not add redis command latency to ddsketch: %v", err)
+		}
+	}
+
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add redis command latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	if newStats.Count == 0 {
+		return
+	}
+
+	if newStats.Count == 1 {
+		// The other stat has a single latency sample, so we "manually" add it
+		r.Add(newStats.FirstLatencySample)
+		return
+	}
+
+	// The other stat has multiple samples and therefore a DDSketch object. We
+	// first ensure that the receiver has one.
+	if newStats.Latencies == nil {
+		log.Debugf("could not merge redis commands: missing ddsketch")
+	} else if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in the receiver we now add it to the DDSketch
+		if r.Count == 1 {
+			if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+				log.Debugf("could not add redis command latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging redis commands: %v", err)
+	}
+	r.Count += newStats.Count
+}
+
+func (r *RequestStat) initSketch() (err error) {
+	r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+	if err != nil {
+		log.Debugf("error recording redis command latency: could not create new ddsketch: %v", err)
+	}
+	return
+}
+
+// RequestStats stores the stats of the commands of a Key, by type of reply
+type RequestStats struct {
+	ErrorToStats map[ErrorType]*RequestStat
+}
+
+// NewRequestStats creates a new RequestStats object.
+func NewRequestStats() *RequestStats {
+	return &RequestStats{
+		ErrorToStats: make(map[ErrorType]*RequestStat),
+	}
+}
+
+// AddRequest records a command with the given reply type and latency.
+func (r *RequestStats) AddRequest(errType ErrorType, latency float64) {
+	stats, ok := r.ErrorToStats[errType]
+	if !ok {
+		stats = new(RequestStat)
+		r.ErrorToStats[errType] = stats
+	}
+	stats.Add(latency)
+}
+
+// CombineWith merges the data in 2 RequestStats objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStats) CombineWith(newStats *RequestStats) {
+	for errType, newRequests := range newStats.ErrorToStats {
+		stats, ok := r.ErrorToStats[errType]
+		if !ok {
+			stats = new(RequestStat)
+			r.ErrorToStats[errType] = stats
+		}
+		stats.CombineWith(newRequests)
+	}
+}
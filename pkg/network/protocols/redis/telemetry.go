@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Telemetry is a struct to hold the telemetry for the redis protocol
+type Telemetry struct {
+	metricGroup *libtelemetry.MetricGroup
+
+	commands        *libtelemetry.Counter
+	errors          *libtelemetry.Counter // commands answered with an error reply
+	dropped         *libtelemetry.Counter // this happens when StatKeeper reaches capacity
+	negativeLatency *libtelemetry.Counter // this happens when the reply was captured before the command
+}
+
+// NewTelemetry creates a new Telemetry
+func NewTelemetry() *Telemetry {
+	metricGroup := libtelemetry.NewMetricGroup("usm.redis")
+
+	return &Telemetry{
+		metricGroup:     metricGroup,
+		commands:        metricGroup.NewCounter("total_hits", libtelemetry.OptStatsd),
+		errors:          metricGroup.NewCounter("errors", libtelemetry.OptStatsd),
+		dropped:         metricGroup.NewCounter("dropped", libtelemetry.OptStatsd),
+		negativeLatency: metricGroup.NewCounter("negative_latency"),
+	}
+}
+
+// Log logs the redis stats summary
+func (t *Telemetry) Log() {
+	log.Debugf("redis stats summary: %s", t.metricGroup.Summary())
+}
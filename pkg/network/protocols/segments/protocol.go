@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package segments
+
+import (
+	"io"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/events"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/buildmode"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ProtocolConfig describes a protocol whose segments are decoded in userspace
+type ProtocolConfig[T any] struct {
+	// Name is the name of the protocol
+	Name string
+	// Type is the type of the stats returned by the protocol
+	Type protocols.ProtocolType
+	// EventStream is the name given to USM_EVENTS_INIT and
+	// SEGMENTS_CAPTURE_INIT in the eBPF programs
+	EventStream string
+	// NewDecoder creates the decoder of a connection
+	NewDecoder func(types.ConnectionKey) Decoder[T]
+	// Process is called for each decoded transaction
+	Process func(*T)
+	// GetAndResetAllStats returns the stats of the transactions processed
+	// since its previous call
+	GetAndResetAllStats func() interface{}
+}
+
+// NewSpec returns the spec of a protocol classified by the
+// socket__protocol_dispatcher_<eventStream> eBPF program, and whose segments
+// are captured by the socket__<eventStream>_filter one
+func NewSpec(eventStream string, program protocols.ProgramType, dispatcherProgram protocols.DispatcherProgramType, factory protocols.ProtocolFactory) *protocols.ProtocolSpec {
+	return &protocols.ProtocolSpec{
+		Factory: factory,
+		Maps: []*manager.Map{
+			{
+				Name: eventStream + "_segment_heap",
+			},
+		},
+		TailCalls: []manager.TailCallRoute{
+			{
+				ProgArrayName: protocols.ProtocolDispatcherProgramsMap,
+				Key:           uint32(program),
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "socket__" + eventStream + "_filter",
+				},
+			},
+			{
+				ProgArrayName: protocols.ProtocolDispatcherClassificationProgramsMap,
+				Key:           uint32(dispatcherProgram),
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: "socket__protocol_dispatcher_" + eventStream,
+				},
+			},
+		},
+	}
+}
+
+type protocol[T any] struct {
+	cfg            *config.Config
+	protocolConfig ProtocolConfig[T]
+	tracker        *Tracker[T]
+	eventsConsumer *events.Consumer[EbpfSegment]
+}
+
+// NewProtocol returns the USM protocol decoding the segments sent by the
+// socket__<EventStream>_filter eBPF program
+func NewProtocol[T any](cfg *config.Config, protocolConfig ProtocolConfig[T]) protocols.Protocol {
+	return &protocol[T]{
+		cfg:            cfg,
+		protocolConfig: protocolConfig,
+		tracker:        NewTracker(protocolConfig.NewDecoder, protocolConfig.Process),
+	}
+}
+
+// Name returns the name of the protocol.
+func (p *protocol[T]) Name() string {
+	return p.protocolConfig.Name
+}
+
+// ConfigureOptions configures the event stream of the segments with the
+// manager and its options, and enables the <EventStream>_monitoring_enabled
+// eBPF option.
+func (p *protocol[T]) ConfigureOptions(mgr *manager.Manager, opts *manager.Options) {
+	events.Configure(p.cfg, p.protocolConfig.EventStream, mgr, opts)
+	utils.EnableOption(opts, p.protocolConfig.EventStream+"_monitoring_enabled")
+}
+
+// PreStart creates the events consumer of the segments and starts it.
+func (p *protocol[T]) PreStart(mgr *manager.Manager) error {
+	var err error
+	p.eventsConsumer, err = events.NewConsumer(
+		p.protocolConfig.EventStream,
+		mgr,
+		p.tracker.Add,
+	)
+	if err != nil {
+		return err
+	}
+
+	p.eventsConsumer.Start()
+	return nil
+}
+
+// PostStart empty implementation.
+func (p *protocol[T]) PostStart(*manager.Manager) error {
+	return nil
+}
+
+// Stop stops the events consumer.
+func (p *protocol[T]) Stop(*manager.Manager) {
+	if p.eventsConsumer != nil {
+		p.eventsConsumer.Stop()
+	}
+}
+
+// DumpMaps empty implementation.
+func (p *protocol[T]) DumpMaps(io.Writer, string, *ebpf.Map) {}
+
+// GetStats decodes the segments captured so far, and returns the stats of the
+// decoded transactions.
+func (p *protocol[T]) GetStats() *protocols.ProtocolStats {
+	p.eventsConsumer.Sync()
+	if dropped := p.tracker.Flush(); dropped > 0 {
+		log.Debugf("%s: %d connections weren't decoded, as too many connections were tracked", p.protocolConfig.Name, dropped)
+	}
+	return &protocols.ProtocolStats{
+		Type:  p.protocolConfig.Type,
+		Stats: p.protocolConfig.GetAndResetAllStats(),
+	}
+}
+
+// IsBuildModeSupported returns always true, as the capture of the segments is
+// supported by all modes.
+func (*protocol[T]) IsBuildModeSupported(buildmode.Type) bool {
+	return true
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+// Package segments decodes in userspace the protocols whose TCP segments are
+// captured by the socket__<protocol>_filter eBPF programs.
+package segments
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// maxPendingSegments is the number of segments buffered before they are
+	// decoded. The segments are reordered within the buffer, as those of a
+	// connection can be captured on different CPUs.
+	maxPendingSegments = 4096
+	// maxFlows is the number of connections tracked at once
+	maxFlows = 65536
+	// flowTimeout is the time after which a connection without traffic is
+	// forgotten
+	flowTimeout = 2 * time.Minute
+)
+
+// Decoder decodes the payload of a connection into transactions of type T
+type Decoder[T any] interface {
+	// Feed decodes bytes of the payload. fromClient is true when the bytes
+	// were sent by the client, ts is their capture time in nanoseconds and
+	// onTx is called for each completed transaction.
+	Feed(fromClient bool, data []byte, ts uint64, onTx func(*T))
+	// Skip accounts for n bytes of the payload which weren't captured
+	Skip(fromClient bool, n int)
+	// Stopped returns true once the decoder gave up on the connection
+	Stopped() bool
+}
+
+// flow is a connection whose segments are decoded
+type flow[T any] struct {
+	decoder  Decoder[T]
+	lastSeen uint64
+}
+
+// Tracker feeds the captured segments to the decoders of their connections
+type Tracker[T any] struct {
+	mux        sync.Mutex
+	newDecoder func(types.ConnectionKey) Decoder[T]
+	onTx       func(*T)
+
+	pending []EbpfSegment
+	flows   map[types.ConnectionKey]*flow[T]
+	// dropped is the number of connections which couldn't be tracked
+	dropped int
+}
+
+// NewTracker returns a Tracker creating the decoders of the connections with
+// newDecoder, and calling onTx for each decoded transaction
+func NewTracker[T any](newDecoder func(types.ConnectionKey) Decoder[T], onTx func(*T)) *Tracker[T] {
+	return &Tracker[T]{
+		newDecoder: newDecoder,
+		onTx:       onTx,
+		flows:      make(map[types.ConnectionKey]*flow[T]),
+	}
+}
+
+// Add buffers segments, which are copied. The buffered segments are decoded
+// when the buffer is full, or on the next call to Flush.
+func (t *Tracker[T]) Add(segments []EbpfSegment) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for i := range segments {
+		if len(t.pending) >= maxPendingSegments {
+			t.flush()
+		}
+		t.pending = append(t.pending, segments[i])
+	}
+}
+
+// Flush decodes the buffered segments in the order they were captured, and
+// forgets the connections without traffic. It returns the number of
+// connections which couldn't be tracked since the previous call.
+func (t *Tracker[T]) Flush() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.flush()
+	dropped := t.dropped
+	t.dropped = 0
+	return dropped
+}
+
+func (t *Tracker[T]) flush() {
+	if len(t.pending) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(t.pending, func(a, b EbpfSegment) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	for i := range t.pending {
+		t.process(&t.pending[i])
+	}
+
+	now := t.pending[len(t.pending)-1].Timestamp
+	for key, f := range t.flows {
+		if now-f.lastSeen > uint64(flowTimeout) {
+			delete(t.flows, key)
+		}
+	}
+	t.pending = t.pending[:0]
+}
+
+func (t *Tracker[T]) process(s *EbpfSegment) {
+	key := s.ConnTuple()
+	closing := s.Flags&segmentClose != 0
+	f, ok := t.flows[key]
+	if !ok {
+		if s.Len == 0 {
+			return
+		}
+		if len(t.flows) >= maxFlows {
+			t.dropped++
+			return
+		}
+		f = &flow[T]{decoder: t.newDecoder(key)}
+		t.flows[key] = f
+	}
+	f.lastSeen = s.Timestamp
+
+	fromClient := s.Flags&segmentFromClient != 0
+	headLen, tailLen := int(s.Head_len), int(s.Tail_len)
+	if headLen > len(s.Head) || tailLen > len(s.Tail) {
+		return
+	}
+	f.decoder.Feed(fromClient, s.Head[:headLen], s.Timestamp, t.onTx)
+	if gap := int(s.Len) - headLen - tailLen; gap > 0 {
+		f.decoder.Skip(fromClient, gap)
+	}
+	f.decoder.Feed(fromClient, s.Tail[:tailLen], s.Timestamp, t.onTx)
+
+	if closing {
+		delete(t.flows, key)
+	}
+}
+
+// ConnTuple returns the connection tuple of the segment, from the client to
+// the server
+func (s *EbpfSegment) ConnTuple() types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: s.Tup.Saddr_h,
+		SrcIPLow:  s.Tup.Saddr_l,
+		DstIPHigh: s.Tup.Daddr_h,
+		DstIPLow:  s.Tup.Daddr_l,
+		SrcPort:   s.Tup.Sport,
+		DstPort:   s.Tup.Dport,
+	}
+}
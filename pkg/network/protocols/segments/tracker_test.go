@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package segments
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// recordingDecoder records the calls made by the tracker
+type recordingDecoder struct {
+	calls []string
+}
+
+func (d *recordingDecoder) Feed(fromClient bool, data []byte, _ uint64, onTx func(*string)) {
+	if len(data) == 0 {
+		return
+	}
+	call := fmt.Sprintf("feed %t %s", fromClient, data)
+	d.calls = append(d.calls, call)
+	onTx(&call)
+}
+
+func (d *recordingDecoder) Skip(fromClient bool, n int) {
+	d.calls = append(d.calls, fmt.Sprintf("skip %t %d", fromClient, n))
+}
+
+func (d *recordingDecoder) Stopped() bool {
+	return false
+}
+
+func newSegment(sport uint16, ts uint64, flags uint8, payload string) EbpfSegment {
+	s := EbpfSegment{
+		Tup:       ConnTuple{Saddr_l: 1, Daddr_l: 2, Sport: sport, Dport: 5432},
+		Timestamp: ts,
+		Len:       uint32(len(payload)),
+		Flags:     flags,
+	}
+	s.Head_len = uint16(copy(s.Head[:], payload))
+	if len(payload) > len(s.Head) {
+		tail := payload[max(len(s.Head), len(payload)-len(s.Tail)):]
+		s.Tail_len = uint8(copy(s.Tail[:], tail))
+	}
+	return s
+}
+
+func newRecordingTracker() (*Tracker[string], map[types.ConnectionKey]*recordingDecoder, *[]string) {
+	decoders := make(map[types.ConnectionKey]*recordingDecoder)
+	var txs []string
+	tracker := NewTracker(func(key types.ConnectionKey) Decoder[string] {
+		decoder := new(recordingDecoder)
+		decoders[key] = decoder
+		return decoder
+	}, func(tx *string) {
+		txs = append(txs, *tx)
+	})
+	return tracker, decoders, &txs
+}
+
+func TestTrackerOrder(t *testing.T) {
+	tracker, decoders, txs := newRecordingTracker()
+
+	// the segments captured on two CPUs are decoded in the order they were
+	// captured
+	tracker.Add([]EbpfSegment{
+		newSegment(1000, 1, segmentFromClient, "query"),
+		newSegment(1000, 4, segmentFromClient, "other query"),
+	})
+	tracker.Add([]EbpfSegment{
+		newSegment(1000, 2, 0, "response"),
+		newSegment(2000, 3, segmentFromClient, "query"),
+	})
+	assert.Empty(t, *txs)
+	assert.Zero(t, tracker.Flush())
+
+	require.Len(t, decoders, 2)
+	key := types.ConnectionKey{SrcIPLow: 1, DstIPLow: 2, SrcPort: 1000, DstPort: 5432}
+	require.Contains(t, decoders, key)
+	assert.Equal(t, []string{
+		"feed true query",
+		"feed false response",
+		"feed true other query",
+	}, decoders[key].calls)
+	assert.Len(t, *txs, 4)
+}
+
+func TestTrackerSkip(t *testing.T) {
+	tracker, decoders, _ := newRecordingTracker()
+
+	payload := make([]byte, 1000)
+	for i := range payload {
+		payload[i] = 'a' + byte(i%26)
+	}
+	tracker.Add([]EbpfSegment{newSegment(1000, 1, segmentFromClient, string(payload))})
+	tracker.Flush()
+
+	require.Len(t, decoders, 1)
+	for _, decoder := range decoders {
+		assert.Equal(t, []string{
+			"feed true " + string(payload[:320]),
+			"skip true 552",
+			"feed true " + string(payload[872:]),
+		}, decoder.calls)
+	}
+}
+
+func TestTrackerClose(t *testing.T) {
+	tracker, decoders, _ := newRecordingTracker()
+
+	tracker.Add([]EbpfSegment{
+		newSegment(1000, 1, segmentFromClient, "query"),
+		newSegment(1000, 2, segmentFromClient|segmentClose, ""),
+		// a closed connection isn't tracked again without payload
+		newSegment(1000, 3, segmentClose, ""),
+	})
+	tracker.Flush()
+	assert.Len(t, decoders, 1)
+	assert.Empty(t, tracker.flows)
+
+	// the connections without traffic are forgotten
+	tracker.Add([]EbpfSegment{
+		newSegment(2000, 4, segmentFromClient, "query"),
+		newSegment(3000, 4+uint64(3*time.Minute), segmentFromClient, "query"),
+	})
+	tracker.Flush()
+	assert.Len(t, decoders, 3)
+	assert.Len(t, tracker.flows, 1)
+}
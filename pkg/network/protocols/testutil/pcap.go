@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// PCAPSegment is the TCP payload of a captured packet
+type PCAPSegment struct {
+	Timestamp time.Time
+	// ConnectionKey identifies the connection, from the client to the server
+	ConnectionKey types.ConnectionKey
+	FromClient    bool
+	Payload       []byte
+}
+
+// ReadPCAP returns the non-empty TCP payloads of an Ethernet capture. The
+// segments sent to serverPort are flagged as sent by the client.
+func ReadPCAP(t *testing.T, path string, serverPort uint16) []PCAPSegment {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+
+	var segments []PCAPSegment
+	source := gopacket.NewPacketSource(r, r.LinkType())
+	for packet := range source.Packets() {
+		ip, ok := packet.NetworkLayer().(*layers.IPv4)
+		if !ok {
+			continue
+		}
+		tcp, ok := packet.TransportLayer().(*layers.TCP)
+		if !ok || len(tcp.Payload) == 0 {
+			continue
+		}
+
+		segment := PCAPSegment{
+			Timestamp:  packet.Metadata().Timestamp,
+			FromClient: uint16(tcp.DstPort) == serverPort,
+			Payload:    tcp.Payload,
+		}
+		if segment.FromClient {
+			segment.ConnectionKey = types.NewConnectionKey(util.AddressFromNetIP(ip.SrcIP), util.AddressFromNetIP(ip.DstIP), uint16(tcp.SrcPort), uint16(tcp.DstPort))
+		} else {
+			segment.ConnectionKey = types.NewConnectionKey(util.AddressFromNetIP(ip.DstIP), util.AddressFromNetIP(ip.SrcIP), uint16(tcp.DstPort), uint16(tcp.SrcPort))
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package replay
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http2"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const dnsPort = 53
+
+// segment is the transport layer of a captured packet
+type segment struct {
+	src, dst     util.Address
+	sport, dport uint16
+	family       network.ConnectionFamily
+	connType     network.ConnectionType
+	// tcp is nil for UDP datagrams
+	tcp     *layers.TCP
+	payload []byte
+	ts      time.Time
+}
+
+// flowKey identifies a flow, from the client to the server
+type flowKey struct {
+	client, server         util.Address
+	clientPort, serverPort uint16
+	connType               network.ConnectionType
+}
+
+// direction tracks the TCP sequence numbers sent by one side of a flow
+type direction struct {
+	nextSeq  uint32
+	seqKnown bool
+	fin      bool
+}
+
+// flow is a connection seen in the capture
+type flow struct {
+	key     flowKey
+	connKey types.ConnectionKey
+	stats   network.ConnectionStats
+	first   time.Time
+
+	client, server direction
+
+	// classified is set once the first payload sent by the client, or the
+	// greeting of a MySQL server, was used to detect the protocol stack of
+	// the flow
+	classified bool
+	// pendingHTTP is the HTTP transaction waiting for the next request or
+	// the end of the flow to be complete
+	pendingHTTP *http.EbpfEvent
+	http2       *http2.Decoder
+	postgres    *postgres.Decoder
+	mysql       *mysql.Decoder
+	redis       *redis.Decoder
+}
+
+// processSegment updates the flow the segment belongs to
+func (r *Replayer) processSegment(seg *segment) {
+	f, fromClient := r.lookupFlow(seg)
+	if f == nil && seg.tcp != nil && !seg.tcp.SYN && len(seg.payload) == 0 {
+		// the end of a closed flow, or a flow whose data wasn't captured
+		return
+	}
+	if f == nil {
+		f = r.newFlow(seg)
+		fromClient = seg.src == f.key.client && seg.sport == f.key.clientPort
+	}
+
+	f.stats.LastUpdateEpoch = uint64(seg.ts.UnixNano())
+	f.stats.Duration = seg.ts.Sub(f.first)
+	if fromClient {
+		f.stats.Monotonic.SentBytes += uint64(len(seg.payload))
+		f.stats.Monotonic.SentPackets++
+	} else {
+		f.stats.Monotonic.RecvBytes += uint64(len(seg.payload))
+		f.stats.Monotonic.RecvPackets++
+	}
+
+	if seg.tcp == nil {
+		return
+	}
+
+	dir := &f.server
+	if fromClient {
+		dir = &f.client
+	}
+
+	payload := seg.payload
+	if seg.tcp.SYN {
+		dir.nextSeq, dir.seqKnown = seg.tcp.Seq+1, true
+	} else if len(payload) > 0 {
+		// the bytes which were already seen are dropped, so that the
+		// decoders see each byte of the stream once
+		if overlap := int32(dir.nextSeq - seg.tcp.Seq); dir.seqKnown && overlap > 0 {
+			if fromClient {
+				f.stats.Monotonic.Retransmits++
+			}
+			payload = payload[min(int(overlap), len(payload)):]
+		}
+		if len(payload) > 0 {
+			dir.nextSeq, dir.seqKnown = seg.tcp.Seq+uint32(len(seg.payload)), true
+		}
+	}
+
+	if len(payload) > 0 {
+		r.processPayload(f, fromClient, payload, seg.ts)
+	}
+
+	if seg.tcp.FIN {
+		dir.fin = true
+	}
+	if seg.tcp.RST || (f.client.fin && f.server.fin) {
+		r.closeFlow(f)
+	}
+}
+
+// lookupFlow returns the flow of the segment, if any, and whether the segment
+// was sent by the client
+func (r *Replayer) lookupFlow(seg *segment) (*flow, bool) {
+	key := flowKey{client: seg.src, server: seg.dst, clientPort: seg.sport, serverPort: seg.dport, connType: seg.connType}
+	if f, ok := r.flows[key]; ok {
+		return f, true
+	}
+
+	key = flowKey{client: seg.dst, server: seg.src, clientPort: seg.dport, serverPort: seg.sport, connType: seg.connType}
+	if f, ok := r.flows[key]; ok {
+		return f, false
+	}
+	return nil, false
+}
+
+// newFlow creates the flow of a segment. The client is the sender of the
+// first segment, unless it is a SYN-ACK, as the capture may start after the
+// SYN was sent, or the greeting of a MySQL server, which speaks first.
+func (r *Replayer) newFlow(seg *segment) *flow {
+	client, server, clientPort, serverPort := seg.src, seg.dst, seg.sport, seg.dport
+	if seg.tcp != nil && (seg.tcp.SYN && seg.tcp.ACK || isMySQLGreeting(seg.payload)) {
+		client, server, clientPort, serverPort = seg.dst, seg.src, seg.dport, seg.sport
+	}
+
+	r.lastCookie++
+	f := &flow{
+		key:     flowKey{client: client, server: server, clientPort: clientPort, serverPort: serverPort, connType: seg.connType},
+		connKey: types.NewConnectionKey(client, server, clientPort, serverPort),
+		first:   seg.ts,
+		stats: network.ConnectionStats{
+			Source: client,
+			Dest:   server,
+			SPort:  clientPort,
+			DPort:  serverPort,
+			Type:   seg.connType,
+			Family: seg.family,
+			// there is no way to tell which side is local, so the
+			// connections are reported from the client side
+			Direction: network.OUTGOING,
+			Cookie:    r.lastCookie,
+		},
+	}
+	if seg.tcp != nil && seg.tcp.SYN {
+		f.stats.Monotonic.TCPEstablished = 1
+	}
+
+	r.flows[f.key] = f
+	return f
+}
+
+// closeFlow marks the flow as closed and removes it from the active flows
+func (r *Replayer) closeFlow(f *flow) {
+	r.flushFlow(f)
+	f.stats.IsClosed = true
+	f.stats.Monotonic.TCPClosed = 1
+	delete(r.flows, f.key)
+	r.closed = append(r.closed, f)
+}
+
+// sortedFlows returns the active flows in the order they were first seen
+func (r *Replayer) sortedFlows() []*flow {
+	flows := make([]*flow, 0, len(r.flows))
+	for _, f := range r.flows {
+		flows = append(flows, f)
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		return flows[i].stats.Cookie < flows[j].stats.Cookie
+	})
+	return flows
+}
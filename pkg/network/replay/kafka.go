@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package replay
+
+import (
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+// The limits of the Kafka requests parsed by the eBPF programs, see
+// pkg/network/ebpf/c/protocols/kafka/defs.h
+const (
+	kafkaProduceAPIKey              = 0
+	kafkaFetchAPIKey                = 1
+	kafkaMaxSupportedFetchVersion   = 11
+	kafkaMaxSupportedProduceVersion = 8
+	kafkaTopicNameMaxAllowedSize    = 255
+	kafkaRecordBatchMagic           = 2
+)
+
+// kafkaReader reads the big-endian fields of a Kafka request
+type kafkaReader struct {
+	buf    []byte
+	offset int
+	ok     bool
+}
+
+func (r *kafkaReader) skip(n int) {
+	r.offset += n
+}
+
+func (r *kafkaReader) bytes(n int) []byte {
+	if !r.ok || n < 0 || r.offset+n > len(r.buf) {
+		r.ok = false
+		return nil
+	}
+	b := r.buf[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *kafkaReader) int8() int8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (r *kafkaReader) int16() int16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (r *kafkaReader) int32() int32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+// parseKafkaRequest applies the validations of the eBPF programs to a
+// produce or fetch request and returns the transaction they would emit
+func parseKafkaRequest(payload []byte) (*kafka.EbpfTx, bool) {
+	r := &kafkaReader{buf: payload, ok: true}
+	messageSize := r.int32()
+	apiKey := r.int16()
+	apiVersion := r.int16()
+	correlationID := r.int32()
+	clientIDSize := r.int16()
+	if !r.ok || messageSize < int32(r.offset) || apiVersion < 0 || correlationID < 0 || clientIDSize < -1 {
+		return nil, false
+	}
+
+	switch apiKey {
+	case kafkaProduceAPIKey:
+		// version 0 is dropped because of false positives
+		if apiVersion == 0 || apiVersion > kafkaMaxSupportedProduceVersion {
+			return nil, false
+		}
+	case kafkaFetchAPIKey:
+		if apiVersion > kafkaMaxSupportedFetchVersion {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	if clientIDSize > 0 && !isPrintable(r.bytes(int(clientIDSize))) {
+		return nil, false
+	}
+
+	if apiKey == kafkaProduceAPIKey {
+		if apiVersion >= 3 {
+			if transactionalIDSize := r.int16(); transactionalIDSize > 0 {
+				r.skip(int(transactionalIDSize))
+			}
+		}
+		if acks := r.int16(); acks < -1 || acks > 1 {
+			return nil, false
+		}
+		if timeout := r.int32(); timeout < 0 {
+			return nil, false
+		}
+	} else {
+		// replica_id, max_wait_ms, min_bytes
+		r.skip(12)
+		if apiVersion >= 3 {
+			// max_bytes
+			r.skip(4)
+		}
+		if apiVersion >= 4 {
+			// isolation_level
+			r.skip(1)
+		}
+		if apiVersion >= 7 {
+			// session_id, session_epoch
+			r.skip(8)
+		}
+	}
+
+	// number of topics
+	r.skip(4)
+	topicNameSize := r.int16()
+	if topicNameSize <= 0 || topicNameSize > kafkaTopicNameMaxAllowedSize {
+		return nil, false
+	}
+	topicName := r.bytes(int(topicNameSize))
+	if !r.ok || !isValidTopicName(topicName) {
+		return nil, false
+	}
+
+	tx := &kafka.EbpfTx{
+		Request_api_key:     uint16(apiKey),
+		Request_api_version: uint16(apiVersion),
+		Topic_name_size:     uint16(topicNameSize),
+		Records_count:       1,
+	}
+	copy(tx.Topic_name[:], topicName)
+	if apiKey == kafkaFetchAPIKey {
+		// the records count of fetch requests is only known from the response
+		return tx, true
+	}
+
+	// only requests for a single partition are supported
+	if partitions := r.int32(); partitions != 1 {
+		return nil, false
+	}
+	// partition id, record set size, base offset, batch length and leader epoch
+	r.skip(4 + 4 + 8 + 4 + 4)
+	if magic := r.int8(); magic != kafkaRecordBatchMagic {
+		return nil, false
+	}
+	// crc, attributes, last offset delta, timestamps, producer id and epoch
+	// and base sequence
+	r.skip(4 + 2 + 4 + 8 + 8 + 8 + 2 + 4)
+	recordsCount := r.int32()
+	if !r.ok || recordsCount <= 0 {
+		return nil, false
+	}
+	tx.Records_count = uint32(recordsCount)
+	return tx, true
+}
+
+// isPrintable returns true if the client id is made of printable ASCII
+// characters
+func isPrintable(b []byte) bool {
+	if b == nil {
+		return false
+	}
+	for _, c := range b {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidTopicName returns true if the topic name is made of the characters
+// [a-zA-Z0-9._-]
+func isValidTopicName(b []byte) bool {
+	for _, c := range b {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func kafkaTuple(key types.ConnectionKey) kafka.ConnTuple {
+	return kafka.ConnTuple(httpTuple(key))
+}
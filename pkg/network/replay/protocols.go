@@ -0,0 +1,272 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http2"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+	// Postgres startup codes, sent by the client as the first message
+	postgresProtocolV3     = 196608
+	postgresSSLRequest     = 80877103
+	postgresGSSENCRequest  = 80877104
+	postgresStartupMinSize = 8
+
+	// the initial handshake packet of a MySQL server starts with the
+	// protocol version and the NUL-terminated server version
+	mysqlProtocolV10      = 10
+	mysqlGreetingMinSize  = 7
+	mysqlPacketHeaderSize = 4
+
+	tlsHandshake     = 0x16
+	tlsClientHello   = 0x01
+	tlsMajorVersion  = 0x03
+	tlsRecordMinSize = 6
+)
+
+var httpMethods = map[string]http.Method{
+	"GET":     http.MethodGet,
+	"POST":    http.MethodPost,
+	"PUT":     http.MethodPut,
+	"DELETE":  http.MethodDelete,
+	"HEAD":    http.MethodHead,
+	"OPTIONS": http.MethodOptions,
+	"PATCH":   http.MethodPatch,
+	"TRACE":   http.MethodTrace,
+}
+
+// processPayload feeds the decoder of the protocol of the flow
+func (r *Replayer) processPayload(f *flow, fromClient bool, payload []byte, ts time.Time) {
+	if !f.classified {
+		// as with the eBPF classification, the protocol is detected from
+		// the data sent by the client, except for MySQL whose server speaks
+		// first
+		if !fromClient {
+			if !isMySQLGreeting(payload) {
+				return
+			}
+			f.classified = true
+			f.stats.ProtocolStack.Application = protocols.MySQL
+			f.mysql = mysql.NewDecoder(f.connKey)
+		} else {
+			r.classify(f, payload)
+		}
+	}
+
+	nsTimestamp := uint64(ts.UnixNano())
+	switch f.stats.ProtocolStack.Application {
+	case protocols.HTTP:
+		r.processHTTP(f, fromClient, payload, nsTimestamp)
+	case protocols.HTTP2:
+		f.http2.Feed(fromClient, payload, nsTimestamp, func(tx *http2.Transaction) {
+			r.processHTTP2(f, tx)
+		})
+	case protocols.Kafka:
+		if !fromClient {
+			return
+		}
+		if tx, ok := parseKafkaRequest(payload); ok {
+			tx.Tup = kafkaTuple(f.connKey)
+			r.kafka.Process(tx)
+		}
+	case protocols.Postgres:
+		f.postgres.Feed(fromClient, payload, nsTimestamp, r.postgres.Process)
+	case protocols.MySQL:
+		f.mysql.Feed(fromClient, payload, nsTimestamp, r.mysql.Process)
+	case protocols.Redis:
+		f.redis.Feed(fromClient, payload, nsTimestamp, r.redis.Process)
+	}
+}
+
+// classify detects the protocol stack of a flow from the first payload sent
+// by the client
+func (r *Replayer) classify(f *flow, payload []byte) {
+	f.classified = true
+	stack := &f.stats.ProtocolStack
+	switch {
+	case isTLSClientHello(payload):
+		stack.Encryption = protocols.TLS
+	case bytes.HasPrefix(payload, []byte(http2Preface)):
+		stack.Application = protocols.HTTP2
+		f.http2 = http2.NewDecoder(f.connKey)
+	case httpMethod(payload) != http.MethodUnknown:
+		stack.Application = protocols.HTTP
+	case isPostgresStartup(payload):
+		stack.Application = protocols.Postgres
+		f.postgres = postgres.NewDecoder(f.connKey)
+	case isRedisCommand(payload):
+		stack.Application = protocols.Redis
+		f.redis = redis.NewDecoder(f.connKey)
+	default:
+		if _, ok := parseKafkaRequest(payload); ok {
+			stack.Application = protocols.Kafka
+		}
+	}
+}
+
+// processHTTP synthesizes the HTTP events of the eBPF program. A transaction
+// starts with a request and ends with the next request or the end of the
+// flow; the latency is measured up to the last response segment.
+func (r *Replayer) processHTTP(f *flow, fromClient bool, payload []byte, ts uint64) {
+	if fromClient {
+		method := httpMethod(payload)
+		if method == http.MethodUnknown {
+			// the body of the request
+			return
+		}
+		r.flushFlow(f)
+		f.pendingHTTP = &http.EbpfEvent{
+			Tuple: httpTuple(f.connKey),
+			Http: http.EbpfTx{
+				Request_started: ts,
+				Request_method:  uint8(method),
+			},
+		}
+		copy(f.pendingHTTP.Http.Request_fragment[:], payload)
+		return
+	}
+
+	tx := f.pendingHTTP
+	if tx == nil {
+		return
+	}
+	if tx.Http.Response_status_code == 0 {
+		status, ok := httpStatus(payload)
+		if !ok {
+			return
+		}
+		tx.Http.Response_status_code = status
+	}
+	tx.Http.Response_last_seen = ts
+}
+
+// processHTTP2 converts an HTTP/2 transaction to the event of the HTTP
+// programs, the request fragment holding the method and the path
+func (r *Replayer) processHTTP2(f *flow, tx *http2.Transaction) {
+	if tx.IsGRPC() {
+		f.stats.ProtocolStack.API = protocols.GRPC
+		r.grpc.Process(tx)
+	}
+
+	event := &http.EbpfEvent{
+		Tuple: httpTuple(f.connKey),
+		Http: http.EbpfTx{
+			Request_started:      tx.RequestStarted,
+			Response_last_seen:   tx.ResponseLastSeen,
+			Response_status_code: tx.StatusCode,
+			Request_method:       uint8(httpMethods[tx.Method]),
+		},
+	}
+	copy(event.Http.Request_fragment[:], tx.Method+" "+tx.Path+" HTTP/2")
+	r.http2.Process(event)
+}
+
+// flushFlow processes the pending transaction of a flow
+func (r *Replayer) flushFlow(f *flow) {
+	if f.pendingHTTP != nil {
+		r.http.Process(f.pendingHTTP)
+		f.pendingHTTP = nil
+	}
+}
+
+// httpMethod returns the method starting an HTTP request, or MethodUnknown
+func httpMethod(payload []byte) http.Method {
+	i := bytes.IndexByte(payload[:min(len(payload), len("OPTIONS")+1)], ' ')
+	if i <= 0 {
+		return http.MethodUnknown
+	}
+	return httpMethods[string(payload[:i])]
+}
+
+// httpStatus returns the status code starting an HTTP/1 response
+func httpStatus(payload []byte) (uint16, bool) {
+	// HTTP/1.1 200
+	if len(payload) < 12 || !bytes.HasPrefix(payload, []byte("HTTP/1.")) || payload[8] != ' ' {
+		return 0, false
+	}
+	status, err := strconv.ParseUint(string(payload[9:12]), 10, 16)
+	if err != nil || status < 100 {
+		return 0, false
+	}
+	return uint16(status), true
+}
+
+func isPostgresStartup(payload []byte) bool {
+	if len(payload) < postgresStartupMinSize {
+		return false
+	}
+	size := binary.BigEndian.Uint32(payload)
+	if size < postgresStartupMinSize {
+		return false
+	}
+	switch binary.BigEndian.Uint32(payload[4:]) {
+	case postgresProtocolV3, postgresSSLRequest, postgresGSSENCRequest:
+		return true
+	}
+	return false
+}
+
+// isMySQLGreeting returns true if the payload starts with the initial
+// handshake packet of a MySQL server
+func isMySQLGreeting(payload []byte) bool {
+	if len(payload) < mysqlGreetingMinSize || payload[3] != 0 || payload[4] != mysqlProtocolV10 {
+		return false
+	}
+	length := int(payload[0]) | int(payload[1])<<8 | int(payload[2])<<16
+	version := payload[5:min(len(payload), mysqlPacketHeaderSize+length)]
+	end := bytes.IndexByte(version, 0)
+	return end > 0 && version[0] >= '0' && version[0] <= '9'
+}
+
+// isRedisCommand returns true if the payload starts with a RESP array of
+// bulk strings, as sent by the clients
+func isRedisCommand(payload []byte) bool {
+	if len(payload) < 4 || payload[0] != '*' {
+		return false
+	}
+	end := bytes.Index(payload, []byte("\r\n"))
+	if end < 2 {
+		return false
+	}
+	if _, err := strconv.ParseUint(string(payload[1:end]), 10, 32); err != nil {
+		return false
+	}
+	return len(payload) > end+2 && payload[end+2] == '$'
+}
+
+func isTLSClientHello(payload []byte) bool {
+	return len(payload) >= tlsRecordMinSize &&
+		payload[0] == tlsHandshake &&
+		payload[1] == tlsMajorVersion &&
+		payload[5] == tlsClientHello
+}
+
+func httpTuple(key types.ConnectionKey) http.ConnTuple {
+	return http.ConnTuple{
+		Saddr_h: key.SrcIPHigh,
+		Saddr_l: key.SrcIPLow,
+		Daddr_h: key.DstIPHigh,
+		Daddr_l: key.DstIPLow,
+		Sport:   key.SrcPort,
+		Dport:   key.DstPort,
+	}
+}
@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+// Package replay feeds the packets of a capture file to the userspace parts of
+// the network tracer: the connection state, the DNS snooper and the USM
+// decoders and stat keepers. The events normally produced by the eBPF programs
+// are synthesized from the packets.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// clientID is the state client the connections are returned to
+const clientID = "replay"
+
+// pcapngMagic is the block type of the section header block starting a pcapng
+// file
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// Replayer replays captured packets
+type Replayer struct {
+	cfg *config.Config
+
+	flows map[flowKey]*flow
+	// closed holds the flows that ended, which are removed from flows so
+	// that the tuple can be reused by a new connection
+	closed     []*flow
+	lastCookie network.StatCookie
+	lastSeen   time.Time
+
+	dns      *dns.PacketReplayer
+	http     *http.StatKeeper
+	http2    *http.StatKeeper
+	kafka    *kafka.StatKeeper
+	postgres *postgres.StatKeeper
+	mysql    *mysql.StatKeeper
+	redis    *redis.StatKeeper
+	grpc     *grpc.StatKeeper
+
+	// packets counts the packets read from the capture, and skipped the ones
+	// which weren't TCP or UDP over IP
+	packets, skipped int
+}
+
+// New returns a new Replayer
+func New(cfg *config.Config) *Replayer {
+	httpTelemetry := http.NewTelemetry("http")
+	http2Telemetry := http.NewTelemetry("http2")
+	return &Replayer{
+		cfg:      cfg,
+		flows:    make(map[flowKey]*flow),
+		dns:      dns.NewPacketReplayer(cfg),
+		http:     http.NewStatkeeper(cfg, httpTelemetry, http.NewIncompleteBuffer(cfg, httpTelemetry)),
+		http2:    http.NewStatkeeper(cfg, http2Telemetry, http.NewIncompleteBuffer(cfg, http2Telemetry)),
+		kafka:    kafka.NewStatkeeper(cfg, kafka.NewTelemetry()),
+		postgres: postgres.NewStatkeeper(cfg, postgres.NewTelemetry()),
+		mysql:    mysql.NewStatkeeper(cfg, mysql.NewTelemetry()),
+		redis:    redis.NewStatkeeper(cfg, redis.NewTelemetry()),
+		grpc:     grpc.NewStatkeeper(cfg, grpc.NewTelemetry()),
+	}
+}
+
+// ReplayFile replays the packets of a pcap or pcapng file
+func (r *Replayer) ReplayFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.Replay(f)
+}
+
+// Replay replays the packets of a pcap or pcapng capture
+func (r *Replayer) Replay(capture io.Reader) error {
+	br := bufio.NewReader(capture)
+	magic, err := br.Peek(len(pcapngMagic))
+	if err != nil {
+		return fmt.Errorf("could not read capture header: %w", err)
+	}
+
+	var (
+		source   gopacket.PacketDataSource
+		linkType layers.LinkType
+	)
+	if bytes.Equal(magic, pcapngMagic) {
+		ngReader, err := pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			return fmt.Errorf("invalid pcapng capture: %w", err)
+		}
+		source, linkType = ngReader, ngReader.LinkType()
+	} else {
+		reader, err := pcapgo.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("invalid pcap capture: %w", err)
+		}
+		source, linkType = reader, reader.LinkType()
+	}
+
+	for {
+		data, ci, err := source.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// a truncated capture still holds useful packets
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			log.Warnf("capture is truncated after %d packets", r.packets)
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read packet %d: %w", r.packets+1, err)
+		}
+
+		r.packets++
+		packet := gopacket.NewPacket(data, linkType, gopacket.Lazy)
+		if !r.processPacket(packet, ci.Timestamp) {
+			r.skipped++
+		}
+	}
+
+	log.Debugf("replayed %d packets, skipped %d", r.packets, r.skipped)
+	return nil
+}
+
+// processPacket processes a decoded packet and returns false if it was skipped
+func (r *Replayer) processPacket(packet gopacket.Packet, ts time.Time) bool {
+	var (
+		src, dst util.Address
+		family   network.ConnectionFamily
+		dnsLayer gopacket.LayerType
+	)
+	switch ip := packet.NetworkLayer().(type) {
+	case *layers.IPv4:
+		src, dst = util.AddressFromNetIP(ip.SrcIP), util.AddressFromNetIP(ip.DstIP)
+		family, dnsLayer = network.AFINET, layers.LayerTypeIPv4
+	case *layers.IPv6:
+		src, dst = util.AddressFromNetIP(ip.SrcIP), util.AddressFromNetIP(ip.DstIP)
+		family, dnsLayer = network.AFINET6, layers.LayerTypeIPv6
+	default:
+		return false
+	}
+
+	if ts.After(r.lastSeen) {
+		r.lastSeen = ts
+	}
+
+	seg := segment{src: src, dst: dst, family: family, ts: ts}
+	switch transport := packet.TransportLayer().(type) {
+	case *layers.TCP:
+		seg.sport, seg.dport, seg.connType = uint16(transport.SrcPort), uint16(transport.DstPort), network.TCP
+		seg.tcp, seg.payload = transport, transport.Payload
+	case *layers.UDP:
+		seg.sport, seg.dport, seg.connType = uint16(transport.SrcPort), uint16(transport.DstPort), network.UDP
+		seg.payload = transport.Payload
+	default:
+		return false
+	}
+
+	if seg.sport == dnsPort || seg.dport == dnsPort {
+		// the DNS parser starts from the network layer, as it doesn't
+		// support every link type
+		ip := packet.NetworkLayer()
+		data := make([]byte, 0, len(ip.LayerContents())+len(ip.LayerPayload()))
+		data = append(append(data, ip.LayerContents()...), ip.LayerPayload()...)
+		if err := r.dns.ProcessPacket(dnsLayer, data, ts); err != nil {
+			log.Debugf("could not process dns packet: %s", err)
+		}
+	}
+
+	r.processSegment(&seg)
+	return true
+}
+
+// Connections returns the connections of the replayed packets along with the
+// stats aggregated by the DNS snooper and the USM stat keepers
+func (r *Replayer) Connections() *network.Connections {
+	state := network.NewState(
+		r.cfg.ClientStateExpiry,
+		r.cfg.MaxClosedConnectionsBuffered,
+		r.cfg.MaxConnectionsStateBuffered,
+		r.cfg.MaxDNSStatsBuffered,
+		r.cfg.MaxHTTPStatsBuffered,
+		r.cfg.MaxKafkaStatsBuffered,
+		r.cfg.MaxPostgresStatsBuffered,
+		r.cfg.MaxMySQLStatsBuffered,
+		r.cfg.MaxRedisStatsBuffered,
+		r.cfg.MaxGRPCStatsBuffered,
+		r.cfg.EnableNPMConnectionRollup,
+		false,
+	)
+	state.RegisterClient(clientID)
+
+	active := make([]network.ConnectionStats, 0, len(r.flows))
+	for _, f := range r.sortedFlows() {
+		r.flushFlow(f)
+		active = append(active, f.stats)
+	}
+	closed := make([]network.ConnectionStats, 0, len(r.closed))
+	for _, f := range r.closed {
+		closed = append(closed, f.stats)
+	}
+	state.StoreClosedConnections(closed)
+
+	usmStats := map[protocols.ProtocolType]interface{}{
+		protocols.HTTP:     r.http.GetAndResetAllStats(),
+		protocols.HTTP2:    r.http2.GetAndResetAllStats(),
+		protocols.Kafka:    r.kafka.GetAndResetAllStats(),
+		protocols.Postgres: r.postgres.GetAndResetAllStats(),
+		protocols.MySQL:    r.mysql.GetAndResetAllStats(),
+		protocols.Redis:    r.redis.GetAndResetAllStats(),
+		protocols.GRPC:     r.grpc.GetAndResetAllStats(),
+	}
+	delta := state.GetDelta(clientID, uint64(r.lastSeen.UnixNano()), active, r.dns.GetDNSStats(), usmStats)
+
+	ips := make(map[util.Address]struct{}, len(delta.Conns)/2)
+	for i := range delta.Conns {
+		ips[delta.Conns[i].Source] = struct{}{}
+		ips[delta.Conns[i].Dest] = struct{}{}
+	}
+
+	return &network.Connections{
+		BufferedData: network.BufferedData{Conns: delta.Conns},
+		DNS:          r.dns.Resolve(ips),
+		HTTP:         delta.HTTP,
+		HTTP2:        delta.HTTP2,
+		Kafka:        delta.Kafka,
+		Postgres:     delta.Postgres,
+		MySQL:        delta.MySQL,
+		Redis:        delta.Redis,
+		GRPC:         delta.GRPC,
+	}
+}
+
+// Close releases the resources held by the replayer
+func (r *Replayer) Close() {
+	r.dns.Close()
+	r.http.Close()
+	r.http2.Close()
+}
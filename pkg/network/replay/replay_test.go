@@ -0,0 +1,293 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding/marshal"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding/unmarshal"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func replayTestCapture(t *testing.T) *network.Connections {
+	cfg := config.New()
+	cfg.CollectDNSStats = true
+	cfg.CollectDNSDomains = true
+
+	r := New(cfg)
+	t.Cleanup(r.Close)
+	require.NoError(t, r.ReplayFile("testdata/mixed.pcap"))
+	return r.Connections()
+}
+
+func findConnection(t *testing.T, conns *network.Connections, dport uint16) network.ConnectionStats {
+	for _, c := range conns.Conns {
+		if c.DPort == dport {
+			return c
+		}
+	}
+	require.Failf(t, "connection not found", "no connection to port %d", dport)
+	return network.ConnectionStats{}
+}
+
+func TestReplayConnections(t *testing.T) {
+	conns := replayTestCapture(t)
+	require.Len(t, conns.Conns, 5)
+
+	dnsConn := findConnection(t, conns, 53)
+	assert.Equal(t, network.UDP, dnsConn.Type)
+	assert.Equal(t, util.AddressFromString("10.0.0.1"), dnsConn.Source)
+	assert.Equal(t, uint64(2), dnsConn.Monotonic.SentPackets)
+	assert.Equal(t, uint64(2), dnsConn.Monotonic.RecvPackets)
+
+	httpConn := findConnection(t, conns, 8080)
+	assert.Equal(t, network.TCP, httpConn.Type)
+	assert.Equal(t, network.OUTGOING, httpConn.Direction)
+	assert.True(t, httpConn.IsClosed)
+	assert.Equal(t, uint32(1), httpConn.Monotonic.TCPEstablished)
+	assert.Equal(t, uint32(1), httpConn.Monotonic.TCPClosed)
+	assert.Equal(t, protocols.HTTP, httpConn.ProtocolStack.Application)
+	// the retransmitted response isn't counted twice by the decoders, but
+	// is part of the received bytes
+	assert.Equal(t, uint64(154), httpConn.Monotonic.RecvBytes)
+
+	// the capture starts with the SYN-ACK of the Kafka connection
+	kafkaConn := findConnection(t, conns, 9092)
+	assert.Equal(t, util.AddressFromString("10.0.0.1"), kafkaConn.Source)
+	assert.Equal(t, uint16(40002), kafkaConn.SPort)
+	assert.Equal(t, uint32(1), kafkaConn.Monotonic.TCPEstablished)
+	assert.False(t, kafkaConn.IsClosed)
+	assert.Equal(t, protocols.Kafka, kafkaConn.ProtocolStack.Application)
+
+	postgresConn := findConnection(t, conns, 5432)
+	assert.True(t, postgresConn.IsClosed)
+	assert.Equal(t, protocols.Postgres, postgresConn.ProtocolStack.Application)
+
+	redisConn := findConnection(t, conns, 6379)
+	assert.False(t, redisConn.IsClosed)
+	assert.Equal(t, protocols.Redis, redisConn.ProtocolStack.Application)
+}
+
+func TestReplayDNS(t *testing.T) {
+	conns := replayTestCapture(t)
+
+	server := util.AddressFromString("10.0.0.2")
+	require.Contains(t, conns.DNS, server)
+	assert.Equal(t, []dns.Hostname{dns.ToHostname("web.example.com")}, conns.DNS[server])
+
+	dnsConn := findConnection(t, conns, 53)
+	stats := dnsConn.DNSStats
+	require.Contains(t, stats, dns.ToHostname("web.example.com"))
+	assert.Equal(t, uint32(1), stats[dns.ToHostname("web.example.com")][dns.TypeA].CountByRcode[0])
+	assert.Equal(t, uint64(2*time.Millisecond/time.Microsecond), stats[dns.ToHostname("web.example.com")][dns.TypeA].SuccessLatencySum)
+	require.Contains(t, stats, dns.ToHostname("missing.example.com"))
+	assert.Equal(t, uint32(1), stats[dns.ToHostname("missing.example.com")][dns.TypeA].CountByRcode[3])
+}
+
+func TestReplayUSM(t *testing.T) {
+	conns := replayTestCapture(t)
+
+	require.Len(t, conns.HTTP, 2)
+	statusByPath := make(map[string]uint16)
+	for key, stats := range conns.HTTP {
+		for status, stat := range stats.Data {
+			assert.Equal(t, 1, stat.Count)
+			statusByPath[key.Method.String()+" "+key.Path.Content.Get()] = status
+		}
+	}
+	assert.Equal(t, map[string]uint16{"GET /users/42": 200, "POST /orders": 503}, statusByPath)
+
+	require.Len(t, conns.Kafka, 1)
+	for key, stats := range conns.Kafka {
+		assert.Equal(t, "orders", key.TopicName)
+		assert.Equal(t, uint16(3), key.RequestVersion)
+		assert.Equal(t, 5, stats.Count)
+	}
+
+	require.Len(t, conns.Postgres, 1)
+	for key, stats := range conns.Postgres {
+		assert.Equal(t, "users", key.TableName)
+		assert.Equal(t, 1, stats.Count)
+	}
+
+	require.Len(t, conns.Redis, 2)
+}
+
+func TestReplayEncoding(t *testing.T) {
+	conns := replayTestCapture(t)
+
+	marshaler := marshal.GetMarshaler(marshal.ContentTypeJSON)
+	modeler := marshal.NewConnectionsModeler(conns)
+	defer modeler.Close()
+	var buf bytes.Buffer
+	require.NoError(t, marshaler.Marshal(conns, &buf, modeler))
+
+	payload, err := unmarshal.GetUnmarshaler(marshal.ContentTypeJSON).Unmarshal(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, payload.Conns, 5)
+	for _, c := range payload.Conns {
+		switch c.Raddr.Port {
+		case 8080:
+			assert.NotEmpty(t, c.HttpAggregations)
+		case 9092:
+			assert.NotEmpty(t, c.DataStreamsAggregations)
+		case 5432, 6379:
+			assert.NotEmpty(t, c.DatabaseAggregations, "port %d", c.Raddr.Port)
+		}
+	}
+}
+
+func TestReplayMySQL(t *testing.T) {
+	cfg := config.New()
+	r := New(cfg)
+	t.Cleanup(r.Close)
+	// the server of the capture speaks first, the protocol is detected from
+	// its greeting
+	require.NoError(t, r.ReplayFile("../protocols/mysql/testdata/queries.pcap"))
+	conns := r.Connections()
+
+	mysqlConn := findConnection(t, conns, 3306)
+	assert.Equal(t, protocols.MySQL, mysqlConn.ProtocolStack.Application)
+
+	queries := 0
+	for key, stats := range conns.MySQL {
+		assert.Equal(t, mysqlConn.DPort, key.DstPort)
+		queries += stats.Count
+	}
+	assert.Equal(t, 7, queries)
+}
+
+func TestReplayGRPC(t *testing.T) {
+	r := New(config.New())
+	t.Cleanup(r.Close)
+
+	f := &flow{connKey: types.NewConnectionKey(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 40000, 50051)}
+	client, server := newHTTP2Peer(t), newHTTP2Peer(t)
+	ts := time.Now()
+
+	require.NoError(t, client.framer.WriteSettings())
+	client.headers(1, false, ":method", "POST", ":scheme", "http", ":path", "/helloworld.Greeter/SayHello", ":authority", "localhost", "content-type", "application/grpc")
+	client.data(1, true, []byte{0, 0, 0, 0, 0})
+	r.processPayload(f, true, append([]byte(http2Preface), client.bytes()...), ts)
+
+	require.NoError(t, server.framer.WriteSettings())
+	server.headers(1, false, ":status", "200", "content-type", "application/grpc")
+	server.headers(1, true, "grpc-status", "5")
+	r.processPayload(f, false, server.bytes(), ts.Add(time.Millisecond))
+
+	assert.Equal(t, protocols.Stack{Application: protocols.HTTP2, API: protocols.GRPC}, f.stats.ProtocolStack)
+	stats := r.grpc.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for key, requests := range stats {
+		assert.Equal(t, "helloworld.Greeter", key.Service)
+		assert.Equal(t, "SayHello", key.Method)
+		require.Contains(t, requests.Data, grpc.NotFound)
+		assert.Equal(t, 1, requests.Data[grpc.NotFound].Count)
+	}
+}
+
+func TestIsMySQLGreeting(t *testing.T) {
+	greeting := append([]byte{0x0a, 0, 0, 0, mysqlProtocolV10}, []byte("8.0.36\x00")...)
+	assert.True(t, isMySQLGreeting(greeting))
+	// the length of the packet doesn't matter as long as the server version
+	// is complete
+	assert.True(t, isMySQLGreeting(append([]byte{0x4a, 0, 0, 0, mysqlProtocolV10}, []byte("5.7.44\x00")...)))
+
+	assert.False(t, isMySQLGreeting(greeting[:6]))
+	assert.False(t, isMySQLGreeting(append([]byte{0x0a, 0, 0, 1, mysqlProtocolV10}, []byte("8.0.36\x00")...)))
+	assert.False(t, isMySQLGreeting(append([]byte{0x0a, 0, 0, 0, 9}, []byte("8.0.36\x00")...)))
+	assert.False(t, isMySQLGreeting(append([]byte{0x0a, 0, 0, 0, mysqlProtocolV10}, []byte("version")...)))
+	assert.False(t, isMySQLGreeting([]byte("220 smtp.example.com ESMTP\r\n")))
+}
+
+// http2Peer writes the frames sent by one side of an HTTP/2 connection
+type http2Peer struct {
+	t       *testing.T
+	buf     bytes.Buffer
+	framer  *http2.Framer
+	hbuf    bytes.Buffer
+	encoder *hpack.Encoder
+}
+
+func newHTTP2Peer(t *testing.T) *http2Peer {
+	p := &http2Peer{t: t}
+	p.framer = http2.NewFramer(&p.buf, nil)
+	p.encoder = hpack.NewEncoder(&p.hbuf)
+	return p
+}
+
+func (p *http2Peer) headers(streamID uint32, endStream bool, fields ...string) {
+	p.hbuf.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		require.NoError(p.t, p.encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	require.NoError(p.t, p.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: p.hbuf.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	}))
+}
+
+func (p *http2Peer) data(streamID uint32, endStream bool, payload []byte) {
+	require.NoError(p.t, p.framer.WriteData(streamID, endStream, payload))
+}
+
+// bytes returns the frames written since the last call
+func (p *http2Peer) bytes() []byte {
+	b := append([]byte(nil), p.buf.Bytes()...)
+	p.buf.Reset()
+	return b
+}
+
+func TestParseKafkaRequest(t *testing.T) {
+	// a fetch request v4, with an empty client id
+	fetch := []byte{
+		0, 0, 0, 0x30, // size
+		0, 1, 0, 4, // fetch v4
+		0, 0, 0, 1, // correlation id
+		0xff, 0xff, // null client id
+		0xff, 0xff, 0xff, 0xff, 0, 0, 1, 0xf4, 0, 0, 0, 1, // replica, max wait, min bytes
+		0, 0x10, 0, 0, // max bytes
+		0,          // isolation level
+		0, 0, 0, 1, // topics
+		0, 6, 'o', 'r', 'd', 'e', 'r', 's',
+	}
+	tx, ok := parseKafkaRequest(fetch)
+	require.True(t, ok)
+	assert.Equal(t, uint16(1), tx.Request_api_key)
+	assert.Equal(t, uint16(4), tx.Request_api_version)
+	assert.Equal(t, "orders", string(tx.Topic_name[:tx.Topic_name_size]))
+	assert.Equal(t, uint32(1), tx.Records_count)
+
+	invalidTopic := append([]byte(nil), fetch...)
+	invalidTopic[len(invalidTopic)-1] = '!'
+	_, ok = parseKafkaRequest(invalidTopic)
+	assert.False(t, ok)
+
+	_, ok = parseKafkaRequest(fetch[:20])
+	assert.False(t, ok)
+
+	_, ok = parseKafkaRequest([]byte(strings.Repeat("GET / HTTP/1.1\r\n", 4)))
+	assert.False(t, ok)
+}
@@ -17,8 +17,12 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/network/slice"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -40,6 +44,10 @@ var stateTelemetry = struct {
 	httpStatsDropped       *telemetry.StatCounterWrapper
 	http2StatsDropped      *telemetry.StatCounterWrapper
 	kafkaStatsDropped      *telemetry.StatCounterWrapper
+	postgresStatsDropped   *telemetry.StatCounterWrapper
+	mysqlStatsDropped      *telemetry.StatCounterWrapper
+	redisStatsDropped      *telemetry.StatCounterWrapper
+	grpcStatsDropped       *telemetry.StatCounterWrapper
 	dnsPidCollisions       *telemetry.StatCounterWrapper
 	incomingDirectionFixes telemetry.Counter
 	outgoingDirectionFixes telemetry.Counter
@@ -53,6 +61,10 @@ var stateTelemetry = struct {
 	telemetry.NewStatCounterWrapper(stateModuleName, "http_stats_dropped", []string{}, "Counter measuring the number of http stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "http2_stats_dropped", []string{}, "Counter measuring the number of http2 stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "kafka_stats_dropped", []string{}, "Counter measuring the number of kafka stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "postgres_stats_dropped", []string{}, "Counter measuring the number of postgres stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "mysql_stats_dropped", []string{}, "Counter measuring the number of mysql stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "redis_stats_dropped", []string{}, "Counter measuring the number of redis stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "grpc_stats_dropped", []string{}, "Counter measuring the number of grpc stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "dns_pid_collisions", []string{}, "Counter measuring the number of DNS PID collisions"),
 	telemetry.NewCounter(stateModuleName, "incoming_direction_fixes", []string{}, "Counter measuring the number of udp direction fixes for incoming connections"),
 	telemetry.NewCounter(stateModuleName, "outgoing_direction_fixes", []string{}, "Counter measuring the number of udp/tcp direction fixes for outgoing connections"),
@@ -119,10 +131,14 @@ type State interface {
 
 // Delta represents a delta of network data compared to the last call to State.
 type Delta struct {
-	Conns []ConnectionStats
-	HTTP  map[http.Key]*http.RequestStats
-	HTTP2 map[http.Key]*http.RequestStats
-	Kafka map[kafka.Key]*kafka.RequestStat
+	Conns    []ConnectionStats
+	HTTP     map[http.Key]*http.RequestStats
+	HTTP2    map[http.Key]*http.RequestStats
+	Kafka    map[kafka.Key]*kafka.RequestStat
+	Postgres map[postgres.Key]*postgres.RequestStat
+	MySQL    map[mysql.Key]*mysql.RequestStat
+	Redis    map[redis.Key]*redis.RequestStats
+	GRPC     map[grpc.Key]*grpc.RequestStats
 }
 
 type lastStateTelemetry struct {
@@ -135,6 +151,10 @@ type lastStateTelemetry struct {
 	httpStatsDropped      int64
 	http2StatsDropped     int64
 	kafkaStatsDropped     int64
+	postgresStatsDropped  int64
+	mysqlStatsDropped     int64
+	redisStatsDropped     int64
+	grpcStatsDropped      int64
 	dnsPidCollisions      int64
 }
 
@@ -231,11 +251,15 @@ type client struct {
 	closed    *closedConnections
 	stats     map[StatCookie]StatCounters
 	// maps by dns key the domain (string) to stats structure
-	dnsStats        dns.StatsByKeyByNameByType
-	httpStatsDelta  map[http.Key]*http.RequestStats
-	http2StatsDelta map[http.Key]*http.RequestStats
-	kafkaStatsDelta map[kafka.Key]*kafka.RequestStat
-	lastTelemetries map[ConnTelemetryType]int64
+	dnsStats           dns.StatsByKeyByNameByType
+	httpStatsDelta     map[http.Key]*http.RequestStats
+	http2StatsDelta    map[http.Key]*http.RequestStats
+	kafkaStatsDelta    map[kafka.Key]*kafka.RequestStat
+	postgresStatsDelta map[postgres.Key]*postgres.RequestStat
+	mysqlStatsDelta    map[mysql.Key]*mysql.RequestStat
+	redisStatsDelta    map[redis.Key]*redis.RequestStats
+	grpcStatsDelta     map[grpc.Key]*grpc.RequestStats
+	lastTelemetries    map[ConnTelemetryType]int64
 }
 
 func (c *client) Reset() {
@@ -250,6 +274,10 @@ func (c *client) Reset() {
 	c.httpStatsDelta = make(map[http.Key]*http.RequestStats)
 	c.http2StatsDelta = make(map[http.Key]*http.RequestStats)
 	c.kafkaStatsDelta = make(map[kafka.Key]*kafka.RequestStat)
+	c.postgresStatsDelta = make(map[postgres.Key]*postgres.RequestStat)
+	c.mysqlStatsDelta = make(map[mysql.Key]*mysql.RequestStat)
+	c.redisStatsDelta = make(map[redis.Key]*redis.RequestStats)
+	c.grpcStatsDelta = make(map[grpc.Key]*grpc.RequestStats)
 }
 
 type networkState struct {
@@ -268,6 +296,10 @@ type networkState struct {
 	maxDNSStats                 int
 	maxHTTPStats                int
 	maxKafkaStats               int
+	maxPostgresStats            int
+	maxMySQLStats               int
+	maxRedisStats               int
+	maxGRPCStats                int
 	enableConnectionRollup      bool
 	processEventConsumerEnabled bool
 
@@ -277,7 +309,7 @@ type networkState struct {
 }
 
 // NewState creates a new network state
-func NewState(clientExpiry time.Duration, maxClosedConns uint32, maxClientStats int, maxDNSStats int, maxHTTPStats int, maxKafkaStats int, enableConnectionRollup bool, processEventConsumerEnabled bool) State {
+func NewState(clientExpiry time.Duration, maxClosedConns uint32, maxClientStats int, maxDNSStats int, maxHTTPStats int, maxKafkaStats int, maxPostgresStats int, maxMySQLStats int, maxRedisStats int, maxGRPCStats int, enableConnectionRollup bool, processEventConsumerEnabled bool) State {
 	ns := &networkState{
 		clients:                map[string]*client{},
 		clientExpiry:           clientExpiry,
@@ -286,6 +318,10 @@ func NewState(clientExpiry time.Duration, maxClosedConns uint32, maxClientStats
 		maxDNSStats:            maxDNSStats,
 		maxHTTPStats:           maxHTTPStats,
 		maxKafkaStats:          maxKafkaStats,
+		maxPostgresStats:       maxPostgresStats,
+		maxMySQLStats:          maxMySQLStats,
+		maxRedisStats:          maxRedisStats,
+		maxGRPCStats:           maxGRPCStats,
 		enableConnectionRollup: enableConnectionRollup,
 		mergeStatsBuffers: [2][]byte{
 			make([]byte, ConnectionByteKeyMaxLen),
@@ -402,14 +438,30 @@ func (ns *networkState) GetDelta(
 		case protocols.HTTP2:
 			stats := protocolStats.(map[http.Key]*http.RequestStats)
 			ns.storeHTTP2Stats(stats)
+		case protocols.Postgres:
+			stats := protocolStats.(map[postgres.Key]*postgres.RequestStat)
+			ns.storePostgresStats(stats)
+		case protocols.MySQL:
+			stats := protocolStats.(map[mysql.Key]*mysql.RequestStat)
+			ns.storeMySQLStats(stats)
+		case protocols.Redis:
+			stats := protocolStats.(map[redis.Key]*redis.RequestStats)
+			ns.storeRedisStats(stats)
+		case protocols.GRPC:
+			stats := protocolStats.(map[grpc.Key]*grpc.RequestStats)
+			ns.storeGRPCStats(stats)
 		}
 	}
 
 	return Delta{
-		Conns: append(active, closed...),
-		HTTP:  client.httpStatsDelta,
-		HTTP2: client.http2StatsDelta,
-		Kafka: client.kafkaStatsDelta,
+		Conns:    append(active, closed...),
+		HTTP:     client.httpStatsDelta,
+		HTTP2:    client.http2StatsDelta,
+		Kafka:    client.kafkaStatsDelta,
+		Postgres: client.postgresStatsDelta,
+		MySQL:    client.mysqlStatsDelta,
+		Redis:    client.redisStatsDelta,
+		GRPC:     client.grpcStatsDelta,
 	}
 }
 
@@ -462,11 +514,17 @@ func (ns *networkState) logTelemetry() {
 	httpStatsDroppedDelta := stateTelemetry.httpStatsDropped.Load() - ns.lastTelemetry.httpStatsDropped
 	http2StatsDroppedDelta := stateTelemetry.http2StatsDropped.Load() - ns.lastTelemetry.http2StatsDropped
 	kafkaStatsDroppedDelta := stateTelemetry.kafkaStatsDropped.Load() - ns.lastTelemetry.kafkaStatsDropped
+	postgresStatsDroppedDelta := stateTelemetry.postgresStatsDropped.Load() - ns.lastTelemetry.postgresStatsDropped
+	mysqlStatsDroppedDelta := stateTelemetry.mysqlStatsDropped.Load() - ns.lastTelemetry.mysqlStatsDropped
+	redisStatsDroppedDelta := stateTelemetry.redisStatsDropped.Load() - ns.lastTelemetry.redisStatsDropped
+	grpcStatsDroppedDelta := stateTelemetry.grpcStatsDropped.Load() - ns.lastTelemetry.grpcStatsDropped
 	dnsPidCollisionsDelta := stateTelemetry.dnsPidCollisions.Load() - ns.lastTelemetry.dnsPidCollisions
 
 	// Flush log line if any metric is non-zero
 	if connDroppedDelta > 0 || closedConnDroppedDelta > 0 || dnsStatsDroppedDelta > 0 ||
-		httpStatsDroppedDelta > 0 || http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 {
+		httpStatsDroppedDelta > 0 || http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 ||
+		postgresStatsDroppedDelta > 0 || mysqlStatsDroppedDelta > 0 || redisStatsDroppedDelta > 0 ||
+		grpcStatsDroppedDelta > 0 {
 		s := "State telemetry: "
 		s += " [%d connections dropped due to stats]"
 		s += " [%d closed connections dropped]"
@@ -474,6 +532,10 @@ func (ns *networkState) logTelemetry() {
 		s += " [%d HTTP stats dropped]"
 		s += " [%d HTTP2 stats dropped]"
 		s += " [%d Kafka stats dropped]"
+		s += " [%d Postgres stats dropped]"
+		s += " [%d MySQL stats dropped]"
+		s += " [%d Redis stats dropped]"
+		s += " [%d gRPC stats dropped]"
 		log.Warnf(s,
 			connDroppedDelta,
 			closedConnDroppedDelta,
@@ -481,6 +543,10 @@ func (ns *networkState) logTelemetry() {
 			httpStatsDroppedDelta,
 			http2StatsDroppedDelta,
 			kafkaStatsDroppedDelta,
+			postgresStatsDroppedDelta,
+			mysqlStatsDroppedDelta,
+			redisStatsDroppedDelta,
+			grpcStatsDroppedDelta,
 		)
 	}
 
@@ -509,6 +575,10 @@ func (ns *networkState) logTelemetry() {
 	ns.lastTelemetry.httpStatsDropped = stateTelemetry.httpStatsDropped.Load()
 	ns.lastTelemetry.http2StatsDropped = stateTelemetry.http2StatsDropped.Load()
 	ns.lastTelemetry.kafkaStatsDropped = stateTelemetry.kafkaStatsDropped.Load()
+	ns.lastTelemetry.postgresStatsDropped = stateTelemetry.postgresStatsDropped.Load()
+	ns.lastTelemetry.mysqlStatsDropped = stateTelemetry.mysqlStatsDropped.Load()
+	ns.lastTelemetry.redisStatsDropped = stateTelemetry.redisStatsDropped.Load()
+	ns.lastTelemetry.grpcStatsDropped = stateTelemetry.grpcStatsDropped.Load()
 	ns.lastTelemetry.dnsPidCollisions = stateTelemetry.dnsPidCollisions.Load()
 }
 
@@ -639,6 +709,14 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 						for rcode, count := range dnsStats.CountByRcode {
 							prev.CountByRcode[rcode] += count
 						}
+						prev.ClientSubnetQueries += dnsStats.ClientSubnetQueries
+						prev.NXDomainStorm = prev.NXDomainStorm || dnsStats.NXDomainStorm
+						for code, count := range dnsStats.CountByExtendedError {
+							if prev.CountByExtendedError == nil {
+								prev.CountByExtendedError = make(map[uint16]uint32)
+							}
+							prev.CountByExtendedError[code] += count
+						}
 						client.dnsStats[key][domain][qtype] = prev
 						continue
 					}
@@ -750,20 +828,148 @@ func (ns *networkState) storeKafkaStats(allStats map[kafka.Key]*kafka.RequestSta
 	}
 }
 
+// storePostgresStats stores the latest Postgres stats for all clients
+func (ns *networkState) storePostgresStats(allStats map[postgres.Key]*postgres.RequestStat) {
+	if len(ns.clients) == 1 {
+		for _, client := range ns.clients {
+			if len(client.postgresStatsDelta) == 0 && len(allStats) <= ns.maxPostgresStats {
+				// optimization for the common case:
+				// if there is only one client and no previous state, no memory allocation is needed
+				client.postgresStatsDelta = allStats
+				return
+			}
+		}
+	}
+
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.postgresStatsDelta[key]
+			if !ok && len(client.postgresStatsDelta) >= ns.maxPostgresStats {
+				stateTelemetry.postgresStatsDropped.Inc()
+				continue
+			}
+
+			if prevStats != nil {
+				prevStats.CombineWith(stats)
+				client.postgresStatsDelta[key] = prevStats
+			} else {
+				client.postgresStatsDelta[key] = stats
+			}
+		}
+	}
+}
+
+// storeMySQLStats stores the latest MySQL stats for all clients
+func (ns *networkState) storeMySQLStats(allStats map[mysql.Key]*mysql.RequestStat) {
+	if len(ns.clients) == 1 {
+		for _, client := range ns.clients {
+			if len(client.mysqlStatsDelta) == 0 && len(allStats) <= ns.maxMySQLStats {
+				// optimization for the common case:
+				// if there is only one client and no previous state, no memory allocation is needed
+				client.mysqlStatsDelta = allStats
+				return
+			}
+		}
+	}
+
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.mysqlStatsDelta[key]
+			if !ok && len(client.mysqlStatsDelta) >= ns.maxMySQLStats {
+				stateTelemetry.mysqlStatsDropped.Inc()
+				continue
+			}
+
+			if prevStats != nil {
+				prevStats.CombineWith(stats)
+				client.mysqlStatsDelta[key] = prevStats
+			} else {
+				client.mysqlStatsDelta[key] = stats
+			}
+		}
+	}
+}
+
+// storeRedisStats stores the latest Redis stats for all clients
+func (ns *networkState) storeRedisStats(allStats map[redis.Key]*redis.RequestStats) {
+	if len(ns.clients) == 1 {
+		for _, client := range ns.clients {
+			if len(client.redisStatsDelta) == 0 && len(allStats) <= ns.maxRedisStats {
+				// optimization for the common case:
+				// if there is only one client and no previous state, no memory allocation is needed
+				client.redisStatsDelta = allStats
+				return
+			}
+		}
+	}
+
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.redisStatsDelta[key]
+			if !ok && len(client.redisStatsDelta) >= ns.maxRedisStats {
+				stateTelemetry.redisStatsDropped.Inc()
+				continue
+			}
+
+			if prevStats != nil {
+				prevStats.CombineWith(stats)
+				client.redisStatsDelta[key] = prevStats
+			} else {
+				client.redisStatsDelta[key] = stats
+			}
+		}
+	}
+}
+
+// storeGRPCStats stores the latest gRPC stats for all clients
+func (ns *networkState) storeGRPCStats(allStats map[grpc.Key]*grpc.RequestStats) {
+	if len(ns.clients) == 1 {
+		for _, client := range ns.clients {
+			if len(client.grpcStatsDelta) == 0 && len(allStats) <= ns.maxGRPCStats {
+				// optimization for the common case:
+				// if there is only one client and no previous state, no memory allocation is needed
+				client.grpcStatsDelta = allStats
+				return
+			}
+		}
+	}
+
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.grpcStatsDelta[key]
+			if !ok && len(client.grpcStatsDelta) >= ns.maxGRPCStats {
+				stateTelemetry.grpcStatsDropped.Inc()
+				continue
+			}
+
+			if prevStats != nil {
+				prevStats.CombineWith(stats)
+				client.grpcStatsDelta[key] = prevStats
+			} else {
+				client.grpcStatsDelta[key] = stats
+			}
+		}
+	}
+}
+
 func (ns *networkState) getClient(clientID string) *client {
 	if c, ok := ns.clients[clientID]; ok {
 		return c
 	}
 	closedConnections := &closedConnections{conns: make([]ConnectionStats, 0, minClosedCapacity), byCookie: make(map[StatCookie]int)}
 	c := &client{
-		lastFetch:       time.Now(),
-		stats:           make(map[StatCookie]StatCounters),
-		closed:          closedConnections,
-		dnsStats:        dns.StatsByKeyByNameByType{},
-		httpStatsDelta:  map[http.Key]*http.RequestStats{},
-		http2StatsDelta: map[http.Key]*http.RequestStats{},
-		kafkaStatsDelta: map[kafka.Key]*kafka.RequestStat{},
-		lastTelemetries: make(map[ConnTelemetryType]int64),
+		lastFetch:          time.Now(),
+		stats:              make(map[StatCookie]StatCounters),
+		closed:             closedConnections,
+		dnsStats:           dns.StatsByKeyByNameByType{},
+		httpStatsDelta:     map[http.Key]*http.RequestStats{},
+		http2StatsDelta:    map[http.Key]*http.RequestStats{},
+		kafkaStatsDelta:    map[kafka.Key]*kafka.RequestStat{},
+		postgresStatsDelta: map[postgres.Key]*postgres.RequestStat{},
+		mysqlStatsDelta:    map[mysql.Key]*mysql.RequestStat{},
+		redisStatsDelta:    map[redis.Key]*redis.RequestStats{},
+		grpcStatsDelta:     map[grpc.Key]*grpc.RequestStats{},
+		lastTelemetries:    make(map[ConnTelemetryType]int64),
 	}
 	ns.clients[clientID] = c
 	return c
@@ -1356,6 +1562,14 @@ func (ac *aggregateConnection) merge(c *ConnectionStats) {
 				for rcode, count := range stats.CountByRcode {
 					queryStats.CountByRcode[rcode] += count
 				}
+				queryStats.ClientSubnetQueries += stats.ClientSubnetQueries
+				queryStats.NXDomainStorm = queryStats.NXDomainStorm || stats.NXDomainStorm
+				for code, count := range stats.CountByExtendedError {
+					if queryStats.CountByExtendedError == nil {
+						queryStats.CountByExtendedError = make(map[uint16]uint32)
+					}
+					queryStats.CountByExtendedError[code] += count
+				}
 				hostStats[q] = queryStats
 			}
 		}
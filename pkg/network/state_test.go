@@ -25,9 +25,12 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
 	"github.com/DataDog/datadog-agent/pkg/network/slice"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -544,7 +547,7 @@ func TestNoPriorRegistrationActiveConnections(t *testing.T) {
 func TestCleanupClient(t *testing.T) {
 	clientID := "1"
 
-	state := NewState(100*time.Millisecond, 50000, 75000, 75000, 75000, 75000, false, false)
+	state := NewState(100*time.Millisecond, 50000, 75000, 75000, 75000, 75000, 75000, 75000, 75000, 75000, false, false)
 	clients := state.(*networkState).getClients()
 	assert.Equal(t, 0, len(clients))
 
@@ -2282,6 +2285,82 @@ func TestKafkaStats(t *testing.T) {
 	assert.Len(t, delta.Kafka, 0)
 }
 
+func TestMySQLStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  3306,
+	}
+
+	connKey := types.NewConnectionKey(c.Source, c.Dest, c.SPort, c.DPort)
+	getStats := func(signature string) map[protocols.ProtocolType]interface{} {
+		stats := new(mysql.RequestStat)
+		stats.Add(1000, false)
+		return map[protocols.ProtocolType]interface{}{
+			protocols.MySQL: map[mysql.Key]*mysql.RequestStat{
+				mysql.NewKey(connKey, mysql.SelectOP, "dummy", signature): stats,
+			},
+		}
+	}
+
+	state := newDefaultState()
+	state.RegisterClient("client1")
+	state.RegisterClient("client2")
+
+	delta := state.GetDelta("client1", latestEpochTime(), []ConnectionStats{c}, nil, getStats("SELECT * FROM dummy"))
+	assert.Len(t, delta.MySQL, 1)
+
+	// the stats are stored for the other client and merged with its next ones
+	delta = state.GetDelta("client2", latestEpochTime(), []ConnectionStats{c}, nil, getStats("SELECT * FROM dummy"))
+	require.Len(t, delta.MySQL, 1)
+	for _, stats := range delta.MySQL {
+		assert.Equal(t, 2, stats.Count)
+	}
+
+	// the stats are flushed
+	delta = state.GetDelta("client2", latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	assert.Len(t, delta.MySQL, 0)
+}
+
+func TestGRPCStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  50051,
+	}
+
+	connKey := types.NewConnectionKey(c.Source, c.Dest, c.SPort, c.DPort)
+	getStats := func(status grpc.StatusCode) map[protocols.ProtocolType]interface{} {
+		stats := grpc.NewRequestStats()
+		stats.AddRequest(status, 1000)
+		return map[protocols.ProtocolType]interface{}{
+			protocols.GRPC: map[grpc.Key]*grpc.RequestStats{
+				grpc.NewKey(connKey, "helloworld.Greeter", "SayHello"): stats,
+			},
+		}
+	}
+
+	state := newDefaultState()
+	state.RegisterClient("client1")
+	state.RegisterClient("client2")
+
+	delta := state.GetDelta("client1", latestEpochTime(), []ConnectionStats{c}, nil, getStats(grpc.OK))
+	assert.Len(t, delta.GRPC, 1)
+
+	// the stats are stored for the other client and merged with its next ones
+	delta = state.GetDelta("client2", latestEpochTime(), []ConnectionStats{c}, nil, getStats(grpc.NotFound))
+	require.Len(t, delta.GRPC, 1)
+	for _, stats := range delta.GRPC {
+		assert.Len(t, stats.Data, 2)
+	}
+
+	// the stats are flushed
+	delta = state.GetDelta("client2", latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	assert.Len(t, delta.GRPC, 0)
+}
+
 func TestKafkaStatsWithMultipleClients(t *testing.T) {
 	c := ConnectionStats{
 		Source: util.AddressFromString("1.1.1.1"),
@@ -2849,7 +2928,7 @@ func latestEpochTime() uint64 {
 
 func newDefaultState() *networkState {
 	// Using values from ebpf.NewConfig()
-	return NewState(2*time.Minute, 50000, 75000, 75000, 7500, 7500, false, false).(*networkState)
+	return NewState(2*time.Minute, 50000, 75000, 75000, 7500, 7500, 7500, 7500, 7500, 7500, false, false).(*networkState)
 }
 
 func getIPProtocol(nt ConnectionType) uint8 {
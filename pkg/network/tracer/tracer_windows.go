@@ -78,6 +78,10 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		config.MaxDNSStatsBuffered,
 		config.MaxHTTPStatsBuffered,
 		config.MaxKafkaStatsBuffered,
+		config.MaxPostgresStatsBuffered,
+		config.MaxMySQLStatsBuffered,
+		config.MaxRedisStatsBuffered,
+		config.MaxGRPCStatsBuffered,
 		config.EnableNPMConnectionRollup,
 		config.EnableProcessEventMonitoring,
 	)
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+// Package interfacestore keeps the metadata of the interfaces of the devices
+// monitored by the SNMP check, so that it can be joined with the data sent by
+// the same devices, such as the flows they export.
+package interfacestore
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long the interfaces of a device are kept after they were
+// last reported, the SNMP check reporting them every few minutes
+const DefaultTTL = 1 * time.Hour
+
+// Interface contains the metadata of an interface
+type Interface struct {
+	Name  string
+	Alias string
+	// Speed is in bits per second, zero if unknown
+	Speed uint64
+}
+
+type deviceInterfaces struct {
+	interfaces map[uint32]Interface
+	updatedAt  time.Time
+}
+
+// Store holds the interfaces of the devices by device ID, the device ID being
+// the namespace and the IP address of the device joined by a colon
+type Store struct {
+	mu      sync.RWMutex
+	devices map[string]deviceInterfaces
+	ttl     time.Duration
+	timeNow func() time.Time
+}
+
+// NewStore returns a new store keeping the interfaces for the given TTL
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		devices: make(map[string]deviceInterfaces),
+		ttl:     ttl,
+		timeNow: time.Now,
+	}
+}
+
+// SetDeviceInterfaces replaces the interfaces of a device, by interface index
+func (s *Store) SetDeviceInterfaces(deviceID string, interfaces map[uint32]Interface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timeNow()
+	s.devices[deviceID] = deviceInterfaces{interfaces: interfaces, updatedAt: now}
+
+	// the devices are few, expire them when a device is updated rather
+	// than from a dedicated routine
+	for id, device := range s.devices {
+		if now.Sub(device.updatedAt) > s.ttl {
+			delete(s.devices, id)
+		}
+	}
+}
+
+// GetInterface returns the interface of a device with the given index
+func (s *Store) GetInterface(deviceID string, index uint32) (Interface, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	device, ok := s.devices[deviceID]
+	if !ok || s.timeNow().Sub(device.updatedAt) > s.ttl {
+		return Interface{}, false
+	}
+	iface, ok := device.interfaces[index]
+	return iface, ok
+}
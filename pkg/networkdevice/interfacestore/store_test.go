@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package interfacestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Hour)
+	store.timeNow = func() time.Time { return now }
+
+	store.SetDeviceInterfaces("default:10.0.0.1", map[uint32]Interface{
+		1: {Name: "eth0", Alias: "uplink", Speed: 1e9},
+	})
+	store.SetDeviceInterfaces("default:10.0.0.2", map[uint32]Interface{
+		1: {Name: "ge-0/0/1"},
+	})
+
+	iface, ok := store.GetInterface("default:10.0.0.1", 1)
+	assert.True(t, ok)
+	assert.Equal(t, Interface{Name: "eth0", Alias: "uplink", Speed: 1e9}, iface)
+
+	_, ok = store.GetInterface("default:10.0.0.1", 2)
+	assert.False(t, ok)
+	_, ok = store.GetInterface("other:10.0.0.1", 1)
+	assert.False(t, ok)
+
+	// the interfaces of a device are replaced
+	store.SetDeviceInterfaces("default:10.0.0.1", map[uint32]Interface{
+		2: {Name: "eth1"},
+	})
+	_, ok = store.GetInterface("default:10.0.0.1", 1)
+	assert.False(t, ok)
+
+	// the interfaces expire once the device isn't reported anymore
+	now = now.Add(30 * time.Minute)
+	store.SetDeviceInterfaces("default:10.0.0.1", map[uint32]Interface{
+		2: {Name: "eth1"},
+	})
+	now = now.Add(31 * time.Minute)
+	_, ok = store.GetInterface("default:10.0.0.2", 1)
+	assert.False(t, ok)
+	_, ok = store.GetInterface("default:10.0.0.1", 2)
+	assert.True(t, ok)
+
+	store.SetDeviceInterfaces("default:10.0.0.3", nil)
+	assert.NotContains(t, store.devices, "default:10.0.0.2")
+}
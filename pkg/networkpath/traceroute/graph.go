@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hopGroup holds the probes of a flow sent with the same TTL
+type hopGroup struct {
+	ttl    uint8
+	probes []hopProbe
+}
+
+// responder returns the address which answered most of the probes, or an
+// empty string if none was answered
+func (g *hopGroup) responder() string {
+	counts := make(map[string]int)
+	best := ""
+	for _, p := range g.probes {
+		if !p.received {
+			continue
+		}
+		ip := p.ip.String()
+		counts[ip]++
+		if best == "" || counts[ip] > counts[best] {
+			best = ip
+		}
+	}
+	return best
+}
+
+// flowPath returns the probes of a flow grouped by TTL, up to the TTL which
+// reached the destination. When the destination wasn't reached, the path ends
+// with the first unanswered TTL after the last answered one.
+func flowPath(probes []hopProbe) []hopGroup {
+	byTTL := make(map[uint8][]hopProbe)
+	var ttls []int
+	destTTL, lastAnswered := uint8(0), uint8(0)
+	for _, p := range probes {
+		if _, ok := byTTL[p.ttl]; !ok {
+			ttls = append(ttls, int(p.ttl))
+		}
+		byTTL[p.ttl] = append(byTTL[p.ttl], p)
+		if p.received && p.isDest && (destTTL == 0 || p.ttl < destTTL) {
+			destTTL = p.ttl
+		}
+		if p.received && p.ttl > lastAnswered {
+			lastAnswered = p.ttl
+		}
+	}
+	sort.Ints(ttls)
+
+	var path []hopGroup
+	for _, ttl := range ttls {
+		if destTTL != 0 && uint8(ttl) > destTTL {
+			break
+		}
+		if destTTL == 0 && len(path) > 0 && uint8(ttl) > lastAnswered && path[len(path)-1].ttl > lastAnswered {
+			break
+		}
+		path = append(path, hopGroup{ttl: uint8(ttl), probes: byTTL[uint8(ttl)]})
+	}
+	return path
+}
+
+// nodeStats accumulates the probes attributed to a node of the graph
+type nodeStats struct {
+	node NetworkPathNode
+	rtts []time.Duration
+}
+
+func (s *nodeStats) add(g hopGroup) {
+	for _, p := range g.probes {
+		s.node.ProbesSent++
+		if p.received {
+			s.node.ProbesReceived++
+			s.rtts = append(s.rtts, p.rtt)
+		}
+	}
+}
+
+func (s *nodeStats) finalize() NetworkPathNode {
+	n := s.node
+	n.Reachable = n.ProbesReceived > 0
+	if n.ProbesSent > 0 {
+		n.PacketLossPct = 100 * float64(n.ProbesSent-n.ProbesReceived) / float64(n.ProbesSent)
+	}
+	if len(s.rtts) == 0 {
+		return n
+	}
+
+	n.RTTMin = math.MaxFloat64
+	var sum, jitterSum float64
+	for i, rtt := range s.rtts {
+		ms := durationMs(rtt)
+		sum += ms
+		n.RTTMin = math.Min(n.RTTMin, ms)
+		n.RTTMax = math.Max(n.RTTMax, ms)
+		if i > 0 {
+			jitterSum += math.Abs(ms - durationMs(s.rtts[i-1]))
+		}
+	}
+	n.RTTAvg = sum / float64(len(s.rtts))
+	if len(s.rtts) > 1 {
+		n.Jitter = jitterSum / float64(len(s.rtts)-1)
+	}
+	return n
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func unknownHopID(ttl uint8) string {
+	return fmt.Sprintf("unknown_hop_%d", ttl)
+}
+
+// buildGraph merges the paths of the flows in a graph whose nodes are the
+// hops, and returns the linear path of the first flow along with it. The
+// probes are expected in the order they were sent.
+func buildGraph(probes []hopProbe, flowIDs []uint16, resolve func(ip string) string) (*NetworkPathGraph, []NetworkPathHop) {
+	byFlow := make(map[uint16][]hopProbe)
+	for _, p := range probes {
+		byFlow[p.flowID] = append(byFlow[p.flowID], p)
+	}
+
+	hostnames := make(map[string]string)
+	hostname := func(ip string) string {
+		if _, ok := hostnames[ip]; !ok {
+			hostnames[ip] = resolve(ip)
+		}
+		return hostnames[ip]
+	}
+
+	graph := &NetworkPathGraph{FlowIDs: flowIDs}
+	nodes := make(map[string]*nodeStats)
+	var nodeIDs []string
+	links := make(map[[2]string]int)
+	paths := make(map[string]struct{})
+	var hops []NetworkPathHop
+
+	for i, flowID := range flowIDs {
+		prev := ""
+		var path []string
+		for _, g := range flowPath(byFlow[flowID]) {
+			ip := g.responder()
+			id := ip
+			if id == "" {
+				id = unknownHopID(g.ttl)
+			}
+
+			stats, ok := nodes[id]
+			if !ok {
+				stats = &nodeStats{node: NetworkPathNode{ID: id, TTL: int(g.ttl), IPAddress: ip}}
+				if ip != "" {
+					stats.node.Hostname = hostname(ip)
+				}
+				nodes[id] = stats
+				nodeIDs = append(nodeIDs, id)
+			}
+			// with ECMP, the paths may have different lengths
+			if int(g.ttl) < stats.node.TTL {
+				stats.node.TTL = int(g.ttl)
+			}
+			stats.add(g)
+
+			if prev != "" && prev != id {
+				key := [2]string{prev, id}
+				idx, ok := links[key]
+				if !ok {
+					idx = len(graph.Links)
+					links[key] = idx
+					graph.Links = append(graph.Links, NetworkPathLink{Source: prev, Target: id})
+				}
+				if flows := graph.Links[idx].FlowIDs; len(flows) == 0 || flows[len(flows)-1] != flowID {
+					graph.Links[idx].FlowIDs = append(flows, flowID)
+				}
+			}
+			prev = id
+			path = append(path, id)
+
+			if i == 0 {
+				single := nodeStats{node: NetworkPathNode{Hostname: stats.node.Hostname}}
+				single.add(g)
+				hop := single.finalize()
+				name := hop.Hostname
+				if ip == "" {
+					name = id
+				}
+				hops = append(hops, NetworkPathHop{
+					TTL:       int(g.ttl),
+					IPAddress: id,
+					Hostname:  name,
+					RTT:       hop.RTTAvg,
+					Success:   hop.Reachable,
+				})
+			}
+		}
+		if len(path) > 0 {
+			paths[strings.Join(path, ",")] = struct{}{}
+		}
+	}
+
+	for _, id := range nodeIDs {
+		graph.Nodes = append(graph.Nodes, nodes[id].finalize())
+	}
+	sort.SliceStable(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].TTL < graph.Nodes[j].TTL
+	})
+	graph.NumPaths = len(paths)
+	return graph, hops
+}
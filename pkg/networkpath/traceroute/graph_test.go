@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func answered(flowID uint16, ttl uint8, ip string, rttMs int, isDest bool) hopProbe {
+	return hopProbe{
+		flowID:   flowID,
+		ttl:      ttl,
+		received: true,
+		rtt:      time.Duration(rttMs) * time.Millisecond,
+		ip:       net.ParseIP(ip),
+		isDest:   isDest,
+	}
+}
+
+func lost(flowID uint16, ttl uint8) hopProbe {
+	return hopProbe{flowID: flowID, ttl: ttl}
+}
+
+func TestBuildGraphECMP(t *testing.T) {
+	// flows 1 and 2 take different paths at the second hop
+	probes := []hopProbe{
+		answered(1, 1, "10.0.0.1", 1, false),
+		answered(2, 1, "10.0.0.1", 3, false),
+		answered(1, 1, "10.0.0.1", 2, false),
+		lost(2, 1),
+		answered(1, 2, "10.0.1.1", 10, false),
+		answered(2, 2, "10.0.2.1", 20, false),
+		answered(1, 2, "10.0.1.1", 12, false),
+		answered(2, 2, "10.0.2.1", 20, false),
+		answered(1, 3, "192.0.2.10", 30, true),
+		answered(2, 3, "192.0.2.10", 30, true),
+		lost(1, 3),
+		answered(2, 3, "192.0.2.10", 40, true),
+		// sent before the destination was known to be reached
+		lost(1, 4),
+	}
+
+	graph, hops := buildGraph(probes, []uint16{1, 2}, func(ip string) string { return "host-" + ip })
+	require.NotNil(t, graph)
+	assert.Equal(t, 2, graph.NumPaths)
+	assert.Equal(t, []uint16{1, 2}, graph.FlowIDs)
+
+	require.Len(t, graph.Nodes, 4)
+	first := graph.Nodes[0]
+	assert.Equal(t, "10.0.0.1", first.ID)
+	assert.Equal(t, "host-10.0.0.1", first.Hostname)
+	assert.Equal(t, 1, first.TTL)
+	assert.Equal(t, 4, first.ProbesSent)
+	assert.Equal(t, 3, first.ProbesReceived)
+	assert.Equal(t, 25.0, first.PacketLossPct)
+	assert.Equal(t, 1.0, first.RTTMin)
+	assert.Equal(t, 2.0, first.RTTAvg)
+	assert.Equal(t, 3.0, first.RTTMax)
+	// the RTTs are accumulated flow by flow: |2-1| and |3-2|
+	assert.Equal(t, 1.0, first.Jitter)
+
+	dest := graph.Nodes[3]
+	assert.Equal(t, "192.0.2.10", dest.ID)
+	assert.Equal(t, 3, dest.TTL)
+	assert.Equal(t, 4, dest.ProbesSent)
+	assert.Equal(t, 3, dest.ProbesReceived)
+
+	assert.Equal(t, []NetworkPathLink{
+		{Source: "10.0.0.1", Target: "10.0.1.1", FlowIDs: []uint16{1}},
+		{Source: "10.0.1.1", Target: "192.0.2.10", FlowIDs: []uint16{1}},
+		{Source: "10.0.0.1", Target: "10.0.2.1", FlowIDs: []uint16{2}},
+		{Source: "10.0.2.1", Target: "192.0.2.10", FlowIDs: []uint16{2}},
+	}, graph.Links)
+
+	// the hops are the path of the first flow
+	require.Len(t, hops, 3)
+	assert.Equal(t, NetworkPathHop{TTL: 2, IPAddress: "10.0.1.1", Hostname: "host-10.0.1.1", RTT: 11, Success: true}, hops[1])
+	assert.Equal(t, "192.0.2.10", hops[2].IPAddress)
+	assert.True(t, hops[2].Success)
+}
+
+func TestBuildGraphUnreachable(t *testing.T) {
+	var probes []hopProbe
+	probes = append(probes, answered(1, 1, "10.0.0.1", 1, false))
+	for ttl := uint8(2); ttl <= 30; ttl++ {
+		probes = append(probes, lost(1, ttl))
+	}
+
+	graph, hops := buildGraph(probes, []uint16{1}, func(ip string) string { return ip })
+	assert.Equal(t, 1, graph.NumPaths)
+	require.Len(t, graph.Nodes, 2)
+	assert.Equal(t, "unknown_hop_2", graph.Nodes[1].ID)
+	assert.False(t, graph.Nodes[1].Reachable)
+	assert.Equal(t, 100.0, graph.Nodes[1].PacketLossPct)
+
+	require.Len(t, hops, 2)
+	assert.Equal(t, NetworkPathHop{TTL: 2, IPAddress: "unknown_hop_2", Hostname: "unknown_hop_2"}, hops[1])
+}
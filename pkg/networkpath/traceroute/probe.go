@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Protocol is the protocol of the traceroute probes
+type Protocol string
+
+const (
+	// ProtocolUDP sends UDP datagrams, answered by an ICMP port
+	// unreachable error by the destination
+	ProtocolUDP Protocol = "udp"
+	// ProtocolTCP sends TCP SYN segments, answered by a SYN-ACK
+	// or a RST by the destination
+	ProtocolTCP Protocol = "tcp"
+	// ProtocolICMP sends ICMP echo requests, answered by an echo
+	// reply by the destination
+	ProtocolICMP Protocol = "icmp"
+)
+
+// The defaults of the probes repeated to discover the ECMP paths and measure
+// the loss and jitter
+const (
+	DefaultTCPDestPort  = 443
+	DefaultProbesPerHop = 3
+	// MaxNumPaths limits the number of flows of a traceroute
+	MaxNumPaths = 16
+	// MaxProbesPerHop limits the number of probes per flow and TTL
+	MaxProbesPerHop = 10
+)
+
+// ParseProtocol returns the protocol of the given name, an empty name being
+// UDP
+func ParseProtocol(name string) (Protocol, error) {
+	switch p := Protocol(strings.ToLower(name)); p {
+	case "":
+		return ProtocolUDP, nil
+	case ProtocolUDP, ProtocolTCP, ProtocolICMP:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported protocol %q, expected one of udp, tcp or icmp", name)
+	}
+}
+
+// hopProbe is the outcome of a single probe
+type hopProbe struct {
+	flowID uint16
+	ttl    uint8
+	sentAt time.Time
+	// the following fields are only set if the probe got a response
+	received bool
+	rtt      time.Duration
+	ip       net.IP
+	// isDest is set when the response was sent by the destination
+	isDest bool
+}
+
+// probesConfig is the configuration of the probes of a traceroute, with the
+// defaults applied
+type probesConfig struct {
+	protocol     Protocol
+	target       net.IP
+	destPort     uint16
+	minTTL       uint8
+	maxTTL       uint8
+	numPaths     uint16
+	probesPerHop uint8
+	flowID       uint16
+	delay        time.Duration
+	timeout      time.Duration
+}
+
+func (c *probesConfig) validate() error {
+	if c.target.To4() == nil {
+		return fmt.Errorf("invalid IPv4 address %s", c.target)
+	}
+	if c.numPaths == 0 || c.numPaths > MaxNumPaths {
+		return fmt.Errorf("number of paths must be between 1 and %d", MaxNumPaths)
+	}
+	if c.probesPerHop == 0 || c.probesPerHop > MaxProbesPerHop {
+		return fmt.Errorf("number of probes per hop must be between 1 and %d", MaxProbesPerHop)
+	}
+	if int(c.flowID)+int(c.numPaths) > 0xffff {
+		return fmt.Errorf("flow ID %d plus the number of paths cannot exceed 65535", c.flowID)
+	}
+	if c.minTTL == 0 || c.maxTTL < c.minTTL {
+		return fmt.Errorf("invalid TTL range %d-%d", c.minTTL, c.maxTTL)
+	}
+	return nil
+}
+
+// ownsFlow returns true if the flow ID is one of the probed flows
+func (c *probesConfig) ownsFlow(flowID uint16) bool {
+	return flowID >= c.flowID && uint32(flowID) < uint32(c.flowID)+uint32(c.numPaths)
+}
@@ -0,0 +1,333 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/ipv4"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	icmpEchoReply      = 0
+	icmpDestUnreach    = 3
+	icmpTimeExceeded   = 11
+	icmpErrorHeaderLen = 8
+	// the ICMP errors quote the IP header and at least the first 8 bytes
+	// of the payload of the probe
+	quotedPayloadLen = 8
+
+	tcpFlagRST = 0x04
+	tcpFlagSYN = 0x02
+	tcpFlagACK = 0x10
+)
+
+// rawProber sends TCP SYN or ICMP echo probes from a raw socket and matches
+// them with the ICMP errors sent by the routers and with the responses of the
+// destination.
+//
+// As with Paris traceroute, the fields used by the routers to balance the
+// traffic across ECMP paths are constant for all the probes of a flow: the
+// ports of TCP probes, and the identifier and checksum of ICMP probes. The
+// probes are identified by their IP ID, which is quoted in the ICMP errors,
+// and by the TCP sequence number or ICMP sequence number, which are echoed by
+// the destination.
+type rawProber struct {
+	cfg probesConfig
+	src net.IP
+
+	mux    sync.Mutex
+	probes map[uint16]*hopProbe
+	// destTTL is the lowest TTL of the probes of each flow which reached
+	// the destination, the higher TTLs are not probed
+	destTTL map[uint16]uint8
+}
+
+func newRawProber(cfg probesConfig, src net.IP) *rawProber {
+	return &rawProber{
+		cfg:     cfg,
+		src:     src.To4(),
+		probes:  make(map[uint16]*hopProbe),
+		destTTL: make(map[uint16]uint8),
+	}
+}
+
+// run sends the probes and returns them once they got a response or timed out
+func (p *rawProber) run() ([]hopProbe, error) {
+	icmpConn, err := listenRaw("ip4:icmp", p.src)
+	if err != nil {
+		return nil, err
+	}
+	defer icmpConn.Close()
+
+	sendConn := icmpConn
+	conns := []*ipv4.RawConn{icmpConn}
+	if p.cfg.protocol == ProtocolTCP {
+		tcpConn, err := listenRaw("ip4:tcp", p.src)
+		if err != nil {
+			return nil, err
+		}
+		defer tcpConn.Close()
+		sendConn = tcpConn
+		conns = append(conns, tcpConn)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *ipv4.RawConn) {
+			defer wg.Done()
+			p.receive(conn, done)
+		}(conn)
+	}
+
+	err = p.send(sendConn)
+	if err == nil {
+		time.Sleep(p.cfg.timeout)
+	}
+	close(done)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	ids := make([]int, 0, len(p.probes))
+	for id := range p.probes {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	probes := make([]hopProbe, 0, len(ids))
+	for _, id := range ids {
+		probes = append(probes, *p.probes[uint16(id)])
+	}
+	return probes, nil
+}
+
+func listenRaw(network string, src net.IP) (*ipv4.RawConn, error) {
+	conn, err := net.ListenPacket(network, src.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s listener: %w", network, err)
+	}
+	// RawConn is necessary to set the TTL and ID fields
+	rconn, err := ipv4.NewRawConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create new RawConn: %w", err)
+	}
+	return rconn, nil
+}
+
+// send sends the probes of every flow, TTL after TTL
+func (p *rawProber) send(conn *ipv4.RawConn) error {
+	var id uint16
+	for ttl := p.cfg.minTTL; ttl <= p.cfg.maxTTL && ttl != 0; ttl++ {
+		for i := 0; i < int(p.cfg.probesPerHop); i++ {
+			for path := uint16(0); path < p.cfg.numPaths; path++ {
+				flowID := p.cfg.flowID + path
+				if p.reached(flowID, ttl) {
+					continue
+				}
+
+				id++
+				header, payload, err := p.packet(id, flowID, ttl)
+				if err != nil {
+					return err
+				}
+				p.mux.Lock()
+				err = conn.WriteTo(header, payload, nil)
+				p.probes[id] = &hopProbe{flowID: flowID, ttl: ttl, sentAt: time.Now()}
+				p.mux.Unlock()
+				if err != nil {
+					return fmt.Errorf("failed to send IPv4 packet: %w", err)
+				}
+				time.Sleep(p.cfg.delay)
+			}
+		}
+	}
+	return nil
+}
+
+// reached returns true if a probe of the flow reached the destination with a
+// TTL lower than the given one
+func (p *rawProber) reached(flowID uint16, ttl uint8) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	destTTL, ok := p.destTTL[flowID]
+	return ok && destTTL < ttl
+}
+
+// receive reads the responses until done is closed
+func (p *rawProber) receive(conn *ipv4.RawConn, done <-chan struct{}) {
+	buf := make([]byte, 1500)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		header, payload, _, err := conn.ReadFrom(buf)
+		receivedAt := time.Now()
+		if err != nil {
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				continue
+			}
+			log.Debugf("failed to read traceroute response: %s", err)
+			continue
+		}
+
+		id, isDest, ok := p.matchResponse(header, payload)
+		if !ok {
+			continue
+		}
+		p.mux.Lock()
+		if probe, ok := p.probes[id]; ok && !probe.received {
+			probe.received = true
+			probe.rtt = receivedAt.Sub(probe.sentAt)
+			probe.ip = append(net.IP(nil), header.Src.To4()...)
+			probe.isDest = isDest
+			if destTTL, ok := p.destTTL[probe.flowID]; isDest && (!ok || probe.ttl < destTTL) {
+				p.destTTL[probe.flowID] = probe.ttl
+			}
+		}
+		p.mux.Unlock()
+	}
+}
+
+// packet returns the IPv4 header and the payload of a probe
+func (p *rawProber) packet(id uint16, flowID uint16, ttl uint8) (*ipv4.Header, []byte, error) {
+	header := &ipv4.Header{
+		Version: ipv4.Version,
+		Len:     ipv4.HeaderLen,
+		ID:      int(id),
+		Flags:   ipv4.DontFragment,
+		TTL:     int(ttl),
+		Src:     p.src,
+		Dst:     p.cfg.target.To4(),
+	}
+
+	var probeLayers []gopacket.SerializableLayer
+	switch p.cfg.protocol {
+	case ProtocolTCP:
+		header.Protocol = int(layers.IPProtocolTCP)
+		tcp := &layers.TCP{
+			SrcPort: layers.TCPPort(flowID),
+			DstPort: layers.TCPPort(p.cfg.destPort),
+			// the destination acknowledges seq+1
+			Seq:    uint32(id) << 16,
+			SYN:    true,
+			Window: 1024,
+		}
+		// only the addresses and the protocol are used for the checksum
+		if err := tcp.SetNetworkLayerForChecksum(&layers.IPv4{SrcIP: header.Src, DstIP: header.Dst, Protocol: layers.IPProtocolTCP}); err != nil {
+			return nil, nil, err
+		}
+		probeLayers = []gopacket.SerializableLayer{tcp}
+	case ProtocolICMP:
+		header.Protocol = int(layers.IPProtocolICMPv4)
+		// the payload compensates the sequence number in the checksum,
+		// so that the checksum is the same for all the probes of a flow
+		compensation := ^id
+		probeLayers = []gopacket.SerializableLayer{
+			&layers.ICMPv4{
+				TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0),
+				Id:       flowID,
+				Seq:      id,
+			},
+			gopacket.Payload{byte(compensation >> 8), byte(compensation)},
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported raw probe protocol %q", p.cfg.protocol)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, probeLayers...); err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize probe: %w", err)
+	}
+	payload := buf.Bytes()
+	header.TotalLen = ipv4.HeaderLen + len(payload)
+	return header, payload, nil
+}
+
+// matchResponse returns the ID of the probe a response was sent for, and
+// whether it was sent by the destination
+func (p *rawProber) matchResponse(header *ipv4.Header, payload []byte) (id uint16, isDest bool, ok bool) {
+	fromTarget := header.Src.Equal(p.cfg.target)
+
+	switch header.Protocol {
+	case int(layers.IPProtocolTCP):
+		if p.cfg.protocol != ProtocolTCP || !fromTarget || len(payload) < 14 {
+			return 0, false, false
+		}
+		srcPort, dstPort := binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])
+		flags := payload[13]
+		if srcPort != p.cfg.destPort || !p.cfg.ownsFlow(dstPort) || flags&tcpFlagACK == 0 || flags&(tcpFlagSYN|tcpFlagRST) == 0 {
+			return 0, false, false
+		}
+		ack := binary.BigEndian.Uint32(payload[8:])
+		return uint16((ack - 1) >> 16), true, true
+
+	case int(layers.IPProtocolICMPv4):
+		if len(payload) < icmpErrorHeaderLen {
+			return 0, false, false
+		}
+		switch payload[0] {
+		case icmpEchoReply:
+			if p.cfg.protocol != ProtocolICMP || !fromTarget || !p.cfg.ownsFlow(binary.BigEndian.Uint16(payload[4:])) {
+				return 0, false, false
+			}
+			return binary.BigEndian.Uint16(payload[6:]), true, true
+		case icmpTimeExceeded, icmpDestUnreach:
+			id, ok := p.matchQuotedProbe(payload[icmpErrorHeaderLen:])
+			// a destination unreachable error is the last hop, be it
+			// sent by the destination or by a filtering router
+			return id, fromTarget || payload[0] == icmpDestUnreach, ok
+		}
+	}
+	return 0, false, false
+}
+
+// matchQuotedProbe returns the ID of the probe quoted by an ICMP error
+func (p *rawProber) matchQuotedProbe(quoted []byte) (uint16, bool) {
+	inner, err := ipv4.ParseHeader(quoted)
+	if err != nil || len(quoted) < inner.Len+quotedPayloadLen || !inner.Dst.Equal(p.cfg.target) {
+		return 0, false
+	}
+	transport := quoted[inner.Len:]
+
+	switch {
+	case p.cfg.protocol == ProtocolTCP && inner.Protocol == int(layers.IPProtocolTCP):
+		if !p.cfg.ownsFlow(binary.BigEndian.Uint16(transport)) {
+			return 0, false
+		}
+	case p.cfg.protocol == ProtocolICMP && inner.Protocol == int(layers.IPProtocolICMPv4):
+		if !p.cfg.ownsFlow(binary.BigEndian.Uint16(transport[4:])) {
+			return 0, false
+		}
+		// the ICMP sequence number is more reliable than the IP ID,
+		// which may be rewritten
+		return binary.BigEndian.Uint16(transport[6:]), true
+	default:
+		return 0, false
+	}
+	return uint16(inner.ID), true
+}
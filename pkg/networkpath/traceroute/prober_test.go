@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package traceroute
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+)
+
+var (
+	testSrc    = net.ParseIP("10.0.0.1").To4()
+	testTarget = net.ParseIP("192.0.2.10").To4()
+	testRouter = net.ParseIP("10.0.0.254").To4()
+)
+
+func newTestProber(protocol Protocol) *rawProber {
+	return newRawProber(probesConfig{
+		protocol:     protocol,
+		target:       testTarget,
+		destPort:     443,
+		minTTL:       1,
+		maxTTL:       30,
+		numPaths:     4,
+		probesPerHop: 3,
+		flowID:       40000,
+	}, testSrc)
+}
+
+// timeExceeded returns the ICMP time exceeded error quoting a probe
+func timeExceeded(t *testing.T, header *ipv4.Header, payload []byte) []byte {
+	quoted, err := header.Marshal()
+	require.NoError(t, err)
+	// ipv4.Header.Marshal uses the host byte order for some fields on a
+	// few platforms, the ID is the one which matters here
+	binary.BigEndian.PutUint16(quoted[4:], uint16(header.ID))
+	return append(append([]byte{icmpTimeExceeded, 0, 0, 0, 0, 0, 0, 0}, quoted...), payload[:quotedPayloadLen]...)
+}
+
+func TestTCPProbeMatching(t *testing.T) {
+	p := newTestProber(ProtocolTCP)
+	header, payload, err := p.packet(42, 40002, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, header.TTL)
+	assert.Equal(t, 42, header.ID)
+	assert.Equal(t, uint16(40002), binary.BigEndian.Uint16(payload))
+	assert.Equal(t, uint16(443), binary.BigEndian.Uint16(payload[2:]))
+
+	// the error of a router
+	id, isDest, ok := p.matchResponse(&ipv4.Header{Src: testRouter, Protocol: 1}, timeExceeded(t, header, payload))
+	require.True(t, ok)
+	assert.Equal(t, uint16(42), id)
+	assert.False(t, isDest)
+
+	// the SYN-ACK of the destination
+	synAck := make([]byte, 20)
+	binary.BigEndian.PutUint16(synAck, 443)
+	binary.BigEndian.PutUint16(synAck[2:], 40002)
+	binary.BigEndian.PutUint32(synAck[8:], binary.BigEndian.Uint32(payload[4:])+1)
+	synAck[13] = tcpFlagSYN | tcpFlagACK
+	id, isDest, ok = p.matchResponse(&ipv4.Header{Src: testTarget, Protocol: 6}, synAck)
+	require.True(t, ok)
+	assert.Equal(t, uint16(42), id)
+	assert.True(t, isDest)
+
+	// another connection of the host
+	binary.BigEndian.PutUint16(synAck[2:], 50000)
+	_, _, ok = p.matchResponse(&ipv4.Header{Src: testTarget, Protocol: 6}, synAck)
+	assert.False(t, ok)
+}
+
+func TestICMPProbeMatching(t *testing.T) {
+	p := newTestProber(ProtocolICMP)
+	header1, payload1, err := p.packet(1, 40001, 1)
+	require.NoError(t, err)
+	_, payload2, err := p.packet(2, 40001, 2)
+	require.NoError(t, err)
+
+	// the identifier and checksum are the same for all the probes of a
+	// flow, as the routers may use them to select a path
+	assert.Equal(t, payload1[2:6], payload2[2:6])
+	assert.NotEqual(t, payload1[6:8], payload2[6:8])
+
+	id, isDest, ok := p.matchResponse(&ipv4.Header{Src: testRouter, Protocol: 1}, timeExceeded(t, header1, payload1))
+	require.True(t, ok)
+	assert.Equal(t, uint16(1), id)
+	assert.False(t, isDest)
+
+	reply := append([]byte(nil), payload2...)
+	reply[0] = icmpEchoReply
+	id, isDest, ok = p.matchResponse(&ipv4.Header{Src: testTarget, Protocol: 1}, reply)
+	require.True(t, ok)
+	assert.Equal(t, uint16(2), id)
+	assert.True(t, isDest)
+
+	// the echo reply of another flow
+	binary.BigEndian.PutUint16(reply[4:], 1234)
+	_, _, ok = p.matchResponse(&ipv4.Header{Src: testTarget, Protocol: 1}, reply)
+	assert.False(t, ok)
+}
+
+func TestProbesConfigValidate(t *testing.T) {
+	cfg := newTestProber(ProtocolTCP).cfg
+	assert.NoError(t, cfg.validate())
+
+	cfg.numPaths = MaxNumPaths + 1
+	assert.Error(t, cfg.validate())
+
+	cfg.numPaths, cfg.flowID = 4, 0xfffe
+	assert.Error(t, cfg.validate())
+}
@@ -51,51 +51,172 @@ func RunTraceroute(cfg Config) (NetworkPath, error) {
 	// use first resolved IP for now
 	dest := dests[0]
 
-	destPort, srcPort, useSourcePort := getPorts(cfg.DestPort)
-
-	maxTTL := cfg.MaxTTL
-	if maxTTL == 0 {
-		maxTTL = DefaultMaxTTL
+	pcfg, useSourcePort, err := newProbesConfig(cfg, dest)
+	if err != nil {
+		return NetworkPath{}, err
+	}
+	if err := pcfg.validate(); err != nil {
+		return NetworkPath{}, err
 	}
 
-	var timeout time.Duration
-	if cfg.TimeoutMs == 0 {
-		timeout = DefaultReadTimeout
+	var probes []hopProbe
+	if pcfg.protocol == ProtocolUDP {
+		probes, err = runUDPTraceroute(pcfg, useSourcePort)
 	} else {
-		timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+		var src net.IP
+		if src, err = localAddr(dest); err == nil {
+			log.Debugf("Traceroute %s probe config: %+v", pcfg.protocol, pcfg)
+			probes, err = newRawProber(pcfg, src).run()
+		}
+	}
+	if err != nil {
+		return NetworkPath{}, fmt.Errorf("traceroute run failed: %s", err.Error())
+	}
+
+	hname, err := hostname.Get(context.TODO())
+	if err != nil {
+		return NetworkPath{}, err
+	}
+
+	return buildNetworkPath(probes, pcfg, hname, rawDest, dest), nil
+}
+
+// newProbesConfig applies the defaults to the configuration. For UDP, it also
+// returns whether the flows are identified by their source port rather than
+// by their destination port.
+func newProbesConfig(cfg Config, dest net.IP) (probesConfig, bool, error) {
+	protocol, err := ParseProtocol(string(cfg.Protocol))
+	if err != nil {
+		return probesConfig{}, false, err
+	}
+
+	pcfg := probesConfig{
+		protocol:     protocol,
+		target:       dest,
+		destPort:     cfg.DestPort,
+		minTTL:       DefaultMinTTL,
+		maxTTL:       cfg.MaxTTL,
+		numPaths:     cfg.NumPaths,
+		probesPerHop: cfg.ProbesPerHop,
+		flowID:       cfg.FlowID,
+		delay:        time.Duration(DefaultDelay) * time.Millisecond,
+		timeout:      DefaultReadTimeout,
+	}
+	if pcfg.maxTTL == 0 {
+		pcfg.maxTTL = DefaultMaxTTL
+	}
+	if pcfg.numPaths == 0 {
+		pcfg.numPaths = DefaultNumPaths
+	}
+	if pcfg.probesPerHop == 0 {
+		pcfg.probesPerHop = DefaultProbesPerHop
+	}
+	if cfg.TimeoutMs != 0 {
+		pcfg.timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+
+	useSourcePort := true
+	switch protocol {
+	case ProtocolUDP:
+		var srcPort uint16
+		pcfg.destPort, srcPort, useSourcePort = getPorts(cfg.DestPort)
+		if cfg.FlowID != 0 {
+			useSourcePort = true
+		} else if useSourcePort {
+			pcfg.flowID = srcPort
+		} else {
+			// the flows are identified by their destination port
+			pcfg.flowID = pcfg.destPort
+		}
+	case ProtocolTCP:
+		if pcfg.destPort == 0 {
+			pcfg.destPort = DefaultTCPDestPort
+		}
+		if pcfg.flowID == 0 {
+			_, pcfg.flowID, _ = getPorts(0)
+		}
+	case ProtocolICMP:
+		if pcfg.flowID == 0 {
+			pcfg.flowID = uint16(1 + rand.Intn(0xffff-MaxNumPaths))
+		}
 	}
+	return pcfg, useSourcePort, nil
+}
 
+func (c *probesConfig) flowIDs() []uint16 {
+	ids := make([]uint16, 0, c.numPaths)
+	for i := uint16(0); i < c.numPaths; i++ {
+		ids = append(ids, c.flowID+i)
+	}
+	return ids
+}
+
+// runUDPTraceroute runs the UDP traceroute once per probe of each hop, the
+// flows being the same for every run
+func runUDPTraceroute(pcfg probesConfig, useSourcePort bool) ([]hopProbe, error) {
 	dt := &probev4.UDPv4{
-		Target:     dest,
-		SrcPort:    srcPort,
-		DstPort:    destPort,
+		Target:     pcfg.target,
+		SrcPort:    pcfg.flowID,
+		DstPort:    pcfg.destPort,
 		UseSrcPort: useSourcePort,
-		NumPaths:   uint16(DefaultNumPaths),
-		MinTTL:     uint8(DefaultMinTTL), // TODO: what's a good value?
-		MaxTTL:     maxTTL,
-		Delay:      time.Duration(DefaultDelay) * time.Millisecond, // TODO: what's a good value?
-		Timeout:    timeout,                                        // TODO: what's a good value?
+		NumPaths:   pcfg.numPaths,
+		MinTTL:     pcfg.minTTL, // TODO: what's a good value?
+		MaxTTL:     pcfg.maxTTL,
+		Delay:      pcfg.delay,   // TODO: what's a good value?
+		Timeout:    pcfg.timeout, // TODO: what's a good value?
 		BrokenNAT:  false,
 	}
+	if !useSourcePort {
+		// the source port is random, the flows differ by destination port
+		_, dt.SrcPort, _ = getPorts(0)
+	}
 
 	log.Debugf("Traceroute UDPv4 probe config: %+v", dt)
-	results, err := dt.Traceroute()
-	if err != nil {
-		return NetworkPath{}, fmt.Errorf("traceroute run failed: %s", err.Error())
+	var probes []hopProbe
+	for i := 0; i < int(pcfg.probesPerHop); i++ {
+		results, err := dt.Traceroute()
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Raw results: %+v", results)
+		probes = append(probes, udpProbes(results)...)
 	}
-	log.Debugf("Raw results: %+v", results)
+	return probes, nil
+}
 
-	hname, err := hostname.Get(context.TODO())
-	if err != nil {
-		return NetworkPath{}, err
+// udpProbes converts the results of a UDP traceroute
+func udpProbes(r *results.Results) []hopProbe {
+	var probes []hopProbe
+	for flowID, flow := range r.Flows {
+		for _, probe := range flow {
+			p := hopProbe{
+				flowID: flowID,
+				ttl:    probe.Sent.IP.TTL,
+				sentAt: time.Time(probe.Sent.Timestamp),
+			}
+			if probe.Received != nil {
+				p.received = true
+				p.rtt = time.Duration(probe.RttUsec) * time.Microsecond
+				p.ip = probe.Received.IP.SrcIP
+				p.isDest = probe.IsLast
+			}
+			probes = append(probes, p)
+		}
 	}
+	sort.SliceStable(probes, func(i, j int) bool {
+		return probes[i].sentAt.Before(probes[j].sentAt)
+	})
+	return probes
+}
 
-	pathResult, err := processResults(results, hname, rawDest, dest)
+// localAddr returns the local address used to reach the destination
+func localAddr(dest net.IP) (net.IP, error) {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: dest, Port: DefaultDestPort})
 	if err != nil {
-		return NetworkPath{}, err
+		return nil, fmt.Errorf("failed to get local address for target %s: %w", dest, err)
 	}
-
-	return pathResult, nil
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
 }
 
 func getPorts(configDestPort uint16) (uint16, uint16, bool) {
@@ -115,17 +236,12 @@ func getPorts(configDestPort uint16) (uint16, uint16, bool) {
 	return destPort, srcPort, useSourcePort
 }
 
-func processResults(r *results.Results, hname string, destinationHost string, destinationIP net.IP) (NetworkPath, error) {
-	type node struct {
-		node  string
-		probe *results.Probe
-	}
-
-	pathID := uuid.New().String()
-
+func buildNetworkPath(probes []hopProbe, pcfg probesConfig, hname string, destinationHost string, destinationIP net.IP) NetworkPath {
+	graph, hops := buildGraph(probes, pcfg.flowIDs(), getHostname)
 	traceroutePath := NetworkPath{
-		PathID:    pathID,
+		PathID:    uuid.New().String(),
 		Timestamp: time.Now().UnixMilli(),
+		Protocol:  pcfg.protocol,
 		Source: NetworkPathSource{
 			Hostname: hname,
 		},
@@ -133,105 +249,12 @@ func processResults(r *results.Results, hname string, destinationHost string, de
 			Hostname:  destinationHost,
 			IPAddress: destinationIP.String(),
 		},
-	}
-
-	for idx, probes := range r.Flows {
-		log.Debugf("Flow idx: %d\n", idx)
-		for probleIndex, probe := range probes {
-			log.Debugf("%d - %d - %s\n", probleIndex, probe.Sent.IP.TTL, probe.Name)
-		}
-	}
-
-	flowIDs := make([]int, 0, len(r.Flows))
-	for flowID := range r.Flows {
-		flowIDs = append(flowIDs, int(flowID))
-	}
-	sort.Ints(flowIDs)
-
-	for _, flowID := range flowIDs {
-		hops := r.Flows[uint16(flowID)]
-		if len(hops) == 0 {
-			log.Debugf("No hops for flow ID %d", flowID)
-			continue
-		}
-		var nodes []node
-		// add first hop
-		firstNodeName := hops[0].Sent.IP.SrcIP.String()
-		nodes = append(nodes, node{node: firstNodeName, probe: &hops[0]})
-
-		// then add all the other hops
-		for _, hop := range hops {
-			hop := hop
-			nodename := fmt.Sprintf("unknown_hop_%d)", hop.Sent.IP.TTL)
-			label := "*"
-			hostname := ""
-			if hop.Received != nil {
-				nodename = hop.Received.IP.SrcIP.String()
-				if hop.Name != nodename {
-					hostname = "\n" + hop.Name
-				}
-				// MPLS labels
-				mpls := ""
-				if len(hop.Received.ICMP.MPLSLabels) > 0 {
-					mpls = "MPLS labels: \n"
-					for _, mplsLabel := range hop.Received.ICMP.MPLSLabels {
-						mpls += fmt.Sprintf(" - %d, ttl: %d\n", mplsLabel.Label, mplsLabel.TTL)
-					}
-				}
-				label = fmt.Sprintf("%s%s\n%s\n%s", nodename, hostname, hop.Received.ICMP.Description, mpls)
-			}
-			nodes = append(nodes, node{node: nodename, probe: &hop})
-
-			if hop.IsLast {
-				break
-			}
-			log.Debugf("Label: %s", label)
-		}
-		// add edges
-		if len(nodes) <= 1 {
-			// no edges to add if there is only one node
-			continue
-		}
-
-		// start at node 1. Each node back-references the previous one
-		for idx := 1; idx < len(nodes); idx++ {
-			if idx >= len(nodes) {
-				// we are at the second-to-last node
-				break
-			}
-			prev := nodes[idx-1]
-			cur := nodes[idx]
-
-			edgeLabel := ""
-			if idx == 1 {
-				edgeLabel += fmt.Sprintf(
-					"srcport %d\ndstport %d",
-					cur.probe.Sent.UDP.SrcPort,
-					cur.probe.Sent.UDP.DstPort,
-				)
-			}
-			if prev.probe.NATID != cur.probe.NATID {
-				edgeLabel += "\nNAT detected"
-			}
-			edgeLabel += fmt.Sprintf("\n%d.%d ms", int(cur.probe.RttUsec/1000), int(cur.probe.RttUsec%1000))
-
-			isSuccess := cur.probe.Received != nil
-			ip := cur.node
-			durationMs := float64(cur.probe.RttUsec) / 1000
-
-			hop := NetworkPathHop{
-				TTL:       idx,
-				IPAddress: ip,
-				Hostname:  getHostname(cur.node),
-				RTT:       durationMs,
-				Success:   isSuccess,
-			}
-			traceroutePath.Hops = append(traceroutePath.Hops, hop)
-		}
+		Hops:  hops,
+		Graph: graph,
 	}
 
 	log.Debugf("Traceroute path metadata payload: %+v", traceroutePath)
-	return traceroutePath, nil
+	return traceroutePath
 }
 
 func getHostname(ipAddr string) string {
@@ -15,6 +15,18 @@ type (
 		DestPort     uint16
 		MaxTTL       uint8
 		TimeoutMs    uint
+		// Protocol is the protocol of the probes, UDP by default
+		Protocol Protocol
+		// NumPaths is the number of flows probed to discover
+		// the ECMP paths to the destination
+		NumPaths uint16
+		// ProbesPerHop is the number of probes sent for each
+		// flow and TTL, used to measure the loss and jitter
+		ProbesPerHop uint8
+		// FlowID identifies the first flow: it's the source port
+		// of UDP and TCP probes and the ICMP echo identifier.
+		// Each path uses the next one. 0 picks a random one.
+		FlowID uint16
 	}
 
 	// Traceroute defines an interface for running
@@ -33,6 +45,45 @@ type (
 		Success   bool    `json:"success"`
 	}
 
+	// NetworkPathNode is a hop of the path graph, a router
+	// answering the probes with a given TTL. The probes which got
+	// no answer are attributed to an unknown node of their TTL.
+	NetworkPathNode struct {
+		ID             string  `json:"id"`
+		TTL            int     `json:"ttl"`
+		IPAddress      string  `json:"ip_address"`
+		Hostname       string  `json:"hostname"`
+		Reachable      bool    `json:"reachable"`
+		ProbesSent     int     `json:"probes_sent"`
+		ProbesReceived int     `json:"probes_received"`
+		PacketLossPct  float64 `json:"packet_loss_pct"`
+		RTTMin         float64 `json:"rtt_min"`
+		RTTAvg         float64 `json:"rtt_avg"`
+		RTTMax         float64 `json:"rtt_max"`
+		// Jitter is the mean difference between the RTTs of
+		// consecutive probes, in milliseconds
+		Jitter float64 `json:"jitter"`
+	}
+
+	// NetworkPathLink links two consecutive nodes of the
+	// path graph, for the flows going through both
+	NetworkPathLink struct {
+		Source  string   `json:"source"`
+		Target  string   `json:"target"`
+		FlowIDs []uint16 `json:"flow_ids"`
+	}
+
+	// NetworkPathGraph holds the paths taken by the
+	// different flows, which differ with ECMP routing
+	NetworkPathGraph struct {
+		// NumPaths is the number of distinct paths taken
+		// by the flows
+		NumPaths int               `json:"num_paths"`
+		FlowIDs  []uint16          `json:"flow_ids"`
+		Nodes    []NetworkPathNode `json:"nodes"`
+		Links    []NetworkPathLink `json:"links"`
+	}
+
 	// NetworkPathSource encapsulates information
 	// about the source of a path
 	NetworkPathSource struct {
@@ -53,7 +104,10 @@ type (
 		PathID      string                 `json:"path_id"`
 		Source      NetworkPathSource      `json:"source"`
 		Destination NetworkPathDestination `json:"destination"`
-		Hops        []NetworkPathHop       `json:"hops"`
-		Tags        []string               `json:"tags"`
+		Protocol    Protocol               `json:"protocol"`
+		// Hops holds the path of the first flow
+		Hops  []NetworkPathHop  `json:"hops"`
+		Graph *NetworkPathGraph `json:"graph,omitempty"`
+		Tags  []string          `json:"tags"`
 	}
 )
@@ -42,7 +42,7 @@ func (l *LinuxTraceroute) Run() (NetworkPath, error) {
 		log.Warnf("could not initialize system-probe connection: %s", err.Error())
 		return NetworkPath{}, err
 	}
-	resp, err := tu.GetTraceroute(clientID, l.cfg.DestHostname, l.cfg.DestPort, l.cfg.MaxTTL, l.cfg.TimeoutMs)
+	resp, err := tu.GetTraceroute(clientID, l.cfg.DestHostname, l.cfg.DestPort, string(l.cfg.Protocol), l.cfg.MaxTTL, l.cfg.TimeoutMs, l.cfg.NumPaths, l.cfg.ProbesPerHop, l.cfg.FlowID)
 	if err != nil {
 		return NetworkPath{}, err
 	}
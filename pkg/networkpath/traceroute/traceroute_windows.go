@@ -42,7 +42,7 @@ func (w *WindowsTraceroute) Run() (NetworkPath, error) {
 		log.Warnf("could not initialize system-probe connection: %s", err.Error())
 		return NetworkPath{}, err
 	}
-	resp, err := tu.GetTraceroute(clientID, w.cfg.DestHostname, w.cfg.DestPort, w.cfg.MaxTTL, w.cfg.TimeoutMs)
+	resp, err := tu.GetTraceroute(clientID, w.cfg.DestHostname, w.cfg.DestPort, string(w.cfg.Protocol), w.cfg.MaxTTL, w.cfg.TimeoutMs, w.cfg.NumPaths, w.cfg.ProbesPerHop, w.cfg.FlowID)
 	if err != nil {
 		return NetworkPath{}, err
 	}
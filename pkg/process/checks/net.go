@@ -70,6 +70,8 @@ type ConnectionsCheck struct {
 
 	localresolver *resolver.LocalResolver
 	wmeta         workloadmeta.Component
+
+	serviceMapReporter *serviceMapReporter
 }
 
 // ProcessConnRates describes connection rates for processes
@@ -116,6 +118,8 @@ func (c *ConnectionsCheck) Init(syscfg *SysProbeConfig, hostInfo *HostInfo, _ bo
 	c.localresolver = resolver.NewLocalResolver(proccontainers.GetSharedContainerProvider(c.wmeta), clock.New())
 	c.localresolver.Run()
 
+	c.serviceMapReporter = newServiceMapReporter(c.config, hostInfo.HostName)
+
 	return nil
 }
 
@@ -177,6 +181,7 @@ func (c *ConnectionsCheck) Run(nextGroupID func() int32, _ *RunOptions) (RunResu
 	c.localresolver.Resolve(conns)
 
 	c.notifyProcessConnRates(c.config, conns)
+	c.serviceMapReporter.report(conns.Conns)
 
 	log.Debugf("collected connections in %s", time.Since(start))
 
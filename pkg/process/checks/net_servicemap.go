@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2024-present Datadog, Inc.
+
+package checks
+
+import (
+	"context"
+	"time"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/servicemap"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const serviceMapReportTimeout = 10 * time.Second
+
+// serviceMapReporter reports the connections of the node to the cluster
+// agent, which deduplicates the connections reported by both of their ends
+// to build the service map of the cluster
+type serviceMapReporter struct {
+	hostname string
+	client   clusteragent.DCAClientInterface
+	inFlight *atomic.Bool
+	errLimit *log.Limit
+}
+
+// newServiceMapReporter returns a reporter, nil if the service map is disabled
+func newServiceMapReporter(config config.Reader, hostname string) *serviceMapReporter {
+	if !config.GetBool("cluster_agent.enabled") || !config.GetBool("cluster_agent.service_map.enabled") {
+		return nil
+	}
+	return &serviceMapReporter{
+		hostname: hostname,
+		inFlight: atomic.NewBool(false),
+		errLimit: log.NewLogLimit(1, 10*time.Minute),
+	}
+}
+
+// report sends the summaries of the connections in the background, the
+// connections are dropped if the previous report is still in flight. It's a
+// noop if the reporter is nil.
+func (r *serviceMapReporter) report(conns []*model.Connection) {
+	if r == nil {
+		return
+	}
+	if !r.inFlight.CompareAndSwap(false, true) {
+		log.Debug("Previous service map report still in flight, skipping the connections")
+		return
+	}
+
+	summaries := servicemap.NewConnectionSummaries(r.hostname, conns)
+	go func() {
+		defer r.inFlight.Store(false)
+		if err := r.send(summaries); err != nil && r.errLimit.ShouldLog() {
+			log.Warnf("Unable to report the connections to the cluster agent for the service map: %v (will only log every 10 minutes)", err)
+		}
+	}()
+}
+
+func (r *serviceMapReporter) send(summaries *servicemap.ConnectionSummaries) error {
+	if r.client == nil {
+		client, err := clusteragent.GetClusterAgentClient()
+		if err != nil {
+			return err
+		}
+		r.client = client
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serviceMapReportTimeout)
+	defer cancel()
+	return r.client.PostConnectionSummaries(ctx, summaries)
+}
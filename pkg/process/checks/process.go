@@ -8,6 +8,7 @@ package checks
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -16,6 +17,8 @@ import (
 	"github.com/shirou/gopsutil/v3/cpu"
 	"go.uber.org/atomic"
 
+	"github.com/DataDog/datadog-agent/comp/core/tagger"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/collectors"
 	workloadmetacomp "github.com/DataDog/datadog-agent/comp/core/workloadmeta"
 	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/process/metadata"
@@ -482,6 +485,10 @@ func fmtProcesses(
 			ProcessContext:         serviceExtractor.GetServiceContext(fp.Pid),
 		}
 
+		if proc.ContainerId == emptyCtrID {
+			proc.Tags = processTags(fp.Pid)
+		}
+
 		if connRates != nil {
 			proc.Networks = connRates[fp.Pid]
 		}
@@ -497,6 +504,17 @@ func fmtProcesses(
 	return procsByCtr
 }
 
+// processTags returns the tags of a process running outside of a container,
+// like the ones of the systemd unit it is the main process of
+func processTags(pid int32) []string {
+	tags, err := tagger.Tag(fmt.Sprintf("process://%d", pid), collectors.HighCardinality)
+	if err != nil {
+		log.Tracef("Could not collect tags for process %d: %v", pid, err)
+		return nil
+	}
+	return tags
+}
+
 func formatCommand(fp *procutil.Process) *model.Command {
 	return &model.Command{
 		Args:   fp.Cmdline,
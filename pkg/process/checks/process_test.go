@@ -572,3 +572,24 @@ func TestProcessContextCollection(t *testing.T) {
 	require.NoError(t, err)
 	assert.ElementsMatch(t, expected, actual.Payloads())
 }
+
+func TestProcessTags(t *testing.T) {
+	fakeTagger := tagger.SetupFakeTagger(t)
+	defer fakeTagger.ResetTagger()
+	fakeTagger.SetTags("process://1", "workloadmeta-systemd_unit", []string{"systemd_unit:nginx.service"}, nil, nil, nil)
+
+	procMap := map[int32]*procutil.Process{
+		1: makeProcess(1, "nginx -g daemon off;"),
+		2: makeProcess(2, "nginx -g daemon off;"),
+	}
+	lastRun := time.Now().Add(-5 * time.Second)
+	syst1, syst2 := cpu.TimesStat{}, cpu.TimesStat{}
+	serviceExtractor := parser.NewServiceExtractor(false, false, false)
+
+	// only the processes running outside of a container get their own tags
+	procs := fmtProcesses(procutil.NewDefaultDataScrubber(), nil, procMap, procMap, map[int]string{2: "ctr1"}, syst2, syst1, lastRun, nil, nil, false, serviceExtractor)
+	require.Len(t, procs[emptyCtrID], 1)
+	assert.Equal(t, []string{"systemd_unit:nginx.service"}, procs[emptyCtrID][0].Tags)
+	require.Len(t, procs["ctr1"], 1)
+	assert.Empty(t, procs["ctr1"][0].Tags)
+}
@@ -23,12 +23,16 @@ const (
 	ContainerImageScanType ScanType = "container-image"
 	// HostScanType defines the host scan type
 	HostScanType ScanType = "host"
+	// ProcessScanType defines the process scan type
+	ProcessScanType ScanType = "process"
 	// ContainerdCollector is the name of the containerd collector
 	ContainerdCollector = "containerd"
 	// DockerCollector is the name of the docker collector
 	DockerCollector = "docker"
 	// HostCollector is the name of the host collector
 	HostCollector = "host"
+	// ProcessCollector is the name of the process collector
+	ProcessCollector = "process"
 )
 
 // Collector interface
@@ -75,3 +79,8 @@ func GetContainerdScanner() Collector {
 func GetHostScanner() Collector {
 	return Collectors[HostCollector]
 }
+
+// GetProcessScanner returns the process scanner
+func GetProcessScanner() Collector {
+	return Collectors[ProcessCollector]
+}
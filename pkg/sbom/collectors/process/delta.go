@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package process
+
+import (
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+)
+
+// DiffBOM returns a BOM holding the packages of bom that are not part of
+// base, typically the SBOM of the image of the container. The packages are
+// the components identified by a package URL, the components grouping them,
+// like applications or operating systems, are not kept.
+func DiffBOM(bom, base *cyclonedxgo.BOM) *cyclonedxgo.BOM {
+	known := make(map[string]struct{})
+	if base != nil {
+		walkPackages(base.Components, func(c *cyclonedxgo.Component) {
+			known[c.PackageURL] = struct{}{}
+		})
+	}
+
+	delta := cyclonedxgo.NewBOM()
+	delta.SerialNumber = bom.SerialNumber
+	delta.Version = bom.Version
+	delta.Metadata = bom.Metadata
+
+	var added []cyclonedxgo.Component
+	seen := make(map[string]struct{})
+	walkPackages(bom.Components, func(c *cyclonedxgo.Component) {
+		if _, found := known[c.PackageURL]; found {
+			return
+		}
+		if _, found := seen[c.PackageURL]; found {
+			return
+		}
+		seen[c.PackageURL] = struct{}{}
+
+		pkg := *c
+		pkg.Components = nil
+		added = append(added, pkg)
+	})
+	delta.Components = &added
+
+	return delta
+}
+
+func walkPackages(components *[]cyclonedxgo.Component, fn func(*cyclonedxgo.Component)) {
+	if components == nil {
+		return
+	}
+	for i := range *components {
+		c := &(*components)[i]
+		if c.PackageURL != "" {
+			fn(c)
+		}
+		walkPackages(c.Components, fn)
+	}
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package process
+
+import (
+	"testing"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffBOM(t *testing.T) {
+	base := &cyclonedxgo.BOM{
+		Components: &[]cyclonedxgo.Component{
+			{Name: "debian", Type: cyclonedxgo.ComponentTypeOS},
+			{Name: "openssl", Version: "3.0.11", PackageURL: "pkg:deb/debian/openssl@3.0.11"},
+			{
+				Name: "Python",
+				Type: cyclonedxgo.ComponentTypeApplication,
+				Components: &[]cyclonedxgo.Component{
+					{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+				},
+			},
+		},
+	}
+
+	bom := &cyclonedxgo.BOM{
+		SerialNumber: "urn:uuid:1",
+		Version:      1,
+		Components: &[]cyclonedxgo.Component{
+			{Name: "openssl", Version: "3.0.11", PackageURL: "pkg:deb/debian/openssl@3.0.11"},
+			{
+				Name: "Python",
+				Type: cyclonedxgo.ComponentTypeApplication,
+				Components: &[]cyclonedxgo.Component{
+					{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+					{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+				},
+			},
+			{
+				Name: "Node.js",
+				Type: cyclonedxgo.ComponentTypeApplication,
+				Components: &[]cyclonedxgo.Component{
+					{Name: "express", Version: "4.18.2", PackageURL: "pkg:npm/express@4.18.2"},
+					{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+				},
+			},
+		},
+	}
+
+	delta := DiffBOM(bom, base)
+	assert.Equal(t, "urn:uuid:1", delta.SerialNumber)
+	assert.Equal(t, []cyclonedxgo.Component{
+		{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+		{Name: "express", Version: "4.18.2", PackageURL: "pkg:npm/express@4.18.2"},
+	}, *delta.Components)
+
+	// without image SBOM, all the packages are part of the delta
+	delta = DiffBOM(bom, nil)
+	assert.Len(t, *delta.Components, 4)
+
+	delta = DiffBOM(base, base)
+	assert.Empty(t, *delta.Components)
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/moby/sys/mountinfo"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// pseudoFilesystems are the filesystem types that never hold packages
+var pseudoFilesystems = map[string]bool{
+	"proc":     true,
+	"sysfs":    true,
+	"cgroup":   true,
+	"cgroup2":  true,
+	"devpts":   true,
+	"devtmpfs": true,
+	"mqueue":   true,
+}
+
+// scanPaths returns the directories to scan, as seen from the host, to find the
+// packages installed in the filesystem of a container at runtime or brought by
+// its volumes: the upper directory of its root overlay filesystem, where the
+// files written at runtime are stored, and the working directories of its
+// processes that belong to a volume. The packages of the image layers aren't
+// scanned again, they are part of the SBOM of the image. The mounts of the
+// container are read through the given process, and the working directories
+// of the given processes are read when the scan runs, out of the loop of the
+// check.
+func scanPaths(pid int, pids []int) ([]string, error) {
+	mounts, err := kernel.ParseMountInfoFile(int32(pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the mounts of process %d: %w", pid, err)
+	}
+
+	cwds := make([]string, 0, len(pids))
+	for _, p := range pids {
+		cwd, err := os.Readlink(kernel.HostProc(strconv.Itoa(p), "cwd"))
+		if err != nil {
+			// the process may have exited since it was listed
+			log.Debugf("failed to read the working directory of process %d: %v", p, err)
+			continue
+		}
+		cwds = append(cwds, cwd)
+	}
+
+	return paths(mounts, cwds, kernel.HostProc(strconv.Itoa(pid), "root"), kernel.HostProc("1", "root")), nil
+}
+
+// paths returns the directories to scan given the mounts of a container and the
+// working directories of its processes. procRoot is the root filesystem of the
+// container and hostRoot the one of the host, from which the upper directory
+// of the overlay is reached. The whole root filesystem is scanned when it
+// isn't an overlay.
+func paths(mounts []*mountinfo.Info, cwds []string, procRoot string, hostRoot string) []string {
+	rootMount := mountOf(mounts, "/")
+	if rootMount == nil {
+		return nil
+	}
+
+	var paths []string
+	if upperDir := overlayUpperDir(rootMount); upperDir != "" {
+		paths = append(paths, filepath.Join(hostRoot, upperDir))
+	} else {
+		paths = append(paths, procRoot)
+	}
+
+	var volumes []string
+	for _, cwd := range cwds {
+		// the working directory of a process can be removed while it's running
+		if strings.HasSuffix(cwd, " (deleted)") || !filepath.IsAbs(cwd) {
+			continue
+		}
+
+		cwd = filepath.Clean(cwd)
+		if cwdMount := mountOf(mounts, cwd); cwdMount != nil && cwdMount != rootMount && !pseudoFilesystems[cwdMount.FSType] {
+			volumes = append(volumes, cwd)
+		}
+	}
+
+	// the working directories nested in another one are scanned with it
+	slices.Sort(volumes)
+	var previous string
+	for _, volume := range volumes {
+		if previous != "" && isSubPath(previous, volume) {
+			continue
+		}
+		paths = append(paths, filepath.Join(procRoot, volume))
+		previous = volume
+	}
+
+	return paths
+}
+
+// overlayUpperDir returns the upper directory of an overlay mount, or an empty
+// string for the other mounts
+func overlayUpperDir(m *mountinfo.Info) string {
+	if m.FSType != "overlay" {
+		return ""
+	}
+	for _, opt := range strings.Split(m.VFSOptions, ",") {
+		if upperDir, found := strings.CutPrefix(opt, "upperdir="); found {
+			return upperDir
+		}
+	}
+	return ""
+}
+
+// mountOf returns the mount holding the given path. When several mounts share
+// the same mount point, the last one hides the others.
+func mountOf(mounts []*mountinfo.Info, path string) *mountinfo.Info {
+	var found *mountinfo.Info
+	for _, m := range mounts {
+		if !isSubPath(m.Mountpoint, path) {
+			continue
+		}
+		if found == nil || len(m.Mountpoint) >= len(found.Mountpoint) {
+			found = m
+		}
+	}
+	return found
+}
+
+func isSubPath(parent, path string) bool {
+	if parent == "/" || parent == path {
+		return true
+	}
+	return strings.HasPrefix(path, parent+"/")
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package process
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const containerMountInfo = `1339 1151 0:155 / / rw,relatime master:445 - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/l/A:/var/lib/docker/overlay2/l/B,upperdir=/var/lib/docker/overlay2/4d1a/diff,workdir=/var/lib/docker/overlay2/4d1a/work
+1340 1339 0:158 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+1341 1339 0:159 / /dev rw,nosuid - tmpfs tmpfs rw,size=65536k,mode=755
+1349 1339 259:2 /var/lib/docker/volumes/app-data/_data /srv/app rw,relatime - ext4 /dev/nvme0n1p2 rw
+1350 1339 259:2 /var/lib/docker/volumes/app-data/_data/vendor /srv/app/vendor rw,relatime - ext4 /dev/nvme0n1p2 rw
+`
+
+func parseMounts(t *testing.T) []*mountinfo.Info {
+	mounts, err := mountinfo.GetMountsFromReader(strings.NewReader(containerMountInfo), nil)
+	require.NoError(t, err)
+	return mounts
+}
+
+func TestPaths(t *testing.T) {
+	mounts := parseMounts(t)
+	const (
+		procRoot  = "/host/proc/42/root"
+		hostRoot  = "/host/proc/1/root"
+		upperPath = hostRoot + "/var/lib/docker/overlay2/4d1a/diff"
+	)
+
+	tests := []struct {
+		name     string
+		cwds     []string
+		expected []string
+	}{
+		{
+			name:     "working directory in the root filesystem",
+			cwds:     []string{"/usr/src/app"},
+			expected: []string{upperPath},
+		},
+		{
+			name:     "working directory in a volume",
+			cwds:     []string{"/srv/app/current"},
+			expected: []string{upperPath, procRoot + "/srv/app/current"},
+		},
+		{
+			name:     "working directory in a nested volume",
+			cwds:     []string{"/srv/app/vendor"},
+			expected: []string{upperPath, procRoot + "/srv/app/vendor"},
+		},
+		{
+			name:     "working directory in a pseudo filesystem",
+			cwds:     []string{"/proc/1"},
+			expected: []string{upperPath},
+		},
+		{
+			name:     "deleted working directory",
+			cwds:     []string{"/srv/app/old (deleted)"},
+			expected: []string{upperPath},
+		},
+		{
+			name:     "working directories of several processes",
+			cwds:     []string{"/srv/app/vendor", "/usr/src/app", "/srv/app", "/srv/app"},
+			expected: []string{upperPath, procRoot + "/srv/app"},
+		},
+		{
+			name:     "working directories in distinct volumes",
+			cwds:     []string{"/srv/app/vendor/lib", "/srv/app/current"},
+			expected: []string{upperPath, procRoot + "/srv/app/current", procRoot + "/srv/app/vendor/lib"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, paths(mounts, test.cwds, procRoot, hostRoot))
+		})
+	}
+}
+
+func TestPathsNoOverlay(t *testing.T) {
+	mounts, err := mountinfo.GetMountsFromReader(strings.NewReader("24 1 259:2 / / rw,relatime - ext4 /dev/nvme0n1p2 rw\n"), nil)
+	require.NoError(t, err)
+
+	// the whole root filesystem is scanned
+	assert.Equal(t, []string{"/proc/42/root"}, paths(mounts, []string{"/"}, "/proc/42/root", "/proc/1/root"))
+}
+
+func TestPathsNoRootMount(t *testing.T) {
+	assert.Empty(t, paths(nil, []string{"/"}, "/proc/42/root", "/proc/1/root"))
+}
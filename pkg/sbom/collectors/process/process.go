@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build trivy && linux
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/sbom/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/optional"
+	"github.com/DataDog/datadog-agent/pkg/util/trivy"
+)
+
+// resultChanSize defines the result channel size
+// A result is sent for each scanned layer, the processor consumes them
+// as they come.
+const resultChanSize = 100
+
+// skipDirs are the directories of the root filesystem of a process that are
+// not walked because they only hold pseudo filesystems
+var skipDirs = []string{"/proc", "/sys", "/dev"}
+
+// Collector defines a process collector, scanning the filesystem of the
+// containers through /proc/<pid>/root of one of their processes to find the
+// language packages installed at runtime or brought by volumes.
+type Collector struct {
+	trivyCollector *trivy.Collector
+	wmeta          optional.Option[workloadmeta.Component]
+	resChan        chan sbom.ScanResult
+	opts           sbom.ScanOptions
+
+	closed bool
+}
+
+// CleanCache cleans the cache
+func (c *Collector) CleanCache() error {
+	return nil
+}
+
+// Init initialize the process collector
+func (c *Collector) Init(cfg config.Config, wmeta optional.Option[workloadmeta.Component]) error {
+	trivyCollector, err := trivy.GetGlobalCollector(cfg, wmeta)
+	if err != nil {
+		return err
+	}
+	c.trivyCollector = trivyCollector
+	c.wmeta = wmeta
+	c.opts = sbom.ScanOptions{
+		Analyzers: cfg.GetStringSlice("sbom.process.analyzers"),
+		Timeout:   time.Duration(cfg.GetInt("sbom.process.scan_timeout")) * time.Second,
+		WaitAfter: time.Duration(cfg.GetInt("sbom.process.scan_interval")) * time.Second,
+		NoCache:   true,
+		SkipDirs:  skipDirs,
+	}
+	return nil
+}
+
+// Scan performs a scan
+func (c *Collector) Scan(ctx context.Context, request sbom.ScanRequest) sbom.ScanResult {
+	processScanRequest, ok := request.(ScanRequest)
+	if !ok {
+		return sbom.ScanResult{Error: fmt.Errorf("invalid request type '%s' for collector '%s'", reflect.TypeOf(request), collectors.ProcessCollector)}
+	}
+	log.Infof("process scan request for container %s through process %d", processScanRequest.ContainerID, processScanRequest.PID)
+
+	paths, err := scanPaths(processScanRequest.PID, c.containerPIDs(processScanRequest))
+	if err != nil {
+		return sbom.ScanResult{Error: err}
+	}
+
+	reports := make([]sbom.Report, 0, len(paths))
+	for _, path := range paths {
+		report, err := c.trivyCollector.ScanFilesystem(ctx, path, c.opts)
+		if err != nil {
+			return sbom.ScanResult{Error: fmt.Errorf("failed to scan %s: %w", path, err)}
+		}
+		reports = append(reports, report)
+	}
+
+	return sbom.ScanResult{
+		Report: newMergedReport(reports...),
+	}
+}
+
+// containerPIDs returns the PIDs of the processes of the container of the
+// request, whose working directories are scanned
+func (c *Collector) containerPIDs(request ScanRequest) []int {
+	pids := []int{request.PID}
+
+	store, ok := c.wmeta.Get()
+	if !ok {
+		return pids
+	}
+
+	processes := store.ListProcessesWithFilter(func(proc *workloadmeta.Process) bool {
+		return proc.ContainerID == request.ContainerID
+	})
+	for _, proc := range processes {
+		if pid, err := strconv.Atoi(proc.ID); err == nil && pid != request.PID {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// Type returns the process scan type
+func (c *Collector) Type() collectors.ScanType {
+	return collectors.ProcessScanType
+}
+
+// Channel returns the channel to send scan results
+func (c *Collector) Channel() chan sbom.ScanResult {
+	return c.resChan
+}
+
+// Options returns the collector options
+func (c *Collector) Options() sbom.ScanOptions {
+	return c.opts
+}
+
+// Shutdown shuts down the collector
+func (c *Collector) Shutdown() {
+	if c.resChan != nil && !c.closed {
+		close(c.resChan)
+	}
+	c.closed = true
+}
+
+func init() {
+	collectors.RegisterCollector(collectors.ProcessCollector, &Collector{
+		resChan: make(chan sbom.ScanResult, resultChanSize),
+	})
+}
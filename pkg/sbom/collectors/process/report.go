@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package process
+
+import (
+	"strings"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+)
+
+// mergedReport merges the reports of the paths scanned in the filesystem of a
+// container
+type mergedReport struct {
+	reports []sbom.Report
+}
+
+// newMergedReport returns a report merging the given reports
+func newMergedReport(reports ...sbom.Report) *mergedReport {
+	return &mergedReport{reports: reports}
+}
+
+// ToCycloneDX returns the components of all the reports in a single BOM,
+// holding the metadata of the first report
+func (r *mergedReport) ToCycloneDX() (*cyclonedxgo.BOM, error) {
+	var (
+		merged     *cyclonedxgo.BOM
+		components []cyclonedxgo.Component
+	)
+	for _, report := range r.reports {
+		bom, err := report.ToCycloneDX()
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			copied := *bom
+			merged = &copied
+		}
+		if bom.Components != nil {
+			components = append(components, *bom.Components...)
+		}
+	}
+
+	if merged == nil {
+		merged = cyclonedxgo.NewBOM()
+	}
+	merged.Components = &components
+	return merged, nil
+}
+
+// ID returns the identifier of the content of the reports
+func (r *mergedReport) ID() string {
+	ids := make([]string, 0, len(r.reports))
+	for _, report := range r.reports {
+		ids = append(ids, report.ID())
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package process
+
+import (
+	"testing"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReport struct {
+	id  string
+	bom *cyclonedxgo.BOM
+}
+
+func (r *fakeReport) ToCycloneDX() (*cyclonedxgo.BOM, error) {
+	return r.bom, nil
+}
+
+func (r *fakeReport) ID() string {
+	return r.id
+}
+
+func TestMergedReport(t *testing.T) {
+	root := &fakeReport{
+		id: "sha256:1",
+		bom: &cyclonedxgo.BOM{
+			SerialNumber: "urn:uuid:root",
+			Components: &[]cyclonedxgo.Component{
+				{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+			},
+		},
+	}
+	volume := &fakeReport{
+		id: "sha256:2",
+		bom: &cyclonedxgo.BOM{
+			SerialNumber: "urn:uuid:volume",
+			Components: &[]cyclonedxgo.Component{
+				{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+			},
+		},
+	}
+
+	report := newMergedReport(root, volume)
+	assert.Equal(t, "sha256:1,sha256:2", report.ID())
+
+	bom, err := report.ToCycloneDX()
+	require.NoError(t, err)
+	assert.Equal(t, "urn:uuid:root", bom.SerialNumber)
+	assert.Equal(t, []cyclonedxgo.Component{
+		{Name: "pip", Version: "23.0.1", PackageURL: "pkg:pypi/pip@23.0.1"},
+		{Name: "requests", Version: "2.31.0", PackageURL: "pkg:pypi/requests@2.31.0"},
+	}, *bom.Components)
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package process
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/sbom/collectors"
+)
+
+// ScanRequest defines a scan request of the filesystem of a container, seen
+// through one of its processes. This struct should be hashable to be pushed in
+// the work queue for processing.
+type ScanRequest struct {
+	// PID is the PID of the process, in the host PID namespace
+	PID int
+	// ContainerID is the ID of the container of the process
+	ContainerID string
+}
+
+// NewScanRequest returns the scan request of the filesystem of the container
+// of the given process
+func NewScanRequest(proc *workloadmeta.Process) (ScanRequest, error) {
+	pid, err := strconv.Atoi(proc.ID)
+	if err != nil {
+		return ScanRequest{}, fmt.Errorf("invalid pid '%s': %w", proc.ID, err)
+	}
+	return ScanRequest{PID: pid, ContainerID: proc.ContainerID}, nil
+}
+
+// Collector returns the collector name
+func (r ScanRequest) Collector() string {
+	return collectors.ProcessCollector
+}
+
+// Type returns the scan request type
+func (r ScanRequest) Type() string {
+	return sbom.ScanFilesystemType
+}
+
+// ID returns the scan request ID
+func (r ScanRequest) ID() string {
+	return r.ContainerID
+}
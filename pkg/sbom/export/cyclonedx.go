@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"io"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+)
+
+// writeCycloneDX writes the document as CycloneDX 1.5 JSON. The findings are
+// added as the vulnerabilities of the BOM, with their impact analysis.
+func writeCycloneDX(w io.Writer, doc *Document) error {
+	bom := toCycloneDX(doc)
+	return cyclonedxgo.NewBOMEncoder(w, cyclonedxgo.BOMFileFormatJSON).SetPretty(true).EncodeVersion(bom, cyclonedxgo.SpecVersion1_5)
+}
+
+// toCycloneDX returns a copy of the BOM of the document with its findings
+func toCycloneDX(doc *Document) *cyclonedxgo.BOM {
+	// The BOM is shared with the workloadmeta store, only the top level
+	// fields of its copy are modified.
+	bom := *doc.BOM
+	bom.SpecVersion = cyclonedxgo.SpecVersion1_5
+	bom.BOMFormat = cyclonedxgo.BOMFormat
+	if bom.JSONSchema == "" {
+		bom.JSONSchema = "http://cyclonedx.org/schema/bom-1.5.schema.json"
+	}
+
+	if len(doc.Findings) == 0 {
+		return &bom
+	}
+
+	refs := make(map[string]string)
+	walkComponents(bom.Components, nil, func(c *cyclonedxgo.Component, _ *cyclonedxgo.Component) {
+		if c.PackageURL != "" && c.BOMRef != "" {
+			refs[c.PackageURL] = c.BOMRef
+		}
+	})
+
+	var vulnerabilities []cyclonedxgo.Vulnerability
+	indexes := make(map[string]int)
+	if bom.Vulnerabilities != nil {
+		vulnerabilities = append(vulnerabilities, *bom.Vulnerabilities...)
+	}
+
+	updated := doc.GeneratedAt.UTC().Format(time.RFC3339)
+	for _, finding := range doc.Findings {
+		i, found := indexes[finding.VulnerabilityID]
+		if !found {
+			i = len(vulnerabilities)
+			indexes[finding.VulnerabilityID] = i
+
+			vuln := cyclonedxgo.Vulnerability{
+				BOMRef:      finding.VulnerabilityID,
+				ID:          finding.VulnerabilityID,
+				Source:      &cyclonedxgo.Source{Name: "OSV", URL: advisoryURL(finding.VulnerabilityID)},
+				Description: finding.Summary,
+				Ratings: &[]cyclonedxgo.VulnerabilityRating{
+					{Source: &cyclonedxgo.Source{Name: "OSV"}, Severity: cyclonedxgo.Severity(finding.Severity)},
+				},
+				// The vulnerable version of the package is installed, but
+				// nothing tells whether it is exploitable
+				Analysis: &cyclonedxgo.VulnerabilityAnalysis{
+					State:       cyclonedxgo.IASInTriage,
+					LastUpdated: updated,
+				},
+				Affects: &[]cyclonedxgo.Affects{},
+			}
+			if len(finding.Aliases) > 0 {
+				references := make([]cyclonedxgo.VulnerabilityReference, 0, len(finding.Aliases))
+				for _, alias := range finding.Aliases {
+					references = append(references, cyclonedxgo.VulnerabilityReference{
+						ID:     alias,
+						Source: &cyclonedxgo.Source{URL: advisoryURL(alias)},
+					})
+				}
+				vuln.References = &references
+			}
+			vulnerabilities = append(vulnerabilities, vuln)
+		}
+
+		vuln := &vulnerabilities[i]
+		ref := refs[finding.PURL]
+		if ref == "" {
+			ref = finding.PURL
+		}
+		*vuln.Affects = append(*vuln.Affects, cyclonedxgo.Affects{
+			Ref: ref,
+			Range: &[]cyclonedxgo.AffectedVersions{
+				{Version: finding.Version, Status: cyclonedxgo.VulnerabilityStatusAffected},
+			},
+		})
+		if finding.FixedVersion != "" {
+			if vuln.Recommendation != "" {
+				vuln.Recommendation += "\n"
+			}
+			vuln.Recommendation += "Upgrade " + finding.Package + " to " + finding.FixedVersion
+		}
+	}
+
+	bom.Vulnerabilities = &vulnerabilities
+	return &bom
+}
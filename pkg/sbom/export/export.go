@@ -0,0 +1,256 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package export exports the latest SBOMs collected by the agent in the
+// standard CycloneDX and SPDX JSON formats
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
+)
+
+// Kinds of the entities whose SBOM can be exported
+const (
+	KindHost      = "host"
+	KindImage     = "image"
+	KindContainer = "container"
+)
+
+// Formats of the exported SBOMs
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// ErrNotFound is returned when there is no SBOM for the requested entity
+var ErrNotFound = errors.New("no SBOM found")
+
+// Document is the latest SBOM of a host, an image or a container
+type Document struct {
+	// Kind is the kind of the entity the SBOM belongs to
+	Kind string
+	// ID is the hostname, the image ID or the container ID
+	ID string
+	// Name is a human readable name of the entity
+	Name string
+	// GeneratedAt is the time the SBOM was generated at
+	GeneratedAt time.Time
+	// BOM is the SBOM, as generated by the scanners
+	BOM *cyclonedxgo.BOM
+	// Findings are the vulnerabilities affecting the components of the SBOM,
+	// exported as VEX annotations
+	Findings []osv.Finding
+}
+
+// latest holds the latest SBOMs generated by the SBOM check. It is global to
+// the package as the check and the API exporting the SBOMs run in the same
+// agent process without sharing a component. Nothing is stored when the check
+// runs in another process, like with the check subcommand.
+var latest = struct {
+	sync.RWMutex
+	host       *Document
+	containers map[string]*Document
+}{
+	containers: make(map[string]*Document),
+}
+
+// SetHostSBOM stores the latest SBOM of the host
+func SetHostSBOM(hostname string, bom *cyclonedxgo.BOM, generatedAt time.Time) {
+	latest.Lock()
+	defer latest.Unlock()
+	latest.host = &Document{
+		Kind:        KindHost,
+		ID:          hostname,
+		Name:        hostname,
+		GeneratedAt: generatedAt,
+		BOM:         bom,
+	}
+}
+
+// SetContainerSBOM stores the latest SBOM of the packages found in the
+// filesystem of a container that are not part of the SBOM of its image. They
+// are merged into the SBOM of the image when the container is exported.
+func SetContainerSBOM(containerID string, bom *cyclonedxgo.BOM, generatedAt time.Time) {
+	latest.Lock()
+	defer latest.Unlock()
+	latest.containers[containerID] = &Document{
+		Kind:        KindContainer,
+		ID:          containerID,
+		GeneratedAt: generatedAt,
+		BOM:         bom,
+	}
+}
+
+// DeleteContainerSBOM forgets the SBOM stored for a container
+func DeleteContainerSBOM(containerID string) {
+	latest.Lock()
+	defer latest.Unlock()
+	delete(latest.containers, containerID)
+}
+
+// Get returns the latest SBOM of an entity. The ID of an image can be its ID,
+// one of its repo tags or one of its repo digests. The ID is ignored for the
+// host.
+func Get(wmeta workloadmeta.Component, kind string, id string) (*Document, error) {
+	switch kind {
+	case KindHost:
+		latest.RLock()
+		defer latest.RUnlock()
+		if latest.host == nil {
+			return nil, fmt.Errorf("%w for the host", ErrNotFound)
+		}
+		doc := *latest.host
+		return &doc, nil
+	case KindImage:
+		img := findImage(wmeta, id)
+		if img == nil {
+			return nil, fmt.Errorf("%w for the image %s: unknown image", ErrNotFound, id)
+		}
+		return imageDocument(KindImage, id, img)
+	case KindContainer:
+		ctr, err := wmeta.GetContainer(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w for the container %s: %v", ErrNotFound, id, err)
+		}
+		img, err := wmeta.GetImage(ctr.Image.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w for the container %s: unknown image %s", ErrNotFound, id, ctr.Image.ID)
+		}
+		doc, err := imageDocument(KindContainer, ctr.ID, img)
+		if err != nil {
+			return nil, err
+		}
+		if ctr.Name != "" {
+			doc.Name = ctr.Name
+		}
+
+		latest.RLock()
+		delta := latest.containers[ctr.ID]
+		latest.RUnlock()
+		if delta != nil {
+			doc.BOM = mergeBOM(doc.BOM, delta.BOM)
+			if delta.GeneratedAt.After(doc.GeneratedAt) {
+				doc.GeneratedAt = delta.GeneratedAt
+			}
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown kind %q, expected one of %s, %s or %s", kind, KindHost, KindImage, KindContainer)
+}
+
+// findImage returns the image whose ID, repo tag or repo digest is the given ID
+func findImage(wmeta workloadmeta.Component, id string) *workloadmeta.ContainerImageMetadata {
+	if img, err := wmeta.GetImage(id); err == nil {
+		return img
+	}
+
+	for _, img := range wmeta.ListImages() {
+		if img.ID == "sha256:"+id || img.Name == id {
+			return img
+		}
+		for _, ref := range img.RepoTags {
+			if ref == id {
+				return img
+			}
+		}
+		for _, ref := range img.RepoDigests {
+			if ref == id {
+				return img
+			}
+		}
+	}
+	return nil
+}
+
+func imageDocument(kind string, id string, img *workloadmeta.ContainerImageMetadata) (*Document, error) {
+	if img.SBOM == nil || img.SBOM.CycloneDXBOM == nil || img.SBOM.Status != workloadmeta.Success {
+		return nil, fmt.Errorf("%w for the image %s: the image wasn't scanned yet", ErrNotFound, img.ID)
+	}
+
+	name := img.Name
+	if len(img.RepoTags) > 0 {
+		name = img.RepoTags[0]
+	}
+	if name == "" {
+		name = img.ID
+	}
+
+	return &Document{
+		Kind:        kind,
+		ID:          id,
+		Name:        name,
+		GeneratedAt: img.SBOM.GenerationTime,
+		BOM:         img.SBOM.CycloneDXBOM,
+	}, nil
+}
+
+// mergeBOM returns a copy of the SBOM with the components of the delta whose
+// package isn't already part of it
+func mergeBOM(bom, delta *cyclonedxgo.BOM) *cyclonedxgo.BOM {
+	if delta == nil || delta.Components == nil {
+		return bom
+	}
+
+	known := make(map[string]struct{})
+	walkComponents(bom.Components, nil, func(c *cyclonedxgo.Component, _ *cyclonedxgo.Component) {
+		if c.PackageURL != "" {
+			known[c.PackageURL] = struct{}{}
+		}
+	})
+
+	var components []cyclonedxgo.Component
+	if bom.Components != nil {
+		components = append(components, *bom.Components...)
+	}
+	for _, c := range *delta.Components {
+		if _, found := known[c.PackageURL]; found && c.PackageURL != "" {
+			continue
+		}
+		components = append(components, c)
+	}
+
+	merged := *bom
+	merged.Components = &components
+	return &merged
+}
+
+// Write writes the document in the given format
+func Write(w io.Writer, doc *Document, format string) error {
+	switch strings.ToLower(format) {
+	case FormatCycloneDX, "":
+		return writeCycloneDX(w, doc)
+	case FormatSPDX:
+		return writeSPDX(w, doc)
+	}
+	return fmt.Errorf("unknown format %q, expected %s or %s", format, FormatCycloneDX, FormatSPDX)
+}
+
+// walkComponents calls fn on the components and their nested components, with
+// their parent, nil for the top level components
+func walkComponents(components *[]cyclonedxgo.Component, parent *cyclonedxgo.Component, fn func(c *cyclonedxgo.Component, parent *cyclonedxgo.Component)) {
+	if components == nil {
+		return
+	}
+	for i := range *components {
+		c := &(*components)[i]
+		fn(c, parent)
+		walkComponents(c.Components, c, fn)
+	}
+}
+
+// advisoryURL returns the URL of an advisory on the OSV website
+func advisoryURL(id string) string {
+	return "https://osv.dev/vulnerability/" + id
+}
@@ -0,0 +1,256 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	configcomp "github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log/logimpl"
+	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/sbom/osv"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+const imageID = "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd"
+
+func newBOM() *cyclonedxgo.BOM {
+	return &cyclonedxgo.BOM{
+		Metadata: &cyclonedxgo.Metadata{
+			Component: &cyclonedxgo.Component{Name: "datadog/app", Version: "1.0", Type: cyclonedxgo.ComponentTypeContainer},
+		},
+		Components: &[]cyclonedxgo.Component{
+			{
+				BOMRef:     "pkg:deb/debian/curl@7.88.1-10+deb12u4",
+				Name:       "curl",
+				Version:    "7.88.1-10+deb12u4",
+				PackageURL: "pkg:deb/debian/curl@7.88.1-10+deb12u4",
+				Type:       cyclonedxgo.ComponentTypeLibrary,
+				Licenses:   &cyclonedxgo.Licenses{{License: &cyclonedxgo.License{ID: "curl"}}},
+			},
+			{
+				BOMRef: "python",
+				Name:   "Python",
+				Type:   cyclonedxgo.ComponentTypeApplication,
+				Components: &[]cyclonedxgo.Component{
+					{BOMRef: "pkg:pypi/requests@2.28.1", Name: "requests", Version: "2.28.1", PackageURL: "pkg:pypi/requests@2.28.1", Type: cyclonedxgo.ComponentTypeLibrary},
+				},
+			},
+		},
+	}
+}
+
+var findings = []osv.Finding{
+	{
+		VulnerabilityID: "DSA-5587-1",
+		Summary:         "curl - security update",
+		Severity:        osv.SeverityHigh,
+		Package:         "curl",
+		Version:         "7.88.1-10+deb12u4",
+		PURL:            "pkg:deb/debian/curl@7.88.1-10+deb12u4",
+		FixedVersion:    "7.88.1-10+deb12u5",
+	},
+	{
+		VulnerabilityID: "GHSA-j8r2-6x86-q33q",
+		Aliases:         []string{"CVE-2023-32681"},
+		Severity:        osv.SeverityMedium,
+		Package:         "requests",
+		Version:         "2.28.1",
+		PURL:            "pkg:pypi/requests@2.28.1",
+		FixedVersion:    "2.31.0",
+	},
+}
+
+func TestGet(t *testing.T) {
+	wmeta := fxutil.Test[workloadmeta.Mock](t, fx.Options(
+		logimpl.MockModule(),
+		configcomp.MockModule(),
+		fx.Supply(context.Background()),
+		fx.Supply(workloadmeta.NewParams()),
+		workloadmeta.MockModuleV2(),
+	))
+
+	generatedAt := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	wmeta.Set(&workloadmeta.ContainerImageMetadata{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainerImageMetadata, ID: imageID},
+		RepoTags: []string{"datadog/app:1.0"},
+		SBOM: &workloadmeta.SBOM{
+			CycloneDXBOM:   newBOM(),
+			GenerationTime: generatedAt,
+			Status:         workloadmeta.Success,
+		},
+	})
+	wmeta.Set(&workloadmeta.ContainerImageMetadata{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainerImageMetadata, ID: "sha256:2"},
+		RepoTags: []string{"datadog/pending:1.0"},
+		SBOM:     &workloadmeta.SBOM{Status: workloadmeta.Pending},
+	})
+	wmeta.Set(&workloadmeta.Container{
+		EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "ctr1"},
+		EntityMeta: workloadmeta.EntityMeta{Name: "app"},
+		Image:      workloadmeta.ContainerImage{ID: imageID},
+	})
+
+	doc, err := Get(wmeta, KindImage, "datadog/app:1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "datadog/app:1.0", doc.Name)
+	assert.Equal(t, generatedAt, doc.GeneratedAt)
+
+	doc, err = Get(wmeta, KindImage, imageID)
+	require.NoError(t, err)
+	assert.Equal(t, imageID, doc.ID)
+
+	doc, err = Get(wmeta, KindContainer, "ctr1")
+	require.NoError(t, err)
+	assert.Equal(t, "app", doc.Name)
+	assert.Equal(t, KindContainer, doc.Kind)
+	assert.Len(t, *doc.BOM.Components, 2)
+
+	// the packages found in the filesystem of the container are merged into
+	// the SBOM of its image
+	scannedAt := generatedAt.Add(time.Hour)
+	SetContainerSBOM("ctr1", &cyclonedxgo.BOM{
+		Components: &[]cyclonedxgo.Component{
+			{Name: "curl", PackageURL: "pkg:deb/debian/curl@7.88.1-10+deb12u4"},
+			{Name: "flask", PackageURL: "pkg:pypi/flask@2.2.2"},
+		},
+	}, scannedAt)
+	defer DeleteContainerSBOM("ctr1")
+
+	doc, err = Get(wmeta, KindContainer, "ctr1")
+	require.NoError(t, err)
+	assert.Equal(t, scannedAt, doc.GeneratedAt)
+	require.Len(t, *doc.BOM.Components, 3)
+	assert.Equal(t, "flask", (*doc.BOM.Components)[2].Name)
+
+	// the SBOM of the image is left untouched
+	doc, err = Get(wmeta, KindImage, imageID)
+	require.NoError(t, err)
+	assert.Len(t, *doc.BOM.Components, 2)
+
+	_, err = Get(wmeta, KindImage, "datadog/pending:1.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Get(wmeta, KindContainer, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Get(wmeta, "pod", "foo")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	bom := newBOM()
+	doc := &Document{Kind: KindImage, ID: imageID, Name: "datadog/app:1.0", BOM: bom, Findings: findings}
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, doc, FormatCycloneDX))
+
+	var exported cyclonedxgo.BOM
+	require.NoError(t, json.Unmarshal(b.Bytes(), &exported))
+	assert.Equal(t, cyclonedxgo.SpecVersion1_5, exported.SpecVersion)
+	require.NotNil(t, exported.Vulnerabilities)
+	require.Len(t, *exported.Vulnerabilities, 2)
+
+	vuln := (*exported.Vulnerabilities)[1]
+	assert.Equal(t, "GHSA-j8r2-6x86-q33q", vuln.ID)
+	assert.Equal(t, cyclonedxgo.IASInTriage, vuln.Analysis.State)
+	assert.Equal(t, "Upgrade requests to 2.31.0", vuln.Recommendation)
+	assert.Equal(t, []cyclonedxgo.Affects{{
+		Ref:   "pkg:pypi/requests@2.28.1",
+		Range: &[]cyclonedxgo.AffectedVersions{{Version: "2.28.1", Status: cyclonedxgo.VulnerabilityStatusAffected}},
+	}}, *vuln.Affects)
+	assert.Equal(t, "CVE-2023-32681", (*vuln.References)[0].ID)
+
+	// the BOM of the document is left untouched
+	assert.Nil(t, bom.Vulnerabilities)
+	assert.Equal(t, cyclonedxgo.SpecVersion(0), bom.SpecVersion)
+}
+
+func TestWriteSPDX(t *testing.T) {
+	doc := &Document{
+		Kind:        KindImage,
+		ID:          imageID,
+		Name:        "datadog/app:1.0",
+		GeneratedAt: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		BOM:         newBOM(),
+		Findings:    findings,
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, doc, FormatSPDX))
+
+	var exported struct {
+		SPDXVersion  string `json:"spdxVersion"`
+		Name         string `json:"name"`
+		CreationInfo struct {
+			Created string `json:"created"`
+		} `json:"creationInfo"`
+		Packages []struct {
+			SPDXID          string `json:"SPDXID"`
+			Name            string `json:"name"`
+			LicenseDeclared string `json:"licenseDeclared"`
+			Purpose         string `json:"primaryPackagePurpose"`
+			ExternalRefs    []struct {
+				Category string `json:"referenceCategory"`
+				Type     string `json:"referenceType"`
+				Locator  string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+			Annotations []struct {
+				Annotator string `json:"annotator"`
+				Type      string `json:"annotationType"`
+				Comment   string `json:"comment"`
+			} `json:"annotations"`
+		} `json:"packages"`
+		Relationships []struct {
+			A    string `json:"spdxElementId"`
+			B    string `json:"relatedSpdxElement"`
+			Type string `json:"relationshipType"`
+		} `json:"relationships"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &exported))
+
+	assert.Equal(t, "SPDX-2.3", exported.SPDXVersion)
+	assert.Equal(t, "datadog/app:1.0", exported.Name)
+	assert.Equal(t, "2024-03-10T08:00:00Z", exported.CreationInfo.Created)
+
+	require.Len(t, exported.Packages, 4)
+	assert.Equal(t, "SPDXRef-Package-root", exported.Packages[0].SPDXID)
+	assert.Equal(t, "CONTAINER", exported.Packages[0].Purpose)
+
+	curl := exported.Packages[1]
+	assert.Equal(t, "curl", curl.Name)
+	assert.Equal(t, "curl", curl.LicenseDeclared)
+	require.Len(t, curl.ExternalRefs, 2)
+	assert.Equal(t, "PACKAGE-MANAGER", curl.ExternalRefs[0].Category)
+	assert.Equal(t, "purl", curl.ExternalRefs[0].Type)
+	assert.Equal(t, "SECURITY", curl.ExternalRefs[1].Category)
+	assert.Equal(t, "https://osv.dev/vulnerability/DSA-5587-1", curl.ExternalRefs[1].Locator)
+	require.Len(t, curl.Annotations, 1)
+	assert.Equal(t, "Tool: datadog-agent", curl.Annotations[0].Annotator)
+	assert.Equal(t, "REVIEW", curl.Annotations[0].Type)
+	assert.Equal(t, "VEX: DSA-5587-1 (severity: high) status: affected, version: 7.88.1-10+deb12u4, fixed in: 7.88.1-10+deb12u5", curl.Annotations[0].Comment)
+
+	assert.Equal(t, "NOASSERTION", exported.Packages[2].LicenseDeclared)
+	assert.Equal(t, "APPLICATION", exported.Packages[2].Purpose)
+
+	require.Len(t, exported.Relationships, 4)
+	assert.Equal(t, "DESCRIBES", exported.Relationships[0].Type)
+	assert.Equal(t, "SPDXRef-DOCUMENT", exported.Relationships[0].A)
+	// the nested components are contained by their parent
+	assert.Equal(t, "SPDXRef-Package-2", exported.Relationships[3].A)
+	assert.Equal(t, "SPDXRef-Package-3", exported.Relationships[3].B)
+	assert.Equal(t, "CONTAINS", exported.Relationships[3].Type)
+}
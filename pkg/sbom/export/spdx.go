@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	noAssertion = "NOASSERTION"
+	spdxTool    = "datadog-agent"
+
+	spdxDocumentID = "DOCUMENT"
+	spdxRootID     = "Package-root"
+)
+
+// writeSPDX writes the document as SPDX 2.3 JSON. The findings are added as
+// review annotations and advisory references of the affected packages.
+func writeSPDX(w io.Writer, doc *Document) error {
+	return spdxjson.Write(toSPDX(doc), w, spdxjson.Indent("  "))
+}
+
+// toSPDX converts the CycloneDX BOM of the document to a SPDX document
+func toSPDX(doc *Document) *v2_3.Document {
+	created := doc.GeneratedAt.UTC().Format(time.RFC3339)
+
+	spdxDoc := &v2_3.Document{
+		SPDXVersion:       v2_3.Version,
+		DataLicense:       v2_3.DataLicense,
+		SPDXIdentifier:    spdxDocumentID,
+		DocumentName:      doc.Name,
+		DocumentNamespace: fmt.Sprintf("https://www.datadoghq.com/spdxdocs/%s/%s-%s", doc.Kind, url.PathEscape(doc.Name), uuid.New()),
+		CreationInfo: &v2_3.CreationInfo{
+			Creators: []common.Creator{
+				{CreatorType: "Organization", Creator: "Datadog"},
+				{CreatorType: "Tool", Creator: spdxTool + "-" + version.AgentVersion},
+			},
+			Created: created,
+		},
+	}
+
+	root := &v2_3.Package{
+		PackageName:             doc.Name,
+		PackageSPDXIdentifier:   spdxRootID,
+		PackageDownloadLocation: noAssertion,
+		PrimaryPackagePurpose:   primaryPurpose(doc.Kind),
+	}
+	if doc.BOM.Metadata != nil && doc.BOM.Metadata.Component != nil {
+		root.PackageVersion = doc.BOM.Metadata.Component.Version
+	}
+	spdxDoc.Packages = append(spdxDoc.Packages, root)
+	spdxDoc.Relationships = append(spdxDoc.Relationships, &v2_3.Relationship{
+		RefA:         common.MakeDocElementID("", spdxDocumentID),
+		RefB:         common.MakeDocElementID("", spdxRootID),
+		Relationship: common.TypeRelationshipDescribe,
+	})
+
+	findings := make(map[string][]int)
+	for i, finding := range doc.Findings {
+		findings[finding.PURL] = append(findings[finding.PURL], i)
+	}
+
+	ids := make(map[*cyclonedxgo.Component]common.ElementID)
+	walkComponents(doc.BOM.Components, nil, func(c *cyclonedxgo.Component, parent *cyclonedxgo.Component) {
+		id := common.ElementID(fmt.Sprintf("Package-%d", len(ids)+1))
+		ids[c] = id
+
+		pkg := &v2_3.Package{
+			PackageName:             c.Name,
+			PackageSPDXIdentifier:   id,
+			PackageVersion:          c.Version,
+			PackageDownloadLocation: noAssertion,
+			PackageLicenseConcluded: noAssertion,
+			PackageLicenseDeclared:  spdxLicense(c.Licenses),
+			PackageCopyrightText:    noAssertion,
+			PrimaryPackagePurpose:   primaryPurpose(string(c.Type)),
+		}
+		if c.PackageURL != "" {
+			pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &v2_3.PackageExternalReference{
+				Category: common.CategoryPackageManager,
+				RefType:  common.TypePackageManagerPURL,
+				Locator:  c.PackageURL,
+			})
+		}
+
+		for _, i := range findings[c.PackageURL] {
+			finding := doc.Findings[i]
+			pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &v2_3.PackageExternalReference{
+				Category: common.CategorySecurity,
+				RefType:  common.TypeSecurityAdvisory,
+				Locator:  advisoryURL(finding.VulnerabilityID),
+			})
+			pkg.Annotations = append(pkg.Annotations, v2_3.Annotation{
+				Annotator:         common.Annotator{AnnotatorType: "Tool", Annotator: spdxTool},
+				AnnotationDate:    created,
+				AnnotationType:    "REVIEW",
+				AnnotationComment: vexComment(finding.VulnerabilityID, finding.Severity, finding.Version, finding.FixedVersion),
+			})
+		}
+		spdxDoc.Packages = append(spdxDoc.Packages, pkg)
+
+		parentID := common.ElementID(spdxRootID)
+		if parent != nil {
+			parentID = ids[parent]
+		}
+		spdxDoc.Relationships = append(spdxDoc.Relationships, &v2_3.Relationship{
+			RefA:         common.MakeDocElementID("", string(parentID)),
+			RefB:         common.MakeDocElementID("", string(id)),
+			Relationship: common.TypeRelationshipContains,
+		})
+	})
+
+	return spdxDoc
+}
+
+// vexComment returns the comment of the annotation of a vulnerability affecting
+// a package
+func vexComment(id, severity, version, fixedVersion string) string {
+	comment := fmt.Sprintf("VEX: %s (severity: %s) status: affected, version: %s", id, severity, version)
+	if fixedVersion != "" {
+		comment += ", fixed in: " + fixedVersion
+	}
+	return comment
+}
+
+// spdxLicense returns the license expression of the licenses of a component
+func spdxLicense(licenses *cyclonedxgo.Licenses) string {
+	if licenses == nil || len(*licenses) == 0 {
+		return noAssertion
+	}
+
+	var expressions []string
+	for _, choice := range *licenses {
+		switch {
+		case choice.Expression != "":
+			expressions = append(expressions, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			expressions = append(expressions, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			// Only the license identifiers of the SPDX list are valid in
+			// an expression
+			return noAssertion
+		}
+	}
+	if len(expressions) == 0 {
+		return noAssertion
+	}
+	if len(expressions) == 1 {
+		return expressions[0]
+	}
+	return "(" + strings.Join(expressions, ") AND (") + ")"
+}
+
+// primaryPurpose returns the SPDX package purpose of a CycloneDX component
+// type or of the kind of an exported entity
+func primaryPurpose(kind string) string {
+	switch kind {
+	case KindImage, KindContainer: // the container kind is also the container component type
+		return "CONTAINER"
+	case KindHost, string(cyclonedxgo.ComponentTypeOS):
+		return "OPERATING-SYSTEM"
+	case string(cyclonedxgo.ComponentTypeApplication):
+		return "APPLICATION"
+	case string(cyclonedxgo.ComponentTypeLibrary):
+		return "LIBRARY"
+	case string(cyclonedxgo.ComponentTypeFramework):
+		return "FRAMEWORK"
+	case string(cyclonedxgo.ComponentTypeFile):
+		return "FILE"
+	}
+	return ""
+}
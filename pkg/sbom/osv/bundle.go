@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// Bundle holds the advisories of an OSV bundle, indexed by ecosystem and
+// package name
+type Bundle struct {
+	// Path is the path the bundle was loaded from
+	Path string
+	// Advisories is the number of advisories of the bundle
+	Advisories int
+	// LastModified is the most recent modification date of the advisories,
+	// it tells how fresh the bundle is
+	LastModified time.Time
+	// LoadedAt is the time the bundle was loaded
+	LoadedAt time.Time
+
+	packages map[string][]*Vulnerability
+}
+
+// LoadBundle loads the OSV advisories found at the given path. It can be a
+// JSON file holding an advisory or a list of advisories, a zip archive of
+// JSON files like the ones distributed by osv.dev, or a directory holding
+// such files.
+func LoadBundle(path string) (*Bundle, error) {
+	b := &Bundle{
+		Path:     path,
+		LoadedAt: time.Now(),
+		packages: make(map[string][]*Vulnerability),
+	}
+
+	err := walkBundleFiles(path, func(file string, _ fs.FileInfo) error {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".json":
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return b.decode(file, f)
+		case ".zip":
+			return b.loadZip(file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Advisories == 0 {
+		return nil, fmt.Errorf("no OSV advisory found in %s", path)
+	}
+	return b, nil
+}
+
+// lastModTime returns the most recent modification time of the files of the
+// bundle at the given path
+func lastModTime(path string) (time.Time, error) {
+	var last time.Time
+	err := walkBundleFiles(path, func(_ string, fi fs.FileInfo) error {
+		if fi.ModTime().After(last) {
+			last = fi.ModTime()
+		}
+		return nil
+	})
+	return last, err
+}
+
+func walkBundleFiles(path string, fn func(file string, fi fs.FileInfo) error) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fn(path, fi)
+	}
+
+	return filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(file, fi)
+	})
+}
+
+func (b *Bundle) loadZip(file string) error {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer r.Close()
+
+	for _, entry := range r.File {
+		if entry.FileInfo().IsDir() || strings.ToLower(filepath.Ext(entry.Name)) != ".json" {
+			continue
+		}
+		f, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s in %s: %w", entry.Name, file, err)
+		}
+		err = b.decode(file+":"+entry.Name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode decodes an advisory or a list of advisories
+func (b *Bundle) decode(name string, r io.Reader) error {
+	br := bufio.NewReader(r)
+	var first byte
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			first = c
+			_ = br.UnreadByte()
+			break
+		}
+	}
+
+	var vulns []*Vulnerability
+	if first == '[' {
+		if err := json.NewDecoder(br).Decode(&vulns); err != nil {
+			return fmt.Errorf("failed to decode the advisories of %s: %w", name, err)
+		}
+	} else {
+		var vuln Vulnerability
+		if err := json.NewDecoder(br).Decode(&vuln); err != nil {
+			return fmt.Errorf("failed to decode the advisory of %s: %w", name, err)
+		}
+		vulns = append(vulns, &vuln)
+	}
+
+	for _, vuln := range vulns {
+		b.add(vuln)
+	}
+	return nil
+}
+
+func (b *Bundle) add(vuln *Vulnerability) {
+	if vuln.ID == "" || vuln.Withdrawn != nil {
+		return
+	}
+
+	b.Advisories++
+	if vuln.Modified.After(b.LastModified) {
+		b.LastModified = vuln.Modified
+	}
+
+	for _, affected := range vuln.Affected {
+		key := packageKey(affected.Package.Ecosystem, affected.Package.Name)
+		vulns := b.packages[key]
+		// an advisory can list several ranges of the same package
+		if len(vulns) > 0 && vulns[len(vulns)-1] == vuln {
+			continue
+		}
+		b.packages[key] = append(vulns, vuln)
+	}
+}
+
+// lookup returns the advisories of a package
+func (b *Bundle) lookup(ecosystem, name string) []*Vulnerability {
+	return b.packages[packageKey(ecosystem, name)]
+}
+
+// packageKey returns the index key of a package. The release of the
+// ecosystem, like the version of a distribution in "Debian:12", is not part of
+// the key.
+func packageKey(ecosystem, name string) string {
+	base := baseEcosystem(ecosystem)
+	return base + "/" + normalizeName(base, name)
+}
+
+// baseEcosystem returns the ecosystem without its release
+func baseEcosystem(ecosystem string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return base
+}
+
+// normalizeName normalizes the package names of the ecosystems where they are
+// case insensitive
+func normalizeName(ecosystem, name string) string {
+	switch ecosystem {
+	case "PyPI":
+		// reference: https://packaging.python.org/en/latest/specifications/name-normalization/
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case "NuGet", "Packagist":
+		return strings.ToLower(name)
+	}
+	return name
+}
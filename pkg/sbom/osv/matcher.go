@@ -0,0 +1,392 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var globalMatcher struct {
+	sync.Mutex
+	matcher *Matcher
+}
+
+// purlEcosystems maps the package URL types to the OSV ecosystems
+var purlEcosystems = map[string]string{
+	packageurl.TypeCargo:    "crates.io",
+	packageurl.TypeComposer: "Packagist",
+	packageurl.TypeGem:      "RubyGems",
+	packageurl.TypeGolang:   "Go",
+	packageurl.TypeHex:      "Hex",
+	packageurl.TypeMaven:    "Maven",
+	packageurl.TypeNPM:      "npm",
+	packageurl.TypeNuget:    "NuGet",
+	packageurl.TypePub:      "Pub",
+	packageurl.TypePyPi:     "PyPI",
+}
+
+// distroEcosystems maps the namespaces of the package URLs of the
+// distribution packages to the OSV ecosystems
+var distroEcosystems = map[string]string{
+	"alma":      "AlmaLinux",
+	"almalinux": "AlmaLinux",
+	"alpine":    "Alpine",
+	"debian":    "Debian",
+	"redhat":    "Red Hat",
+	"rocky":     "Rocky Linux",
+	"ubuntu":    "Ubuntu",
+}
+
+// Names of the properties of the components holding the source package of the
+// distribution packages, as set by trivy
+const (
+	propertySrcName    = "aquasecurity:trivy:SrcName"
+	propertySrcVersion = "aquasecurity:trivy:SrcVersion"
+	propertySrcRelease = "aquasecurity:trivy:SrcRelease"
+	propertySrcEpoch   = "aquasecurity:trivy:SrcEpoch"
+)
+
+// Finding defines a vulnerability affecting a component of a SBOM
+type Finding struct {
+	VulnerabilityID string   `json:"id"`
+	Aliases         []string `json:"aliases,omitempty"`
+	Summary         string   `json:"summary,omitempty"`
+	Severity        string   `json:"severity"`
+	Ecosystem       string   `json:"ecosystem"`
+	Package         string   `json:"package"`
+	Version         string   `json:"version"`
+	PURL            string   `json:"purl"`
+	FixedVersion    string   `json:"fixed_version,omitempty"`
+}
+
+// Matcher matches the components of SBOMs against the advisories of a bundle
+type Matcher struct {
+	path string
+
+	sync.RWMutex
+	bundle  *Bundle
+	modTime time.Time
+}
+
+// NewMatcher returns a matcher of the advisories of the bundle at the given
+// path. The matcher is returned even when the bundle can't be loaded, so that
+// it can be loaded later by Refresh.
+func NewMatcher(path string) (*Matcher, error) {
+	m := &Matcher{path: path}
+	return m, m.Refresh()
+}
+
+// GetGlobalMatcher returns the matcher of the configured advisories bundle,
+// creating it on the first call. It returns nil when the vulnerability
+// matching is disabled. Like NewMatcher, the matcher is returned along with the
+// error when the bundle can't be loaded.
+func GetGlobalMatcher(cfg config.Config) (*Matcher, error) {
+	if !cfg.GetBool("sbom.vulnerabilities.enabled") {
+		return nil, nil
+	}
+
+	globalMatcher.Lock()
+	defer globalMatcher.Unlock()
+	if globalMatcher.matcher != nil {
+		return globalMatcher.matcher, nil
+	}
+
+	matcher, err := NewMatcher(cfg.GetString("sbom.vulnerabilities.advisories_path"))
+	globalMatcher.matcher = matcher
+	return matcher, err
+}
+
+// Refresh reloads the bundle when its files were modified since it was loaded
+func (m *Matcher) Refresh() error {
+	modTime, err := lastModTime(m.path)
+	if err != nil {
+		setBundleError(m.path, err)
+		return fmt.Errorf("failed to read the OSV bundle: %w", err)
+	}
+
+	m.RLock()
+	upToDate := m.bundle != nil && !modTime.After(m.modTime)
+	m.RUnlock()
+	if upToDate {
+		return nil
+	}
+
+	bundle, err := LoadBundle(m.path)
+	if err != nil {
+		setBundleError(m.path, err)
+		return fmt.Errorf("failed to load the OSV bundle: %w", err)
+	}
+
+	m.Lock()
+	m.bundle = bundle
+	m.modTime = modTime
+	m.Unlock()
+
+	setBundleStatus(bundle)
+	return nil
+}
+
+// Bundle returns the bundle of advisories
+func (m *Matcher) Bundle() *Bundle {
+	m.RLock()
+	defer m.RUnlock()
+	return m.bundle
+}
+
+// Match returns the vulnerabilities affecting the components of the SBOM,
+// sorted by severity. The components are identified by their package URL.
+func (m *Matcher) Match(bom *cyclonedxgo.BOM) []Finding {
+	bundle := m.Bundle()
+	if bundle == nil || bom == nil {
+		return nil
+	}
+
+	var findings []Finding
+	seen := make(map[string]struct{})
+	walkComponents(bom.Components, func(c *cyclonedxgo.Component) {
+		for _, finding := range bundle.matchPURL(c.PackageURL, componentSource(c)) {
+			key := finding.VulnerabilityID + "/" + finding.PURL
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
+			findings = append(findings, finding)
+		}
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		ri, rj := severityRank(findings[i].Severity), severityRank(findings[j].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		if findings[i].VulnerabilityID != findings[j].VulnerabilityID {
+			return findings[i].VulnerabilityID < findings[j].VulnerabilityID
+		}
+		return findings[i].PURL < findings[j].PURL
+	})
+	return findings
+}
+
+func walkComponents(components *[]cyclonedxgo.Component, fn func(*cyclonedxgo.Component)) {
+	if components == nil {
+		return
+	}
+	for i := range *components {
+		c := &(*components)[i]
+		if c.PackageURL != "" {
+			fn(c)
+		}
+		walkComponents(c.Components, fn)
+	}
+}
+
+// sourcePackage defines the source package a distribution package was built from
+type sourcePackage struct {
+	name    string
+	version string
+}
+
+// componentSource returns the source package of a component from its
+// properties, or an empty source package when they are not set
+func componentSource(c *cyclonedxgo.Component) sourcePackage {
+	if c.Properties == nil {
+		return sourcePackage{}
+	}
+
+	properties := make(map[string]string)
+	for _, property := range *c.Properties {
+		properties[property.Name] = property.Value
+	}
+
+	src := sourcePackage{name: properties[propertySrcName]}
+	if version := properties[propertySrcVersion]; version != "" {
+		if release := properties[propertySrcRelease]; release != "" {
+			version += "-" + release
+		}
+		if epoch, err := strconv.Atoi(properties[propertySrcEpoch]); err == nil && epoch != 0 {
+			version = strconv.Itoa(epoch) + ":" + version
+		}
+		src.version = version
+	}
+	return src
+}
+
+// matchPURL returns the vulnerabilities affecting the package identified by
+// the given package URL. The Debian and Ubuntu advisories are keyed by source
+// package, so the source package is matched instead when it is known.
+func (b *Bundle) matchPURL(purl string, src sourcePackage) []Finding {
+	p, err := packageurl.FromString(purl)
+	if err != nil || p.Version == "" {
+		return nil
+	}
+
+	ecosystem, name, version := purlPackage(p)
+	if ecosystem == "" {
+		return nil
+	}
+
+	if p.Type == packageurl.TypeDebian && src.name != "" {
+		name = src.name
+		if src.version != "" {
+			version = src.version
+		}
+	}
+
+	var findings []Finding
+	for _, vuln := range b.lookup(ecosystem, name) {
+		for i := range vuln.Affected {
+			affected := &vuln.Affected[i]
+			if !sameEcosystem(affected.Package.Ecosystem, ecosystem) || normalizeName(baseEcosystem(ecosystem), affected.Package.Name) != normalizeName(baseEcosystem(ecosystem), name) {
+				continue
+			}
+
+			isAffected, fixed := affectedVersion(affected, ecosystem, version)
+			if !isAffected {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				VulnerabilityID: vuln.ID,
+				Aliases:         vuln.Aliases,
+				Summary:         vuln.Summary,
+				Severity:        severityOf(vuln, affected),
+				Ecosystem:       affected.Package.Ecosystem,
+				Package:         name,
+				Version:         version,
+				PURL:            purl,
+				FixedVersion:    fixed,
+			})
+			break
+		}
+	}
+	return findings
+}
+
+// affectedVersion tells whether the version of the package is affected and
+// returns the version fixing it when there is one.
+func affectedVersion(affected *Affected, ecosystem string, version string) (bool, string) {
+	for _, v := range affected.Versions {
+		if v == version {
+			fixed := ""
+			for _, r := range affected.Ranges {
+				if ok, f := affectedRange(comparerFor(ecosystem, r.Type), version, r); ok {
+					fixed = f
+					break
+				}
+			}
+			return true, fixed
+		}
+	}
+
+	for _, r := range affected.Ranges {
+		if ok, fixed := affectedRange(comparerFor(ecosystem, r.Type), version, r); ok {
+			return true, fixed
+		}
+	}
+	return false, ""
+}
+
+// sameEcosystem tells whether the ecosystem of an advisory applies to the
+// ecosystem of a package. An advisory of an ecosystem without release applies
+// to all its releases, and a package of an unknown release matches the
+// advisories of all the releases.
+func sameEcosystem(advisoryEcosystem, packageEcosystem string) bool {
+	if advisoryEcosystem == packageEcosystem {
+		return true
+	}
+	if baseEcosystem(advisoryEcosystem) != baseEcosystem(packageEcosystem) {
+		return false
+	}
+	advisoryRelease, packageRelease := ecosystemRelease(advisoryEcosystem), ecosystemRelease(packageEcosystem)
+	return advisoryRelease == "" || packageRelease == "" || advisoryRelease == packageRelease
+}
+
+// ecosystemRelease returns the release of an OSV ecosystem. The Ubuntu
+// advisories qualify their releases, like "Ubuntu:22.04:LTS" or
+// "Ubuntu:Pro:18.04:LTS", and only the version of the release is kept so that
+// they match the packages of "Ubuntu:22.04".
+func ecosystemRelease(ecosystem string) string {
+	parts := strings.Split(ecosystem, ":")[1:]
+	if baseEcosystem(ecosystem) != "Ubuntu" {
+		return strings.Join(parts, ":")
+	}
+	for _, part := range parts {
+		if part != "" && part[0] >= '0' && part[0] <= '9' {
+			return part
+		}
+	}
+	return ""
+}
+
+// purlPackage returns the OSV ecosystem, the name and the version of the
+// package identified by a package URL
+func purlPackage(p packageurl.PackageURL) (string, string, string) {
+	qualifiers := p.Qualifiers.Map()
+	name, version := p.Name, p.Version
+
+	switch p.Type {
+	case packageurl.TypeMaven:
+		if p.Namespace != "" {
+			name = p.Namespace + ":" + name
+		}
+	case packageurl.TypeNPM, packageurl.TypeGolang, packageurl.TypeComposer:
+		if p.Namespace != "" {
+			name = p.Namespace + "/" + name
+		}
+	case packageurl.TypeDebian, packageurl.TypeApk, packageurl.TypeRPM:
+		if epoch := qualifiers["epoch"]; epoch != "" && epoch != "0" {
+			version = epoch + ":" + version
+		}
+		ecosystem := distroEcosystems[strings.ToLower(p.Namespace)]
+		if ecosystem == "" {
+			return "", "", ""
+		}
+		if release := distroRelease(ecosystem, qualifiers["distro"]); release != "" {
+			ecosystem += ":" + release
+		}
+		return ecosystem, name, version
+	}
+
+	return purlEcosystems[p.Type], name, version
+}
+
+// distroRelease returns the release of a distribution in the format of its OSV
+// ecosystem from the distro qualifier of a package URL, like "debian-12.4".
+// The Ubuntu releases are returned without the qualifiers of the advisories,
+// like "22.04" for "Ubuntu:22.04:LTS", see ecosystemRelease.
+func distroRelease(ecosystem, distro string) string {
+	if i := strings.LastIndex(distro, "-"); i != -1 {
+		distro = distro[i+1:]
+	}
+	if distro == "" {
+		return ""
+	}
+
+	parts := strings.Split(distro, ".")
+	switch ecosystem {
+	case "Debian":
+		return parts[0]
+	case "Alpine":
+		if len(parts) < 2 {
+			return ""
+		}
+		return "v" + parts[0] + "." + parts[1]
+	case "Ubuntu":
+		return distro
+	}
+	// the other distributions have releases that can't be deduced
+	return ""
+}
@@ -0,0 +1,297 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cyclonedxgo "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBundle(t *testing.T) {
+	b, err := LoadBundle("testdata/bundle")
+	require.NoError(t, err)
+
+	// the withdrawn advisory is ignored
+	assert.Equal(t, 5, b.Advisories)
+	assert.Equal(t, time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), b.LastModified)
+	assert.Len(t, b.lookup("PyPI", "Requests"), 1)
+	assert.Len(t, b.lookup("Debian:12", "curl"), 1)
+
+	_, err = LoadBundle(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestLoadBundleZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"PyPI/GHSA-j8r2-6x86-q33q.json", "Debian/DSA-5587-1.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata/bundle", name))
+		require.NoError(t, err)
+		f, err := w.Create(filepath.Base(name))
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "all.zip")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	b, err := LoadBundle(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, b.Advisories)
+}
+
+func TestMatch(t *testing.T) {
+	m, err := NewMatcher("testdata/bundle")
+	require.NoError(t, err)
+
+	bom := &cyclonedxgo.BOM{
+		Components: &[]cyclonedxgo.Component{
+			{Name: "curl", PackageURL: "pkg:deb/debian/curl@7.88.1-10+deb12u4?arch=amd64&distro=debian-12.4"},
+			{Name: "libcurl4", PackageURL: "pkg:deb/debian/libcurl4@7.88.1-10+deb12u5?arch=amd64&distro=debian-12.4"},
+			{
+				Name: "Python",
+				Type: cyclonedxgo.ComponentTypeApplication,
+				Components: &[]cyclonedxgo.Component{
+					{Name: "requests", PackageURL: "pkg:pypi/requests@2.28.1"},
+					{Name: "urllib3", PackageURL: "pkg:pypi/urllib3@2.0.7"},
+				},
+			},
+			{Name: "express", PackageURL: "pkg:npm/express@4.18.2"},
+			{Name: "express", PackageURL: "pkg:npm/express@4.19.2"},
+			{Name: "path-to-regexp", PackageURL: "pkg:npm/path-to-regexp@0.1.7"},
+		},
+	}
+
+	findings := m.Match(bom)
+	assert.Equal(t, []Finding{
+		{
+			VulnerabilityID: "DSA-5587-1",
+			Summary:         "curl - security update",
+			Severity:        SeverityHigh,
+			Ecosystem:       "Debian:12",
+			Package:         "curl",
+			Version:         "7.88.1-10+deb12u4",
+			PURL:            "pkg:deb/debian/curl@7.88.1-10+deb12u4?arch=amd64&distro=debian-12.4",
+			FixedVersion:    "7.88.1-10+deb12u5",
+		},
+		{
+			VulnerabilityID: "GHSA-9wv6-86v2-598j",
+			Summary:         "path-to-regexp outputs backtracking regular expressions",
+			Severity:        SeverityHigh,
+			Ecosystem:       "npm",
+			Package:         "path-to-regexp",
+			Version:         "0.1.7",
+			PURL:            "pkg:npm/path-to-regexp@0.1.7",
+		},
+		{
+			VulnerabilityID: "GHSA-j8r2-6x86-q33q",
+			Aliases:         []string{"CVE-2023-32681"},
+			Summary:         "Unintended leak of Proxy-Authorization header in requests",
+			Severity:        SeverityMedium,
+			Ecosystem:       "PyPI",
+			Package:         "requests",
+			Version:         "2.28.1",
+			PURL:            "pkg:pypi/requests@2.28.1",
+			FixedVersion:    "2.31.0",
+		},
+		{
+			VulnerabilityID: "GHSA-rv95-896h-c2vc",
+			Aliases:         []string{"CVE-2024-29041"},
+			Summary:         "Express.js Open Redirect in malformed URLs",
+			Severity:        SeverityMedium,
+			Ecosystem:       "npm",
+			Package:         "express",
+			Version:         "4.18.2",
+			PURL:            "pkg:npm/express@4.18.2",
+			FixedVersion:    "4.19.2",
+		},
+	}, findings)
+
+	assert.Empty(t, m.Match(&cyclonedxgo.BOM{}))
+}
+
+func TestAffectedRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		ecosystem string
+		version   string
+		r         Range
+		affected  bool
+		fixed     string
+	}{
+		{
+			name:      "introduced and fixed",
+			ecosystem: "PyPI",
+			version:   "2.3.0",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "2.3.0"}, {Fixed: "2.31.0"}}},
+			affected:  true,
+			fixed:     "2.31.0",
+		},
+		{
+			name:      "before introduced",
+			ecosystem: "PyPI",
+			version:   "2.2.9",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "2.3.0"}, {Fixed: "2.31.0"}}},
+		},
+		{
+			name:      "fixed",
+			ecosystem: "PyPI",
+			version:   "2.31.0",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "2.3.0"}, {Fixed: "2.31.0"}}},
+		},
+		{
+			name:      "several unsorted intervals",
+			ecosystem: "Maven",
+			version:   "2.15.0",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "2.13.0"}, {Fixed: "2.12.2"}, {Introduced: "0"}, {Fixed: "2.16.0"}}},
+			affected:  true,
+			fixed:     "2.16.0",
+		},
+		{
+			name:      "last affected",
+			ecosystem: "npm",
+			version:   "0.1.9",
+			r:         Range{Type: RangeTypeSemver, Events: []Event{{Introduced: "0"}, {LastAffected: "0.1.9"}}},
+			affected:  true,
+		},
+		{
+			name:      "after last affected",
+			ecosystem: "npm",
+			version:   "0.1.10",
+			r:         Range{Type: RangeTypeSemver, Events: []Event{{Introduced: "0"}, {LastAffected: "0.1.9"}}},
+		},
+		{
+			name:      "debian epoch",
+			ecosystem: "Debian:12",
+			version:   "1:2.38.1-5",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "1:2.38.1-5+deb12u1"}}},
+			affected:  true,
+			fixed:     "1:2.38.1-5+deb12u1",
+		},
+		{
+			name:      "alpine",
+			ecosystem: "Alpine:v3.18",
+			version:   "3.1.4-r1",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}, {Fixed: "3.1.4-r5"}}},
+			affected:  true,
+			fixed:     "3.1.4-r5",
+		},
+		{
+			name:      "go version prefix",
+			ecosystem: "Go",
+			version:   "v0.17.0",
+			r:         Range{Type: RangeTypeSemver, Events: []Event{{Introduced: "0"}, {Fixed: "0.17.0"}}},
+		},
+		{
+			name:      "git ranges are ignored",
+			ecosystem: "PyPI",
+			version:   "1.0.0",
+			r:         Range{Type: RangeTypeGit, Events: []Event{{Introduced: "0"}}},
+		},
+		{
+			name:      "unparsable version",
+			ecosystem: "PyPI",
+			version:   "not a version",
+			r:         Range{Type: RangeTypeEcosystem, Events: []Event{{Introduced: "0"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			affected, fixed := affectedRange(comparerFor(test.ecosystem, test.r.Type), test.version, test.r)
+			assert.Equal(t, test.affected, affected)
+			assert.Equal(t, test.fixed, fixed)
+		})
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		score  float64
+		ok     bool
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:L/I:L/A:N", 5.4, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, true},
+		{"CVSS:3.0/AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H", 9.9, true},
+		{"CVSS:3.1/AV:L/AC:H/PR:H/UI:R/S:U/C:N/I:N/A:N", 0, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", 0, false},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.vector, func(t *testing.T) {
+			score, ok := cvss3BaseScore(test.vector)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.score, score)
+		})
+	}
+}
+
+func TestPURLPackage(t *testing.T) {
+	m, err := NewMatcher("testdata/bundle")
+	require.NoError(t, err)
+	b := m.Bundle()
+
+	// Debian 11 packages are checked against the Debian 11 advisories
+	findings := b.matchPURL("pkg:deb/debian/curl@7.74.0-1.3+deb11u10?distro=debian-11.8", sourcePackage{})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Debian:11", findings[0].Ecosystem)
+	assert.Equal(t, "7.74.0-1.3+deb11u11", findings[0].FixedVersion)
+
+	// without the release of the distribution, all the releases are matched
+	assert.Len(t, b.matchPURL("pkg:deb/debian/curl@7.74.0-1.3+deb11u10", sourcePackage{}), 1)
+
+	// the Ubuntu advisories qualify their releases
+	findings = b.matchPURL("pkg:deb/ubuntu/openssl@3.0.2-0ubuntu1.12?distro=ubuntu-22.04", sourcePackage{})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Ubuntu:22.04:LTS", findings[0].Ecosystem)
+	assert.Equal(t, "3.0.2-0ubuntu1.14", findings[0].FixedVersion)
+	findings = b.matchPURL("pkg:deb/ubuntu/openssl@1.1.1-1ubuntu2.1~18.04.23?distro=ubuntu-18.04", sourcePackage{})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Ubuntu:Pro:18.04:LTS", findings[0].Ecosystem)
+	assert.Empty(t, b.matchPURL("pkg:deb/ubuntu/openssl@3.0.2-0ubuntu1.12?distro=ubuntu-20.04", sourcePackage{}))
+
+	// the normalized names of the Python packages are matched
+	assert.Len(t, b.matchPURL("pkg:pypi/Requests@2.28.1", sourcePackage{}), 1)
+
+	assert.Empty(t, b.matchPURL("pkg:generic/requests@2.28.1", sourcePackage{}))
+	assert.Empty(t, b.matchPURL("not a purl", sourcePackage{}))
+
+	// binary packages are matched against the advisories of their source package
+	findings = b.matchPURL("pkg:deb/debian/libcurl4@7.88.1-10+deb12u4?distro=debian-12.4", sourcePackage{name: "curl", version: "7.88.1-10+deb12u4"})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "DSA-5587-1", findings[0].VulnerabilityID)
+	assert.Equal(t, "curl", findings[0].Package)
+	assert.Empty(t, b.matchPURL("pkg:deb/debian/libcurl4@7.88.1-10+deb12u4?distro=debian-12.4", sourcePackage{}))
+}
+
+func TestComponentSource(t *testing.T) {
+	c := &cyclonedxgo.Component{
+		Name:       "libcurl4",
+		PackageURL: "pkg:deb/debian/libcurl4@7.88.1-10+deb12u4?distro=debian-12.4",
+		Properties: &[]cyclonedxgo.Property{
+			{Name: propertySrcName, Value: "curl"},
+			{Name: propertySrcVersion, Value: "7.88.1"},
+			{Name: propertySrcRelease, Value: "10+deb12u4"},
+			{Name: propertySrcEpoch, Value: "1"},
+		},
+	}
+	assert.Equal(t, sourcePackage{name: "curl", version: "1:7.88.1-10+deb12u4"}, componentSource(c))
+
+	assert.Equal(t, sourcePackage{}, componentSource(&cyclonedxgo.Component{Name: "curl"}))
+}
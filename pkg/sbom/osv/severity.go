@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"math"
+	"strings"
+)
+
+// Severities of the findings
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityUnknown  = "unknown"
+)
+
+// Severities lists the severities, from the most to the least severe
+var Severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown}
+
+// severityRank returns the rank of a severity in Severities
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// severityOf returns the severity of an advisory for an affected package. The
+// severities given by the databases are preferred over the CVSS scores.
+func severityOf(vuln *Vulnerability, affected *Affected) string {
+	for _, specific := range []map[string]interface{}{affected.EcosystemSpecific, affected.DatabaseSpecific, vuln.DatabaseSpecific} {
+		if s, ok := specific["severity"].(string); ok {
+			if severity := normalizeSeverity(s); severity != SeverityUnknown {
+				return severity
+			}
+		}
+	}
+
+	for _, s := range vuln.Severity {
+		if s.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := cvss3BaseScore(s.Score); ok {
+			return scoreSeverity(score)
+		}
+	}
+
+	return SeverityUnknown
+}
+
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(s) {
+	case "critical":
+		return SeverityCritical
+	case "high", "important":
+		return SeverityHigh
+	case "medium", "moderate":
+		return SeverityMedium
+	case "low", "negligible", "unimportant":
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+func scoreSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3 vector like
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+//
+// reference: https://www.first.org/cvss/v3.1/specification-document#7-1-Base-Metrics-Equations
+func cvss3BaseScore(vector string) (float64, bool) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3") {
+		return 0, false
+	}
+	metrics := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if k, v, found := strings.Cut(part, ":"); found {
+			metrics[k] = v
+		}
+	}
+
+	weight := func(metric string, weights map[string]float64) (float64, bool) {
+		w, ok := weights[metrics[metric]]
+		return w, ok
+	}
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	scopeChanged := metrics["S"] == "C"
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if scopeChanged {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+
+	av, ok1 := weight("AV", map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2})
+	ac, ok2 := weight("AC", map[string]float64{"L": 0.77, "H": 0.44})
+	pr, ok3 := weight("PR", privileges)
+	ui, ok4 := weight("UI", map[string]float64{"N": 0.85, "R": 0.62})
+	c, ok5 := weight("C", cia)
+	i, ok6 := weight("I", cia)
+	a, ok7 := weight("A", cia)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6 && ok7) || (metrics["S"] != "U" && !scopeChanged) {
+		return 0, false
+	}
+
+	iss := 1 - (1-c)*(1-i)*(1-a)
+	var impact float64
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * av * ac * pr * ui
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp returns the smallest number, specified to one decimal place, that
+// is equal to or higher than its input
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"embed"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/status"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+//go:embed status_templates
+var templatesFS embed.FS
+
+var bundleStatus struct {
+	sync.Mutex
+	path         string
+	advisories   int
+	lastModified time.Time
+	loadedAt     time.Time
+	err          error
+}
+
+func setBundleStatus(b *Bundle) {
+	bundleStatus.Lock()
+	defer bundleStatus.Unlock()
+	bundleStatus.path = b.Path
+	bundleStatus.advisories = b.Advisories
+	bundleStatus.lastModified = b.LastModified
+	bundleStatus.loadedAt = b.LoadedAt
+	bundleStatus.err = nil
+}
+
+func setBundleError(path string, err error) {
+	bundleStatus.Lock()
+	defer bundleStatus.Unlock()
+	bundleStatus.path = path
+	bundleStatus.err = err
+}
+
+// Provider provides the functionality to populate the status output
+type Provider struct{}
+
+// GetProvider returns the status provider of the advisories bundle if the
+// vulnerability matching is enabled
+func GetProvider(conf config.Component) status.Provider {
+	if conf.GetBool("sbom.vulnerabilities.enabled") {
+		return Provider{}
+	}
+	return nil
+}
+
+// Name returns the name
+func (Provider) Name() string {
+	return "SBOM Vulnerabilities"
+}
+
+// Section return the section
+func (Provider) Section() string {
+	return "SBOM Vulnerabilities"
+}
+
+// JSON populates the status map
+func (Provider) JSON(_ bool, stats map[string]interface{}) error {
+	populateStatus(stats)
+
+	return nil
+}
+
+// Text renders the text output
+func (Provider) Text(_ bool, buffer io.Writer) error {
+	return status.RenderText(templatesFS, "osv.tmpl", buffer, getStatusInfo())
+}
+
+// HTML renders the html output
+func (Provider) HTML(_ bool, buffer io.Writer) error {
+	return status.RenderHTML(templatesFS, "osvHTML.tmpl", buffer, getStatusInfo())
+}
+
+func populateStatus(stats map[string]interface{}) {
+	bundleStatus.Lock()
+	defer bundleStatus.Unlock()
+
+	osvStats := make(map[string]interface{})
+	stats["osvBundle"] = osvStats
+
+	osvStats["path"] = bundleStatus.path
+	if bundleStatus.err != nil {
+		osvStats["error"] = bundleStatus.err.Error()
+	}
+	if bundleStatus.loadedAt.IsZero() {
+		osvStats["status"] = "Not loaded"
+		return
+	}
+
+	age := time.Since(bundleStatus.lastModified)
+	osvStats["status"] = "Loaded"
+	osvStats["advisories"] = bundleStatus.advisories
+	osvStats["lastModified"] = bundleStatus.lastModified.Format(time.RFC1123)
+	osvStats["loadedAt"] = bundleStatus.loadedAt.Format(time.RFC1123)
+	osvStats["age"] = age.Truncate(time.Second).String()
+	if maxAge := pkgconfig.Datadog.GetDuration("sbom.vulnerabilities.max_bundle_age"); maxAge > 0 && age > maxAge {
+		osvStats["outdated"] = true
+	}
+}
+
+func getStatusInfo() map[string]interface{} {
+	stats := make(map[string]interface{})
+
+	populateStatus(stats)
+
+	return stats
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	_, err := NewMatcher("testdata/bundle")
+	require.NoError(t, err)
+
+	provider := Provider{}
+
+	tests := []struct {
+		name       string
+		assertFunc func(t *testing.T)
+	}{
+		{"JSON", func(t *testing.T) {
+			stats := make(map[string]interface{})
+			provider.JSON(false, stats)
+
+			assert.Equal(t, "Loaded", stats["osvBundle"].(map[string]interface{})["status"])
+			assert.Equal(t, 5, stats["osvBundle"].(map[string]interface{})["advisories"])
+		}},
+		{"Text", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := provider.Text(false, b)
+
+			assert.NoError(t, err)
+
+			assert.Contains(t, b.String(), "Advisories: 5")
+			assert.Contains(t, b.String(), "outdated")
+		}},
+		{"HTML", func(t *testing.T) {
+			b := new(bytes.Buffer)
+			err := provider.HTML(false, b)
+
+			assert.NoError(t, err)
+
+			assert.Contains(t, b.String(), "Advisories: 5")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.assertFunc(t)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package osv
+
+import (
+	"sort"
+	"strings"
+
+	gem "github.com/aquasecurity/go-gem-version"
+	pep440 "github.com/aquasecurity/go-pep440-version"
+	"github.com/aquasecurity/go-version/pkg/semver"
+	apk "github.com/knqyf263/go-apk-version"
+	deb "github.com/knqyf263/go-deb-version"
+	rpm "github.com/knqyf263/go-rpm-version"
+	mvn "github.com/masahiro331/go-mvn-version"
+)
+
+// compareFunc compares two versions, it returns a negative number when a < b,
+// zero when a == b and a positive number when a > b
+type compareFunc func(a, b string) (int, error)
+
+// comparerFor returns the version comparison of the given ecosystem and range
+// type
+func comparerFor(ecosystem, rangeType string) compareFunc {
+	if rangeType == RangeTypeSemver {
+		return compareSemver
+	}
+
+	switch baseEcosystem(ecosystem) {
+	case "PyPI":
+		return comparePEP440
+	case "RubyGems":
+		return compareGem
+	case "Maven":
+		return compareMaven
+	case "Debian", "Ubuntu":
+		return compareDeb
+	case "Alpine":
+		return compareApk
+	case "Red Hat", "Rocky Linux", "AlmaLinux", "openSUSE", "SUSE":
+		return compareRPM
+	default:
+		// npm, Go, crates.io, NuGet, Packagist, Hex and Pub versions are
+		// close enough to semver
+		return compareSemver
+	}
+}
+
+func compareSemver(a, b string) (int, error) {
+	va, err := semver.Parse(strings.TrimPrefix(a, "v"))
+	if err != nil {
+		return 0, err
+	}
+	vb, err := semver.Parse(strings.TrimPrefix(b, "v"))
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func comparePEP440(a, b string) (int, error) {
+	va, err := pep440.Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := pep440.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareGem(a, b string) (int, error) {
+	va, err := gem.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := gem.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareMaven(a, b string) (int, error) {
+	va, err := mvn.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := mvn.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareDeb(a, b string) (int, error) {
+	va, err := deb.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := deb.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareApk(a, b string) (int, error) {
+	va, err := apk.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := apk.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+func compareRPM(a, b string) (int, error) {
+	return rpm.NewVersion(a).Compare(rpm.NewVersion(b)), nil
+}
+
+// affectedRange tells whether the version is affected by the range of
+// versions and returns the version fixing it when there is one.
+//
+// reference: https://ossf.github.io/osv-schema/#evaluation
+func affectedRange(cmp compareFunc, version string, r Range) (bool, string) {
+	if r.Type == RangeTypeGit {
+		return false, ""
+	}
+
+	type event struct {
+		Event
+		version string
+	}
+	events := make([]event, 0, len(r.Events))
+	for _, e := range r.Events {
+		ev := event{Event: e}
+		switch {
+		case e.Introduced != "":
+			ev.version = e.Introduced
+		case e.Fixed != "":
+			ev.version = e.Fixed
+		case e.LastAffected != "":
+			ev.version = e.LastAffected
+		case e.Limit != "":
+			ev.version = e.Limit
+		default:
+			continue
+		}
+		if ev.version != "0" {
+			if _, err := cmp(ev.version, ev.version); err != nil {
+				// the range can't be evaluated
+				return false, ""
+			}
+		}
+		events = append(events, ev)
+	}
+
+	// "0" is a special version preceding all the others
+	compare := func(a, b string) int {
+		switch {
+		case a == b:
+			return 0
+		case a == "0":
+			return -1
+		case b == "0":
+			return 1
+		}
+		c, _ := cmp(a, b)
+		return c
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return compare(events[i].version, events[j].version) < 0
+	})
+
+	if _, err := cmp(version, version); err != nil {
+		return false, ""
+	}
+
+	affected := false
+	for _, e := range events {
+		c := compare(version, e.version)
+		switch {
+		case e.Introduced != "" && c >= 0:
+			affected = true
+		case (e.Fixed != "" || e.Limit != "") && c >= 0:
+			affected = false
+		case e.LastAffected != "" && c > 0:
+			affected = false
+		}
+	}
+	if !affected {
+		return false, ""
+	}
+
+	for _, e := range events {
+		if e.Fixed != "" && compare(version, e.version) < 0 {
+			return true, e.Fixed
+		}
+	}
+	return true, ""
+}
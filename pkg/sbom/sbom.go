@@ -35,6 +35,7 @@ type ScanOptions struct {
 	Fast             bool
 	NoCache          bool // Caching doesn't really provide any value when scanning filesystem as the filesystem has to be walked to compute the keys
 	CollectFiles     bool
+	SkipDirs         []string // Directories, relative to the scanned path, that are not walked by filesystem scans
 }
 
 // ScanOptionsFromConfig loads the scanning options from the configuration
@@ -70,4 +71,5 @@ type ScanResult struct {
 	CreatedAt time.Time
 	Duration  time.Duration
 	ImgMeta   *workloadmeta.ContainerImageMetadata
+	Request   ScanRequest
 }
@@ -71,7 +71,7 @@ func NewScanner(cfg config.Config, wmeta optional.Option[workloadmeta.Component]
 // global one, and returns it. Start() needs to be called before any data
 // collection happens.
 func CreateGlobalScanner(cfg config.Config, wmeta optional.Option[workloadmeta.Component]) (*Scanner, error) {
-	if !cfg.GetBool("sbom.host.enabled") && !cfg.GetBool("sbom.container_image.enabled") && !cfg.GetBool("runtime_security_config.sbom.enabled") {
+	if !cfg.GetBool("sbom.host.enabled") && !cfg.GetBool("sbom.container_image.enabled") && !cfg.GetBool("sbom.process.enabled") && !cfg.GetBool("runtime_security_config.sbom.enabled") {
 		return nil, nil
 	}
 
@@ -252,6 +252,7 @@ func (s *Scanner) processScan(ctx context.Context, request sbom.ScanRequest, img
 		result = s.performScan(scanContext, request, collector)
 		errorType = "scan"
 	}
+	result.Request = request
 	sendResult(request.ID(), result, collector)
 	s.handleScanResult(result, request, collector, errorType)
 	waitAfterScanIfNecessary(ctx, collector)
@@ -222,6 +222,14 @@ type RuntimeSecurityConfig struct {
 
 	// Enforcement capabilities
 	EnforcementEnabled bool
+	// EnforcementRemediationMaxRate defines the maximum number of remediation actions per second, per action type
+	EnforcementRemediationMaxRate int
+	// EnforcementRemediationMaxBurst defines the maximum burst of remediation actions, per action type
+	EnforcementRemediationMaxBurst int
+	// EnforcementQuarantineDirectory defines the directory where the quarantined files are moved
+	EnforcementQuarantineDirectory string
+	// EnforcementCoreDumpMaxSize defines the default maximum size of the memory captured by the 'coredump' action
+	EnforcementCoreDumpMaxSize int
 }
 
 // Config defines a security config
@@ -373,7 +381,11 @@ func NewRuntimeSecurityConfig() (*RuntimeSecurityConfig, error) {
 		AnomalyDetectionEnabled:                      coreconfig.SystemProbe.GetBool("runtime_security_config.security_profile.anomaly_detection.enabled"),
 
 		// enforcement
-		EnforcementEnabled: coreconfig.SystemProbe.GetBool("runtime_security_config.enforcement.enabled"),
+		EnforcementEnabled:             coreconfig.SystemProbe.GetBool("runtime_security_config.enforcement.enabled"),
+		EnforcementRemediationMaxRate:  coreconfig.SystemProbe.GetInt("runtime_security_config.enforcement.remediation.max_rate"),
+		EnforcementRemediationMaxBurst: coreconfig.SystemProbe.GetInt("runtime_security_config.enforcement.remediation.max_burst"),
+		EnforcementQuarantineDirectory: coreconfig.SystemProbe.GetString("runtime_security_config.enforcement.quarantine.directory"),
+		EnforcementCoreDumpMaxSize:     coreconfig.SystemProbe.GetInt("runtime_security_config.enforcement.coredump.max_size"),
 
 		// User Sessions
 		UserSessionsCacheSize: coreconfig.SystemProbe.GetInt("runtime_security_config.user_sessions.cache_size"),
@@ -76,3 +76,183 @@ func (k *KillActionReport) ToJSON() ([]byte, bool, error) {
 
 	return data, resolved, nil
 }
+
+const (
+	// RemediationStatusPerformed the remediation action was performed
+	RemediationStatusPerformed = "performed"
+	// RemediationStatusPending the remediation action is in progress
+	RemediationStatusPending = "pending"
+	// RemediationStatusDryRun the remediation action was only reported
+	RemediationStatusDryRun = "dry_run"
+	// RemediationStatusRateLimited the remediation action was dropped by the rate limiter
+	RemediationStatusRateLimited = "rate_limited"
+	// RemediationStatusFailed the remediation action failed
+	RemediationStatusFailed = "failed"
+)
+
+// RemediationActionReport defines the common fields of the remediation action reports
+type RemediationActionReport struct {
+	sync.RWMutex
+
+	Status    string
+	Error     string
+	CreatedAt time.Time
+	DoneAt    time.Time
+}
+
+// resolved returns whether the action completed, the report being sent once resolved
+func (r *RemediationActionReport) resolved() bool {
+	return r.Status != RemediationStatusPending
+}
+
+func (r *RemediationActionReport) fail(err error) {
+	r.Status = RemediationStatusFailed
+	r.Error = err.Error()
+}
+
+// JRemediationActionReport used to serialize the common fields of the remediation action reports
+// easyjson:json
+type JRemediationActionReport struct {
+	Type      string              `json:"type"`
+	Status    string              `json:"status"`
+	Error     string              `json:"error,omitempty"`
+	CreatedAt utils.EasyjsonTime  `json:"created_at"`
+	DoneAt    *utils.EasyjsonTime `json:"done_at,omitempty"`
+}
+
+func (r *RemediationActionReport) toJSON(actionType string) JRemediationActionReport {
+	return JRemediationActionReport{
+		Type:      actionType,
+		Status:    r.Status,
+		Error:     r.Error,
+		CreatedAt: utils.NewEasyjsonTime(r.CreatedAt),
+		DoneAt:    utils.NewEasyjsonTimeIfNotZero(r.DoneAt),
+	}
+}
+
+// QuarantineFileActionReport defines a quarantine file action report
+type QuarantineFileActionReport struct {
+	RemediationActionReport
+
+	Path           string
+	QuarantinePath string
+	SHA256         string
+	Mode           uint32
+	UID            uint32
+	GID            uint32
+	Size           int64
+	ModifiedAt     time.Time
+}
+
+// JQuarantineFileActionReport used to serialize the quarantine file action report
+// easyjson:json
+type JQuarantineFileActionReport struct {
+	JRemediationActionReport
+	Path           string              `json:"path"`
+	QuarantinePath string              `json:"quarantine_path,omitempty"`
+	SHA256         string              `json:"sha256,omitempty"`
+	Mode           uint32              `json:"mode,omitempty"`
+	UID            uint32              `json:"uid"`
+	GID            uint32              `json:"gid"`
+	Size           int64               `json:"size,omitempty"`
+	ModifiedAt     *utils.EasyjsonTime `json:"modified_at,omitempty"`
+}
+
+// ToJSON marshal the action
+func (q *QuarantineFileActionReport) ToJSON() ([]byte, bool, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	data, err := utils.MarshalEasyJSON(JQuarantineFileActionReport{
+		JRemediationActionReport: q.toJSON(rules.QuarantineFileAction),
+		Path:                     q.Path,
+		QuarantinePath:           q.QuarantinePath,
+		SHA256:                   q.SHA256,
+		Mode:                     q.Mode,
+		UID:                      q.UID,
+		GID:                      q.GID,
+		Size:                     q.Size,
+		ModifiedAt:               utils.NewEasyjsonTimeIfNotZero(q.ModifiedAt),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, q.resolved(), nil
+}
+
+// IsolateActionReport defines a network isolation action report
+type IsolateActionReport struct {
+	RemediationActionReport
+
+	Scope       string
+	ContainerID string
+	CGroup      string
+}
+
+// JIsolateActionReport used to serialize the network isolation action report
+// easyjson:json
+type JIsolateActionReport struct {
+	JRemediationActionReport
+	Scope       string `json:"scope"`
+	ContainerID string `json:"container_id,omitempty"`
+	CGroup      string `json:"cgroup,omitempty"`
+}
+
+// ToJSON marshal the action
+func (i *IsolateActionReport) ToJSON() ([]byte, bool, error) {
+	i.RLock()
+	defer i.RUnlock()
+
+	data, err := utils.MarshalEasyJSON(JIsolateActionReport{
+		JRemediationActionReport: i.toJSON(rules.IsolateAction),
+		Scope:                    i.Scope,
+		ContainerID:              i.ContainerID,
+		CGroup:                   i.CGroup,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, i.resolved(), nil
+}
+
+// CoreDumpActionReport defines a core dump action report
+type CoreDumpActionReport struct {
+	RemediationActionReport
+
+	Pid       uint32
+	Path      string
+	MaxSize   int
+	Size      int64
+	Truncated bool
+}
+
+// JCoreDumpActionReport used to serialize the core dump action report
+// easyjson:json
+type JCoreDumpActionReport struct {
+	JRemediationActionReport
+	Path      string `json:"path,omitempty"`
+	MaxSize   int    `json:"max_size"`
+	Size      int64  `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// ToJSON marshal the action
+func (c *CoreDumpActionReport) ToJSON() ([]byte, bool, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	data, err := utils.MarshalEasyJSON(JCoreDumpActionReport{
+		JRemediationActionReport: c.toJSON(rules.CoreDumpAction),
+		Path:                     c.Path,
+		MaxSize:                  c.MaxSize,
+		Size:                     c.Size,
+		Truncated:                c.Truncated,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, c.resolved(), nil
+}
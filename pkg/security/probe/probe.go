@@ -272,6 +272,11 @@ func (p *Probe) NewEvaluationSet(eventTypeEnabled map[eval.EventType]bool, ruleS
 
 		ruleOpts.WithLogger(seclog.DefaultLogger)
 		ruleOpts.WithReservedRuleIDs(events.AllCustomRuleIDs())
+		ruleOpts.WithEventSnapshotter(func(event eval.Event) interface{} {
+			// only the fields reported for the sequence steps are resolved while the event is still valid,
+			// their serialization is deferred to the completion of the sequence
+			return serializers.NewSequenceStepSerializer(event.(*model.Event))
+		})
 		if ruleSetTagValue == rules.DefaultRuleSetTagValue {
 			ruleOpts.WithSupportedDiscarders(SupportedDiscarders)
 		}
@@ -129,6 +129,7 @@ type EBPFProbe struct {
 	killListMap           *lib.Map
 	supportsBPFSendSignal bool
 	processKiller         *ProcessKiller
+	remediator            *Remediator
 
 	isRuntimeDiscarded bool
 	constantOffsets    map[string]uint64
@@ -1485,6 +1486,7 @@ func NewEBPFProbe(probe *Probe, config *config.Config, opts Opts, wmeta optional
 		ctx:                  ctx,
 		cancelFnc:            cancelFnc,
 		processKiller:        NewProcessKiller(),
+		remediator:           NewRemediator(config.RuntimeSecurity),
 	}
 
 	if err := p.detectKernelVersion(); err != nil {
@@ -1951,6 +1953,15 @@ func (p *EBPFProbe) HandleActions(ctx *eval.Context, rule *rules.Rule) {
 				}
 				return p.processKiller.KillFromUserspace(pid, sig, ev)
 			})
+
+		case action.QuarantineFile != nil:
+			p.remediator.QuarantineFileAndReport(action.QuarantineFile, ev)
+
+		case action.Isolate != nil:
+			p.remediator.IsolateAndReport(action.Isolate, ev)
+
+		case action.CoreDump != nil:
+			p.remediator.CoreDumpAndReport(action.CoreDump, ev)
 		}
 	}
 }
@@ -0,0 +1,516 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package probe holds probe related files
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netns"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+const (
+	isolationInputChain  = "DD_CWS_ISOLATION_IN"
+	isolationOutputChain = "DD_CWS_ISOLATION_OUT"
+
+	quarantineMetadataSuffix = ".json"
+	coreDumpsDirectory       = "coredumps"
+)
+
+// Remediator applies the remediation actions of the rules: file quarantine,
+// network isolation and core dump capture
+type Remediator struct {
+	config   *config.RuntimeSecurityConfig
+	limiters map[string]*rate.Limiter
+
+	// iptables runs an iptables command in the current network namespace
+	iptables func(binary string, args ...string) error
+}
+
+// NewRemediator returns a new Remediator
+func NewRemediator(cfg *config.RuntimeSecurityConfig) *Remediator {
+	limiters := make(map[string]*rate.Limiter)
+	for _, action := range []string{rules.QuarantineFileAction, rules.IsolateAction, rules.CoreDumpAction} {
+		limiters[action] = rate.NewLimiter(rate.Limit(cfg.EnforcementRemediationMaxRate), cfg.EnforcementRemediationMaxBurst)
+	}
+
+	return &Remediator{
+		config:   cfg,
+		limiters: limiters,
+		iptables: runIPTables,
+	}
+}
+
+func runIPTables(binary string, args ...string) error {
+	output, err := exec.Command(binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", binary, strings.Join(args, " "), err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// skip returns whether the action has only to be reported, either because of
+// the dry-run mode or of the rate limiter
+func (r *Remediator) skip(action string, dryRun bool, report *RemediationActionReport) bool {
+	switch {
+	case dryRun:
+		report.Status = RemediationStatusDryRun
+	case !r.limiters[action].Allow():
+		report.Status = RemediationStatusRateLimited
+	default:
+		return false
+	}
+	return true
+}
+
+// QuarantineFileAndReport moves the file of the event to the quarantine directory and reports it
+func (r *Remediator) QuarantineFileAndReport(def *rules.QuarantineFileDefinition, ev *model.Event) {
+	report := &QuarantineFileActionReport{
+		RemediationActionReport: RemediationActionReport{CreatedAt: time.Now()},
+	}
+	ev.ActionReports = append(ev.ActionReports, report)
+
+	field := def.Field
+	if field == "" {
+		field = ev.GetType() + ".file.path"
+	}
+
+	value, err := ev.GetFieldValue(field)
+	if path, ok := value.(string); err == nil && ok && path != "" {
+		report.Path = path
+	} else {
+		report.fail(fmt.Errorf("no file path for field `%s`", field))
+		return
+	}
+
+	if r.skip(rules.QuarantineFileAction, def.DryRun, &report.RemediationActionReport) {
+		return
+	}
+
+	report.Status = RemediationStatusPending
+	pid := ev.ProcessContext.Pid
+
+	// moving and hashing the file can take a while, the report is resolved once it completes
+	go func() {
+		if err := r.quarantineFile(pid, report); err != nil {
+			seclog.Warnf("failed to quarantine %s: %s", report.Path, err)
+
+			report.Lock()
+			report.fail(err)
+			report.DoneAt = time.Now()
+			report.Unlock()
+		}
+	}()
+}
+
+// quarantineFile moves the file, as seen by the process, to the quarantine
+// directory, removes its permissions and records its metadata next to it
+func (r *Remediator) quarantineFile(pid uint32, report *QuarantineFileActionReport) error {
+	src := utils.ProcRootFilePath(pid, report.Path)
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s isn't a regular file", report.Path)
+	}
+
+	if err := os.MkdirAll(r.config.EnforcementQuarantineDirectory, 0700); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(r.config.EnforcementQuarantineDirectory, fmt.Sprintf("%d-%s", report.CreatedAt.UnixNano(), filepath.Base(report.Path)))
+	if err := moveFile(src, dst); err != nil {
+		return err
+	}
+
+	// the quarantined file can only be read by the agent
+	if err := os.Chmod(dst, 0400); err != nil {
+		return err
+	}
+
+	hash, err := sha256File(dst)
+	if err != nil {
+		return err
+	}
+
+	report.Lock()
+	report.Mode = uint32(info.Mode().Perm())
+	report.Size = info.Size()
+	report.ModifiedAt = info.ModTime()
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		report.UID = stat.Uid
+		report.GID = stat.Gid
+	}
+	report.QuarantinePath = dst
+	report.SHA256 = hash
+	report.Status = RemediationStatusPerformed
+	report.DoneAt = time.Now()
+	report.Unlock()
+
+	metadata, _, err := report.ToJSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst+quarantineMetadataSuffix, metadata, 0400)
+}
+
+// moveFile renames the file, or copies it when the quarantine directory is on another filesystem
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0400)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// IsolateAndReport denies the network traffic of the container or cgroup of the process of the event and reports it
+func (r *Remediator) IsolateAndReport(def *rules.IsolateDefinition, ev *model.Event) {
+	report := &IsolateActionReport{
+		RemediationActionReport: RemediationActionReport{CreatedAt: time.Now()},
+		Scope:                   def.Scope,
+	}
+	if report.Scope == "" {
+		report.Scope = rules.IsolateScopeContainer
+	}
+	if ev.ContainerContext != nil {
+		report.ContainerID = string(ev.ContainerContext.ID)
+	}
+	ev.ActionReports = append(ev.ActionReports, report)
+
+	if r.skip(rules.IsolateAction, def.DryRun, &report.RemediationActionReport) {
+		return
+	}
+
+	report.Status = RemediationStatusPending
+	pid := ev.ProcessContext.Pid
+
+	// the iptables commands can take a while, the report is resolved once they complete
+	go func() {
+		err := r.isolate(pid, report)
+
+		report.Lock()
+		defer report.Unlock()
+
+		report.DoneAt = time.Now()
+		if err != nil {
+			seclog.Warnf("failed to isolate %s %s: %s", report.Scope, report.ContainerID+report.CGroup, err)
+			report.fail(err)
+			return
+		}
+		report.Status = RemediationStatusPerformed
+	}()
+}
+
+// isolate applies the isolation rules in the network namespace of the
+// process. A container is isolated in its own network namespace, which isn't
+// the one of the agent, while a cgroup is isolated by rules matching only its
+// sockets, so that the connectivity of the agent is preserved in both cases.
+func (r *Remediator) isolate(pid uint32, report *IsolateActionReport) error {
+	ns, err := kernel.GetNetNamespaceFromPid(kernel.HostProc(), int(pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+
+	var inputMatch, outputMatch []string
+	switch report.Scope {
+	case rules.IsolateScopeContainer:
+		if report.ContainerID == "" {
+			return errors.New("the process isn't running in a container")
+		}
+
+		agentNS, err := netns.Get()
+		if err != nil {
+			return err
+		}
+		defer agentNS.Close()
+
+		if ns.Equal(agentNS) {
+			return errors.New("the container shares the network namespace of the agent, the cgroup scope has to be used")
+		}
+
+		inputMatch = []string{"!", "-i", "lo"}
+		outputMatch = []string{"!", "-o", "lo"}
+	case rules.IsolateScopeCGroup:
+		cgroup, err := unifiedCGroup(pid)
+		if err != nil {
+			return err
+		}
+		report.Lock()
+		report.CGroup = cgroup
+		report.Unlock()
+
+		inputMatch = []string{"-m", "cgroup", "--path", cgroup}
+		outputMatch = inputMatch
+	}
+
+	return kernel.WithNS(ns, func() error {
+		if err := r.applyIsolation("iptables", inputMatch, outputMatch); err != nil {
+			return err
+		}
+		if err := r.applyIsolation("ip6tables", inputMatch, outputMatch); err != nil {
+			seclog.Warnf("failed to apply the IPv6 isolation rules: %s", err)
+		}
+		return nil
+	})
+}
+
+// applyIsolation adds the drop rules to the isolation chains, and jumps to the
+// isolation chains from the builtin chains
+func (r *Remediator) applyIsolation(binary string, inputMatch, outputMatch []string) error {
+	for _, c := range []struct {
+		builtin string
+		chain   string
+		match   []string
+	}{
+		{builtin: "INPUT", chain: isolationInputChain, match: inputMatch},
+		{builtin: "OUTPUT", chain: isolationOutputChain, match: outputMatch},
+	} {
+		// the chain may already exist
+		_ = r.iptables(binary, "-w", "-N", c.chain)
+
+		if r.iptables(binary, "-w", "-C", c.builtin, "-j", c.chain) != nil {
+			if err := r.iptables(binary, "-w", "-I", c.builtin, "1", "-j", c.chain); err != nil {
+				return err
+			}
+		}
+
+		rule := append(append([]string{}, c.match...), "-j", "DROP")
+		if r.iptables(binary, append([]string{"-w", "-C", c.chain}, rule...)...) != nil {
+			if err := r.iptables(binary, append([]string{"-w", "-A", c.chain}, rule...)...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unifiedCGroup returns the path of the process in the cgroup v2 hierarchy
+func unifiedCGroup(pid uint32) (string, error) {
+	cgroups, err := utils.GetProcControlGroups(pid, pid)
+	if err != nil {
+		return "", err
+	}
+
+	for _, cgroup := range cgroups {
+		if cgroup.ID == 0 && cgroup.Path != "" {
+			return cgroup.Path, nil
+		}
+	}
+	return "", errors.New("the cgroup scope requires cgroup v2")
+}
+
+// CoreDumpAndReport captures the memory maps and a bounded core of the process of the event and reports it
+func (r *Remediator) CoreDumpAndReport(def *rules.CoreDumpDefinition, ev *model.Event) {
+	report := &CoreDumpActionReport{
+		RemediationActionReport: RemediationActionReport{CreatedAt: time.Now()},
+		Pid:                     ev.ProcessContext.Pid,
+		MaxSize:                 def.MaxSize,
+	}
+	if report.MaxSize == 0 {
+		report.MaxSize = r.config.EnforcementCoreDumpMaxSize
+	}
+	ev.ActionReports = append(ev.ActionReports, report)
+
+	if r.skip(rules.CoreDumpAction, def.DryRun, &report.RemediationActionReport) {
+		return
+	}
+
+	if r.config.ActivityDumpLocalStorageDirectory == "" {
+		report.fail(errors.New("no activity dump storage directory"))
+		return
+	}
+
+	report.Status = RemediationStatusPending
+	report.Path = filepath.Join(r.config.ActivityDumpLocalStorageDirectory, coreDumpsDirectory, fmt.Sprintf("%d-%d", report.Pid, report.CreatedAt.UnixNano()))
+
+	// the capture can take a while, the report is resolved once it completes
+	go func() {
+		size, truncated, err := captureCoreDump(report.Pid, report.Path, report.MaxSize)
+
+		report.Lock()
+		defer report.Unlock()
+
+		report.Size = size
+		report.Truncated = truncated
+		report.DoneAt = time.Now()
+		if err != nil {
+			seclog.Warnf("failed to capture the core dump of %d: %s", report.Pid, err)
+			report.fail(err)
+			return
+		}
+		report.Status = RemediationStatusPerformed
+	}()
+}
+
+// coreDumpRegion describes a memory region captured in a core dump
+type coreDumpRegion struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Perms    string `json:"perms"`
+	Pathname string `json:"pathname,omitempty"`
+	Offset   int64  `json:"offset"`
+	Size     int64  `json:"size"`
+}
+
+// captureCoreDump writes the memory maps of the process, its readable memory
+// regions up to maxSize bytes, and the index of the captured regions
+func captureCoreDump(pid uint32, dir string, maxSize int) (int64, bool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, false, err
+	}
+
+	maps, err := os.ReadFile(kernel.HostProc(strconv.FormatUint(uint64(pid), 10), "maps"))
+	if err != nil {
+		return 0, false, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "maps"), maps, 0600); err != nil {
+		return 0, false, err
+	}
+
+	mem, err := os.Open(kernel.HostProc(strconv.FormatUint(uint64(pid), 10), "mem"))
+	if err != nil {
+		return 0, false, err
+	}
+	defer mem.Close()
+
+	out, err := os.OpenFile(filepath.Join(dir, "memory"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, false, err
+	}
+	defer out.Close()
+
+	var (
+		size      int64
+		truncated bool
+		regions   []coreDumpRegion
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(maps))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[1], "r") {
+			continue
+		}
+
+		var pathname string
+		if len(fields) > 5 {
+			pathname = fields[5]
+		}
+		if pathname == "[vvar]" || pathname == "[vsyscall]" {
+			continue
+		}
+
+		bounds := strings.SplitN(fields[0], "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		start, err := strconv.ParseUint(bounds[0], 16, 64)
+		if err != nil || start > math.MaxInt64 {
+			continue
+		}
+		end, err := strconv.ParseUint(bounds[1], 16, 64)
+		if err != nil || end <= start {
+			continue
+		}
+
+		remaining := int64(maxSize) - size
+		if remaining <= 0 {
+			truncated = true
+			break
+		}
+		length := int64(end - start)
+		if length > remaining {
+			length = remaining
+			truncated = true
+		}
+
+		// some regions can't be read, the readable part is kept
+		n, _ := io.Copy(out, io.NewSectionReader(mem, int64(start), length))
+		if n == 0 {
+			continue
+		}
+
+		regions = append(regions, coreDumpRegion{
+			Start:    bounds[0],
+			End:      bounds[1],
+			Perms:    fields[1],
+			Pathname: pathname,
+			Offset:   size,
+			Size:     n,
+		})
+		size += n
+	}
+
+	index, err := json.MarshalIndent(regions, "", "  ")
+	if err != nil {
+		return size, truncated, err
+	}
+	return size, truncated, os.WriteFile(filepath.Join(dir, "regions.json"), index, 0600)
+}
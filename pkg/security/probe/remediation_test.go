@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package probe holds probe related files
+package probe
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+)
+
+func newTestRemediator(t *testing.T) *Remediator {
+	return NewRemediator(&config.RuntimeSecurityConfig{
+		EnforcementRemediationMaxRate:     0,
+		EnforcementRemediationMaxBurst:    1,
+		EnforcementQuarantineDirectory:    filepath.Join(t.TempDir(), "quarantine"),
+		EnforcementCoreDumpMaxSize:        4096,
+		ActivityDumpLocalStorageDirectory: t.TempDir(),
+	})
+}
+
+func newRemediationEvent(path string) *model.Event {
+	ev := model.NewFakeEvent()
+	ev.Type = uint32(model.FileOpenEventType)
+	ev.SetFieldValue("open.file.path", path)
+	ev.ProcessContext = &model.ProcessContext{
+		Process: model.Process{
+			PIDContext: model.PIDContext{Pid: uint32(os.Getpid())},
+		},
+	}
+	return ev
+}
+
+func reportJSON(t *testing.T, report model.ActionReport) map[string]interface{} {
+	data, _, err := report.ToJSON()
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields
+}
+
+// waitForReport waits for the completion of an asynchronous remediation action
+func waitForReport(t *testing.T, report model.ActionReport) {
+	require.Eventually(t, func() bool {
+		_, resolved, err := report.ToJSON()
+		return err == nil && resolved
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestQuarantineFile(t *testing.T) {
+	r := newTestRemediator(t)
+
+	path := filepath.Join(t.TempDir(), "payload")
+	require.NoError(t, os.WriteFile(path, []byte("malicious"), 0755))
+
+	ev := newRemediationEvent(path)
+	r.QuarantineFileAndReport(&rules.QuarantineFileDefinition{}, ev)
+	require.Len(t, ev.ActionReports, 1)
+
+	report := ev.ActionReports[0].(*QuarantineFileActionReport)
+	waitForReport(t, report)
+	assert.Equal(t, RemediationStatusPerformed, report.Status, report.Error)
+	assert.NoFileExists(t, path)
+	assert.Equal(t, uint32(0755), report.Mode)
+	assert.Equal(t, "3aed37043fac3afaa69c36191a63494d5630deb996fc61b437524cddd55326f6", report.SHA256)
+
+	info, err := os.Stat(report.QuarantinePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0400), info.Mode().Perm())
+
+	metadata, err := os.ReadFile(report.QuarantinePath + quarantineMetadataSuffix)
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `"path":"`+path+`"`)
+
+	fields := reportJSON(t, report)
+	assert.Equal(t, rules.QuarantineFileAction, fields["type"])
+	assert.Equal(t, RemediationStatusPerformed, fields["status"])
+
+	// the rate limiter allows a single action
+	require.NoError(t, os.WriteFile(path, []byte("malicious"), 0755))
+	ev = newRemediationEvent(path)
+	r.QuarantineFileAndReport(&rules.QuarantineFileDefinition{}, ev)
+	assert.Equal(t, RemediationStatusRateLimited, ev.ActionReports[0].(*QuarantineFileActionReport).Status)
+	assert.FileExists(t, path)
+}
+
+func TestQuarantineFileDryRun(t *testing.T) {
+	r := newTestRemediator(t)
+
+	path := filepath.Join(t.TempDir(), "payload")
+	require.NoError(t, os.WriteFile(path, []byte("malicious"), 0755))
+
+	ev := newRemediationEvent(path)
+	r.QuarantineFileAndReport(&rules.QuarantineFileDefinition{DryRun: true}, ev)
+
+	report := ev.ActionReports[0].(*QuarantineFileActionReport)
+	assert.Equal(t, RemediationStatusDryRun, report.Status)
+	assert.Equal(t, path, report.Path)
+	assert.FileExists(t, path)
+}
+
+func TestIsolateContainerSharingAgentNetwork(t *testing.T) {
+	r := newTestRemediator(t)
+
+	ev := newRemediationEvent("/tmp/payload")
+	ev.ContainerContext.ID = "abc"
+	r.IsolateAndReport(&rules.IsolateDefinition{}, ev)
+
+	report := ev.ActionReports[0].(*IsolateActionReport)
+	waitForReport(t, report)
+	assert.Equal(t, RemediationStatusFailed, report.Status)
+	assert.Equal(t, rules.IsolateScopeContainer, report.Scope)
+	assert.Contains(t, report.Error, "shares the network namespace of the agent")
+}
+
+func TestApplyIsolation(t *testing.T) {
+	r := newTestRemediator(t)
+
+	var commands []string
+	r.iptables = func(binary string, args ...string) error {
+		command := binary + " " + strings.Join(args, " ")
+		commands = append(commands, command)
+		if args[1] == "-C" {
+			return errors.New("not found")
+		}
+		return nil
+	}
+
+	require.NoError(t, r.applyIsolation("iptables", []string{"!", "-i", "lo"}, []string{"!", "-o", "lo"}))
+	assert.Equal(t, []string{
+		"iptables -w -N DD_CWS_ISOLATION_IN",
+		"iptables -w -C INPUT -j DD_CWS_ISOLATION_IN",
+		"iptables -w -I INPUT 1 -j DD_CWS_ISOLATION_IN",
+		"iptables -w -C DD_CWS_ISOLATION_IN ! -i lo -j DROP",
+		"iptables -w -A DD_CWS_ISOLATION_IN ! -i lo -j DROP",
+		"iptables -w -N DD_CWS_ISOLATION_OUT",
+		"iptables -w -C OUTPUT -j DD_CWS_ISOLATION_OUT",
+		"iptables -w -I OUTPUT 1 -j DD_CWS_ISOLATION_OUT",
+		"iptables -w -C DD_CWS_ISOLATION_OUT ! -o lo -j DROP",
+		"iptables -w -A DD_CWS_ISOLATION_OUT ! -o lo -j DROP",
+	}, commands)
+}
+
+func TestCoreDump(t *testing.T) {
+	r := newTestRemediator(t)
+
+	ev := newRemediationEvent("/tmp/payload")
+	r.CoreDumpAndReport(&rules.CoreDumpDefinition{}, ev)
+
+	report := ev.ActionReports[0].(*CoreDumpActionReport)
+	waitForReport(t, report)
+
+	assert.Equal(t, RemediationStatusPerformed, report.Status, report.Error)
+	assert.True(t, report.Truncated)
+	assert.LessOrEqual(t, report.Size, int64(4096))
+	assert.FileExists(t, filepath.Join(report.Path, "maps"))
+	assert.FileExists(t, filepath.Join(report.Path, "regions.json"))
+
+	info, err := os.Stat(filepath.Join(report.Path, "memory"))
+	require.NoError(t, err)
+	assert.Equal(t, report.Size, info.Size())
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package policytest
+
+import (
+	"encoding/json"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/serializers"
+)
+
+// newEvent decodes the serialized event of a test event
+func newEvent(testEvent *TestEvent) (*model.Event, error) {
+	raw, err := json.Marshal(testEvent.Event)
+	if err != nil {
+		return nil, err
+	}
+	return serializers.UnmarshalEvent(raw)
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux
+
+package policytest
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+// newEvent decodes the serialized event of a test event
+func newEvent(_ *TestEvent) (*model.Event, error) {
+	return nil, errors.New("decoding serialized events is only supported on linux")
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package policytest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the result in the JUnit XML format
+func WriteJUnit(w io.Writer, result *Result) error {
+	suites := junitTestSuites{Name: "policies"}
+
+	var total time.Duration
+	for _, suite := range result.Suites {
+		junitSuite := junitTestSuite{
+			Name:     suite.Name,
+			Tests:    len(suite.Cases),
+			Failures: suite.Failures(),
+			Time:     junitTime(suite.Duration()),
+		}
+
+		for _, c := range suite.Cases {
+			testCase := junitTestCase{
+				Classname: suite.Name,
+				Name:      c.Name,
+				Time:      junitTime(c.Duration),
+			}
+			if c.Failed() {
+				testCase.Failure = &junitFailure{
+					Message:  c.Failures[0],
+					Type:     "PolicyTestFailure",
+					Contents: strings.Join(c.Failures, "\n"),
+				}
+			}
+			junitSuite.TestCases = append(junitSuite.TestCases, testCase)
+		}
+
+		suites.Tests += junitSuite.Tests
+		suites.Failures += junitSuite.Failures
+		total += suite.Duration()
+		suites.Suites = append(suites.Suites, junitSuite)
+	}
+	suites.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package policytest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `---
+version: 1.0.0
+macros:
+  - id: shells
+    values: ["/usr/bin/bash", "/usr/bin/sh"]
+rules:
+  - id: shell_exec
+    expression: exec.file.path in shells
+    actions:
+      - set:
+          name: shell_spawned
+          scope: process
+          value: true
+      - set:
+          name: shell_path
+          field: exec.file.path
+  - id: shadow_after_shell
+    expression: open.file.path == "/etc/shadow" && open.flags & O_RDWR > 0 && ${process.shell_spawned}
+  - id: kill_netcat
+    expression: exec.file.path == "/usr/bin/nc"
+    actions:
+      - kill:
+          signal: SIGKILL
+          scope: container
+  - id: future_rule
+    agent_version: ">= 99.0.0"
+    expression: exec.file.path == "/usr/bin/nc"
+`
+
+const testSuite = `tests:
+  - name: shadow read by a shell
+    events:
+      - event: {"evt": {"name": "exec"}, "process": {"pid": 42, "executable": {"path": "/usr/bin/bash"}}}
+        expect:
+          rules: [shell_exec]
+          variables:
+            process.shell_spawned: true
+            shell_path: [/usr/bin/bash]
+          actions:
+            - rule: shell_exec
+              set: process.shell_spawned
+              value: true
+            - rule: shell_exec
+              set: shell_path
+              value: /usr/bin/bash
+      - event:
+          evt:
+            name: open
+          file:
+            path: /etc/shadow
+            flags: [O_RDWR]
+          process:
+            pid: 42
+        expect:
+          rules: [shadow_after_shell]
+      - event:
+          evt:
+            name: open
+          file:
+            path: /etc/shadow
+            flags: [O_RDWR]
+          process:
+            pid: 43
+        expect:
+          variables:
+            process.shell_spawned: false
+  - name: netcat killed
+    events:
+      - event: {"evt": {"name": "exec"}, "process": {"executable": {"path": "/usr/bin/nc"}}}
+        expect:
+          rules: [kill_netcat]
+          actions:
+            - rule: kill_netcat
+              kill: SIGKILL
+              scope: process
+      - event: {"evt": {"name": "exec"}, "process": {"executable": {"path": "/usr/bin/nc"}}, "container": {"id": "abc"}}
+        expect:
+          rules: [kill_netcat]
+          actions:
+            - rule: kill_netcat
+              kill: SIGKILL
+              scope: container
+  - name: wrong expectations
+    events:
+      - event: {"evt": {"name": "open"}, "file": {"path": "/etc/shadow"}}
+        expect:
+          rules: [shadow_after_shell]
+          variables:
+            process.unknown: 1
+      - event: {"evt": {"name": "exec"}, "process": {"executable": {"path": "/usr/bin/sh"}}}
+        expect:
+          rules: [shell_exec]
+          actions:
+            - rule: shell_exec
+              set: process.shell_spawned
+              value: false
+            - rule: shell_exec
+              set: shell_path
+`
+
+func writeFiles(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.policy"), []byte(testPolicy), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom"+SuiteExtension), []byte(testSuite), 0644))
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := writeFiles(t)
+
+	suites, err := LoadSuites(dir)
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	assert.Equal(t, "custom", suites[0].Name)
+
+	result, err := Run(Opts{
+		PoliciesDir:  dir,
+		AgentVersion: semver.MustParse("7.50.0"),
+	}, suites)
+	require.NoError(t, err)
+	require.Len(t, result.Suites, 1)
+
+	cases := result.Suites[0].Cases
+	require.Len(t, cases, 3)
+	assert.Empty(t, cases[0].Failures)
+	assert.Empty(t, cases[1].Failures)
+	assert.Equal(t, []string{
+		"event #1 (open): expected matching rules [shadow_after_shell], got []",
+		"event #1 (open): unknown variable `process.unknown`",
+		"event #2 (exec): expected actions [shell_exec: set process.shell_spawned = false shell_exec: set shell_path], got [shell_exec: set process.shell_spawned = true shell_exec: set shell_path = /usr/bin/sh]",
+	}, cases[2].Failures)
+	assert.True(t, result.Failed())
+	assert.Equal(t, 1, result.Suites[0].Failures())
+}
+
+func TestLoadSuiteWithoutEventType(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "invalid"+SuiteExtension)
+	require.NoError(t, os.WriteFile(filename, []byte(`tests:
+  - events:
+      - event: {"file": {"path": "/etc/shadow"}}
+`), 0644))
+
+	_, err := LoadSuite(filename)
+	assert.ErrorContains(t, err, "has no `evt.name`")
+}
+
+func TestRunInvalidPolicy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.policy"), []byte(`rules:
+  - id: invalid
+    expression: open.file.unknown == "/etc/shadow"
+`), 0644))
+
+	_, err := Run(Opts{PoliciesDir: dir}, nil)
+	assert.Error(t, err)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	result := &Result{
+		Suites: []*SuiteResult{
+			{
+				Name: "custom",
+				Cases: []*CaseResult{
+					{Name: "ok"},
+					{Name: "ko", Failures: []string{"event #1 (open): expected matching rules [a], got []"}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, result))
+
+	output := buf.String()
+	assert.Contains(t, output, `<testsuites name="policies" tests="2" failures="1" time="0.000">`)
+	assert.Contains(t, output, `<testcase classname="custom" name="ok" time="0.000"></testcase>`)
+	assert.Contains(t, output, `<failure message="event #1 (open): expected matching rules [a], got []" type="PolicyTestFailure">`)
+}
@@ -0,0 +1,477 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package policytest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+)
+
+// Opts defines the options used to load the policies under test
+type Opts struct {
+	// PoliciesDir is the directory of the policies
+	PoliciesDir string
+	// AgentVersion is the version used to evaluate the agent version
+	// constraints of the rules and macros, no constraint is applied if nil
+	AgentVersion *semver.Version
+	// RuleFilterModel is the model used to evaluate the filters of the rules,
+	// no filter is applied if nil
+	RuleFilterModel eval.Model
+}
+
+// CaseResult is the result of a test case
+type CaseResult struct {
+	Name     string
+	Duration time.Duration
+	Failures []string
+}
+
+// Failed returns whether the test case failed
+func (r *CaseResult) Failed() bool {
+	return len(r.Failures) > 0
+}
+
+// SuiteResult is the result of the test cases of a suite
+type SuiteResult struct {
+	Name  string
+	Cases []*CaseResult
+}
+
+// Failures returns the number of failed test cases
+func (r *SuiteResult) Failures() int {
+	var failures int
+	for _, c := range r.Cases {
+		if c.Failed() {
+			failures++
+		}
+	}
+	return failures
+}
+
+// Duration returns the total duration of the test cases
+func (r *SuiteResult) Duration() time.Duration {
+	var duration time.Duration
+	for _, c := range r.Cases {
+		duration += c.Duration
+	}
+	return duration
+}
+
+// Result is the result of a run of test suites
+type Result struct {
+	Suites []*SuiteResult
+}
+
+// Failed returns whether at least one test case failed
+func (r *Result) Failed() bool {
+	for _, suite := range r.Suites {
+		if suite.Failures() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the test suites against the policies. An error is returned if the
+// policies can't be loaded.
+func Run(opts Opts, suites []*Suite) (*Result, error) {
+	// load the policies once to report their errors before running the tests
+	if _, _, err := newRuleSet(opts); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, suite := range suites {
+		suiteResult := &SuiteResult{Name: suite.Name}
+		for _, test := range suite.Tests {
+			caseResult, err := runTestCase(opts, test)
+			if err != nil {
+				return nil, err
+			}
+			suiteResult.Cases = append(suiteResult.Cases, caseResult)
+		}
+		result.Suites = append(result.Suites, suiteResult)
+	}
+
+	return result, nil
+}
+
+func newFakeEvent() eval.Event {
+	return model.NewFakeEvent()
+}
+
+// newRuleSet returns a rule set with all the rules of the policies, filtered
+// the same way as by the engine of the agent
+func newRuleSet(opts Opts) (*rules.RuleSet, *eval.Opts, error) {
+	// enabled all the rules
+	enabled := map[eval.EventType]bool{"*": true}
+
+	ruleOpts, evalOpts := rules.NewEvalOpts(enabled)
+	ruleOpts.WithLogger(seclog.DefaultLogger)
+
+	var loaderOpts rules.PolicyLoaderOpts
+	if opts.AgentVersion != nil {
+		agentVersionFilter, err := rules.NewAgentVersionFilter(opts.AgentVersion)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create agent version filter: %w", err)
+		}
+		loaderOpts.MacroFilters = append(loaderOpts.MacroFilters, agentVersionFilter)
+		loaderOpts.RuleFilters = append(loaderOpts.RuleFilters, agentVersionFilter)
+	}
+	if opts.RuleFilterModel != nil {
+		seclRuleFilter := rules.NewSECLRuleFilter(opts.RuleFilterModel)
+		loaderOpts.MacroFilters = append(loaderOpts.MacroFilters, seclRuleFilter)
+		loaderOpts.RuleFilters = append(loaderOpts.RuleFilters, seclRuleFilter)
+	}
+
+	provider, err := rules.NewPoliciesDirProvider(opts.PoliciesDir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loader := rules.NewPolicyLoader(provider)
+
+	ruleSet := rules.NewRuleSet(&model.Model{}, newFakeEvent, ruleOpts, evalOpts)
+	evaluationSet, err := rules.NewEvaluationSet([]*rules.RuleSet{ruleSet})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := loadingErrors(evaluationSet.LoadPolicies(loader, loaderOpts)); err != nil {
+		return nil, nil, err
+	}
+
+	return ruleSet, evalOpts, nil
+}
+
+// loadingErrors returns the errors of the policies, ignoring the rules
+// skipped because of their agent version constraints or filters
+func loadingErrors(m *multierror.Error) error {
+	var errs *multierror.Error
+	if m == nil {
+		return nil
+	}
+	for _, err := range m.Errors {
+		if rErr, ok := err.(*rules.ErrRuleLoad); ok {
+			if errors.Is(rErr.Err, rules.ErrRuleAgentVersion) || errors.Is(rErr.Err, rules.ErrRuleAgentFilter) {
+				continue
+			}
+		}
+		errs = multierror.Append(errs, err)
+	}
+	return errs.ErrorOrNil()
+}
+
+// matchListener records the rules matched by an event and the results of
+// their actions
+type matchListener struct {
+	rules   []string
+	actions []ExpectedAction
+}
+
+// RuleMatch is called when a rule matches an event
+func (l *matchListener) RuleMatch(rule *rules.Rule, event eval.Event) bool {
+	l.rules = append(l.rules, rule.ID)
+
+	ctx := eval.NewContext(event)
+	for _, action := range rule.Definition.Actions {
+		if !action.IsAccepted(ctx) {
+			continue
+		}
+
+		switch {
+		case action.Set != nil:
+			name := action.Set.Name
+			if action.Set.Scope != "" {
+				name = string(action.Set.Scope) + "." + name
+			}
+			l.actions = append(l.actions, ExpectedAction{Rule: rule.ID, Set: name, Value: setValue(action.Set, event)})
+		case action.Kill != nil:
+			l.actions = append(l.actions, ExpectedAction{Rule: rule.ID, Kill: action.Kill.Signal, Scope: killScope(action.Kill, event)})
+		}
+	}
+
+	return true
+}
+
+// setValue returns the value set by a set action, the same way as the rule set
+// applies it
+func setValue(set *rules.SetDefinition, event eval.Event) interface{} {
+	if set.Field == "" {
+		return set.Value
+	}
+	value, err := event.(*model.Event).GetFieldValue(set.Field)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// killScope returns the scope of the processes killed by a kill action, the
+// same way as the process killer of the probe resolves it
+func killScope(kill *rules.KillDefinition, event eval.Event) string {
+	if kill.Scope == "container" && event.(*model.Event).ContainerContext.ID != "" {
+		return "container"
+	}
+	return "process"
+}
+
+// EventDiscarderFound is called when a discarder is found
+func (l *matchListener) EventDiscarderFound(_ *rules.RuleSet, _ eval.Event, _ eval.Field, _ eval.EventType) {
+}
+
+// scopes holds the process and container contexts shared by the events of a
+// test case, used as keys by the scoped variables
+type scopes struct {
+	processes  map[uint32]*model.ProcessCacheEntry
+	containers map[string]*model.ContainerContext
+}
+
+func (s *scopes) attach(event *model.Event) {
+	pid := event.ProcessContext.Pid
+	entry, found := s.processes[pid]
+	if !found {
+		entry = event.ProcessCacheEntry
+		if entry == nil {
+			entry = &model.ProcessCacheEntry{}
+		}
+		s.processes[pid] = entry
+	}
+	event.ProcessCacheEntry = entry
+
+	id := event.ContainerContext.ID
+	container, found := s.containers[id]
+	if !found {
+		s.containers[id] = event.ContainerContext
+		return
+	}
+	if event.ContainerContext.CreatedAt != 0 {
+		container.CreatedAt = event.ContainerContext.CreatedAt
+	}
+	if len(event.ContainerContext.Tags) > 0 {
+		container.Tags = event.ContainerContext.Tags
+	}
+	event.ContainerContext = container
+}
+
+func runTestCase(opts Opts, test *TestCase) (*CaseResult, error) {
+	ruleSet, evalOpts, err := newRuleSet(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := &matchListener{}
+	ruleSet.AddListener(listener)
+
+	s := &scopes{
+		processes:  make(map[uint32]*model.ProcessCacheEntry),
+		containers: make(map[string]*model.ContainerContext),
+	}
+
+	result := &CaseResult{Name: test.Name}
+	start := time.Now()
+
+	for i, testEvent := range test.Events {
+		prefix := fmt.Sprintf("event #%d (%s)", i+1, testEvent.Type())
+
+		event, err := newEvent(testEvent)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", prefix, err))
+			break
+		}
+		s.attach(event)
+
+		listener.rules, listener.actions = nil, nil
+		ruleSet.Evaluate(event)
+
+		for _, failure := range checkExpectation(evalOpts.VariableStore, event, &testEvent.Expect, listener) {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %s", prefix, failure))
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// normalizeValue converts a decoded YAML value to the type of the SECL fields
+// and variables
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{}
+		}
+		if _, ok := v[0].(int); ok {
+			values := make([]int, 0, len(v))
+			for _, item := range v {
+				i, ok := item.(int)
+				if !ok {
+					return value
+				}
+				values = append(values, i)
+			}
+			return values
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	}
+	return value
+}
+
+// variableValue returns the value of a variable in the context of an event
+func variableValue(variable eval.VariableValue, ctx *eval.Context) (interface{}, error) {
+	switch evaluator := variable.GetEvaluator().(type) {
+	case *eval.BoolEvaluator:
+		if evaluator.EvalFnc != nil {
+			return evaluator.EvalFnc(ctx), nil
+		}
+		return evaluator.Value, nil
+	case *eval.IntEvaluator:
+		if evaluator.EvalFnc != nil {
+			return evaluator.EvalFnc(ctx), nil
+		}
+		return evaluator.Value, nil
+	case *eval.StringEvaluator:
+		if evaluator.EvalFnc != nil {
+			return evaluator.EvalFnc(ctx), nil
+		}
+		return evaluator.Value, nil
+	case *eval.StringArrayEvaluator:
+		if evaluator.EvalFnc != nil {
+			return evaluator.EvalFnc(ctx), nil
+		}
+		return evaluator.Values, nil
+	case *eval.IntArrayEvaluator:
+		if evaluator.EvalFnc != nil {
+			return evaluator.EvalFnc(ctx), nil
+		}
+		return evaluator.Values, nil
+	default:
+		return nil, fmt.Errorf("unsupported variable type %T", evaluator)
+	}
+}
+
+// equalValues compares an expected value with the value of a variable or a
+// field, the order of the items of arrays isn't significant
+func equalValues(expected, actual interface{}) bool {
+	expected = normalizeValue(expected)
+
+	switch a := actual.(type) {
+	case []string:
+		e, ok := expected.([]string)
+		return ok && reflect.DeepEqual(sortedStrings(e), sortedStrings(a))
+	case []int:
+		e, ok := expected.([]int)
+		return ok && reflect.DeepEqual(sortedInts(e), sortedInts(a))
+	default:
+		return reflect.DeepEqual(expected, actual)
+	}
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedInts(values []int) []int {
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+// checkExpectation returns the mismatches between the expectation and the
+// result of the evaluation of the event
+func checkExpectation(variables *eval.VariableStore, event *model.Event, expect *Expectation, listener *matchListener) []string {
+	var failures []string
+
+	if expected, actual := sortedStrings(expect.Rules), sortedStrings(listener.rules); !reflect.DeepEqual(expected, actual) {
+		failures = append(failures, fmt.Sprintf("expected matching rules %v, got %v", expected, actual))
+	}
+
+	if len(expect.Variables) > 0 {
+		ctx := eval.NewContext(event)
+
+		names := make([]string, 0, len(expect.Variables))
+		for name := range expect.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			variable := variables.Get(name)
+			if variable == nil {
+				failures = append(failures, fmt.Sprintf("unknown variable `%s`", name))
+				continue
+			}
+
+			value, err := variableValue(variable, ctx)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("variable `%s`: %v", name, err))
+				continue
+			}
+
+			if expected := expect.Variables[name]; !equalValues(expected, value) {
+				failures = append(failures, fmt.Sprintf("expected variable `%s` to be %v, got %v", name, expected, value))
+			}
+		}
+	}
+
+	if expect.Actions != nil && !matchActions(expect.Actions, listener.actions) {
+		failures = append(failures, fmt.Sprintf("expected actions %v, got %v", actionStrings(expect.Actions), actionStrings(listener.actions)))
+	}
+
+	return failures
+}
+
+// matchActions returns whether each action triggered by the rules matches
+// exactly one expected action
+func matchActions(expected, actual []ExpectedAction) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	matched := make([]bool, len(actual))
+	for _, e := range expected {
+		found := false
+		for i, a := range actual {
+			if !matched[i] && e.matches(a) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func actionStrings(actions []ExpectedAction) []string {
+	values := make([]string, 0, len(actions))
+	for _, action := range actions {
+		values = append(values, action.String())
+	}
+	return sortedStrings(values)
+}
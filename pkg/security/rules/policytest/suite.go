@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package policytest runs regression test suites against runtime security policies.
+//
+// A test suite is a `*.test.yaml` file listing test cases. Each test case is a
+// sequence of events evaluated in order against the rule set loaded from the
+// policies, along with the rules expected to match each event, the expected
+// state of the variables after the event and the expected results of the
+// actions of the matched rules:
+//
+//	tests:
+//	  - name: shell spawned by nginx
+//	    events:
+//	      - event: {"evt": {"name": "exec"}, "process": {"pid": 42, "executable": {"path": "/usr/bin/bash"}}}
+//	        expect:
+//	          rules: [nginx_shell]
+//	          variables:
+//	            process.shell_spawned: true
+//	          actions:
+//	            - rule: nginx_shell
+//	              set: process.shell_spawned
+//	              value: true
+//
+// Events are written in the JSON layout of the events sent by the agent, so
+// that an event reported by the agent can be copied as is into a test case.
+// They are decoded with the deserializers of the agent, which are only
+// available on Linux. Each test case starts with a fresh rule set, and the
+// events of a test case sharing the same process ID or container ID share the
+// same process or container scoped variables.
+package policytest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SuiteExtension is the file extension of the test suites
+const SuiteExtension = ".test.yaml"
+
+// Suite is a set of test cases read from a file
+type Suite struct {
+	Name  string      `yaml:"-"`
+	Tests []*TestCase `yaml:"tests"`
+}
+
+// TestCase is a sequence of events and their expectations
+type TestCase struct {
+	Name   string       `yaml:"name"`
+	Events []*TestEvent `yaml:"events"`
+}
+
+// TestEvent is a serialized event and the expected result of its evaluation
+type TestEvent struct {
+	Event  map[string]interface{} `yaml:"event"`
+	Expect Expectation            `yaml:"expect"`
+}
+
+// Type returns the type of the event, read from its `evt.name` field
+func (e *TestEvent) Type() string {
+	if evt, ok := e.Event["evt"].(map[string]interface{}); ok {
+		if name, ok := evt["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Expectation describes the expected result of the evaluation of an event
+type Expectation struct {
+	// Rules are the IDs of all the rules expected to match the event
+	Rules []string `yaml:"rules"`
+	// Variables are the expected values of variables after the evaluation.
+	// Scoped variables are prefixed by their scope.
+	Variables map[string]interface{} `yaml:"variables"`
+	// Actions are the expected actions triggered by the matched rules. They
+	// are only checked when specified.
+	Actions []ExpectedAction `yaml:"actions"`
+}
+
+// ExpectedAction is an action expected to be triggered by a rule, along with
+// its result
+type ExpectedAction struct {
+	Rule string `yaml:"rule"`
+	// Set is the name of the variable set by the action, prefixed by its scope
+	Set string `yaml:"set,omitempty"`
+	// Value is the value set by the action, only checked when specified
+	Value interface{} `yaml:"value,omitempty"`
+	// Kill is the signal sent by the action
+	Kill string `yaml:"kill,omitempty"`
+	// Scope is the scope of the processes killed by the action, either
+	// `process` or `container`, only checked when specified
+	Scope string `yaml:"scope,omitempty"`
+}
+
+func (a ExpectedAction) String() string {
+	if a.Kill != "" {
+		if a.Scope != "" {
+			return fmt.Sprintf("%s: kill %s (%s)", a.Rule, a.Kill, a.Scope)
+		}
+		return fmt.Sprintf("%s: kill %s", a.Rule, a.Kill)
+	}
+	if a.Value != nil {
+		return fmt.Sprintf("%s: set %s = %v", a.Rule, a.Set, a.Value)
+	}
+	return fmt.Sprintf("%s: set %s", a.Rule, a.Set)
+}
+
+// matches returns whether an action triggered by a rule matches the expected
+// action
+func (a ExpectedAction) matches(actual ExpectedAction) bool {
+	if a.Rule != actual.Rule || a.Set != actual.Set || a.Kill != actual.Kill {
+		return false
+	}
+	if a.Value != nil && !equalValues(a.Value, actual.Value) {
+		return false
+	}
+	return a.Scope == "" || a.Scope == actual.Scope
+}
+
+// LoadSuite reads a test suite file
+func LoadSuite(filename string) (*Suite, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite Suite
+	if err := yaml.Unmarshal(content, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse test suite %s: %w", filename, err)
+	}
+	suite.Name = strings.TrimSuffix(filepath.Base(filename), SuiteExtension)
+
+	for i, test := range suite.Tests {
+		if test.Name == "" {
+			test.Name = fmt.Sprintf("test_%d", i+1)
+		}
+		for j, event := range test.Events {
+			if event.Type() == "" {
+				return nil, fmt.Errorf("%s: event %d of test `%s` has no `evt.name`", filename, j+1, test.Name)
+			}
+		}
+	}
+
+	return &suite, nil
+}
+
+// LoadSuites reads all the test suite files of a directory
+func LoadSuites(dir string) ([]*Suite, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var filenames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), SuiteExtension) {
+			filenames = append(filenames, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(filenames)
+
+	suites := make([]*Suite, 0, len(filenames))
+	for _, filename := range filenames {
+		suite, err := LoadSuite(filename)
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, suite)
+	}
+
+	return suites, nil
+}
@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package ast holds ast related files
+package ast
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// canonical spelling of the operators
+var canonicalOps = map[string]string{
+	"or":    "||",
+	"and":   "&&",
+	"not":   "!",
+	"notin": "not in",
+}
+
+func canonicalOp(op string) string {
+	if canonical, ok := canonicalOps[op]; ok {
+		return canonical
+	}
+	return op
+}
+
+// FormatRule returns the canonical form of a rule expression: operators are
+// written with their symbols, binary operators are surrounded by a single
+// space and array members are separated by a comma and a space. Comments
+// aren't kept.
+func (pc *ParsingContext) FormatRule(expr string) (string, error) {
+	rule, err := pc.ParseRule(expr)
+	if err != nil {
+		return "", err
+	}
+	return rule.Format(), nil
+}
+
+// FormatMacro returns the canonical form of a macro expression
+func (pc *ParsingContext) FormatMacro(expr string) (string, error) {
+	macro, err := pc.ParseMacro(expr)
+	if err != nil {
+		return "", err
+	}
+	return macro.Format(), nil
+}
+
+// Format returns the canonical form of the rule
+func (r *Rule) Format() string {
+	var b strings.Builder
+	r.BooleanExpression.Expression.format(&b)
+	return b.String()
+}
+
+// Format returns the canonical form of the macro
+func (m *Macro) Format() string {
+	var b strings.Builder
+	switch {
+	case m.Expression != nil:
+		m.Expression.format(&b)
+	case m.Array != nil:
+		m.Array.format(&b)
+	case m.Primary != nil:
+		m.Primary.format(&b)
+	}
+	return b.String()
+}
+
+func (e *Expression) format(b *strings.Builder) {
+	e.Comparison.format(b)
+	if e.Op != nil {
+		b.WriteString(" " + canonicalOp(*e.Op) + " ")
+		e.Next.Expression.format(b)
+	}
+}
+
+func (c *Comparison) format(b *strings.Builder) {
+	c.ArithmeticOperation.format(b)
+	switch {
+	case c.ScalarComparison != nil:
+		b.WriteString(" " + *c.ScalarComparison.Op + " ")
+		c.ScalarComparison.Next.format(b)
+	case c.ArrayComparison != nil:
+		b.WriteString(" " + canonicalOp(*c.ArrayComparison.Op) + " ")
+		c.ArrayComparison.Array.format(b)
+	}
+}
+
+func (a *ArithmeticOperation) format(b *strings.Builder) {
+	a.First.format(b)
+	for _, element := range a.Rest {
+		b.WriteString(" " + element.Op + " ")
+		element.Operand.format(b)
+	}
+}
+
+func (o *BitOperation) format(b *strings.Builder) {
+	o.Unary.format(b)
+	if o.Op != nil {
+		b.WriteString(" " + *o.Op + " ")
+		o.Next.format(b)
+	}
+}
+
+func (u *Unary) format(b *strings.Builder) {
+	if u.Op != nil {
+		b.WriteString(canonicalOp(*u.Op))
+		u.Unary.format(b)
+		return
+	}
+	u.Primary.format(b)
+}
+
+func (p *Primary) format(b *strings.Builder) {
+	switch {
+	case p.Ident != nil:
+		b.WriteString(*p.Ident)
+	case p.CIDR != nil:
+		b.WriteString(*p.CIDR)
+	case p.IP != nil:
+		b.WriteString(*p.IP)
+	case p.Number != nil:
+		b.WriteString(strconv.Itoa(*p.Number))
+	case p.Variable != nil:
+		b.WriteString(*p.Variable)
+	case p.String != nil:
+		b.WriteString(strconv.Quote(*p.String))
+	case p.Pattern != nil:
+		b.WriteString(`~"` + *p.Pattern + `"`)
+	case p.Regexp != nil:
+		b.WriteString(`r"` + *p.Regexp + `"`)
+	case p.Duration != nil:
+		b.WriteString(formatDuration(time.Duration(*p.Duration)))
+	case p.SubExpression != nil:
+		b.WriteString("(")
+		p.SubExpression.format(b)
+		b.WriteString(")")
+	}
+}
+
+func (a *Array) format(b *strings.Builder) {
+	switch {
+	case a.CIDR != nil:
+		b.WriteString(*a.CIDR)
+	case a.Variable != nil:
+		b.WriteString(*a.Variable)
+	case a.Ident != nil:
+		b.WriteString(*a.Ident)
+	case len(a.StringMembers) > 0:
+		members := make([]string, 0, len(a.StringMembers))
+		for _, member := range a.StringMembers {
+			switch {
+			case member.String != nil:
+				members = append(members, strconv.Quote(*member.String))
+			case member.Pattern != nil:
+				members = append(members, `~"`+*member.Pattern+`"`)
+			case member.Regexp != nil:
+				members = append(members, `r"`+*member.Regexp+`"`)
+			}
+		}
+		b.WriteString("[" + strings.Join(members, ", ") + "]")
+	case len(a.CIDRMembers) > 0:
+		members := make([]string, 0, len(a.CIDRMembers))
+		for _, member := range a.CIDRMembers {
+			if member.IP != nil {
+				members = append(members, *member.IP)
+			} else {
+				members = append(members, *member.CIDR)
+			}
+		}
+		b.WriteString("[" + strings.Join(members, ", ") + "]")
+	default:
+		members := make([]string, 0, len(a.Numbers))
+		for _, number := range a.Numbers {
+			members = append(members, strconv.Itoa(number))
+		}
+		b.WriteString("[" + strings.Join(members, ", ") + "]")
+	}
+}
+
+// formatDuration writes the duration with the largest unit supported by the
+// lexer that represents it exactly
+func formatDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+	} {
+		if d%unit.duration == 0 {
+			return strconv.FormatInt(int64(d/unit.duration), 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+}
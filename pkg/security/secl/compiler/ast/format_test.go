@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package ast holds ast related files
+package ast
+
+import (
+	"testing"
+)
+
+func TestFormatRule(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{
+			expr:     `open.file.path=="/etc/shadow"and process.uid!=0`,
+			expected: `open.file.path == "/etc/shadow" && process.uid != 0`,
+		},
+		{
+			expr:     `exec.file.name in ["sh","bash"]   or not (process.ancestors.file.name in [ ~"/usr/*" , r"^nginx$" ])`,
+			expected: `exec.file.name in ["sh", "bash"] || !(process.ancestors.file.name in [~"/usr/*", r"^nginx$"])`,
+		},
+		{
+			expr:     `exec.file.path not in [ "/usr/bin/id" ] && process.created_at > 1500ms # comment`,
+			expected: `exec.file.path not in ["/usr/bin/id"] && process.created_at > 1500ms`,
+		},
+		{
+			expr:     `network.destination.ip in [ 192.168.0.1, 10.0.0.0/8 ]&&open.flags&O_CREAT>0`,
+			expected: `network.destination.ip in [192.168.0.1, 10.0.0.0/8] && open.flags & O_CREAT > 0`,
+		},
+		{
+			expr:     `process.created_at<2h&&${process.counter} + 1>=3&&open.file.path=="/tmp/\"quoted\""`,
+			expected: `process.created_at < 2h && ${process.counter} + 1 >= 3 && open.file.path == "/tmp/\"quoted\""`,
+		},
+		{
+			expr:     `process.pid in [1,2 ,3] && container.id =~ "abc*" && exec.args_flags allin ["a","b"]`,
+			expected: `process.pid in [1, 2, 3] && container.id =~ "abc*" && exec.args_flags allin ["a", "b"]`,
+		},
+	}
+
+	pc := NewParsingContext()
+	for _, test := range tests {
+		formatted, err := pc.FormatRule(test.expr)
+		if err != nil {
+			t.Fatalf("failed to format `%s`: %s", test.expr, err)
+		}
+
+		if formatted != test.expected {
+			t.Errorf("unexpected format of `%s`:\n got %s\nwant %s", test.expr, formatted, test.expected)
+		}
+
+		// the canonical form is stable
+		reformatted, err := pc.FormatRule(formatted)
+		if err != nil {
+			t.Fatalf("failed to format `%s`: %s", formatted, err)
+		}
+		if reformatted != formatted {
+			t.Errorf("format of `%s` isn't stable: %s", formatted, reformatted)
+		}
+	}
+}
+
+func TestFormatMacro(t *testing.T) {
+	pc := NewParsingContext()
+
+	formatted, err := pc.FormatMacro(`[ "/usr/bin/sh","/usr/bin/bash" ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `["/usr/bin/sh", "/usr/bin/bash"]`; formatted != expected {
+		t.Errorf("expected %s, got %s", expected, formatted)
+	}
+
+	if _, err := pc.FormatRule(`open.file.path ==`); err == nil {
+		t.Error("an invalid expression shouldn't be formatted")
+	}
+}
@@ -109,6 +109,16 @@ func (e *ErrRuleParse) Error() string {
 	return str
 }
 
+// Position returns the position of the error in the expression
+func (e *ErrRuleParse) Position() lexer.Position {
+	return e.pos
+}
+
+// Expression returns the expression in which the error occurred
+func (e *ErrRuleParse) Expression() string {
+	return e.expr
+}
+
 // ErrFieldNotFound error when a field is not present in the model
 type ErrFieldNotFound struct {
 	Field string
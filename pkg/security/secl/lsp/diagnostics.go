@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle"
+	"github.com/alecthomas/participle/lexer"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/rules"
+)
+
+const diagnosticSource = "secl"
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// documentProvider provides the policy of an open document
+type documentProvider struct {
+	doc *document
+}
+
+// LoadPolicies implements the policy provider interface
+func (p *documentProvider) LoadPolicies(macroFilters []rules.MacroFilter, ruleFilters []rules.RuleFilter) ([]*rules.Policy, *multierror.Error) {
+	var errs *multierror.Error
+
+	policy, err := rules.LoadPolicy(p.doc.uri, "lsp", strings.NewReader(p.doc.text), macroFilters, ruleFilters)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if policy == nil {
+		return nil, errs
+	}
+	return []*rules.Policy{policy}, errs
+}
+
+// SetOnNewPoliciesReadyCb implements the policy provider interface
+func (p *documentProvider) SetOnNewPoliciesReadyCb(_ func()) {}
+
+// Start implements the policy provider interface
+func (p *documentProvider) Start() {}
+
+// Close implements the policy provider interface
+func (p *documentProvider) Close() error { return nil }
+
+// Type implements the policy provider interface
+func (p *documentProvider) Type() string { return "lsp" }
+
+// diagnose returns the syntax and compilation errors of a document
+func (s *Server) diagnose(doc *document) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	if doc.err != nil {
+		line := 0
+		if match := yamlErrorLine.FindStringSubmatch(doc.err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			line--
+		}
+		return append(diagnostics, newDiagnostic(lineRange(doc, line), doc.err.Error()))
+	}
+
+	// syntax errors are reported precisely by the parser
+	invalid := make(map[string]bool)
+	for _, expr := range doc.expressions {
+		var err error
+		if expr.kind == macroExpression {
+			_, err = s.parsingContext.ParseMacro(expr.value)
+		} else {
+			_, err = s.parsingContext.ParseRule(expr.value)
+		}
+		if err == nil {
+			continue
+		}
+
+		invalid[expr.kind+":"+expr.id] = true
+
+		var perr participle.Error
+		if errors.As(err, &perr) {
+			diagnostics = append(diagnostics, newDiagnostic(expr.errorRange(perr.Token().Pos), perr.Message()))
+		} else {
+			diagnostics = append(diagnostics, newDiagnostic(expr.errorRange(lexer.Position{Line: 1, Column: 1}), err.Error()))
+		}
+	}
+
+	// the other errors are reported by the compiler
+	ruleOpts, evalOpts := rules.NewEvalOpts(map[eval.EventType]bool{"*": true})
+	ruleSet := rules.NewRuleSet(&model.Model{}, func() eval.Event { return model.NewFakeEvent() }, ruleOpts, evalOpts)
+	evaluationSet, err := rules.NewEvaluationSet([]*rules.RuleSet{ruleSet})
+	if err != nil {
+		return diagnostics
+	}
+
+	loader := rules.NewPolicyLoader(&documentProvider{doc: doc})
+	for _, err := range flatten(evaluationSet.LoadPolicies(loader, rules.PolicyLoaderOpts{})) {
+		var (
+			ruleErr  *rules.ErrRuleLoad
+			macroErr *rules.ErrMacroLoad
+		)
+
+		switch {
+		case errors.As(err, &ruleErr) && ruleErr.Definition != nil:
+			if invalid[ruleExpression+":"+ruleErr.Definition.ID] {
+				continue
+			}
+			diagnostics = append(diagnostics, newDiagnostic(doc.definitionRange(ruleExpression, ruleErr.Definition.ID, ruleErr.Err), ruleErr.Error()))
+		case errors.As(err, &macroErr) && macroErr.Definition != nil:
+			if invalid[macroExpression+":"+macroErr.Definition.ID] {
+				continue
+			}
+			diagnostics = append(diagnostics, newDiagnostic(doc.definitionRange(macroExpression, macroErr.Definition.ID, macroErr.Err), macroErr.Error()))
+		default:
+			diagnostics = append(diagnostics, newDiagnostic(lineRange(doc, 0), err.Error()))
+		}
+	}
+
+	return diagnostics
+}
+
+func newDiagnostic(r Range, message string) Diagnostic {
+	return Diagnostic{Range: r, Severity: severityError, Source: diagnosticSource, Message: message}
+}
+
+// flatten returns the errors of nested multierrors
+func flatten(m *multierror.Error) []error {
+	if m == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, err := range m.Errors {
+		var nested *multierror.Error
+		if errors.As(err, &nested) {
+			errs = append(errs, flatten(nested)...)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// definitionRange returns the range of the error of a macro or a rule. The
+// position of the compilation errors is used when the definition has a single
+// expression, the range of the ID otherwise.
+func (d *document) definitionRange(kind string, id string, err error) Range {
+	var (
+		exprs   []*expression
+		idRange Range
+		found   bool
+	)
+
+	for _, expr := range d.expressions {
+		if expr.kind == kind && expr.id == id {
+			exprs = append(exprs, expr)
+		}
+	}
+
+	if kind == macroExpression {
+		if macro := d.macros[id]; macro != nil {
+			idRange, found = macro.idRange, true
+		}
+	} else {
+		idRange, found = d.rules[id]
+	}
+
+	if len(exprs) == 1 {
+		var (
+			expr     = exprs[0]
+			parseErr *eval.ErrRuleParse
+			astErr   *eval.ErrAstToEval
+			fieldErr *eval.ErrFieldNotFound
+		)
+
+		switch {
+		case errors.As(err, &parseErr) && parseErr.Expression() == expr.value:
+			return expr.errorRange(parseErr.Position())
+		case errors.As(err, &astErr):
+			return expr.errorRange(astErr.Pos)
+		case errors.As(err, &fieldErr):
+			if offset := identifierOffset(expr.value, fieldErr.Field); offset != -1 {
+				return expr.rangeOf(offset, offset+len(fieldErr.Field))
+			}
+		}
+	}
+	if !found {
+		return lineRange(d, 0)
+	}
+	return idRange
+}
+
+// errorRange returns the range of the token at a position of the expression
+func (e *expression) errorRange(pos lexer.Position) Range {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		next := strings.IndexByte(e.value[offset:], '\n')
+		if next == -1 {
+			break
+		}
+		offset += next + 1
+	}
+	offset += pos.Column - 1
+	if offset > len(e.value) {
+		offset = len(e.value)
+	} else if offset < 0 {
+		offset = 0
+	}
+
+	_, end := wordAt(e.value, offset)
+	if end == offset && end < len(e.value) {
+		end++
+		// the range of a string covers its quotes
+		if e.value[offset] == '"' {
+			for end < len(e.value) && e.value[end] != '"' {
+				if e.value[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(e.value) {
+				end++
+			} else {
+				end = len(e.value)
+			}
+		}
+	}
+	return e.rangeOf(offset, end)
+}
+
+// identifierOffset returns the offset of the first occurrence of an identifier in the expression
+func identifierOffset(value string, identifier string) int {
+	for offset := 0; offset < len(value); {
+		index := strings.Index(value[offset:], identifier)
+		if index == -1 {
+			return -1
+		}
+		start, end := offset+index, offset+index+len(identifier)
+		if (start == 0 || !isWordChar(value[start-1])) && (end == len(value) || !isWordChar(value[end])) {
+			return start
+		}
+		offset = end
+	}
+	return -1
+}
+
+// lineRange returns the range of a line of the document
+func lineRange(d *document, line int) Range {
+	if line < 0 || line >= len(d.lines) {
+		line = 0
+	}
+	return Range{
+		Start: Position{Line: line},
+		End:   Position{Line: line, Character: len(d.lines[line])},
+	}
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// FieldDoc is the documentation of a SECL field
+type FieldDoc struct {
+	Type       string
+	Definition string
+	Constants  string
+}
+
+// seclDoc is the subset of the SECL documentation file used by the language server
+type seclDoc struct {
+	PropertiesDoc []struct {
+		Name       string   `json:"name"`
+		Type       string   `json:"type"`
+		Definition string   `json:"definition"`
+		Prefixes   []string `json:"prefixes"`
+		Constants  string   `json:"constants"`
+	} `json:"properties_doc"`
+}
+
+// LoadFieldDocs reads the documentation of the fields from the SECL
+// documentation file generated with the accessors, secl.json
+func LoadFieldDocs(filename string) (map[string]FieldDoc, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc seclDoc
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]FieldDoc)
+	for _, property := range doc.PropertiesDoc {
+		fieldDoc := FieldDoc{
+			Type:       property.Type,
+			Definition: property.Definition,
+			Constants:  property.Constants,
+		}
+
+		// the common fields are documented once for all their prefixes
+		if suffix, found := strings.CutPrefix(property.Name, "*."); found {
+			for _, prefix := range property.Prefixes {
+				docs[prefix+"."+suffix] = fieldDoc
+			}
+			continue
+		}
+		docs[property.Name] = fieldDoc
+	}
+
+	return docs, nil
+}
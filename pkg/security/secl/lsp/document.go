@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	macroExpression = "macro"
+	ruleExpression  = "rule"
+)
+
+// segment maps a line of an expression to its position in the document
+type segment struct {
+	offset int
+	line   int
+	column int
+}
+
+// expression is a SECL expression of a policy document
+type expression struct {
+	kind    string
+	id      string
+	idRange Range
+	value   string
+	node    *yaml.Node
+	// values of the macros defined with a list of values
+	values   []string
+	segments []segment
+}
+
+// position returns the position in the document of an offset in the expression
+func (e *expression) position(offset int) Position {
+	seg := e.segments[0]
+	for _, s := range e.segments {
+		if s.offset > offset {
+			break
+		}
+		seg = s
+	}
+	return Position{Line: seg.line, Character: seg.column + offset - seg.offset}
+}
+
+// offset returns the offset in the expression of a position in the document
+func (e *expression) offset(pos Position) (int, bool) {
+	for i, seg := range e.segments {
+		if seg.line != pos.Line || pos.Character < seg.column {
+			continue
+		}
+
+		end := len(e.value)
+		if i+1 < len(e.segments) {
+			end = e.segments[i+1].offset - 1
+		}
+
+		offset := seg.offset + pos.Character - seg.column
+		if offset <= end {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+func (e *expression) rangeOf(start, end int) Range {
+	return Range{Start: e.position(start), End: e.position(end)}
+}
+
+// document is a policy file opened in the editor
+type document struct {
+	uri         string
+	text        string
+	lines       []string
+	expressions []*expression
+	macros      map[string]*expression
+	rules       map[string]Range
+	variables   []string
+	err         error
+}
+
+func parseDocument(uri, text string) *document {
+	doc := &document{
+		uri:    uri,
+		text:   text,
+		lines:  strings.Split(text, "\n"),
+		macros: make(map[string]*expression),
+		rules:  make(map[string]Range),
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		doc.err = err
+		return doc
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return doc
+	}
+
+	top := root.Content[0]
+	for _, item := range sequenceItems(mappingValue(top, "macros")) {
+		macro := doc.newExpression(macroExpression, item, mappingValue(item, "expression"))
+		if macro == nil {
+			continue
+		}
+		for _, value := range sequenceItems(mappingValue(item, "values")) {
+			macro.values = append(macro.values, value.Value)
+		}
+		doc.macros[macro.id] = macro
+	}
+
+	for _, item := range sequenceItems(mappingValue(top, "rules")) {
+		if idNode := mappingValue(item, "id"); idNode != nil {
+			doc.rules[idNode.Value] = nodeRange(idNode)
+		}
+		doc.newExpression(ruleExpression, item, mappingValue(item, "expression"))
+		for _, step := range sequenceItems(mappingValue(mappingValue(item, "sequence"), "steps")) {
+			doc.newExpression(ruleExpression, item, mappingValue(step, "expression"))
+		}
+
+		for _, action := range sequenceItems(mappingValue(item, "actions")) {
+			set := mappingValue(action, "set")
+			if name := mappingValue(set, "name"); name != nil {
+				variable := name.Value
+				if scope := mappingValue(set, "scope"); scope != nil && scope.Value != "" {
+					variable = scope.Value + "." + variable
+				}
+				doc.variables = append(doc.variables, variable)
+			}
+		}
+	}
+
+	return doc
+}
+
+// newExpression adds the expression of a macro or a rule to the document
+func (d *document) newExpression(kind string, item *yaml.Node, node *yaml.Node) *expression {
+	idNode := mappingValue(item, "id")
+	if idNode == nil {
+		return nil
+	}
+
+	expr := &expression{
+		kind:    kind,
+		id:      idNode.Value,
+		idRange: nodeRange(idNode),
+		node:    node,
+	}
+
+	if kind == ruleExpression && node == nil {
+		return nil
+	}
+	if node != nil && node.Kind == yaml.ScalarNode {
+		expr.value = node.Value
+		expr.segments = d.segments(node)
+		d.expressions = append(d.expressions, expr)
+	}
+
+	return expr
+}
+
+// segments maps the lines of the value of a scalar node to the document
+func (d *document) segments(node *yaml.Node) []segment {
+	switch node.Style {
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		var (
+			segments []segment
+			offset   int
+			indent   = -1
+		)
+
+		// the content of a block scalar starts on the line following its indicator
+		for line := node.Line; line < len(d.lines); line++ {
+			text := d.lines[line]
+			if strings.TrimSpace(text) == "" {
+				offset++
+				continue
+			}
+
+			lineIndent := len(text) - len(strings.TrimLeft(text, " "))
+			if indent == -1 {
+				indent = lineIndent
+			}
+			if lineIndent < indent {
+				break
+			}
+
+			segments = append(segments, segment{offset: offset, line: line, column: indent})
+			offset += len(text) - indent + 1
+		}
+
+		if len(segments) > 0 {
+			return segments
+		}
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		return []segment{{line: node.Line - 1, column: node.Column}}
+	}
+
+	return []segment{{line: node.Line - 1, column: node.Column - 1}}
+}
+
+// expressionAt returns the expression at a position, and the offset of the position in the expression
+func (d *document) expressionAt(pos Position) (*expression, int, bool) {
+	for _, expr := range d.expressions {
+		if offset, ok := expr.offset(pos); ok {
+			return expr, offset, true
+		}
+	}
+	return nil, 0, false
+}
+
+func nodeRange(node *yaml.Node) Range {
+	start := Position{Line: node.Line - 1, Character: node.Column - 1}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + len(node.Value)}}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '[' || c == ']' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// wordAt returns the bounds of the identifier around an offset of the expression
+func wordAt(value string, offset int) (int, int) {
+	start, end := offset, offset
+	for start > 0 && isWordChar(value[start-1]) {
+		start--
+	}
+	for end < len(value) && isWordChar(value[end]) {
+		end++
+	}
+	return start, end
+}
+
+// isVariable returns whether the identifier starting at the offset is a variable
+func isVariable(value string, start int) bool {
+	return start >= 2 && value[start-2:start] == "${"
+}
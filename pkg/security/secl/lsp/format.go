@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/ast"
+)
+
+// FormatDocument returns a policy document with its macro and rule
+// expressions in their canonical form. The expressions written on several
+// lines and the invalid ones are kept as they are.
+func FormatDocument(text string) (string, error) {
+	doc := parseDocument("", text)
+	if doc.err != nil {
+		return "", doc.err
+	}
+
+	edits := formatEdits(ast.NewParsingContext(), doc)
+
+	// apply the edits from the end of the document so that the positions stay valid
+	sort.Slice(edits, func(i, j int) bool {
+		a, b := edits[i].Range.Start, edits[j].Range.Start
+		return a.Line > b.Line || (a.Line == b.Line && a.Character > b.Character)
+	})
+
+	lines := strings.SplitAfter(text, "\n")
+	lineOffset := func(pos Position) int {
+		offset := 0
+		for _, line := range lines[:pos.Line] {
+			offset += len(line)
+		}
+		return offset + pos.Character
+	}
+
+	for _, edit := range edits {
+		text = text[:lineOffset(edit.Range.Start)] + edit.NewText + text[lineOffset(edit.Range.End):]
+	}
+
+	return text, nil
+}
+
+// formatEdits returns the edits formatting the expressions of a document
+func formatEdits(pc *ast.ParsingContext, doc *document) []TextEdit {
+	edits := []TextEdit{}
+
+	for _, expr := range doc.expressions {
+		var (
+			formatted string
+			err       error
+		)
+		if expr.kind == macroExpression {
+			formatted, err = pc.FormatMacro(expr.value)
+		} else {
+			formatted, err = pc.FormatRule(expr.value)
+		}
+		if err != nil || formatted == strings.TrimSpace(expr.value) || len(expr.segments) != 1 {
+			continue
+		}
+
+		if edit, ok := doc.formatEdit(expr, formatted); ok {
+			edits = append(edits, edit)
+		}
+	}
+
+	return edits
+}
+
+// formatEdit returns the edit replacing the scalar of an expression written on a single line
+func (d *document) formatEdit(expr *expression, formatted string) (TextEdit, bool) {
+	seg := expr.segments[0]
+	if seg.line >= len(d.lines) {
+		return TextEdit{}, false
+	}
+	line := d.lines[seg.line]
+
+	var start, end int
+	switch expr.node.Style {
+	case 0:
+		start, end = seg.column, seg.column+len(expr.value)
+		if end > len(line) || line[start:end] != expr.value {
+			return TextEdit{}, false
+		}
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		// the range covers the quotes and the escape sequences of the source
+		start = seg.column - 1
+		end = quotedEnd(line, start)
+		if end == -1 {
+			return TextEdit{}, false
+		}
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		start, end = seg.column, len(strings.TrimRight(line, " \r"))
+		if end < start || strings.TrimSpace(expr.value) != line[start:end] {
+			return TextEdit{}, false
+		}
+		return TextEdit{Range: expr.rangeOf(0, end-start), NewText: formatted}, true
+	default:
+		return TextEdit{}, false
+	}
+
+	return TextEdit{
+		Range: Range{
+			Start: Position{Line: seg.line, Character: start},
+			End:   Position{Line: seg.line, Character: end},
+		},
+		NewText: scalar(formatted, expr.node.Style),
+	}, true
+}
+
+// quotedEnd returns the offset following the closing quote of the scalar starting at start
+func quotedEnd(line string, start int) int {
+	if start < 0 || start >= len(line) {
+		return -1
+	}
+
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch {
+		case quote == '"' && line[i] == '\\':
+			i++
+		case line[i] == quote:
+			if quote == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// scalar returns the YAML representation of a value, keeping the plain style when possible
+func scalar(value string, style yaml.Style) string {
+	if style == 0 {
+		var decoded struct {
+			Value string `yaml:"value"`
+		}
+		if err := yaml.Unmarshal([]byte("value: "+value), &decoded); err == nil && decoded.Value == value {
+			return value
+		}
+		style = yaml.DoubleQuotedStyle
+	}
+
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Style: style, Value: value})
+	if err != nil {
+		return value
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
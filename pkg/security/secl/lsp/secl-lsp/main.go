@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package main holds main related files
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/lsp"
+)
+
+func main() {
+	var (
+		docFile string
+		format  bool
+		write   bool
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s [-doc secl.json]          serve the language server on stdin/stdout\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -fmt [-w] policy.yaml...  format the expressions of policy files\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.BoolVar(&format, "fmt", false, "Format the expressions of the policy files given as arguments")
+	flag.StringVar(&docFile, "doc", "", "Path of the SECL documentation file (secl.json) used for the hover and completion documentation")
+	flag.BoolVar(&write, "w", false, "Write the formatted policies to their file instead of stdout")
+	flag.Parse()
+
+	if format {
+		if flag.NArg() == 0 {
+			fmt.Fprintf(os.Stderr, "Please provide the policy files to format\n")
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		failed := false
+		for _, filename := range flag.Args() {
+			if err := formatFile(filename, write); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", filename, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var fieldDocs map[string]lsp.FieldDoc
+	if docFile != "" {
+		var err error
+		if fieldDocs, err = lsp.LoadFieldDocs(docFile); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load the SECL documentation: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := lsp.NewServer(fieldDocs).Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func formatFile(filename string, write bool) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := lsp.FormatDocument(string(content))
+	if err != nil {
+		return err
+	}
+
+	if !write {
+		_, err = os.Stdout.WriteString(formatted)
+		return err
+	}
+
+	if formatted == string(content) {
+		return nil
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(formatted), info.Mode())
+}
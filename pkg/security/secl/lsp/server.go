@@ -0,0 +1,388 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/ast"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+// keywords of the SECL language
+var keywords = []string{"in", "not", "allin", "and", "or", "true", "false"}
+
+// Server is a language server for the SECL policies. It provides the
+// diagnostics of the compiler, the completion of fields, constants, macros and
+// variables, the documentation of fields on hover, the definition of macros
+// and the formatting of expressions.
+type Server struct {
+	conn           *conn
+	parsingContext *ast.ParsingContext
+	event          *model.Event
+	fields         []string
+	constants      map[string]interface{}
+	fieldDocs      map[string]FieldDoc
+	documents      map[string]*document
+}
+
+// NewServer returns a new language server. The documentation of the fields is
+// optional.
+func NewServer(fieldDocs map[string]FieldDoc) *Server {
+	event := model.NewFakeEvent()
+
+	fields := event.GetFields()
+	sort.Strings(fields)
+
+	return &Server{
+		parsingContext: ast.NewParsingContext(),
+		event:          event,
+		fields:         fields,
+		constants:      model.SECLConstants(),
+		fieldDocs:      fieldDocs,
+		documents:      make(map[string]*document),
+	}
+}
+
+// Serve handles the messages read from r until the exit notification or the end of r
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		msg, respErr, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if respErr != nil {
+			if err := s.conn.reply(nil, nil, respErr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, respErr := s.handle(msg)
+		if msg.ID == nil {
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, respErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (interface{}, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           textDocumentSyncFull,
+				"completionProvider":         map[string]interface{}{"triggerCharacters": []string{"."}},
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "secl-lsp"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.publishDiagnostics(params.TextDocument.URI, []Diagnostic{})
+		return nil, nil
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.documents[params.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+
+		switch msg.Method {
+		case "textDocument/completion":
+			return s.complete(doc, params.Position), nil
+		case "textDocument/hover":
+			return s.hover(doc, params.Position), nil
+		default:
+			return s.definition(doc, params.Position), nil
+		}
+	case "textDocument/formatting":
+		var params formattingParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.documents[params.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+		return formatEdits(s.parsingContext, doc), nil
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method `%s` not supported", msg.Method)}
+	}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// update parses the new content of a document and publishes its diagnostics
+func (s *Server) update(uri, text string) {
+	doc := parseDocument(uri, text)
+	s.documents[uri] = doc
+	s.publishDiagnostics(uri, s.diagnose(doc))
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []Diagnostic) {
+	_ = s.conn.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// macro returns the definition of a macro from the open documents
+func (s *Server) macro(id string, preferred *document) (*document, *expression) {
+	if macro := preferred.macros[id]; macro != nil {
+		return preferred, macro
+	}
+
+	uris := make([]string, 0, len(s.documents))
+	for uri := range s.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		if macro := s.documents[uri].macros[id]; macro != nil {
+			return s.documents[uri], macro
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) fieldType(field string) string {
+	if doc, ok := s.fieldDocs[field]; ok && doc.Type != "" {
+		return doc.Type
+	}
+
+	kind, err := s.event.GetFieldType(field)
+	if err != nil {
+		return ""
+	}
+	if kind == reflect.Struct {
+		return "IP/CIDR"
+	}
+	return kind.String()
+}
+
+func (s *Server) complete(doc *document, pos Position) []CompletionItem {
+	items := []CompletionItem{}
+
+	expr, offset, ok := doc.expressionAt(pos)
+	if !ok {
+		return items
+	}
+
+	start, _ := wordAt(expr.value, offset)
+	prefix := expr.value[start:offset]
+	edit := func(label string) *TextEdit {
+		return &TextEdit{Range: expr.rangeOf(start, offset), NewText: label}
+	}
+
+	if isVariable(expr.value, start) {
+		seen := make(map[string]bool)
+		for _, d := range s.sortedDocuments(doc) {
+			for _, variable := range d.variables {
+				if !seen[variable] && strings.HasPrefix(variable, prefix) {
+					seen[variable] = true
+					items = append(items, CompletionItem{Label: variable, Kind: completionKindVariable, Detail: "variable", TextEdit: edit(variable)})
+				}
+			}
+		}
+		return items
+	}
+
+	for _, field := range s.fields {
+		if !strings.HasPrefix(field, prefix) {
+			continue
+		}
+
+		item := CompletionItem{Label: field, Kind: completionKindField, Detail: s.fieldType(field), TextEdit: edit(field)}
+		if fieldDoc, ok := s.fieldDocs[field]; ok {
+			item.Documentation = &MarkupContent{Kind: markupKindMarkdown, Value: fieldDoc.Definition}
+		}
+		items = append(items, item)
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range s.sortedDocuments(doc) {
+		ids := make([]string, 0, len(d.macros))
+		for id := range d.macros {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if !seen[id] && strings.HasPrefix(id, prefix) {
+				seen[id] = true
+				items = append(items, CompletionItem{Label: id, Kind: completionKindFunction, Detail: "macro", TextEdit: edit(id)})
+			}
+		}
+	}
+
+	// constants and keywords don't contain dots
+	if strings.Contains(prefix, ".") {
+		return items
+	}
+
+	constants := make([]string, 0, len(s.constants))
+	for constant := range s.constants {
+		if strings.HasPrefix(constant, prefix) {
+			constants = append(constants, constant)
+		}
+	}
+	sort.Strings(constants)
+	for _, constant := range constants {
+		items = append(items, CompletionItem{Label: constant, Kind: completionKindConstant, Detail: constantValue(s.constants[constant]), TextEdit: edit(constant)})
+	}
+
+	for _, keyword := range keywords {
+		if strings.HasPrefix(keyword, prefix) {
+			items = append(items, CompletionItem{Label: keyword, Kind: completionKindKeyword, TextEdit: edit(keyword)})
+		}
+	}
+
+	return items
+}
+
+// sortedDocuments returns the open documents, starting with the current one
+func (s *Server) sortedDocuments(current *document) []*document {
+	uris := make([]string, 0, len(s.documents))
+	for uri := range s.documents {
+		if uri != current.uri {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+
+	docs := []*document{current}
+	for _, uri := range uris {
+		docs = append(docs, s.documents[uri])
+	}
+	return docs
+}
+
+func constantValue(value interface{}) string {
+	switch value := value.(type) {
+	case *eval.IntEvaluator:
+		return fmt.Sprintf("%d", value.Value)
+	case *eval.BoolEvaluator:
+		return fmt.Sprintf("%t", value.Value)
+	case *eval.StringEvaluator:
+		return fmt.Sprintf("%q", value.Value)
+	default:
+		return ""
+	}
+}
+
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	expr, offset, ok := doc.expressionAt(pos)
+	if !ok {
+		return nil
+	}
+
+	start, end := wordAt(expr.value, offset)
+	if start == end || isVariable(expr.value, start) {
+		return nil
+	}
+	word := expr.value[start:end]
+	wordRange := expr.rangeOf(start, end)
+
+	var contents string
+	if _, macro := s.macro(word, doc); macro != nil {
+		definition := macro.value
+		if len(macro.values) > 0 {
+			definition = "[" + strings.Join(macro.values, ", ") + "]"
+		}
+		contents = fmt.Sprintf("**macro** `%s`\n\n```\n%s\n```", word, definition)
+	} else if value, ok := s.constants[word]; ok {
+		contents = fmt.Sprintf("**constant** `%s` = `%s`", word, constantValue(value))
+	} else if fieldType := s.fieldType(word); fieldType != "" {
+		contents = fmt.Sprintf("**field** `%s` `%s`", word, fieldType)
+		if eventType, err := s.event.GetFieldEventType(word); err == nil && eventType != "" {
+			contents += fmt.Sprintf("\n\nEvent type: `%s`", eventType)
+		}
+		if fieldDoc, ok := s.fieldDocs[word]; ok {
+			if fieldDoc.Definition != "" {
+				contents += "\n\n" + fieldDoc.Definition
+			}
+			if fieldDoc.Constants != "" {
+				contents += "\n\nConstants: " + fieldDoc.Constants
+			}
+		}
+	} else {
+		return nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: markupKindMarkdown, Value: contents},
+		Range:    &wordRange,
+	}
+}
+
+func (s *Server) definition(doc *document, pos Position) []Location {
+	expr, offset, ok := doc.expressionAt(pos)
+	if !ok {
+		return nil
+	}
+
+	start, end := wordAt(expr.value, offset)
+	if start == end {
+		return nil
+	}
+
+	macroDoc, macro := s.macro(expr.value[start:end], doc)
+	if macro == nil {
+		return nil
+	}
+	return []Location{{URI: macroDoc.uri, Range: macro.idRange}}
+}
@@ -0,0 +1,394 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lsp holds the language server of the SECL policies
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testURI = "file:///policies/test.policy"
+
+const testPolicy = `---
+version: 1.0.0
+macros:
+  - id: shadow_readers
+    expression: process.file.name in ["passwd", "chage"]
+rules:
+  - id: open_shadow
+    expression: open.file.path   in ["/etc/shadow","/etc/gshadow"] and not shadow_readers
+    actions:
+      - set:
+          name: shadow_opened
+          value: true
+          scope: process
+  - id: exec_after_open
+    expression: |
+      exec.file.name=="nc" and ${process.shadow_opened}
+`
+
+type testClient struct {
+	t      *testing.T
+	input  bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) send(method string, params interface{}, request bool) int {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if request {
+		c.nextID++
+		msg["id"] = c.nextID
+	}
+
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	fmt.Fprintf(&c.input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return c.nextID
+}
+
+func (c *testClient) open(uri, text string) {
+	c.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "yaml", "version": 1, "text": text},
+	}, false)
+}
+
+func (c *testClient) request(method string, line, character int) int {
+	return c.send(method, map[string]interface{}{
+		"textDocument": map[string]string{"uri": testURI},
+		"position":     map[string]int{"line": line, "character": character},
+	}, true)
+}
+
+type testMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// run serves the requests of the client and returns the messages sent by the server
+func (c *testClient) run(server *Server) []testMessage {
+	var output bytes.Buffer
+	require.NoError(c.t, server.Serve(&c.input, &output))
+
+	var messages []testMessage
+	reader := bufio.NewReader(&output)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(c.t, err)
+
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(c.t, err)
+
+		body := make([]byte, length)
+		_, err = io.ReadFull(reader, body)
+		require.NoError(c.t, err)
+
+		var msg testMessage
+		require.NoError(c.t, json.Unmarshal(body, &msg))
+		messages = append(messages, msg)
+	}
+}
+
+func resultOf(t *testing.T, messages []testMessage, id int, result interface{}) {
+	t.Helper()
+	for _, msg := range messages {
+		if msg.ID != nil && *msg.ID == id {
+			require.Nil(t, msg.Error)
+			require.NoError(t, json.Unmarshal(msg.Result, result))
+			return
+		}
+	}
+	t.Fatalf("no response to request %d", id)
+}
+
+func diagnostics(t *testing.T, messages []testMessage) []Diagnostic {
+	t.Helper()
+	var params publishDiagnosticsParams
+	for _, msg := range messages {
+		if msg.Method == "textDocument/publishDiagnostics" {
+			require.NoError(t, json.Unmarshal(msg.Params, &params))
+		}
+	}
+	return params.Diagnostics
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected []Diagnostic
+	}{
+		{
+			name:   "valid",
+			policy: testPolicy,
+		},
+		{
+			name:   "yaml",
+			policy: "rules:\n  - id: test\n   expression: open.file.path == \"/etc/shadow\"\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 0}, End: Position{Line: 0, Character: 6}}},
+			},
+		},
+		{
+			name:   "syntax",
+			policy: "rules:\n  - id: test\n    expression: open.file.path == \"/etc/shadow\" &&\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 2, Character: 50}, End: Position{Line: 2, Character: 50}}},
+			},
+		},
+		{
+			name:   "unknown field",
+			policy: "rules:\n  - id: test\n    expression: open.file.path == \"/etc/shadow\" && open.file.unknown == 1\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 2, Character: 51}, End: Position{Line: 2, Character: 68}}},
+			},
+		},
+		{
+			name:   "type mismatch in block",
+			policy: "rules:\n  - id: test\n    expression: >-\n      open.file.path == \"/etc/shadow\" &&\n      open.flags == \"O_RDONLY\"\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 4, Character: 20}, End: Position{Line: 4, Character: 30}}},
+			},
+		},
+		{
+			name:   "unknown macro",
+			policy: "rules:\n  - id: test\n    expression: unknown_macro && open.file.path == \"/etc/shadow\"\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 2, Character: 16}, End: Position{Line: 2, Character: 29}}},
+			},
+		},
+		{
+			name:   "rule without expression",
+			policy: "rules:\n  - id: test\n    description: no expression\n",
+			expected: []Diagnostic{
+				{Range: Range{Start: Position{Line: 1, Character: 8}, End: Position{Line: 1, Character: 12}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &testClient{t: t}
+			client.open(testURI, test.policy)
+
+			diags := diagnostics(t, client.run(NewServer(nil)))
+			require.Len(t, diags, len(test.expected), "%+v", diags)
+			for i, diag := range diags {
+				assert.Equal(t, test.expected[i].Range, diag.Range, diag.Message)
+				assert.Equal(t, severityError, diag.Severity)
+				assert.NotEmpty(t, diag.Message)
+			}
+		})
+	}
+}
+
+func TestServerCompletion(t *testing.T) {
+	client := &testClient{t: t}
+	client.open(testURI, testPolicy+"  - id: completion\n    expression: open.file.pa && shad && ${process.sh} && open.flags & O_RDO\n")
+	fieldID := client.request("textDocument/completion", 17, 28)
+	macroID := client.request("textDocument/completion", 17, 36)
+	variableID := client.request("textDocument/completion", 17, 52)
+	constantID := client.request("textDocument/completion", 17, 75)
+	noneID := client.request("textDocument/completion", 0, 1)
+	messages := client.run(NewServer(map[string]FieldDoc{"open.file.path": {Type: "string", Definition: "Path of the file"}}))
+
+	labels := func(items []CompletionItem) []string {
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	var items []CompletionItem
+	resultOf(t, messages, fieldID, &items)
+	assert.Contains(t, labels(items), "open.file.path")
+	assert.Contains(t, labels(items), "open.file.path.length")
+	for _, item := range items {
+		assert.True(t, strings.HasPrefix(item.Label, "open.file.pa"), item.Label)
+		if item.Label == "open.file.path" {
+			assert.Equal(t, completionKindField, item.Kind)
+			assert.Equal(t, "string", item.Detail)
+			assert.Equal(t, "Path of the file", item.Documentation.Value)
+			assert.Equal(t, &TextEdit{Range: Range{Start: Position{Line: 17, Character: 16}, End: Position{Line: 17, Character: 28}}, NewText: "open.file.path"}, item.TextEdit)
+		}
+	}
+
+	resultOf(t, messages, macroID, &items)
+	assert.Equal(t, []string{"shadow_readers"}, labels(items))
+	assert.Equal(t, completionKindFunction, items[0].Kind)
+
+	resultOf(t, messages, variableID, &items)
+	assert.Equal(t, []string{"process.shadow_opened"}, labels(items))
+	assert.Equal(t, completionKindVariable, items[0].Kind)
+
+	resultOf(t, messages, constantID, &items)
+	assert.Equal(t, []string{"O_RDONLY"}, labels(items))
+	assert.Equal(t, "0", items[0].Detail)
+
+	resultOf(t, messages, noneID, &items)
+	assert.Empty(t, items)
+}
+
+func TestServerHover(t *testing.T) {
+	client := &testClient{t: t}
+	client.open(testURI, testPolicy)
+	fieldID := client.request("textDocument/hover", 4, 20)
+	macroID := client.request("textDocument/hover", 7, 80)
+	blockID := client.request("textDocument/hover", 15, 8)
+	noneID := client.request("textDocument/hover", 1, 2)
+	messages := client.run(NewServer(map[string]FieldDoc{"process.file.name": {Type: "string", Definition: "Basename of the path of the process executable"}}))
+
+	var hover *Hover
+	resultOf(t, messages, fieldID, &hover)
+	require.NotNil(t, hover)
+	assert.Contains(t, hover.Contents.Value, "`process.file.name` `string`")
+	assert.Contains(t, hover.Contents.Value, "Basename of the path of the process executable")
+	assert.Equal(t, &Range{Start: Position{Line: 4, Character: 16}, End: Position{Line: 4, Character: 33}}, hover.Range)
+
+	resultOf(t, messages, macroID, &hover)
+	require.NotNil(t, hover)
+	assert.Contains(t, hover.Contents.Value, `process.file.name in ["passwd", "chage"]`)
+
+	resultOf(t, messages, blockID, &hover)
+	require.NotNil(t, hover)
+	assert.Contains(t, hover.Contents.Value, "`exec.file.name` `string`")
+	assert.Contains(t, hover.Contents.Value, "Event type: `exec`")
+
+	hover = &Hover{}
+	resultOf(t, messages, noneID, &hover)
+	assert.Nil(t, hover)
+}
+
+func TestServerDefinition(t *testing.T) {
+	client := &testClient{t: t}
+	client.open("file:///policies/macros.policy", "macros:\n  - id: shells\n    values: [\"bash\", \"sh\"]\n")
+	client.open(testURI, testPolicy+"  - id: shell\n    expression: exec.file.name in shells\n")
+	localID := client.request("textDocument/definition", 7, 80)
+	otherID := client.request("textDocument/definition", 17, 36)
+	noneID := client.request("textDocument/definition", 7, 20)
+	messages := client.run(NewServer(nil))
+
+	var locations []Location
+	resultOf(t, messages, localID, &locations)
+	assert.Equal(t, []Location{{URI: testURI, Range: Range{Start: Position{Line: 3, Character: 8}, End: Position{Line: 3, Character: 22}}}}, locations)
+
+	resultOf(t, messages, otherID, &locations)
+	assert.Equal(t, []Location{{URI: "file:///policies/macros.policy", Range: Range{Start: Position{Line: 1, Character: 8}, End: Position{Line: 1, Character: 14}}}}, locations)
+
+	resultOf(t, messages, noneID, &locations)
+	assert.Empty(t, locations)
+}
+
+func TestServerFormatting(t *testing.T) {
+	client := &testClient{t: t}
+	client.open(testURI, testPolicy)
+	id := client.send("textDocument/formatting", map[string]interface{}{
+		"textDocument": map[string]string{"uri": testURI},
+		"options":      map[string]interface{}{"tabSize": 2, "insertSpaces": true},
+	}, true)
+	unknownID := client.send("textDocument/unknown", map[string]interface{}{}, true)
+	messages := client.run(NewServer(nil))
+
+	var edits []TextEdit
+	resultOf(t, messages, id, &edits)
+	assert.Equal(t, []TextEdit{
+		{
+			Range:   Range{Start: Position{Line: 7, Character: 16}, End: Position{Line: 7, Character: 89}},
+			NewText: `open.file.path in ["/etc/shadow", "/etc/gshadow"] && !shadow_readers`,
+		},
+		{
+			Range:   Range{Start: Position{Line: 15, Character: 6}, End: Position{Line: 15, Character: 55}},
+			NewText: `exec.file.name == "nc" && ${process.shadow_opened}`,
+		},
+	}, edits)
+
+	var found bool
+	for _, msg := range messages {
+		if msg.ID != nil && *msg.ID == unknownID {
+			found = true
+			require.NotNil(t, msg.Error)
+			assert.Equal(t, codeMethodNotFound, msg.Error.Code)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestFormatDocument(t *testing.T) {
+	policy := `rules:
+  - id: plain
+    expression: open.file.path=="/etc/shadow" or open.file.path=="/etc/gshadow"
+  - id: quoted
+    expression: "open.file.path == \"/etc/shadow\"    and not open.file.name in [\"a\",\"b\"]"
+  - id: single_quoted
+    expression: 'open.file.name in [~"*.sh","*.py"]'  # comment
+  - id: plain_to_quoted
+    expression: not open.file.name == "a"   and open.flags & O_CREAT > 0
+  - id: multiline
+    expression: >-
+      open.file.path == "/etc/shadow"
+      and open.flags & O_CREAT > 0
+  - id: invalid
+    expression: open.file.path ==
+`
+
+	formatted, err := FormatDocument(policy)
+	require.NoError(t, err)
+	assert.Equal(t, `rules:
+  - id: plain
+    expression: open.file.path == "/etc/shadow" || open.file.path == "/etc/gshadow"
+  - id: quoted
+    expression: "open.file.path == \"/etc/shadow\" && !open.file.name in [\"a\", \"b\"]"
+  - id: single_quoted
+    expression: 'open.file.name in [~"*.sh", "*.py"]'  # comment
+  - id: plain_to_quoted
+    expression: "!open.file.name == \"a\" && open.flags & O_CREAT > 0"
+  - id: multiline
+    expression: >-
+      open.file.path == "/etc/shadow"
+      and open.flags & O_CREAT > 0
+  - id: invalid
+    expression: open.file.path ==
+`, formatted)
+
+	// formatting is idempotent
+	again, err := FormatDocument(formatted)
+	require.NoError(t, err)
+	assert.Equal(t, formatted, again)
+
+	_, err = FormatDocument("rules:\n - id: test\n  expression: true\n")
+	assert.Error(t, err)
+}
+
+func TestLoadFieldDocs(t *testing.T) {
+	docs, err := LoadFieldDocs("../../../../docs/cloud-workload-security/secl.json")
+	require.NoError(t, err)
+
+	doc, ok := docs["open.file.path"]
+	require.True(t, ok)
+	assert.Equal(t, "string", doc.Type)
+	assert.True(t, strings.Contains(doc.Definition, "ath"), doc.Definition)
+
+	doc, ok = docs["exec.file.name"]
+	require.True(t, ok)
+	assert.NotEmpty(t, doc.Definition)
+}
@@ -118,16 +118,17 @@ type SpanContext struct {
 
 // BaseEvent represents an event sent from the kernel
 type BaseEvent struct {
-	ID            string         `field:"-" event:"*"`
-	Type          uint32         `field:"-"`
-	Flags         uint32         `field:"-"`
-	TimestampRaw  uint64         `field:"event.timestamp,handler:ResolveEventTimestamp" event:"*"` // SECLDoc[event.timestamp] Definition:`Timestamp of the event`
-	Timestamp     time.Time      `field:"timestamp,opts:getters_only,handler:ResolveEventTime" event:"*"`
-	Rules         []*MatchedRule `field:"-"`
-	ActionReports []ActionReport `field:"-"`
-	Os            string         `field:"event.os" event:"*"`                             // SECLDoc[event.os] Definition:`Operating system of the event`
-	Origin        string         `field:"event.origin" event:"*"`                         // SECLDoc[event.origin] Definition:`Origin of the event`
-	Service       string         `field:"event.service,handler:ResolveService" event:"*"` // SECLDoc[event.service] Definition:`Service associated with the event`
+	ID             string         `field:"-" event:"*"`
+	Type           uint32         `field:"-"`
+	Flags          uint32         `field:"-"`
+	TimestampRaw   uint64         `field:"event.timestamp,handler:ResolveEventTimestamp" event:"*"` // SECLDoc[event.timestamp] Definition:`Timestamp of the event`
+	Timestamp      time.Time      `field:"timestamp,opts:getters_only,handler:ResolveEventTime" event:"*"`
+	Rules          []*MatchedRule `field:"-"`
+	ActionReports  []ActionReport `field:"-"`
+	SequenceEvents []interface{}  `field:"-"`                                              // events of the previous steps of a matched sequence rule
+	Os             string         `field:"event.os" event:"*"`                             // SECLDoc[event.os] Definition:`Operating system of the event`
+	Origin         string         `field:"event.origin" event:"*"`                         // SECLDoc[event.origin] Definition:`Origin of the event`
+	Service        string         `field:"event.service,handler:ResolveService" event:"*"` // SECLDoc[event.service] Definition:`Service associated with the event`
 
 	// context shared with all events
 	ProcessContext         *ProcessContext        `field:"process" event:"*"`
@@ -263,6 +264,11 @@ func (e *Event) GetActionReports() []ActionReport {
 	return e.ActionReports
 }
 
+// SetSequenceEvents sets the events of the previous steps of a matched sequence rule
+func (e *Event) SetSequenceEvents(events []interface{}) {
+	e.SequenceEvents = events
+}
+
 // GetWorkloadID returns an ID that represents the workload
 func (e *Event) GetWorkloadID() string {
 	return e.SecurityProfileContext.Name
@@ -21,30 +21,51 @@ type ActionName = string
 const (
 	// KillAction name a the kill action
 	KillAction ActionName = "kill"
+	// QuarantineFileAction name of the quarantine file action
+	QuarantineFileAction ActionName = "quarantine_file"
+	// IsolateAction name of the network isolation action
+	IsolateAction ActionName = "isolate"
+	// CoreDumpAction name of the core dump action
+	CoreDumpAction ActionName = "coredump"
 )
 
 // ActionDefinition describes a rule action section
 type ActionDefinition struct {
-	Filter *string         `yaml:"filter"`
-	Set    *SetDefinition  `yaml:"set"`
-	Kill   *KillDefinition `yaml:"kill"`
+	Filter         *string                   `yaml:"filter"`
+	Set            *SetDefinition            `yaml:"set"`
+	Kill           *KillDefinition           `yaml:"kill"`
+	QuarantineFile *QuarantineFileDefinition `yaml:"quarantine_file"`
+	Isolate        *IsolateDefinition        `yaml:"isolate"`
+	CoreDump       *CoreDumpDefinition       `yaml:"coredump"`
 
 	// internal
 	InternalCallback *InternalCallbackDefinition
 	FilterEvaluator  *eval.RuleEvaluator
 }
 
+// sections returns the number of sections specified in the action
+func (a *ActionDefinition) sections() int {
+	var count int
+	for _, specified := range []bool{a.Set != nil, a.InternalCallback != nil, a.Kill != nil, a.QuarantineFile != nil, a.Isolate != nil, a.CoreDump != nil} {
+		if specified {
+			count++
+		}
+	}
+	return count
+}
+
 // Check returns an error if the action in invalid
 func (a *ActionDefinition) Check(opts PolicyLoaderOpts) error {
-	if a.Set == nil && a.InternalCallback == nil && a.Kill == nil {
-		return errors.New("either 'set' or 'kill' section of an action must be specified")
+	switch a.sections() {
+	case 0:
+		return errors.New("either 'set', 'kill', 'quarantine_file', 'isolate' or 'coredump' section of an action must be specified")
+	case 1:
+	default:
+		return errors.New("only one of 'set', 'kill', 'quarantine_file', 'isolate' or 'coredump' section of an action can be specified")
 	}
 
-	if a.Set != nil {
-		if a.Kill != nil {
-			return errors.New("only of 'set' or 'kill' section of an action can be specified")
-		}
-
+	switch {
+	case a.Set != nil:
 		if a.Set.Name == "" {
 			return errors.New("action name is empty")
 		}
@@ -52,7 +73,7 @@ func (a *ActionDefinition) Check(opts PolicyLoaderOpts) error {
 		if (a.Set.Value == nil && a.Set.Field == "") || (a.Set.Value != nil && a.Set.Field != "") {
 			return errors.New("either 'value' or 'field' must be specified")
 		}
-	} else if a.Kill != nil {
+	case a.Kill != nil:
 		if opts.DisableEnforcement {
 			a.Kill = nil
 			return errors.New("'kill' action is disabled globally")
@@ -65,6 +86,31 @@ func (a *ActionDefinition) Check(opts PolicyLoaderOpts) error {
 		if _, found := model.SignalConstants[a.Kill.Signal]; !found {
 			return fmt.Errorf("unsupported signal '%s'", a.Kill.Signal)
 		}
+	case a.QuarantineFile != nil:
+		if opts.DisableEnforcement && !a.QuarantineFile.DryRun {
+			a.QuarantineFile = nil
+			return errors.New("'quarantine_file' action is disabled globally")
+		}
+	case a.Isolate != nil:
+		if opts.DisableEnforcement && !a.Isolate.DryRun {
+			a.Isolate = nil
+			return errors.New("'isolate' action is disabled globally")
+		}
+
+		switch a.Isolate.Scope {
+		case "", IsolateScopeContainer, IsolateScopeCGroup:
+		default:
+			return fmt.Errorf("unsupported isolation scope '%s'", a.Isolate.Scope)
+		}
+	case a.CoreDump != nil:
+		if opts.DisableEnforcement && !a.CoreDump.DryRun {
+			a.CoreDump = nil
+			return errors.New("'coredump' action is disabled globally")
+		}
+
+		if a.CoreDump.MaxSize < 0 {
+			return errors.New("the maximum size of the 'coredump' action can't be negative")
+		}
 	}
 
 	return nil
@@ -120,3 +166,31 @@ type KillDefinition struct {
 	Signal string `yaml:"signal"`
 	Scope  string `yaml:"scope"`
 }
+
+const (
+	// IsolateScopeContainer isolates the container of the process
+	IsolateScopeContainer = "container"
+	// IsolateScopeCGroup isolates the cgroup of the process
+	IsolateScopeCGroup = "cgroup"
+)
+
+// QuarantineFileDefinition describes the 'quarantine_file' section of a rule action
+type QuarantineFileDefinition struct {
+	// Field is the path field of the file to quarantine, the file of the event by default
+	Field  string `yaml:"field"`
+	DryRun bool   `yaml:"dry_run"`
+}
+
+// IsolateDefinition describes the 'isolate' section of a rule action
+type IsolateDefinition struct {
+	// Scope is either 'container', the default, or 'cgroup'
+	Scope  string `yaml:"scope"`
+	DryRun bool   `yaml:"dry_run"`
+}
+
+// CoreDumpDefinition describes the 'coredump' section of a rule action
+type CoreDumpDefinition struct {
+	// MaxSize is the maximum size of the captured memory, in bytes
+	MaxSize int  `yaml:"max_size"`
+	DryRun  bool `yaml:"dry_run"`
+}
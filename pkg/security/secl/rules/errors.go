@@ -46,6 +46,18 @@ var (
 	// ErrRuleAgentFilter is returned when an agent rule was filtered
 	ErrRuleAgentFilter = errors.New("agent rule filtered")
 
+	// ErrSequenceWithExpression is returned when a rule has both an expression and a sequence
+	ErrSequenceWithExpression = errors.New("a rule can't have both an expression and a sequence")
+
+	// ErrSequenceSteps is returned when a sequence has less than two steps
+	ErrSequenceSteps = errors.New("a sequence requires at least two steps")
+
+	// ErrSequenceWithoutJoin is returned when a sequence has no join field
+	ErrSequenceWithoutJoin = errors.New("no join field in the sequence definition")
+
+	// ErrSequenceWithoutWindow is returned when a sequence has no time window
+	ErrSequenceWithoutWindow = errors.New("no time window in the sequence definition")
+
 	// ErrNoRuleSetsInEvaluationSet is returned when no rule sets were provided to instantiate an evaluation set
 	ErrNoRuleSetsInEvaluationSet = errors.New("no rule sets provided to instantiate an evaluation set")
 
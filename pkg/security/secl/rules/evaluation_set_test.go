@@ -1006,6 +1006,47 @@ func TestEvaluationSet_LoadPolicies_DisableEnforcement(t *testing.T) {
 				assert.ErrorContains(t, err, "action is disabled")
 			},
 		},
+		{
+			name:               "remediation policy with enforcement disabled",
+			disableEnforcement: true,
+			args: args{
+				policy: &PolicyDef{
+					Rules: []*RuleDefinition{
+						{
+							ID:         "ruleA",
+							Expression: `exec.file.path == "/tmp/test"`,
+							Actions: []*ActionDefinition{
+								{
+									QuarantineFile: &QuarantineFileDefinition{},
+								}, {
+									Isolate: &IsolateDefinition{
+										Scope:  IsolateScopeContainer,
+										DryRun: true,
+									},
+								}, {
+									CoreDump: &CoreDumpDefinition{
+										MaxSize: 1024,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: func(t assert.TestingT, args args, got *EvaluationSet, msgs ...interface{}) {
+				rule := got.RuleSets[DefaultRuleSetTagValue].rules["ruleA"]
+				assert.NotNil(t, rule)
+
+				assert.Equal(t, 3, len(rule.Definition.Actions))
+				assert.Nil(t, rule.Definition.Actions[0].QuarantineFile)
+				assert.NotNil(t, rule.Definition.Actions[1].Isolate)
+				assert.Nil(t, rule.Definition.Actions[2].CoreDump)
+			},
+			wantErr: func(t assert.TestingT, err *multierror.Error, msgs ...interface{}) {
+				assert.ErrorContains(t, err, "'quarantine_file' action is disabled")
+				assert.ErrorContains(t, err, "'coredump' action is disabled")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -27,6 +27,7 @@ type Opts struct {
 	ReservedRuleIDs     []RuleID
 	EventTypeEnabled    map[eval.EventType]bool
 	StateScopes         map[Scope]VariableProviderFactory
+	EventSnapshotter    EventSnapshotter
 	Logger              log.Logger
 }
 
@@ -63,6 +64,12 @@ func (o *Opts) WithLogger(logger log.Logger) *Opts {
 	return o
 }
 
+// WithEventSnapshotter set the snapshotter of the events of the sequence steps
+func (o *Opts) WithEventSnapshotter(snapshotter EventSnapshotter) *Opts {
+	o.EventSnapshotter = snapshotter
+	return o
+}
+
 // WithStateScopes set state scopes
 func (o *Opts) WithStateScopes(stateScopes map[Scope]VariableProviderFactory) *Opts {
 	o.StateScopes = stateScopes
@@ -126,7 +126,7 @@ RULES:
 			continue
 		}
 
-		if ruleDef.Expression == "" && !ruleDef.Disabled && ruleDef.Combine == "" {
+		if ruleDef.Expression == "" && ruleDef.Sequence == nil && !ruleDef.Disabled && ruleDef.Combine == "" {
 			errs = multierror.Append(errs, &ErrRuleLoad{Definition: ruleDef, Err: ErrRuleWithoutExpression})
 			continue
 		}
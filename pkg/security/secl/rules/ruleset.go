@@ -117,6 +117,7 @@ type RuleDefinition struct {
 	Every                  time.Duration       `yaml:"every"`
 	Silent                 bool                `yaml:"silent"`
 	GroupID                string              `yaml:"group_id"`
+	Sequence               *SequenceDefinition `yaml:"sequence"`
 	Policy                 *Policy
 }
 
@@ -136,6 +137,7 @@ func applyOverride(rd1, rd2 *RuleDefinition) {
 	// for backward compatibility, by default only the expression is copied if no options
 	if len(rd2.OverrideOptions.Fields) == 0 {
 		rd1.Expression = rd2.Expression
+		rd1.Sequence = rd2.Sequence
 	} else if slices.Contains(rd2.OverrideOptions.Fields, OverrideAllFields) {
 		// keep the original policy
 		policy := rd1.Policy
@@ -145,6 +147,7 @@ func applyOverride(rd1, rd2 *RuleDefinition) {
 	} else {
 		if slices.Contains(rd2.OverrideOptions.Fields, OverrideExpressionField) {
 			rd1.Expression = rd2.Expression
+			rd1.Sequence = rd2.Sequence
 		}
 		if slices.Contains(rd2.OverrideOptions.Fields, OverrideActionFields) {
 			rd1.Actions = rd2.Actions
@@ -205,6 +208,12 @@ type RuleSet struct {
 	logger log.Logger
 	pool   *eval.ContextPool
 
+	// sequenceSteps maps the rules of the steps of the sequences to their
+	// sequence. evaluations counts the evaluated events, used to advance the
+	// pending matches of the sequences once per event.
+	sequenceSteps map[*Rule]*sequenceStep
+	evaluations   uint64
+
 	// event collector, used for tests
 	eventCollector EventCollector
 }
@@ -447,36 +456,67 @@ func (rs *RuleSet) AddRule(parsingContext *ast.ParsingContext, ruleDef *RuleDefi
 		tags = append(tags, k+":"+v)
 	}
 
+	if ruleDef.Sequence != nil {
+		return rs.addSequenceRule(parsingContext, ruleDef, tags)
+	}
+
 	rule := &Rule{
 		Rule:       eval.NewRule(ruleDef.ID, ruleDef.Expression, rs.evalOpts, tags...),
 		Definition: ruleDef,
 	}
 
+	if err := rs.compileRule(parsingContext, rule); err != nil {
+		return nil, err
+	}
+
+	if err := rs.compileRuleActions(parsingContext, rule); err != nil {
+		return nil, err
+	}
+
+	if err := rs.addRuleToBuckets(rule); err != nil {
+		return nil, err
+	}
+
+	rs.rules[ruleDef.ID] = rule
+
+	return rule.Rule, nil
+}
+
+// compileRule parses the expression of the rule and generates its evaluator
+func (rs *RuleSet) compileRule(parsingContext *ast.ParsingContext, rule *Rule) error {
+	ruleDef := rule.Definition
+
 	if err := rule.Parse(parsingContext); err != nil {
-		return nil, &ErrRuleLoad{Definition: ruleDef, Err: &ErrRuleSyntax{Err: err}}
+		return &ErrRuleLoad{Definition: ruleDef, Err: &ErrRuleSyntax{Err: err}}
 	}
 
 	if err := rule.GenEvaluator(rs.model, parsingContext); err != nil {
-		return nil, &ErrRuleLoad{Definition: ruleDef, Err: err}
+		return &ErrRuleLoad{Definition: ruleDef, Err: err}
 	}
 
 	eventType, err := GetRuleEventType(rule.Rule)
 	if err != nil {
-		return nil, &ErrRuleLoad{Definition: ruleDef, Err: err}
+		return &ErrRuleLoad{Definition: ruleDef, Err: err}
 	}
 
 	// ignore event types not supported
 	if _, exists := rs.opts.EventTypeEnabled["*"]; !exists {
 		if _, exists := rs.opts.EventTypeEnabled[eventType]; !exists {
-			return nil, &ErrRuleLoad{Definition: ruleDef, Err: ErrEventTypeNotEnabled}
+			return &ErrRuleLoad{Definition: ruleDef, Err: ErrEventTypeNotEnabled}
 		}
 	}
 
+	return nil
+}
+
+// compileRuleActions compiles the filters of the actions of the rule and the
+// evaluators of the fields they use
+func (rs *RuleSet) compileRuleActions(parsingContext *ast.ParsingContext, rule *Rule) error {
 	for _, action := range rule.Definition.Actions {
 		// compile action filter
 		if action.Filter != nil {
 			if err := action.CompileFilter(parsingContext, rs.model, rs.evalOpts); err != nil {
-				return nil, &ErrRuleLoad{Definition: ruleDef, Err: err}
+				return &ErrRuleLoad{Definition: rule.Definition, Err: err}
 			}
 		}
 
@@ -484,13 +524,18 @@ func (rs *RuleSet) AddRule(parsingContext *ast.ParsingContext, ruleDef *RuleDefi
 			if _, found := rs.fieldEvaluators[action.Set.Field]; !found {
 				evaluator, err := rs.model.GetEvaluator(action.Set.Field, "")
 				if err != nil {
-					return nil, err
+					return err
 				}
 				rs.fieldEvaluators[action.Set.Field] = evaluator
 			}
 		}
 	}
 
+	return nil
+}
+
+// addRuleToBuckets adds the rule to the buckets of its events
+func (rs *RuleSet) addRuleToBuckets(rule *Rule) error {
 	for _, event := range rule.GetEvaluator().EventTypes {
 		bucket, exists := rs.eventRuleBuckets[event]
 		if !exists {
@@ -499,16 +544,14 @@ func (rs *RuleSet) AddRule(parsingContext *ast.ParsingContext, ruleDef *RuleDefi
 		}
 
 		if err := bucket.AddRule(rule); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	// Merge the fields of the new rule with the existing list of fields of the ruleset
 	rs.AddFields(rule.GetEvaluator().GetFields())
 
-	rs.rules[ruleDef.ID] = rule
-
-	return rule.Rule, nil
+	return nil
 }
 
 // NotifyRuleMatch notifies all the ruleset listeners that an event matched a rule
@@ -693,20 +736,38 @@ func (rs *RuleSet) Evaluate(event eval.Event) bool {
 	}
 
 	result := false
+	rs.evaluations++
 
 	for _, rule := range bucket.rules {
 		utils.PprofDoWithoutContext(rule.GetPprofLabels(), func() {
 			if rule.GetEvaluator().Eval(ctx) {
+				matched := rule
+
+				var sequenceEvents []interface{}
+				if step := rs.sequenceStepOf(rule); step != nil {
+					events, completed := step.sequence.matchStep(step.index, event, ctx, rs.evaluations)
+					if !completed {
+						return
+					}
+					matched, sequenceEvents = step.sequence.rule, events
+				}
 
 				if rs.logger.IsTracing() {
-					rs.logger.Tracef("Rule `%s` matches with event `%s`\n", rule.ID, event)
+					rs.logger.Tracef("Rule `%s` matches with event `%s`\n", matched.ID, event)
 				}
 
-				if err := rs.runRuleActions(event, ctx, rule); err != nil {
+				if err := rs.runRuleActions(event, ctx, matched); err != nil {
 					rs.logger.Errorf("Error while executing rule actions: %s", err)
 				}
 
-				rs.NotifyRuleMatch(rule, event)
+				// the event carries the events of the previous steps while the
+				// listeners are notified of the match of the sequence
+				if sequenceEvent, ok := event.(SequenceEvent); ok && sequenceEvents != nil {
+					sequenceEvent.SetSequenceEvents(sequenceEvents)
+					defer sequenceEvent.SetSequenceEvents(nil)
+				}
+
+				rs.NotifyRuleMatch(matched, event)
 				result = true
 			}
 		})
@@ -802,5 +863,6 @@ func NewRuleSet(model eval.Model, eventCtor func() eval.Event, opts *Opts, evalO
 		pool:             eval.NewContextPool(),
 		fieldEvaluators:  make(map[string]eval.Evaluator),
 		scopedVariables:  make(map[Scope]VariableProvider),
+		sequenceSteps:    make(map[*Rule]*sequenceStep),
 	}
 }
@@ -0,0 +1,306 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package rules holds rules related files
+package rules
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/ast"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+)
+
+// defaultSequenceMaxStates is the default maximum number of pending matches of a sequence
+const defaultSequenceMaxStates = 1024
+
+// SequenceDefinition describes an ordered list of steps, each step matching a
+// single event. The events matching the steps are correlated by the values of
+// the JoinOn fields, and the sequence matches when all its steps matched in
+// order within the window, starting at the match of the first step.
+//
+// For array fields, such as `process.ancestors.pid`, a pending match is keyed
+// by the first value of the field for the event of the first step, and is
+// continued by the events having this value in their own values of the field.
+// Events with an empty string value for a join field aren't correlated.
+type SequenceDefinition struct {
+	Steps     []*SequenceStepDefinition `yaml:"steps"`
+	JoinOn    []string                  `yaml:"join_on"`
+	Window    time.Duration             `yaml:"window"`
+	MaxStates int                       `yaml:"max_states"`
+}
+
+// SequenceStepDefinition describes a step of a sequence
+type SequenceStepDefinition struct {
+	Expression string `yaml:"expression"`
+}
+
+// EventSnapshotter returns a copy of an event that can be retained after its
+// evaluation, used to keep the events of the steps of the pending sequences
+type EventSnapshotter func(event eval.Event) interface{}
+
+// SequenceEvent is implemented by the events which can carry the events of the
+// previous steps of a matched sequence
+type SequenceEvent interface {
+	SetSequenceEvents(events []interface{})
+}
+
+// sequenceStep links the rule of a step to its sequence
+type sequenceStep struct {
+	sequence *sequence
+	index    int
+}
+
+// sequenceState is a pending match of a sequence
+type sequenceState struct {
+	key     string
+	next    int
+	start   time.Time
+	events  []interface{}
+	updated uint64
+	element *list.Element
+}
+
+// sequence tracks the pending matches of a sequence rule
+type sequence struct {
+	rule           *Rule
+	steps          int
+	window         time.Duration
+	maxStates      int
+	joinEvaluators []eval.Evaluator
+	snapshot       EventSnapshotter
+
+	// states are indexed by key and ordered by start time
+	states map[string]*sequenceState
+	order  *list.List
+	now    func() time.Time
+}
+
+// joinValues returns the values of the join fields for the event, nil if one
+// of them has no value
+func (s *sequence) joinValues(ctx *eval.Context) [][]string {
+	values := make([][]string, 0, len(s.joinEvaluators))
+	for _, evaluator := range s.joinEvaluators {
+		var fieldValues []string
+		switch value := evaluator.Eval(ctx).(type) {
+		case string:
+			fieldValues = []string{value}
+		case []string:
+			fieldValues = value
+		case int:
+			fieldValues = []string{strconv.Itoa(value)}
+		case []int:
+			for _, i := range value {
+				fieldValues = append(fieldValues, strconv.Itoa(i))
+			}
+		default:
+			fieldValues = []string{fmt.Sprint(value)}
+		}
+
+		if len(fieldValues) == 0 || fieldValues[0] == "" {
+			return nil
+		}
+		values = append(values, fieldValues)
+	}
+	return values
+}
+
+// joinKeys returns the keys of all the combinations of the values
+func joinKeys(values [][]string) []string {
+	keys := []string{""}
+	for i, fieldValues := range values {
+		combined := make([]string, 0, len(keys)*len(fieldValues))
+		for _, key := range keys {
+			for _, value := range fieldValues {
+				if i > 0 {
+					combined = append(combined, key+"|"+value)
+				} else {
+					combined = append(combined, value)
+				}
+			}
+		}
+		keys = combined
+	}
+	return keys
+}
+
+func (s *sequence) remove(state *sequenceState) {
+	s.order.Remove(state.element)
+	delete(s.states, state.key)
+}
+
+// expire removes the pending matches older than the window
+func (s *sequence) expire(now time.Time) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		state := element.Value.(*sequenceState)
+		if now.Sub(state.start) <= s.window {
+			return
+		}
+		s.remove(state)
+	}
+}
+
+func (s *sequence) snapshotEvent(event eval.Event) interface{} {
+	if s.snapshot == nil {
+		return event
+	}
+	return s.snapshot(event)
+}
+
+// matchStep updates the pending matches with an event matching the step.
+// The events of the previous steps are returned when the event completes a
+// match of the sequence. An event advances a pending match once, even if it
+// matches several steps.
+func (s *sequence) matchStep(index int, event eval.Event, ctx *eval.Context, evaluation uint64) ([]interface{}, bool) {
+	values := s.joinValues(ctx)
+	if values == nil {
+		return nil, false
+	}
+
+	now := s.now()
+	s.expire(now)
+
+	if index == 0 {
+		key := joinKeys(firstValues(values))[0]
+		if _, exists := s.states[key]; exists {
+			return nil, false
+		}
+
+		if len(s.states) >= s.maxStates {
+			s.remove(s.order.Front().Value.(*sequenceState))
+		}
+
+		state := &sequenceState{
+			key:     key,
+			next:    1,
+			start:   now,
+			events:  []interface{}{s.snapshotEvent(event)},
+			updated: evaluation,
+		}
+		state.element = s.order.PushBack(state)
+		s.states[key] = state
+		return nil, false
+	}
+
+	for _, key := range joinKeys(values) {
+		state := s.states[key]
+		if state == nil || state.next != index || state.updated == evaluation {
+			continue
+		}
+
+		if index == s.steps-1 {
+			s.remove(state)
+			return state.events, true
+		}
+
+		state.events = append(state.events, s.snapshotEvent(event))
+		state.next++
+		state.updated = evaluation
+		return nil, false
+	}
+
+	return nil, false
+}
+
+// sequenceStepOf returns the sequence step of the rule, nil if it isn't the
+// rule of a step
+func (rs *RuleSet) sequenceStepOf(rule *Rule) *sequenceStep {
+	if rule.Definition.Sequence == nil {
+		return nil
+	}
+	return rs.sequenceSteps[rule]
+}
+
+func firstValues(values [][]string) [][]string {
+	first := make([][]string, 0, len(values))
+	for _, fieldValues := range values {
+		first = append(first, fieldValues[:1])
+	}
+	return first
+}
+
+// addSequenceRule compiles the steps of a sequence rule and adds them to the
+// buckets of their events. The rule of the sequence is notified when the last
+// step completes a match.
+func (rs *RuleSet) addSequenceRule(parsingContext *ast.ParsingContext, ruleDef *RuleDefinition, tags []string) (*eval.Rule, error) {
+	def := ruleDef.Sequence
+
+	switch {
+	case ruleDef.Expression != "":
+		return nil, &ErrRuleLoad{Definition: ruleDef, Err: ErrSequenceWithExpression}
+	case len(def.Steps) < 2:
+		return nil, &ErrRuleLoad{Definition: ruleDef, Err: ErrSequenceSteps}
+	case len(def.JoinOn) == 0:
+		return nil, &ErrRuleLoad{Definition: ruleDef, Err: ErrSequenceWithoutJoin}
+	case def.Window <= 0:
+		return nil, &ErrRuleLoad{Definition: ruleDef, Err: ErrSequenceWithoutWindow}
+	}
+
+	seq := &sequence{
+		steps:     len(def.Steps),
+		window:    def.Window,
+		maxStates: def.MaxStates,
+		snapshot:  rs.opts.EventSnapshotter,
+		states:    make(map[string]*sequenceState),
+		order:     list.New(),
+		now:       time.Now,
+	}
+	if seq.maxStates <= 0 {
+		seq.maxStates = defaultSequenceMaxStates
+	}
+
+	for _, field := range def.JoinOn {
+		evaluator, err := rs.model.GetEvaluator(field, "")
+		if err != nil {
+			return nil, &ErrRuleLoad{Definition: ruleDef, Err: fmt.Errorf("invalid join field `%s`: %w", field, err)}
+		}
+		seq.joinEvaluators = append(seq.joinEvaluators, evaluator)
+	}
+
+	steps := make([]*Rule, 0, len(def.Steps))
+	for i, stepDef := range def.Steps {
+		if strings.TrimSpace(stepDef.Expression) == "" {
+			return nil, &ErrRuleLoad{Definition: ruleDef, Err: fmt.Errorf("step %d: %w", i+1, ErrRuleWithoutExpression)}
+		}
+
+		step := &Rule{
+			Rule:       eval.NewRule(fmt.Sprintf("%s#%d", ruleDef.ID, i+1), stepDef.Expression, rs.evalOpts, tags...),
+			Definition: ruleDef,
+		}
+		if err := rs.compileRule(parsingContext, step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+
+	// the rule of the sequence is notified with the event of the last step
+	rule := &Rule{
+		Rule:       eval.NewRule(ruleDef.ID, def.Steps[len(def.Steps)-1].Expression, rs.evalOpts, tags...),
+		Definition: ruleDef,
+	}
+	if err := rs.compileRule(parsingContext, rule); err != nil {
+		return nil, err
+	}
+	if err := rs.compileRuleActions(parsingContext, rule); err != nil {
+		return nil, err
+	}
+	seq.rule = rule
+
+	for i, step := range steps {
+		if err := rs.addRuleToBuckets(step); err != nil {
+			return nil, err
+		}
+		rs.sequenceSteps[step] = &sequenceStep{sequence: seq, index: i}
+	}
+	rs.AddFields(def.JoinOn)
+
+	rs.rules[ruleDef.ID] = rule
+
+	return rule.Rule, nil
+}
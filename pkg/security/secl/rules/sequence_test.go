@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package rules holds rules related files
+package rules
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/ast"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+const testSequencePolicy = `---
+rules:
+  - id: download_and_exec
+    sequence:
+      steps:
+        - expression: open.file.path =~ "/tmp/*"
+        - expression: exec.file.path =~ "/tmp/*"
+      join_on: [container.id]
+      window: 30s
+      max_states: 2
+`
+
+type sequenceListener struct {
+	matches []string
+	events  [][]interface{}
+}
+
+func (l *sequenceListener) RuleMatch(rule *Rule, event eval.Event) bool {
+	l.matches = append(l.matches, rule.ID)
+	l.events = append(l.events, event.(*model.Event).SequenceEvents)
+	return true
+}
+
+func (l *sequenceListener) EventDiscarderFound(_ *RuleSet, _ eval.Event, _ string, _ eval.EventType) {
+}
+
+func newSequenceRuleSet(t *testing.T) (*RuleSet, *sequenceListener, *time.Time) {
+	policy, err := LoadPolicy("sequence", PolicyProviderTypeDir, strings.NewReader(testSequencePolicy), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs := newRuleSet()
+	if err := rs.AddRules(ast.NewParsingContext(), policy.Rules); err != nil {
+		t.Fatal(err)
+	}
+
+	listener := &sequenceListener{}
+	rs.AddListener(listener)
+
+	now := time.Now()
+	for _, step := range rs.sequenceSteps {
+		step.sequence.now = func() time.Time { return now }
+	}
+
+	return rs, listener, &now
+}
+
+func newSequenceEvent(eventType string, containerID string, path string) *model.Event {
+	event := model.NewFakeEvent()
+	event.Type = uint32(model.ExecEventType)
+	field := "exec.file.path"
+	if eventType == "open" {
+		event.Type = uint32(model.FileOpenEventType)
+		field = "open.file.path"
+	}
+	event.SetFieldValue("container.id", containerID)
+	event.SetFieldValue(field, path)
+	return event
+}
+
+func TestSequenceRule(t *testing.T) {
+	rs, listener, _ := newSequenceRuleSet(t)
+
+	assert.Equal(t, []string{"download_and_exec"}, rs.ListRuleIDs())
+
+	open := newSequenceEvent("open", "abc", "/tmp/payload")
+	assert.False(t, rs.Evaluate(open))
+
+	// another container doesn't continue the sequence
+	assert.False(t, rs.Evaluate(newSequenceEvent("exec", "def", "/tmp/payload")))
+	assert.Empty(t, listener.matches)
+
+	exec := newSequenceEvent("exec", "abc", "/tmp/payload")
+	assert.True(t, rs.Evaluate(exec))
+	assert.Equal(t, []string{"download_and_exec"}, listener.matches)
+	assert.Equal(t, [][]interface{}{{open}}, listener.events)
+	assert.Nil(t, exec.SequenceEvents)
+
+	// the pending match was consumed
+	assert.False(t, rs.Evaluate(newSequenceEvent("exec", "abc", "/tmp/payload")))
+}
+
+func TestSequenceRuleWindow(t *testing.T) {
+	rs, listener, now := newSequenceRuleSet(t)
+
+	assert.False(t, rs.Evaluate(newSequenceEvent("open", "abc", "/tmp/payload")))
+
+	*now = now.Add(time.Minute)
+	assert.False(t, rs.Evaluate(newSequenceEvent("exec", "abc", "/tmp/payload")))
+	assert.Empty(t, listener.matches)
+}
+
+func TestSequenceRuleMaxStates(t *testing.T) {
+	rs, listener, _ := newSequenceRuleSet(t)
+
+	for _, containerID := range []string{"a", "b", "c"} {
+		assert.False(t, rs.Evaluate(newSequenceEvent("open", containerID, "/tmp/payload")))
+	}
+
+	// the oldest pending match was evicted
+	assert.False(t, rs.Evaluate(newSequenceEvent("exec", "a", "/tmp/payload")))
+	assert.True(t, rs.Evaluate(newSequenceEvent("exec", "c", "/tmp/payload")))
+	assert.Equal(t, []string{"download_and_exec"}, listener.matches)
+}
+
+func TestSequenceRuleErrors(t *testing.T) {
+	steps := []*SequenceStepDefinition{
+		{Expression: `open.file.path == "/tmp/a"`},
+		{Expression: `exec.file.path == "/tmp/a"`},
+	}
+
+	tests := []struct {
+		name     string
+		sequence *SequenceDefinition
+		expr     string
+		err      error
+	}{
+		{
+			name:     "expression",
+			sequence: &SequenceDefinition{Steps: steps, JoinOn: []string{"container.id"}, Window: time.Second},
+			expr:     `open.file.path == "/tmp/a"`,
+			err:      ErrSequenceWithExpression,
+		},
+		{
+			name:     "single step",
+			sequence: &SequenceDefinition{Steps: steps[:1], JoinOn: []string{"container.id"}, Window: time.Second},
+			err:      ErrSequenceSteps,
+		},
+		{
+			name:     "no join",
+			sequence: &SequenceDefinition{Steps: steps, Window: time.Second},
+			err:      ErrSequenceWithoutJoin,
+		},
+		{
+			name:     "no window",
+			sequence: &SequenceDefinition{Steps: steps, JoinOn: []string{"container.id"}},
+			err:      ErrSequenceWithoutWindow,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs := newRuleSet()
+			_, err := rs.AddRule(ast.NewParsingContext(), &RuleDefinition{
+				ID:         "sequence",
+				Expression: test.expr,
+				Sequence:   test.sequence,
+			})
+			var errRuleLoad *ErrRuleLoad
+			if assert.True(t, errors.As(err, &errRuleLoad), "unexpected error: %v", err) {
+				assert.Equal(t, test.err, errRuleLoad.Err)
+			}
+		})
+	}
+}
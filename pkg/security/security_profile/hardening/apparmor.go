@@ -0,0 +1,245 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package hardening holds the generators of enforceable profiles (seccomp, AppArmor, Kubernetes network policies)
+// computed from the activity recorded in an activity dump or a security profile
+package hardening
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+)
+
+var (
+	// appArmorProfileName is the set of characters allowed in the name of a profile
+	appArmorProfileName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+	// appArmorNetworkFamilies maps the socket families to the AppArmor network domains
+	appArmorNetworkFamilies = map[string]string{
+		"AF_INET":    "inet",
+		"AF_INET6":   "inet6",
+		"AF_UNIX":    "unix",
+		"AF_NETLINK": "netlink",
+		"AF_PACKET":  "packet",
+	}
+)
+
+// AppArmorOptions defines the options of the AppArmor profile generation
+type AppArmorOptions struct {
+	// Name is the name of the profile
+	Name string
+}
+
+// AppArmorFileRule is a file rule of an AppArmor profile
+type AppArmorFileRule struct {
+	Path        string
+	Permissions string
+}
+
+// String returns the AppArmor syntax of the rule
+func (r AppArmorFileRule) String() string {
+	path := r.Path
+	if strings.ContainsAny(path, " \t\"") {
+		path = `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+	}
+	return fmt.Sprintf("%s %s,", path, r.Permissions)
+}
+
+// AppArmorProfile is an AppArmor profile
+type AppArmorProfile struct {
+	Name      string
+	Network   []string
+	FileRules []AppArmorFileRule
+}
+
+// GenerateAppArmorProfile returns an AppArmor profile allowing the files and network domains used by the processes
+// of the activity tree. The paths are collapsed into globs with the paths reducer of the activity trees.
+func GenerateAppArmorProfile(tree *activity_tree.ActivityTree, opts AppArmorOptions) (*AppArmorProfile, error) {
+	if !appArmorProfileName.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid AppArmor profile name `%s`", opts.Name)
+	}
+
+	var (
+		reducer     = activity_tree.NewPathsReducer()
+		permissions = make(map[string]map[byte]bool)
+		network     = make(map[string]bool)
+	)
+
+	addPermissions := func(path string, perms string) {
+		if path == "" {
+			return
+		}
+		if permissions[path] == nil {
+			permissions[path] = make(map[byte]bool)
+		}
+		for i := 0; i < len(perms); i++ {
+			permissions[path][perms[i]] = true
+		}
+	}
+
+	walkProcessNodes(tree, func(node *activity_tree.ProcessNode) {
+		// executed binaries inherit the profile
+		addPermissions(node.Process.FileEvent.PathnameStr, "rix")
+		if node.Process.LinuxBinprm.FileEvent.PathnameStr != "" {
+			addPermissions(node.Process.LinuxBinprm.FileEvent.PathnameStr, "r")
+		}
+
+		var walkFiles func(prefix string, files map[string]*activity_tree.FileNode)
+		walkFiles = func(prefix string, files map[string]*activity_tree.FileNode) {
+			for name, file := range files {
+				path := prefix + "/" + name
+				if file.Open != nil || (file.File != nil && len(file.Children) == 0) {
+					addPermissions(reducer.ReducePath(path, file.File, node), filePermissions(path, file))
+				}
+				walkFiles(path, file.Children)
+			}
+		}
+		walkFiles("", node.Files)
+
+		for _, socket := range node.Sockets {
+			if domain, ok := appArmorNetworkFamilies[socket.Family]; ok {
+				network[domain] = true
+			}
+		}
+
+		// the workload connects to the resolved domains with unknown protocols
+		if len(node.DNSNames) > 0 {
+			network["inet"] = true
+			network["inet6"] = true
+		}
+	})
+
+	profile := &AppArmorProfile{
+		Name:    opts.Name,
+		Network: sortedKeys(network),
+	}
+	for _, path := range sortedKeys(toSet(permissions)) {
+		profile.FileRules = append(profile.FileRules, AppArmorFileRule{
+			Path:        path,
+			Permissions: formatPermissions(permissions[path]),
+		})
+	}
+
+	return profile, nil
+}
+
+func toSet[V any](m map[string]V) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+	return set
+}
+
+// filePermissions returns the AppArmor permissions of a file from the flags of its open events
+func filePermissions(path string, file *activity_tree.FileNode) string {
+	perms := "r"
+	if file.Open != nil && file.Open.Flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_CREAT|syscall.O_TRUNC|syscall.O_APPEND) != 0 {
+		perms += "w"
+	}
+	// shared libraries are memory mapped with PROT_EXEC
+	if strings.HasSuffix(path, ".so") || strings.Contains(path, ".so.") {
+		perms += "m"
+	}
+	return perms
+}
+
+// formatPermissions returns the permissions in the order used by the AppArmor documentation
+func formatPermissions(perms map[byte]bool) string {
+	var b strings.Builder
+	for _, perm := range []byte("rwamix") {
+		if perms[perm] {
+			b.WriteByte(perm)
+		}
+	}
+	return b.String()
+}
+
+// Encode returns the text of the profile
+func (p *AppArmorProfile) Encode() []byte {
+	var b bytes.Buffer
+	b.WriteString("#include <tunables/global>\n\n")
+	fmt.Fprintf(&b, "profile %s flags=(attach_disconnected,mediate_deleted) {\n", p.Name)
+	b.WriteString("  #include <abstractions/base>\n")
+
+	if len(p.Network) > 0 {
+		b.WriteString("\n")
+		for _, domain := range p.Network {
+			fmt.Fprintf(&b, "  network %s,\n", domain)
+		}
+	}
+
+	if len(p.FileRules) > 0 {
+		b.WriteString("\n")
+		for _, rule := range p.FileRules {
+			fmt.Fprintf(&b, "  %s\n", rule)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// DiffAppArmorProfile compares a generated AppArmor profile with the text of a prior profile
+func DiffAppArmorProfile(prior []byte, current *AppArmorProfile) (*Diff, error) {
+	priorEntries, err := appArmorEntries(prior)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the prior AppArmor profile: %w", err)
+	}
+	currentEntries, err := appArmorEntries(current.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return diffEntries(priorEntries, currentEntries), nil
+}
+
+// appArmorEntries returns the rules of the body of a profile
+func appArmorEntries(profile []byte) ([]string, error) {
+	var (
+		entries []string
+		depth   int
+		found   bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(profile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasSuffix(line, "{"):
+			depth++
+			found = true
+			continue
+		case line == "}":
+			depth--
+			continue
+		case depth == 0 || line == "":
+			continue
+		case strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include"):
+			// comment
+			continue
+		}
+
+		entries = append(entries, strings.Join(strings.Fields(line), " "))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no profile found")
+	}
+
+	sort.Strings(entries)
+	return entries, nil
+}
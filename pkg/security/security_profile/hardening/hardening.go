@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package hardening holds the generators of enforceable profiles (seccomp, AppArmor, Kubernetes network policies)
+// computed from the activity recorded in an activity dump or a security profile
+package hardening
+
+import (
+	"sort"
+	"strings"
+
+	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+)
+
+// Diff lists the entries of a generated profile added or removed compared to a prior profile
+type Diff struct {
+	Added   []string
+	Removed []string
+}
+
+// IsEmpty returns true if the generated profile is equivalent to the prior profile
+func (d *Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// String returns the diff with one entry per line, prefixed by + or -
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, entry := range d.Removed {
+		b.WriteString("- " + entry + "\n")
+	}
+	for _, entry := range d.Added {
+		b.WriteString("+ " + entry + "\n")
+	}
+	return b.String()
+}
+
+func diffEntries(prior []string, current []string) *Diff {
+	priorSet := make(map[string]bool, len(prior))
+	for _, entry := range prior {
+		priorSet[entry] = true
+	}
+	currentSet := make(map[string]bool, len(current))
+	for _, entry := range current {
+		currentSet[entry] = true
+	}
+
+	diff := &Diff{}
+	for entry := range currentSet {
+		if !priorSet[entry] {
+			diff.Added = append(diff.Added, entry)
+		}
+	}
+	for entry := range priorSet {
+		if !currentSet[entry] {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// walkProcessNodes calls the callback on every process node of the tree
+func walkProcessNodes(tree *activity_tree.ActivityTree, callback func(node *activity_tree.ProcessNode)) {
+	var walk func(nodes []*activity_tree.ProcessNode)
+	walk = func(nodes []*activity_tree.ProcessNode) {
+		for _, node := range nodes {
+			callback(node)
+			walk(node.Children)
+		}
+	}
+	walk(tree.ProcessNodes)
+}
+
+// dnsNames returns the sorted list of the domain names queried by the processes of the tree
+func dnsNames(tree *activity_tree.ActivityTree) []string {
+	names := make(map[string]bool)
+	walkProcessNodes(tree, func(node *activity_tree.ProcessNode) {
+		for name := range node.DNSNames {
+			names[name] = true
+		}
+	})
+	return sortedKeys(names)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,288 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package hardening holds the generators of enforceable profiles (seccomp, AppArmor, Kubernetes network policies)
+// computed from the activity recorded in an activity dump or a security profile
+package hardening
+
+import (
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+func newFileNode(name string, flags uint32, children ...*activity_tree.FileNode) *activity_tree.FileNode {
+	node := &activity_tree.FileNode{
+		Name:     name,
+		File:     &model.FileEvent{},
+		Children: make(map[string]*activity_tree.FileNode),
+	}
+	if len(children) == 0 {
+		node.Open = &activity_tree.OpenNode{Flags: flags}
+	}
+	for _, child := range children {
+		node.Children[child.Name] = child
+	}
+	return node
+}
+
+func newTestTree() *activity_tree.ActivityTree {
+	curl := &activity_tree.ProcessNode{
+		Process: model.Process{
+			PIDContext: model.PIDContext{Pid: 43},
+			FileEvent:  model.FileEvent{PathnameStr: "/usr/bin/curl"},
+		},
+		Files: map[string]*activity_tree.FileNode{
+			"proc": newFileNode("proc", 0, newFileNode("43", 0, newFileNode("status", syscall.O_RDONLY))),
+			"lib":  newFileNode("lib", 0, newFileNode("libcurl.so.4", syscall.O_RDONLY)),
+		},
+		DNSNames: map[string]*activity_tree.DNSNode{
+			"datadoghq.com": {},
+		},
+		Sockets: []*activity_tree.SocketNode{
+			{Family: "AF_INET6"},
+		},
+		Syscalls: []int{int(model.SysConnect), int(model.SysRead)},
+	}
+
+	server := &activity_tree.ProcessNode{
+		Process: model.Process{
+			PIDContext: model.PIDContext{Pid: 42},
+			FileEvent:  model.FileEvent{PathnameStr: "/usr/bin/server"},
+		},
+		Files: map[string]*activity_tree.FileNode{
+			"etc": newFileNode("etc", 0, newFileNode("passwd", syscall.O_RDONLY)),
+			"var": newFileNode("var", 0, newFileNode("log", 0, newFileNode("server.log", syscall.O_WRONLY|syscall.O_APPEND))),
+		},
+		Sockets: []*activity_tree.SocketNode{
+			{
+				Family: "AF_INET",
+				Bind: []*activity_tree.BindNode{
+					{Port: 8080, IP: "0.0.0.0"},
+					{Port: 9090, IP: "127.0.0.1"},
+				},
+			},
+		},
+		Syscalls: []int{int(model.SysRead), int(model.SysRtSigaction), int(model.SysBind)},
+		Children: []*activity_tree.ProcessNode{curl},
+	}
+
+	return &activity_tree.ActivityTree{
+		ProcessNodes: []*activity_tree.ProcessNode{server},
+	}
+}
+
+func TestSyscallName(t *testing.T) {
+	name, ok := syscallName(model.SysRtSigaction)
+	assert.True(t, ok)
+	assert.Equal(t, "rt_sigaction", name)
+
+	name, ok = syscallName(model.SysRead)
+	assert.True(t, ok)
+	assert.Equal(t, "read", name)
+
+	_, ok = syscallName(model.Syscall(10000))
+	assert.False(t, ok)
+}
+
+func TestSeccompProfile(t *testing.T) {
+	profile, err := GenerateSeccompProfile(newTestTree(), SeccompOptions{ExtraSyscalls: []string{"exit_group"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, specs.ActErrno, profile.DefaultAction)
+	assert.Equal(t, seccompArchitectures[utils.RuntimeArch()], profile.Architectures)
+	if assert.Len(t, profile.Syscalls, 1) {
+		assert.Equal(t, specs.ActAllow, profile.Syscalls[0].Action)
+		assert.Equal(t, []string{"bind", "connect", "exit_group", "read", "rt_sigaction"}, profile.Syscalls[0].Names)
+	}
+
+	t.Run("no-syscall", func(t *testing.T) {
+		_, err := GenerateSeccompProfile(&activity_tree.ActivityTree{}, SeccompOptions{})
+		assert.ErrorIs(t, err, ErrNoSyscalls)
+	})
+
+	t.Run("other-arch", func(t *testing.T) {
+		arch := "arm64"
+		if utils.RuntimeArch() == "arm64" {
+			arch = "x64"
+		}
+		_, err := GenerateSeccompProfile(newTestTree(), SeccompOptions{Arch: arch})
+		assert.Error(t, err)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		prior := *profile
+		prior.Syscalls = []specs.LinuxSyscall{{Names: []string{"read", "write"}, Action: specs.ActAllow}}
+		data, err := EncodeSeccompProfile(&prior)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		diff, err := DiffSeccompProfile(data, profile)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"bind SCMP_ACT_ALLOW", "connect SCMP_ACT_ALLOW", "exit_group SCMP_ACT_ALLOW", "rt_sigaction SCMP_ACT_ALLOW"}, diff.Added)
+		assert.Equal(t, []string{"write SCMP_ACT_ALLOW"}, diff.Removed)
+
+		data, _ = EncodeSeccompProfile(profile)
+		diff, err = DiffSeccompProfile(data, profile)
+		assert.NoError(t, err)
+		assert.True(t, diff.IsEmpty())
+	})
+}
+
+func TestAppArmorProfile(t *testing.T) {
+	profile, err := GenerateAppArmorProfile(newTestTree(), AppArmorOptions{Name: "server"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"inet", "inet6"}, profile.Network)
+	assert.Equal(t, []AppArmorFileRule{
+		{Path: "/etc/passwd", Permissions: "r"},
+		{Path: "/lib/libcurl.so.4", Permissions: "rm"},
+		{Path: "/proc/self/status", Permissions: "r"},
+		{Path: "/usr/bin/curl", Permissions: "rix"},
+		{Path: "/usr/bin/server", Permissions: "rix"},
+		{Path: "/var/log/server.log", Permissions: "rw"},
+	}, profile.FileRules)
+
+	text := string(profile.Encode())
+	assert.True(t, strings.HasPrefix(text, "#include <tunables/global>\n"))
+	assert.Contains(t, text, "profile server flags=(attach_disconnected,mediate_deleted) {\n")
+	assert.Contains(t, text, "  network inet6,\n")
+	assert.Contains(t, text, "  /var/log/server.log rw,\n")
+
+	t.Run("invalid-name", func(t *testing.T) {
+		_, err := GenerateAppArmorProfile(newTestTree(), AppArmorOptions{Name: "my profile"})
+		assert.Error(t, err)
+	})
+
+	t.Run("quoted-path", func(t *testing.T) {
+		assert.Equal(t, `"/tmp/a b" r,`, AppArmorFileRule{Path: "/tmp/a b", Permissions: "r"}.String())
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		prior := `#include <tunables/global>
+
+# generated from a previous dump
+profile server flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+  # network access
+  network inet,
+  network inet6,
+
+  /etc/passwd   r,
+  /etc/shadow r,
+  /lib/libcurl.so.4 rm,
+  /proc/self/status r,
+  /usr/bin/curl rix,
+  /usr/bin/server rix,
+  /var/log/server.log r,
+}
+`
+		diff, err := DiffAppArmorProfile([]byte(prior), profile)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"/var/log/server.log rw,"}, diff.Added)
+		assert.Equal(t, []string{"/etc/shadow r,", "/var/log/server.log r,"}, diff.Removed)
+		assert.Equal(t, "- /etc/shadow r,\n- /var/log/server.log r,\n+ /var/log/server.log rw,\n", diff.String())
+
+		_, err = DiffAppArmorProfile([]byte("network inet,\n"), profile)
+		assert.Error(t, err)
+	})
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	opts := NetworkPolicyOptions{
+		Name:        "server",
+		Namespace:   "default",
+		PodSelector: map[string]string{"app": "server"},
+	}
+
+	policy, err := GenerateNetworkPolicy(newTestTree(), opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "datadoghq.com", policy.Annotations[ObservedDNSNamesAnnotation])
+	assert.Equal(t, []string{
+		"egress to namespace kubernetes.io/metadata.name=kube-system pod k8s-app=kube-dns port TCP/53",
+		"egress to namespace kubernetes.io/metadata.name=kube-system pod k8s-app=kube-dns port UDP/53",
+		"ingress from any port TCP/8080",
+		"ingress from any port UDP/8080",
+		"policy type Egress",
+		"policy type Ingress",
+	}, networkPolicyEntries(policy))
+
+	t.Run("resolver", func(t *testing.T) {
+		opts := opts
+		opts.Resolver = func(name string) ([]net.IP, error) {
+			assert.Equal(t, "datadoghq.com", name)
+			return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")}, nil
+		}
+
+		policy, err := GenerateNetworkPolicy(newTestTree(), opts)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, policy.Spec.Egress, 2) {
+			assert.Equal(t, "10.0.0.1/32", policy.Spec.Egress[1].To[0].IPBlock.CIDR)
+			assert.Equal(t, "2001:db8::1/128", policy.Spec.Egress[1].To[1].IPBlock.CIDR)
+			assert.Empty(t, policy.Spec.Egress[1].Ports)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		data, err := EncodeNetworkPolicy(policy)
+		if !assert.NoError(t, err) {
+			return
+		}
+		diff, err := DiffNetworkPolicy(data, policy)
+		assert.NoError(t, err)
+		assert.True(t, diff.IsEmpty())
+
+		prior := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: server
+spec:
+  podSelector:
+    matchLabels:
+      app: server
+  policyTypes:
+  - Ingress
+  ingress:
+  - ports:
+    - port: 8080
+    - port: 8443
+`
+		diff, err = DiffNetworkPolicy([]byte(prior), policy)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{
+			"egress to namespace kubernetes.io/metadata.name=kube-system pod k8s-app=kube-dns port TCP/53",
+			"egress to namespace kubernetes.io/metadata.name=kube-system pod k8s-app=kube-dns port UDP/53",
+			"ingress from any port UDP/8080",
+			"policy type Egress",
+		}, diff.Added)
+		assert.Equal(t, []string{"ingress from any port TCP/8443"}, diff.Removed)
+	})
+}
@@ -0,0 +1,261 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package hardening holds the generators of enforceable profiles (seccomp, AppArmor, Kubernetes network policies)
+// computed from the activity recorded in an activity dump or a security profile
+package hardening
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+)
+
+const (
+	// ObservedDNSNamesAnnotation is the annotation listing the domain names queried by the workload
+	ObservedDNSNamesAnnotation = "security.datadoghq.com/observed-dns-names"
+
+	dnsPort = 53
+)
+
+var (
+	// kubeDNSPeer selects the cluster DNS pods
+	kubeDNSPeer = networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"},
+		},
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+		},
+	}
+)
+
+// NetworkPolicyOptions defines the options of the network policy generation
+type NetworkPolicyOptions struct {
+	// Name is the name of the network policy
+	Name string
+	// Namespace is the namespace of the network policy
+	Namespace string
+	// PodSelector selects the pods of the workload
+	PodSelector map[string]string
+	// Resolver resolves the queried domain names to allow egress traffic to their addresses. When it isn't set, the
+	// domain names are only listed in an annotation of the policy.
+	Resolver func(name string) ([]net.IP, error)
+}
+
+// GenerateNetworkPolicy returns a Kubernetes network policy allowing the ingress traffic to the ports bound by the
+// workload, and the egress traffic to the cluster DNS and to the addresses of the queried domain names.
+func GenerateNetworkPolicy(tree *activity_tree.ActivityTree, opts NetworkPolicyOptions) (*networkingv1.NetworkPolicy, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("a network policy name is required")
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: opts.PodSelector},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{},
+			Egress:      []networkingv1.NetworkPolicyEgressRule{},
+		},
+	}
+
+	// ingress, the protocol of the bound sockets isn't recorded
+	boundPorts := make(map[uint16]bool)
+	walkProcessNodes(tree, func(node *activity_tree.ProcessNode) {
+		for _, socket := range node.Sockets {
+			if socket.Family != "AF_INET" && socket.Family != "AF_INET6" {
+				continue
+			}
+			for _, bind := range socket.Bind {
+				if bind.Port == 0 {
+					continue
+				}
+				if ip := net.ParseIP(bind.IP); ip != nil && ip.IsLoopback() {
+					continue
+				}
+				boundPorts[bind.Port] = true
+			}
+		}
+	})
+	if len(boundPorts) > 0 {
+		ports := make([]int, 0, len(boundPorts))
+		for port := range boundPorts {
+			ports = append(ports, int(port))
+		}
+		sort.Ints(ports)
+
+		var rule networkingv1.NetworkPolicyIngressRule
+		for _, port := range ports {
+			rule.Ports = append(rule.Ports, networkPolicyPorts(port)...)
+		}
+		policy.Spec.Ingress = append(policy.Spec.Ingress, rule)
+	}
+
+	// egress
+	names := dnsNames(tree)
+	if len(names) == 0 {
+		return policy, nil
+	}
+
+	policy.Annotations = map[string]string{
+		ObservedDNSNamesAnnotation: strings.Join(names, ","),
+	}
+	policy.Spec.Egress = append(policy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+		To:    []networkingv1.NetworkPolicyPeer{kubeDNSPeer},
+		Ports: networkPolicyPorts(dnsPort),
+	})
+
+	if opts.Resolver == nil {
+		return policy, nil
+	}
+
+	cidrs := make(map[string]bool)
+	for _, name := range names {
+		ips, err := opts.Resolver(name)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't resolve %s: %w", name, err)
+		}
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				cidrs[ip.String()+"/32"] = true
+			} else {
+				cidrs[ip.String()+"/128"] = true
+			}
+		}
+	}
+	if len(cidrs) > 0 {
+		var rule networkingv1.NetworkPolicyEgressRule
+		for _, cidr := range sortedKeys(cidrs) {
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+			})
+		}
+		policy.Spec.Egress = append(policy.Spec.Egress, rule)
+	}
+
+	return policy, nil
+}
+
+// networkPolicyPorts returns the TCP and UDP ports of a port number
+func networkPolicyPorts(port int) []networkingv1.NetworkPolicyPort {
+	var ports []networkingv1.NetworkPolicyPort
+	for _, protocol := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+		protocol := protocol
+		value := intstr.FromInt(port)
+		ports = append(ports, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &value,
+		})
+	}
+	return ports
+}
+
+// EncodeNetworkPolicy returns the YAML representation of a network policy
+func EncodeNetworkPolicy(policy *networkingv1.NetworkPolicy) ([]byte, error) {
+	return yaml.Marshal(policy)
+}
+
+// DiffNetworkPolicy compares a generated network policy with a prior YAML or JSON network policy
+func DiffNetworkPolicy(prior []byte, current *networkingv1.NetworkPolicy) (*Diff, error) {
+	var priorPolicy networkingv1.NetworkPolicy
+	if err := yaml.UnmarshalStrict(prior, &priorPolicy); err != nil {
+		return nil, fmt.Errorf("couldn't parse the prior network policy: %w", err)
+	}
+	return diffEntries(networkPolicyEntries(&priorPolicy), networkPolicyEntries(current)), nil
+}
+
+// networkPolicyEntries returns the allowed flows of a policy, one per peer and port
+func networkPolicyEntries(policy *networkingv1.NetworkPolicy) []string {
+	var entries []string
+	for _, policyType := range policy.Spec.PolicyTypes {
+		entries = append(entries, fmt.Sprintf("policy type %s", policyType))
+	}
+	for _, rule := range policy.Spec.Ingress {
+		entries = append(entries, flowEntries("ingress from", rule.From, rule.Ports)...)
+	}
+	for _, rule := range policy.Spec.Egress {
+		entries = append(entries, flowEntries("egress to", rule.To, rule.Ports)...)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func flowEntries(direction string, peers []networkingv1.NetworkPolicyPeer, ports []networkingv1.NetworkPolicyPort) []string {
+	peerNames := []string{"any"}
+	if len(peers) > 0 {
+		peerNames = peerNames[:0]
+		for _, peer := range peers {
+			peerNames = append(peerNames, peerString(peer))
+		}
+	}
+
+	portNames := []string{"any port"}
+	if len(ports) > 0 {
+		portNames = portNames[:0]
+		for _, port := range ports {
+			portNames = append(portNames, portString(port))
+		}
+	}
+
+	var entries []string
+	for _, peer := range peerNames {
+		for _, port := range portNames {
+			entries = append(entries, fmt.Sprintf("%s %s %s", direction, peer, port))
+		}
+	}
+	return entries
+}
+
+func peerString(peer networkingv1.NetworkPolicyPeer) string {
+	if peer.IPBlock != nil {
+		s := "cidr " + peer.IPBlock.CIDR
+		if len(peer.IPBlock.Except) > 0 {
+			s += " except " + strings.Join(peer.IPBlock.Except, ",")
+		}
+		return s
+	}
+
+	var parts []string
+	if peer.NamespaceSelector != nil {
+		parts = append(parts, "namespace "+metav1.FormatLabelSelector(peer.NamespaceSelector))
+	}
+	if peer.PodSelector != nil {
+		parts = append(parts, "pod "+metav1.FormatLabelSelector(peer.PodSelector))
+	}
+	return strings.Join(parts, " ")
+}
+
+func portString(port networkingv1.NetworkPolicyPort) string {
+	protocol := corev1.ProtocolTCP
+	if port.Protocol != nil {
+		protocol = *port.Protocol
+	}
+	if port.Port == nil {
+		return fmt.Sprintf("port %s/any", protocol)
+	}
+	if port.EndPort != nil {
+		return fmt.Sprintf("port %s/%s-%d", protocol, port.Port.String(), *port.EndPort)
+	}
+	return fmt.Sprintf("port %s/%s", protocol, port.Port.String())
+}
@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package hardening holds the generators of enforceable profiles (seccomp, AppArmor, Kubernetes network policies)
+// computed from the activity recorded in an activity dump or a security profile
+package hardening
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+var (
+	// ErrNoSyscalls is returned when the activity tree doesn't contain any syscall
+	ErrNoSyscalls = errors.New("no syscall recorded in the activity tree, syscall monitoring must be enabled to generate a seccomp profile")
+
+	// seccompArchitectures maps the architectures of the activity dumps to the seccomp architectures
+	seccompArchitectures = map[string][]specs.Arch{
+		"x64":   {specs.ArchX86_64, specs.ArchX86, specs.ArchX32},
+		"arm64": {specs.ArchAARCH64, specs.ArchARM},
+	}
+
+	// errnoEPERM is the errno returned by the denied syscalls
+	errnoEPERM uint = 1
+)
+
+// SeccompOptions defines the options of the seccomp profile generation
+type SeccompOptions struct {
+	// Arch is the architecture of the host on which the activity was recorded, as reported in the metadata of the
+	// activity dumps. The syscall numbers are resolved with the table of the current architecture.
+	Arch string
+	// ExtraSyscalls is a list of syscalls allowed in addition to the recorded ones
+	ExtraSyscalls []string
+}
+
+// GenerateSeccompProfile returns an OCI seccomp profile allowing the syscalls recorded in the activity tree and
+// denying all the others with EPERM
+func GenerateSeccompProfile(tree *activity_tree.ActivityTree, opts SeccompOptions) (*specs.LinuxSeccomp, error) {
+	arch := opts.Arch
+	if arch == "" {
+		arch = utils.RuntimeArch()
+	}
+	if arch != utils.RuntimeArch() {
+		return nil, fmt.Errorf("the activity was recorded on %s, a seccomp profile can only be generated on the same architecture (%s)", arch, utils.RuntimeArch())
+	}
+	architectures, ok := seccompArchitectures[arch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported architecture %s", arch)
+	}
+
+	syscalls := make(map[string]bool)
+	walkProcessNodes(tree, func(node *activity_tree.ProcessNode) {
+		for _, syscall := range node.Syscalls {
+			if name, ok := syscallName(model.Syscall(syscall)); ok {
+				syscalls[name] = true
+			}
+		}
+	})
+	if len(syscalls) == 0 {
+		return nil, ErrNoSyscalls
+	}
+	for _, syscall := range opts.ExtraSyscalls {
+		syscalls[syscall] = true
+	}
+
+	errnoRet := errnoEPERM
+	return &specs.LinuxSeccomp{
+		DefaultAction:   specs.ActErrno,
+		DefaultErrnoRet: &errnoRet,
+		Architectures:   architectures,
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:  sortedKeys(syscalls),
+				Action: specs.ActAllow,
+			},
+		},
+	}, nil
+}
+
+// syscallName returns the name of a syscall as used in the seccomp profiles, e.g. SysRtSigaction becomes rt_sigaction
+func syscallName(syscall model.Syscall) (string, bool) {
+	camel, found := strings.CutPrefix(syscall.String(), "Sys")
+	if !found || strings.HasSuffix(camel, ")") {
+		// unknown syscall number, formatted as Syscall(n)
+		return "", false
+	}
+
+	var b strings.Builder
+	for i, r := range camel {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), true
+}
+
+// EncodeSeccompProfile returns the JSON representation of a seccomp profile
+func EncodeSeccompProfile(profile *specs.LinuxSeccomp) ([]byte, error) {
+	return json.MarshalIndent(profile, "", "  ")
+}
+
+// DiffSeccompProfile compares a generated seccomp profile with a prior JSON profile
+func DiffSeccompProfile(prior []byte, current *specs.LinuxSeccomp) (*Diff, error) {
+	var priorProfile specs.LinuxSeccomp
+	if err := json.Unmarshal(prior, &priorProfile); err != nil {
+		return nil, fmt.Errorf("couldn't parse the prior seccomp profile: %w", err)
+	}
+	return diffEntries(seccompEntries(&priorProfile), seccompEntries(current)), nil
+}
+
+// seccompEntries returns the actions of a profile, one per syscall
+func seccompEntries(profile *specs.LinuxSeccomp) []string {
+	entries := []string{fmt.Sprintf("default %s", profile.DefaultAction)}
+	for _, arch := range profile.Architectures {
+		entries = append(entries, fmt.Sprintf("architecture %s", arch))
+	}
+	for _, syscall := range profile.Syscalls {
+		action := string(syscall.Action)
+		if len(syscall.Args) > 0 {
+			action += " (with argument filters)"
+		}
+		for _, name := range syscall.Names {
+			entries = append(entries, fmt.Sprintf("%s %s", name, action))
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
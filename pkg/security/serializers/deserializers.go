@@ -9,9 +9,11 @@ package serializers
 
 import (
 	json "encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 )
 
@@ -35,8 +37,6 @@ func newFileEvent(fs *FileSerializer) model.FileEvent {
 			Group:        fs.Group,
 			InUpperLayer: getPointerValue(fs.InUpperLayer),
 			Mode:         uint16(getPointerValue(fs.Mode)),
-			MTime:        uint64(fs.Mtime.GetInnerTime().UnixMicro()),
-			CTime:        uint64(fs.Ctime.GetInnerTime().UnixMicro()),
 			PathKey: model.PathKey{
 				Inode:   getPointerValue(fs.Inode),
 				MountID: getPointerValue(fs.MountID),
@@ -48,6 +48,12 @@ func newFileEvent(fs *FileSerializer) model.FileEvent {
 		IsBasenameStrResolved: true,
 		HashState:             model.NoHash,
 	}
+	if fs.Mtime != nil {
+		file.MTime = uint64(fs.Mtime.GetInnerTime().UnixMicro())
+	}
+	if fs.Ctime != nil {
+		file.CTime = uint64(fs.Ctime.GetInnerTime().UnixMicro())
+	}
 	return file
 }
 
@@ -56,7 +62,6 @@ func newProcess(ps *ProcessSerializer) model.Process {
 		PPid:          getPointerValue(ps.PPid),
 		Comm:          ps.Comm,
 		TTYName:       ps.TTY,
-		FileEvent:     newFileEvent(ps.Executable),
 		Argv0:         ps.Argv0,
 		Argv:          ps.Args,
 		ArgsTruncated: ps.ArgsTruncated,
@@ -70,6 +75,9 @@ func newProcess(ps *ProcessSerializer) model.Process {
 			IsKworker: ps.IsKworker,
 		},
 	}
+	if ps.Executable != nil {
+		p.FileEvent = newFileEvent(ps.Executable)
+	}
 	if ps.ForkTime != nil {
 		p.ForkTime = ps.ForkTime.GetInnerTime()
 	}
@@ -87,39 +95,40 @@ func newProcess(ps *ProcessSerializer) model.Process {
 	return p
 }
 
-// UnmarshalEvent unmarshal an model.Event (only exec one for now)
-func UnmarshalEvent(raw []byte) (*model.Event, error) {
-	rawEvent := EventSerializer{}
-	err := json.Unmarshal(raw, &rawEvent)
-	if err != nil {
-		return nil, err
+// constantValue returns the value of a SECL constant, such as an open flag or a signal name
+func constantValue(name string) (int, bool) {
+	if evaluator, ok := model.SECLConstants()[name].(*eval.IntEvaluator); ok {
+		return evaluator.Value, true
 	}
-	if rawEvent.EventContextSerializer.Name != "exec" {
-		return nil, fmt.Errorf("Unmarshalling of event %v is not yet supported", rawEvent.EventContextSerializer.Name)
+	return 0, false
+}
+
+func parseEventType(name string) model.EventType {
+	for eventType := model.FirstEventType; eventType < model.MaxKernelEventType; eventType++ {
+		if eventType.String() == name {
+			return eventType
+		}
 	}
+	return model.UnknownEventType
+}
 
-	parent := newProcess(rawEvent.ProcessContextSerializer.Parent)
-	process := newProcess(rawEvent.ProcessContextSerializer.ProcessSerializer)
-	event := model.Event{
-		BaseEvent: model.BaseEvent{
-			Type:             uint32(model.ExecEventType),
-			FieldHandlers:    &model.FakeFieldHandlers{},
-			ContainerContext: &model.ContainerContext{},
-			ProcessContext: &model.ProcessContext{
-				Process:  process,
-				Parent:   &parent,
-				Ancestor: nil,
-			},
-		},
+func newProcessContext(pcs *ProcessContextSerializer) *model.ProcessContext {
+	pc := &model.ProcessContext{}
+	if pcs == nil {
+		return pc
 	}
-	event.BaseEvent.ProcessCacheEntry = &model.ProcessCacheEntry{
-		ProcessContext: *event.BaseEvent.ProcessContext,
+	if pcs.ProcessSerializer != nil {
+		pc.Process = newProcess(pcs.ProcessSerializer)
+	}
+	if pcs.Parent != nil {
+		parent := newProcess(pcs.Parent)
+		pc.Parent = &parent
 	}
 
 	// Fill ancestors
-	prevProcessContext := event.BaseEvent.ProcessContext
-	prevProcess := &process
-	for _, ancestor := range rawEvent.ProcessContextSerializer.Ancestors {
+	prevProcessContext := pc
+	prevProcess := &pc.Process
+	for _, ancestor := range pcs.Ancestors {
 		currentPocess := newProcess(ancestor)
 		prevProcessContext.Ancestor = &model.ProcessCacheEntry{
 			ProcessContext: model.ProcessContext{
@@ -131,9 +140,132 @@ func UnmarshalEvent(raw []byte) (*model.Event, error) {
 		prevProcess = &currentPocess
 	}
 
+	return pc
+}
+
+// newFileEvents returns the file and the destination file of a file event
+func newFileEvents(fes *FileEventSerializer) (model.FileEvent, model.FileEvent) {
+	var file, destination model.FileEvent
+	if fes == nil {
+		return file, destination
+	}
+	file = newFileEvent(&fes.FileSerializer)
+	if fes.Destination != nil {
+		destination = newFileEvent(fes.Destination)
+	}
+	return file, destination
+}
+
+func destinationMode(fes *FileEventSerializer) uint32 {
+	if fes == nil || fes.Destination == nil {
+		return 0
+	}
+	return getPointerValue(fes.Destination.Mode)
+}
+
+// UnmarshalEvent unmarshals a serialized event to a model.Event. The process and container contexts are
+// restored, along with the fields of the process, file, signal and dns events.
+func UnmarshalEvent(raw []byte) (*model.Event, error) {
+	rawEvent := EventSerializer{}
+	err := json.Unmarshal(raw, &rawEvent)
+	if err != nil {
+		return nil, err
+	}
+	if rawEvent.BaseEventSerializer == nil {
+		return nil, errors.New("missing event context")
+	}
+
+	eventType := parseEventType(rawEvent.EventContextSerializer.Name)
+	if eventType == model.UnknownEventType {
+		return nil, fmt.Errorf("unknown event type `%s`", rawEvent.EventContextSerializer.Name)
+	}
+
+	event := model.Event{
+		BaseEvent: model.BaseEvent{
+			Type:             uint32(eventType),
+			FieldHandlers:    &model.FakeFieldHandlers{},
+			ContainerContext: &model.ContainerContext{},
+			ProcessContext:   newProcessContext(rawEvent.ProcessContextSerializer),
+		},
+	}
 	event.BaseEvent.ProcessCacheEntry = &model.ProcessCacheEntry{
 		ProcessContext: *event.BaseEvent.ProcessContext,
 	}
+	if rawEvent.ContainerContextSerializer != nil {
+		event.ContainerContext.ID = rawEvent.ContainerContextSerializer.ID
+	}
+
+	fes := rawEvent.FileEventSerializer
+	switch eventType {
+	case model.ExecEventType:
+		event.Exec.Process = &event.ProcessContext.Process
+	case model.ExitEventType:
+		event.Exit.Process = &event.ProcessContext.Process
+		if rawEvent.ExitEventSerializer != nil {
+			cause, _ := constantValue(rawEvent.ExitEventSerializer.Cause)
+			event.Exit.Cause = uint32(cause)
+			event.Exit.Code = rawEvent.ExitEventSerializer.Code
+		}
+	case model.FileOpenEventType:
+		event.Open.File, _ = newFileEvents(fes)
+		event.Open.Mode = destinationMode(fes)
+		if fes != nil {
+			for _, flag := range fes.Flags {
+				value, ok := constantValue(flag)
+				if !ok {
+					return nil, fmt.Errorf("unknown open flag `%s`", flag)
+				}
+				event.Open.Flags |= uint32(value)
+			}
+		}
+	case model.FileChmodEventType:
+		event.Chmod.File, _ = newFileEvents(fes)
+		event.Chmod.Mode = destinationMode(fes)
+	case model.FileChownEventType:
+		event.Chown.File, _ = newFileEvents(fes)
+	case model.FileMkdirEventType:
+		event.Mkdir.File, _ = newFileEvents(fes)
+		event.Mkdir.Mode = destinationMode(fes)
+	case model.FileRmdirEventType:
+		event.Rmdir.File, _ = newFileEvents(fes)
+	case model.FileUnlinkEventType:
+		event.Unlink.File, _ = newFileEvents(fes)
+	case model.FileUtimesEventType:
+		event.Utimes.File, _ = newFileEvents(fes)
+	case model.FileChdirEventType:
+		event.Chdir.File, _ = newFileEvents(fes)
+	case model.FileRenameEventType:
+		event.Rename.Old, event.Rename.New = newFileEvents(fes)
+	case model.FileLinkEventType:
+		event.Link.Source, event.Link.Target = newFileEvents(fes)
+	case model.SignalEventType:
+		event.Signal.Target = &model.ProcessContext{}
+		if rawEvent.SignalEventSerializer != nil {
+			signal, ok := constantValue(rawEvent.SignalEventSerializer.Type)
+			if !ok {
+				return nil, fmt.Errorf("unknown signal `%s`", rawEvent.SignalEventSerializer.Type)
+			}
+			event.Signal.Type = uint32(signal)
+			event.Signal.PID = rawEvent.SignalEventSerializer.PID
+			event.Signal.Target = newProcessContext(rawEvent.SignalEventSerializer.Target)
+		}
+	case model.DNSEventType:
+		if rawEvent.DNSEventSerializer != nil {
+			question := rawEvent.DNSEventSerializer.Question
+			qtype, _ := constantValue(question.Type)
+			qclass, _ := constantValue(question.Class)
+			event.DNS = model.DNSEvent{
+				ID:    rawEvent.DNSEventSerializer.ID,
+				Name:  question.Name,
+				Type:  uint16(qtype),
+				Class: uint16(qclass),
+				Size:  question.Size,
+				Count: question.Count,
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unmarshalling of event %v is not yet supported", rawEvent.EventContextSerializer.Name)
+	}
 
 	return &event, nil
 }
@@ -214,6 +214,42 @@ func newIPPortFamilySerializer(c *model.IPPortContext, family string) IPPortFami
 	}
 }
 
+// SequenceStepSerializer serializes the event of a previous step of a matched sequence rule to JSON
+// easyjson:json
+type SequenceStepSerializer struct {
+	// Event name
+	Name string `json:"name"`
+	// Event date
+	Date utils.EasyjsonTime `json:"date"`
+	// Process ID
+	PID uint32 `json:"pid"`
+	// Path of the process executable
+	Executable string `json:"executable,omitempty"`
+	// Path of the file of the event, if any
+	File string `json:"file,omitempty"`
+	// Container ID
+	ContainerID string `json:"container_id,omitempty"`
+}
+
+// NewSequenceStepSerializer returns a snapshot of the fields of the event of a sequence step, reported
+// with the event completing the sequence. The fields are resolved while the event is still valid.
+func NewSequenceStepSerializer(event *model.Event) *SequenceStepSerializer {
+	eventType := model.EventType(event.Type)
+	s := &SequenceStepSerializer{
+		Name:       eventType.String(),
+		Date:       utils.NewEasyjsonTime(event.ResolveEventTime()),
+		PID:        event.ProcessContext.Pid,
+		Executable: event.FieldHandlers.ResolveFilePath(event, &event.ProcessContext.Process.FileEvent),
+	}
+	if file, err := event.GetFieldValue(eventType.String() + ".file.path"); err == nil {
+		s.File, _ = file.(string)
+	}
+	if event.ContainerContext != nil {
+		s.ContainerID = event.FieldHandlers.ResolveContainerID(event, event.ContainerContext)
+	}
+	return s
+}
+
 func newExitEventSerializer(e *model.Event) *ExitEventSerializer {
 	return &ExitEventSerializer{
 		Cause: model.ExitCause(e.Exit.Cause).String(),
@@ -483,6 +483,29 @@ type EventSerializer struct {
 	*MountEventSerializer                   `json:"mount,omitempty"`
 	*AnomalyDetectionSyscallEventSerializer `json:"anomaly_detection_syscall,omitempty"`
 	*UserContextSerializer                  `json:"usr,omitempty"`
+	*SequenceSerializer                     `json:"sequence,omitempty"`
+}
+
+// SequenceSerializer serializes the events of the previous steps of a matched sequence rule to JSON
+// easyjson:json
+type SequenceSerializer struct {
+	// Events of the previous steps of the sequence, in order
+	Events []*SequenceStepSerializer `json:"events"`
+}
+
+func newSequenceSerializer(e *model.Event) *SequenceSerializer {
+	s := &SequenceSerializer{
+		Events: make([]*SequenceStepSerializer, 0, len(e.SequenceEvents)),
+	}
+	for _, event := range e.SequenceEvents {
+		switch event := event.(type) {
+		case *SequenceStepSerializer:
+			s.Events = append(s.Events, event)
+		case *model.Event:
+			s.Events = append(s.Events, NewSequenceStepSerializer(event))
+		}
+	}
+	return s
 }
 
 func newAnomalyDetectionSyscallEventSerializer(e *model.AnomalyDetectionSyscallEvent) *AnomalyDetectionSyscallEventSerializer {
@@ -973,6 +996,10 @@ func NewEventSerializer(event *model.Event, opts *eval.Opts) *EventSerializer {
 		}
 	}
 
+	if len(event.SequenceEvents) > 0 {
+		s.SequenceSerializer = newSequenceSerializer(event)
+	}
+
 	eventType := model.EventType(event.Type)
 
 	switch eventType {
@@ -92,6 +92,26 @@ func (h *HashingTagsAccumulator) Truncate(len int) {
 	h.hash = h.hash[0:len]
 }
 
+// RetainFunc keeps the tags for which keep returns true and removes the
+// others, without discarding the internal buffer. The order of the retained
+// tags is preserved. It returns the number of removed tags.
+func (h *HashingTagsAccumulator) RetainFunc(keep func(tag string) bool) int {
+	j := 0
+	for i := range h.data {
+		if !keep(h.data[i]) {
+			continue
+		}
+		h.data[j] = h.data[i]
+		h.hash[j] = h.hash[i]
+		j++
+	}
+
+	removed := len(h.data) - j
+	h.Truncate(j)
+
+	return removed
+}
+
 // Less implements sort.Interface.Less
 func (h *HashingTagsAccumulator) Less(i, j int) bool {
 	if h.hash[i] == h.hash[j] {
@@ -57,6 +57,19 @@ func TestHashingTagsAccumulatorGet(t *testing.T) {
 	assert.Equal(t, []string{"test", "b", "c"}, tb.data)
 }
 
+func TestHashingTagsAccumulatorRetainFunc(t *testing.T) {
+	tb := NewHashingTagsAccumulatorWithTags([]string{"a:1", "b:2", "c:3", "a:4"})
+
+	removed := tb.RetainFunc(func(tag string) bool { return tag[0] != 'a' })
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, []string{"b:2", "c:3"}, tb.data)
+	assert.Equal(t, NewHashingTagsAccumulatorWithTags([]string{"b:2", "c:3"}).hash, tb.hash)
+
+	removed = tb.RetainFunc(func(string) bool { return true })
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, []string{"b:2", "c:3"}, tb.data)
+}
+
 func TestHashingTagsAccumulatorCopy(t *testing.T) {
 	tb := NewHashingTagsAccumulator()
 
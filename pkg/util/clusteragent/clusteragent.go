@@ -23,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/servicemap"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	pbgo "github.com/DataDog/datadog-agent/pkg/proto/pbgo/process"
@@ -41,6 +42,7 @@ const (
 	// RealIPHeader refers to the cluster level check runner ip passed in the request headers
 	RealIPHeader          = "X-Real-Ip"
 	languageDetectionPath = "api/v1/languagedetection"
+	serviceMapPath        = "api/v1/servicemap/connections"
 )
 
 var globalClusterAgentClient *DCAClient
@@ -66,6 +68,7 @@ type DCAClientInterface interface {
 	GetKubernetesClusterID() (string, error)
 
 	PostLanguageMetadata(ctx context.Context, data *pbgo.ParentLanguageAnnotationRequest) error
+	PostConnectionSummaries(ctx context.Context, data *servicemap.ConnectionSummaries) error
 }
 
 // DCAClient is required to query the API of Datadog cluster agent
@@ -477,3 +480,16 @@ func (c *DCAClient) PostLanguageMetadata(ctx context.Context, data *pbgo.ParentL
 	_, err = c.doQuery(ctx, languageDetectionPath, "POST", bytes.NewBuffer(queryBody), false, false)
 	return err
 }
+
+// PostConnectionSummaries is called by the process-agent's connections check
+// to report the connections of the node for the service map
+func (c *DCAClient) PostConnectionSummaries(ctx context.Context, data *servicemap.ConnectionSummaries) error {
+	queryBody, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// query https://host:port/api/v1/servicemap/connections without expecting a response
+	_, err = c.doQuery(ctx, serviceMapPath, "POST", bytes.NewBuffer(queryBody), false, false)
+	return err
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package systemd provides helpers to identify the systemd units that
+// processes belong to.
+package systemd
+
+import (
+	"strings"
+)
+
+// UnitEntityPrefix is the prefix of the tagger entities of systemd units
+const UnitEntityPrefix = "systemd_unit://"
+
+// unitSuffixes are the suffixes of the units that processes can be attached
+// to. Slices only group other units and are never returned.
+var unitSuffixes = []string{".service", ".scope"}
+
+// BuildTaggerEntityName builds a valid tagger entity name for a given unit.
+func BuildTaggerEntityName(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return UnitEntityPrefix + unit
+}
+
+// UnitFromCgroupPath returns the name of the innermost unit found in a cgroup
+// path, e.g. nginx.service for /system.slice/nginx.service. It returns an
+// empty string if the path doesn't belong to a unit.
+func UnitFromCgroupPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		for _, suffix := range unitSuffixes {
+			if len(parts[i]) > len(suffix) && strings.HasSuffix(parts[i], suffix) {
+				return parts[i]
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package systemd
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+// UnitForPID returns the name of the systemd unit a process belongs to, based
+// on its entry in /proc/<pid>/cgroup. It returns an empty string if the
+// process doesn't belong to a unit.
+func UnitForPID(pid int) (string, error) {
+	f, err := os.Open(kernel.HostProc(strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return unitFromProcCgroup(bufio.NewScanner(f))
+}
+
+// unitFromProcCgroup parses the content of a /proc/<pid>/cgroup file. The
+// unified hierarchy (cgroup v2) and the `name=systemd` hierarchy (cgroup v1)
+// are both maintained by systemd and hold the unit of the process.
+func unitFromProcCgroup(scanner *bufio.Scanner) (string, error) {
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if (parts[0] == "0" && parts[1] == "") || parts[1] == "name=systemd" {
+			if unit := UnitFromCgroupPath(parts[2]); unit != "" {
+				return unit, nil
+			}
+		}
+	}
+
+	return "", scanner.Err()
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package systemd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitFromProcCgroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "cgroup v2",
+			content:  "0::/system.slice/nginx.service\n",
+			expected: "nginx.service",
+		},
+		{
+			name: "cgroup v1",
+			content: `12:memory:/system.slice/redis-server.service
+11:cpu,cpuacct:/system.slice/redis-server.service
+1:name=systemd:/system.slice/redis-server.service
+`,
+			expected: "redis-server.service",
+		},
+		{
+			name:     "not a unit",
+			content:  "0::/\n",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unit, err := unitFromProcCgroup(bufio.NewScanner(strings.NewReader(test.content)))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, unit)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package systemd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitFromCgroupPath(t *testing.T) {
+	for path, unit := range map[string]string{
+		"/system.slice/nginx.service":                                         "nginx.service",
+		"/system.slice/postgresql@15-main.service":                            "postgresql@15-main.service",
+		"/user.slice/user-1000.slice/user@1000.service/app.slice/foo.service": "foo.service",
+		"/user.slice/user-1000.slice/session-2.scope":                         "session-2.scope",
+		"/system.slice": "",
+		"/":             "",
+		"":              "",
+		"/kubepods/besteffort/pod1234/0123456789abcdef": "",
+	} {
+		assert.Equal(t, unit, UnitFromCgroupPath(path), path)
+	}
+}
+
+func TestBuildTaggerEntityName(t *testing.T) {
+	assert.Equal(t, "systemd_unit://nginx.service", BuildTaggerEntityName("nginx.service"))
+	assert.Equal(t, "", BuildTaggerEntityName(""))
+}
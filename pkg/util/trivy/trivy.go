@@ -65,6 +65,7 @@ type ContainerdAccessor func() (cutil.ContainerdItf, error)
 // CollectorConfig allows to pass configuration
 type CollectorConfig struct {
 	ClearCacheOnClose bool
+	CacheDir          string // Directory of the cache, the default cache directory is used when empty
 }
 
 // Collector uses trivy to generate a SBOM
@@ -115,6 +116,10 @@ func getDefaultArtifactOption(root string, opts sbom.ScanOptions) artifact.Optio
 		}
 	}
 
+	for _, dir := range opts.SkipDirs {
+		option.SkipDirs = append(option.SkipDirs, filepath.Join(root, dir))
+	}
+
 	return option
 }
 
@@ -183,6 +188,18 @@ func NewCollector(cfg config.Config, wmeta optional.Option[workloadmeta.Componen
 	}, nil
 }
 
+// NewCollectorWithCacheDir returns a new collector using its own cache
+// directory. It allows one-shot scans to not contend with the cache of a
+// running agent.
+func NewCollectorWithCacheDir(cfg config.Config, cacheDir string) (*Collector, error) {
+	collector, err := NewCollector(cfg, optional.NewNoneOption[workloadmeta.Component]())
+	if err != nil {
+		return nil, err
+	}
+	collector.config.CacheDir = cacheDir
+	return collector, nil
+}
+
 // GetGlobalCollector gets the global collector
 func GetGlobalCollector(cfg config.Config, wmeta optional.Option[workloadmeta.Component]) (*Collector, error) {
 	if globalCollector != nil {
@@ -228,7 +245,7 @@ func (c *Collector) getCache() (CacheWithCleaner, error) {
 	c.cacheInitialized.Do(func() {
 		c.cache, err = NewCustomBoltCache(
 			c.wmeta,
-			defaultCacheDir(),
+			c.config.CacheDir,
 			config.Datadog.GetInt("sbom.cache.max_disk_size"),
 		)
 	})
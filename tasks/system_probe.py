@@ -408,6 +408,10 @@ def ninja_cgo_type_files(nw):
                 "pkg/network/ebpf/c/tracer/tracer.h",
                 "pkg/network/ebpf/c/protocols/kafka/types.h",
             ],
+            "pkg/network/protocols/segments/types.go": [
+                "pkg/network/ebpf/c/tracer/tracer.h",
+                "pkg/network/ebpf/c/protocols/segments/types.h",
+            ],
             "pkg/ebpf/telemetry/types.go": [
                 "pkg/ebpf/c/telemetry_types.h",
             ],